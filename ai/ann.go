@@ -0,0 +1,221 @@
+package ai
+
+import (
+	"math"
+	"sort"
+)
+
+// VectorMatch is a single nearest-neighbor search result
+type VectorMatch struct {
+	ID    string
+	Score float32
+}
+
+// VectorIndex is an inverted-file (IVF) approximate nearest-neighbor index.
+// Vectors are partitioned into lists around k-means centroids; a query only
+// scans the lists whose centroids are closest to it.
+type VectorIndex struct {
+	Centroids [][]float32
+	lists     [][]int
+	ids       []string
+	vectors   [][]float32
+	norms     []float32
+}
+
+const (
+	// kmeansIterations bounds centroid training time
+	kmeansIterations = 8
+	// trainingSamplesPerList caps how many vectors are used to train each centroid
+	trainingSamplesPerList = 32
+	// defaultProbes is the number of lists scanned per query
+	defaultProbes = 8
+)
+
+// BuildVectorIndex trains centroids over the vectors and assigns each vector to a list
+func BuildVectorIndex(ids []string, vectors [][]float32) *VectorIndex {
+	numLists := int(math.Sqrt(float64(len(vectors))))
+	if numLists < 1 {
+		numLists = 1
+	}
+	centroids := trainCentroids(vectors, numLists)
+	return NewVectorIndex(centroids, ids, vectors, nil)
+}
+
+// NewVectorIndex creates an index from previously trained centroids.
+// Vectors with a known list in assignments skip centroid assignment.
+func NewVectorIndex(centroids [][]float32, ids []string, vectors [][]float32, assignments map[string]int) *VectorIndex {
+	idx := &VectorIndex{
+		Centroids: centroids,
+		lists:     make([][]int, len(centroids)),
+		ids:       ids,
+		vectors:   vectors,
+		norms:     make([]float32, len(vectors)),
+	}
+
+	for i, v := range vectors {
+		idx.norms[i] = norm(v)
+		list, ok := assignments[ids[i]]
+		if !ok || list < 0 || list >= len(centroids) {
+			list = nearestCentroid(centroids, v)
+		}
+		idx.lists[list] = append(idx.lists[list], i)
+	}
+
+	return idx
+}
+
+// Len returns the number of indexed vectors
+func (idx *VectorIndex) Len() int {
+	return len(idx.vectors)
+}
+
+// Assignments returns the list each vector belongs to, keyed by ID
+func (idx *VectorIndex) Assignments() map[string]int {
+	assignments := make(map[string]int, len(idx.ids))
+	for list, members := range idx.lists {
+		for _, i := range members {
+			assignments[idx.ids[i]] = list
+		}
+	}
+	return assignments
+}
+
+// Search returns up to k vectors most similar to the query by cosine similarity
+func (idx *VectorIndex) Search(query []float32, k int) []VectorMatch {
+	if k <= 0 || len(idx.Centroids) == 0 {
+		return nil
+	}
+	queryNorm := norm(query)
+	if queryNorm == 0 {
+		return nil
+	}
+
+	// Rank lists by centroid similarity
+	order := make([]int, len(idx.Centroids))
+	centroidScores := make([]float32, len(idx.Centroids))
+	for i, c := range idx.Centroids {
+		order[i] = i
+		centroidScores[i] = dot(query, c)
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return centroidScores[order[a]] > centroidScores[order[b]]
+	})
+
+	probes := defaultProbes
+	if probes > len(order) {
+		probes = len(order)
+	}
+
+	var matches []VectorMatch
+	for _, list := range order[:probes] {
+		for _, i := range idx.lists[list] {
+			v := idx.vectors[i]
+			if len(v) != len(query) || idx.norms[i] == 0 {
+				continue
+			}
+			matches = append(matches, VectorMatch{
+				ID:    idx.ids[i],
+				Score: dot(query, v) / (queryNorm * idx.norms[i]),
+			})
+		}
+	}
+
+	sort.Slice(matches, func(a, b int) bool {
+		return matches[a].Score > matches[b].Score
+	})
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches
+}
+
+// trainCentroids runs k-means over a sample of the vectors
+func trainCentroids(vectors [][]float32, numLists int) [][]float32 {
+	if len(vectors) == 0 {
+		return nil
+	}
+
+	// Take an evenly spaced sample so training is deterministic and bounded
+	sample := vectors
+	if maxSamples := numLists * trainingSamplesPerList; len(vectors) > maxSamples {
+		sample = make([][]float32, maxSamples)
+		for i := range sample {
+			sample[i] = vectors[i*len(vectors)/maxSamples]
+		}
+	}
+	if numLists > len(sample) {
+		numLists = len(sample)
+	}
+
+	dim := len(sample[0])
+	centroids := make([][]float32, numLists)
+	for i := range centroids {
+		centroids[i] = normalized(sample[i*len(sample)/numLists])
+	}
+
+	for iter := 0; iter < kmeansIterations; iter++ {
+		sums := make([][]float32, numLists)
+		counts := make([]int, numLists)
+		for i := range sums {
+			sums[i] = make([]float32, dim)
+		}
+
+		for _, v := range sample {
+			if len(v) != dim {
+				continue
+			}
+			c := nearestCentroid(centroids, v)
+			counts[c]++
+			for d, x := range v {
+				sums[c][d] += x
+			}
+		}
+
+		for i := range centroids {
+			// Keep the previous centroid for empty clusters
+			if counts[i] > 0 {
+				centroids[i] = normalized(sums[i])
+			}
+		}
+	}
+
+	return centroids
+}
+
+// nearestCentroid returns the index of the centroid most similar to v
+func nearestCentroid(centroids [][]float32, v []float32) int {
+	best, bestScore := 0, float32(math.Inf(-1))
+	for i, c := range centroids {
+		if score := dot(v, c); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+func dot(a, b []float32) float32 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func norm(v []float32) float32 {
+	return float32(math.Sqrt(float64(dot(v, v))))
+}
+
+func normalized(v []float32) []float32 {
+	out := make([]float32, len(v))
+	n := norm(v)
+	if n == 0 {
+		return out
+	}
+	for i, x := range v {
+		out[i] = x / n
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package ai
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func randomVectors(n, dim int, seed int64) ([]string, [][]float32) {
+	rng := rand.New(rand.NewSource(seed))
+	ids := make([]string, n)
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		ids[i] = fmt.Sprintf("pkg%d", i)
+		vectors[i] = make([]float32, dim)
+		for d := range vectors[i] {
+			vectors[i][d] = rng.Float32()*2 - 1
+		}
+	}
+	return ids, vectors
+}
+
+func TestVectorIndex_FindsExactMatch(t *testing.T) {
+	ids, vectors := randomVectors(2000, 32, 1)
+	idx := BuildVectorIndex(ids, vectors)
+
+	if idx.Len() != 2000 {
+		t.Fatalf("Len() = %d, want 2000", idx.Len())
+	}
+
+	for _, i := range []int{0, 17, 999, 1999} {
+		matches := idx.Search(vectors[i], 1)
+		if len(matches) != 1 {
+			t.Fatalf("Search() returned %d matches, want 1", len(matches))
+		}
+		if matches[0].ID != ids[i] {
+			t.Errorf("Search(%s) top match = %s", ids[i], matches[0].ID)
+		}
+		if matches[0].Score < 0.999 {
+			t.Errorf("Search(%s) score = %f, want ~1", ids[i], matches[0].Score)
+		}
+	}
+}
+
+func TestVectorIndex_ReuseAssignments(t *testing.T) {
+	ids, vectors := randomVectors(500, 16, 2)
+	built := BuildVectorIndex(ids, vectors)
+
+	restored := NewVectorIndex(built.Centroids, ids, vectors, built.Assignments())
+
+	for _, i := range []int{3, 250, 499} {
+		a := built.Search(vectors[i], 5)
+		b := restored.Search(vectors[i], 5)
+		if len(a) != len(b) {
+			t.Fatalf("restored index returned %d matches, want %d", len(b), len(a))
+		}
+		for j := range a {
+			if a[j].ID != b[j].ID {
+				t.Errorf("match %d: restored = %s, built = %s", j, b[j].ID, a[j].ID)
+			}
+		}
+	}
+}
+
+func TestVectorIndex_Empty(t *testing.T) {
+	idx := BuildVectorIndex(nil, nil)
+	if matches := idx.Search([]float32{1, 0}, 10); len(matches) != 0 {
+		t.Errorf("expected no matches from empty index, got %d", len(matches))
+	}
+}
@@ -68,9 +68,12 @@ func (c *Cache) Get(prompt string) (string, bool) {
 	// Check if expired
 	if time.Now().After(entry.ExpiresAt) {
 		c.mu.Lock()
-		delete(c.entries, key)
-		c.stats.Evictions++
-		c.stats.CurrentSize = len(c.entries)
+		// Re-check under the write lock so a concurrent Set isn't evicted
+		if current, ok := c.entries[key]; ok && time.Now().After(current.ExpiresAt) {
+			delete(c.entries, key)
+			c.stats.Evictions++
+			c.stats.CurrentSize = len(c.entries)
+		}
 		c.mu.Unlock()
 
 		c.recordMiss()
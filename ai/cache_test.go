@@ -0,0 +1,68 @@
+package ai
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache_SetGet(t *testing.T) {
+	c := NewCache(1 * time.Minute)
+
+	c.Set("prompt", "response", 0.01, 100)
+
+	content, ok := c.Get("prompt")
+	if !ok {
+		t.Fatal("expected cache hit")
+	}
+	if content != "response" {
+		t.Errorf("expected 'response', got %q", content)
+	}
+
+	stats := c.GetStats()
+	if stats.Hits != 1 {
+		t.Errorf("expected 1 hit, got %d", stats.Hits)
+	}
+}
+
+func TestCache_Expiry(t *testing.T) {
+	c := NewCache(50 * time.Millisecond)
+
+	c.Set("prompt", "response", 0.01, 100)
+
+	if _, ok := c.Get("prompt"); !ok {
+		t.Fatal("expected cache hit immediately after setting")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if _, ok := c.Get("prompt"); ok {
+		t.Error("expected cache miss after TTL expired")
+	}
+
+	stats := c.GetStats()
+	if stats.Evictions != 1 {
+		t.Errorf("expected 1 eviction, got %d", stats.Evictions)
+	}
+	if stats.CurrentSize != 0 {
+		t.Errorf("expected expired entry to be removed, size is %d", stats.CurrentSize)
+	}
+	if stats.Misses != 1 {
+		t.Errorf("expected 1 miss, got %d", stats.Misses)
+	}
+}
+
+func TestCache_ExpiryRefreshedBySet(t *testing.T) {
+	c := NewCache(50 * time.Millisecond)
+
+	c.Set("prompt", "old", 0, 0)
+	time.Sleep(100 * time.Millisecond)
+	c.Set("prompt", "new", 0, 0)
+
+	content, ok := c.Get("prompt")
+	if !ok {
+		t.Fatal("expected cache hit after re-setting expired entry")
+	}
+	if content != "new" {
+		t.Errorf("expected 'new', got %q", content)
+	}
+}
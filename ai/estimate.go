@@ -0,0 +1,37 @@
+package ai
+
+// CostEstimate adds up the tokens and cost of AI requests without making them,
+// using the same prompts and cost model as the Service
+type CostEstimate struct {
+	Requests int
+	Tokens   int
+	CostUSD  float64
+}
+
+// add counts one request
+func (e *CostEstimate) add(systemPrompt, userPrompt string, maxTokens int) {
+	tokens, cost := estimateCost(systemPrompt, userPrompt, maxTokens)
+	e.Requests++
+	e.Tokens += tokens
+	e.CostUSD += cost
+}
+
+// FunctionComment counts a Service.GenerateFunctionComment request
+func (e *CostEstimate) FunctionComment(functionSignature, functionBody string) {
+	e.add(functionCommentPrompt(functionSignature, functionBody))
+}
+
+// MethodComment counts a Service.GenerateMethodComment request
+func (e *CostEstimate) MethodComment(receiverType, methodName, signature, body string) {
+	e.add(methodCommentPrompt(receiverType, methodName, signature, body))
+}
+
+// TypeComment counts a Service.GenerateTypeComment request
+func (e *CostEstimate) TypeComment(typeName, typeDefinition string) {
+	e.add(typeCommentPrompt(typeName, typeDefinition))
+}
+
+// PackageSynopsis counts a Service.GeneratePackageSynopsis request
+func (e *CostEstimate) PackageSynopsis(packageName string, exportedSymbols []string) {
+	e.add(packageSynopsisPrompt(packageName, exportedSymbols))
+}
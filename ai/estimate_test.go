@@ -0,0 +1,37 @@
+package ai
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCostEstimate(t *testing.T) {
+	var est CostEstimate
+	est.FunctionComment("func Add(a, b int) int", "{ return a + b }")
+	est.TypeComment("Point", "struct{ X, Y int }")
+	est.MethodComment("Point", "String", "func (p Point) String() string", `{ return "" }`)
+	est.PackageSynopsis("geom", []string{"Add", "Point"})
+
+	// The estimate follows the model GenerateWithCache charges against the budget
+	var wantTokens int
+	var wantCost float64
+	add := func(systemPrompt, userPrompt string, maxTokens int) {
+		tokens, cost := estimateCost(systemPrompt, userPrompt, maxTokens)
+		wantTokens += tokens
+		wantCost += cost
+	}
+	add(functionCommentPrompt("func Add(a, b int) int", "{ return a + b }"))
+	add(typeCommentPrompt("Point", "struct{ X, Y int }"))
+	add(methodCommentPrompt("Point", "String", "func (p Point) String() string", `{ return "" }`))
+	add(packageSynopsisPrompt("geom", []string{"Add", "Point"}))
+
+	if est.Requests != 4 {
+		t.Errorf("Requests = %d, want 4", est.Requests)
+	}
+	if est.Tokens != wantTokens || math.Abs(est.CostUSD-wantCost) > 1e-12 {
+		t.Errorf("estimate = %d tokens, $%f, want %d tokens, $%f", est.Tokens, est.CostUSD, wantTokens, wantCost)
+	}
+	if est.Tokens < 150+100+150+50 {
+		t.Errorf("Tokens = %d, want at least the maximum response tokens", est.Tokens)
+	}
+}
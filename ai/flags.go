@@ -1,7 +1,11 @@
 package ai
 
 import (
+	"fmt"
+	"slices"
+	"strings"
 	"sync"
+	"unicode"
 )
 
 // FeatureFlag represents an AI feature that can be enabled/disabled
@@ -27,6 +31,72 @@ const (
 	FlagDocTranslation    FeatureFlag = "doc_translation"
 )
 
+// FeaturesEnv names the environment variable listing the AI features to enable
+const FeaturesEnv = "AI_FEATURES"
+
+// AllFlags lists every feature flag
+var AllFlags = []FeatureFlag{
+	FlagAutoComments,
+	FlagAutoSynopsis,
+	FlagEnhanceDocs,
+	FlagSemanticSearch,
+	FlagQueryUnderstanding,
+	FlagAutoExamples,
+	FlagPlaygroundLinks,
+	FlagExplainCode,
+	FlagLicenseSummary,
+	FlagDocTranslation,
+}
+
+// flagAliases are the short feature names accepted besides the flag values
+var flagAliases = map[string]FeatureFlag{
+	"explain":   FlagExplainCode,
+	"translate": FlagDocTranslation,
+}
+
+// ParseFeatureFlags parses a comma-separated list of features such as
+// "explain,license-summary,QueryUnderstanding". Names are the flag values,
+// written with dashes, underscores or in CamelCase, or "all".
+func ParseFeatureFlags(list string) ([]FeatureFlag, error) {
+	var flags []FeatureFlag
+	for _, name := range strings.Split(list, ",") {
+		name = featureName(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if name == "all" {
+			flags = append(flags, AllFlags...)
+			continue
+		}
+		flag, ok := flagAliases[name]
+		if !ok {
+			if flag = FeatureFlag(name); !slices.Contains(AllFlags, flag) {
+				return nil, fmt.Errorf("unknown AI feature %q", name)
+			}
+		}
+		flags = append(flags, flag)
+	}
+	return flags, nil
+}
+
+// featureName normalizes "LicenseSummary" or "license-summary" to "license_summary"
+func featureName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if r == '-' {
+			r = '_'
+		}
+		if unicode.IsUpper(r) {
+			if i > 0 && name[i-1] != '_' && name[i-1] != '-' {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
 // FeatureFlags manages feature flag state
 type FeatureFlags struct {
 	flags map[FeatureFlag]bool
@@ -66,20 +136,7 @@ func (ff *FeatureFlags) EnableAll() {
 	ff.mu.Lock()
 	defer ff.mu.Unlock()
 
-	allFlags := []FeatureFlag{
-		FlagAutoComments,
-		FlagAutoSynopsis,
-		FlagEnhanceDocs,
-		FlagSemanticSearch,
-		FlagQueryUnderstanding,
-		FlagAutoExamples,
-		FlagPlaygroundLinks,
-		FlagExplainCode,
-		FlagLicenseSummary,
-		FlagDocTranslation,
-	}
-
-	for _, flag := range allFlags {
+	for _, flag := range AllFlags {
 		ff.flags[flag] = true
 	}
 }
@@ -0,0 +1,60 @@
+package ai
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseFeatureFlags(t *testing.T) {
+	got, err := ParseFeatureFlags("explain, license-summary,QueryUnderstanding,semantic_search,")
+	if err != nil {
+		t.Fatalf("ParseFeatureFlags() error = %v", err)
+	}
+	want := []FeatureFlag{FlagExplainCode, FlagLicenseSummary, FlagQueryUnderstanding, FlagSemanticSearch}
+	if !slices.Equal(got, want) {
+		t.Errorf("ParseFeatureFlags() = %v, want %v", got, want)
+	}
+
+	if got, err := ParseFeatureFlags("all"); err != nil || len(got) != len(AllFlags) {
+		t.Errorf("ParseFeatureFlags(all) = %v, %v", got, err)
+	}
+	if got, err := ParseFeatureFlags(""); err != nil || len(got) != 0 {
+		t.Errorf("ParseFeatureFlags(\"\") = %v, %v", got, err)
+	}
+	if _, err := ParseFeatureFlags("explain,telepathy"); err == nil {
+		t.Error("ParseFeatureFlags() accepted an unknown feature")
+	}
+}
+
+func TestEnableFromEnv(t *testing.T) {
+	enabled := func(defaults ...FeatureFlag) []FeatureFlag {
+		t.Helper()
+		s := NewService("test-key", 10, time.Minute)
+		if err := s.EnableFromEnv(defaults...); err != nil {
+			t.Fatalf("EnableFromEnv() error = %v", err)
+		}
+		var flags []FeatureFlag
+		for _, flag := range AllFlags {
+			if s.IsEnabled(flag) {
+				flags = append(flags, flag)
+			}
+		}
+		return flags
+	}
+
+	t.Setenv(FeaturesEnv, "explain,license-summary,query-understanding")
+	if got, want := enabled(FlagAutoComments), []FeatureFlag{FlagQueryUnderstanding, FlagExplainCode, FlagLicenseSummary}; !slices.Equal(got, want) {
+		t.Errorf("with %s set, enabled = %v, want %v", FeaturesEnv, got, want)
+	}
+
+	t.Setenv(FeaturesEnv, "")
+	if got := enabled(FlagAutoComments); len(got) != 0 {
+		t.Errorf("with %s empty, enabled = %v, want none", FeaturesEnv, got)
+	}
+
+	t.Setenv(FeaturesEnv, "explain,bogus")
+	if err := NewService("test-key", 10, time.Minute).EnableFromEnv(); err == nil {
+		t.Error("EnableFromEnv() accepted an unknown feature")
+	}
+}
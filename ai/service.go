@@ -112,6 +112,23 @@ func (s *Service) Enable(flag FeatureFlag) {
 	}
 }
 
+// EnableFromEnv enables the features listed in AI_FEATURES (see
+// ParseFeatureFlags), or the given defaults when it is unset. Set but empty, it
+// leaves every feature off.
+func (s *Service) EnableFromEnv(defaults ...FeatureFlag) error {
+	flags := defaults
+	if list, ok := os.LookupEnv(FeaturesEnv); ok {
+		var err error
+		if flags, err = ParseFeatureFlags(list); err != nil {
+			return fmt.Errorf("%s: %w", FeaturesEnv, err)
+		}
+	}
+	for _, flag := range flags {
+		s.Enable(flag)
+	}
+	return nil
+}
+
 // GenerateWithCache generates text using cache when possible
 func (s *Service) GenerateWithCache(flag FeatureFlag, systemPrompt, userPrompt string, maxTokens int) (string, error) {
 	// Check if feature is enabled
@@ -126,8 +143,7 @@ func (s *Service) GenerateWithCache(flag FeatureFlag, systemPrompt, userPrompt s
 	}
 
 	// Estimate cost (rough approximation)
-	estimatedTokens := len(systemPrompt+userPrompt)/4 + maxTokens
-	estimatedCost := float64(estimatedTokens) / 1000.0 * 0.002
+	estimatedTokens, estimatedCost := estimateCost(systemPrompt, userPrompt, maxTokens)
 
 	// Check budget
 	if !s.budget.CanSpend(estimatedCost) {
@@ -154,8 +170,20 @@ func (s *Service) GenerateWithCache(flag FeatureFlag, systemPrompt, userPrompt s
 	return content, nil
 }
 
+// estimateCost approximates the tokens and cost of a request from the length of its prompts
+func estimateCost(systemPrompt, userPrompt string, maxTokens int) (int, float64) {
+	tokens := len(systemPrompt+userPrompt)/4 + maxTokens
+	return tokens, float64(tokens) / 1000.0 * 0.002
+}
+
 // GenerateFunctionComment generates a comment for an uncommented function
 func (s *Service) GenerateFunctionComment(functionSignature, functionBody string) (string, error) {
+	systemPrompt, userPrompt, maxTokens := functionCommentPrompt(functionSignature, functionBody)
+	return s.GenerateWithCache(FlagAutoComments, systemPrompt, userPrompt, maxTokens)
+}
+
+// functionCommentPrompt returns the prompts of GenerateFunctionComment
+func functionCommentPrompt(functionSignature, functionBody string) (string, string, int) {
 	systemPrompt := "You are a Go documentation expert. Generate concise, accurate doc comments for Go functions. Follow Go conventions: start with the function name, be brief, explain what it does (not how)."
 
 	userPrompt := fmt.Sprintf(`Generate a doc comment for this Go function:
@@ -165,18 +193,24 @@ func (s *Service) GenerateFunctionComment(functionSignature, functionBody string
 
 Return ONLY the comment text, without code fences or additional explanation.`, functionSignature, functionBody)
 
-	return s.GenerateWithCache(FlagAutoComments, systemPrompt, userPrompt, 150)
+	return systemPrompt, userPrompt, 150
 }
 
 // GeneratePackageSynopsis generates a synopsis for a package
 func (s *Service) GeneratePackageSynopsis(packageName string, exportedSymbols []string) (string, error) {
+	systemPrompt, userPrompt, maxTokens := packageSynopsisPrompt(packageName, exportedSymbols)
+	return s.GenerateWithCache(FlagAutoSynopsis, systemPrompt, userPrompt, maxTokens)
+}
+
+// packageSynopsisPrompt returns the prompts of GeneratePackageSynopsis
+func packageSynopsisPrompt(packageName string, exportedSymbols []string) (string, string, int) {
 	systemPrompt := "You are a Go documentation expert. Generate concise package synopses (one line, under 80 characters) that describe what the package does."
 
 	userPrompt := fmt.Sprintf(`Generate a one-line synopsis for Go package "%s" with these exported symbols: %v
 
 Return ONLY the synopsis text, under 80 characters.`, packageName, exportedSymbols)
 
-	return s.GenerateWithCache(FlagAutoSynopsis, systemPrompt, userPrompt, 50)
+	return systemPrompt, userPrompt, 50
 }
 
 // ExplainCode explains what a piece of code does
@@ -242,6 +276,12 @@ func (s *Service) SetBudget(maxDailyUSD, maxMonthlyUSD float64) {
 
 // GenerateMethodComment generates a comment for an uncommented method
 func (s *Service) GenerateMethodComment(receiverType, methodName, signature, body string) (string, error) {
+	systemPrompt, userPrompt, maxTokens := methodCommentPrompt(receiverType, methodName, signature, body)
+	return s.GenerateWithCache(FlagAutoComments, systemPrompt, userPrompt, maxTokens)
+}
+
+// methodCommentPrompt returns the prompts of GenerateMethodComment
+func methodCommentPrompt(receiverType, methodName, signature, body string) (string, string, int) {
 	systemPrompt := "You are a Go documentation expert. Generate concise, accurate doc comments for Go methods. Follow Go conventions: start with the method name, be brief, explain what it does (not how)."
 
 	userPrompt := fmt.Sprintf(`Generate a doc comment for this Go method:
@@ -252,11 +292,17 @@ Receiver: %s
 
 Return ONLY the comment text, without code fences or additional explanation.`, receiverType, signature, body)
 
-	return s.GenerateWithCache(FlagAutoComments, systemPrompt, userPrompt, 150)
+	return systemPrompt, userPrompt, 150
 }
 
 // GenerateTypeComment generates a comment for an uncommented type
 func (s *Service) GenerateTypeComment(typeName, typeDefinition string) (string, error) {
+	systemPrompt, userPrompt, maxTokens := typeCommentPrompt(typeName, typeDefinition)
+	return s.GenerateWithCache(FlagAutoComments, systemPrompt, userPrompt, maxTokens)
+}
+
+// typeCommentPrompt returns the prompts of GenerateTypeComment
+func typeCommentPrompt(typeName, typeDefinition string) (string, string, int) {
 	systemPrompt := "You are a Go documentation expert. Generate concise, accurate doc comments for Go types. Follow Go conventions: start with the type name, be brief, explain what it represents."
 
 	userPrompt := fmt.Sprintf(`Generate a doc comment for this Go type:
@@ -265,7 +311,7 @@ type %s %s
 
 Return ONLY the comment text, without code fences or additional explanation.`, typeName, typeDefinition)
 
-	return s.GenerateWithCache(FlagAutoComments, systemPrompt, userPrompt, 100)
+	return systemPrompt, userPrompt, 100
 }
 
 // EnhanceDocumentation improves existing sparse documentation
@@ -496,6 +542,41 @@ func (s *Service) GenerateEmbedding(text string) ([]float32, error) {
 	return s.client.GenerateEmbedding(text)
 }
 
+// GenerateEmbeddings generates embeddings for a batch of texts in a single API call
+func (s *Service) GenerateEmbeddings(texts []string) ([][]float32, error) {
+	if !s.IsEnabled(FlagSemanticSearch) {
+		return nil, fmt.Errorf("semantic search is not enabled")
+	}
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	// Estimate cost (embeddings are ~$0.0001 per 1K tokens)
+	estimatedTokens := 0
+	for _, text := range texts {
+		estimatedTokens += len(text) / 4
+	}
+	estimatedCost := float64(estimatedTokens) / 1000.0 * 0.0001
+
+	if !s.budget.CanSpend(estimatedCost) {
+		return nil, fmt.Errorf("budget limit exceeded (daily: $%.2f/%.2f, monthly: $%.2f/%.2f)",
+			s.budget.CurrentDayUSD, s.budget.MaxDailyUSD,
+			s.budget.CurrentMonthUSD, s.budget.MaxMonthlyUSD)
+	}
+
+	embeddings, err := s.client.GenerateEmbeddings(texts)
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(embeddings))
+	}
+
+	s.budget.RecordSpend(estimatedCost)
+
+	return embeddings, nil
+}
+
 // QueryUnderstanding represents the AI's interpretation of a search query
 type QueryUnderstanding struct {
 	OriginalQuery   string   `json:"original_query"`
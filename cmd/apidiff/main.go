@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alexisbouchez/wikigo/web"
+)
+
+func main() {
+	jsonOutput := flag.Bool("json", false, "Print the diff as JSON")
+	fromVersion := flag.String("from", "", "Version of the old tree (e.g. v1.4.2), used to recommend a semver bump")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: apidiff [-json] [-from version] <old-dir> <new-dir>\n")
+		fmt.Fprintf(os.Stderr, "Compares the exported API of the Go packages under two directories and\n")
+		fmt.Fprintf(os.Stderr, "exits with status 1 if any change can break existing callers.\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	oldAPI, err := loadAPI(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", flag.Arg(0), err)
+		os.Exit(2)
+	}
+	newAPI, err := loadAPI(flag.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", flag.Arg(1), err)
+		os.Exit(2)
+	}
+
+	diffs := diffTrees(oldAPI, newAPI)
+
+	var all []web.DiffEntry
+	breaking := 0
+	for _, entries := range diffs {
+		for _, e := range entries {
+			if e.Breaking {
+				breaking++
+			}
+		}
+		all = append(all, entries...)
+	}
+	verdict := web.Verdict(all, *fromVersion)
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		out := struct {
+			Packages map[string][]web.DiffEntry
+			Verdict  web.APIVerdict
+		}{diffs, verdict}
+		if err := enc.Encode(out); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding diff: %v\n", err)
+			os.Exit(2)
+		}
+	} else {
+		printDiff(diffs)
+		fmt.Printf("\nRecommended bump: %s (%s)\n", verdict.Bump, verdict.Reason)
+	}
+
+	if breaking > 0 {
+		fmt.Fprintf(os.Stderr, "%d breaking change(s)\n", breaking)
+		os.Exit(1)
+	}
+}
+
+// diffTrees diffs every package present in either tree, keyed by package directory
+func diffTrees(oldAPI, newAPI map[string]*web.PackageDoc) map[string][]web.DiffEntry {
+	diffs := make(map[string][]web.DiffEntry)
+	for dir, oldPkg := range oldAPI {
+		newPkg, ok := newAPI[dir]
+		if !ok {
+			diffs[dir] = []web.DiffEntry{{Kind: "removed", Type: "package", Name: dir, Breaking: true}}
+			continue
+		}
+		if entries := web.DiffAPI(oldPkg, newPkg); len(entries) > 0 {
+			diffs[dir] = entries
+		}
+	}
+	for dir, newPkg := range newAPI {
+		if _, ok := oldAPI[dir]; !ok {
+			if entries := web.DiffAPI(nil, newPkg); len(entries) > 0 {
+				diffs[dir] = entries
+			}
+		}
+	}
+	return diffs
+}
+
+func printDiff(diffs map[string][]web.DiffEntry) {
+	dirs := make([]string, 0, len(diffs))
+	for dir := range diffs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	markers := map[string]string{"added": "+", "removed": "-", "changed": "~"}
+	for _, dir := range dirs {
+		fmt.Printf("%s:\n", dir)
+		for _, e := range diffs[dir] {
+			compat := ""
+			if e.Breaking {
+				compat = " (breaking)"
+			}
+			fmt.Printf("  %s %s %s %s%s\n", markers[e.Kind], e.Kind, e.Type, e.Name, compat)
+			switch e.Kind {
+			case "changed":
+				fmt.Printf("      old: %s\n", indent(e.OldDecl))
+				fmt.Printf("      new: %s\n", indent(e.NewDecl))
+			case "added":
+				if e.NewDecl != "" {
+					fmt.Printf("      %s\n", indent(e.NewDecl))
+				}
+			case "removed":
+				if e.OldDecl != "" {
+					fmt.Printf("      %s\n", indent(e.OldDecl))
+				}
+			}
+		}
+	}
+}
+
+func indent(decl string) string {
+	return strings.ReplaceAll(decl, "\n", "\n           ")
+}
+
+// loadAPI extracts the exported API of every non-main package under root,
+// keyed by the package directory relative to root
+func loadAPI(root string) (map[string]*web.PackageDoc, error) {
+	api := make(map[string]*web.PackageDoc)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if path != root && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+			return filepath.SkipDir
+		}
+
+		pkg, err := loadPackage(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if pkg == nil {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		api[filepath.ToSlash(rel)] = pkg
+		return nil
+	})
+	return api, err
+}
+
+// loadPackage documents the exported API of the package in dir, or returns nil
+// if the directory holds no importable package
+func loadPackage(dir string) (*web.PackageDoc, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		if f.Name.Name == "main" || f.Name.Name == "documentation" {
+			continue
+		}
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	docPkg, err := doc.NewFromFiles(fset, files, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &web.PackageDoc{Name: docPkg.Name}
+	for _, f := range docPkg.Funcs {
+		pkg.Functions = append(pkg.Functions, web.Function{Name: f.Name, Signature: formatNode(fset, f.Decl)})
+	}
+	pkg.Constants = valueDecls[web.Constant](fset, docPkg.Consts, token.CONST)
+	pkg.Variables = valueDecls[web.Variable](fset, docPkg.Vars, token.VAR)
+	for _, t := range docPkg.Types {
+		typ := web.Type{Name: t.Name, Decl: typeDecl(fset, t)}
+		for _, f := range t.Funcs {
+			typ.Functions = append(typ.Functions, web.Function{Name: f.Name, Signature: formatNode(fset, f.Decl)})
+		}
+		for _, m := range t.Methods {
+			typ.Methods = append(typ.Methods, web.Function{Name: m.Name, Signature: formatNode(fset, m.Decl)})
+		}
+		typ.Constants = valueDecls[web.Constant](fset, t.Consts, token.CONST)
+		typ.Variables = valueDecls[web.Variable](fset, t.Vars, token.VAR)
+		pkg.Types = append(pkg.Types, typ)
+	}
+	return pkg, nil
+}
+
+// valueDecls splits const and var groups into one declaration per spec, so that
+// changing one value in a group is not reported against its neighbours
+func valueDecls[T web.Constant | web.Variable](fset *token.FileSet, values []*doc.Value, tok token.Token) []T {
+	var decls []T
+	for _, v := range values {
+		for _, spec := range v.Decl.Specs {
+			vs, ok := spec.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			var names []string
+			for _, n := range vs.Names {
+				if n.IsExported() {
+					names = append(names, n.Name)
+				}
+			}
+			if len(names) == 0 {
+				continue
+			}
+			decl := formatNode(fset, &ast.GenDecl{Tok: tok, Specs: []ast.Spec{vs}})
+			decls = append(decls, T{Names: names, Decl: decl})
+		}
+	}
+	return decls
+}
+
+// typeDecl formats a type's own spec, even when it is declared in a group
+func typeDecl(fset *token.FileSet, t *doc.Type) string {
+	for _, spec := range t.Decl.Specs {
+		if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.Name == t.Name {
+			return formatNode(fset, &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{ts}})
+		}
+	}
+	return formatNode(fset, t.Decl)
+}
+
+func formatNode(fset *token.FileSet, node any) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
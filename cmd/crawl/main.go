@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/alexisbouchez/wikigo/ai"
 	"github.com/alexisbouchez/wikigo/crawler"
 )
 
@@ -21,8 +23,21 @@ func main() {
 	tempDir := flag.String("temp", "", "Temporary directory for downloads (default: system temp)")
 	daemon := flag.Bool("daemon", false, "Run in daemon mode with periodic re-indexing")
 	interval := flag.Duration("interval", 1*time.Hour, "Re-indexing interval in daemon mode")
+	licenseFiles := flag.String("license-files", "", "Comma-separated additional license file names to look for")
+	retryFailed := flag.Bool("retry-failed", false, "Re-process only the module versions in the crawl error log")
+	excludeDirs := flag.String("exclude-dirs", strings.Join(crawler.DefaultExcludeDirs, ","), "Comma-separated directory names not indexed as packages (empty to index all)")
+	maxModuleMB := flag.Int64("max-module-mb", 100, "Maximum size of a module zip in MB")
+	maxExtractMB := flag.Int64("max-extract-mb", 0, "Maximum MB of modules extracted to the temporary directory at once across workers (0 = unlimited)")
+	staleTempAge := flag.Duration("stale-temp-age", crawler.DefaultStaleTempAge, "Remove temporary directories left by killed crawlers older than this on startup (negative to keep them)")
+	maxAttempts := flag.Int("max-attempts", crawler.DefaultMaxAttempts, "Times a module proxy or index request is made when it fails with a network error, 429 or 5xx (1 = no retries)")
+	aiSynopsis := flag.Bool("ai-synopsis", false, "Generate synopses with AI (requires MISTRAL_API_KEY) for packages with neither a doc comment nor a README")
 	flag.Parse()
 
+	if *retryFailed && *daemon {
+		fmt.Fprintln(os.Stderr, "Error: -retry-failed cannot be combined with -daemon")
+		os.Exit(1)
+	}
+
 	var since time.Time
 	if *sinceStr != "" {
 		var err error
@@ -34,13 +49,44 @@ func main() {
 		}
 	}
 
+	var extraLicenseFiles []string
+	for _, name := range strings.Split(*licenseFiles, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			extraLicenseFiles = append(extraLicenseFiles, name)
+		}
+	}
+
+	excluded := []string{}
+	for _, name := range strings.Split(*excludeDirs, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			excluded = append(excluded, name)
+		}
+	}
+
 	cfg := crawler.Config{
-		DBPath:     *dbPath,
-		Workers:    *workers,
-		RateLimit:  *rateLimit,
-		Since:      since,
-		MaxModules: *maxModules,
-		TempDir:    *tempDir,
+		DBPath:            *dbPath,
+		Workers:           *workers,
+		RateLimit:         *rateLimit,
+		Since:             since,
+		MaxModules:        *maxModules,
+		TempDir:           *tempDir,
+		ExtraLicenseFiles: extraLicenseFiles,
+		ExcludeDirs:       excluded,
+		MaxModuleSize:     *maxModuleMB * 1024 * 1024,
+		MaxExtractSize:    *maxExtractMB * 1024 * 1024,
+		StaleTempAge:      *staleTempAge,
+		MaxAttempts:       *maxAttempts,
+	}
+
+	if *aiSynopsis {
+		service := ai.NewServiceFromEnv()
+		service.SetBudget(5.0, 100.0) // $5/day, $100/month
+		service.Enable(ai.FlagAutoSynopsis)
+		if !service.IsEnabled(ai.FlagAutoSynopsis) {
+			fmt.Fprintln(os.Stderr, "Error: -ai-synopsis requires MISTRAL_API_KEY")
+			os.Exit(1)
+		}
+		cfg.SynopsisGenerator = service
 	}
 
 	c, err := crawler.New(cfg)
@@ -69,6 +115,8 @@ func main() {
 	fmt.Printf("Rate limit: %v\n", *rateLimit)
 	if *daemon {
 		fmt.Printf("Mode: daemon (interval: %v)\n", *interval)
+	} else if *retryFailed {
+		fmt.Printf("Mode: retry failed\n")
 	} else {
 		fmt.Printf("Mode: one-shot\n")
 	}
@@ -80,7 +128,16 @@ func main() {
 	}
 	fmt.Println()
 
-	if *daemon {
+	if *retryFailed {
+		if err := c.RetryFailed(ctx); err != nil {
+			if err == context.Canceled {
+				fmt.Println("Retry cancelled")
+			} else {
+				fmt.Fprintf(os.Stderr, "Error retrying failed modules: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	} else if *daemon {
 		// Run in daemon mode with scheduled re-indexing
 		if err := c.RunWithSchedule(ctx, *interval); err != nil {
 			if err == context.Canceled {
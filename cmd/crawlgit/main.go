@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/alexisbouchez/wikigo/crawler"
+)
+
+func main() {
+	dbPath := flag.String("db", "wikigo.db", "SQLite database path")
+	ref := flag.String("ref", "", "Branch, tag or commit to index (default: the default branch)")
+	modulePath := flag.String("module", "", "Module path, for repositories without a go.mod")
+	tempDir := flag.String("temp", "", "Temporary directory for the checkout")
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: crawlgit [-db path] [-ref ref] [-module path] <repository-url>\n")
+		fmt.Fprintf(os.Stderr, "Example: crawlgit -ref v1.2.0 git@github.com:me/private-repo.git\n")
+		os.Exit(1)
+	}
+
+	c, err := crawler.New(crawler.Config{
+		DBPath:  *dbPath,
+		TempDir: *tempDir,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating crawler: %v\n", err)
+		os.Exit(1)
+	}
+	defer c.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	fmt.Printf("Indexing %s...\n", args[0])
+	mv, err := c.IndexGitRepo(ctx, crawler.GitSource{
+		URL:        args[0],
+		Ref:        *ref,
+		ModulePath: *modulePath,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error indexing repository: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Indexed %s@%s\n", mv.Path, mv.Version)
+}
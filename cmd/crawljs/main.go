@@ -1,34 +1,64 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/alexisbouchez/wikigo/crawler"
 	"github.com/alexisbouchez/wikigo/db"
+	"github.com/alexisbouchez/wikigo/jsparser"
 )
 
 func main() {
 	var (
-		dbPath      = flag.String("db", "wikigo.db", "Database path")
-		npmPackage  = flag.String("npm", "", "NPM package name to index")
-		githubRepo  = flag.String("github", "", "GitHub repository (owner/repo) to index")
-		githubToken = flag.String("token", os.Getenv("GITHUB_TOKEN"), "GitHub API token")
+		dbPath       = flag.String("db", "wikigo.db", "Database path")
+		npmPackage   = flag.String("npm", "", "NPM package name to index")
+		search       = flag.String("search", "", "Index the NPM packages matching this search")
+		limit        = flag.Int("limit", 20, "Maximum number of search results to index")
+		minStars     = flag.Int("min-stars", 0, "Skip packages whose GitHub repository has fewer stars")
+		minDownloads = flag.Int("min-downloads", 0, "Skip NPM packages with fewer downloads last week")
+		githubRepo   = flag.String("github", "", "GitHub repository (owner/repo) to index")
+		githubToken  = flag.String("token", os.Getenv("GITHUB_TOKEN"), "GitHub API token")
+		minAge       = flag.Duration("min-age", 24*time.Hour, "Skip packages indexed more recently than this")
+		force        = flag.Bool("force", false, "Re-fetch packages even if they were indexed recently or are unchanged")
+		extensions   = flag.String("extensions", strings.Join(jsparser.DefaultExtensions, ","), "Comma-separated file extensions parsed for symbols")
 	)
 	flag.Parse()
 
-	if *npmPackage == "" && *githubRepo == "" {
-		fmt.Println("Usage: crawljs -npm <package> OR -github <owner/repo>")
+	var exts []string
+	for _, ext := range strings.Split(*extensions, ",") {
+		if ext = strings.TrimSpace(ext); ext != "" {
+			exts = append(exts, "."+strings.TrimPrefix(ext, "."))
+		}
+	}
+
+	if *npmPackage == "" && *search == "" && *githubRepo == "" {
+		fmt.Println("Usage: crawljs -npm <package> OR -search <query> OR -github <owner/repo>")
 		fmt.Println("  -npm string")
 		fmt.Println("        NPM package name to index")
+		fmt.Println("  -search string")
+		fmt.Println("        Index the NPM packages matching this search")
+		fmt.Println("  -limit int")
+		fmt.Println("        Maximum number of search results to index (default: 20)")
 		fmt.Println("  -github string")
 		fmt.Println("        GitHub repository (owner/repo) to index")
 		fmt.Println("  -token string")
 		fmt.Println("        GitHub API token (default: $GITHUB_TOKEN)")
 		fmt.Println("  -db string")
 		fmt.Println("        Database path (default: wikigo.db)")
+		fmt.Println("  -min-age duration")
+		fmt.Println("        Skip packages indexed more recently than this (default: 24h)")
+		fmt.Println("  -force")
+		fmt.Println("        Re-fetch packages even if they were indexed recently or are unchanged")
+		fmt.Println("  -min-stars int")
+		fmt.Println("        Skip packages whose GitHub repository has fewer stars")
+		fmt.Println("  -min-downloads int")
+		fmt.Println("        Skip NPM packages with fewer downloads last week")
 		os.Exit(1)
 	}
 
@@ -39,20 +69,41 @@ func main() {
 	}
 	defer database.Close()
 
-	if *npmPackage != "" {
-		// Index NPM package
-		log.Printf("Indexing NPM package: %s", *npmPackage)
+	if *npmPackage != "" || *search != "" {
 		npmCrawler, err := crawler.NewNPMCrawler(database)
 		if err != nil {
 			log.Fatalf("Failed to create NPM crawler: %v", err)
 		}
 		defer npmCrawler.Close()
+		if !*force {
+			npmCrawler.MinAge = *minAge
+		}
+		npmCrawler.MinStars = *minStars
+		npmCrawler.MinDownloads = *minDownloads
+		npmCrawler.GitHubToken = *githubToken
+		npmCrawler.Extensions = exts
 
-		if err := npmCrawler.IndexPackage(*npmPackage); err != nil {
-			log.Fatalf("Failed to index package: %v", err)
+		if *npmPackage != "" {
+			// Index NPM package
+			log.Printf("Indexing NPM package: %s", *npmPackage)
+			if err := indexNPM(npmCrawler, *npmPackage, *minAge); err != nil {
+				log.Fatalf("Failed to index package: %v", err)
+			}
 		}
 
-		log.Printf("Successfully indexed %s", *npmPackage)
+		if *search != "" {
+			names, err := npmCrawler.SearchPackages(*search, *limit)
+			if err != nil {
+				log.Fatalf("Failed to search packages: %v", err)
+			}
+			log.Printf("Indexing %d NPM packages matching %q", len(names), *search)
+			for _, name := range names {
+				// One bad package should not stop a bulk crawl
+				if err := indexNPM(npmCrawler, name, *minAge); err != nil {
+					log.Printf("Failed to index %s: %v", name, err)
+				}
+			}
+		}
 	}
 
 	if *githubRepo != "" {
@@ -70,11 +121,36 @@ func main() {
 			log.Fatalf("Failed to create GitHub crawler: %v", err)
 		}
 		defer githubCrawler.Close()
+		githubCrawler.MinStars = *minStars
+		githubCrawler.Force = *force
+		githubCrawler.Extensions = exts
 
-		if err := githubCrawler.IndexRepository(owner, repo); err != nil {
+		err = githubCrawler.IndexRepository(owner, repo)
+		switch {
+		case errors.Is(err, crawler.ErrNotModified):
+			log.Printf("Skipping %s/%s: %v (use -force to re-index)", owner, repo, err)
+		case errors.Is(err, crawler.ErrBelowThreshold):
+			log.Printf("Skipping %s/%s: %v", owner, repo, err)
+		case err != nil:
 			log.Fatalf("Failed to index repository: %v", err)
+		default:
+			log.Printf("Successfully indexed %s/%s", owner, repo)
 		}
+	}
+}
 
-		log.Printf("Successfully indexed %s/%s", owner, repo)
+// indexNPM indexes one package, logging the packages skipped as fresh or unpopular
+func indexNPM(npmCrawler *crawler.NPMCrawler, name string, minAge time.Duration) error {
+	err := npmCrawler.IndexPackage(name)
+	switch {
+	case errors.Is(err, crawler.ErrRecentlyIndexed):
+		log.Printf("Skipping %s: indexed less than %s ago (use -force to re-fetch)", name, minAge)
+	case errors.Is(err, crawler.ErrBelowThreshold):
+		log.Printf("Skipping %s: %v", name, err)
+	case err != nil:
+		return err
+	default:
+		log.Printf("Successfully indexed %s", name)
 	}
+	return nil
 }
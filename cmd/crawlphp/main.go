@@ -1,10 +1,12 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/alexisbouchez/wikigo/crawler"
 	"github.com/alexisbouchez/wikigo/db"
@@ -14,6 +16,8 @@ func main() {
 	var (
 		dbPath = flag.String("db", "wikigo.db", "Database path")
 		pkg    = flag.String("package", "", "PHP package name to index (vendor/package)")
+		minAge = flag.Duration("min-age", 24*time.Hour, "Skip packages indexed more recently than this")
+		force  = flag.Bool("force", false, "Re-fetch packages even if they were indexed recently")
 	)
 	flag.Parse()
 
@@ -23,6 +27,10 @@ func main() {
 		fmt.Println("        PHP package name to index (e.g., laravel/framework)")
 		fmt.Println("  -db string")
 		fmt.Println("        Database path (default: wikigo.db)")
+		fmt.Println("  -min-age duration")
+		fmt.Println("        Skip packages indexed more recently than this (default: 24h)")
+		fmt.Println("  -force")
+		fmt.Println("        Re-fetch packages even if they were indexed recently")
 		os.Exit(1)
 	}
 
@@ -40,10 +48,17 @@ func main() {
 		log.Fatalf("Failed to create Packagist crawler: %v", err)
 	}
 	defer packagistCrawler.Close()
+	if !*force {
+		packagistCrawler.MinAge = *minAge
+	}
 
-	if err := packagistCrawler.IndexPackage(*pkg); err != nil {
+	err = packagistCrawler.IndexPackage(*pkg)
+	switch {
+	case errors.Is(err, crawler.ErrRecentlyIndexed):
+		log.Printf("Skipping %s: indexed less than %s ago (use -force to re-fetch)", *pkg, *minAge)
+	case err != nil:
 		log.Fatalf("Failed to index package: %v", err)
+	default:
+		log.Printf("Successfully indexed %s", *pkg)
 	}
-
-	log.Printf("Successfully indexed %s", *pkg)
 }
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/alexisbouchez/wikigo/crawler"
 	"github.com/alexisbouchez/wikigo/db"
@@ -12,8 +14,11 @@ import (
 
 func main() {
 	var (
-		dbPath  = flag.String("db", "wikigo.db", "Database path")
-		pkg     = flag.String("package", "", "Python package name to index")
+		dbPath       = flag.String("db", "wikigo.db", "Database path")
+		pkg          = flag.String("package", "", "Python package name to index")
+		minAge       = flag.Duration("min-age", 24*time.Hour, "Skip packages indexed more recently than this")
+		force        = flag.Bool("force", false, "Re-fetch packages even if they were indexed recently")
+		minDownloads = flag.Int("min-downloads", 0, "Skip packages with fewer downloads last week")
 	)
 	flag.Parse()
 
@@ -23,6 +28,12 @@ func main() {
 		fmt.Println("        Python package name to index")
 		fmt.Println("  -db string")
 		fmt.Println("        Database path (default: wikigo.db)")
+		fmt.Println("  -min-age duration")
+		fmt.Println("        Skip packages indexed more recently than this (default: 24h)")
+		fmt.Println("  -force")
+		fmt.Println("        Re-fetch packages even if they were indexed recently")
+		fmt.Println("  -min-downloads int")
+		fmt.Println("        Skip packages with fewer downloads last week")
 		os.Exit(1)
 	}
 
@@ -40,10 +51,20 @@ func main() {
 		log.Fatalf("Failed to create PyPI crawler: %v", err)
 	}
 	defer pypiCrawler.Close()
+	if !*force {
+		pypiCrawler.MinAge = *minAge
+	}
+	pypiCrawler.MinDownloads = *minDownloads
 
-	if err := pypiCrawler.IndexPackage(*pkg); err != nil {
+	err = pypiCrawler.IndexPackage(*pkg)
+	switch {
+	case errors.Is(err, crawler.ErrRecentlyIndexed):
+		log.Printf("Skipping %s: indexed less than %s ago (use -force to re-fetch)", *pkg, *minAge)
+	case errors.Is(err, crawler.ErrBelowThreshold):
+		log.Printf("Skipping %s: %v", *pkg, err)
+	case err != nil:
 		log.Fatalf("Failed to index package: %v", err)
+	default:
+		log.Printf("Successfully indexed %s", *pkg)
 	}
-
-	log.Printf("Successfully indexed %s", *pkg)
 }
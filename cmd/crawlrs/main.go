@@ -1,10 +1,12 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/alexisbouchez/wikigo/crawler"
 	"github.com/alexisbouchez/wikigo/db"
@@ -12,8 +14,11 @@ import (
 
 func main() {
 	var (
-		dbPath = flag.String("db", "wikigo.db", "Database path")
-		crate  = flag.String("crate", "", "Crate name to index")
+		dbPath       = flag.String("db", "wikigo.db", "Database path")
+		crate        = flag.String("crate", "", "Crate name to index")
+		minAge       = flag.Duration("min-age", 24*time.Hour, "Skip crates indexed more recently than this")
+		force        = flag.Bool("force", false, "Re-fetch crates even if they were indexed recently")
+		minDownloads = flag.Int("min-downloads", 0, "Skip crates with fewer downloads in the last 90 days")
 	)
 	flag.Parse()
 
@@ -23,6 +28,12 @@ func main() {
 		fmt.Println("        Crate name to index")
 		fmt.Println("  -db string")
 		fmt.Println("        Database path (default: wikigo.db)")
+		fmt.Println("  -min-age duration")
+		fmt.Println("        Skip crates indexed more recently than this (default: 24h)")
+		fmt.Println("  -force")
+		fmt.Println("        Re-fetch crates even if they were indexed recently")
+		fmt.Println("  -min-downloads int")
+		fmt.Println("        Skip crates with fewer downloads in the last 90 days")
 		os.Exit(1)
 	}
 
@@ -40,10 +51,20 @@ func main() {
 		log.Fatalf("Failed to create crates crawler: %v", err)
 	}
 	defer cratesCrawler.Close()
+	if !*force {
+		cratesCrawler.MinAge = *minAge
+	}
+	cratesCrawler.MinDownloads = *minDownloads
 
-	if err := cratesCrawler.IndexCrate(*crate); err != nil {
+	err = cratesCrawler.IndexCrate(*crate)
+	switch {
+	case errors.Is(err, crawler.ErrRecentlyIndexed):
+		log.Printf("Skipping %s: indexed less than %s ago (use -force to re-fetch)", *crate, *minAge)
+	case errors.Is(err, crawler.ErrBelowThreshold):
+		log.Printf("Skipping %s: %v", *crate, err)
+	case err != nil:
 		log.Fatalf("Failed to index crate: %v", err)
+	default:
+		log.Printf("Successfully indexed %s", *crate)
 	}
-
-	log.Printf("Successfully indexed %s", *crate)
 }
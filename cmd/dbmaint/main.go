@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/alexisbouchez/wikigo/db"
+)
+
+func main() {
+	dbPath := flag.String("db", "wikigo.db", "Path to SQLite database")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: dbmaint [-db path] <command>\n\n")
+		fmt.Fprintf(os.Stderr, "Commands:\n")
+		fmt.Fprintf(os.Stderr, "  check        Compare the row counts of each table and its full-text index\n")
+		fmt.Fprintf(os.Stderr, "  rebuild-fts  Clear and repopulate every full-text index from its table\n")
+		fmt.Fprintf(os.Stderr, "  imported-by  Recompute the imported-by counts shown on package pages (run after crawls)\n")
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	database, err := db.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	switch flag.Arg(0) {
+	case "check":
+		if drifted := checkFTS(database); drifted > 0 {
+			fmt.Printf("\n%d index(es) out of sync; run dbmaint rebuild-fts to repair\n", drifted)
+			database.Close()
+			os.Exit(1)
+		}
+	case "rebuild-fts":
+		if err := database.RebuildFTS(); err != nil {
+			log.Fatalf("Failed to rebuild FTS indexes: %v", err)
+		}
+		fmt.Println("Rebuilt full-text indexes")
+		checkFTS(database)
+	case "imported-by":
+		n, err := database.RefreshImportedByCounts()
+		if err != nil {
+			log.Fatalf("Failed to refresh imported-by counts: %v", err)
+		}
+		fmt.Printf("Refreshed imported-by counts of %d packages\n", n)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", flag.Arg(0))
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// checkFTS prints the row counts of each FTS index and returns how many drifted
+func checkFTS(database *db.DB) int {
+	counts, err := database.CheckFTS()
+	if err != nil {
+		log.Fatalf("Failed to check FTS indexes: %v", err)
+	}
+
+	drifted := 0
+	fmt.Printf("%-22s %10s %10s %8s\n", "INDEX", "ROWS", "INDEXED", "DELTA")
+	for _, c := range counts {
+		status := ""
+		if c.Delta() != 0 {
+			status = "  out of sync"
+			drifted++
+		}
+		fmt.Printf("%-22s %10d %10d %+8d%s\n", c.Table, c.Rows, c.Indexed, c.Delta(), status)
+	}
+	return drifted
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/alexisbouchez/wikigo/ai"
+	"github.com/alexisbouchez/wikigo/db"
+)
+
+func main() {
+	var (
+		dbPath        = flag.String("db", "wikigo.db", "Path to SQLite database")
+		batchSize     = flag.Int("batch", 32, "Number of texts sent per embeddings API call")
+		maxPackages   = flag.Int("max", 0, "Maximum number of packages to embed (0 = unlimited)")
+		dailyBudget   = flag.Float64("daily-budget", 5.0, "Maximum daily spend in USD")
+		monthlyBudget = flag.Float64("monthly-budget", 100.0, "Maximum monthly spend in USD")
+		dryRun        = flag.Bool("dry-run", false, "Report what would be embedded without calling the API")
+	)
+	flag.Parse()
+
+	if *batchSize <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: -batch must be positive\n")
+		os.Exit(1)
+	}
+
+	service := ai.NewServiceFromEnv()
+	service.SetBudget(*dailyBudget, *monthlyBudget)
+	service.Enable(ai.FlagSemanticSearch)
+
+	if !*dryRun && !service.IsEnabled(ai.FlagSemanticSearch) {
+		log.Fatalf("Semantic search is not available (is MISTRAL_API_KEY set?)")
+	}
+
+	database, err := db.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	packages, err := database.ListPackages()
+	if err != nil {
+		log.Fatalf("Failed to list packages: %v", err)
+	}
+
+	existing, err := database.GetEmbeddingHashes("go")
+	if err != nil {
+		log.Fatalf("Failed to load existing embeddings: %v", err)
+	}
+
+	// Collect packages whose embedding is missing or out of date
+	type pending struct {
+		importPath string
+		text       string
+		hash       string
+	}
+	var todo []pending
+	skipped := 0
+	for _, pkg := range packages {
+		text := embeddingText(pkg)
+		hash := textHash(text)
+		if existing[pkg.ImportPath] == hash {
+			skipped++
+			continue
+		}
+		todo = append(todo, pending{importPath: pkg.ImportPath, text: text, hash: hash})
+		if *maxPackages > 0 && len(todo) >= *maxPackages {
+			break
+		}
+	}
+
+	log.Printf("%d packages up to date, %d to embed", skipped, len(todo))
+
+	if *dryRun {
+		for _, p := range todo {
+			fmt.Println(p.importPath)
+		}
+		return
+	}
+
+	embedded := 0
+	for start := 0; start < len(todo); start += *batchSize {
+		end := start + *batchSize
+		if end > len(todo) {
+			end = len(todo)
+		}
+		batch := todo[start:end]
+
+		texts := make([]string, len(batch))
+		for i, p := range batch {
+			texts[i] = p.text
+		}
+
+		vectors, err := service.GenerateEmbeddings(texts)
+		if err != nil {
+			// Stop on budget or API errors; already stored batches are kept for the next run
+			log.Printf("Error embedding batch %d-%d: %v", start, end, err)
+			break
+		}
+
+		for i, p := range batch {
+			if err := database.UpsertEmbedding(p.importPath, "go", p.hash, vectors[i]); err != nil {
+				log.Printf("Error saving embedding for %s: %v", p.importPath, err)
+				continue
+			}
+			embedded++
+		}
+		log.Printf("Embedded %d/%d packages", embedded, len(todo))
+	}
+
+	stats := service.GetStats()
+	fmt.Printf("\n=== Statistics ===\n")
+	fmt.Printf("Embedded: %d\n", embedded)
+	fmt.Printf("Skipped (up to date): %d\n", skipped)
+	fmt.Printf("Total requests: %v\n", stats["total_requests"])
+	fmt.Printf("Total cost: $%.4f\n", stats["total_cost_usd"])
+	fmt.Printf("Budget used (daily): $%.4f / $%.2f\n", stats["budget_daily_used"], stats["budget_daily_max"])
+}
+
+// embeddingText builds the text that represents a package for semantic search
+func embeddingText(pkg *db.Package) string {
+	if pkg.Synopsis == "" {
+		return pkg.ImportPath
+	}
+	return pkg.ImportPath + "\n" + pkg.Synopsis
+}
+
+// textHash returns a content hash used to detect stale embeddings
+func textHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
@@ -20,20 +20,40 @@ func main() {
 		packagePath = flag.String("pkg", "", "Package import path")
 		sourceDir   = flag.String("src", "", "Source directory to analyze")
 		dryRun      = flag.Bool("dry-run", false, "Print results without saving to database")
+		estimate    = flag.Bool("estimate", false, "Print the projected cost of generating docs without making any API calls")
 	)
 	flag.Parse()
 
 	if *packagePath == "" || *sourceDir == "" {
-		fmt.Fprintf(os.Stderr, "Usage: gendocs -pkg <import-path> -src <source-dir> [-db <db-path>] [-dry-run]\n")
+		fmt.Fprintf(os.Stderr, "Usage: gendocs -pkg <import-path> -src <source-dir> [-db <db-path>] [-dry-run] [-estimate]\n")
 		os.Exit(1)
 	}
 
+	// Parse source directory
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, *sourceDir, nil, parser.ParseComments)
+	if err != nil {
+		log.Fatalf("Failed to parse directory: %v", err)
+	}
+
+	if len(pkgs) == 0 {
+		log.Fatalf("No Go packages found in %s", *sourceDir)
+	}
+
+	if *estimate {
+		est := estimateCost(fset, pkgs)
+		fmt.Printf("would generate docs for %d symbols, estimated cost $%.2f (%d tokens)\n", est.Requests, est.CostUSD, est.Tokens)
+		return
+	}
+
 	// Initialize AI service
 	service := ai.NewServiceFromEnv()
 	service.SetBudget(5.0, 100.0) // $5/day, $100/month
 
-	// Enable auto-comment feature
-	service.IsEnabled(ai.FlagAutoComments)
+	// Enable auto-comment feature, unless AI_FEATURES says otherwise
+	if err := service.EnableFromEnv(ai.FlagAutoComments); err != nil {
+		log.Fatalf("Invalid AI features: %v", err)
+	}
 
 	// Open database
 	database, err := db.Open(*dbPath)
@@ -42,15 +62,16 @@ func main() {
 	}
 	defer database.Close()
 
-	// Parse source directory
-	fset := token.NewFileSet()
-	pkgs, err := parser.ParseDir(fset, *sourceDir, nil, parser.ParseComments)
-	if err != nil {
-		log.Fatalf("Failed to parse directory: %v", err)
-	}
-
-	if len(pkgs) == 0 {
-		log.Fatalf("No Go packages found in %s", *sourceDir)
+	// spent returns the cost and tokens of the AI requests made since it was last called
+	var lastCost float64
+	var lastTokens int64
+	spent := func() (float64, int) {
+		stats := service.GetStats()
+		cost, _ := stats["total_cost_usd"].(float64)
+		tokens, _ := stats["total_tokens"].(int64)
+		costDelta, tokensDelta := cost-lastCost, tokens-lastTokens
+		lastCost, lastTokens = cost, tokens
+		return costDelta, int(tokensDelta)
 	}
 
 	// Process each package
@@ -77,6 +98,7 @@ func main() {
 			exportedSymbols := analyzer.ExtractExportedSymbols(files)
 
 			synopsis, err := service.GeneratePackageSynopsis(pkgName, exportedSymbols)
+			cost, tokens := spent()
 			if err != nil {
 				log.Printf("Error generating synopsis for package %s: %v", pkgName, err)
 			} else {
@@ -88,6 +110,8 @@ func main() {
 						SymbolKind:   "package",
 						ImportPath:   *packagePath,
 						GeneratedDoc: synopsis,
+						CostUSD:      cost,
+						Tokens:       tokens,
 					}
 					if err := database.UpsertAIDoc(aiDoc); err != nil {
 						log.Printf("Error saving synopsis for %s: %v", pkgName, err)
@@ -111,6 +135,7 @@ func main() {
 			log.Printf("Generating doc for function %s...", fn.Name)
 
 			doc, err := service.GenerateFunctionComment(fn.Signature, fn.Body)
+			cost, tokens := spent()
 			if err != nil {
 				log.Printf("Error generating doc for %s: %v", fn.Name, err)
 				continue
@@ -124,6 +149,8 @@ func main() {
 					SymbolKind:   "func",
 					ImportPath:   *packagePath,
 					GeneratedDoc: doc,
+					CostUSD:      cost,
+					Tokens:       tokens,
 				}
 				if err := database.UpsertAIDoc(aiDoc); err != nil {
 					log.Printf("Error saving doc for %s: %v", fn.Name, err)
@@ -138,6 +165,7 @@ func main() {
 			log.Printf("Generating doc for type %s...", typ.Name)
 
 			doc, err := service.GenerateTypeComment(typ.Name, typ.Body)
+			cost, tokens := spent()
 			if err != nil {
 				log.Printf("Error generating doc for %s: %v", typ.Name, err)
 				continue
@@ -151,6 +179,8 @@ func main() {
 					SymbolKind:   "type",
 					ImportPath:   *packagePath,
 					GeneratedDoc: doc,
+					CostUSD:      cost,
+					Tokens:       tokens,
 				}
 				if err := database.UpsertAIDoc(aiDoc); err != nil {
 					log.Printf("Error saving doc for %s: %v", typ.Name, err)
@@ -165,6 +195,7 @@ func main() {
 			log.Printf("Generating doc for method %s...", method.Name)
 
 			doc, err := service.GenerateMethodComment("", method.Name, method.Signature, method.Body)
+			cost, tokens := spent()
 			if err != nil {
 				log.Printf("Error generating doc for %s: %v", method.Name, err)
 				continue
@@ -178,6 +209,8 @@ func main() {
 					SymbolKind:   "method",
 					ImportPath:   *packagePath,
 					GeneratedDoc: doc,
+					CostUSD:      cost,
+					Tokens:       tokens,
 				}
 				if err := database.UpsertAIDoc(aiDoc); err != nil {
 					log.Printf("Error saving doc for %s: %v", method.Name, err)
@@ -209,3 +242,33 @@ func main() {
 		}
 	}
 }
+
+// estimateCost projects the requests a run would make for the undocumented
+// symbols of pkgs, one per generated doc
+func estimateCost(fset *token.FileSet, pkgs map[string]*ast.Package) ai.CostEstimate {
+	var est ai.CostEstimate
+	for pkgName, pkg := range pkgs {
+		if filepath.Ext(pkgName) == "_test" {
+			continue
+		}
+		var files []*ast.File
+		for _, file := range pkg.Files {
+			files = append(files, file)
+		}
+
+		analyzer := ai.NewDocumentationAnalyzer(fset)
+		if analyzer.FindUncommentedPackage(files) {
+			est.PackageSynopsis(pkgName, analyzer.ExtractExportedSymbols(files))
+		}
+		for _, fn := range analyzer.FindUncommentedFunctions(pkgName, files) {
+			est.FunctionComment(fn.Signature, fn.Body)
+		}
+		for _, typ := range analyzer.FindUncommentedTypes(files) {
+			est.TypeComment(typ.Name, typ.Body)
+		}
+		for _, method := range analyzer.FindUncommentedMethods(files) {
+			est.MethodComment("", method.Name, method.Signature, method.Body)
+		}
+	}
+	return est
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/alexisbouchez/wikigo/db"
+)
+
+func main() {
+	var (
+		dbPath  = flag.String("db", "wikigo.db", "Path to SQLite database")
+		all     = flag.Bool("all", false, "Include resolved feedback")
+		limit   = flag.Int("limit", 50, "Maximum number of entries to list per section")
+		resolve = flag.Int64("resolve", 0, "Mark the feedback with this ID as resolved")
+		unflag  = flag.Int64("unflag", 0, "Clear the flag on the AI doc with this ID once reviewed")
+		orphans = flag.Bool("remove-orphans", false, "Delete AI docs and feedback whose symbol no longer exists")
+	)
+	flag.Parse()
+
+	database, err := db.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	if *resolve != 0 || *unflag != 0 || *orphans {
+		if *resolve != 0 {
+			if err := database.ResolveFeedback(*resolve); err != nil {
+				log.Fatalf("Failed to resolve feedback %d: %v", *resolve, err)
+			}
+			fmt.Printf("Resolved feedback #%d\n", *resolve)
+		}
+		if *unflag != 0 {
+			if err := database.UnflagAIDoc(*unflag); err != nil {
+				log.Fatalf("Failed to unflag AI doc %d: %v", *unflag, err)
+			}
+			fmt.Printf("Cleared flag on AI doc #%d\n", *unflag)
+		}
+		if *orphans {
+			docs, feedback, err := database.DeleteOrphans()
+			if err != nil {
+				log.Fatalf("Failed to remove orphans: %v", err)
+			}
+			fmt.Printf("Removed %d orphaned AI docs and %d orphaned feedback entries\n", docs, feedback)
+		}
+		return
+	}
+
+	feedback, err := database.ListFeedback(*all, *limit)
+	if err != nil {
+		log.Fatalf("Failed to list feedback: %v", err)
+	}
+	flagged, err := database.GetFlaggedAIDocs(*limit)
+	if err != nil {
+		log.Fatalf("Failed to list flagged AI docs: %v", err)
+	}
+	orphaned, err := database.GetOrphanedAIDocs(*limit)
+	if err != nil {
+		log.Fatalf("Failed to list orphaned AI docs: %v", err)
+	}
+
+	fmt.Printf("=== Feedback (%d) ===\n", len(feedback))
+	for _, fb := range feedback {
+		target := fb.ImportPath
+		if fb.Symbol != "" {
+			target += "." + fb.Symbol
+		}
+		var labels string
+		if fb.AIDoc {
+			labels += " [AI doc]"
+		}
+		if fb.Resolved {
+			labels += " [resolved]"
+		}
+		if fb.Orphaned {
+			labels += " [orphaned]"
+		}
+		fmt.Printf("#%d %s%s (%s)\n", fb.ID, target, labels, fb.CreatedAt.Format("2006-01-02"))
+		fmt.Printf("    %s\n", fb.Message)
+	}
+
+	fmt.Printf("\n=== Flagged AI docs (%d) ===\n", len(flagged))
+	for _, doc := range flagged {
+		fmt.Printf("#%d %s %s %s\n", doc.ID, doc.ImportPath, doc.SymbolKind, doc.SymbolName)
+		if doc.FlagReason != "" {
+			fmt.Printf("    Reason: %s\n", doc.FlagReason)
+		}
+		fmt.Printf("    Doc: %s\n", doc.GeneratedDoc)
+	}
+
+	fmt.Printf("\n=== Orphaned AI docs (%d) ===\n", len(orphaned))
+	for _, doc := range orphaned {
+		fmt.Printf("#%d %s %s %s\n", doc.ID, doc.ImportPath, doc.SymbolKind, doc.SymbolName)
+		fmt.Printf("    Doc: %s\n", doc.GeneratedDoc)
+	}
+	if len(orphaned) > 0 {
+		fmt.Println("\nThese symbols were renamed or removed; delete the orphans with -remove-orphans")
+	}
+
+	if len(feedback) == 0 && len(flagged) == 0 && len(orphaned) == 0 {
+		fmt.Fprintln(os.Stderr, "\nNothing to review")
+	}
+}
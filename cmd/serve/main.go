@@ -6,22 +6,69 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/alexisbouchez/wikigo/web"
 )
 
 func main() {
 	addr := flag.String("addr", ":8080", "HTTP server address")
+	synopsisLen := flag.Int("synopsis-length", 160, "Characters of synopsis shown in search results and package cards (0 for no limit)")
+	maxSymbols := flag.Int("max-symbols", 100, "Symbols rendered per section of a package page; the rest load on demand with \"Show all\" (0 for no limit)")
+	adminToken := flag.String("admin-token", os.Getenv("WIKIGO_ADMIN_TOKEN"), "Token for the /admin pages, given as a bearer token or basic auth password (default $WIKIGO_ADMIN_TOKEN; admin pages are disabled without one)")
+	socket := flag.String("socket", "", "Listen on this Unix socket instead of -addr (e.g. behind a reverse proxy on the same host)")
 	dataDir := flag.String("data", ".", "Directory containing JSON documentation files")
 	dbPath := flag.String("db", "", "SQLite database path (enables indexing features)")
+	loadWorkers := flag.Int("load-workers", 0, "Concurrent JSON parsers at startup (default: number of CPUs)")
+	readOnly := flag.Bool("db-readonly", false, "Open the database read-only (e.g. a replica synced from the crawler's database)")
+	dbOnly := flag.Bool("db-only", false, "Serve only packages from the database, without loading JSON files")
+	slimJSON := flag.Bool("slim-json", false, "Leave license text, go.mod and embedded sources out of package JSON unless requested with ?fields=")
+	staticGzip := flag.Bool("static-gzip", true, "Serve CSS, JS and other static text assets gzip-compressed to clients that accept it")
+	readHeaderTimeout := flag.Duration("read-header-timeout", 10*time.Second, "Time allowed to read request headers (0 for no limit)")
+	readTimeout := flag.Duration("read-timeout", 30*time.Second, "Time allowed to read a whole request, body included (0 for no limit)")
+	writeTimeout := flag.Duration("write-timeout", 2*time.Minute, "Time allowed to write a response, or each batch of streamed search results (0 for no limit)")
+	idleTimeout := flag.Duration("idle-timeout", 2*time.Minute, "Time a keep-alive connection may wait for its next request (0 for no limit)")
 	flag.Parse()
 
-	if _, err := os.Stat(*dataDir); os.IsNotExist(err) {
+	if *synopsisLen == 0 {
+		*synopsisLen = -1
+	}
+	if *maxSymbols == 0 {
+		*maxSymbols = -1
+	}
+	// Zero means no limit on the command line but the default in web.Timeouts
+	timeouts := web.Timeouts{ReadHeader: *readHeaderTimeout, Read: *readTimeout, Write: *writeTimeout, Idle: *idleTimeout}
+	for _, d := range []*time.Duration{&timeouts.ReadHeader, &timeouts.Read, &timeouts.Write, &timeouts.Idle} {
+		if *d == 0 {
+			*d = -1
+		}
+	}
+	if *dbOnly {
+		*dataDir = ""
+	}
+	if *dataDir == "" && *dbPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: serving without a data directory requires -db")
+		os.Exit(1)
+	}
+	if _, err := os.Stat(*dataDir); *dataDir != "" && os.IsNotExist(err) {
 		fmt.Fprintf(os.Stderr, "Error: data directory %q does not exist\n", *dataDir)
 		os.Exit(1)
 	}
 
-	server, err := web.NewServerWithDB(*dataDir, *dbPath)
+	server, err := web.NewServerWithOptions(web.Options{
+		DataDir:     *dataDir,
+		DBPath:      *dbPath,
+		LoadWorkers: *loadWorkers,
+		ReadOnlyDB:  *readOnly,
+		SlimJSON:    *slimJSON,
+		Socket:      *socket,
+		SynopsisLen: *synopsisLen,
+		MaxSymbols:  *maxSymbols,
+		AdminToken:  *adminToken,
+
+		NoStaticGzip: !*staticGzip,
+		Timeouts:     timeouts,
+	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating server: %v\n", err)
 		os.Exit(1)
@@ -35,14 +82,29 @@ func main() {
 		<-sigChan
 		fmt.Println("\nShutting down...")
 		server.Close()
+		if *socket != "" {
+			os.Remove(*socket)
+		}
 		os.Exit(0)
 	}()
 
-	fmt.Printf("Starting wikigo server at http://localhost%s\n", *addr)
-	fmt.Printf("Data directory: %s\n", *dataDir)
+	if *socket != "" {
+		fmt.Printf("Starting wikigo server on unix socket %s\n", *socket)
+	} else {
+		fmt.Printf("Starting wikigo server at http://localhost%s\n", *addr)
+	}
+	if *dataDir != "" {
+		fmt.Printf("Data directory: %s\n", *dataDir)
+	} else {
+		fmt.Println("Data directory: none (serving from the database only)")
+	}
 	if *dbPath != "" {
 		pkgCount, symCount, impCount := server.GetDBStats()
-		fmt.Printf("Database: %s (%d packages, %d symbols, %d imports)\n", *dbPath, pkgCount, symCount, impCount)
+		mode := ""
+		if *readOnly {
+			mode = ", read-only"
+		}
+		fmt.Printf("Database: %s (%d packages, %d symbols, %d imports%s)\n", *dbPath, pkgCount, symCount, impCount, mode)
 	}
 
 	if err := server.ListenAndServe(*addr); err != nil {
@@ -52,8 +52,7 @@ func main() {
 			return err
 		}
 		if !info.IsDir() {
-			ext := filepath.Ext(path)
-			if ext == ".js" || ext == ".ts" || ext == ".jsx" || ext == ".tsx" {
+			if jsparser.HasExtension(path, jsparser.DefaultExtensions) {
 				relPath, _ := filepath.Rel(pkgDir, path)
 				fmt.Printf("  %s\n", relPath)
 			}
@@ -64,14 +63,14 @@ func main() {
 		log.Printf("Error walking directory: %v", err)
 	}
 
-	// Parse one JS file manually to test
+	// Parse one JS/TS file manually to test
 	parser := jsparser.NewParser()
 	var testFile string
 	err = filepath.Walk(pkgDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() && filepath.Ext(path) == ".js" {
+		if !info.IsDir() && jsparser.HasExtension(path, jsparser.DefaultExtensions) {
 			testFile = path
 			return filepath.SkipAll
 		}
@@ -0,0 +1,102 @@
+package crawler
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexisbouchez/wikigo/db"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+// moduleChecksum returns the go.sum hash ("h1:...") of a module zip, the one
+// the go command computes when it downloads the module
+func moduleChecksum(zr *zip.Reader) (string, error) {
+	files := make([]string, 0, len(zr.File))
+	byName := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files = append(files, f.Name)
+		byName[f.Name] = f
+	}
+	return dirhash.Hash1(files, func(name string) (io.ReadCloser, error) {
+		f := byName[name]
+		if f == nil {
+			return nil, fmt.Errorf("file %q not found in zip", name)
+		}
+		return f.Open()
+	})
+}
+
+// fetchZipHash returns the checksum the proxy reports in @v/<version>.ziphash,
+// or "" when it has none. The file belongs to the module cache layout, which
+// file:// and cache-backed proxies serve as is.
+func (c *Crawler) fetchZipHash(ctx context.Context, mv ModuleVersion) string {
+	url := fmt.Sprintf("%s/%s/@v/%s.ziphash", c.proxyURL, escapeModulePath(mv.Path), mv.Version)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return ""
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return ""
+	}
+	if sum := strings.TrimSpace(string(data)); strings.HasPrefix(sum, "h1:") {
+		return sum
+	}
+	return ""
+}
+
+// goSumModules counts the module versions whose zip checksums the go.sum in
+// moduleDir pins, 0 without one
+func goSumModules(moduleDir string) int {
+	f, err := os.Open(filepath.Join(moduleDir, "go.sum"))
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	pinned := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// "<module> <version> h1:<hash>"; "<version>/go.mod" lines only pin the go.mod
+		if len(fields) != 3 || strings.HasSuffix(fields[1], "/go.mod") || !strings.HasPrefix(fields[2], "h1:") {
+			continue
+		}
+		pinned[fields[0]+"@"+fields[1]] = true
+	}
+	return len(pinned)
+}
+
+// recordChecksum stores the checksum computed for a crawled module version,
+// with the proxy's when it serves one, and warns when they differ
+func (c *Crawler) recordChecksum(ctx context.Context, mv ModuleVersion, checksum, moduleDir string) {
+	dbVersion := &db.ModuleVersion{
+		ModulePath:    mv.Path,
+		Version:       mv.Version,
+		Checksum:      checksum,
+		ProxyChecksum: c.fetchZipHash(ctx, mv),
+		GoSumModules:  goSumModules(moduleDir),
+	}
+	if dbVersion.ChecksumMismatch() {
+		log.Printf("Warning: %s@%s hashes to %s but the proxy reports %s", mv.Path, mv.Version, checksum, dbVersion.ProxyChecksum)
+	}
+	if err := c.db.SetModuleChecksum(dbVersion); err != nil {
+		log.Printf("Warning: failed to record checksum of %s@%s: %v", mv.Path, mv.Version, err)
+	}
+}
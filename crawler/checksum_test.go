@@ -0,0 +1,86 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+func TestRun_Checksums(t *testing.T) {
+	versions := []ModuleVersion{
+		{Path: "example.com/greet", Version: "v1.0.0", Timestamp: time.Now()},
+		{Path: "example.com/greet", Version: "v1.1.0", Timestamp: time.Now()},
+	}
+
+	// The go command's own hash of the v1.0.0 zip, as a module cache would hold it
+	zipFile := filepath.Join(t.TempDir(), "greet.zip")
+	if err := os.WriteFile(zipFile, moduleZip(t, versions[0]), 0644); err != nil {
+		t.Fatal(err)
+	}
+	want, err := dirhash.HashZip(zipFile, dirhash.Hash1)
+	if err != nil {
+		t.Fatalf("HashZip() error = %v", err)
+	}
+
+	// A proxy serving v1.0.0's ziphash, a tampered one for v1.1.0
+	upstream := fakeProxy(t, versions)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/@v/v1.0.0.ziphash"):
+			w.Write([]byte(want + "\n"))
+		case strings.HasSuffix(r.URL.Path, "/@v/v1.1.0.ziphash"):
+			w.Write([]byte("h1:tampered="))
+		default:
+			upstream.Config.Handler.ServeHTTP(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := New(Config{
+		DBPath:    filepath.Join(t.TempDir(), "test.db"),
+		Workers:   1,
+		RateLimit: time.Millisecond,
+		TempDir:   t.TempDir(),
+		ProxyURL:  srv.URL,
+		IndexURL:  srv.URL + "/index",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := c.Run(ctx, time.Time{}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	verified, err := c.GetDB().GetModuleVersion("example.com/greet", "v1.0.0")
+	if err != nil || verified == nil {
+		t.Fatalf("GetModuleVersion(v1.0.0) = %v, %v", verified, err)
+	}
+	if verified.Checksum != want || verified.ProxyChecksum != want || verified.ChecksumMismatch() {
+		t.Errorf("v1.0.0 checksum = %q, proxy %q, want both %q", verified.Checksum, verified.ProxyChecksum, want)
+	}
+	if verified.GoSumModules != 0 {
+		t.Errorf("v1.0.0 go.sum modules = %d, want 0 without a go.sum", verified.GoSumModules)
+	}
+
+	tampered, err := c.GetDB().GetModuleVersion("example.com/greet", "v1.1.0")
+	if err != nil || tampered == nil {
+		t.Fatalf("GetModuleVersion(v1.1.0) = %v, %v", tampered, err)
+	}
+	if !strings.HasPrefix(tampered.Checksum, "h1:") || !tampered.ChecksumMismatch() {
+		t.Errorf("v1.1.0 checksum = %q, proxy %q, want a mismatch", tampered.Checksum, tampered.ProxyChecksum)
+	}
+	if tampered.GoSumModules != 1 {
+		t.Errorf("v1.1.0 go.sum modules = %d, want 1", tampered.GoSumModules)
+	}
+}
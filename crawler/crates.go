@@ -2,6 +2,7 @@ package crawler
 
 import (
 	"archive/tar"
+	"cmp"
 	"compress/gzip"
 	"encoding/json"
 	"fmt"
@@ -10,6 +11,8 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -32,6 +35,8 @@ type CrateMetadata struct {
 		MaxVersion   string    `json:"max_version"`
 		Downloads    int       `json:"downloads"`
 		RecentDownloads int    `json:"recent_downloads"`
+		Keywords     []string  `json:"keywords"`
+		Categories   []string  `json:"categories"`
 		CreatedAt    time.Time `json:"created_at"`
 		UpdatedAt    time.Time `json:"updated_at"`
 	} `json:"crate"`
@@ -41,10 +46,116 @@ type CrateMetadata struct {
 		Downloads  int       `json:"downloads"`
 		Yanked     bool      `json:"yanked"`
 		License    string    `json:"license"`
+		Features   map[string][]string `json:"features"`
 		CreatedAt  time.Time `json:"created_at"`
 	} `json:"versions"`
 }
 
+// releases returns every published version of the crate
+func (m *CrateMetadata) releases() []*db.RegistryVersion {
+	versions := make([]*db.RegistryVersion, 0, len(m.Versions))
+	for _, v := range m.Versions {
+		versions = append(versions, &db.RegistryVersion{
+			Version:     v.Num,
+			PublishedAt: v.CreatedAt,
+			Yanked:      v.Yanked,
+		})
+	}
+	return versions
+}
+
+// latestRelease returns the highest version that has not been yanked,
+// preferring releases to pre-releases as the registries' own "latest" does
+func latestRelease(versions []*db.RegistryVersion) *db.RegistryVersion {
+	var latest *db.RegistryVersion
+	for _, v := range versions {
+		if v.Yanked {
+			continue
+		}
+		if latest == nil || newerRelease(v.Version, latest.Version) {
+			latest = v
+		}
+	}
+	return latest
+}
+
+// newerRelease reports whether version a should be picked over b: a release
+// beats a pre-release, otherwise the higher version wins. Publication dates
+// are no guide, since backports to older lines are published after newer ones.
+func newerRelease(a, b string) bool {
+	if preA, preB := isPreRelease(a), isPreRelease(b); preA != preB {
+		return preB
+	}
+	return compareReleaseVersions(a, b) > 0
+}
+
+// compareReleaseVersions orders semver and PEP 440 version strings: by their
+// numeric release segments, then a pre-release ("-rc.1", "b2", ".dev1")
+// before the release and a post-release (".post1") after it. Build metadata
+// and local versions ("+...") are ignored.
+func compareReleaseVersions(a, b string) int {
+	numsA, suffixA := splitReleaseVersion(a)
+	numsB, suffixB := splitReleaseVersion(b)
+	for i := 0; i < max(len(numsA), len(numsB)); i++ {
+		var x, y int
+		if i < len(numsA) {
+			x = numsA[i]
+		}
+		if i < len(numsB) {
+			y = numsB[i]
+		}
+		if c := cmp.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+	return cmp.Or(
+		cmp.Compare(releaseSuffixRank(suffixA), releaseSuffixRank(suffixB)),
+		strings.Compare(suffixA, suffixB),
+	)
+}
+
+// splitReleaseVersion splits "1.40.0-rc.1" into [1 40 0] and "-rc.1"
+func splitReleaseVersion(v string) ([]int, string) {
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+	var nums []int
+	for {
+		end := 0
+		for end < len(v) && v[end] >= '0' && v[end] <= '9' {
+			end++
+		}
+		if end == 0 {
+			return nums, v
+		}
+		n, _ := strconv.Atoi(v[:end])
+		nums = append(nums, n)
+		v = v[end:]
+		if len(v) < 2 || v[0] != '.' || v[1] < '0' || v[1] > '9' {
+			return nums, v
+		}
+		v = v[1:]
+	}
+}
+
+// releaseSuffixRank orders what follows a version's release segments:
+// pre-releases first, then the release itself, then post-releases
+func releaseSuffixRank(suffix string) int {
+	switch {
+	case suffix == "":
+		return 0
+	case strings.HasPrefix(strings.TrimLeft(suffix, ".-_"), "post"):
+		return 1
+	}
+	return -1
+}
+
+func isPreRelease(v string) bool {
+	_, suffix := splitReleaseVersion(v)
+	return releaseSuffixRank(suffix) < 0
+}
+
 // CargoToml represents a simplified Cargo.toml
 type CargoToml struct {
 	Package struct {
@@ -69,6 +180,12 @@ type CratesCrawler struct {
 	parser    *rsparser.Parser
 	tempDir   string
 	rateLimit time.Duration
+
+	// MinAge skips crates indexed more recently than this (0 = always fetch)
+	MinAge time.Duration
+
+	// MinDownloads skips crates with fewer downloads in the last 90 days (0 = no minimum)
+	MinDownloads int
 }
 
 // NewCratesCrawler creates a new crates.io crawler
@@ -217,8 +334,56 @@ func (c *CratesCrawler) ParseCrateSymbols(crateDir string) ([]rsparser.Symbol, e
 	return symbols, nil
 }
 
+// detectPlatformSupport reports whether a crate declares no_std or WASM support,
+// from its crates.io categories and keywords or, failing that, the #![no_std]
+// attribute of its library root and the WASM dependencies and targets in Cargo.toml
+func detectPlatformSupport(crateDir string, keywords, categories []string) (noStd, wasm bool) {
+	for _, category := range categories {
+		switch {
+		case category == "no-std" || strings.HasPrefix(category, "no-std::"):
+			noStd = true
+		case category == "wasm":
+			wasm = true
+		}
+	}
+	for _, keyword := range keywords {
+		switch strings.ToLower(keyword) {
+		case "no_std", "no-std", "nostd":
+			noStd = true
+		case "wasm", "wasm32", "webassembly":
+			wasm = true
+		}
+	}
+
+	if !noStd {
+		if data, err := os.ReadFile(filepath.Join(crateDir, "src", "lib.rs")); err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				// Either #![no_std] or #![cfg_attr(not(feature = "std"), no_std)]
+				line = strings.TrimSpace(line)
+				if strings.HasPrefix(line, "#![") && strings.Contains(line, "no_std") {
+					noStd = true
+					break
+				}
+			}
+		}
+	}
+	if !wasm {
+		if data, err := os.ReadFile(filepath.Join(crateDir, "Cargo.toml")); err == nil {
+			manifest := string(data)
+			wasm = strings.Contains(manifest, "wasm-bindgen") || strings.Contains(manifest, "wasm32")
+		}
+	}
+	return noStd, wasm
+}
+
 // IndexCrate indexes a crate into the database
 func (c *CratesCrawler) IndexCrate(name string) error {
+	if c.db != nil && c.MinAge > 0 {
+		if existing, err := c.db.GetRustCrate(name); err == nil && existing != nil && indexedWithin(existing.IndexedAt, c.MinAge) {
+			return ErrRecentlyIndexed
+		}
+	}
+
 	log.Printf("Indexing crate: %s", name)
 
 	// Fetch metadata
@@ -226,23 +391,34 @@ func (c *CratesCrawler) IndexCrate(name string) error {
 	if err != nil {
 		return fmt.Errorf("fetching crate: %w", err)
 	}
+	if err := checkPopularity(metadata.Crate.RecentDownloads, c.MinDownloads, "downloads in the last 90 days"); err != nil {
+		return err
+	}
 
 	// Get latest non-yanked version
-	var latestVersion string
+	releases := metadata.releases()
+	latest := latestRelease(releases)
+	if latest == nil {
+		return fmt.Errorf("no non-yanked versions found")
+	}
+	latestVersion := latest.Version
+	maxYanked := slices.ContainsFunc(releases, func(v *db.RegistryVersion) bool {
+		return v.Version == metadata.Crate.MaxVersion && v.Yanked
+	})
+	if maxYanked && latestVersion != metadata.Crate.MaxVersion {
+		log.Printf("Latest version %s of %s is yanked, using %s", metadata.Crate.MaxVersion, name, latestVersion)
+	}
+
 	var license string
+	var features map[string][]string
 	for _, v := range metadata.Versions {
-		if !v.Yanked {
-			if latestVersion == "" {
-				latestVersion = v.Num
-				license = v.License
-			}
+		if v.Num == latestVersion {
+			license = v.License
+			features = v.Features
+			break
 		}
 	}
 
-	if latestVersion == "" {
-		return fmt.Errorf("no non-yanked versions found")
-	}
-
 	// Download and extract
 	crateDir, err := c.DownloadCrate(name, latestVersion)
 	if err != nil {
@@ -269,13 +445,21 @@ func (c *CratesCrawler) IndexCrate(name string) error {
 			Homepage:      metadata.Crate.Homepage,
 			Documentation: metadata.Crate.Documentation,
 			Downloads:     metadata.Crate.Downloads,
+			Keywords:      metadata.Crate.Keywords,
+			Categories:    metadata.Crate.Categories,
+			Features:      features,
 		}
+		dbCrate.NoStd, dbCrate.WASM = detectPlatformSupport(crateDir, metadata.Crate.Keywords, metadata.Crate.Categories)
 
 		crateID, err := c.db.UpsertRustCrate(dbCrate)
 		if err != nil {
 			return fmt.Errorf("storing crate: %w", err)
 		}
 
+		if err := c.db.ReplaceRegistryVersions(db.EcosystemCrates, metadata.Crate.Name, metadata.releases()); err != nil {
+			return fmt.Errorf("storing versions: %w", err)
+		}
+
 		// Delete old symbols
 		if err := c.db.DeleteRustCrateSymbols(crateID); err != nil {
 			return fmt.Errorf("deleting old symbols: %w", err)
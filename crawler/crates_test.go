@@ -1,6 +1,9 @@
 package crawler
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -83,3 +86,163 @@ func TestDownloadCrate(t *testing.T) {
 		t.Error("Expected directory path to be returned")
 	}
 }
+
+func TestCrateMetadata_Releases(t *testing.T) {
+	data := `{
+		"crate": {"name": "serde"},
+		"versions": [
+			{"num": "1.0.200", "created_at": "2024-05-01T12:00:00Z"},
+			{"num": "1.0.0", "created_at": "2017-04-20T08:00:00Z"}
+		]
+	}`
+
+	var metadata CrateMetadata
+	if err := json.Unmarshal([]byte(data), &metadata); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	releases := metadata.releases()
+	if len(releases) != 2 {
+		t.Fatalf("Expected 2 releases, got %d", len(releases))
+	}
+	if releases[1].Version != "1.0.0" || releases[1].PublishedAt.Year() != 2017 {
+		t.Errorf("Unexpected release: %+v", releases[1])
+	}
+}
+
+func TestLatestRelease_SkipsYanked(t *testing.T) {
+	data := `{
+		"crate": {"name": "example", "max_version": "2.0.0"},
+		"versions": [
+			{"num": "2.0.0", "yanked": true, "created_at": "2024-03-01T00:00:00Z"},
+			{"num": "1.9.0", "created_at": "2024-02-01T00:00:00Z"},
+			{"num": "1.8.0", "created_at": "2024-01-01T00:00:00Z"}
+		]
+	}`
+
+	var metadata CrateMetadata
+	if err := json.Unmarshal([]byte(data), &metadata); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	latest := latestRelease(metadata.releases())
+	if latest == nil || latest.Version != "1.9.0" {
+		t.Fatalf("Expected latest release 1.9.0, got %+v", latest)
+	}
+
+	if latestRelease(metadata.releases()[:1]) != nil {
+		t.Error("Expected no latest release when every version is yanked")
+	}
+}
+
+func TestLatestRelease_HighestVersion(t *testing.T) {
+	// A backport to 1.38 published after 1.40.0, and a 1.41 release candidate
+	data := `{
+		"crate": {"name": "tokio", "max_version": "1.40.0"},
+		"versions": [
+			{"num": "1.41.0-rc.1", "created_at": "2024-10-01T00:00:00Z"},
+			{"num": "1.38.1", "created_at": "2024-09-15T00:00:00Z"},
+			{"num": "1.40.0", "created_at": "2024-08-30T00:00:00Z"},
+			{"num": "1.39.3", "created_at": "2024-08-17T00:00:00Z"}
+		]
+	}`
+
+	var metadata CrateMetadata
+	if err := json.Unmarshal([]byte(data), &metadata); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	latest := latestRelease(metadata.releases())
+	if latest == nil || latest.Version != "1.40.0" {
+		t.Fatalf("Expected latest release 1.40.0, got %+v", latest)
+	}
+
+	// Only pre-releases left
+	latest = latestRelease(metadata.releases()[:1])
+	if latest == nil || latest.Version != "1.41.0-rc.1" {
+		t.Errorf("Expected latest release 1.41.0-rc.1, got %+v", latest)
+	}
+}
+
+func TestCompareReleaseVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.40.0", "1.38.1", 1},
+		{"1.9.0", "1.10.0", -1},
+		{"2.0", "2.0.0", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0.0+build.5", "1.0.0", 0},
+		{"2.0.0rc1", "2.0.0", -1},
+		{"2.0.0b2", "2.0.0rc1", -1},
+		{"1.0.dev1", "1.0a1", -1},
+		{"1.0.post1", "1.0", 1},
+		{"1.0.post1", "1.0.1", -1},
+	}
+	for _, tt := range tests {
+		if got := compareReleaseVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareReleaseVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := compareReleaseVersions(tt.b, tt.a); got != -tt.want {
+			t.Errorf("compareReleaseVersions(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestDetectPlatformSupport(t *testing.T) {
+	tests := []struct {
+		name       string
+		keywords   []string
+		categories []string
+		files      map[string]string
+		wantNoStd  bool
+		wantWASM   bool
+	}{
+		{name: "no hints"},
+		{name: "no-std category", categories: []string{"no-std::no-alloc"}, wantNoStd: true},
+		{name: "wasm category", categories: []string{"wasm"}, wantWASM: true},
+		{name: "keywords", keywords: []string{"No_Std", "WebAssembly"}, wantNoStd: true, wantWASM: true},
+		{
+			name:      "no_std attribute",
+			files:     map[string]string{"src/lib.rs": "//! A crate\n#![no_std]\n\npub fn f() {}\n"},
+			wantNoStd: true,
+		},
+		{
+			name:      "conditional no_std attribute",
+			files:     map[string]string{"src/lib.rs": "#![cfg_attr(not(feature = \"std\"), no_std)]\n"},
+			wantNoStd: true,
+		},
+		{
+			name:  "no_std in a comment",
+			files: map[string]string{"src/lib.rs": "// Not no_std yet\npub fn f() {}\n"},
+		},
+		{
+			name:     "wasm-bindgen dependency",
+			files:    map[string]string{"Cargo.toml": "[target.'cfg(target_arch = \"wasm32\")'.dependencies]\nwasm-bindgen = \"0.2\"\n"},
+			wantWASM: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(dir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			noStd, wasm := detectPlatformSupport(dir, tt.keywords, tt.categories)
+			if noStd != tt.wantNoStd || wasm != tt.wantWASM {
+				t.Errorf("detectPlatformSupport() = %v, %v, want %v, %v", noStd, wasm, tt.wantNoStd, tt.wantWASM)
+			}
+		})
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"archive/zip"
 	"bufio"
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/doc"
@@ -14,10 +16,12 @@ import (
 	"go/token"
 	"io"
 	"log"
+	"maps"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -41,8 +45,22 @@ type Crawler struct {
 	stats      Stats
 	statsMu    sync.Mutex
 	maxModules int // 0 = unlimited
+	proxyURL   string
+	indexURL   string
+
+	extraLicenseFiles []string
+	excludeDirs       []string
+	maxModuleSize     int64       // bytes of a module zip read into memory
+	diskBudget        *diskBudget // nil when extraction size is unlimited
+	synopsisGenerator SynopsisGenerator
+	maxAttempts       int           // tries of a proxy or index request, see do
+	retryDelay        time.Duration // wait before the first retry
 }
 
+// DefaultExcludeDirs names the directories of demo code that are not indexed as
+// packages unless Config.ExcludeDirs says otherwise
+var DefaultExcludeDirs = []string{"examples", "example", "_examples"}
+
 // Stats tracks crawling statistics
 type Stats struct {
 	ModulesProcessed int
@@ -67,6 +85,46 @@ type Config struct {
 	Since      time.Time
 	MaxModules int
 	TempDir    string
+
+	// ProxyURL and IndexURL default to proxy.golang.org and index.golang.org
+	ProxyURL string
+	IndexURL string
+
+	// ExtraLicenseFiles names license files to look for besides LICENSE and COPYING variants
+	ExtraLicenseFiles []string
+
+	// ExcludeDirs names directories that are skipped, with everything below them,
+	// when finding a module's packages. Nil means DefaultExcludeDirs; an empty
+	// slice indexes every directory.
+	ExcludeDirs []string
+
+	// MaxModuleSize caps the size of a module zip, which is held in memory
+	// while it is extracted (default 100 MB)
+	MaxModuleSize int64
+
+	// MaxExtractSize caps the bytes of modules extracted to TempDir at once
+	// across workers, which wait for space beyond it (0 = unlimited)
+	MaxExtractSize int64
+
+	// StaleTempAge is the age past which temporary directories left by
+	// crawlers that were killed are removed on startup (default
+	// DefaultStaleTempAge, negative to keep them)
+	StaleTempAge time.Duration
+
+	// SynopsisGenerator writes synopses for packages with neither a doc
+	// comment nor a README to take one from (optional)
+	SynopsisGenerator SynopsisGenerator
+
+	// MaxAttempts is how many times a request to the module proxy or index
+	// is made when it fails on the network, with 429 Too Many Requests or
+	// with a 5xx status (default DefaultMaxAttempts, 1 to never retry)
+	MaxAttempts int
+}
+
+// SynopsisGenerator generates the synopsis of a package from its exported
+// symbols; *ai.Service implements it
+type SynopsisGenerator interface {
+	GeneratePackageSynopsis(packageName string, exportedSymbols []string) (string, error)
 }
 
 // New creates a new crawler
@@ -85,6 +143,33 @@ func New(cfg Config) (*Crawler, error) {
 	if cfg.TempDir == "" {
 		cfg.TempDir = os.TempDir()
 	}
+	if cfg.ProxyURL == "" {
+		cfg.ProxyURL = ProxyURL
+	}
+	if cfg.IndexURL == "" {
+		cfg.IndexURL = IndexURL
+	}
+	if cfg.ExcludeDirs == nil {
+		cfg.ExcludeDirs = DefaultExcludeDirs
+	}
+	if cfg.MaxModuleSize <= 0 {
+		cfg.MaxModuleSize = 100 * 1024 * 1024
+	}
+	if cfg.StaleTempAge == 0 {
+		cfg.StaleTempAge = DefaultStaleTempAge
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	if cfg.StaleTempAge > 0 {
+		removed, err := cleanStaleTempDirs(cfg.TempDir, cfg.StaleTempAge)
+		if err != nil {
+			log.Printf("Warning: failed to clean up stale temp dirs: %v", err)
+		}
+		if removed > 0 {
+			log.Printf("Removed %d stale temp dirs from %s", removed, cfg.TempDir)
+		}
+	}
 
 	return &Crawler{
 		db:         database,
@@ -93,6 +178,16 @@ func New(cfg Config) (*Crawler, error) {
 		rateLimit:  cfg.RateLimit,
 		tempDir:    cfg.TempDir,
 		maxModules: cfg.MaxModules,
+		proxyURL:   strings.TrimSuffix(cfg.ProxyURL, "/"),
+		indexURL:   cfg.IndexURL,
+
+		extraLicenseFiles: cfg.ExtraLicenseFiles,
+		excludeDirs:       cfg.ExcludeDirs,
+		maxModuleSize:     cfg.MaxModuleSize,
+		diskBudget:        newDiskBudget(cfg.MaxExtractSize),
+		synopsisGenerator: cfg.SynopsisGenerator,
+		maxAttempts:       cfg.MaxAttempts,
+		retryDelay:        defaultRetryDelay,
 	}, nil
 }
 
@@ -103,6 +198,49 @@ func (c *Crawler) Close() error {
 
 // Run starts the crawling process
 func (c *Crawler) Run(ctx context.Context, since time.Time) error {
+	c.process(ctx, func(modules chan<- ModuleVersion) {
+		if err := c.fetchIndex(ctx, since, modules); err != nil {
+			log.Printf("Error fetching index: %v", err)
+		}
+	})
+
+	// Save crawl time to database
+	if err := c.db.SetLastCrawlTime(time.Now()); err != nil {
+		log.Printf("Warning: failed to save crawl time: %v", err)
+	}
+
+	return nil
+}
+
+// RetryFailed re-processes only the module versions in the crawl error log.
+// Those that index cleanly leave the log; those failing again are logged anew.
+func (c *Crawler) RetryFailed(ctx context.Context) error {
+	failed, err := c.db.FailedModuleVersions()
+	if err != nil {
+		return err
+	}
+	log.Printf("Retrying %d failed module versions", len(failed))
+
+	c.process(ctx, func(modules chan<- ModuleVersion) {
+		for _, ce := range failed {
+			mv := ModuleVersion{Path: ce.ModulePath, Version: ce.Version}
+			// Keep the publication time recorded by the failed attempt
+			if v, err := c.db.GetModuleVersion(mv.Path, mv.Version); err == nil && v != nil {
+				mv.Timestamp = v.Timestamp
+			}
+			select {
+			case modules <- mv:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	return ctx.Err()
+}
+
+// process runs the workers over the module versions sent by feed and prints the stats
+func (c *Crawler) process(ctx context.Context, feed func(modules chan<- ModuleVersion)) {
 	c.stats.StartTime = time.Now()
 
 	log.Printf("Starting crawler with %d workers, rate limit %v", c.workers, c.rateLimit)
@@ -120,12 +258,9 @@ func (c *Crawler) Run(ctx context.Context, since time.Time) error {
 		}(i)
 	}
 
-	// Fetch module index
 	go func() {
 		defer close(modules)
-		if err := c.fetchIndex(ctx, since, modules); err != nil {
-			log.Printf("Error fetching index: %v", err)
-		}
+		feed(modules)
 	}()
 
 	// Wait for workers to finish
@@ -133,13 +268,6 @@ func (c *Crawler) Run(ctx context.Context, since time.Time) error {
 
 	// Print final stats
 	c.printStats()
-
-	// Save crawl time to database
-	if err := c.db.SetLastCrawlTime(time.Now()); err != nil {
-		log.Printf("Warning: failed to save crawl time: %v", err)
-	}
-
-	return nil
 }
 
 // RunWithSchedule runs the crawler on a schedule
@@ -200,9 +328,9 @@ func (c *Crawler) GetDB() *db.DB {
 
 // fetchIndex fetches the module index from index.golang.org
 func (c *Crawler) fetchIndex(ctx context.Context, since time.Time, modules chan<- ModuleVersion) error {
-	url := IndexURL
+	url := c.indexURL
 	if !since.IsZero() {
-		url = fmt.Sprintf("%s?since=%s", IndexURL, since.Format(time.RFC3339))
+		url = fmt.Sprintf("%s?since=%s", c.indexURL, since.Format(time.RFC3339))
 	}
 
 	log.Printf("Fetching index from %s", url)
@@ -212,7 +340,7 @@ func (c *Crawler) fetchIndex(ctx context.Context, since time.Time, modules chan<
 		return err
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
@@ -295,101 +423,137 @@ func (c *Crawler) worker(ctx context.Context, id int, modules <-chan ModuleVersi
 
 		if err := c.processModule(ctx, mv); err != nil {
 			log.Printf("[Worker %d] Failed %s@%s: %v", id, mv.Path, mv.Version, err)
-			c.recordFailure()
+			c.recordFailure(mv, err)
 		} else {
 			log.Printf("[Worker %d] Indexed %s@%s", id, mv.Path, mv.Version)
-			c.recordSuccess()
+			c.recordSuccess(mv)
 		}
 	}
 }
 
 // ProcessModulePublic is a public wrapper for processModule
 func (c *Crawler) ProcessModulePublic(ctx context.Context, mv ModuleVersion) error {
-	return c.processModule(ctx, mv)
+	err := c.processModule(ctx, mv)
+	if err != nil {
+		c.recordFailure(mv, err)
+	} else {
+		c.recordSuccess(mv)
+	}
+	return err
 }
 
 // processModule fetches and indexes a single module
 func (c *Crawler) processModule(ctx context.Context, mv ModuleVersion) error {
-	c.statsMu.Lock()
-	c.stats.ModulesProcessed++
-	c.statsMu.Unlock()
-
-	// Record version in version history
-	dbVersion := &db.ModuleVersion{
-		ModulePath: mv.Path,
-		Version:    mv.Version,
-		Timestamp:  mv.Timestamp,
-		IsTagged:   isTaggedVersion(mv.Version),
-		IsStable:   isStableVersion(mv.Version),
-	}
-	if err := c.db.UpsertModuleVersion(dbVersion); err != nil {
-		log.Printf("Warning: failed to record version %s@%s: %v", mv.Path, mv.Version, err)
-	}
+	c.recordModuleVersion(mv)
 
 	// Create temp directory for this module
-	tempDir, err := os.MkdirTemp(c.tempDir, "wikigo-*")
+	tempDir, err := os.MkdirTemp(c.tempDir, tempDirPrefix+"*")
 	if err != nil {
 		return fmt.Errorf("creating temp dir: %w", err)
 	}
 	defer os.RemoveAll(tempDir)
 
-	// Download and extract module
-	if err := c.downloadModule(ctx, mv, tempDir); err != nil {
+	// Download and extract module; its disk space is held until indexing is done
+	checksum, release, err := c.downloadModule(ctx, mv, tempDir)
+	if err != nil {
 		return fmt.Errorf("downloading module: %w", err)
 	}
+	defer release()
 
 	// Find the module root directory (contains go.mod)
 	moduleDir, err := findModuleRoot(tempDir)
 	if err != nil {
 		return fmt.Errorf("finding module root: %w", err)
 	}
+	c.recordChecksum(ctx, mv, checksum, moduleDir)
 
 	// Extract and index packages
 	return c.indexModule(ctx, mv, moduleDir)
 }
 
-// downloadModule downloads and extracts a module zip
-func (c *Crawler) downloadModule(ctx context.Context, mv ModuleVersion, destDir string) error {
+// recordModuleVersion counts a module as processed and adds it to the version history
+func (c *Crawler) recordModuleVersion(mv ModuleVersion) {
+	c.statsMu.Lock()
+	c.stats.ModulesProcessed++
+	c.statsMu.Unlock()
+
+	dbVersion := &db.ModuleVersion{
+		ModulePath: mv.Path,
+		Version:    mv.Version,
+		Timestamp:  mv.Timestamp,
+		IsTagged:   isTaggedVersion(mv.Version),
+		IsStable:   isStableVersion(mv.Version),
+	}
+	if err := c.db.UpsertModuleVersion(dbVersion); err != nil {
+		log.Printf("Warning: failed to record version %s@%s: %v", mv.Path, mv.Version, err)
+	}
+}
+
+// downloadModule downloads and extracts a module zip, once its extracted size
+// fits in the disk budget, and returns the zip's go.sum checksum. The returned
+// function releases that space.
+func (c *Crawler) downloadModule(ctx context.Context, mv ModuleVersion, destDir string) (checksum string, release func(), err error) {
 	// Escape module path for URL
 	escapedPath := escapeModulePath(mv.Path)
-	url := fmt.Sprintf("%s/%s/@v/%s.zip", ProxyURL, escapedPath, mv.Version)
+	url := fmt.Sprintf("%s/%s/@v/%s.zip", c.proxyURL, escapedPath, mv.Version)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download returned status %d", resp.StatusCode)
+		return "", nil, fmt.Errorf("download returned status %d", resp.StatusCode)
 	}
 
 	// Read zip into memory (modules are usually small)
-	data, err := io.ReadAll(io.LimitReader(resp.Body, 100*1024*1024)) // 100MB limit
+	data, err := io.ReadAll(io.LimitReader(resp.Body, c.maxModuleSize+1))
 	if err != nil {
-		return fmt.Errorf("reading zip: %w", err)
+		return "", nil, fmt.Errorf("reading zip: %w", err)
+	}
+	if int64(len(data)) > c.maxModuleSize {
+		return "", nil, fmt.Errorf("module zip exceeds %d bytes", c.maxModuleSize)
 	}
 
 	// Extract zip
 	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
 	if err != nil {
-		return fmt.Errorf("opening zip: %w", err)
+		return "", nil, fmt.Errorf("opening zip: %w", err)
+	}
+
+	checksum, err = moduleChecksum(zipReader)
+	if err != nil {
+		return "", nil, fmt.Errorf("hashing zip: %w", err)
+	}
+
+	var size int64
+	for _, f := range zipReader.File {
+		size += int64(min(f.UncompressedSize64, maxExtractedFileSize))
+	}
+	release, err = c.diskBudget.acquire(ctx, size)
+	if err != nil {
+		return "", nil, err
 	}
 
 	for _, f := range zipReader.File {
 		if err := extractZipFile(f, destDir); err != nil {
-			return fmt.Errorf("extracting %s: %w", f.Name, err)
+			release()
+			return "", nil, fmt.Errorf("extracting %s: %w", f.Name, err)
 		}
 	}
 
-	return nil
+	return checksum, release, nil
 }
 
+// maxExtractedFileSize caps the size of each file extracted from a module zip
+const maxExtractedFileSize = 10 * 1024 * 1024
+
 // extractZipFile extracts a single file from a zip
 func extractZipFile(f *zip.File, destDir string) error {
 	destPath := filepath.Join(destDir, f.Name)
@@ -421,7 +585,7 @@ func extractZipFile(f *zip.File, destDir string) error {
 	}
 	defer dst.Close()
 
-	_, err = io.Copy(dst, io.LimitReader(src, 10*1024*1024)) // 10MB per file limit
+	_, err = io.Copy(dst, io.LimitReader(src, maxExtractedFileSize))
 	return err
 }
 
@@ -440,6 +604,16 @@ func (c *Crawler) indexModule(ctx context.Context, mv ModuleVersion, moduleDir s
 			if strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata" {
 				return filepath.SkipDir
 			}
+			if path != moduleDir {
+				// Demo code such as examples/ is not part of the library
+				if slices.Contains(c.excludeDirs, name) {
+					return filepath.SkipDir
+				}
+				// Nested modules are separate modules; proxy zips already leave them out
+				if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+					return filepath.SkipDir
+				}
+			}
 			// Check if directory contains Go files
 			hasGo, _ := filepath.Glob(filepath.Join(path, "*.go"))
 			if len(hasGo) > 0 {
@@ -469,6 +643,36 @@ func (c *Crawler) indexModule(ctx context.Context, mv ModuleVersion, moduleDir s
 	return nil
 }
 
+// parseTestFiles parses the test files of package pkgName in pkgDir so
+// examples can be found, internal tests first, then the external _test
+// package. A test file that fails to parse is skipped: examples are not worth
+// failing the package over.
+func parseTestFiles(fset *token.FileSet, pkgDir, pkgName string) []*ast.File {
+	entries, err := os.ReadDir(pkgDir)
+	if err != nil {
+		return nil
+	}
+	var internal, external []*ast.File
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), "_test.go") {
+			continue
+		}
+		path := filepath.Join(pkgDir, entry.Name())
+		f, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			log.Printf("Warning: skipping test file %s: %v", path, err)
+			continue
+		}
+		switch f.Name.Name {
+		case pkgName:
+			internal = append(internal, f)
+		case pkgName + "_test":
+			external = append(external, f)
+		}
+	}
+	return append(internal, external...)
+}
+
 // indexPackage indexes a single package
 func (c *Crawler) indexPackage(ctx context.Context, mv ModuleVersion, moduleDir, pkgDir string) error {
 	// Calculate import path
@@ -484,30 +688,36 @@ func (c *Crawler) indexPackage(ctx context.Context, mv ModuleVersion, moduleDir,
 	// Parse package
 	fset := token.NewFileSet()
 	pkgs, err := parser.ParseDir(fset, pkgDir, func(fi os.FileInfo) bool {
-		name := fi.Name()
-		return strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go")
+		return !strings.HasSuffix(fi.Name(), "_test.go")
 	}, parser.ParseComments)
 	if err != nil {
 		return fmt.Errorf("parsing package: %w", err)
 	}
 
-	// Find the main package (not _test)
-	var astPkg *ast.Package
+	// Pick the documented package among the packages in the directory
+	fileCounts := make(map[string]int)
 	for name, pkg := range pkgs {
-		if !strings.HasSuffix(name, "_test") {
-			astPkg = pkg
-			break
-		}
+		fileCounts[name] = len(pkg.Files)
 	}
-	if astPkg == nil {
-		return nil // No main package found
+	if len(fileCounts) == 0 {
+		return nil // Test-only directory, nothing to document
+	}
+	pkgName, err := util.SelectPackageName(fileCounts, importPath)
+	if err != nil {
+		return err
 	}
 
-	// Create doc package
+	// Files go in name order: go/doc orders values and joins package comments by it
 	var files []*ast.File
-	for _, f := range astPkg.Files {
-		files = append(files, f)
+	for _, filename := range slices.Sorted(maps.Keys(pkgs[pkgName].Files)) {
+		files = append(files, pkgs[pkgName].Files[filename])
 	}
+	testFiles := parseTestFiles(fset, pkgDir, pkgName)
+
+	// go/doc drops function bodies, so record where each function ends first
+	funcEnds := util.FuncEndLines(fset, files)
+
+	// Create doc package
 	docPkg, err := doc.NewFromFiles(fset, files, importPath, doc.AllDecls|doc.AllMethods)
 	if err != nil {
 		return fmt.Errorf("creating doc: %w", err)
@@ -515,17 +725,15 @@ func (c *Crawler) indexPackage(ctx context.Context, mv ModuleVersion, moduleDir,
 
 	// Read go.mod if at module root
 	var goModContent, goVersion, modulePath string
+	var hasValidMod bool
 	if relPath == "." {
 		if data, err := os.ReadFile(filepath.Join(pkgDir, "go.mod")); err == nil {
 			goModContent = string(data)
-			for _, line := range strings.Split(goModContent, "\n") {
-				line = strings.TrimSpace(line)
-				if strings.HasPrefix(line, "module ") {
-					modulePath = strings.TrimSpace(strings.TrimPrefix(line, "module "))
-				}
-				if strings.HasPrefix(line, "go ") {
-					goVersion = strings.TrimSpace(strings.TrimPrefix(line, "go "))
-				}
+			if goMod, err := util.ParseGoMod(data); err != nil {
+				log.Printf("Warning: failed to parse go.mod of %s@%s: %v", mv.Path, mv.Version, err)
+			} else {
+				goVersion, modulePath = goMod.Go, goMod.Module
+				hasValidMod = goMod.Module != ""
 			}
 		}
 	}
@@ -534,13 +742,20 @@ func (c *Crawler) indexPackage(ctx context.Context, mv ModuleVersion, moduleDir,
 	}
 
 	// Detect license
-	license, licenseText := detectLicense(moduleDir)
+	license, licenseText := util.DetectLicense(moduleDir, c.extraLicenseFiles...)
+
+	classification := util.ClassifyPackage(files, testFiles)
+	synopsis, synopsisSource := doc.Synopsis(docPkg.Doc), ""
+	if synopsis == "" && classification == "" {
+		synopsis, synopsisSource = c.derivedSynopsis(docPkg, pkgDir)
+	}
 
 	// Build database package
 	dbPkg := &db.Package{
 		ImportPath:      importPath,
 		Name:            docPkg.Name,
-		Synopsis:        doc.Synopsis(docPkg.Doc),
+		Synopsis:        synopsis,
+		SynopsisSource:  synopsisSource,
 		Doc:             docPkg.Doc,
 		Version:         mv.Version,
 		Versions:        []string{mv.Version},
@@ -550,10 +765,15 @@ func (c *Crawler) indexPackage(ctx context.Context, mv ModuleVersion, moduleDir,
 		LicenseText:     licenseText,
 		Redistributable: isRedistributable(license),
 		Repository:      moduleToRepoURL(mv.Path),
-		HasValidMod:     goModContent != "",
+		HasValidMod:     hasValidMod,
 		GoVersion:       goVersion,
 		ModulePath:      modulePath,
 		GoModContent:    goModContent,
+		Generate:        util.GenerateDirectives(fset, files),
+		Classification:  classification,
+		Coverage:        util.DetectCoverage(moduleDir),
+		Experimental:    util.IsExperimental(importPath, docPkg.Doc),
+		Deprecated:      isDeprecated(docPkg.Doc),
 	}
 
 	// Upsert package
@@ -562,14 +782,13 @@ func (c *Crawler) indexPackage(ctx context.Context, mv ModuleVersion, moduleDir,
 		return fmt.Errorf("upserting package: %w", err)
 	}
 
-	// Delete old symbols
-	c.db.DeletePackageSymbols(pkgID)
-
-	// Index symbols
-	symbolCount := 0
+	// Collect symbols, then insert them in the order GetPackageSymbols returns
+	// them so reindexing an unchanged package stores identical rows
+	var symbols []*db.Symbol
 
 	// Functions
 	for _, fn := range docPkg.Funcs {
+		pos := fset.Position(fn.Decl.Pos())
 		sym := &db.Symbol{
 			Name:       fn.Name,
 			Kind:       "func",
@@ -579,14 +798,18 @@ func (c *Crawler) indexPackage(ctx context.Context, mv ModuleVersion, moduleDir,
 			Doc:        fn.Doc,
 			Signature:  formatDecl(fset, fn.Decl),
 			Deprecated: isDeprecated(fn.Doc),
+			Filename:   filepath.Base(pos.Filename),
+			Line:       pos.Line,
+			EndLine:    funcEnds[fn.Decl.Pos()],
 		}
-		if err := c.db.UpsertSymbol(sym); err == nil {
-			symbolCount++
-		}
+		sym.Params, _ = util.ParseParamDocs(fn.Doc, util.ParamNames(fn.Decl.Type))
+		symbols = append(symbols, sym)
 	}
 
 	// Types
 	for _, t := range docPkg.Types {
+		start, end := util.TypeDeclRange(fset, t)
+		isInterface, methodSet := util.MethodSet(docPkg, t)
 		sym := &db.Symbol{
 			Name:       t.Name,
 			Kind:       "type",
@@ -596,13 +819,17 @@ func (c *Crawler) indexPackage(ctx context.Context, mv ModuleVersion, moduleDir,
 			Doc:        t.Doc,
 			Decl:       formatDecl(fset, t.Decl),
 			Deprecated: isDeprecated(t.Doc),
+			Filename:   filepath.Base(start.Filename),
+			Line:       start.Line,
+			EndLine:    end.Line,
+			Interface:  isInterface,
+			MethodSet:  methodSet,
 		}
-		if err := c.db.UpsertSymbol(sym); err == nil {
-			symbolCount++
-		}
+		symbols = append(symbols, sym)
 
 		// Methods
 		for _, m := range t.Methods {
+			pos := fset.Position(m.Decl.Pos())
 			sym := &db.Symbol{
 				Name:       t.Name + "." + m.Name,
 				Kind:       "method",
@@ -612,14 +839,17 @@ func (c *Crawler) indexPackage(ctx context.Context, mv ModuleVersion, moduleDir,
 				Doc:        m.Doc,
 				Signature:  formatDecl(fset, m.Decl),
 				Deprecated: isDeprecated(m.Doc),
+				Filename:   filepath.Base(pos.Filename),
+				Line:       pos.Line,
+				EndLine:    funcEnds[m.Decl.Pos()],
 			}
-			if err := c.db.UpsertSymbol(sym); err == nil {
-				symbolCount++
-			}
+			sym.Params, _ = util.ParseParamDocs(m.Doc, util.ParamNames(m.Decl.Type))
+			symbols = append(symbols, sym)
 		}
 
 		// Type functions
 		for _, fn := range t.Funcs {
+			pos := fset.Position(fn.Decl.Pos())
 			sym := &db.Symbol{
 				Name:       fn.Name,
 				Kind:       "func",
@@ -629,16 +859,19 @@ func (c *Crawler) indexPackage(ctx context.Context, mv ModuleVersion, moduleDir,
 				Doc:        fn.Doc,
 				Signature:  formatDecl(fset, fn.Decl),
 				Deprecated: isDeprecated(fn.Doc),
+				Filename:   filepath.Base(pos.Filename),
+				Line:       pos.Line,
+				EndLine:    funcEnds[fn.Decl.Pos()],
 			}
-			if err := c.db.UpsertSymbol(sym); err == nil {
-				symbolCount++
-			}
+			sym.Params, _ = util.ParseParamDocs(fn.Doc, util.ParamNames(fn.Decl.Type))
+			symbols = append(symbols, sym)
 		}
 	}
 
 	// Constants
 	for _, con := range docPkg.Consts {
 		decl := formatDecl(fset, con.Decl)
+		deprecated := util.DeprecatedValueNames(con)
 		for _, name := range con.Names {
 			sym := &db.Symbol{
 				Name:       name,
@@ -648,16 +881,16 @@ func (c *Crawler) indexPackage(ctx context.Context, mv ModuleVersion, moduleDir,
 				Synopsis:   doc.Synopsis(con.Doc),
 				Doc:        con.Doc,
 				Decl:       decl,
+				Deprecated: isDeprecated(con.Doc) || slices.Contains(deprecated, name),
 			}
-			if err := c.db.UpsertSymbol(sym); err == nil {
-				symbolCount++
-			}
+			symbols = append(symbols, sym)
 		}
 	}
 
 	// Variables
 	for _, v := range docPkg.Vars {
 		decl := formatDecl(fset, v.Decl)
+		deprecated := util.DeprecatedValueNames(v)
 		for _, name := range v.Names {
 			sym := &db.Symbol{
 				Name:       name,
@@ -667,40 +900,137 @@ func (c *Crawler) indexPackage(ctx context.Context, mv ModuleVersion, moduleDir,
 				Synopsis:   doc.Synopsis(v.Doc),
 				Doc:        v.Doc,
 				Decl:       decl,
+				Deprecated: isDeprecated(v.Doc) || slices.Contains(deprecated, name),
 			}
-			if err := c.db.UpsertSymbol(sym); err == nil {
-				symbolCount++
-			}
+			symbols = append(symbols, sym)
+		}
+	}
+
+	slices.SortStableFunc(symbols, func(a, b *db.Symbol) int {
+		return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Name, b.Name))
+	})
+	orphaned, err := c.db.ReplacePackageSymbols(pkgID, importPath, docPkg.Name, symbols)
+	if err != nil {
+		return fmt.Errorf("indexing symbols: %w", err)
+	}
+	if orphaned > 0 {
+		log.Printf("%s has %d orphaned AI docs or feedback entries", importPath, orphaned)
+	}
+
+	// Examples
+	var examples []*db.Example
+	for _, ex := range doc.Examples(testFiles...) {
+		code := formatDecl(fset, ex.Code)
+		if code == "" && ex.Play != nil {
+			code = formatDecl(fset, ex.Play)
 		}
+		examples = append(examples, &db.Example{
+			Symbol:      util.ExampleSymbol(ex.Name),
+			Name:        ex.Name,
+			Doc:         ex.Doc,
+			Code:        code,
+			Output:      ex.Output,
+			Unordered:   ex.Unordered,
+			EmptyOutput: ex.EmptyOutput,
+		})
+	}
+	if err := c.db.ReplacePackageExamples(pkgID, importPath, examples); err != nil {
+		log.Printf("Warning: failed to index examples for %s: %v", importPath, err)
 	}
 
-	// Index imports
+	// Index imports, once each across files
+	imported := make(map[string]bool)
 	for _, f := range files {
 		for _, imp := range f.Imports {
 			if imp.Path != nil {
-				impPath := strings.Trim(imp.Path.Value, `"`)
-				c.db.AddImport(importPath, impPath, modulePath)
+				imported[strings.Trim(imp.Path.Value, `"`)] = true
 			}
 		}
 	}
+	if err := c.db.ReplaceImports(importPath, modulePath, slices.Sorted(maps.Keys(imported))); err != nil {
+		log.Printf("Warning: failed to index imports for %s: %v", importPath, err)
+	}
 
 	c.statsMu.Lock()
-	c.stats.SymbolsIndexed += symbolCount
+	c.stats.SymbolsIndexed += len(symbols)
 	c.statsMu.Unlock()
 
 	return nil
 }
 
-func (c *Crawler) recordSuccess() {
+// derivedSynopsis returns a synopsis for a package without a package doc,
+// taken from the README of its directory or else generated from its exported
+// symbols, and its source: "readme", "ai", or "" when there is none
+func (c *Crawler) derivedSynopsis(docPkg *doc.Package, pkgDir string) (synopsis, source string) {
+	if synopsis := util.ReadmeSynopsis(pkgDir); synopsis != "" {
+		return synopsis, "readme"
+	}
+	if c.synopsisGenerator == nil {
+		return "", ""
+	}
+
+	// The package is documented with all declarations, so keep the exported ones
+	var exported []string
+	add := func(names ...string) {
+		for _, name := range names {
+			if token.IsExported(name) {
+				exported = append(exported, name)
+			}
+		}
+	}
+	for _, fn := range docPkg.Funcs {
+		add(fn.Name)
+	}
+	for _, t := range docPkg.Types {
+		add(t.Name)
+		for _, fn := range t.Funcs {
+			add(fn.Name)
+		}
+	}
+	for _, values := range [][]*doc.Value{docPkg.Consts, docPkg.Vars} {
+		for _, v := range values {
+			add(v.Names...)
+		}
+	}
+	if len(exported) == 0 {
+		return "", ""
+	}
+
+	synopsis, err := c.synopsisGenerator.GeneratePackageSynopsis(docPkg.Name, exported)
+	if err != nil {
+		log.Printf("Warning: failed to generate synopsis for %s: %v", docPkg.ImportPath, err)
+		return "", ""
+	}
+	if synopsis = strings.TrimSpace(synopsis); synopsis == "" {
+		return "", ""
+	}
+	return synopsis, "ai"
+}
+
+// recordSuccess counts an indexed module and clears its earlier failures from the crawl error log
+func (c *Crawler) recordSuccess(mv ModuleVersion) {
 	c.statsMu.Lock()
 	c.stats.ModulesSucceeded++
 	c.statsMu.Unlock()
+
+	if err := c.db.ClearCrawlErrors(mv.Path, mv.Version); err != nil {
+		log.Printf("Warning: %v", err)
+	}
 }
 
-func (c *Crawler) recordFailure() {
+// recordFailure counts a failed module and keeps the error in the crawl error log
+func (c *Crawler) recordFailure(mv ModuleVersion, err error) {
 	c.statsMu.Lock()
 	c.stats.ModulesFailed++
 	c.statsMu.Unlock()
+
+	// Interrupting the crawl is not a failure of the module
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if err := c.db.RecordCrawlError(mv.Path, mv.Version, err.Error()); err != nil {
+		log.Printf("Warning: %v", err)
+	}
 }
 
 func (c *Crawler) printStats() {
@@ -779,6 +1109,27 @@ func isStableVersion(version string) bool {
 	return true
 }
 
+// ErrRecentlyIndexed is returned by the registry crawlers when a package was
+// indexed within their MinAge window and was therefore not fetched again
+var ErrRecentlyIndexed = errors.New("package was indexed recently")
+
+// ErrBelowThreshold is returned by the registry crawlers when a package has
+// fewer stars or downloads than their configured minimum
+var ErrBelowThreshold = errors.New("package is below the popularity threshold")
+
+// checkPopularity returns ErrBelowThreshold when a positive minimum is not met
+func checkPopularity(count, minimum int, unit string) error {
+	if minimum > 0 && count < minimum {
+		return fmt.Errorf("%w: %d %s, want at least %d", ErrBelowThreshold, count, unit, minimum)
+	}
+	return nil
+}
+
+// indexedWithin reports whether indexedAt is less than minAge ago
+func indexedWithin(indexedAt time.Time, minAge time.Duration) bool {
+	return minAge > 0 && !indexedAt.IsZero() && time.Since(indexedAt) < minAge
+}
+
 // Deprecated: Use util.IsDeprecated instead
 func isDeprecated(docText string) bool {
 	return util.IsDeprecated(docText)
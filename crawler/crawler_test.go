@@ -1,8 +1,16 @@
 package crawler
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/alexisbouchez/wikigo/db"
 	"github.com/alexisbouchez/wikigo/util"
 )
 
@@ -348,3 +356,382 @@ func TestModuleToRepoURL_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestIndexModule_ExcludeDirs(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":                      "module example.com/lib\n\ngo 1.22\n",
+		"lib.go":                      "// Package lib is a library.\npackage lib\n",
+		"examples/hello/main.go":      "package main\n\nfunc main() {}\n",
+		"_examples/demo/main.go":      "package main\n\nfunc main() {}\n",
+		"internal/example/example.go": "package example\n",
+		"sub/sub.go":                  "package sub\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mv := ModuleVersion{Path: "example.com/lib", Version: "v1.0.0"}
+
+	indexed := func(excludeDirs []string) []string {
+		t.Helper()
+		c, err := New(Config{DBPath: filepath.Join(t.TempDir(), "test.db"), TempDir: t.TempDir(), ExcludeDirs: excludeDirs})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		defer c.Close()
+		if err := c.indexModule(context.Background(), mv, dir); err != nil {
+			t.Fatalf("indexModule() error = %v", err)
+		}
+		var paths []string
+		for _, path := range []string{"example.com/lib", "example.com/lib/examples/hello", "example.com/lib/_examples/demo", "example.com/lib/internal/example", "example.com/lib/sub"} {
+			if pkg, err := c.GetDB().GetPackage(path); err == nil && pkg != nil {
+				paths = append(paths, path)
+			}
+		}
+		return paths
+	}
+
+	if got, want := indexed(nil), []string{"example.com/lib", "example.com/lib/sub"}; !slices.Equal(got, want) {
+		t.Errorf("default exclusions indexed %v, want %v", got, want)
+	}
+	if got, want := indexed([]string{}), []string{"example.com/lib", "example.com/lib/examples/hello", "example.com/lib/_examples/demo", "example.com/lib/internal/example", "example.com/lib/sub"}; !slices.Equal(got, want) {
+		t.Errorf("no exclusions indexed %v, want %v", got, want)
+	}
+}
+
+func TestIndexModule_Reindex(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module example.com/stable\n\ngo 1.22\n")
+	write("b.go", "package stable\n\nimport \"fmt\"\n\n// B is declared second.\nconst B = 2\n\n// V is a variable.\nvar V = fmt.Sprint(B)\n")
+	write("a.go", "// Package stable is indexed the same way every time.\npackage stable\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\n// A is declared first.\nconst A = 1\n\n// T is a type.\ntype T struct{}\n\n// Print prints.\nfunc (T) Print() { fmt.Fprint(os.Stdout, A) }\n\n// New returns a T.\nfunc New() T { return T{} }\n")
+
+	c, err := New(Config{DBPath: filepath.Join(t.TempDir(), "test.db"), TempDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+	database := c.GetDB()
+	mv := ModuleVersion{Path: "example.com/stable", Version: "v1.0.0"}
+
+	// snapshot returns the stored symbols without their row IDs, checking that rows
+	// were inserted in the order they are read back, and the stored imports
+	snapshot := func() ([]db.Symbol, []string) {
+		t.Helper()
+		if err := c.indexModule(context.Background(), mv, dir); err != nil {
+			t.Fatalf("indexModule() error = %v", err)
+		}
+		pkg, err := database.GetPackage("example.com/stable")
+		if err != nil || pkg == nil {
+			t.Fatalf("GetPackage() = %v, %v", pkg, err)
+		}
+		symbols, err := database.GetPackageSymbols(pkg.ID)
+		if err != nil {
+			t.Fatalf("GetPackageSymbols() error = %v", err)
+		}
+		var rows []db.Symbol
+		for i, sym := range symbols {
+			if i > 0 && sym.ID < symbols[i-1].ID {
+				t.Errorf("symbol %s was inserted before %s", sym.Name, symbols[i-1].Name)
+			}
+			row := *sym
+			row.ID = 0
+			rows = append(rows, row)
+		}
+		imports, err := database.GetImports("example.com/stable")
+		if err != nil {
+			t.Fatalf("GetImports() error = %v", err)
+		}
+		return rows, imports
+	}
+
+	firstSymbols, firstImports := snapshot()
+	if want := []string{"fmt", "os"}; !slices.Equal(firstImports, want) {
+		t.Errorf("imports = %v, want %v", firstImports, want)
+	}
+	for range 3 {
+		symbols, imports := snapshot()
+		if !reflect.DeepEqual(symbols, firstSymbols) || !slices.Equal(imports, firstImports) {
+			t.Fatalf("reindexing changed the stored data:\n%+v %v\n%+v %v", firstSymbols, firstImports, symbols, imports)
+		}
+	}
+
+	// Imports dropped from the source are dropped from the index
+	write("a.go", "// Package stable is indexed the same way every time.\npackage stable\n\n// A is declared first.\nconst A = 1\n")
+	if _, imports := snapshot(); !slices.Equal(imports, []string{"fmt"}) {
+		t.Errorf("imports after removing os = %v, want [fmt]", imports)
+	}
+}
+
+func TestIndexModule_Coverage(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":     "module example.com/lib\n\ngo 1.22\n",
+		"lib.go":     "// Package lib is a library.\npackage lib\n",
+		"sub/sub.go": "package sub\n",
+		"README.md":  "# lib\n\n![coverage](https://img.shields.io/badge/coverage-91%25-brightgreen)\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := New(Config{DBPath: filepath.Join(t.TempDir(), "test.db"), TempDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+	if err := c.indexModule(context.Background(), ModuleVersion{Path: "example.com/lib", Version: "v1.0.0"}, dir); err != nil {
+		t.Fatalf("indexModule() error = %v", err)
+	}
+
+	// Coverage is reported for the whole module, so every package shows it
+	for _, path := range []string{"example.com/lib", "example.com/lib/sub"} {
+		pkg, err := c.GetDB().GetPackage(path)
+		if err != nil || pkg == nil {
+			t.Fatalf("GetPackage(%s) = %v, %v", path, pkg, err)
+		}
+		if pkg.Coverage != "91%" {
+			t.Errorf("%s coverage = %q, want 91%%", path, pkg.Coverage)
+		}
+	}
+}
+
+// fakeSynopsisGenerator records the packages it was asked to describe
+type fakeSynopsisGenerator struct {
+	calls []string
+}
+
+func (g *fakeSynopsisGenerator) GeneratePackageSynopsis(packageName string, exportedSymbols []string) (string, error) {
+	g.calls = append(g.calls, packageName+":"+strings.Join(exportedSymbols, ","))
+	return " Package " + packageName + " does things. ", nil
+}
+
+func TestIndexModule_DerivedSynopsis(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":               "module example.com/lib\n\ngo 1.22\n",
+		"lib.go":               "// Package lib is a library.\npackage lib\n",
+		"README.md":            "# lib\n\nThe lib module is documented here.\n",
+		"readme/readme.go":     "package readme\n\nfunc Parse() {}\n",
+		"readme/README.md":     "# readme\n\n[![CI](https://x/ci.svg)](https://x/ci)\n\nReadme parses **things** quickly. More below.\n",
+		"generated/gen.go":     "package generated\n\nfunc New() {}\n\nfunc helper() {}\n\ntype Thing struct{}\n",
+		"undocumented/none.go": "package undocumented\n\nfunc helper() {}\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gen := &fakeSynopsisGenerator{}
+	c, err := New(Config{DBPath: filepath.Join(t.TempDir(), "test.db"), TempDir: t.TempDir(), SynopsisGenerator: gen})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+	if err := c.indexModule(context.Background(), ModuleVersion{Path: "example.com/lib", Version: "v1.0.0"}, dir); err != nil {
+		t.Fatalf("indexModule() error = %v", err)
+	}
+
+	tests := []struct {
+		path, synopsis, source string
+	}{
+		{"example.com/lib", "Package lib is a library.", ""},
+		{"example.com/lib/readme", "Readme parses things quickly.", "readme"},
+		{"example.com/lib/generated", "Package generated does things.", "ai"},
+		{"example.com/lib/undocumented", "", ""},
+	}
+	for _, tt := range tests {
+		pkg, err := c.GetDB().GetPackage(tt.path)
+		if err != nil || pkg == nil {
+			t.Fatalf("GetPackage(%s) = %v, %v", tt.path, pkg, err)
+		}
+		if pkg.Synopsis != tt.synopsis || pkg.SynopsisSource != tt.source {
+			t.Errorf("%s synopsis = %q from %q, want %q from %q", tt.path, pkg.Synopsis, pkg.SynopsisSource, tt.synopsis, tt.source)
+		}
+	}
+
+	// Only the package with neither a doc nor a README, but with an API, is sent
+	if want := []string{"generated:New,Thing"}; !slices.Equal(gen.calls, want) {
+		t.Errorf("generator calls = %v, want %v", gen.calls, want)
+	}
+}
+
+func TestIndexModule_BrokenTestFile(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":             "module example.com/lib\n\ngo 1.22\n",
+		"lib.go":             "// Package lib is a library.\npackage lib\n\nfunc Hello() {}\n",
+		"broken_test.go":     "package lib\n\nfunc TestBroken( {\n",
+		"example_test.go":    "package lib_test\n\nfunc ExampleHello() {\n\t// Output:\n}\n",
+		"testonly/x_test.go": "package testonly\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := New(Config{DBPath: filepath.Join(t.TempDir(), "test.db"), TempDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+	if err := c.indexModule(context.Background(), ModuleVersion{Path: "example.com/lib", Version: "v1.0.0"}, dir); err != nil {
+		t.Fatalf("indexModule() error = %v", err)
+	}
+
+	if pkg, err := c.GetDB().GetPackage("example.com/lib"); err != nil || pkg == nil {
+		t.Fatalf("GetPackage() = %v, %v, want the package indexed despite its broken test file", pkg, err)
+	}
+	examples, err := c.GetDB().GetPackageExamples("example.com/lib")
+	if err != nil {
+		t.Fatalf("GetPackageExamples() error = %v", err)
+	}
+	if len(examples) != 1 || examples[0].Symbol != "Hello" {
+		t.Errorf("examples = %v, want ExampleHello from the test file that parses", examples)
+	}
+	if pkg, err := c.GetDB().GetPackage("example.com/lib/testonly"); err == nil && pkg != nil {
+		t.Errorf("test-only directory indexed as %v", pkg)
+	}
+}
+
+func TestIndexModule_MethodSets(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":     "module example.com/lib\n\ngo 1.22\n",
+		"lib.go":     "package lib\n\ntype Writer interface {\n\tWrite(p []byte) (n int, err error)\n}\n",
+		"buf/buf.go": "package buf\n\ntype Buffer struct{}\n\nfunc (b *Buffer) Write(p []byte) (int, error) { return len(p), nil }\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := New(Config{DBPath: filepath.Join(t.TempDir(), "test.db"), TempDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+	if err := c.indexModule(context.Background(), ModuleVersion{Path: "example.com/lib", Version: "v1.0.0"}, dir); err != nil {
+		t.Fatalf("indexModule() error = %v", err)
+	}
+
+	types, err := c.GetDB().GetModuleMethodSets("example.com/lib")
+	if err != nil {
+		t.Fatalf("GetModuleMethodSets() error = %v", err)
+	}
+	var got []string
+	for _, typ := range types {
+		got = append(got, fmt.Sprintf("%s.%s interface=%v %v", typ.Package, typ.Name, typ.Interface, typ.MethodSet))
+	}
+	want := []string{
+		"lib.Writer interface=true [Write([]byte) (int, error)]",
+		"buf.Buffer interface=false [*Write([]byte) (int, error)]",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("method sets = %q, want %q", got, want)
+	}
+}
+
+func TestIndexModule_GoMod(t *testing.T) {
+	tests := []struct {
+		name      string
+		gomod     string
+		wantValid bool
+		wantGo    string
+		wantMod   string
+	}{
+		{"quoted path and comments", "// The lib module.\nmodule \"example.com/lib\" // canonical\n\ngo 1.22.1\n\ntoolchain go1.23.0\n", true, "1.22.1", "example.com/lib"},
+		{"invalid", "module example.com/lib\n\ngo banana\n", false, "", "example.com/lib"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range map[string]string{"go.mod": tt.gomod, "lib.go": "package lib\n"} {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			c, err := New(Config{DBPath: filepath.Join(t.TempDir(), "test.db"), TempDir: t.TempDir()})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer c.Close()
+			if err := c.indexModule(context.Background(), ModuleVersion{Path: "example.com/lib", Version: "v1.0.0"}, dir); err != nil {
+				t.Fatalf("indexModule() error = %v", err)
+			}
+
+			pkg, err := c.GetDB().GetPackage("example.com/lib")
+			if err != nil {
+				t.Fatalf("GetPackage() error = %v", err)
+			}
+			if pkg.HasValidMod != tt.wantValid || pkg.GoVersion != tt.wantGo || pkg.ModulePath != tt.wantMod || pkg.GoModContent != tt.gomod {
+				t.Errorf("package = valid %v, go %q, module %q, want %v, %q, %q", pkg.HasValidMod, pkg.GoVersion, pkg.ModulePath, tt.wantValid, tt.wantGo, tt.wantMod)
+			}
+		})
+	}
+}
+
+func TestIndexModule_ParamDocs(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod": "module example.com/net\n\ngo 1.22\n",
+		"net.go": "package net\n\n// Dial connects to the address.\n//\n// Parameters:\n//   network - the network name\n//   address - host and port\nfunc Dial(network, address string) error { return nil }\n\n// Close closes.\nfunc Close() {}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := New(Config{DBPath: filepath.Join(t.TempDir(), "test.db"), TempDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+	if err := c.indexModule(context.Background(), ModuleVersion{Path: "example.com/net", Version: "v1.0.0"}, dir); err != nil {
+		t.Fatalf("indexModule() error = %v", err)
+	}
+
+	pkg, err := c.GetDB().GetPackage("example.com/net")
+	if err != nil {
+		t.Fatalf("GetPackage() error = %v", err)
+	}
+	symbols, err := c.GetDB().GetPackageSymbols(pkg.ID)
+	if err != nil {
+		t.Fatalf("GetPackageSymbols() error = %v", err)
+	}
+	params := make(map[string][]util.ParamDoc)
+	for _, sym := range symbols {
+		params[sym.Name] = sym.Params
+	}
+	want := []util.ParamDoc{{Name: "network", Description: "the network name"}, {Name: "address", Description: "host and port"}}
+	if !slices.Equal(params["Dial"], want) || params["Close"] != nil {
+		t.Errorf("params = %+v, want Dial's %+v and none for Close", params, want)
+	}
+}
@@ -0,0 +1,147 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// GitSource identifies a repository to index directly, bypassing the proxy
+type GitSource struct {
+	URL        string // anything git fetch accepts, including SSH URLs and local paths
+	Ref        string // branch, tag or commit; empty for the default branch
+	ModulePath string // overrides the module path, for repositories without a go.mod
+}
+
+// IndexGitRepo fetches a repository at a ref and indexes its Go packages. The
+// version is the ref itself when it is a semver tag, otherwise the highest semver
+// tag pointing at the fetched commit, otherwise a pseudo-version.
+func (c *Crawler) IndexGitRepo(ctx context.Context, src GitSource) (ModuleVersion, error) {
+	var mv ModuleVersion
+
+	dir, err := os.MkdirTemp(c.tempDir, "wikigo-git-*")
+	if err != nil {
+		return mv, fmt.Errorf("creating temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ref := src.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	// A shallow fetch of one ref works for branches, tags and commit hashes alike
+	steps := [][]string{
+		{"init", "-q"},
+		{"remote", "add", "origin", src.URL},
+		{"fetch", "-q", "--depth", "1", "origin", ref},
+		{"checkout", "-q", "FETCH_HEAD"},
+	}
+	for _, args := range steps {
+		if _, err := runGit(ctx, dir, args...); err != nil {
+			return mv, err
+		}
+	}
+
+	rev, err := runGit(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return mv, err
+	}
+	commitTime, err := runGit(ctx, dir, "show", "-s", "--format=%ct", "HEAD")
+	if err != nil {
+		return mv, err
+	}
+	seconds, err := strconv.ParseInt(commitTime, 10, 64)
+	if err != nil {
+		return mv, fmt.Errorf("parsing commit time %q: %w", commitTime, err)
+	}
+	committed := time.Unix(seconds, 0).UTC()
+
+	modulePath := src.ModulePath
+	if modulePath == "" {
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err != nil {
+			return mv, fmt.Errorf("reading go.mod (set a module path for repositories without one): %w", err)
+		}
+		if modulePath = modfile.ModulePath(data); modulePath == "" {
+			return mv, fmt.Errorf("go.mod has no module directive")
+		}
+	}
+
+	tags, err := runGit(ctx, dir, "ls-remote", "--tags", "origin")
+	if err != nil {
+		log.Printf("Warning: could not list tags of %s: %v", src.URL, err)
+	}
+
+	mv = ModuleVersion{
+		Path:      modulePath,
+		Version:   gitVersion(modulePath, src.Ref, rev, committed, tags),
+		Timestamp: committed,
+	}
+	c.recordModuleVersion(mv)
+	if err := c.indexModule(ctx, mv, dir); err != nil {
+		return mv, err
+	}
+	return mv, nil
+}
+
+// gitVersion picks the version to index a commit as. lsRemote is the output of
+// git ls-remote --tags; tags must match the major version of the module path.
+func gitVersion(modulePath, ref, rev string, commitTime time.Time, lsRemote string) string {
+	_, pathMajor, _ := module.SplitPathVersion(modulePath)
+	matches := func(tag string) bool {
+		return semver.IsValid(tag) && module.CheckPathMajor(tag, pathMajor) == nil
+	}
+
+	if matches(ref) {
+		return ref
+	}
+
+	best := ""
+	for _, line := range strings.Split(lsRemote, "\n") {
+		hash, name, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok || hash != rev {
+			continue
+		}
+		// Annotated tags point at the commit through their peeled ^{} entry
+		tag := strings.TrimSuffix(strings.TrimPrefix(name, "refs/tags/"), "^{}")
+		if matches(tag) && (best == "" || semver.Compare(tag, best) > 0) {
+			best = tag
+		}
+	}
+	if best != "" {
+		return best
+	}
+
+	short := rev
+	if len(short) > 12 {
+		short = short[:12]
+	}
+	return module.PseudoVersion(module.PathMajorPrefix(pathMajor), "", commitTime, short)
+}
+
+// runGit runs git in dir without prompting for credentials and returns its trimmed output
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,144 @@
+package crawler
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGitVersion(t *testing.T) {
+	rev := "0123456789abcdef0123456789abcdef01234567"
+	other := "fedcba9876543210fedcba9876543210fedcba98"
+	committed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tags := other + "\trefs/tags/v1.5.0\n" +
+		rev + "\trefs/tags/v1.2.0\n" +
+		rev + "\trefs/tags/v1.3.0-rc.1\n" +
+		"aaaa\trefs/tags/v1.4.0\n" +
+		rev + "\trefs/tags/v1.4.0^{}\n" + // annotated tag
+		rev + "\trefs/tags/v2.0.0\n" +
+		rev + "\trefs/tags/release-1\n"
+
+	tests := []struct {
+		name       string
+		modulePath string
+		ref        string
+		lsRemote   string
+		want       string
+	}{
+		{"ref is a tag", "example.com/m", "v1.1.0", tags, "v1.1.0"},
+		{"highest tag at the commit", "example.com/m", "main", tags, "v1.4.0"},
+		{"default branch", "example.com/m", "", tags, "v1.4.0"},
+		{"major version suffix", "example.com/m/v2", "", tags, "v2.0.0"},
+		{"ref tag of another major", "example.com/m/v2", "v1.1.0", "", "v2.0.0-20240102030405-0123456789ab"},
+		{"no tags", "example.com/m", "main", "", "v0.0.0-20240102030405-0123456789ab"},
+		{"no tags at the commit", "example.com/m", "", other + "\trefs/tags/v1.5.0\n", "v0.0.0-20240102030405-0123456789ab"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gitVersion(tt.modulePath, tt.ref, rev, committed, tt.lsRemote); got != tt.want {
+				t.Errorf("gitVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexGitRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repo := t.TempDir()
+	files := map[string]string{
+		"go.mod":          "module example.com/private\n\ngo 1.22\n",
+		"private.go":      "// Package private was never published.\npackage private\n\n// Secret returns a secret.\nfunc Secret() string { return \"s\" }\n",
+		"inner/inner.go":  "// Package inner is internal to the repo.\npackage inner\n\n// Value is a value.\nconst Value = 1\n",
+		"tools/go.mod":    "module example.com/private/tools\n",
+		"tools/tools.go":  "// Package tools is a separate module.\npackage tools\n",
+		"testdata/x.go":   "package broken(\n",
+		".github/ci/a.go": "package ci\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(repo, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE=2024-01-02T03:04:05Z", "GIT_AUTHOR_DATE=2024-01-02T03:04:05Z")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+
+	c, err := New(Config{DBPath: filepath.Join(t.TempDir(), "test.db"), TempDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	mv, err := c.IndexGitRepo(ctx, GitSource{URL: repo})
+	if err != nil {
+		t.Fatalf("IndexGitRepo() error = %v", err)
+	}
+	if mv.Path != "example.com/private" || !mv.Timestamp.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("module = %+v", mv)
+	}
+	if want := "v0.0.0-20240102030405-"; len(mv.Version) != len(want)+12 || mv.Version[:len(want)] != want {
+		t.Errorf("untagged version = %q, want a pseudo-version", mv.Version)
+	}
+
+	database := c.GetDB()
+	pkg, err := database.GetPackage("example.com/private")
+	if err != nil || pkg == nil {
+		t.Fatalf("GetPackage() = %v, %v", pkg, err)
+	}
+	if pkg.Synopsis != "Package private was never published." || pkg.Version != mv.Version || pkg.GoVersion != "1.22" {
+		t.Errorf("package synopsis, version, go version = %q, %q, %q", pkg.Synopsis, pkg.Version, pkg.GoVersion)
+	}
+
+	var indexed []string
+	for _, path := range []string{"example.com/private", "example.com/private/inner", "example.com/private/tools", "example.com/private/testdata", "example.com/private/.github/ci"} {
+		if p, _ := database.GetPackage(path); p != nil {
+			indexed = append(indexed, path)
+		}
+	}
+	if want := []string{"example.com/private", "example.com/private/inner"}; !slices.Equal(indexed, want) {
+		t.Errorf("indexed packages = %v, want %v", indexed, want)
+	}
+
+	// Once tagged, the same commit is indexed under its tag
+	git("tag", "v0.3.0")
+	mv, err = c.IndexGitRepo(ctx, GitSource{URL: repo})
+	if err != nil {
+		t.Fatalf("IndexGitRepo() after tagging error = %v", err)
+	}
+	if mv.Version != "v0.3.0" {
+		t.Errorf("tagged version = %q, want v0.3.0", mv.Version)
+	}
+
+	versions, err := database.GetModuleVersions("example.com/private")
+	if err != nil || len(versions) != 2 {
+		t.Errorf("GetModuleVersions() = %d versions, %v, want both indexed versions", len(versions), err)
+	}
+
+	if _, err := c.IndexGitRepo(ctx, GitSource{URL: repo, Ref: "no-such-branch"}); err == nil {
+		t.Error("IndexGitRepo() with a missing ref succeeded, want an error")
+	}
+}
@@ -4,12 +4,15 @@ import (
 	"archive/zip"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,6 +24,10 @@ const (
 	GitHubAPIURL = "https://api.github.com"
 )
 
+// ErrNotModified is returned by IndexRepository when GitHub reports that the
+// repository is unchanged since it was last indexed
+var ErrNotModified = errors.New("repository is unchanged since it was last indexed")
+
 // GitHubRepository represents a GitHub repository
 type GitHubRepository struct {
 	Name            string    `json:"name"`
@@ -50,6 +57,16 @@ type GitHubCrawler struct {
 	tempDir   string
 	rateLimit time.Duration
 	token     string // GitHub API token (optional, for higher rate limits)
+	apiURL    string
+	sleep     func(time.Duration)
+	resetAt   time.Time // when the API quota is replenished, once it has run out
+
+	// MinStars skips repositories with fewer stars (0 = no minimum)
+	MinStars int
+	// Force re-indexes repositories that are unchanged since they were last indexed
+	Force bool
+	// Extensions are the file extensions parsed for symbols (nil = jsparser.DefaultExtensions)
+	Extensions []string
 }
 
 // NewGitHubCrawler creates a new GitHub crawler
@@ -66,6 +83,8 @@ func NewGitHubCrawler(database *db.DB, token string) (*GitHubCrawler, error) {
 		tempDir:   tempDir,
 		rateLimit: 1 * time.Second, // GitHub rate limiting (60 req/hour without auth, 5000 with)
 		token:     token,
+		apiURL:    GitHubAPIURL,
+		sleep:     time.Sleep,
 	}, nil
 }
 
@@ -76,66 +95,149 @@ func (c *GitHubCrawler) Close() error {
 
 // makeRequest makes an authenticated GitHub API request
 func (c *GitHubCrawler) makeRequest(url string) (*http.Response, error) {
-	time.Sleep(c.rateLimit)
+	return c.conditionalRequest(url, "")
+}
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
+// conditionalRequest makes an authenticated GitHub API request, sending etag as
+// If-None-Match when set. Once the quota runs out it sleeps until GitHub resets
+// it, and a request rejected for exceeding the rate limit is retried once.
+func (c *GitHubCrawler) conditionalRequest(url, etag string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if wait := time.Until(c.resetAt); wait > 0 {
+			log.Printf("GitHub rate limit exhausted, waiting %s for it to reset", wait.Round(time.Second))
+			c.sleep(wait)
+		}
+		c.resetAt = time.Time{}
+		c.sleep(c.rateLimit)
+
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.token != "" {
+			req.Header.Set("Authorization", "token "+c.token)
+		}
+		req.Header.Set("Accept", "application/vnd.github.v3+json")
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		limited := c.recordRateLimit(resp)
+		if limited && attempt == 0 {
+			resp.Body.Close()
+			continue
+		}
+		return resp, nil
+	}
+}
+
+// recordRateLimit notes when the quota resets if a response used it up, and
+// reports whether the request itself was rejected by the rate limit
+func (c *GitHubCrawler) recordRateLimit(resp *http.Response) bool {
+	exhausted := resp.Header.Get("X-RateLimit-Remaining") == "0"
+	if exhausted {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			// A second of slack for clock skew between us and GitHub
+			c.resetAt = time.Unix(reset, 0).Add(time.Second)
+		}
 	}
 
-	if c.token != "" {
-		req.Header.Set("Authorization", "token "+c.token)
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return false
 	}
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	// Secondary rate limits say how long to back off instead
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		c.resetAt = time.Now().Add(time.Duration(seconds) * time.Second)
+		return true
+	}
+	return exhausted
+}
 
-	return c.client.Do(req)
+// nextPageURL returns the rel="next" URL of a paginated response's Link header
+func nextPageURL(header http.Header) string {
+	for _, link := range strings.Split(header.Get("Link"), ",") {
+		target, params, ok := strings.Cut(link, ";")
+		if ok && strings.Contains(params, `rel="next"`) {
+			return strings.Trim(strings.TrimSpace(target), "<>")
+		}
+	}
+	return ""
 }
 
-// SearchRepositories searches GitHub for JavaScript/TypeScript repositories
+// SearchRepositories searches GitHub for JavaScript/TypeScript repositories,
+// following result pages until limit repositories are found
 func (c *GitHubCrawler) SearchRepositories(query string, limit int) ([]*GitHubRepository, error) {
 	// Default query for JS/TS repositories
 	searchQuery := fmt.Sprintf("%s language:javascript OR language:typescript", query)
-	url := fmt.Sprintf("%s/search/repositories?q=%s&sort=stars&order=desc&per_page=%d",
-		GitHubAPIURL, searchQuery, limit)
+	perPage := min(limit, 100) // the most GitHub returns per page
+	pageURL := fmt.Sprintf("%s/search/repositories?q=%s&sort=stars&order=desc&per_page=%d",
+		c.apiURL, url.QueryEscape(searchQuery), perPage)
 
-	resp, err := c.makeRequest(url)
-	if err != nil {
-		return nil, fmt.Errorf("searching repositories: %w", err)
-	}
-	defer resp.Body.Close()
+	var repositories []*GitHubRepository
+	for pageURL != "" && len(repositories) < limit {
+		resp, err := c.makeRequest(pageURL)
+		if err != nil {
+			return nil, fmt.Errorf("searching repositories: %w", err)
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("search failed: status %d", resp.StatusCode)
-	}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("search failed: status %d", resp.StatusCode)
+		}
 
-	var result struct {
-		Items []*GitHubRepository `json:"items"`
-	}
+		var result struct {
+			Items []*GitHubRepository `json:"items"`
+		}
+
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decoding results: %w", err)
+		}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decoding results: %w", err)
+		repositories = append(repositories, result.Items...)
+		pageURL = nextPageURL(resp.Header)
 	}
 
-	return result.Items, nil
+	if len(repositories) > limit {
+		repositories = repositories[:limit]
+	}
+	return repositories, nil
 }
 
 // FetchRepository fetches detailed repository information
 func (c *GitHubCrawler) FetchRepository(owner, repo string) (*GitHubRepository, error) {
-	url := fmt.Sprintf("%s/repos/%s/%s", GitHubAPIURL, owner, repo)
+	repository, _, err := c.fetchRepository(owner, repo, "")
+	return repository, err
+}
 
-	resp, err := c.makeRequest(url)
+// fetchRepository fetches repository information unless its ETag still matches
+// etag, in which case it returns ErrNotModified. Conditional requests answered
+// with 304 Not Modified do not count against the rate limit.
+func (c *GitHubCrawler) fetchRepository(owner, repo, etag string) (*GitHubRepository, string, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s", c.apiURL, owner, repo)
+
+	resp, err := c.conditionalRequest(url, etag)
 	if err != nil {
-		return nil, fmt.Errorf("fetching repository: %w", err)
+		return nil, "", fmt.Errorf("fetching repository: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, etag, ErrNotModified
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("repository not found: %s/%s", owner, repo)
+		return nil, "", fmt.Errorf("repository not found: %s/%s", owner, repo)
 	}
 
 	var repository GitHubRepository
 	if err := json.NewDecoder(resp.Body).Decode(&repository); err != nil {
-		return nil, fmt.Errorf("decoding repository: %w", err)
+		return nil, "", fmt.Errorf("decoding repository: %w", err)
 	}
 
 	// Check for package.json
@@ -145,12 +247,12 @@ func (c *GitHubCrawler) FetchRepository(owner, repo string) (*GitHubRepository,
 	}
 	repository.HasPackageJSON = hasPackageJSON
 
-	return &repository, nil
+	return &repository, resp.Header.Get("ETag"), nil
 }
 
 // hasFile checks if a file exists in the repository
 func (c *GitHubCrawler) hasFile(owner, repo, path string) (bool, error) {
-	url := fmt.Sprintf("%s/repos/%s/%s/contents/%s", GitHubAPIURL, owner, repo, path)
+	url := fmt.Sprintf("%s/repos/%s/%s/contents/%s", c.apiURL, owner, repo, path)
 
 	resp, err := c.makeRequest(url)
 	if err != nil {
@@ -171,7 +273,7 @@ func (c *GitHubCrawler) DownloadRepository(repo *GitHubRepository) (string, erro
 	owner, name := parts[0], parts[1]
 
 	url := fmt.Sprintf("%s/repos/%s/%s/zipball/%s",
-		GitHubAPIURL, owner, name, repo.DefaultBranch)
+		c.apiURL, owner, name, repo.DefaultBranch)
 
 	resp, err := c.makeRequest(url)
 	if err != nil {
@@ -259,8 +361,7 @@ func (c *GitHubCrawler) ParseRepositorySymbols(repoDir string) ([]jsparser.Symbo
 		}
 
 		// Parse JS/TS files
-		ext := filepath.Ext(path)
-		if ext == ".js" || ext == ".ts" || ext == ".jsx" || ext == ".tsx" {
+		if jsparser.HasExtension(path, jsExtensions(c.Extensions)) {
 			symbols, err := c.parser.ParseFile(path)
 			if err != nil {
 				log.Printf("Warning: failed to parse %s: %v", path, err)
@@ -279,15 +380,29 @@ func (c *GitHubCrawler) ParseRepositorySymbols(repoDir string) ([]jsparser.Symbo
 	return allSymbols, nil
 }
 
-// IndexRepository indexes a GitHub repository
+// IndexRepository indexes a GitHub repository. Repositories whose metadata has
+// not changed since they were last indexed are skipped with ErrNotModified.
 func (c *GitHubCrawler) IndexRepository(owner, repo string) error {
 	log.Printf("Indexing GitHub repository: %s/%s", owner, repo)
 
+	// The ETag of the last indexed metadata, which changes on every push
+	etagKey := "github_etag:" + owner + "/" + repo
+	var etag string
+	if c.db != nil && !c.Force {
+		etag, _ = c.db.GetMetadata(etagKey)
+	}
+
 	// Fetch repository metadata
-	repository, err := c.FetchRepository(owner, repo)
+	repository, etag, err := c.fetchRepository(owner, repo, etag)
+	if errors.Is(err, ErrNotModified) {
+		return err
+	}
 	if err != nil {
 		return fmt.Errorf("fetching repository: %w", err)
 	}
+	if err := checkPopularity(repository.Stars, c.MinStars, "stars"); err != nil {
+		return err
+	}
 
 	// Download repository
 	repoDir, err := c.DownloadRepository(repository)
@@ -362,6 +477,12 @@ func (c *GitHubCrawler) IndexRepository(owner, repo string) error {
 		}
 
 		log.Printf("Stored %d symbols (%d exported) in database", len(symbols), exportedCount)
+
+		if etag != "" {
+			if err := c.db.SetMetadata(etagKey, etag); err != nil {
+				log.Printf("Warning: failed to store ETag of %s/%s: %v", owner, repo, err)
+			}
+		}
 	}
 
 	return nil
@@ -0,0 +1,202 @@
+package crawler
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alexisbouchez/wikigo/db"
+)
+
+// newTestGitHubCrawler returns a crawler for the API served by handler that
+// records how long it would have slept instead of sleeping
+func newTestGitHubCrawler(t *testing.T, database *db.DB, handler http.HandlerFunc) (*GitHubCrawler, *[]time.Duration) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	c, err := NewGitHubCrawler(database, "")
+	if err != nil {
+		t.Fatalf("NewGitHubCrawler() error = %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	var slept []time.Duration
+	c.rateLimit = 0
+	c.apiURL = srv.URL
+	c.sleep = func(d time.Duration) {
+		if d > 0 {
+			slept = append(slept, d)
+		}
+	}
+	return c, &slept
+}
+
+func TestGitHubRateLimit(t *testing.T) {
+	reset := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	requests := 0
+	c, slept := newTestGitHubCrawler(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch requests {
+		case 1: // the last request of the quota succeeds
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", reset)
+			fmt.Fprint(w, `{"full_name": "a/b"}`)
+		case 3: // a secondary rate limit
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.Header().Set("X-RateLimit-Remaining", "4999")
+			fmt.Fprint(w, `{"full_name": "a/b"}`)
+		}
+	})
+
+	resp, err := c.makeRequest(c.apiURL + "/repos/a/b")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("makeRequest() = %v, %v", resp, err)
+	}
+	resp.Body.Close()
+	if len(*slept) != 0 {
+		t.Errorf("slept %v before the quota ran out", *slept)
+	}
+
+	// The next request waits for the reset instead of failing
+	resp, err = c.makeRequest(c.apiURL + "/repos/a/b")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("makeRequest() after exhausting the quota = %v, %v", resp, err)
+	}
+	resp.Body.Close()
+	if len(*slept) != 1 || (*slept)[0] < 59*time.Minute || (*slept)[0] > 61*time.Minute {
+		t.Errorf("slept %v, want about an hour until the reset", *slept)
+	}
+
+	// A rejected request is retried after the Retry-After delay
+	resp, err = c.makeRequest(c.apiURL + "/repos/a/b")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("makeRequest() after a secondary rate limit = %v, %v", resp, err)
+	}
+	resp.Body.Close()
+	if requests != 4 || len(*slept) != 2 || (*slept)[1] < 59*time.Second || (*slept)[1] > time.Minute {
+		t.Errorf("requests = %d, slept %v, want a retry after about a minute", requests, *slept)
+	}
+}
+
+func TestNextPageURL(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"", ""},
+		{`<https://api.github.com/search/repositories?q=x&page=2>; rel="next", <https://api.github.com/search/repositories?q=x&page=5>; rel="last"`, "https://api.github.com/search/repositories?q=x&page=2"},
+		{`<https://api.github.com/search/repositories?q=x&page=1>; rel="prev", <https://api.github.com/search/repositories?q=x&page=1>; rel="first"`, ""},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		header.Set("Link", tt.link)
+		if got := nextPageURL(header); got != tt.want {
+			t.Errorf("nextPageURL(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestSearchRepositories_Pagination(t *testing.T) {
+	var srvURL string
+	c, _ := newTestGitHubCrawler(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("q"); got != "web framework language:javascript OR language:typescript" {
+			t.Errorf("q = %q", got)
+		}
+		if got := r.URL.Query().Get("per_page"); got != "100" {
+			t.Errorf("per_page = %q, want 100", got)
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		if page < 3 {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/search/repositories?q=web+framework+language%%3Ajavascript+OR+language%%3Atypescript&per_page=100&page=%d>; rel="next"`, srvURL, page+1))
+		}
+		var items bytes.Buffer
+		for i := range 100 {
+			if i > 0 {
+				items.WriteString(",")
+			}
+			fmt.Fprintf(&items, `{"full_name": "owner/repo-%d-%d"}`, page, i)
+		}
+		fmt.Fprintf(w, `{"items": [%s]}`, items.String())
+	})
+	srvURL = c.apiURL
+
+	repos, err := c.SearchRepositories("web framework", 150)
+	if err != nil {
+		t.Fatalf("SearchRepositories() error = %v", err)
+	}
+	if len(repos) != 150 || repos[0].FullName != "owner/repo-1-0" || repos[149].FullName != "owner/repo-2-49" {
+		t.Errorf("SearchRepositories() = %d repositories, want the first 150 across two pages", len(repos))
+	}
+}
+
+func TestIndexRepository_NotModified(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("db.Open() error = %v", err)
+	}
+	defer database.Close()
+
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	f, _ := zw.Create("acme-widgets-abc123/index.js")
+	fmt.Fprint(f, "export function spin() {}\n")
+	zw.Close()
+
+	etag := `"v1"`
+	var paths []string
+	c, _ := newTestGitHubCrawler(t, database, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/repos/acme/widgets":
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			fmt.Fprint(w, `{"full_name": "acme/widgets", "default_branch": "main", "stargazers_count": 3}`)
+		case "/repos/acme/widgets/zipball/main":
+			w.Write(archive.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	if err := c.IndexRepository("acme", "widgets"); err != nil {
+		t.Fatalf("IndexRepository() error = %v", err)
+	}
+	if pkg, _ := database.GetJSPackage("acme/widgets"); pkg == nil {
+		t.Fatal("IndexRepository() did not store the repository")
+	}
+
+	// Unchanged: one conditional request, nothing downloaded
+	paths = nil
+	if err := c.IndexRepository("acme", "widgets"); !errors.Is(err, ErrNotModified) {
+		t.Fatalf("IndexRepository() of an unchanged repository error = %v, want ErrNotModified", err)
+	}
+	if len(paths) != 1 {
+		t.Errorf("unchanged repository made requests %v, want only the metadata", paths)
+	}
+
+	// A push changes the ETag and the repository is indexed again
+	etag = `"v2"`
+	paths = nil
+	if err := c.IndexRepository("acme", "widgets"); err != nil {
+		t.Fatalf("IndexRepository() after a change error = %v", err)
+	}
+	if len(paths) < 2 {
+		t.Errorf("changed repository made requests %v, want it downloaded again", paths)
+	}
+}
@@ -18,8 +18,9 @@ import (
 )
 
 const (
-	NPMRegistryURL = "https://registry.npmjs.org"
-	NPMSearchURL   = "https://registry.npmjs.org/-/v1/search"
+	NPMRegistryURL  = "https://registry.npmjs.org"
+	NPMSearchURL    = "https://registry.npmjs.org/-/v1/search"
+	NPMDownloadsURL = "https://api.npmjs.org/downloads/point/last-week"
 )
 
 // cleanRepoURL normalizes git repository URLs for web display
@@ -29,21 +30,56 @@ func cleanRepoURL(url string) string {
 	return url
 }
 
+// githubRepo extracts the owner and name from the forms package.json uses for
+// GitHub repositories
+func githubRepo(url string) (owner, repo string, ok bool) {
+	url = cleanRepoURL(url)
+	for _, prefix := range []string{"https://github.com/", "http://github.com/", "git://github.com/", "ssh://git@github.com/", "git@github.com:", "github:"} {
+		if rest, found := strings.CutPrefix(url, prefix); found {
+			owner, repo, _ = strings.Cut(rest, "/")
+			repo, _, _ = strings.Cut(repo, "/")
+			repo, _, _ = strings.Cut(repo, "#")
+			if owner != "" && repo != "" {
+				return owner, repo, true
+			}
+			break
+		}
+	}
+	return "", "", false
+}
+
 // NPMPackage represents npm package metadata
 type NPMPackage struct {
-	Name        string            `json:"name"`
-	Version     string            `json:"version"`
-	Description string            `json:"description"`
-	Keywords    []string          `json:"keywords"`
-	Author      NPMPerson         `json:"author"`
-	License     string            `json:"license"`
-	Repository  NPMRepository     `json:"repository"`
-	Homepage    string            `json:"homepage"`
-	Main        string            `json:"main"`
-	Types       string            `json:"types"`
-	TypeScript  bool              `json:"-"`
-	Dist        NPMDist           `json:"dist"`
-	Dependencies map[string]string `json:"dependencies"`
+	Name                 string                `json:"name"`
+	Version              string                `json:"version"`
+	Description          string                `json:"description"`
+	Keywords             []string              `json:"keywords"`
+	Author               NPMPerson             `json:"author"`
+	License              string                `json:"license"`
+	Repository           NPMRepository         `json:"repository"`
+	Homepage             string                `json:"homepage"`
+	Main                 string                `json:"main"`
+	Types                string                `json:"types"`
+	TypeScript           bool                  `json:"-"`
+	TarballSize          int64                 `json:"-"`
+	Releases             []*db.RegistryVersion `json:"-"`
+	Dist                 NPMDist               `json:"dist"`
+	Dependencies         map[string]string     `json:"dependencies"`
+	PeerDependencies     map[string]string     `json:"peerDependencies"`
+	DevDependencies      map[string]string     `json:"devDependencies"`
+	OptionalDependencies map[string]string     `json:"optionalDependencies"`
+}
+
+// runtimeDependencies returns the dependencies that are not also listed as optional.
+// npm copies optionalDependencies into dependencies when publishing.
+func (p *NPMPackage) runtimeDependencies() map[string]string {
+	deps := make(map[string]string, len(p.Dependencies))
+	for name, versionRange := range p.Dependencies {
+		if _, ok := p.OptionalDependencies[name]; !ok {
+			deps[name] = versionRange
+		}
+	}
+	return deps
 }
 
 // NPMPerson represents package author/maintainer
@@ -60,7 +96,20 @@ type NPMRepository struct {
 
 // NPMDist represents distribution info
 type NPMDist struct {
-	Tarball string `json:"tarball"`
+	Tarball      string `json:"tarball"`
+	UnpackedSize int64  `json:"unpackedSize"`
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
 }
 
 // NPMCrawler fetches and indexes NPM packages
@@ -70,6 +119,26 @@ type NPMCrawler struct {
 	parser    *jsparser.Parser
 	tempDir   string
 	rateLimit time.Duration
+
+	// Overridden in tests
+	downloadsURL string
+	githubAPIURL string
+
+	// MinAge skips packages indexed more recently than this (0 = always fetch)
+	MinAge time.Duration
+
+	// MinDownloads skips packages with fewer downloads last week (0 = no minimum)
+	MinDownloads int
+
+	// MinStars skips packages whose GitHub repository has fewer stars; packages
+	// not hosted on GitHub count as having none (0 = no minimum)
+	MinStars int
+
+	// GitHubToken authenticates the star lookups (optional, for higher rate limits)
+	GitHubToken string
+
+	// Extensions are the file extensions parsed for symbols (nil = jsparser.DefaultExtensions)
+	Extensions []string
 }
 
 // NewNPMCrawler creates a new NPM package crawler
@@ -85,6 +154,9 @@ func NewNPMCrawler(database *db.DB) (*NPMCrawler, error) {
 		parser:    jsparser.NewParser(),
 		tempDir:   tempDir,
 		rateLimit: 100 * time.Millisecond, // npm rate limiting
+
+		downloadsURL: NPMDownloadsURL,
+		githubAPIURL: GitHubAPIURL,
 	}, nil
 }
 
@@ -111,6 +183,7 @@ func (c *NPMCrawler) FetchPackage(name string) (*NPMPackage, error) {
 	var data struct {
 		DistTags map[string]string          `json:"dist-tags"`
 		Versions map[string]json.RawMessage `json:"versions"`
+		Time     map[string]time.Time       `json:"time"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
@@ -132,9 +205,83 @@ func (c *NPMCrawler) FetchPackage(name string) (*NPMPackage, error) {
 	// Check if package has TypeScript support
 	pkg.TypeScript = pkg.Types != "" || strings.HasSuffix(pkg.Main, ".ts")
 
+	// Record every published version with its publish time
+	for version := range data.Versions {
+		pkg.Releases = append(pkg.Releases, &db.RegistryVersion{
+			Version:     version,
+			PublishedAt: data.Time[version],
+		})
+	}
+
 	return &pkg, nil
 }
 
+// FetchWeeklyDownloads returns how many times a package was downloaded last week
+func (c *NPMCrawler) FetchWeeklyDownloads(name string) (int, error) {
+	time.Sleep(c.rateLimit)
+
+	resp, err := c.client.Get(fmt.Sprintf("%s/%s", c.downloadsURL, name))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Packages that were never downloaded are reported as not found
+	if resp.StatusCode == http.StatusNotFound {
+		return 0, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	var data struct {
+		Downloads int `json:"downloads"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return 0, fmt.Errorf("decoding response: %w", err)
+	}
+	return data.Downloads, nil
+}
+
+// FetchStars returns the star count of a package's GitHub repository, or 0 when
+// the repository is not hosted on GitHub
+func (c *NPMCrawler) FetchStars(repositoryURL string) (int, error) {
+	owner, repo, ok := githubRepo(repositoryURL)
+	if !ok {
+		return 0, nil
+	}
+
+	time.Sleep(c.rateLimit)
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/repos/%s/%s", c.githubAPIURL, owner, repo), nil)
+	if err != nil {
+		return 0, err
+	}
+	if c.GitHubToken != "" {
+		req.Header.Set("Authorization", "token "+c.GitHubToken)
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return 0, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	var repository GitHubRepository
+	if err := json.NewDecoder(resp.Body).Decode(&repository); err != nil {
+		return 0, fmt.Errorf("decoding repository: %w", err)
+	}
+	return repository.Stars, nil
+}
+
 // DownloadPackage downloads and extracts package tarball
 func (c *NPMCrawler) DownloadPackage(pkg *NPMPackage) (string, error) {
 	time.Sleep(c.rateLimit)
@@ -155,14 +302,16 @@ func (c *NPMCrawler) DownloadPackage(pkg *NPMPackage) (string, error) {
 		return "", fmt.Errorf("creating extract dir: %w", err)
 	}
 
-	// Decompress gzip
-	gzr, err := gzip.NewReader(resp.Body)
+	// Decompress gzip, counting compressed bytes for the tarball size
+	body := &countingReader{r: resp.Body}
+	gzr, err := gzip.NewReader(body)
 	if err != nil {
 		return "", fmt.Errorf("creating gzip reader: %w", err)
 	}
 	defer gzr.Close()
 
 	// Extract tar
+	var unpackedSize int64
 	tr := tar.NewReader(gzr)
 	for {
 		header, err := tr.Next()
@@ -177,6 +326,7 @@ func (c *NPMCrawler) DownloadPackage(pkg *NPMPackage) (string, error) {
 		if header.Typeflag != tar.TypeReg {
 			continue
 		}
+		unpackedSize += header.Size
 
 		// Remove "package/" prefix from tar paths
 		targetPath := filepath.Join(extractDir, strings.TrimPrefix(header.Name, "package/"))
@@ -199,6 +349,14 @@ func (c *NPMCrawler) DownloadPackage(pkg *NPMPackage) (string, error) {
 		outFile.Close()
 	}
 
+	// Drain any trailing padding so the whole tarball is counted
+	io.Copy(io.Discard, body)
+	pkg.TarballSize = body.n
+	// Older registry entries lack dist.unpackedSize
+	if pkg.Dist.UnpackedSize == 0 {
+		pkg.Dist.UnpackedSize = unpackedSize
+	}
+
 	return extractDir, nil
 }
 
@@ -220,8 +378,7 @@ func (c *NPMCrawler) ParsePackageSymbols(pkgDir string) ([]jsparser.Symbol, erro
 		}
 
 		// Parse JS/TS files
-		ext := filepath.Ext(path)
-		if ext == ".js" || ext == ".ts" || ext == ".jsx" || ext == ".tsx" {
+		if jsparser.HasExtension(path, jsExtensions(c.Extensions)) {
 			symbols, err := c.parser.ParseFile(path)
 			if err != nil {
 				log.Printf("Warning: failed to parse %s: %v", path, err)
@@ -240,8 +397,33 @@ func (c *NPMCrawler) ParsePackageSymbols(pkgDir string) ([]jsparser.Symbol, erro
 	return allSymbols, nil
 }
 
+// jsExtensions returns the configured file extensions parsed for symbols, or
+// the default ones
+func jsExtensions(extensions []string) []string {
+	if extensions == nil {
+		return jsparser.DefaultExtensions
+	}
+	return extensions
+}
+
 // IndexPackage indexes an NPM package into the database
 func (c *NPMCrawler) IndexPackage(name string) error {
+	if c.db != nil && c.MinAge > 0 {
+		if existing, err := c.db.GetJSPackage(name); err == nil && existing != nil && indexedWithin(existing.IndexedAt, c.MinAge) {
+			return ErrRecentlyIndexed
+		}
+	}
+
+	if c.MinDownloads > 0 {
+		downloads, err := c.FetchWeeklyDownloads(name)
+		if err != nil {
+			return fmt.Errorf("fetching downloads: %w", err)
+		}
+		if err := checkPopularity(downloads, c.MinDownloads, "downloads last week"); err != nil {
+			return err
+		}
+	}
+
 	log.Printf("Indexing NPM package: %s", name)
 
 	// Fetch metadata
@@ -250,6 +432,16 @@ func (c *NPMCrawler) IndexPackage(name string) error {
 		return fmt.Errorf("fetching package: %w", err)
 	}
 
+	if c.MinStars > 0 {
+		stars, err := c.FetchStars(pkg.Repository.URL)
+		if err != nil {
+			return fmt.Errorf("fetching stars: %w", err)
+		}
+		if err := checkPopularity(stars, c.MinStars, "stars"); err != nil {
+			return err
+		}
+	}
+
 	// Download and extract
 	pkgDir, err := c.DownloadPackage(pkg)
 	if err != nil {
@@ -268,19 +460,24 @@ func (c *NPMCrawler) IndexPackage(name string) error {
 	// Store package in database
 	if c.db != nil {
 		dbPkg := &db.JSPackage{
-			Name:          pkg.Name,
-			Version:       pkg.Version,
-			Description:   pkg.Description,
-			Author:        pkg.Author.Name,
-			License:       pkg.License,
-			RepositoryURL: cleanRepoURL(pkg.Repository.URL),
-			Homepage:      pkg.Homepage,
-			NPMURL:        fmt.Sprintf("https://www.npmjs.com/package/%s", pkg.Name),
-			MainFile:      pkg.Main,
-			TypesFile:     pkg.Types,
-			HasTypeScript: pkg.TypeScript,
-			Keywords:      pkg.Keywords,
-			Dependencies:  pkg.Dependencies,
+			Name:                 pkg.Name,
+			Version:              pkg.Version,
+			Description:          pkg.Description,
+			Author:               pkg.Author.Name,
+			License:              pkg.License,
+			RepositoryURL:        cleanRepoURL(pkg.Repository.URL),
+			Homepage:             pkg.Homepage,
+			NPMURL:               fmt.Sprintf("https://www.npmjs.com/package/%s", pkg.Name),
+			MainFile:             pkg.Main,
+			TypesFile:            pkg.Types,
+			HasTypeScript:        pkg.TypeScript,
+			Keywords:             pkg.Keywords,
+			Dependencies:         pkg.runtimeDependencies(),
+			PeerDependencies:     pkg.PeerDependencies,
+			DevDependencies:      pkg.DevDependencies,
+			OptionalDependencies: pkg.OptionalDependencies,
+			UnpackedSize:         pkg.Dist.UnpackedSize,
+			TarballSize:          pkg.TarballSize,
 		}
 
 		pkgID, err := c.db.UpsertJSPackage(dbPkg)
@@ -288,6 +485,10 @@ func (c *NPMCrawler) IndexPackage(name string) error {
 			return fmt.Errorf("storing package: %w", err)
 		}
 
+		if err := c.db.ReplaceRegistryVersions(db.EcosystemNPM, pkg.Name, pkg.Releases); err != nil {
+			return fmt.Errorf("storing versions: %w", err)
+		}
+
 		// Delete old symbols
 		if err := c.db.DeleteJSPackageSymbols(pkgID); err != nil {
 			return fmt.Errorf("deleting old symbols: %w", err)
@@ -1,7 +1,18 @@
 package crawler
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
+	"time"
+
+	"github.com/alexisbouchez/wikigo/db"
 )
 
 func TestFetchPackage(t *testing.T) {
@@ -89,3 +100,246 @@ func TestDownloadPackage(t *testing.T) {
 		t.Error("Expected directory path to be returned")
 	}
 }
+
+func TestParsePackageSymbols_ModuleFiles(t *testing.T) {
+	crawler, err := NewNPMCrawler(nil)
+	if err != nil {
+		t.Fatalf("NewNPMCrawler() error = %v", err)
+	}
+	defer crawler.Close()
+
+	// A package shipping only ES modules, CommonJS and type declarations
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.mjs":     "export function parse(input) {}\nexport { parse as decode };\n",
+		"index.cjs":     "exports.format = function (v) { return v; };\n",
+		"index.d.ts":    "export declare function parse(input: string): unknown;\n",
+		"index.js.map":  `{"version":3}`,
+		"package.json":  `{"name":"esm-only"}`,
+		"test/a.mjs":    "export function skipped() {}\n",
+		"lib/util.mjs":  "export const VERSION = \"1.0.0\";\n",
+		"lib/types.mts": "export type ID = string;\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	symbols, err := crawler.ParsePackageSymbols(dir)
+	if err != nil {
+		t.Fatalf("ParsePackageSymbols() error = %v", err)
+	}
+	var got []string
+	for _, sym := range symbols {
+		got = append(got, filepath.Base(sym.FilePath)+":"+sym.Name)
+	}
+	slices.Sort(got)
+	want := []string{"index.cjs:format", "index.d.ts:parse", "index.mjs:decode", "index.mjs:parse", "types.mts:ID", "util.mjs:VERSION"}
+	if !slices.Equal(got, want) {
+		t.Errorf("symbols = %v, want %v", got, want)
+	}
+
+	// Limited to type declarations
+	crawler.Extensions = []string{".d.ts"}
+	symbols, err = crawler.ParsePackageSymbols(dir)
+	if err != nil {
+		t.Fatalf("ParsePackageSymbols() error = %v", err)
+	}
+	if len(symbols) != 1 || symbols[0].Name != "parse" || filepath.Base(symbols[0].FilePath) != "index.d.ts" {
+		t.Errorf("symbols with .d.ts only = %+v", symbols)
+	}
+}
+
+func TestNPMPackage_DependencyTypes(t *testing.T) {
+	manifest := `{
+		"name": "react-widgets",
+		"dependencies": {"classnames": "^2.3.0", "fsevents": "^2.3.2"},
+		"peerDependencies": {"react": ">=16.8.0"},
+		"devDependencies": {"jest": "^29.0.0"},
+		"optionalDependencies": {"fsevents": "^2.3.2"}
+	}`
+
+	var pkg NPMPackage
+	if err := json.Unmarshal([]byte(manifest), &pkg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if _, ok := pkg.PeerDependencies["react"]; !ok {
+		t.Error("Expected react to be a peer dependency")
+	}
+	if _, ok := pkg.DevDependencies["jest"]; !ok {
+		t.Error("Expected jest to be a dev dependency")
+	}
+
+	runtime := pkg.runtimeDependencies()
+	if _, ok := runtime["react"]; ok {
+		t.Error("Expected react not to be a runtime dependency")
+	}
+	if _, ok := runtime["fsevents"]; ok {
+		t.Error("Expected optional fsevents not to be a runtime dependency")
+	}
+	if runtime["classnames"] != "^2.3.0" {
+		t.Errorf("Expected classnames ^2.3.0 runtime dependency, got %q", runtime["classnames"])
+	}
+}
+
+func TestIndexPackage_SkipsRecentlyIndexed(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("db.Open() error = %v", err)
+	}
+	defer database.Close()
+
+	if _, err := database.UpsertJSPackage(&db.JSPackage{Name: "left-pad", Version: "1.3.0"}); err != nil {
+		t.Fatalf("UpsertJSPackage() error = %v", err)
+	}
+
+	crawler, err := NewNPMCrawler(database)
+	if err != nil {
+		t.Fatalf("NewNPMCrawler() error = %v", err)
+	}
+	defer crawler.Close()
+	crawler.MinAge = time.Hour
+
+	// The freshness check runs before any registry request is made
+	if err := crawler.IndexPackage("left-pad"); !errors.Is(err, ErrRecentlyIndexed) {
+		t.Errorf("IndexPackage() error = %v, want ErrRecentlyIndexed", err)
+	}
+}
+
+func TestIndexedWithin(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		indexedAt time.Time
+		minAge    time.Duration
+		want      bool
+	}{
+		{now.Add(-time.Minute), time.Hour, true},
+		{now.Add(-2 * time.Hour), time.Hour, false},
+		{now.Add(-time.Minute), 0, false},
+		{time.Time{}, time.Hour, false},
+	}
+	for _, tt := range tests {
+		if got := indexedWithin(tt.indexedAt, tt.minAge); got != tt.want {
+			t.Errorf("indexedWithin(%v, %v) = %v, want %v", tt.indexedAt, tt.minAge, got, tt.want)
+		}
+	}
+}
+
+func TestIndexPackage_SkipsBelowThreshold(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/downloads/left-pad":
+			fmt.Fprint(w, `{"downloads": 42, "package": "left-pad"}`)
+		case "/downloads/@scope/unused":
+			http.Error(w, `{"error": "package @scope/unused not found"}`, http.StatusNotFound)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	crawler, err := NewNPMCrawler(nil)
+	if err != nil {
+		t.Fatalf("NewNPMCrawler() error = %v", err)
+	}
+	defer crawler.Close()
+	crawler.rateLimit = 0
+	crawler.downloadsURL = srv.URL + "/downloads"
+	crawler.MinDownloads = 100
+
+	// The downloads check runs before the registry metadata is fetched
+	for _, name := range []string{"left-pad", "@scope/unused"} {
+		if err := crawler.IndexPackage(name); !errors.Is(err, ErrBelowThreshold) {
+			t.Errorf("IndexPackage(%q) error = %v, want ErrBelowThreshold", name, err)
+		}
+	}
+}
+
+func TestFetchStars(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "token secret" {
+			t.Errorf("Authorization = %q, want the GitHub token", got)
+		}
+		switch r.URL.Path {
+		case "/repos/expressjs/express":
+			fmt.Fprint(w, `{"full_name": "expressjs/express", "stargazers_count": 65000}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	crawler, err := NewNPMCrawler(nil)
+	if err != nil {
+		t.Fatalf("NewNPMCrawler() error = %v", err)
+	}
+	defer crawler.Close()
+	crawler.rateLimit = 0
+	crawler.githubAPIURL = srv.URL
+	crawler.GitHubToken = "secret"
+
+	tests := []struct {
+		repositoryURL string
+		want          int
+	}{
+		{"git+https://github.com/expressjs/express.git", 65000},
+		{"github:expressjs/express", 65000},
+		{"https://github.com/deleted/repo", 0},
+		{"https://gitlab.com/expressjs/express", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		got, err := crawler.FetchStars(tt.repositoryURL)
+		if err != nil || got != tt.want {
+			t.Errorf("FetchStars(%q) = %d, %v, want %d", tt.repositoryURL, got, err, tt.want)
+		}
+	}
+}
+
+func TestGithubRepo(t *testing.T) {
+	tests := []struct {
+		url         string
+		owner, repo string
+		ok          bool
+	}{
+		{"git+https://github.com/facebook/react.git", "facebook", "react", true},
+		{"https://github.com/babel/babel/tree/main/packages/babel-core", "babel", "babel", true},
+		{"git://github.com/lodash/lodash.git", "lodash", "lodash", true},
+		{"git+ssh://git@github.com/vuejs/core.git", "vuejs", "core", true},
+		{"git@github.com:sindresorhus/got.git", "sindresorhus", "got", true},
+		{"github:user/repo#v1.0.0", "user", "repo", true},
+		{"https://github.com/user", "", "", false},
+		{"https://bitbucket.org/user/repo", "", "", false},
+	}
+	for _, tt := range tests {
+		owner, repo, ok := githubRepo(tt.url)
+		if owner != tt.owner || repo != tt.repo || ok != tt.ok {
+			t.Errorf("githubRepo(%q) = %q, %q, %v, want %q, %q, %v", tt.url, owner, repo, ok, tt.owner, tt.repo, tt.ok)
+		}
+	}
+}
+
+func TestCheckPopularity(t *testing.T) {
+	tests := []struct {
+		count, minimum int
+		wantErr        bool
+	}{
+		{99, 100, true},
+		{100, 100, false},
+		{0, 0, false},
+		{0, 1, true},
+	}
+	for _, tt := range tests {
+		err := checkPopularity(tt.count, tt.minimum, "stars")
+		if got := errors.Is(err, ErrBelowThreshold); got != tt.wantErr {
+			t.Errorf("checkPopularity(%d, %d) = %v, want below threshold %v", tt.count, tt.minimum, err, tt.wantErr)
+		}
+	}
+}
@@ -68,6 +68,9 @@ type PackagistCrawler struct {
 	parser    *phpparser.Parser
 	tempDir   string
 	rateLimit time.Duration
+
+	// MinAge skips packages indexed more recently than this (0 = always fetch)
+	MinAge time.Duration
 }
 
 // NewPackagistCrawler creates a new Packagist crawler
@@ -279,6 +282,12 @@ func (c *PackagistCrawler) ParsePackageSymbols(pkgDir string) ([]phpparser.Symbo
 
 // IndexPackage indexes a package from Packagist
 func (c *PackagistCrawler) IndexPackage(name string) error {
+	if c.db != nil && c.MinAge > 0 {
+		if existing, err := c.db.GetPHPPackage(name); err == nil && existing != nil && indexedWithin(existing.IndexedAt, c.MinAge) {
+			return ErrRecentlyIndexed
+		}
+	}
+
 	log.Printf("Fetching package metadata: %s", name)
 
 	pkg, err := c.FetchPackage(name)
@@ -0,0 +1,299 @@
+package crawler
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeProxy serves an index and module zips built from testdata/proxy/<module>@<version>
+// directories, mimicking index.golang.org and proxy.golang.org
+func fakeProxy(t *testing.T, versions []ModuleVersion) *httptest.Server {
+	t.Helper()
+
+	zips := make(map[string][]byte)
+	for _, mv := range versions {
+		zips[escapeModulePath(mv.Path)+"/@v/"+mv.Version] = moduleZip(t, mv)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/index", func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		for _, mv := range versions {
+			enc.Encode(mv)
+		}
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/")
+		switch {
+		case strings.HasSuffix(path, ".zip"):
+			data, ok := zips[strings.TrimSuffix(path, ".zip")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "application/zip")
+			w.Write(data)
+		case strings.HasSuffix(path, ".info"):
+			for _, mv := range versions {
+				if escapeModulePath(mv.Path)+"/@v/"+mv.Version+".info" == path {
+					json.NewEncoder(w).Encode(struct{ Version, Time string }{mv.Version, mv.Timestamp.Format(time.RFC3339)})
+					return
+				}
+			}
+			http.NotFound(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// moduleZip zips a fixture directory with the module@version/ prefix the proxy uses
+func moduleZip(t *testing.T, mv ModuleVersion) []byte {
+	t.Helper()
+
+	prefix := mv.Path + "@" + mv.Version
+	root := filepath.Join("testdata", "proxy", filepath.FromSlash(prefix))
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		w, err := zw.Create(prefix + "/" + filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("zipping %s: %v", root, err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zipping %s: %v", root, err)
+	}
+	return buf.Bytes()
+}
+
+func TestRun_FakeProxy(t *testing.T) {
+	published := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	versions := []ModuleVersion{
+		{Path: "example.com/greet", Version: "v1.0.0", Timestamp: published},
+		{Path: "example.com/greet", Version: "v1.1.0", Timestamp: published.Add(24 * time.Hour)},
+	}
+	srv := fakeProxy(t, versions)
+
+	c, err := New(Config{
+		DBPath:    filepath.Join(t.TempDir(), "test.db"),
+		Workers:   1, // index order decides which version ends up indexed
+		RateLimit: time.Millisecond,
+		TempDir:   t.TempDir(),
+		ProxyURL:  srv.URL,
+		IndexURL:  srv.URL + "/index",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := c.Run(ctx, time.Time{}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	stats := c.stats
+	if stats.ModulesProcessed != 2 || stats.ModulesFailed != 0 {
+		t.Errorf("stats = %+v, want 2 modules processed and none failed", stats)
+	}
+
+	database := c.GetDB()
+
+	dbVersions, err := database.GetModuleVersions("example.com/greet")
+	if err != nil {
+		t.Fatalf("GetModuleVersions() error = %v", err)
+	}
+	var gotVersions []string
+	for _, v := range dbVersions {
+		gotVersions = append(gotVersions, v.Version)
+		if !v.IsTagged || !v.IsStable {
+			t.Errorf("version %s: IsTagged = %v, IsStable = %v, want both true", v.Version, v.IsTagged, v.IsStable)
+		}
+	}
+	slices.Sort(gotVersions)
+	if want := []string{"v1.0.0", "v1.1.0"}; !slices.Equal(gotVersions, want) {
+		t.Errorf("module versions = %v, want %v", gotVersions, want)
+	}
+
+	pkg, err := database.GetPackage("example.com/greet")
+	if err != nil || pkg == nil {
+		t.Fatalf("GetPackage(example.com/greet) = %v, %v", pkg, err)
+	}
+	if pkg.Name != "greet" || pkg.Synopsis != "Package greet builds greetings." {
+		t.Errorf("package name, synopsis = %q, %q", pkg.Name, pkg.Synopsis)
+	}
+	if pkg.Version != "v1.1.0" {
+		t.Errorf("package version = %q, want v1.1.0", pkg.Version)
+	}
+	if pkg.ModulePath != "example.com/greet" || pkg.GoVersion != "1.21" {
+		t.Errorf("module path, go version = %q, %q", pkg.ModulePath, pkg.GoVersion)
+	}
+	if pkg.License != "MIT" || !pkg.Redistributable {
+		t.Errorf("license = %q, redistributable = %v, want MIT and true", pkg.License, pkg.Redistributable)
+	}
+
+	symbols, err := database.GetPackageSymbols(pkg.ID)
+	if err != nil {
+		t.Fatalf("GetPackageSymbols() error = %v", err)
+	}
+	var gotSymbols []string
+	for _, sym := range symbols {
+		gotSymbols = append(gotSymbols, fmt.Sprintf("%s %s", sym.Kind, sym.Name))
+	}
+	slices.Sort(gotSymbols)
+	wantSymbols := []string{
+		"const DefaultName",
+		"func Goodbye", // added in v1.1.0
+		"func Hello",
+		"method Greeter.Greet",
+		"type Greeter",
+	}
+	if !slices.Equal(gotSymbols, wantSymbols) {
+		t.Errorf("symbols = %v, want %v", gotSymbols, wantSymbols)
+	}
+
+	sub, err := database.GetPackage("example.com/greet/loud")
+	if err != nil || sub == nil {
+		t.Fatalf("GetPackage(example.com/greet/loud) = %v, %v", sub, err)
+	}
+	if sub.ModulePath != "example.com/greet" {
+		t.Errorf("loud module path = %q, want example.com/greet", sub.ModulePath)
+	}
+
+	importers, _, err := database.GetImportedBy("example.com/greet", 10, 0)
+	if err != nil {
+		t.Fatalf("GetImportedBy() error = %v", err)
+	}
+	var importerPaths []string
+	for _, p := range importers {
+		importerPaths = append(importerPaths, p.ImportPath)
+	}
+	if !slices.Equal(importerPaths, []string{"example.com/greet/loud"}) {
+		t.Errorf("importers of example.com/greet = %v, want [example.com/greet/loud]", importerPaths)
+	}
+
+	if last, err := database.GetLastCrawlTime(); err != nil || last.IsZero() {
+		t.Errorf("GetLastCrawlTime() = %v, %v, want the time of this crawl", last, err)
+	}
+}
+
+func TestProcessModule_RecordsCrawlError(t *testing.T) {
+	srv := fakeProxy(t, nil)
+
+	c, err := New(Config{
+		DBPath:   filepath.Join(t.TempDir(), "test.db"),
+		TempDir:  t.TempDir(),
+		ProxyURL: srv.URL,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	mv := ModuleVersion{Path: "example.com/missing", Version: "v0.1.0"}
+	if err := c.ProcessModulePublic(context.Background(), mv); err == nil {
+		t.Fatal("ProcessModulePublic() of a module the proxy lacks succeeded")
+	}
+
+	crawlErrors, err := c.GetDB().RecentCrawlErrors(10)
+	if err != nil {
+		t.Fatalf("RecentCrawlErrors() error = %v", err)
+	}
+	if len(crawlErrors) != 1 || crawlErrors[0].ModulePath != mv.Path || crawlErrors[0].Version != mv.Version ||
+		!strings.Contains(crawlErrors[0].Error, "downloading module") {
+		t.Errorf("crawl errors = %+v, want the failed download", crawlErrors)
+	}
+
+	// An interrupted crawl leaves no error behind
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.ProcessModulePublic(ctx, mv)
+	if crawlErrors, _ := c.GetDB().RecentCrawlErrors(10); len(crawlErrors) != 1 {
+		t.Errorf("crawl errors after cancel = %d, want 1", len(crawlErrors))
+	}
+}
+
+func TestRetryFailed(t *testing.T) {
+	published := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	greet := ModuleVersion{Path: "example.com/greet", Version: "v1.0.0", Timestamp: published}
+	srv := fakeProxy(t, []ModuleVersion{greet})
+
+	c, err := New(Config{
+		DBPath:    filepath.Join(t.TempDir(), "test.db"),
+		Workers:   2,
+		RateLimit: time.Millisecond,
+		TempDir:   t.TempDir(),
+		ProxyURL:  srv.URL,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+	database := c.GetDB()
+
+	// A transient failure of greet, and a module the proxy never had
+	c.recordModuleVersion(greet)
+	database.RecordCrawlError(greet.Path, greet.Version, "downloading module: connection reset")
+	database.RecordCrawlError(greet.Path, greet.Version, "downloading module: timeout")
+	database.RecordCrawlError("example.com/missing", "v0.1.0", "downloading module: status 404")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := c.RetryFailed(ctx); err != nil {
+		t.Fatalf("RetryFailed() error = %v", err)
+	}
+
+	if c.stats.ModulesSucceeded != 1 || c.stats.ModulesFailed != 1 {
+		t.Errorf("stats = %+v, want one success and one failure", c.stats)
+	}
+	if pkg, _ := database.GetPackage("example.com/greet"); pkg == nil {
+		t.Error("retry did not index example.com/greet")
+	}
+	if v, _ := database.GetModuleVersion(greet.Path, greet.Version); v == nil || !v.Timestamp.Equal(published) {
+		t.Errorf("greet version after retry = %+v, want the original publication time", v)
+	}
+
+	failed, err := database.FailedModuleVersions()
+	if err != nil {
+		t.Fatalf("FailedModuleVersions() error = %v", err)
+	}
+	if len(failed) != 1 || failed[0].ModulePath != "example.com/missing" {
+		t.Errorf("failed after retry = %+v, want only example.com/missing", failed)
+	}
+}
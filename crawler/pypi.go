@@ -20,6 +20,7 @@ import (
 
 const (
 	PyPIRegistryURL = "https://pypi.org/pypi"
+	PyPIStatsURL    = "https://pypistats.org/api/packages"
 )
 
 // cleanLicense extracts a short license name from the license field or classifiers
@@ -80,10 +81,12 @@ type PyPIPackageInfo struct {
 
 // PyPIRelease represents a release file from PyPI
 type PyPIRelease struct {
-	Filename    string `json:"filename"`
-	URL         string `json:"url"`
-	PackageType string `json:"packagetype"`
-	Size        int64  `json:"size"`
+	Filename    string    `json:"filename"`
+	URL         string    `json:"url"`
+	PackageType string    `json:"packagetype"`
+	Size        int64     `json:"size"`
+	UploadTime  time.Time `json:"upload_time_iso_8601"`
+	Yanked      bool      `json:"yanked"`
 }
 
 // PyPIResponse represents the PyPI JSON API response
@@ -93,6 +96,45 @@ type PyPIResponse struct {
 	URLs     []PyPIRelease          `json:"urls"`
 }
 
+// releases returns every published version, dated by its earliest uploaded file
+func (r *PyPIResponse) releases() []*db.RegistryVersion {
+	versions := make([]*db.RegistryVersion, 0, len(r.Releases))
+	for version, files := range r.Releases {
+		v := &db.RegistryVersion{Version: version}
+		for _, f := range files {
+			if v.PublishedAt.IsZero() || f.UploadTime.Before(v.PublishedAt) {
+				v.PublishedAt = f.UploadTime
+			}
+			// PyPI yanks whole releases, so every file carries the flag
+			v.Yanked = f.Yanked
+		}
+		versions = append(versions, v)
+	}
+	return versions
+}
+
+// skipYankedLatest switches to the highest non-yanked release with files
+// when the reported latest version has been yanked
+func (r *PyPIResponse) skipYankedLatest() {
+	files := r.Releases[r.Info.Version]
+	if len(files) == 0 || !files[0].Yanked {
+		return
+	}
+	var candidates []*db.RegistryVersion
+	for _, v := range r.releases() {
+		if len(r.Releases[v.Version]) > 0 {
+			candidates = append(candidates, v)
+		}
+	}
+	latest := latestRelease(candidates)
+	if latest == nil {
+		return
+	}
+	log.Printf("Latest version %s of %s is yanked, using %s", r.Info.Version, r.Info.Name, latest.Version)
+	r.Info.Version = latest.Version
+	r.URLs = r.Releases[latest.Version]
+}
+
 // PyPICrawler fetches and indexes packages from PyPI
 type PyPICrawler struct {
 	db        *db.DB
@@ -100,6 +142,13 @@ type PyPICrawler struct {
 	parser    *pyparser.Parser
 	tempDir   string
 	rateLimit time.Duration
+	statsURL  string // overridden in tests
+
+	// MinAge skips packages indexed more recently than this (0 = always fetch)
+	MinAge time.Duration
+
+	// MinDownloads skips packages with fewer downloads last week (0 = no minimum)
+	MinDownloads int
 }
 
 // NewPyPICrawler creates a new PyPI crawler
@@ -115,6 +164,7 @@ func NewPyPICrawler(database *db.DB) (*PyPICrawler, error) {
 		parser:    pyparser.NewParser(),
 		tempDir:   tempDir,
 		rateLimit: 200 * time.Millisecond,
+		statsURL:  PyPIStatsURL,
 	}, nil
 }
 
@@ -150,10 +200,48 @@ func (c *PyPICrawler) FetchPackage(name string) (*PyPIResponse, error) {
 	if err := json.NewDecoder(resp.Body).Decode(&pypiResp); err != nil {
 		return nil, fmt.Errorf("decoding response: %w", err)
 	}
+	pypiResp.skipYankedLatest()
 
 	return &pypiResp, nil
 }
 
+// FetchWeeklyDownloads returns how many times a package was downloaded last
+// week, as reported by pypistats.org since PyPI itself no longer counts downloads
+func (c *PyPICrawler) FetchWeeklyDownloads(name string) (int, error) {
+	time.Sleep(c.rateLimit)
+
+	url := fmt.Sprintf("%s/%s/recent", c.statsURL, strings.ToLower(name))
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", "wikigo-crawler (github.com/alexisbouchez/wikigo)")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Packages without recorded downloads are reported as not found
+	if resp.StatusCode == http.StatusNotFound {
+		return 0, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	var stats struct {
+		Data struct {
+			LastWeek int `json:"last_week"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return 0, fmt.Errorf("decoding response: %w", err)
+	}
+	return stats.Data.LastWeek, nil
+}
+
 // DownloadPackage downloads and extracts a package source distribution
 func (c *PyPICrawler) DownloadPackage(pkg *PyPIResponse) (string, error) {
 	// Find the source distribution (sdist)
@@ -382,6 +470,22 @@ func (c *PyPICrawler) ParsePackageSymbols(pkgDir string) ([]pyparser.Symbol, err
 
 // IndexPackage indexes a package from PyPI
 func (c *PyPICrawler) IndexPackage(name string) error {
+	if c.db != nil && c.MinAge > 0 {
+		if existing, err := c.db.GetPythonPackage(name); err == nil && existing != nil && indexedWithin(existing.IndexedAt, c.MinAge) {
+			return ErrRecentlyIndexed
+		}
+	}
+
+	if c.MinDownloads > 0 {
+		downloads, err := c.FetchWeeklyDownloads(name)
+		if err != nil {
+			return fmt.Errorf("fetching downloads: %w", err)
+		}
+		if err := checkPopularity(downloads, c.MinDownloads, "downloads last week"); err != nil {
+			return err
+		}
+	}
+
 	log.Printf("Fetching package metadata: %s", name)
 
 	pkg, err := c.FetchPackage(name)
@@ -456,6 +560,10 @@ func (c *PyPICrawler) IndexPackage(name string) error {
 		return fmt.Errorf("storing package: %w", err)
 	}
 
+	if err := c.db.ReplaceRegistryVersions(db.EcosystemPyPI, pkg.Info.Name, pkg.releases()); err != nil {
+		return fmt.Errorf("storing versions: %w", err)
+	}
+
 	// Delete old symbols
 	if err := c.db.DeletePythonPackageSymbols(pkgID); err != nil {
 		return fmt.Errorf("deleting old symbols: %w", err)
@@ -0,0 +1,139 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPyPIResponse_Releases(t *testing.T) {
+	data := `{
+		"info": {"name": "requests", "version": "2.31.0"},
+		"releases": {
+			"2.31.0": [
+				{"filename": "requests-2.31.0.tar.gz", "upload_time_iso_8601": "2023-05-22T15:12:46.000000Z"},
+				{"filename": "requests-2.31.0-py3-none-any.whl", "upload_time_iso_8601": "2023-05-22T15:12:44.000000Z"}
+			],
+			"0.0.1": []
+		}
+	}`
+
+	var resp PyPIResponse
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	published := make(map[string]string)
+	for _, v := range resp.releases() {
+		published[v.Version] = v.PublishedAt.Format("15:04:05")
+	}
+
+	if len(published) != 2 {
+		t.Fatalf("Expected 2 releases, got %d", len(published))
+	}
+	// The earliest uploaded file dates the release
+	if published["2.31.0"] != "15:12:44" {
+		t.Errorf("Expected 2.31.0 published at 15:12:44, got %s", published["2.31.0"])
+	}
+	if published["0.0.1"] != "00:00:00" {
+		t.Errorf("Expected release without files to be undated, got %s", published["0.0.1"])
+	}
+}
+
+func TestPyPIResponse_SkipYankedLatest(t *testing.T) {
+	data := `{
+		"info": {"name": "example", "version": "1.1.0"},
+		"releases": {
+			"1.1.0": [{"filename": "example-1.1.0.tar.gz", "yanked": true, "upload_time_iso_8601": "2024-02-01T00:00:00Z"}],
+			"1.0.0": [{"filename": "example-1.0.0.tar.gz", "upload_time_iso_8601": "2024-01-01T00:00:00Z"}]
+		},
+		"urls": [{"filename": "example-1.1.0.tar.gz", "yanked": true}]
+	}`
+
+	var resp PyPIResponse
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	resp.skipYankedLatest()
+
+	if resp.Info.Version != "1.0.0" {
+		t.Errorf("Expected version 1.0.0, got %s", resp.Info.Version)
+	}
+	if len(resp.URLs) != 1 || resp.URLs[0].Filename != "example-1.0.0.tar.gz" {
+		t.Errorf("Expected URLs for 1.0.0, got %+v", resp.URLs)
+	}
+
+	yanked := 0
+	for _, v := range resp.releases() {
+		if v.Yanked {
+			yanked++
+		}
+	}
+	if yanked != 1 {
+		t.Errorf("Expected 1 yanked release, got %d", yanked)
+	}
+}
+
+func TestPyPIResponse_SkipYankedLatest_HighestVersion(t *testing.T) {
+	// A 1.9 backport published after 1.10.0, and a 2.1.0 with no files
+	data := `{
+		"info": {"name": "example", "version": "2.0.0"},
+		"releases": {
+			"2.1.0": [],
+			"2.0.0": [{"filename": "example-2.0.0.tar.gz", "yanked": true, "upload_time_iso_8601": "2024-03-01T00:00:00Z"}],
+			"1.9.5": [{"filename": "example-1.9.5.tar.gz", "upload_time_iso_8601": "2024-04-01T00:00:00Z"}],
+			"1.10.0": [{"filename": "example-1.10.0.tar.gz", "upload_time_iso_8601": "2024-02-01T00:00:00Z"}]
+		}
+	}`
+
+	var resp PyPIResponse
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	resp.skipYankedLatest()
+
+	if resp.Info.Version != "1.10.0" {
+		t.Errorf("Expected version 1.10.0, got %s", resp.Info.Version)
+	}
+}
+
+func TestPyPIIndexPackage_SkipsBelowThreshold(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/requests/recent":
+			fmt.Fprint(w, `{"data": {"last_day": 1, "last_month": 900, "last_week": 250}, "package": "requests"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	crawler, err := NewPyPICrawler(nil)
+	if err != nil {
+		t.Fatalf("NewPyPICrawler() error = %v", err)
+	}
+	defer crawler.Close()
+	crawler.rateLimit = 0
+	crawler.statsURL = srv.URL
+
+	tests := []struct {
+		name string
+		want int
+	}{
+		{"Requests", 250},
+		{"never-downloaded", 0},
+	}
+	for _, tt := range tests {
+		if got, err := crawler.FetchWeeklyDownloads(tt.name); err != nil || got != tt.want {
+			t.Errorf("FetchWeeklyDownloads(%q) = %d, %v, want %d", tt.name, got, err, tt.want)
+		}
+	}
+
+	crawler.MinDownloads = 1000
+	if err := crawler.IndexPackage("requests"); !errors.Is(err, ErrBelowThreshold) {
+		t.Errorf("IndexPackage() error = %v, want ErrBelowThreshold", err)
+	}
+}
@@ -0,0 +1,102 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultMaxAttempts is how many times a request to the module proxy or index
+// is made before its failure is reported
+const DefaultMaxAttempts = 4
+
+// defaultRetryDelay is the wait before the first retry, doubled for each one
+// after it
+const defaultRetryDelay = time.Second
+
+// maxRetryDelay caps the wait before a retry, including one a Retry-After
+// header asks for
+const maxRetryDelay = time.Minute
+
+// do sends a request to the module proxy or index, retrying network errors,
+// 429 Too Many Requests and 5xx responses with exponential backoff, or after
+// the delay a 429's Retry-After header gives, up to c.maxAttempts attempts in
+// all. The request must have no body. Cancelling its context aborts at once,
+// including while waiting to retry.
+func (c *Crawler) do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	delay := c.retryDelay
+	for attempt := 1; ; attempt++ {
+		resp, err := c.client.Do(req)
+		if ctx.Err() != nil {
+			if err == nil {
+				resp.Body.Close()
+			}
+			return nil, ctx.Err()
+		}
+		if attempt >= c.maxAttempts || !isTransient(resp, err) {
+			return resp, err
+		}
+
+		wait, reason := delay, fmt.Sprint(err)
+		if err == nil {
+			reason = resp.Status
+			if after, ok := retryAfter(resp); ok && resp.StatusCode == http.StatusTooManyRequests {
+				wait = after
+			}
+			resp.Body.Close()
+		}
+		wait = min(wait, maxRetryDelay)
+		log.Printf("Retrying %s in %s after %s (attempt %d of %d)", req.URL, wait, reason, attempt+1, c.maxAttempts)
+
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+		delay *= 2
+	}
+}
+
+// isTransient reports whether a failed request may succeed if sent again:
+// it failed on the network, or the server was overloaded or rate limited it
+func isTransient(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses a response's Retry-After header, in seconds or as an HTTP
+// date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d, returning the context's error if it is done first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,174 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer answers with the given statuses in turn, then 200 OK
+func flakyServer(t *testing.T, header http.Header, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(hits.Add(1))
+		if n <= len(statuses) {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      http.Header
+		statuses    []int
+		maxAttempts int
+		retryDelay  time.Duration
+		wantStatus  int
+		wantHits    int32
+	}{
+		{"succeeds first time", nil, nil, 4, time.Millisecond, 200, 1},
+		{"retries 5xx", nil, []int{503, 502}, 4, time.Millisecond, 200, 3},
+		{"gives up after max attempts", nil, []int{500, 500, 500}, 2, time.Millisecond, 500, 2},
+		{"never retries with one attempt", nil, []int{503}, 1, time.Millisecond, 503, 1},
+		{"does not retry 404", nil, []int{404}, 4, time.Millisecond, 404, 1},
+		// Retry-After overrides the hour of backoff
+		{"honours Retry-After on 429", http.Header{"Retry-After": {"0"}}, []int{429}, 4, time.Hour, 200, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, hits := flakyServer(t, tt.header, tt.statuses...)
+			c := &Crawler{client: srv.Client(), maxAttempts: tt.maxAttempts, retryDelay: tt.retryDelay}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			req, err := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := c.do(req)
+			if err != nil {
+				t.Fatalf("do() error = %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := hits.Load(); got != tt.wantHits {
+				t.Errorf("requests = %d, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestDo_CancelWhileWaiting(t *testing.T) {
+	srv, hits := flakyServer(t, nil, 503)
+	c := &Crawler{client: srv.Client(), maxAttempts: 4, retryDelay: time.Hour}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := c.do(req); err != context.DeadlineExceeded {
+		t.Errorf("do() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("do() returned after %s, want it to stop waiting when cancelled", elapsed)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"5", 5 * time.Second, true},
+		{"0", 0, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{"Mon, 02 Jan 2006 15:04:05 GMT", 0, true}, // in the past
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.value != "" {
+			resp.Header.Set("Retry-After", tt.value)
+		}
+		got, ok := retryAfter(resp)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("retryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	if got, ok := retryAfter(resp); !ok || got < 59*time.Minute || got > time.Hour {
+		t.Errorf("retryAfter(in an hour) = %v, %v, want about 1h, true", got, ok)
+	}
+}
+
+func TestRun_RetriesTransientFailures(t *testing.T) {
+	versions := []ModuleVersion{{Path: "example.com/greet", Version: "v1.0.0", Timestamp: time.Now()}}
+
+	// A proxy whose index and first zip download fail before succeeding
+	upstream := fakeProxy(t, versions)
+	var indexFailed, zipFailed atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/index") && !indexFailed.Swap(true):
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case strings.HasSuffix(r.URL.Path, ".zip") && !zipFailed.Swap(true):
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			upstream.Config.Handler.ServeHTTP(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := New(Config{
+		DBPath:    filepath.Join(t.TempDir(), "test.db"),
+		Workers:   1,
+		RateLimit: time.Millisecond,
+		TempDir:   t.TempDir(),
+		ProxyURL:  srv.URL,
+		IndexURL:  srv.URL + "/index",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+	c.retryDelay = time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := c.Run(ctx, time.Time{}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !indexFailed.Load() || !zipFailed.Load() {
+		t.Fatalf("index failed = %v, zip failed = %v, want both", indexFailed.Load(), zipFailed.Load())
+	}
+	if c.stats.ModulesSucceeded != 1 || c.stats.ModulesFailed != 0 {
+		t.Errorf("modules succeeded = %d, failed = %d, want 1, 0", c.stats.ModulesSucceeded, c.stats.ModulesFailed)
+	}
+}
@@ -0,0 +1,94 @@
+package crawler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tempDirPrefix prefixes the temporary directories modules are extracted to
+const tempDirPrefix = "wikigo-"
+
+// DefaultStaleTempAge is how old a leftover temporary directory must be before
+// a starting crawler removes it
+const DefaultStaleTempAge = time.Hour
+
+// cleanStaleTempDirs removes the temporary directories in dir that crawlers
+// killed mid-run left behind, once older than maxAge. Younger ones may belong
+// to a crawler still running. It returns the number of directories removed.
+func cleanStaleTempDirs(dir string, maxAge time.Duration) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), tempDirPrefix) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// diskBudget caps the bytes of modules extracted on disk at once; workers
+// wait for others to finish before extracting past it
+type diskBudget struct {
+	mu    sync.Mutex
+	limit int64
+	used  int64
+	freed chan struct{} // closed, then replaced, whenever space is released
+}
+
+// newDiskBudget returns a budget of limit bytes, or nil for no limit
+func newDiskBudget(limit int64) *diskBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &diskBudget{limit: limit, freed: make(chan struct{})}
+}
+
+// acquire waits until n bytes fit in the budget and reserves them. A module
+// larger than the whole budget waits for the disk to itself. The returned
+// function releases the reservation.
+func (b *diskBudget) acquire(ctx context.Context, n int64) (release func(), err error) {
+	if b == nil {
+		return func() {}, nil
+	}
+	n = min(n, b.limit)
+	for {
+		b.mu.Lock()
+		if b.used+n <= b.limit {
+			b.used += n
+			b.mu.Unlock()
+			return func() { b.release(n) }, nil
+		}
+		freed := b.freed
+		b.mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (b *diskBudget) release(n int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used -= n
+	close(b.freed)
+	b.freed = make(chan struct{})
+}
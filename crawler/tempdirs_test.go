@@ -0,0 +1,128 @@
+package crawler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCleanStaleTempDirs(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-2 * time.Hour)
+	for _, name := range []string{"wikigo-stale", "wikigo-fresh", "other-stale"} {
+		if err := os.MkdirAll(filepath.Join(dir, name, "pkg"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"wikigo-stale", "other-stale"} {
+		if err := os.Chtimes(filepath.Join(dir, name), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := cleanStaleTempDirs(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("cleanStaleTempDirs: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("removed = %d, want 1", removed)
+	}
+	for name, want := range map[string]bool{"wikigo-stale": false, "wikigo-fresh": true, "other-stale": true} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists := err == nil; exists != want {
+			t.Errorf("%s exists = %v, want %v", name, exists, want)
+		}
+	}
+}
+
+func TestNew_CleansStaleTempDirs(t *testing.T) {
+	tempDir := t.TempDir()
+	stale := filepath.Join(tempDir, "wikigo-123")
+	if err := os.Mkdir(stale, 0755); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * DefaultStaleTempAge)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := New(Config{DBPath: filepath.Join(t.TempDir(), "test.db"), TempDir: tempDir})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale temp dir was not removed: %v", err)
+	}
+}
+
+func TestDiskBudget(t *testing.T) {
+	b := newDiskBudget(100)
+	ctx := context.Background()
+
+	release, err := b.acquire(ctx, 60)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	acquired := make(chan func())
+	go func() {
+		r, err := b.acquire(ctx, 60)
+		if err != nil {
+			t.Errorf("second acquire: %v", err)
+		}
+		acquired <- r
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second acquire did not wait for space")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release()
+	select {
+	case r := <-acquired:
+		r()
+	case <-time.After(time.Second):
+		t.Fatal("second acquire did not proceed after release")
+	}
+
+	// A module larger than the whole budget gets it to itself
+	release, err = b.acquire(ctx, 500)
+	if err != nil {
+		t.Fatalf("acquire over limit: %v", err)
+	}
+	release()
+
+	if b.used != 0 {
+		t.Errorf("used = %d after releasing everything, want 0", b.used)
+	}
+}
+
+func TestDiskBudget_Cancel(t *testing.T) {
+	b := newDiskBudget(10)
+	release, err := b.acquire(context.Background(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := b.acquire(ctx, 1); err != context.DeadlineExceeded {
+		t.Errorf("acquire error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestDiskBudget_Unlimited(t *testing.T) {
+	b := newDiskBudget(0)
+	release, err := b.acquire(context.Background(), 1<<40)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	release()
+}
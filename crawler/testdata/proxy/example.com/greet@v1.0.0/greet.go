@@ -0,0 +1,22 @@
+// Package greet builds greetings.
+package greet
+
+import "fmt"
+
+// DefaultName is used when no name is given.
+const DefaultName = "World"
+
+// Greeter greets people by name.
+type Greeter struct {
+	Prefix string
+}
+
+// Greet returns a greeting for name.
+func (g Greeter) Greet(name string) string {
+	return fmt.Sprintf("%s, %s!", g.Prefix, name)
+}
+
+// Hello returns "Hello, name!".
+func Hello(name string) string {
+	return Greeter{Prefix: "Hello"}.Greet(name)
+}
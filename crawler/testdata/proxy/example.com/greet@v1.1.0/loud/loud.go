@@ -0,0 +1,13 @@
+// Package loud shouts greetings.
+package loud
+
+import (
+	"strings"
+
+	"example.com/greet"
+)
+
+// Hello returns the greeting in upper case.
+func Hello(name string) string {
+	return strings.ToUpper(greet.Hello(name))
+}
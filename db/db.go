@@ -5,15 +5,32 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"go/version"
 	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/alexisbouchez/wikigo/util"
 )
 
 // DB wraps the SQLite database connection
 type DB struct {
-	conn *sql.DB
+	conn     querier // the pool, or the transaction inside Batch
+	sqlDB    *sql.DB
+	inTx     bool
+	readOnly bool
+}
+
+// querier is the subset of *sql.DB and *sql.Tx used by DB methods
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
 }
 
 // Package represents a Go package in the database
@@ -37,7 +54,13 @@ type Package struct {
 	GoModContent    string    `json:"gomod_content"`
 	GOOS            []string  `json:"goos"`
 	GOARCH          []string  `json:"goarch"`
+	Generate        []string  `json:"generate"` // //go:generate commands
 	DocJSON         string    `json:"doc_json"` // Full package documentation as JSON
+	Classification  string    `json:"classification"` // "", "test-only" or "example-only"
+	Coverage        string    `json:"coverage"`       // test coverage the module reports, e.g. "87.5%"
+	SynopsisSource  string    `json:"synopsis_source"` // "" for the package doc, or "readme" or "ai" when derived
+	Experimental    bool      `json:"experimental"`    // under golang.org/x/exp or documented as experimental, alpha or beta
+	Deprecated      bool      `json:"deprecated"`      // the package doc has a "Deprecated:" paragraph
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 	IndexedAt       time.Time `json:"indexed_at"`
@@ -53,16 +76,37 @@ type Import struct {
 
 // Symbol represents a searchable symbol (function, type, method, etc.)
 type Symbol struct {
-	ID         int64  `json:"id"`
-	Name       string `json:"name"`
-	Kind       string `json:"kind"` // func, type, method, const, var
-	PackageID  int64  `json:"package_id"`
-	ImportPath string `json:"import_path"`
-	Synopsis   string `json:"synopsis"`
-	Doc        string `json:"doc"`       // Full documentation
-	Signature  string `json:"signature"` // Function signature
-	Decl       string `json:"decl"`      // Type/const/var declaration
-	Deprecated bool   `json:"deprecated"`
+	ID         int64           `json:"id"`
+	Name       string          `json:"name"`
+	Kind       string          `json:"kind"` // func, type, method, const, var
+	PackageID  int64           `json:"package_id"`
+	ImportPath string          `json:"import_path"`
+	Synopsis   string          `json:"synopsis"`
+	Doc        string          `json:"doc"`       // Full documentation
+	Signature  string          `json:"signature"` // Function signature
+	Decl       string          `json:"decl"`      // Type/const/var declaration
+	Deprecated bool            `json:"deprecated"`
+	Filename   string          `json:"filename,omitempty"`
+	Line       int             `json:"line,omitempty"`
+	EndLine    int             `json:"end_line,omitempty"` // last line of the declaration, for source snippets
+	Interface  bool            `json:"interface,omitempty"`
+	MethodSet  []string        `json:"method_set,omitempty"` // types only: normalized method signatures, "*" marks pointer receivers
+	Params     []util.ParamDoc `json:"params,omitempty"`     // funcs and methods only: parameters documented in prose
+}
+
+// Example represents a runnable example from a package's test files
+type Example struct {
+	ID          int64  `json:"id"`
+	PackageID   int64  `json:"package_id"`
+	ImportPath  string `json:"import_path"`
+	Symbol      string `json:"symbol"`       // Documented symbol, empty for package examples
+	Name        string `json:"name"`         // Example name without the "Example" prefix
+	Doc         string `json:"doc"`
+	Code        string `json:"code"`
+	Output      string `json:"output"`
+	Unordered   bool   `json:"unordered"`    // "// Unordered output:" rather than "// Output:"
+	EmptyOutput bool   `json:"empty_output"` // the output comment is present but empty
+	Verified    bool   `json:"verified"`     // ran and printed Output when the package was indexed
 }
 
 // ModuleVersion represents a version of a module
@@ -75,6 +119,16 @@ type ModuleVersion struct {
 	IsStable   bool      `json:"is_stable"`  // v1+ and no pre-release
 	Retracted  bool      `json:"retracted"`  // Version was retracted
 	CreatedAt  time.Time `json:"created_at"` // When we indexed it
+
+	Checksum      string `json:"checksum,omitempty"`       // go.sum hash of the module zip, computed when crawled
+	ProxyChecksum string `json:"proxy_checksum,omitempty"` // hash the proxy reported in @v/<version>.ziphash, if it serves one
+	GoSumModules  int    `json:"gosum_modules,omitempty"`  // module versions whose checksums the module's go.sum pins
+}
+
+// ChecksumMismatch reports whether the proxy reported a different checksum
+// than the one computed from the module zip it served
+func (mv *ModuleVersion) ChecksumMismatch() bool {
+	return mv.Checksum != "" && mv.ProxyChecksum != "" && mv.Checksum != mv.ProxyChecksum
 }
 
 // AIDoc represents AI-generated documentation for a symbol
@@ -87,12 +141,35 @@ type AIDoc struct {
 	Approved     bool      `json:"approved"`
 	Flagged      bool      `json:"flagged"`
 	FlagReason   string    `json:"flag_reason,omitempty"`
+	Orphaned     bool      `json:"orphaned"` // the symbol disappeared when the package was reindexed
 	CostUSD      float64   `json:"cost_usd"`
 	Tokens       int       `json:"tokens"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+// Feedback is a user report of incorrect documentation
+type Feedback struct {
+	ID         int64     `json:"id"`
+	ImportPath string    `json:"import_path"`
+	Symbol     string    `json:"symbol"`      // Empty for the package documentation
+	SymbolKind string    `json:"symbol_kind"` // Kind of the reported AI doc: "func", "type", "method" or "package"
+	AIDoc      bool      `json:"ai_doc"`      // Whether an AI-generated doc was reported
+	Message    string    `json:"message"`
+	Resolved   bool      `json:"resolved"`
+	Orphaned   bool      `json:"orphaned"` // the symbol disappeared when the package was reindexed
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CrawlError is a failure to index a module version
+type CrawlError struct {
+	ID         int64     `json:"id"`
+	ModulePath string    `json:"module_path"`
+	Version    string    `json:"version"`
+	Error      string    `json:"error"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
 // Embedding represents a stored embedding for semantic search
 type Embedding struct {
 	ID         int64     `json:"id"`
@@ -103,6 +180,16 @@ type Embedding struct {
 	CreatedAt  time.Time `json:"created_at"`
 }
 
+// EmbeddingIndex represents persisted ANN index state for a language
+type EmbeddingIndex struct {
+	Lang           string         `json:"lang"`
+	Dim            int            `json:"dim"`
+	EmbeddingCount int            `json:"embedding_count"`
+	Centroids      [][]float32    `json:"centroids"`
+	Assignments    map[string]int `json:"assignments"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
 // GeneratedExample represents an AI-generated code example
 type GeneratedExample struct {
 	ID           int64     `json:"id"`
@@ -128,7 +215,7 @@ func Open(path string) (*DB, error) {
 		return nil, fmt.Errorf("enabling foreign keys: %w", err)
 	}
 
-	db := &DB{conn: conn}
+	db := &DB{conn: conn, sqlDB: conn}
 
 	// Run migrations
 	if err := db.migrate(); err != nil {
@@ -139,595 +226,1160 @@ func Open(path string) (*DB, error) {
 	return db, nil
 }
 
+// OpenReadOnly opens an existing database without write access, e.g. a replica
+// copy synced from the crawler's primary database. Migrations are not run.
+func OpenReadOnly(path string) (*DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+
+	conn, err := sql.Open("sqlite3", "file:"+path+"?mode=ro&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+
+	// Verify the file is a readable database
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+
+	return &DB{conn: conn, sqlDB: conn, readOnly: true}, nil
+}
+
+// ReadOnly reports whether the database was opened with OpenReadOnly
+func (db *DB) ReadOnly() bool {
+	return db.readOnly
+}
+
 // Close closes the database connection
 func (db *DB) Close() error {
-	return db.conn.Close()
+	return db.sqlDB.Close()
+}
+
+// Batch runs fn with a DB whose statements share a single transaction,
+// committing if fn returns nil. Nested calls reuse the outer transaction.
+func (db *DB) Batch(fn func(tx *DB) error) error {
+	if db.inTx {
+		return fn(db)
+	}
+
+	tx, err := db.sqlDB.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(&DB{conn: tx, sqlDB: db.sqlDB, inTx: true}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// initialSchema is the schema as of migration version 1
+var initialSchema = []string{
+	// Packages table
+	`CREATE TABLE IF NOT EXISTS packages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		import_path TEXT UNIQUE NOT NULL,
+		name TEXT NOT NULL,
+		synopsis TEXT,
+		doc TEXT,
+		version TEXT,
+		versions_json TEXT,
+		is_tagged INTEGER DEFAULT 0,
+		is_stable INTEGER DEFAULT 0,
+		license TEXT,
+		license_text TEXT,
+		redistributable INTEGER DEFAULT 0,
+		repository TEXT,
+		has_valid_mod INTEGER DEFAULT 0,
+		go_version TEXT,
+		module_path TEXT,
+		gomod_content TEXT,
+		goos_json TEXT,
+		goarch_json TEXT,
+		doc_json TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		indexed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+
+	// Imports table for tracking import relationships
+	`CREATE TABLE IF NOT EXISTS imports (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		importer_path TEXT NOT NULL,
+		imported_path TEXT NOT NULL,
+		importer_module TEXT,
+		UNIQUE(importer_path, imported_path)
+	)`,
+
+	// Symbols table for symbol search
+	`CREATE TABLE IF NOT EXISTS symbols (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		package_id INTEGER NOT NULL,
+		import_path TEXT NOT NULL,
+		synopsis TEXT,
+		doc TEXT,
+		signature TEXT,
+		decl TEXT,
+		deprecated INTEGER DEFAULT 0,
+		FOREIGN KEY (package_id) REFERENCES packages(id) ON DELETE CASCADE
+	)`,
+
+	// Indexes for fast lookups
+	`CREATE INDEX IF NOT EXISTS idx_packages_import_path ON packages(import_path)`,
+	`CREATE INDEX IF NOT EXISTS idx_packages_module_path ON packages(module_path)`,
+	`CREATE INDEX IF NOT EXISTS idx_packages_name ON packages(name)`,
+	`CREATE INDEX IF NOT EXISTS idx_imports_importer ON imports(importer_path)`,
+	`CREATE INDEX IF NOT EXISTS idx_imports_imported ON imports(imported_path)`,
+	`CREATE INDEX IF NOT EXISTS idx_symbols_name ON symbols(name)`,
+	`CREATE INDEX IF NOT EXISTS idx_symbols_kind ON symbols(kind)`,
+	`CREATE INDEX IF NOT EXISTS idx_symbols_package ON symbols(package_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_symbols_import_path ON symbols(import_path)`,
+
+	// Full-text search for packages using FTS4 (more widely supported)
+	`CREATE VIRTUAL TABLE IF NOT EXISTS packages_fts USING fts4(
+		import_path,
+		name,
+		synopsis,
+		doc,
+		content="packages",
+		tokenize=porter
+	)`,
+
+	// Full-text search for symbols using FTS4
+	`CREATE VIRTUAL TABLE IF NOT EXISTS symbols_fts USING fts4(
+		name,
+		synopsis,
+		content="symbols",
+		tokenize=porter
+	)`,
+
+	// Triggers to keep FTS in sync with packages
+	`CREATE TRIGGER IF NOT EXISTS packages_ai AFTER INSERT ON packages BEGIN
+		INSERT INTO packages_fts(docid, import_path, name, synopsis, doc)
+		VALUES (new.id, new.import_path, new.name, new.synopsis, new.doc);
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS packages_ad AFTER DELETE ON packages BEGIN
+		DELETE FROM packages_fts WHERE docid = old.id;
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS packages_au AFTER UPDATE ON packages BEGIN
+		DELETE FROM packages_fts WHERE docid = old.id;
+		INSERT INTO packages_fts(docid, import_path, name, synopsis, doc)
+		VALUES (new.id, new.import_path, new.name, new.synopsis, new.doc);
+	END`,
+
+	// Triggers to keep FTS in sync with symbols
+	`CREATE TRIGGER IF NOT EXISTS symbols_ai AFTER INSERT ON symbols BEGIN
+		INSERT INTO symbols_fts(docid, name, synopsis)
+		VALUES (new.id, new.name, new.synopsis);
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS symbols_ad AFTER DELETE ON symbols BEGIN
+		DELETE FROM symbols_fts WHERE docid = old.id;
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS symbols_au AFTER UPDATE ON symbols BEGIN
+		DELETE FROM symbols_fts WHERE docid = old.id;
+		INSERT INTO symbols_fts(docid, name, synopsis)
+		VALUES (new.id, new.name, new.synopsis);
+	END`,
+
+	// Metadata table for crawl state tracking
+	`CREATE TABLE IF NOT EXISTS crawl_metadata (
+		key TEXT PRIMARY KEY,
+		value TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+
+	// Module versions table for version history tracking
+	`CREATE TABLE IF NOT EXISTS module_versions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		module_path TEXT NOT NULL,
+		version TEXT NOT NULL,
+		timestamp DATETIME,
+		is_tagged INTEGER DEFAULT 0,
+		is_stable INTEGER DEFAULT 0,
+		retracted INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(module_path, version)
+	)`,
+
+	`CREATE INDEX IF NOT EXISTS idx_module_versions_path ON module_versions(module_path)`,
+	`CREATE INDEX IF NOT EXISTS idx_module_versions_timestamp ON module_versions(timestamp DESC)`,
+
+	// AI-generated documentation table
+	`CREATE TABLE IF NOT EXISTS ai_docs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		symbol_name TEXT NOT NULL,
+		symbol_kind TEXT NOT NULL,
+		import_path TEXT NOT NULL,
+		generated_doc TEXT NOT NULL,
+		approved INTEGER DEFAULT 0,
+		flagged INTEGER DEFAULT 0,
+		flag_reason TEXT,
+		cost_usd REAL DEFAULT 0,
+		tokens INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(import_path, symbol_name, symbol_kind)
+	)`,
+
+	`CREATE INDEX IF NOT EXISTS idx_ai_docs_import_path ON ai_docs(import_path)`,
+	`CREATE INDEX IF NOT EXISTS idx_ai_docs_approved ON ai_docs(approved)`,
+	`CREATE INDEX IF NOT EXISTS idx_ai_docs_flagged ON ai_docs(flagged)`,
+
+	// JavaScript/TypeScript packages table
+	`CREATE TABLE IF NOT EXISTS js_packages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		version TEXT,
+		description TEXT,
+		author TEXT,
+		license TEXT,
+		repository_url TEXT,
+		homepage TEXT,
+		npm_url TEXT,
+		github_url TEXT,
+		main_file TEXT,
+		types_file TEXT,
+		has_typescript INTEGER DEFAULT 0,
+		stars INTEGER DEFAULT 0,
+		forks INTEGER DEFAULT 0,
+		keywords_json TEXT,
+		dependencies_json TEXT,
+		package_json TEXT,
+		readme TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		indexed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+
+	// JavaScript/TypeScript symbols table
+	`CREATE TABLE IF NOT EXISTS js_symbols (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		signature TEXT,
+		package_id INTEGER NOT NULL,
+		package_name TEXT NOT NULL,
+		file_path TEXT,
+		line INTEGER DEFAULT 0,
+		exported INTEGER DEFAULT 0,
+		doc TEXT,
+		deprecated INTEGER DEFAULT 0,
+		FOREIGN KEY (package_id) REFERENCES js_packages(id) ON DELETE CASCADE
+	)`,
+
+	// Indexes for JS packages and symbols
+	`CREATE INDEX IF NOT EXISTS idx_js_packages_name ON js_packages(name)`,
+	`CREATE INDEX IF NOT EXISTS idx_js_packages_author ON js_packages(author)`,
+	`CREATE INDEX IF NOT EXISTS idx_js_packages_stars ON js_packages(stars DESC)`,
+	`CREATE INDEX IF NOT EXISTS idx_js_symbols_name ON js_symbols(name)`,
+	`CREATE INDEX IF NOT EXISTS idx_js_symbols_kind ON js_symbols(kind)`,
+	`CREATE INDEX IF NOT EXISTS idx_js_symbols_package ON js_symbols(package_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_js_symbols_exported ON js_symbols(exported)`,
+
+	// FTS for JavaScript/TypeScript packages
+	`CREATE VIRTUAL TABLE IF NOT EXISTS js_packages_fts USING fts4(
+		name,
+		description,
+		author,
+		keywords,
+		content=js_packages,
+		tokenize=porter
+	)`,
+
+	// FTS for JavaScript/TypeScript symbols
+	`CREATE VIRTUAL TABLE IF NOT EXISTS js_symbols_fts USING fts4(
+		name,
+		signature,
+		doc,
+		content=js_symbols,
+		tokenize=porter
+	)`,
+
+	// Triggers for JS packages FTS
+	`CREATE TRIGGER IF NOT EXISTS js_packages_ai AFTER INSERT ON js_packages BEGIN
+		INSERT INTO js_packages_fts(docid, name, description, author, keywords)
+		VALUES (new.id, new.name, new.description, new.author, new.keywords_json);
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS js_packages_ad AFTER DELETE ON js_packages BEGIN
+		DELETE FROM js_packages_fts WHERE docid = old.id;
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS js_packages_au AFTER UPDATE ON js_packages BEGIN
+		DELETE FROM js_packages_fts WHERE docid = old.id;
+		INSERT INTO js_packages_fts(docid, name, description, author, keywords)
+		VALUES (new.id, new.name, new.description, new.author, new.keywords_json);
+	END`,
+
+	// Triggers for JS symbols FTS
+	`CREATE TRIGGER IF NOT EXISTS js_symbols_ai AFTER INSERT ON js_symbols BEGIN
+		INSERT INTO js_symbols_fts(docid, name, signature, doc)
+		VALUES (new.id, new.name, new.signature, new.doc);
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS js_symbols_ad AFTER DELETE ON js_symbols BEGIN
+		DELETE FROM js_symbols_fts WHERE docid = old.id;
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS js_symbols_au AFTER UPDATE ON js_symbols BEGIN
+		DELETE FROM js_symbols_fts WHERE docid = old.id;
+		INSERT INTO js_symbols_fts(docid, name, signature, doc)
+		VALUES (new.id, new.name, new.signature, new.doc);
+	END`,
+
+	// Rust crates table
+	`CREATE TABLE IF NOT EXISTS rust_crates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		version TEXT,
+		description TEXT,
+		license TEXT,
+		repository TEXT,
+		homepage TEXT,
+		documentation TEXT,
+		downloads INTEGER DEFAULT 0,
+		keywords_json TEXT,
+		categories_json TEXT,
+		dependencies_json TEXT,
+		authors_json TEXT,
+		readme TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		indexed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+
+	// Rust symbols table
+	`CREATE TABLE IF NOT EXISTS rust_symbols (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		signature TEXT,
+		crate_id INTEGER NOT NULL,
+		crate_name TEXT NOT NULL,
+		file_path TEXT,
+		line INTEGER DEFAULT 0,
+		public INTEGER DEFAULT 0,
+		doc TEXT,
+		FOREIGN KEY (crate_id) REFERENCES rust_crates(id) ON DELETE CASCADE
+	)`,
+
+	// Rust crates FTS table
+	`CREATE VIRTUAL TABLE IF NOT EXISTS rust_crates_fts USING fts4(
+		name,
+		description,
+		keywords,
+		content=rust_crates,
+		tokenize=porter
+	)`,
+
+	// Rust symbols FTS table
+	`CREATE VIRTUAL TABLE IF NOT EXISTS rust_symbols_fts USING fts4(
+		name,
+		signature,
+		doc,
+		content=rust_symbols,
+		tokenize=porter
+	)`,
+
+	// Triggers for Rust crates FTS
+	`CREATE TRIGGER IF NOT EXISTS rust_crates_ai AFTER INSERT ON rust_crates BEGIN
+		INSERT INTO rust_crates_fts(docid, name, description, keywords)
+		VALUES (new.id, new.name, new.description, new.keywords_json);
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS rust_crates_ad AFTER DELETE ON rust_crates BEGIN
+		DELETE FROM rust_crates_fts WHERE docid = old.id;
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS rust_crates_au AFTER UPDATE ON rust_crates BEGIN
+		DELETE FROM rust_crates_fts WHERE docid = old.id;
+		INSERT INTO rust_crates_fts(docid, name, description, keywords)
+		VALUES (new.id, new.name, new.description, new.keywords_json);
+	END`,
+
+	// Triggers for Rust symbols FTS
+	`CREATE TRIGGER IF NOT EXISTS rust_symbols_ai AFTER INSERT ON rust_symbols BEGIN
+		INSERT INTO rust_symbols_fts(docid, name, signature, doc)
+		VALUES (new.id, new.name, new.signature, new.doc);
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS rust_symbols_ad AFTER DELETE ON rust_symbols BEGIN
+		DELETE FROM rust_symbols_fts WHERE docid = old.id;
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS rust_symbols_au AFTER UPDATE ON rust_symbols BEGIN
+		DELETE FROM rust_symbols_fts WHERE docid = old.id;
+		INSERT INTO rust_symbols_fts(docid, name, signature, doc)
+		VALUES (new.id, new.name, new.signature, new.doc);
+	END`,
+
+	// Python packages table
+	`CREATE TABLE IF NOT EXISTS python_packages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		version TEXT,
+		summary TEXT,
+		author TEXT,
+		author_email TEXT,
+		license TEXT,
+		home_page TEXT,
+		project_url TEXT,
+		pypi_url TEXT,
+		repository_url TEXT,
+		documentation_url TEXT,
+		requires_python TEXT,
+		downloads INTEGER DEFAULT 0,
+		keywords_json TEXT,
+		classifiers_json TEXT,
+		dependencies_json TEXT,
+		readme TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		indexed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+
+	// Python symbols table
+	`CREATE TABLE IF NOT EXISTS python_symbols (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		signature TEXT,
+		package_id INTEGER NOT NULL,
+		package_name TEXT NOT NULL,
+		file_path TEXT,
+		line INTEGER DEFAULT 0,
+		public INTEGER DEFAULT 0,
+		doc TEXT,
+		FOREIGN KEY (package_id) REFERENCES python_packages(id) ON DELETE CASCADE
+	)`,
+
+	// Python packages FTS table
+	`CREATE VIRTUAL TABLE IF NOT EXISTS python_packages_fts USING fts4(
+		name,
+		summary,
+		author,
+		keywords,
+		content=python_packages,
+		tokenize=porter
+	)`,
+
+	// Python symbols FTS table
+	`CREATE VIRTUAL TABLE IF NOT EXISTS python_symbols_fts USING fts4(
+		name,
+		signature,
+		doc,
+		content=python_symbols,
+		tokenize=porter
+	)`,
+
+	// Triggers for Python packages FTS
+	`CREATE TRIGGER IF NOT EXISTS python_packages_ai AFTER INSERT ON python_packages BEGIN
+		INSERT INTO python_packages_fts(docid, name, summary, author, keywords)
+		VALUES (new.id, new.name, new.summary, new.author, new.keywords_json);
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS python_packages_ad AFTER DELETE ON python_packages BEGIN
+		DELETE FROM python_packages_fts WHERE docid = old.id;
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS python_packages_au AFTER UPDATE ON python_packages BEGIN
+		DELETE FROM python_packages_fts WHERE docid = old.id;
+		INSERT INTO python_packages_fts(docid, name, summary, author, keywords)
+		VALUES (new.id, new.name, new.summary, new.author, new.keywords_json);
+	END`,
+
+	// Triggers for Python symbols FTS
+	`CREATE TRIGGER IF NOT EXISTS python_symbols_ai AFTER INSERT ON python_symbols BEGIN
+		INSERT INTO python_symbols_fts(docid, name, signature, doc)
+		VALUES (new.id, new.name, new.signature, new.doc);
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS python_symbols_ad AFTER DELETE ON python_symbols BEGIN
+		DELETE FROM python_symbols_fts WHERE docid = old.id;
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS python_symbols_au AFTER UPDATE ON python_symbols BEGIN
+		DELETE FROM python_symbols_fts WHERE docid = old.id;
+		INSERT INTO python_symbols_fts(docid, name, signature, doc)
+		VALUES (new.id, new.name, new.signature, new.doc);
+	END`,
+
+	// Indexes for Python
+	`CREATE INDEX IF NOT EXISTS idx_python_packages_name ON python_packages(name)`,
+	`CREATE INDEX IF NOT EXISTS idx_python_symbols_package ON python_symbols(package_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_python_symbols_public ON python_symbols(public)`,
+
+	// PHP packages table
+	`CREATE TABLE IF NOT EXISTS php_packages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		version TEXT,
+		description TEXT,
+		type TEXT,
+		license TEXT,
+		homepage TEXT,
+		repository_url TEXT,
+		packagist_url TEXT,
+		downloads INTEGER DEFAULT 0,
+		stars INTEGER DEFAULT 0,
+		authors_json TEXT,
+		keywords_json TEXT,
+		require_json TEXT,
+		readme TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		indexed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+
+	// PHP symbols table
+	`CREATE TABLE IF NOT EXISTS php_symbols (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		signature TEXT,
+		package_id INTEGER NOT NULL,
+		package_name TEXT NOT NULL,
+		file_path TEXT,
+		line INTEGER DEFAULT 0,
+		public INTEGER DEFAULT 0,
+		doc TEXT,
+		FOREIGN KEY (package_id) REFERENCES php_packages(id) ON DELETE CASCADE
+	)`,
+
+	// PHP packages FTS table
+	`CREATE VIRTUAL TABLE IF NOT EXISTS php_packages_fts USING fts4(
+		name,
+		description,
+		keywords,
+		content=php_packages,
+		tokenize=porter
+	)`,
+
+	// PHP symbols FTS table
+	`CREATE VIRTUAL TABLE IF NOT EXISTS php_symbols_fts USING fts4(
+		name,
+		signature,
+		doc,
+		content=php_symbols,
+		tokenize=porter
+	)`,
+
+	// Triggers for PHP packages FTS
+	`CREATE TRIGGER IF NOT EXISTS php_packages_ai AFTER INSERT ON php_packages BEGIN
+		INSERT INTO php_packages_fts(docid, name, description, keywords)
+		VALUES (new.id, new.name, new.description, new.keywords_json);
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS php_packages_ad AFTER DELETE ON php_packages BEGIN
+		DELETE FROM php_packages_fts WHERE docid = old.id;
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS php_packages_au AFTER UPDATE ON php_packages BEGIN
+		DELETE FROM php_packages_fts WHERE docid = old.id;
+		INSERT INTO php_packages_fts(docid, name, description, keywords)
+		VALUES (new.id, new.name, new.description, new.keywords_json);
+	END`,
+
+	// Triggers for PHP symbols FTS
+	`CREATE TRIGGER IF NOT EXISTS php_symbols_ai AFTER INSERT ON php_symbols BEGIN
+		INSERT INTO php_symbols_fts(docid, name, signature, doc)
+		VALUES (new.id, new.name, new.signature, new.doc);
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS php_symbols_ad AFTER DELETE ON php_symbols BEGIN
+		DELETE FROM php_symbols_fts WHERE docid = old.id;
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS php_symbols_au AFTER UPDATE ON php_symbols BEGIN
+		DELETE FROM php_symbols_fts WHERE docid = old.id;
+		INSERT INTO php_symbols_fts(docid, name, signature, doc)
+		VALUES (new.id, new.name, new.signature, new.doc);
+	END`,
+
+	// Indexes for PHP
+	`CREATE INDEX IF NOT EXISTS idx_php_packages_name ON php_packages(name)`,
+	`CREATE INDEX IF NOT EXISTS idx_php_symbols_package ON php_symbols(package_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_php_symbols_public ON php_symbols(public)`,
+
+	// Embeddings table for semantic search
+	`CREATE TABLE IF NOT EXISTS embeddings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		import_path TEXT NOT NULL,
+		lang TEXT NOT NULL DEFAULT 'go',
+		text_hash TEXT NOT NULL,
+		embedding BLOB NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(import_path, lang)
+	)`,
+
+	`CREATE INDEX IF NOT EXISTS idx_embeddings_lang ON embeddings(lang)`,
+
+	// Persisted ANN index centroids and list assignments
+	`CREATE TABLE IF NOT EXISTS embedding_indexes (
+		lang TEXT PRIMARY KEY,
+		dim INTEGER NOT NULL,
+		embedding_count INTEGER NOT NULL,
+		centroids BLOB NOT NULL,
+		assignments_json TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+
+	`CREATE TABLE IF NOT EXISTS generated_examples (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		import_path TEXT NOT NULL,
+		function_name TEXT NOT NULL,
+		signature TEXT NOT NULL,
+		description TEXT,
+		imports TEXT,
+		code TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(import_path, function_name)
+	)`,
+
+	`CREATE INDEX IF NOT EXISTS idx_examples_import_path ON generated_examples(import_path)`,
+}
+
+// migration is an incremental schema change, applied once in version order
+type migration struct {
+	version     int
+	description string
+	apply       func(db *DB) error
+}
+
+// migrations lists every schema change. Append new versions; never edit applied ones.
+var migrations = []migration{
+	{1, "initial schema", func(db *DB) error {
+		for _, stmt := range initialSchema {
+			if _, err := db.conn.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{2, "package classification", func(db *DB) error {
+		return db.addColumnIfMissing("packages", "classification", "TEXT DEFAULT ''")
+	}},
+	{3, "npm dependency graph", func(db *DB) error {
+		stmts := []string{
+			`CREATE TABLE IF NOT EXISTS js_dependencies (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				package_name TEXT NOT NULL,
+				dependency_name TEXT NOT NULL,
+				version_range TEXT
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_js_dependencies_package ON js_dependencies(package_name)`,
+			`CREATE INDEX IF NOT EXISTS idx_js_dependencies_dependency ON js_dependencies(dependency_name)`,
+		}
+		for _, stmt := range stmts {
+			if _, err := db.conn.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{4, "standalone js package FTS", func(db *DB) error {
+		// js_packages_fts named a "keywords" column that js_packages lacks, so any
+		// update or delete failed reading the external content. Store the text instead.
+		stmts := []string{
+			`DROP TRIGGER IF EXISTS js_packages_ai`,
+			`DROP TRIGGER IF EXISTS js_packages_ad`,
+			`DROP TRIGGER IF EXISTS js_packages_au`,
+			`DROP TABLE IF EXISTS js_packages_fts`,
+			`CREATE VIRTUAL TABLE js_packages_fts USING fts4(
+				name,
+				description,
+				author,
+				keywords,
+				tokenize=porter
+			)`,
+			`INSERT INTO js_packages_fts(docid, name, description, author, keywords)
+				SELECT id, name, description, author, keywords_json FROM js_packages`,
+			`CREATE TRIGGER js_packages_ai AFTER INSERT ON js_packages BEGIN
+				INSERT INTO js_packages_fts(docid, name, description, author, keywords)
+				VALUES (new.id, new.name, new.description, new.author, new.keywords_json);
+			END`,
+			`CREATE TRIGGER js_packages_ad AFTER DELETE ON js_packages BEGIN
+				DELETE FROM js_packages_fts WHERE docid = old.id;
+			END`,
+			`CREATE TRIGGER js_packages_au AFTER UPDATE ON js_packages BEGIN
+				DELETE FROM js_packages_fts WHERE docid = old.id;
+				INSERT INTO js_packages_fts(docid, name, description, author, keywords)
+				VALUES (new.id, new.name, new.description, new.author, new.keywords_json);
+			END`,
+		}
+		for _, stmt := range stmts {
+			if _, err := db.conn.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{5, "npm dependency types", func(db *DB) error {
+		return db.addColumnIfMissing("js_dependencies", "dep_type", "TEXT NOT NULL DEFAULT 'runtime'")
+	}},
+	{6, "npm package sizes", func(db *DB) error {
+		if err := db.addColumnIfMissing("js_packages", "unpacked_size", "INTEGER DEFAULT 0"); err != nil {
+			return err
+		}
+		return db.addColumnIfMissing("js_packages", "tarball_size", "INTEGER DEFAULT 0")
+	}},
+	{7, "registry version lists", func(db *DB) error {
+		for _, table := range registryVersionTables {
+			stmts := []string{
+				`CREATE TABLE IF NOT EXISTS ` + table + ` (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					package_name TEXT NOT NULL,
+					version TEXT NOT NULL,
+					published_at DATETIME,
+					UNIQUE(package_name, version)
+				)`,
+				`CREATE INDEX IF NOT EXISTS idx_` + table + `_package ON ` + table + `(package_name)`,
+			}
+			for _, stmt := range stmts {
+				if _, err := db.conn.Exec(stmt); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}},
+	{8, "yanked registry versions", func(db *DB) error {
+		for _, table := range registryVersionTables {
+			if err := db.addColumnIfMissing(table, "yanked", "INTEGER DEFAULT 0"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{9, "go examples", func(db *DB) error {
+		stmts := []string{
+			`CREATE TABLE IF NOT EXISTS examples (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				package_id INTEGER NOT NULL,
+				import_path TEXT NOT NULL,
+				symbol TEXT NOT NULL DEFAULT '',
+				name TEXT NOT NULL,
+				doc TEXT,
+				code TEXT,
+				output TEXT,
+				words TEXT,
+				UNIQUE(import_path, name)
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_examples_package ON examples(package_id)`,
+			`CREATE VIRTUAL TABLE IF NOT EXISTS examples_fts USING fts4(
+				import_path,
+				symbol,
+				doc,
+				words,
+				tokenize=porter
+			)`,
+			`CREATE TRIGGER IF NOT EXISTS examples_ai AFTER INSERT ON examples BEGIN
+				INSERT INTO examples_fts(docid, import_path, symbol, doc, words)
+				VALUES (new.id, new.import_path, new.symbol, new.doc, new.words);
+			END`,
+			`CREATE TRIGGER IF NOT EXISTS examples_ad AFTER DELETE ON examples BEGIN
+				DELETE FROM examples_fts WHERE docid = old.id;
+			END`,
+		}
+		for _, stmt := range stmts {
+			if _, err := db.conn.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{10, "documentation feedback", func(db *DB) error {
+		stmts := []string{
+			`CREATE TABLE IF NOT EXISTS feedback (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				import_path TEXT NOT NULL,
+				symbol TEXT NOT NULL DEFAULT '',
+				symbol_kind TEXT NOT NULL DEFAULT '',
+				ai_doc INTEGER DEFAULT 0,
+				message TEXT NOT NULL,
+				resolved INTEGER DEFAULT 0,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_feedback_resolved ON feedback(resolved)`,
+		}
+		for _, stmt := range stmts {
+			if _, err := db.conn.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{11, "symbol source positions", func(db *DB) error {
+		if err := db.addColumnIfMissing("symbols", "filename", "TEXT"); err != nil {
+			return err
+		}
+		if err := db.addColumnIfMissing("symbols", "line", "INTEGER DEFAULT 0"); err != nil {
+			return err
+		}
+		return db.addColumnIfMissing("symbols", "end_line", "INTEGER DEFAULT 0")
+	}},
+	{12, "verified examples", func(db *DB) error {
+		return db.addColumnIfMissing("examples", "verified", "INTEGER DEFAULT 0")
+	}},
+	{13, "symbol browse index", func(db *DB) error {
+		_, err := db.conn.Exec(`CREATE INDEX IF NOT EXISTS idx_symbols_kind_name ON symbols(kind, name)`)
+		return err
+	}},
+	{14, "standalone rust crate FTS", func(db *DB) error {
+		// rust_crates_fts had the same missing "keywords" column as js_packages_fts
+		// (see version 4), so reindexing a crate failed
+		stmts := []string{
+			`DROP TRIGGER IF EXISTS rust_crates_ai`,
+			`DROP TRIGGER IF EXISTS rust_crates_ad`,
+			`DROP TRIGGER IF EXISTS rust_crates_au`,
+			`DROP TABLE IF EXISTS rust_crates_fts`,
+			`CREATE VIRTUAL TABLE rust_crates_fts USING fts4(
+				name,
+				description,
+				keywords,
+				tokenize=porter
+			)`,
+			`INSERT INTO rust_crates_fts(docid, name, description, keywords)
+				SELECT id, name, description, keywords_json FROM rust_crates`,
+			`CREATE TRIGGER rust_crates_ai AFTER INSERT ON rust_crates BEGIN
+				INSERT INTO rust_crates_fts(docid, name, description, keywords)
+				VALUES (new.id, new.name, new.description, new.keywords_json);
+			END`,
+			`CREATE TRIGGER rust_crates_ad AFTER DELETE ON rust_crates BEGIN
+				DELETE FROM rust_crates_fts WHERE docid = old.id;
+			END`,
+			`CREATE TRIGGER rust_crates_au AFTER UPDATE ON rust_crates BEGIN
+				DELETE FROM rust_crates_fts WHERE docid = old.id;
+				INSERT INTO rust_crates_fts(docid, name, description, keywords)
+				VALUES (new.id, new.name, new.description, new.keywords_json);
+			END`,
+		}
+		for _, stmt := range stmts {
+			if _, err := db.conn.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{15, "cargo features", func(db *DB) error {
+		return db.addColumnIfMissing("rust_crates", "features_json", "TEXT")
+	}},
+	{16, "rust platform support", func(db *DB) error {
+		if err := db.addColumnIfMissing("rust_crates", "no_std", "INTEGER DEFAULT 0"); err != nil {
+			return err
+		}
+		return db.addColumnIfMissing("rust_crates", "wasm", "INTEGER DEFAULT 0")
+	}},
+	{17, "standalone python and php package FTS", func(db *DB) error {
+		// Same missing "keywords" column as versions 4 and 14. The triggers only
+		// name the FTS table, so they keep working once it is recreated.
+		for _, idx := range ftsIndexes {
+			if idx.table != "python_packages_fts" && idx.table != "php_packages_fts" {
+				continue
+			}
+			stmts := []string{
+				`DROP TABLE IF EXISTS ` + idx.table,
+				fmt.Sprintf(`CREATE VIRTUAL TABLE %s USING fts4(%s, tokenize=porter)`, idx.table, strings.Join(idx.columns, ", ")),
+				idx.populate(),
+			}
+			for _, stmt := range stmts {
+				if _, err := db.conn.Exec(stmt); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}},
+	{18, "go:generate directives", func(db *DB) error {
+		return db.addColumnIfMissing("packages", "generate_json", "TEXT")
+	}},
+	{19, "orphaned ai docs and feedback", func(db *DB) error {
+		if err := db.addColumnIfMissing("ai_docs", "orphaned", "INTEGER DEFAULT 0"); err != nil {
+			return err
+		}
+		return db.addColumnIfMissing("feedback", "orphaned", "INTEGER DEFAULT 0")
+	}},
+	{20, "crawl error log", func(db *DB) error {
+		stmts := []string{
+			`CREATE TABLE IF NOT EXISTS crawl_errors (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				module_path TEXT NOT NULL,
+				version TEXT NOT NULL DEFAULT '',
+				error TEXT NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_crawl_errors_created ON crawl_errors(created_at)`,
+			`CREATE INDEX IF NOT EXISTS idx_crawl_errors_module ON crawl_errors(module_path, version)`,
+		}
+		for _, stmt := range stmts {
+			if _, err := db.conn.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{21, "function shapes", func(db *DB) error {
+		if err := db.addColumnIfMissing("symbols", "shape", "TEXT"); err != nil {
+			return err
+		}
+		if _, err := db.conn.Exec(`CREATE INDEX IF NOT EXISTS idx_symbols_shape ON symbols(shape)`); err != nil {
+			return err
+		}
+
+		rows, err := db.conn.Query(`SELECT id, kind, signature FROM symbols WHERE kind IN ('func', 'method') AND COALESCE(signature, '') != ''`)
+		if err != nil {
+			return err
+		}
+		shapes := make(map[int64]string)
+		for rows.Next() {
+			sym := &Symbol{}
+			if err := rows.Scan(&sym.ID, &sym.Kind, &sym.Signature); err != nil {
+				rows.Close()
+				return err
+			}
+			if shape := symbolShape(sym); shape != nil {
+				shapes[sym.ID] = *shape
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for id, shape := range shapes {
+			if _, err := db.conn.Exec(`UPDATE symbols SET shape = ? WHERE id = ?`, shape, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{22, "package coverage", func(db *DB) error {
+		return db.addColumnIfMissing("packages", "coverage", "TEXT DEFAULT ''")
+	}},
+	{23, "derived synopses", func(db *DB) error {
+		return db.addColumnIfMissing("packages", "synopsis_source", "TEXT DEFAULT ''")
+	}},
+	{24, "imported-by counts", func(db *DB) error {
+		_, err := db.conn.Exec(`CREATE TABLE IF NOT EXISTS imported_by_counts (
+			imported_path TEXT PRIMARY KEY,
+			count INTEGER NOT NULL
+		)`)
+		if err != nil {
+			return err
+		}
+		_, err = db.conn.Exec(populateImportedByCounts)
+		return err
+	}},
+	{25, "module checksums", func(db *DB) error {
+		if err := db.addColumnIfMissing("module_versions", "checksum", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		if err := db.addColumnIfMissing("module_versions", "proxy_checksum", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		return db.addColumnIfMissing("module_versions", "gosum_modules", "INTEGER NOT NULL DEFAULT 0")
+	}},
+	{26, "experimental packages", func(db *DB) error {
+		return db.addColumnIfMissing("packages", "experimental", "INTEGER NOT NULL DEFAULT 0")
+	}},
+	{27, "example output kinds", func(db *DB) error {
+		if err := db.addColumnIfMissing("examples", "unordered", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return err
+		}
+		return db.addColumnIfMissing("examples", "empty_output", "INTEGER NOT NULL DEFAULT 0")
+	}},
+	{28, "deprecated packages", func(db *DB) error {
+		return db.addColumnIfMissing("packages", "deprecated", "INTEGER NOT NULL DEFAULT 0")
+	}},
+	{29, "type method sets", func(db *DB) error {
+		if err := db.addColumnIfMissing("symbols", "is_interface", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return err
+		}
+		return db.addColumnIfMissing("symbols", "method_set_json", "TEXT")
+	}},
+	{30, "symbol parameter docs", func(db *DB) error {
+		return db.addColumnIfMissing("symbols", "params_json", "TEXT")
+	}},
+}
+
+// ftsIndex is a full-text index kept in sync with a base table by triggers
+type ftsIndex struct {
+	table   string
+	base    string
+	columns []string // FTS columns
+	values  []string // base table expression for each column
+	content bool     // external content: the FTS table reads its text from the base table
+}
+
+var ftsIndexes = []ftsIndex{
+	{"packages_fts", "packages", []string{"import_path", "name", "synopsis", "doc"}, []string{"import_path", "name", "synopsis", "doc"}, true},
+	{"symbols_fts", "symbols", []string{"name", "synopsis"}, []string{"name", "synopsis"}, true},
+	{"examples_fts", "examples", []string{"import_path", "symbol", "doc", "words"}, []string{"import_path", "symbol", "doc", "words"}, false},
+	{"js_packages_fts", "js_packages", []string{"name", "description", "author", "keywords"}, []string{"name", "description", "author", "keywords_json"}, false},
+	{"js_symbols_fts", "js_symbols", []string{"name", "signature", "doc"}, []string{"name", "signature", "doc"}, true},
+	{"rust_crates_fts", "rust_crates", []string{"name", "description", "keywords"}, []string{"name", "description", "keywords_json"}, false},
+	{"rust_symbols_fts", "rust_symbols", []string{"name", "signature", "doc"}, []string{"name", "signature", "doc"}, true},
+	{"python_packages_fts", "python_packages", []string{"name", "summary", "author", "keywords"}, []string{"name", "summary", "author", "keywords_json"}, false},
+	{"python_symbols_fts", "python_symbols", []string{"name", "signature", "doc"}, []string{"name", "signature", "doc"}, true},
+	{"php_packages_fts", "php_packages", []string{"name", "description", "keywords"}, []string{"name", "description", "keywords_json"}, false},
+	{"php_symbols_fts", "php_symbols", []string{"name", "signature", "doc"}, []string{"name", "signature", "doc"}, true},
+}
+
+// populate returns the statement that indexes every row of the base table
+func (idx ftsIndex) populate() string {
+	return fmt.Sprintf(`INSERT INTO %s(docid, %s) SELECT id, %s FROM %s`,
+		idx.table, strings.Join(idx.columns, ", "), strings.Join(idx.values, ", "), idx.base)
+}
+
+// FTSCount compares the rows of a base table with the documents in its FTS index
+type FTSCount struct {
+	Table   string // FTS table
+	Base    string // table it indexes
+	Rows    int    // rows in the base table
+	Indexed int    // documents in the FTS index
+}
+
+// Delta is the number of base table rows missing from the index, negative when
+// the index holds documents for rows that no longer exist
+func (c FTSCount) Delta() int {
+	return c.Rows - c.Indexed
+}
+
+// CheckFTS counts the rows of every base table and the documents of its FTS index
+func (db *DB) CheckFTS() ([]FTSCount, error) {
+	var counts []FTSCount
+	for _, idx := range ftsIndexes {
+		c := FTSCount{Table: idx.table, Base: idx.base}
+		if err := db.conn.QueryRow(`SELECT COUNT(*) FROM ` + idx.base).Scan(&c.Rows); err != nil {
+			return nil, fmt.Errorf("counting %s: %w", idx.base, err)
+		}
+		// Counting the FTS table itself would count the external content rows;
+		// the docsize shadow table has one row per indexed document
+		if err := db.conn.QueryRow(`SELECT COUNT(*) FROM ` + idx.table + `_docsize`).Scan(&c.Indexed); err != nil {
+			return nil, fmt.Errorf("counting %s: %w", idx.table, err)
+		}
+		counts = append(counts, c)
+	}
+	return counts, nil
+}
+
+// RebuildFTS clears every FTS index and repopulates it from its base table, for
+// when rows were written with the triggers missing or the index was damaged
+func (db *DB) RebuildFTS() error {
+	return db.Batch(func(tx *DB) error {
+		for _, idx := range ftsIndexes {
+			stmts := []string{`DELETE FROM ` + idx.table, idx.populate()}
+			if idx.content {
+				stmts = []string{fmt.Sprintf(`INSERT INTO %s(%s) VALUES('rebuild')`, idx.table, idx.table)}
+			}
+			for _, stmt := range stmts {
+				if _, err := tx.conn.Exec(stmt); err != nil {
+					return fmt.Errorf("rebuilding %s: %w", idx.table, err)
+				}
+			}
+		}
+		return nil
+	})
 }
 
-// migrate runs database migrations
+// migrate applies pending migrations and records them in schema_migrations
 func (db *DB) migrate() error {
-	migrations := []string{
-		// Packages table
-		`CREATE TABLE IF NOT EXISTS packages (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			import_path TEXT UNIQUE NOT NULL,
-			name TEXT NOT NULL,
-			synopsis TEXT,
-			doc TEXT,
-			version TEXT,
-			versions_json TEXT,
-			is_tagged INTEGER DEFAULT 0,
-			is_stable INTEGER DEFAULT 0,
-			license TEXT,
-			license_text TEXT,
-			redistributable INTEGER DEFAULT 0,
-			repository TEXT,
-			has_valid_mod INTEGER DEFAULT 0,
-			go_version TEXT,
-			module_path TEXT,
-			gomod_content TEXT,
-			goos_json TEXT,
-			goarch_json TEXT,
-			doc_json TEXT,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			indexed_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)`,
-
-		// Imports table for tracking import relationships
-		`CREATE TABLE IF NOT EXISTS imports (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			importer_path TEXT NOT NULL,
-			imported_path TEXT NOT NULL,
-			importer_module TEXT,
-			UNIQUE(importer_path, imported_path)
-		)`,
-
-		// Symbols table for symbol search
-		`CREATE TABLE IF NOT EXISTS symbols (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT NOT NULL,
-			kind TEXT NOT NULL,
-			package_id INTEGER NOT NULL,
-			import_path TEXT NOT NULL,
-			synopsis TEXT,
-			doc TEXT,
-			signature TEXT,
-			decl TEXT,
-			deprecated INTEGER DEFAULT 0,
-			FOREIGN KEY (package_id) REFERENCES packages(id) ON DELETE CASCADE
-		)`,
-
-		// Indexes for fast lookups
-		`CREATE INDEX IF NOT EXISTS idx_packages_import_path ON packages(import_path)`,
-		`CREATE INDEX IF NOT EXISTS idx_packages_module_path ON packages(module_path)`,
-		`CREATE INDEX IF NOT EXISTS idx_packages_name ON packages(name)`,
-		`CREATE INDEX IF NOT EXISTS idx_imports_importer ON imports(importer_path)`,
-		`CREATE INDEX IF NOT EXISTS idx_imports_imported ON imports(imported_path)`,
-		`CREATE INDEX IF NOT EXISTS idx_symbols_name ON symbols(name)`,
-		`CREATE INDEX IF NOT EXISTS idx_symbols_kind ON symbols(kind)`,
-		`CREATE INDEX IF NOT EXISTS idx_symbols_package ON symbols(package_id)`,
-
-		// Full-text search for packages using FTS4 (more widely supported)
-		`CREATE VIRTUAL TABLE IF NOT EXISTS packages_fts USING fts4(
-			import_path,
-			name,
-			synopsis,
-			doc,
-			content="packages",
-			tokenize=porter
-		)`,
-
-		// Full-text search for symbols using FTS4
-		`CREATE VIRTUAL TABLE IF NOT EXISTS symbols_fts USING fts4(
-			name,
-			synopsis,
-			content="symbols",
-			tokenize=porter
-		)`,
-
-		// Triggers to keep FTS in sync with packages
-		`CREATE TRIGGER IF NOT EXISTS packages_ai AFTER INSERT ON packages BEGIN
-			INSERT INTO packages_fts(docid, import_path, name, synopsis, doc)
-			VALUES (new.id, new.import_path, new.name, new.synopsis, new.doc);
-		END`,
-
-		`CREATE TRIGGER IF NOT EXISTS packages_ad AFTER DELETE ON packages BEGIN
-			DELETE FROM packages_fts WHERE docid = old.id;
-		END`,
-
-		`CREATE TRIGGER IF NOT EXISTS packages_au AFTER UPDATE ON packages BEGIN
-			DELETE FROM packages_fts WHERE docid = old.id;
-			INSERT INTO packages_fts(docid, import_path, name, synopsis, doc)
-			VALUES (new.id, new.import_path, new.name, new.synopsis, new.doc);
-		END`,
-
-		// Triggers to keep FTS in sync with symbols
-		`CREATE TRIGGER IF NOT EXISTS symbols_ai AFTER INSERT ON symbols BEGIN
-			INSERT INTO symbols_fts(docid, name, synopsis)
-			VALUES (new.id, new.name, new.synopsis);
-		END`,
-
-		`CREATE TRIGGER IF NOT EXISTS symbols_ad AFTER DELETE ON symbols BEGIN
-			DELETE FROM symbols_fts WHERE docid = old.id;
-		END`,
-
-		`CREATE TRIGGER IF NOT EXISTS symbols_au AFTER UPDATE ON symbols BEGIN
-			DELETE FROM symbols_fts WHERE docid = old.id;
-			INSERT INTO symbols_fts(docid, name, synopsis)
-			VALUES (new.id, new.name, new.synopsis);
-		END`,
-
-		// Metadata table for crawl state tracking
-		`CREATE TABLE IF NOT EXISTS crawl_metadata (
-			key TEXT PRIMARY KEY,
-			value TEXT,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)`,
-
-		// Module versions table for version history tracking
-		`CREATE TABLE IF NOT EXISTS module_versions (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			module_path TEXT NOT NULL,
-			version TEXT NOT NULL,
-			timestamp DATETIME,
-			is_tagged INTEGER DEFAULT 0,
-			is_stable INTEGER DEFAULT 0,
-			retracted INTEGER DEFAULT 0,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			UNIQUE(module_path, version)
-		)`,
-
-		`CREATE INDEX IF NOT EXISTS idx_module_versions_path ON module_versions(module_path)`,
-		`CREATE INDEX IF NOT EXISTS idx_module_versions_timestamp ON module_versions(timestamp DESC)`,
-
-		// AI-generated documentation table
-		`CREATE TABLE IF NOT EXISTS ai_docs (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			symbol_name TEXT NOT NULL,
-			symbol_kind TEXT NOT NULL,
-			import_path TEXT NOT NULL,
-			generated_doc TEXT NOT NULL,
-			approved INTEGER DEFAULT 0,
-			flagged INTEGER DEFAULT 0,
-			flag_reason TEXT,
-			cost_usd REAL DEFAULT 0,
-			tokens INTEGER DEFAULT 0,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			UNIQUE(import_path, symbol_name, symbol_kind)
-		)`,
-
-		`CREATE INDEX IF NOT EXISTS idx_ai_docs_import_path ON ai_docs(import_path)`,
-		`CREATE INDEX IF NOT EXISTS idx_ai_docs_approved ON ai_docs(approved)`,
-		`CREATE INDEX IF NOT EXISTS idx_ai_docs_flagged ON ai_docs(flagged)`,
-
-		// JavaScript/TypeScript packages table
-		`CREATE TABLE IF NOT EXISTS js_packages (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT UNIQUE NOT NULL,
-			version TEXT,
-			description TEXT,
-			author TEXT,
-			license TEXT,
-			repository_url TEXT,
-			homepage TEXT,
-			npm_url TEXT,
-			github_url TEXT,
-			main_file TEXT,
-			types_file TEXT,
-			has_typescript INTEGER DEFAULT 0,
-			stars INTEGER DEFAULT 0,
-			forks INTEGER DEFAULT 0,
-			keywords_json TEXT,
-			dependencies_json TEXT,
-			package_json TEXT,
-			readme TEXT,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			indexed_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)`,
-
-		// JavaScript/TypeScript symbols table
-		`CREATE TABLE IF NOT EXISTS js_symbols (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT NOT NULL,
-			kind TEXT NOT NULL,
-			signature TEXT,
-			package_id INTEGER NOT NULL,
-			package_name TEXT NOT NULL,
-			file_path TEXT,
-			line INTEGER DEFAULT 0,
-			exported INTEGER DEFAULT 0,
-			doc TEXT,
-			deprecated INTEGER DEFAULT 0,
-			FOREIGN KEY (package_id) REFERENCES js_packages(id) ON DELETE CASCADE
-		)`,
-
-		// Indexes for JS packages and symbols
-		`CREATE INDEX IF NOT EXISTS idx_js_packages_name ON js_packages(name)`,
-		`CREATE INDEX IF NOT EXISTS idx_js_packages_author ON js_packages(author)`,
-		`CREATE INDEX IF NOT EXISTS idx_js_packages_stars ON js_packages(stars DESC)`,
-		`CREATE INDEX IF NOT EXISTS idx_js_symbols_name ON js_symbols(name)`,
-		`CREATE INDEX IF NOT EXISTS idx_js_symbols_kind ON js_symbols(kind)`,
-		`CREATE INDEX IF NOT EXISTS idx_js_symbols_package ON js_symbols(package_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_js_symbols_exported ON js_symbols(exported)`,
-
-		// FTS for JavaScript/TypeScript packages
-		`CREATE VIRTUAL TABLE IF NOT EXISTS js_packages_fts USING fts4(
-			name,
-			description,
-			author,
-			keywords,
-			content=js_packages,
-			tokenize=porter
-		)`,
-
-		// FTS for JavaScript/TypeScript symbols
-		`CREATE VIRTUAL TABLE IF NOT EXISTS js_symbols_fts USING fts4(
-			name,
-			signature,
-			doc,
-			content=js_symbols,
-			tokenize=porter
-		)`,
-
-		// Triggers for JS packages FTS
-		`CREATE TRIGGER IF NOT EXISTS js_packages_ai AFTER INSERT ON js_packages BEGIN
-			INSERT INTO js_packages_fts(docid, name, description, author, keywords)
-			VALUES (new.id, new.name, new.description, new.author, new.keywords_json);
-		END`,
-
-		`CREATE TRIGGER IF NOT EXISTS js_packages_ad AFTER DELETE ON js_packages BEGIN
-			DELETE FROM js_packages_fts WHERE docid = old.id;
-		END`,
-
-		`CREATE TRIGGER IF NOT EXISTS js_packages_au AFTER UPDATE ON js_packages BEGIN
-			DELETE FROM js_packages_fts WHERE docid = old.id;
-			INSERT INTO js_packages_fts(docid, name, description, author, keywords)
-			VALUES (new.id, new.name, new.description, new.author, new.keywords_json);
-		END`,
-
-		// Triggers for JS symbols FTS
-		`CREATE TRIGGER IF NOT EXISTS js_symbols_ai AFTER INSERT ON js_symbols BEGIN
-			INSERT INTO js_symbols_fts(docid, name, signature, doc)
-			VALUES (new.id, new.name, new.signature, new.doc);
-		END`,
-
-		`CREATE TRIGGER IF NOT EXISTS js_symbols_ad AFTER DELETE ON js_symbols BEGIN
-			DELETE FROM js_symbols_fts WHERE docid = old.id;
-		END`,
-
-		`CREATE TRIGGER IF NOT EXISTS js_symbols_au AFTER UPDATE ON js_symbols BEGIN
-			DELETE FROM js_symbols_fts WHERE docid = old.id;
-			INSERT INTO js_symbols_fts(docid, name, signature, doc)
-			VALUES (new.id, new.name, new.signature, new.doc);
-		END`,
-
-		// Rust crates table
-		`CREATE TABLE IF NOT EXISTS rust_crates (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT UNIQUE NOT NULL,
-			version TEXT,
-			description TEXT,
-			license TEXT,
-			repository TEXT,
-			homepage TEXT,
-			documentation TEXT,
-			downloads INTEGER DEFAULT 0,
-			keywords_json TEXT,
-			categories_json TEXT,
-			dependencies_json TEXT,
-			authors_json TEXT,
-			readme TEXT,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			indexed_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)`,
-
-		// Rust symbols table
-		`CREATE TABLE IF NOT EXISTS rust_symbols (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT NOT NULL,
-			kind TEXT NOT NULL,
-			signature TEXT,
-			crate_id INTEGER NOT NULL,
-			crate_name TEXT NOT NULL,
-			file_path TEXT,
-			line INTEGER DEFAULT 0,
-			public INTEGER DEFAULT 0,
-			doc TEXT,
-			FOREIGN KEY (crate_id) REFERENCES rust_crates(id) ON DELETE CASCADE
-		)`,
-
-		// Rust crates FTS table
-		`CREATE VIRTUAL TABLE IF NOT EXISTS rust_crates_fts USING fts4(
-			name,
-			description,
-			keywords,
-			content=rust_crates,
-			tokenize=porter
-		)`,
-
-		// Rust symbols FTS table
-		`CREATE VIRTUAL TABLE IF NOT EXISTS rust_symbols_fts USING fts4(
-			name,
-			signature,
-			doc,
-			content=rust_symbols,
-			tokenize=porter
-		)`,
-
-		// Triggers for Rust crates FTS
-		`CREATE TRIGGER IF NOT EXISTS rust_crates_ai AFTER INSERT ON rust_crates BEGIN
-			INSERT INTO rust_crates_fts(docid, name, description, keywords)
-			VALUES (new.id, new.name, new.description, new.keywords_json);
-		END`,
-
-		`CREATE TRIGGER IF NOT EXISTS rust_crates_ad AFTER DELETE ON rust_crates BEGIN
-			DELETE FROM rust_crates_fts WHERE docid = old.id;
-		END`,
-
-		`CREATE TRIGGER IF NOT EXISTS rust_crates_au AFTER UPDATE ON rust_crates BEGIN
-			DELETE FROM rust_crates_fts WHERE docid = old.id;
-			INSERT INTO rust_crates_fts(docid, name, description, keywords)
-			VALUES (new.id, new.name, new.description, new.keywords_json);
-		END`,
-
-		// Triggers for Rust symbols FTS
-		`CREATE TRIGGER IF NOT EXISTS rust_symbols_ai AFTER INSERT ON rust_symbols BEGIN
-			INSERT INTO rust_symbols_fts(docid, name, signature, doc)
-			VALUES (new.id, new.name, new.signature, new.doc);
-		END`,
-
-		`CREATE TRIGGER IF NOT EXISTS rust_symbols_ad AFTER DELETE ON rust_symbols BEGIN
-			DELETE FROM rust_symbols_fts WHERE docid = old.id;
-		END`,
-
-		`CREATE TRIGGER IF NOT EXISTS rust_symbols_au AFTER UPDATE ON rust_symbols BEGIN
-			DELETE FROM rust_symbols_fts WHERE docid = old.id;
-			INSERT INTO rust_symbols_fts(docid, name, signature, doc)
-			VALUES (new.id, new.name, new.signature, new.doc);
-		END`,
-
-		// Python packages table
-		`CREATE TABLE IF NOT EXISTS python_packages (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT UNIQUE NOT NULL,
-			version TEXT,
-			summary TEXT,
-			author TEXT,
-			author_email TEXT,
-			license TEXT,
-			home_page TEXT,
-			project_url TEXT,
-			pypi_url TEXT,
-			repository_url TEXT,
-			documentation_url TEXT,
-			requires_python TEXT,
-			downloads INTEGER DEFAULT 0,
-			keywords_json TEXT,
-			classifiers_json TEXT,
-			dependencies_json TEXT,
-			readme TEXT,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			indexed_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)`,
-
-		// Python symbols table
-		`CREATE TABLE IF NOT EXISTS python_symbols (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT NOT NULL,
-			kind TEXT NOT NULL,
-			signature TEXT,
-			package_id INTEGER NOT NULL,
-			package_name TEXT NOT NULL,
-			file_path TEXT,
-			line INTEGER DEFAULT 0,
-			public INTEGER DEFAULT 0,
-			doc TEXT,
-			FOREIGN KEY (package_id) REFERENCES python_packages(id) ON DELETE CASCADE
-		)`,
-
-		// Python packages FTS table
-		`CREATE VIRTUAL TABLE IF NOT EXISTS python_packages_fts USING fts4(
-			name,
-			summary,
-			author,
-			keywords,
-			content=python_packages,
-			tokenize=porter
-		)`,
-
-		// Python symbols FTS table
-		`CREATE VIRTUAL TABLE IF NOT EXISTS python_symbols_fts USING fts4(
-			name,
-			signature,
-			doc,
-			content=python_symbols,
-			tokenize=porter
-		)`,
-
-		// Triggers for Python packages FTS
-		`CREATE TRIGGER IF NOT EXISTS python_packages_ai AFTER INSERT ON python_packages BEGIN
-			INSERT INTO python_packages_fts(docid, name, summary, author, keywords)
-			VALUES (new.id, new.name, new.summary, new.author, new.keywords_json);
-		END`,
-
-		`CREATE TRIGGER IF NOT EXISTS python_packages_ad AFTER DELETE ON python_packages BEGIN
-			DELETE FROM python_packages_fts WHERE docid = old.id;
-		END`,
-
-		`CREATE TRIGGER IF NOT EXISTS python_packages_au AFTER UPDATE ON python_packages BEGIN
-			DELETE FROM python_packages_fts WHERE docid = old.id;
-			INSERT INTO python_packages_fts(docid, name, summary, author, keywords)
-			VALUES (new.id, new.name, new.summary, new.author, new.keywords_json);
-		END`,
-
-		// Triggers for Python symbols FTS
-		`CREATE TRIGGER IF NOT EXISTS python_symbols_ai AFTER INSERT ON python_symbols BEGIN
-			INSERT INTO python_symbols_fts(docid, name, signature, doc)
-			VALUES (new.id, new.name, new.signature, new.doc);
-		END`,
-
-		`CREATE TRIGGER IF NOT EXISTS python_symbols_ad AFTER DELETE ON python_symbols BEGIN
-			DELETE FROM python_symbols_fts WHERE docid = old.id;
-		END`,
-
-		`CREATE TRIGGER IF NOT EXISTS python_symbols_au AFTER UPDATE ON python_symbols BEGIN
-			DELETE FROM python_symbols_fts WHERE docid = old.id;
-			INSERT INTO python_symbols_fts(docid, name, signature, doc)
-			VALUES (new.id, new.name, new.signature, new.doc);
-		END`,
-
-		// Indexes for Python
-		`CREATE INDEX IF NOT EXISTS idx_python_packages_name ON python_packages(name)`,
-		`CREATE INDEX IF NOT EXISTS idx_python_symbols_package ON python_symbols(package_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_python_symbols_public ON python_symbols(public)`,
-
-		// PHP packages table
-		`CREATE TABLE IF NOT EXISTS php_packages (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT UNIQUE NOT NULL,
-			version TEXT,
-			description TEXT,
-			type TEXT,
-			license TEXT,
-			homepage TEXT,
-			repository_url TEXT,
-			packagist_url TEXT,
-			downloads INTEGER DEFAULT 0,
-			stars INTEGER DEFAULT 0,
-			authors_json TEXT,
-			keywords_json TEXT,
-			require_json TEXT,
-			readme TEXT,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			indexed_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)`,
-
-		// PHP symbols table
-		`CREATE TABLE IF NOT EXISTS php_symbols (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT NOT NULL,
-			kind TEXT NOT NULL,
-			signature TEXT,
-			package_id INTEGER NOT NULL,
-			package_name TEXT NOT NULL,
-			file_path TEXT,
-			line INTEGER DEFAULT 0,
-			public INTEGER DEFAULT 0,
-			doc TEXT,
-			FOREIGN KEY (package_id) REFERENCES php_packages(id) ON DELETE CASCADE
-		)`,
-
-		// PHP packages FTS table
-		`CREATE VIRTUAL TABLE IF NOT EXISTS php_packages_fts USING fts4(
-			name,
-			description,
-			keywords,
-			content=php_packages,
-			tokenize=porter
-		)`,
-
-		// PHP symbols FTS table
-		`CREATE VIRTUAL TABLE IF NOT EXISTS php_symbols_fts USING fts4(
-			name,
-			signature,
-			doc,
-			content=php_symbols,
-			tokenize=porter
-		)`,
-
-		// Triggers for PHP packages FTS
-		`CREATE TRIGGER IF NOT EXISTS php_packages_ai AFTER INSERT ON php_packages BEGIN
-			INSERT INTO php_packages_fts(docid, name, description, keywords)
-			VALUES (new.id, new.name, new.description, new.keywords_json);
-		END`,
-
-		`CREATE TRIGGER IF NOT EXISTS php_packages_ad AFTER DELETE ON php_packages BEGIN
-			DELETE FROM php_packages_fts WHERE docid = old.id;
-		END`,
-
-		`CREATE TRIGGER IF NOT EXISTS php_packages_au AFTER UPDATE ON php_packages BEGIN
-			DELETE FROM php_packages_fts WHERE docid = old.id;
-			INSERT INTO php_packages_fts(docid, name, description, keywords)
-			VALUES (new.id, new.name, new.description, new.keywords_json);
-		END`,
-
-		// Triggers for PHP symbols FTS
-		`CREATE TRIGGER IF NOT EXISTS php_symbols_ai AFTER INSERT ON php_symbols BEGIN
-			INSERT INTO php_symbols_fts(docid, name, signature, doc)
-			VALUES (new.id, new.name, new.signature, new.doc);
-		END`,
-
-		`CREATE TRIGGER IF NOT EXISTS php_symbols_ad AFTER DELETE ON php_symbols BEGIN
-			DELETE FROM php_symbols_fts WHERE docid = old.id;
-		END`,
-
-		`CREATE TRIGGER IF NOT EXISTS php_symbols_au AFTER UPDATE ON php_symbols BEGIN
-			DELETE FROM php_symbols_fts WHERE docid = old.id;
-			INSERT INTO php_symbols_fts(docid, name, signature, doc)
-			VALUES (new.id, new.name, new.signature, new.doc);
-		END`,
-
-		// Indexes for PHP
-		`CREATE INDEX IF NOT EXISTS idx_php_packages_name ON php_packages(name)`,
-		`CREATE INDEX IF NOT EXISTS idx_php_symbols_package ON php_symbols(package_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_php_symbols_public ON php_symbols(public)`,
-
-		// Embeddings table for semantic search
-		`CREATE TABLE IF NOT EXISTS embeddings (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			import_path TEXT NOT NULL,
-			lang TEXT NOT NULL DEFAULT 'go',
-			text_hash TEXT NOT NULL,
-			embedding BLOB NOT NULL,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			UNIQUE(import_path, lang)
-		)`,
-
-		`CREATE INDEX IF NOT EXISTS idx_embeddings_lang ON embeddings(lang)`,
-
-		`CREATE TABLE IF NOT EXISTS generated_examples (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			import_path TEXT NOT NULL,
-			function_name TEXT NOT NULL,
-			signature TEXT NOT NULL,
+	_, err := db.conn.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
 			description TEXT,
-			imports TEXT,
-			code TEXT NOT NULL,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			UNIQUE(import_path, function_name)
-		)`,
+			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("creating schema_migrations: %w", err)
+	}
 
-		`CREATE INDEX IF NOT EXISTS idx_examples_import_path ON generated_examples(import_path)`,
+	current, err := db.SchemaVersion()
+	if err != nil {
+		return err
 	}
 
-	for _, migration := range migrations {
-		if _, err := db.conn.Exec(migration); err != nil {
-			return fmt.Errorf("executing migration: %w", err)
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		err := db.Batch(func(tx *DB) error {
+			if err := m.apply(tx); err != nil {
+				return err
+			}
+			_, err := tx.conn.Exec(`INSERT INTO schema_migrations (version, description) VALUES (?, ?)`, m.version, m.description)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("applying migration %d (%s): %w", m.version, m.description, err)
 		}
 	}
 
 	return nil
 }
 
+// SchemaVersion returns the highest applied migration version
+func (db *DB) SchemaVersion() (int, error) {
+	var version sql.NullInt64
+	if err := db.conn.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("reading schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+// addColumnIfMissing adds a column to an existing table unless it is already present
+func (db *DB) addColumnIfMissing(table, column, definition string) error {
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("reading %s columns: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return fmt.Errorf("scanning %s columns: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	if _, err := db.conn.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("adding column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
 // UpsertPackage inserts or updates a package
 func (db *DB) UpsertPackage(pkg *Package) (int64, error) {
 	versionsJSON, _ := json.Marshal(pkg.Versions)
 	goosJSON, _ := json.Marshal(pkg.GOOS)
 	goarchJSON, _ := json.Marshal(pkg.GOARCH)
+	generateJSON, _ := json.Marshal(pkg.Generate)
 
-	result, err := db.conn.Exec(`
+	_, err := db.conn.Exec(`
 		INSERT INTO packages (
 			import_path, name, synopsis, doc, version, versions_json,
 			is_tagged, is_stable, license, license_text, redistributable,
 			repository, has_valid_mod, go_version, module_path, gomod_content,
-			goos_json, goarch_json, doc_json, updated_at, indexed_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+			goos_json, goarch_json, generate_json, doc_json, classification, coverage, synopsis_source, experimental, deprecated, updated_at, indexed_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 		ON CONFLICT(import_path) DO UPDATE SET
 			name = excluded.name,
 			synopsis = excluded.synopsis,
@@ -746,25 +1398,29 @@ func (db *DB) UpsertPackage(pkg *Package) (int64, error) {
 			gomod_content = excluded.gomod_content,
 			goos_json = excluded.goos_json,
 			goarch_json = excluded.goarch_json,
+			generate_json = excluded.generate_json,
 			doc_json = excluded.doc_json,
+			classification = excluded.classification,
+			coverage = excluded.coverage,
+			synopsis_source = excluded.synopsis_source,
+			experimental = excluded.experimental,
+			deprecated = excluded.deprecated,
 			updated_at = CURRENT_TIMESTAMP,
 			indexed_at = CURRENT_TIMESTAMP
 	`, pkg.ImportPath, pkg.Name, pkg.Synopsis, pkg.Doc, pkg.Version, string(versionsJSON),
 		pkg.IsTagged, pkg.IsStable, pkg.License, pkg.LicenseText, pkg.Redistributable,
 		pkg.Repository, pkg.HasValidMod, pkg.GoVersion, pkg.ModulePath, pkg.GoModContent,
-		string(goosJSON), string(goarchJSON), pkg.DocJSON)
+		string(goosJSON), string(goarchJSON), string(generateJSON), pkg.DocJSON, pkg.Classification, pkg.Coverage, pkg.SynopsisSource, pkg.Experimental, pkg.Deprecated)
 
 	if err != nil {
 		return 0, fmt.Errorf("upserting package: %w", err)
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		// If upsert did an update, get the existing ID
-		row := db.conn.QueryRow("SELECT id FROM packages WHERE import_path = ?", pkg.ImportPath)
-		if err := row.Scan(&id); err != nil {
-			return 0, fmt.Errorf("getting package id: %w", err)
-		}
+	// LastInsertId is stale when the upsert took the UPDATE branch, so look the ID up
+	var id int64
+	row := db.conn.QueryRow("SELECT id FROM packages WHERE import_path = ?", pkg.ImportPath)
+	if err := row.Scan(&id); err != nil {
+		return 0, fmt.Errorf("getting package id: %w", err)
 	}
 
 	return id, nil
@@ -776,21 +1432,21 @@ func (db *DB) GetPackage(importPath string) (*Package, error) {
 		SELECT id, import_path, name, synopsis, doc, version, versions_json,
 			is_tagged, is_stable, license, license_text, redistributable,
 			repository, has_valid_mod, go_version, module_path, gomod_content,
-			goos_json, goarch_json, doc_json, created_at, updated_at, indexed_at
+			goos_json, goarch_json, generate_json, doc_json, classification, coverage, synopsis_source, experimental, deprecated, created_at, updated_at, indexed_at
 		FROM packages WHERE import_path = ?
 	`, importPath)
 
 	pkg := &Package{}
-	var versionsJSON, goosJSON, goarchJSON sql.NullString
-	var docJSON sql.NullString
+	var versionsJSON, goosJSON, goarchJSON, generateJSON sql.NullString
+	var docJSON, classification, coverage, synopsisSource sql.NullString
 
 	err := row.Scan(
 		&pkg.ID, &pkg.ImportPath, &pkg.Name, &pkg.Synopsis, &pkg.Doc,
 		&pkg.Version, &versionsJSON, &pkg.IsTagged, &pkg.IsStable,
 		&pkg.License, &pkg.LicenseText, &pkg.Redistributable,
 		&pkg.Repository, &pkg.HasValidMod, &pkg.GoVersion, &pkg.ModulePath,
-		&pkg.GoModContent, &goosJSON, &goarchJSON, &docJSON,
-		&pkg.CreatedAt, &pkg.UpdatedAt, &pkg.IndexedAt,
+		&pkg.GoModContent, &goosJSON, &goarchJSON, &generateJSON, &docJSON, &classification, &coverage, &synopsisSource,
+		&pkg.Experimental, &pkg.Deprecated, &pkg.CreatedAt, &pkg.UpdatedAt, &pkg.IndexedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -815,20 +1471,42 @@ func (db *DB) GetPackage(importPath string) (*Package, error) {
 			return nil, fmt.Errorf("unmarshaling goarch: %w", err)
 		}
 	}
+	if generateJSON.Valid {
+		if err := json.Unmarshal([]byte(generateJSON.String), &pkg.Generate); err != nil {
+			return nil, fmt.Errorf("unmarshaling generate directives: %w", err)
+		}
+	}
 	if docJSON.Valid {
 		pkg.DocJSON = docJSON.String
 	}
+	pkg.Classification = classification.String
+	pkg.Coverage = coverage.String
+	pkg.SynopsisSource = synopsisSource.String
 
 	return pkg, nil
 }
 
 // ListPackages returns all packages
 func (db *DB) ListPackages() ([]*Package, error) {
-	rows, err := db.conn.Query(`
+	return db.listPackages(`
 		SELECT id, import_path, name, synopsis, version, is_tagged, is_stable,
 			license, redistributable, repository, module_path
 		FROM packages ORDER BY import_path
 	`)
+}
+
+// ListPackagesPage returns limit packages by import path, skipping the first offset
+func (db *DB) ListPackagesPage(limit, offset int) ([]*Package, error) {
+	return db.listPackages(`
+		SELECT id, import_path, name, synopsis, version, is_tagged, is_stable,
+			license, redistributable, repository, module_path
+		FROM packages ORDER BY import_path
+		LIMIT ? OFFSET ?
+	`, limit, offset)
+}
+
+func (db *DB) listPackages(query string, args ...any) ([]*Package, error) {
+	rows, err := db.conn.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("querying packages: %w", err)
 	}
@@ -851,41 +1529,231 @@ func (db *DB) ListPackages() ([]*Package, error) {
 	return packages, rows.Err()
 }
 
-// SearchPackages searches packages using full-text search
-func (db *DB) SearchPackages(query string, limit int) ([]*Package, error) {
+// BrowsePackages lists packages by import path, optionally restricted to paths
+// starting with prefix, and returns the total number of matches. Test-only and
+// example-only packages are left out.
+func (db *DB) BrowsePackages(prefix string, limit, offset int) ([]*Package, int, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	where := "COALESCE(classification, '') = ''"
+	var args []any
+	if prefix != "" {
+		// Unlike LIKE, GLOB is case-sensitive, so SQLite answers the prefix
+		// from the import path index; escape its wildcards in the prefix
+		where += " AND import_path GLOB ?"
+		args = append(args, globEscaper.Replace(prefix)+"*")
+	}
+
+	var total int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM packages WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("counting packages: %w", err)
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT id, import_path, name, synopsis, version, is_tagged, is_stable,
+			license, redistributable, repository, module_path
+		FROM packages
+		WHERE `+where+`
+		ORDER BY import_path
+		LIMIT ? OFFSET ?
+	`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("browsing packages: %w", err)
+	}
+	defer rows.Close()
+
+	var packages []*Package
+	for rows.Next() {
+		pkg := &Package{}
+		err := rows.Scan(
+			&pkg.ID, &pkg.ImportPath, &pkg.Name, &pkg.Synopsis,
+			&pkg.Version, &pkg.IsTagged, &pkg.IsStable,
+			&pkg.License, &pkg.Redistributable, &pkg.Repository, &pkg.ModulePath,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scanning package row: %w", err)
+		}
+		packages = append(packages, pkg)
+	}
+
+	return packages, total, rows.Err()
+}
+
+// GetRecentPackages returns the packages indexed since the given time, most
+// recent first. Test-only and example-only packages are left out.
+func (db *DB) GetRecentPackages(since time.Time, limit int) ([]*Package, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, import_path, name, synopsis, version, is_tagged, is_stable,
+			license, redistributable, repository, module_path, COALESCE(synopsis_source, ''), indexed_at
+		FROM packages
+		WHERE COALESCE(classification, '') = '' AND indexed_at >= ?
+		ORDER BY indexed_at DESC, import_path
+		LIMIT ?
+	`, sqliteTime(since), limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing recent packages: %w", err)
+	}
+	defer rows.Close()
+
+	var packages []*Package
+	for rows.Next() {
+		pkg := &Package{}
+		err := rows.Scan(
+			&pkg.ID, &pkg.ImportPath, &pkg.Name, &pkg.Synopsis,
+			&pkg.Version, &pkg.IsTagged, &pkg.IsStable,
+			&pkg.License, &pkg.Redistributable, &pkg.Repository, &pkg.ModulePath,
+			&pkg.SynopsisSource, &pkg.IndexedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanning package row: %w", err)
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, rows.Err()
+}
+
+// PackagesByModule returns the packages of a module ordered by import path
+func (db *DB) PackagesByModule(modulePath string) ([]*Package, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, import_path, name, synopsis, version, is_tagged, is_stable,
+			license, redistributable, repository, module_path
+		FROM packages WHERE module_path = ? ORDER BY import_path
+	`, modulePath)
+	if err != nil {
+		return nil, fmt.Errorf("querying module packages: %w", err)
+	}
+	defer rows.Close()
+
+	var packages []*Package
+	for rows.Next() {
+		pkg := &Package{}
+		err := rows.Scan(
+			&pkg.ID, &pkg.ImportPath, &pkg.Name, &pkg.Synopsis,
+			&pkg.Version, &pkg.IsTagged, &pkg.IsStable,
+			&pkg.License, &pkg.Redistributable, &pkg.Repository, &pkg.ModulePath,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanning package row: %w", err)
+		}
+		packages = append(packages, pkg)
+	}
+
+	return packages, rows.Err()
+}
+
+// PackageQuality summarizes the symbols of a package for module overviews
+type PackageQuality struct {
+	ImportPath  string `json:"import_path"`
+	Symbols     int    `json:"symbols"`
+	Deprecated  int    `json:"deprecated"`
+	Documented  int    `json:"documentable"` // funcs, types and methods, which examples can document
+	WithExample int    `json:"with_example"` // documentable symbols with at least one example
+}
+
+// ModulePackageQuality returns the symbol counts of each package of a module,
+// keyed by import path. Packages without symbols are left out.
+func (db *DB) ModulePackageQuality(modulePath string) (map[string]*PackageQuality, error) {
+	rows, err := db.conn.Query(`
+		SELECT s.import_path, COUNT(*), COALESCE(SUM(s.deprecated), 0),
+			SUM(s.kind IN ('func', 'type', 'method')),
+			SUM(s.kind IN ('func', 'type', 'method') AND EXISTS (
+				SELECT 1 FROM examples e WHERE e.import_path = s.import_path AND e.symbol = s.name
+			))
+		FROM symbols s
+		JOIN packages p ON p.id = s.package_id
+		WHERE p.module_path = ?
+		GROUP BY s.import_path
+	`, modulePath)
+	if err != nil {
+		return nil, fmt.Errorf("querying module symbols: %w", err)
+	}
+	defer rows.Close()
+
+	quality := make(map[string]*PackageQuality)
+	for rows.Next() {
+		q := &PackageQuality{}
+		if err := rows.Scan(&q.ImportPath, &q.Symbols, &q.Deprecated, &q.Documented, &q.WithExample); err != nil {
+			return nil, fmt.Errorf("scanning module symbols: %w", err)
+		}
+		quality[q.ImportPath] = q
+	}
+	return quality, rows.Err()
+}
+
+// SearchPackages searches packages using full-text search, leaving out
+// experimental packages when excludeExperimental is set
+func (db *DB) SearchPackages(query string, limit int, excludeExperimental bool) ([]*Package, error) {
 	if limit <= 0 {
 		limit = 50
 	}
 
+	var packages []*Package
+	err := db.EachSearchPackage(query, limit, excludeExperimental, func(pkg *Package) error {
+		packages = append(packages, pkg)
+		return nil
+	})
+	return packages, err
+}
+
+// EachSearchPackage calls fn with each package matching query, up to limit, as
+// rows are read, so callers can stream large result sets. Deprecated packages
+// come after the others, and experimental ones are left out when
+// excludeExperimental is set. It stops at the first error fn returns.
+func (db *DB) EachSearchPackage(query string, limit int, excludeExperimental bool, fn func(*Package) error) error {
+	// Ordering by p.deprecated would sort every match before returning the first
+	// row; scanning the matches once per deprecation state keeps them streaming
+	for _, deprecated := range []bool{false, true} {
+		n, err := db.eachSearchPackage(query, deprecated, limit, excludeExperimental, fn)
+		if err != nil {
+			return err
+		}
+		if limit -= n; limit <= 0 {
+			break
+		}
+	}
+	return nil
+}
+
+// eachSearchPackage calls fn with each package matching query whose deprecation
+// is deprecated, up to limit, in index order, and returns how many it called fn with
+func (db *DB) eachSearchPackage(query string, deprecated bool, limit int, excludeExperimental bool, fn func(*Package) error) (int, error) {
 	rows, err := db.conn.Query(`
 		SELECT p.id, p.import_path, p.name, p.synopsis, p.version,
 			p.is_tagged, p.is_stable, p.license, p.redistributable,
-			p.repository, p.module_path
+			p.repository, p.module_path, COALESCE(p.synopsis_source, ''), p.experimental, p.deprecated
 		FROM packages p
 		JOIN packages_fts fts ON p.id = fts.docid
 		WHERE packages_fts MATCH ?
+			AND COALESCE(p.classification, '') = ''
+			AND p.deprecated = ?
+			AND (? = 0 OR p.experimental = 0)
 		LIMIT ?
-	`, query, limit)
+	`, query, deprecated, excludeExperimental, limit)
 	if err != nil {
-		return nil, fmt.Errorf("searching packages: %w", err)
+		return 0, fmt.Errorf("searching packages: %w", err)
 	}
 	defer rows.Close()
 
-	var packages []*Package
+	n := 0
 	for rows.Next() {
 		pkg := &Package{}
 		err := rows.Scan(
 			&pkg.ID, &pkg.ImportPath, &pkg.Name, &pkg.Synopsis,
 			&pkg.Version, &pkg.IsTagged, &pkg.IsStable,
-			&pkg.License, &pkg.Redistributable, &pkg.Repository, &pkg.ModulePath,
+			&pkg.License, &pkg.Redistributable, &pkg.Repository, &pkg.ModulePath, &pkg.SynopsisSource, &pkg.Experimental, &pkg.Deprecated,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("scanning search result: %w", err)
+			return n, fmt.Errorf("scanning search result: %w", err)
 		}
-		packages = append(packages, pkg)
+		if err := fn(pkg); err != nil {
+			return n, err
+		}
+		n++
 	}
 
-	return packages, rows.Err()
+	return n, rows.Err()
 }
 
 // AddImport records an import relationship
@@ -897,6 +1765,42 @@ func (db *DB) AddImport(importerPath, importedPath, importerModule string) error
 	return err
 }
 
+// ReplaceImports replaces the recorded imports of a package
+func (db *DB) ReplaceImports(importerPath, importerModule string, imported []string) error {
+	return db.Batch(func(tx *DB) error {
+		if _, err := tx.conn.Exec(`DELETE FROM imports WHERE importer_path = ?`, importerPath); err != nil {
+			return fmt.Errorf("deleting imports: %w", err)
+		}
+		for _, imp := range imported {
+			if err := tx.AddImport(importerPath, imp, importerModule); err != nil {
+				return fmt.Errorf("inserting import: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetImports returns the import paths the given package imports, sorted
+func (db *DB) GetImports(importerPath string) ([]string, error) {
+	rows, err := db.conn.Query(`
+		SELECT DISTINCT imported_path FROM imports WHERE importer_path = ? ORDER BY imported_path
+	`, importerPath)
+	if err != nil {
+		return nil, fmt.Errorf("querying imports: %w", err)
+	}
+	defer rows.Close()
+
+	var imports []string
+	for rows.Next() {
+		var imp string
+		if err := rows.Scan(&imp); err != nil {
+			return nil, fmt.Errorf("scanning import: %w", err)
+		}
+		imports = append(imports, imp)
+	}
+	return imports, rows.Err()
+}
+
 // GetImportedBy returns packages that import the given package
 func (db *DB) GetImportedBy(importPath string, limit, offset int) ([]*Package, int, error) {
 	if limit <= 0 {
@@ -954,31 +1858,212 @@ func (db *DB) GetImportedByCount(importPath string) (int, error) {
 	return count, err
 }
 
+// populateImportedByCounts fills imported_by_counts from the imports table
+const populateImportedByCounts = `
+	INSERT INTO imported_by_counts (imported_path, count)
+	SELECT imported_path, COUNT(DISTINCT importer_path) FROM imports GROUP BY imported_path
+`
+
+// CachedImportedByCount returns the number of packages importing the given
+// package as of the last RefreshImportedByCounts, 0 when it had none then
+func (db *DB) CachedImportedByCount(importPath string) (int, error) {
+	var count int
+	err := db.conn.QueryRow(`
+		SELECT count FROM imported_by_counts WHERE imported_path = ?
+	`, importPath).Scan(&count)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return count, err
+}
+
+// RefreshImportedByCounts recomputes the imported-by count of every package
+// into imported_by_counts, which package pages read instead of aggregating
+// imports on each request. It returns the number of packages with importers.
+func (db *DB) RefreshImportedByCounts() (int, error) {
+	var refreshed int
+	err := db.Batch(func(tx *DB) error {
+		if _, err := tx.conn.Exec(`DELETE FROM imported_by_counts`); err != nil {
+			return fmt.Errorf("clearing imported-by counts: %w", err)
+		}
+		res, err := tx.conn.Exec(populateImportedByCounts)
+		if err != nil {
+			return fmt.Errorf("counting importers: %w", err)
+		}
+		n, err := res.RowsAffected()
+		refreshed = int(n)
+		return err
+	})
+	return refreshed, err
+}
+
 // UpsertSymbol inserts or updates a symbol
 func (db *DB) UpsertSymbol(symbol *Symbol) error {
 	_, err := db.conn.Exec(`
-		INSERT INTO symbols (name, kind, package_id, import_path, synopsis, doc, signature, decl, deprecated)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO symbols (name, kind, package_id, import_path, synopsis, doc, signature, decl, deprecated, filename, line, end_line, shape,
+			is_interface, method_set_json, params_json)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT DO UPDATE SET
 			synopsis = excluded.synopsis,
 			doc = excluded.doc,
 			signature = excluded.signature,
 			decl = excluded.decl,
-			deprecated = excluded.deprecated
-	`, symbol.Name, symbol.Kind, symbol.PackageID, symbol.ImportPath, symbol.Synopsis, symbol.Doc, symbol.Signature, symbol.Decl, symbol.Deprecated)
+			deprecated = excluded.deprecated,
+			filename = excluded.filename,
+			line = excluded.line,
+			end_line = excluded.end_line,
+			shape = excluded.shape,
+			is_interface = excluded.is_interface,
+			method_set_json = excluded.method_set_json,
+			params_json = excluded.params_json
+	`, symbol.Name, symbol.Kind, symbol.PackageID, symbol.ImportPath, symbol.Synopsis, symbol.Doc, symbol.Signature, symbol.Decl, symbol.Deprecated,
+		symbol.Filename, symbol.Line, symbol.EndLine, symbolShape(symbol), symbol.Interface, listJSON(symbol.MethodSet), listJSON(symbol.Params))
 	return err
 }
 
+// listJSON encodes a symbol's method set or params, or nil when it has none
+func listJSON[T any](list []T) *string {
+	if len(list) == 0 {
+		return nil
+	}
+	data, _ := json.Marshal(list)
+	s := string(data)
+	return &s
+}
+
+// symbolShape returns the normalized shape of a function or method signature,
+// like "func([]byte) (int, error)", or nil for other symbols
+func symbolShape(symbol *Symbol) *string {
+	if symbol.Kind != "func" && symbol.Kind != "method" {
+		return nil
+	}
+	shape, ok := util.SignatureShape(symbol.Signature)
+	if !ok {
+		return nil
+	}
+	s := shape.String()
+	return &s
+}
+
+// globEscaper quotes the characters that are special in SQLite GLOB patterns
+var globEscaper = strings.NewReplacer("[", "[[]", "*", "[*]", "?", "[?]")
+
+// SearchSymbolsByShape finds functions and methods whose signature has the
+// given shape, such as "func([]byte) (int, error)". Single capital letters in
+// the pattern match any type and a final "..." any further parameters or
+// results (see util.ParseShapePattern).
+func (db *DB) SearchSymbolsByShape(pattern, kind string, limit int) ([]*Symbol, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	shape, err := util.ParseShapePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	// Narrow the candidates with the indexed shape prefix, then match exactly
+	query := `
+		SELECT id, name, kind, package_id, import_path, synopsis, signature, deprecated, shape
+		FROM symbols
+		WHERE shape GLOB ?`
+	args := []any{globEscaper.Replace(shape.LiteralPrefix()) + "*"}
+	if kind != "" {
+		query += ` AND kind = ?`
+		args = append(args, kind)
+	}
+	rows, err := db.conn.Query(query+` ORDER BY name, import_path`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("searching symbols by shape: %w", err)
+	}
+	defer rows.Close()
+
+	var symbols []*Symbol
+	for rows.Next() && len(symbols) < limit {
+		sym := &Symbol{}
+		var stored string
+		if err := rows.Scan(&sym.ID, &sym.Name, &sym.Kind, &sym.PackageID,
+			&sym.ImportPath, &sym.Synopsis, &sym.Signature, &sym.Deprecated, &stored); err != nil {
+			return nil, fmt.Errorf("scanning symbol: %w", err)
+		}
+		if candidate, err := util.ParseShapePattern(stored); err == nil && shape.Match(candidate) {
+			symbols = append(symbols, sym)
+		}
+	}
+	return symbols, rows.Err()
+}
+
 // DeletePackageSymbols deletes all symbols for a package
 func (db *DB) DeletePackageSymbols(packageID int64) error {
 	_, err := db.conn.Exec("DELETE FROM symbols WHERE package_id = ?", packageID)
 	return err
 }
 
+// ReplacePackageSymbols replaces the stored symbols of a reindexed package. AI
+// docs and feedback outlive the symbols they describe, so those left without
+// one, e.g. after a rename, are then flagged by MarkOrphans. It returns how many
+// are orphaned.
+func (db *DB) ReplacePackageSymbols(packageID int64, importPath, packageName string, symbols []*Symbol) (int, error) {
+	var orphaned int
+	err := db.Batch(func(tx *DB) error {
+		if err := tx.DeletePackageSymbols(packageID); err != nil {
+			return fmt.Errorf("deleting symbols: %w", err)
+		}
+		for _, sym := range symbols {
+			if err := tx.UpsertSymbol(sym); err != nil {
+				return fmt.Errorf("inserting symbol %s: %w", sym.Name, err)
+			}
+		}
+		var err error
+		orphaned, err = tx.MarkOrphans(importPath, packageName)
+		return err
+	})
+	return orphaned, err
+}
+
+// PackagesRequiringGoVersion returns the packages whose go directive is minVersion
+// or newer (e.g. "1.23"), newest requirement first
+func (db *DB) PackagesRequiringGoVersion(minVersion string) ([]*Package, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, import_path, name, synopsis, version, module_path, go_version
+		FROM packages
+		WHERE COALESCE(go_version, '') != ''
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying go versions: %w", err)
+	}
+	defer rows.Close()
+
+	// go_version is text, so "1.9" sorts after "1.22"; compare in Go instead
+	minGo := "go" + minVersion
+	var packages []*Package
+	for rows.Next() {
+		pkg := &Package{}
+		if err := rows.Scan(&pkg.ID, &pkg.ImportPath, &pkg.Name, &pkg.Synopsis,
+			&pkg.Version, &pkg.ModulePath, &pkg.GoVersion); err != nil {
+			return nil, fmt.Errorf("scanning package: %w", err)
+		}
+		if version.IsValid("go"+pkg.GoVersion) && version.Compare("go"+pkg.GoVersion, minGo) >= 0 {
+			packages = append(packages, pkg)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(packages, func(i, j int) bool {
+		if c := version.Compare("go"+packages[i].GoVersion, "go"+packages[j].GoVersion); c != 0 {
+			return c > 0
+		}
+		return packages[i].ImportPath < packages[j].ImportPath
+	})
+	return packages, nil
+}
+
 // GetPackageSymbols returns all symbols for a package
 func (db *DB) GetPackageSymbols(packageID int64) ([]*Symbol, error) {
 	rows, err := db.conn.Query(`
-		SELECT id, name, kind, package_id, import_path, synopsis, doc, signature, decl, deprecated
+		SELECT id, name, kind, package_id, import_path, synopsis, doc, signature, decl, deprecated, filename, line, end_line,
+			is_interface, method_set_json, params_json
 		FROM symbols WHERE package_id = ?
 		ORDER BY kind, name
 	`, packageID)
@@ -990,13 +2075,141 @@ func (db *DB) GetPackageSymbols(packageID int64) ([]*Symbol, error) {
 	var symbols []*Symbol
 	for rows.Next() {
 		sym := &Symbol{}
-		var doc, signature, decl sql.NullString
-		if err := rows.Scan(&sym.ID, &sym.Name, &sym.Kind, &sym.PackageID, &sym.ImportPath, &sym.Synopsis, &doc, &signature, &decl, &sym.Deprecated); err != nil {
+		var doc, signature, decl, filename, methodSet, params sql.NullString
+		if err := rows.Scan(&sym.ID, &sym.Name, &sym.Kind, &sym.PackageID, &sym.ImportPath, &sym.Synopsis, &doc, &signature, &decl, &sym.Deprecated, &filename, &sym.Line, &sym.EndLine,
+			&sym.Interface, &methodSet, &params); err != nil {
 			return nil, err
 		}
 		sym.Doc = doc.String
 		sym.Signature = signature.String
 		sym.Decl = decl.String
+		sym.Filename = filename.String
+		if methodSet.Valid {
+			if err := json.Unmarshal([]byte(methodSet.String), &sym.MethodSet); err != nil {
+				return nil, fmt.Errorf("unmarshaling method set of %s: %w", sym.Name, err)
+			}
+		}
+		if params.Valid {
+			if err := json.Unmarshal([]byte(params.String), &sym.Params); err != nil {
+				return nil, fmt.Errorf("unmarshaling params of %s: %w", sym.Name, err)
+			}
+		}
+		symbols = append(symbols, sym)
+	}
+	return symbols, rows.Err()
+}
+
+// TypeMethodSet is a type with the method set recorded when its package was indexed
+type TypeMethodSet struct {
+	ImportPath string
+	Package    string // package name
+	Name       string
+	Interface  bool
+	MethodSet  []string
+}
+
+// GetModuleMethodSets returns the types with a method set in the packages of
+// a module, ordered by import path and name, to match types against interfaces
+func (db *DB) GetModuleMethodSets(modulePath string) ([]*TypeMethodSet, error) {
+	rows, err := db.conn.Query(`
+		SELECT s.import_path, p.name, s.name, s.is_interface, s.method_set_json
+		FROM symbols s
+		JOIN packages p ON p.id = s.package_id
+		WHERE p.module_path = ? AND s.kind = 'type' AND s.method_set_json IS NOT NULL
+		ORDER BY s.import_path, s.name
+	`, modulePath)
+	if err != nil {
+		return nil, fmt.Errorf("getting method sets of %s: %w", modulePath, err)
+	}
+	defer rows.Close()
+
+	var types []*TypeMethodSet
+	for rows.Next() {
+		t := &TypeMethodSet{}
+		var methodSet string
+		if err := rows.Scan(&t.ImportPath, &t.Package, &t.Name, &t.Interface, &methodSet); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(methodSet), &t.MethodSet); err != nil {
+			return nil, fmt.Errorf("unmarshaling method set of %s.%s: %w", t.ImportPath, t.Name, err)
+		}
+		types = append(types, t)
+	}
+	return types, rows.Err()
+}
+
+// BrowseSymbols lists symbols alphabetically by name, optionally restricted to a
+// kind and to names starting with letter, and returns the total number of matches
+func (db *DB) BrowseSymbols(kind, letter string, limit, offset int) ([]*Symbol, int, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	where := "1 = 1"
+	var args []any
+	if kind != "" {
+		where += " AND kind = ?"
+		args = append(args, kind)
+	}
+	if letter != "" {
+		// GLOB is case-sensitive, so SQLite can answer the prefix from the name index
+		where += " AND name GLOB ?"
+		args = append(args, letter+"*")
+	}
+
+	var total int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM symbols WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("counting symbols: %w", err)
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT id, name, kind, package_id, import_path, COALESCE(synopsis, ''), deprecated
+		FROM symbols
+		WHERE `+where+`
+		ORDER BY name, import_path
+		LIMIT ? OFFSET ?
+	`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("browsing symbols: %w", err)
+	}
+	defer rows.Close()
+
+	var symbols []*Symbol
+	for rows.Next() {
+		sym := &Symbol{}
+		if err := rows.Scan(&sym.ID, &sym.Name, &sym.Kind, &sym.PackageID,
+			&sym.ImportPath, &sym.Synopsis, &sym.Deprecated); err != nil {
+			return nil, 0, fmt.Errorf("scanning symbol: %w", err)
+		}
+		symbols = append(symbols, sym)
+	}
+
+	return symbols, total, rows.Err()
+}
+
+// GetSymbolsByImportPath returns all symbols of a package by its import path
+func (db *DB) GetSymbolsByImportPath(importPath string) ([]*Symbol, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, name, kind, package_id, import_path, synopsis, doc, signature, decl, deprecated, filename, line, end_line
+		FROM symbols WHERE import_path = ?
+		ORDER BY kind, name
+	`, importPath)
+	if err != nil {
+		return nil, fmt.Errorf("querying symbols: %w", err)
+	}
+	defer rows.Close()
+
+	var symbols []*Symbol
+	for rows.Next() {
+		sym := &Symbol{}
+		var doc, signature, decl, filename sql.NullString
+		if err := rows.Scan(&sym.ID, &sym.Name, &sym.Kind, &sym.PackageID, &sym.ImportPath, &sym.Synopsis, &doc, &signature, &decl, &sym.Deprecated, &filename, &sym.Line, &sym.EndLine); err != nil {
+			return nil, fmt.Errorf("scanning symbol: %w", err)
+		}
+		sym.Doc = doc.String
+		sym.Signature = signature.String
+		sym.Decl = decl.String
+		sym.Filename = filename.String
 		symbols = append(symbols, sym)
 	}
 	return symbols, rows.Err()
@@ -1048,6 +2261,121 @@ func (db *DB) SearchSymbols(query, kind string, limit int) ([]*Symbol, error) {
 	return symbols, rows.Err()
 }
 
+// ReplacePackageExamples replaces the stored examples of a package
+func (db *DB) ReplacePackageExamples(packageID int64, importPath string, examples []*Example) error {
+	return db.Batch(func(tx *DB) error {
+		if _, err := tx.conn.Exec(`DELETE FROM examples WHERE import_path = ?`, importPath); err != nil {
+			return fmt.Errorf("deleting examples: %w", err)
+		}
+		for _, ex := range examples {
+			_, err := tx.conn.Exec(`
+				INSERT INTO examples (package_id, import_path, symbol, name, doc, code, output, unordered, empty_output, verified, words)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT(import_path, name) DO NOTHING
+			`, packageID, importPath, ex.Symbol, ex.Name, ex.Doc, ex.Code, ex.Output, ex.Unordered, ex.EmptyOutput, ex.Verified, identifierWords(ex.Symbol+" "+ex.Code))
+			if err != nil {
+				return fmt.Errorf("inserting example: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetPackageExamples returns the examples of a package ordered by symbol and name
+func (db *DB) GetPackageExamples(importPath string) ([]*Example, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, package_id, import_path, symbol, name, doc, code, output, unordered, empty_output, COALESCE(verified, 0)
+		FROM examples WHERE import_path = ?
+		ORDER BY symbol, name
+	`, importPath)
+	if err != nil {
+		return nil, fmt.Errorf("querying examples: %w", err)
+	}
+	defer rows.Close()
+	return scanExamples(rows)
+}
+
+// SearchExamples searches example code and documentation using full-text search.
+// Query words match loosely, so "http server" finds examples calling ListenAndServe.
+func (db *DB) SearchExamples(query string, limit int) ([]*Example, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	query = exampleMatchQuery(query)
+	if query == "" {
+		return nil, nil
+	}
+	rows, err := db.conn.Query(`
+		SELECT e.id, e.package_id, e.import_path, e.symbol, e.name, e.doc, e.code, e.output, e.unordered, e.empty_output, COALESCE(e.verified, 0)
+		FROM examples e
+		JOIN examples_fts fts ON e.id = fts.docid
+		WHERE examples_fts MATCH ?
+		ORDER BY e.import_path, e.symbol, e.name
+		LIMIT ?
+	`, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("searching examples: %w", err)
+	}
+	defer rows.Close()
+	return scanExamples(rows)
+}
+
+var (
+	identifierRe = regexp.MustCompile(`[A-Za-z][A-Za-z0-9]*`)
+	camelWordRe  = regexp.MustCompile(`[A-Z]+[a-z0-9]*|[a-z0-9]+`)
+)
+
+// identifierWords returns the identifiers in code followed by their camel-case
+// parts, so "ListenAndServe" is also indexed as "Listen And Serve"
+func identifierWords(code string) string {
+	seen := make(map[string]bool)
+	var words []string
+	for _, ident := range identifierRe.FindAllString(code, -1) {
+		if seen[ident] {
+			continue
+		}
+		seen[ident] = true
+		words = append(words, ident)
+		if parts := camelWordRe.FindAllString(ident, -1); len(parts) > 1 {
+			words = append(words, parts...)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// exampleMatchQuery turns free text into an FTS query of prefix terms, trimming
+// common suffixes so "server" and "serving" also match "serve"
+func exampleMatchQuery(query string) string {
+	var terms []string
+	for _, word := range identifierRe.FindAllString(query, -1) {
+		word = strings.ToLower(word)
+		for _, suffix := range []string{"ers", "er", "ing", "es", "s"} {
+			if len(word)-len(suffix) >= 4 && strings.HasSuffix(word, suffix) {
+				word = strings.TrimSuffix(word, suffix)
+				break
+			}
+		}
+		terms = append(terms, word+"*")
+	}
+	return strings.Join(terms, " ")
+}
+
+func scanExamples(rows *sql.Rows) ([]*Example, error) {
+	var examples []*Example
+	for rows.Next() {
+		ex := &Example{}
+		var doc, code, output sql.NullString
+		if err := rows.Scan(&ex.ID, &ex.PackageID, &ex.ImportPath, &ex.Symbol, &ex.Name, &doc, &code, &output, &ex.Unordered, &ex.EmptyOutput, &ex.Verified); err != nil {
+			return nil, fmt.Errorf("scanning example: %w", err)
+		}
+		ex.Doc = doc.String
+		ex.Code = code.String
+		ex.Output = output.String
+		examples = append(examples, ex)
+	}
+	return examples, rows.Err()
+}
+
 // GetStats returns database statistics
 func (db *DB) GetStats() (packageCount, symbolCount, importCount int, err error) {
 	err = db.conn.QueryRow("SELECT COUNT(*) FROM packages").Scan(&packageCount)
@@ -1064,38 +2392,36 @@ func (db *DB) GetStats() (packageCount, symbolCount, importCount int, err error)
 
 // DeletePackage deletes a package and its related data
 func (db *DB) DeletePackage(importPath string) error {
-	tx, err := db.conn.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
+	return db.Batch(func(tx *DB) error {
+		// Get package ID first
+		var packageID int64
+		err := tx.conn.QueryRow("SELECT id FROM packages WHERE import_path = ?", importPath).Scan(&packageID)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 
-	// Get package ID first
-	var packageID int64
-	err = tx.QueryRow("SELECT id FROM packages WHERE import_path = ?", importPath).Scan(&packageID)
-	if err == sql.ErrNoRows {
-		return nil
-	}
-	if err != nil {
-		return err
-	}
+		// Delete symbols
+		if _, err := tx.conn.Exec("DELETE FROM symbols WHERE package_id = ?", packageID); err != nil {
+			return err
+		}
 
-	// Delete symbols
-	if _, err := tx.Exec("DELETE FROM symbols WHERE package_id = ?", packageID); err != nil {
-		return err
-	}
+		// Delete imports
+		if _, err := tx.conn.Exec("DELETE FROM imports WHERE importer_path = ?", importPath); err != nil {
+			return err
+		}
 
-	// Delete imports
-	if _, err := tx.Exec("DELETE FROM imports WHERE importer_path = ?", importPath); err != nil {
-		return err
-	}
+		// Delete examples
+		if _, err := tx.conn.Exec("DELETE FROM examples WHERE package_id = ?", packageID); err != nil {
+			return err
+		}
 
-	// Delete package
-	if _, err := tx.Exec("DELETE FROM packages WHERE id = ?", packageID); err != nil {
+		// Delete package
+		_, err = tx.conn.Exec("DELETE FROM packages WHERE id = ?", packageID)
 		return err
-	}
-
-	return tx.Commit()
+	})
 }
 
 // GetLastCrawlTime returns the last successful crawl time
@@ -1170,10 +2496,20 @@ func (db *DB) UpsertModuleVersion(mv *ModuleVersion) error {
 	return err
 }
 
+// SetModuleChecksum records the checksums of a crawled module version
+func (db *DB) SetModuleChecksum(mv *ModuleVersion) error {
+	_, err := db.conn.Exec(`
+		UPDATE module_versions SET checksum = ?, proxy_checksum = ?, gosum_modules = ?
+		WHERE module_path = ? AND version = ?
+	`, mv.Checksum, mv.ProxyChecksum, mv.GoSumModules, mv.ModulePath, mv.Version)
+	return err
+}
+
 // GetModuleVersions returns all versions for a module, sorted by semver (newest first)
 func (db *DB) GetModuleVersions(modulePath string) ([]*ModuleVersion, error) {
 	rows, err := db.conn.Query(`
-		SELECT id, module_path, version, timestamp, is_tagged, is_stable, retracted, created_at
+		SELECT id, module_path, version, timestamp, is_tagged, is_stable, retracted, created_at,
+			checksum, proxy_checksum, gosum_modules
 		FROM module_versions
 		WHERE module_path = ?
 		ORDER BY
@@ -1191,7 +2527,8 @@ func (db *DB) GetModuleVersions(modulePath string) ([]*ModuleVersion, error) {
 		mv := &ModuleVersion{}
 		var timestamp sql.NullTime
 		err := rows.Scan(&mv.ID, &mv.ModulePath, &mv.Version, &timestamp,
-			&mv.IsTagged, &mv.IsStable, &mv.Retracted, &mv.CreatedAt)
+			&mv.IsTagged, &mv.IsStable, &mv.Retracted, &mv.CreatedAt,
+			&mv.Checksum, &mv.ProxyChecksum, &mv.GoSumModules)
 		if err != nil {
 			return nil, fmt.Errorf("scanning version: %w", err)
 		}
@@ -1207,7 +2544,8 @@ func (db *DB) GetModuleVersions(modulePath string) ([]*ModuleVersion, error) {
 // GetModuleVersion returns a specific version of a module
 func (db *DB) GetModuleVersion(modulePath, version string) (*ModuleVersion, error) {
 	row := db.conn.QueryRow(`
-		SELECT id, module_path, version, timestamp, is_tagged, is_stable, retracted, created_at
+		SELECT id, module_path, version, timestamp, is_tagged, is_stable, retracted, created_at,
+			checksum, proxy_checksum, gosum_modules
 		FROM module_versions
 		WHERE module_path = ? AND version = ?
 	`, modulePath, version)
@@ -1215,7 +2553,8 @@ func (db *DB) GetModuleVersion(modulePath, version string) (*ModuleVersion, erro
 	mv := &ModuleVersion{}
 	var timestamp sql.NullTime
 	err := row.Scan(&mv.ID, &mv.ModulePath, &mv.Version, &timestamp,
-		&mv.IsTagged, &mv.IsStable, &mv.Retracted, &mv.CreatedAt)
+		&mv.IsTagged, &mv.IsStable, &mv.Retracted, &mv.CreatedAt,
+		&mv.Checksum, &mv.ProxyChecksum, &mv.GoSumModules)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -1226,13 +2565,100 @@ func (db *DB) GetModuleVersion(modulePath, version string) (*ModuleVersion, erro
 		mv.Timestamp = timestamp.Time
 	}
 
-	return mv, nil
+	return mv, nil
+}
+
+// Registry ecosystems with stored version lists
+const (
+	EcosystemNPM    = "npm"
+	EcosystemPyPI   = "pypi"
+	EcosystemCrates = "crates"
+)
+
+// registryVersionTables maps each registry ecosystem to its version table
+var registryVersionTables = map[string]string{
+	EcosystemNPM:    "js_versions",
+	EcosystemPyPI:   "python_versions",
+	EcosystemCrates: "rust_versions",
+}
+
+// RegistryVersion is a published version of an npm, PyPI or crates.io package
+type RegistryVersion struct {
+	Version     string    `json:"version"`
+	PublishedAt time.Time `json:"published_at"` // Zero when the registry has no date
+	Yanked      bool      `json:"yanked"`       // Withdrawn by the publisher
+}
+
+// ReplaceRegistryVersions stores the full version list of a registry package
+func (db *DB) ReplaceRegistryVersions(ecosystem, packageName string, versions []*RegistryVersion) error {
+	table, ok := registryVersionTables[ecosystem]
+	if !ok {
+		return fmt.Errorf("unknown ecosystem: %s", ecosystem)
+	}
+
+	return db.Batch(func(tx *DB) error {
+		if _, err := tx.conn.Exec(`DELETE FROM `+table+` WHERE package_name = ?`, packageName); err != nil {
+			return fmt.Errorf("deleting versions: %w", err)
+		}
+		for _, v := range versions {
+			var publishedAt sql.NullTime
+			if !v.PublishedAt.IsZero() {
+				publishedAt = sql.NullTime{Time: v.PublishedAt, Valid: true}
+			}
+			_, err := tx.conn.Exec(`
+				INSERT INTO `+table+` (package_name, version, published_at, yanked)
+				VALUES (?, ?, ?, ?)
+				ON CONFLICT(package_name, version) DO UPDATE SET
+					published_at = excluded.published_at,
+					yanked = excluded.yanked
+			`, packageName, v.Version, publishedAt, v.Yanked)
+			if err != nil {
+				return fmt.Errorf("inserting version: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetRegistryVersions returns the versions of a registry package, newest first
+func (db *DB) GetRegistryVersions(ecosystem, packageName string) ([]*RegistryVersion, error) {
+	table, ok := registryVersionTables[ecosystem]
+	if !ok {
+		return nil, fmt.Errorf("unknown ecosystem: %s", ecosystem)
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT version, published_at, COALESCE(yanked, 0)
+		FROM `+table+`
+		WHERE package_name = ?
+		ORDER BY published_at IS NULL, published_at DESC, id DESC
+	`, packageName)
+	if err != nil {
+		return nil, fmt.Errorf("querying versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []*RegistryVersion
+	for rows.Next() {
+		v := &RegistryVersion{}
+		var publishedAt sql.NullTime
+		if err := rows.Scan(&v.Version, &publishedAt, &v.Yanked); err != nil {
+			return nil, fmt.Errorf("scanning version: %w", err)
+		}
+		if publishedAt.Valid {
+			v.PublishedAt = publishedAt.Time
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, rows.Err()
 }
 
 // GetLatestModuleVersion returns the latest version for a module
 func (db *DB) GetLatestModuleVersion(modulePath string) (*ModuleVersion, error) {
 	row := db.conn.QueryRow(`
-		SELECT id, module_path, version, timestamp, is_tagged, is_stable, retracted, created_at
+		SELECT id, module_path, version, timestamp, is_tagged, is_stable, retracted, created_at,
+			checksum, proxy_checksum, gosum_modules
 		FROM module_versions
 		WHERE module_path = ? AND retracted = 0
 		ORDER BY
@@ -1244,7 +2670,8 @@ func (db *DB) GetLatestModuleVersion(modulePath string) (*ModuleVersion, error)
 	mv := &ModuleVersion{}
 	var timestamp sql.NullTime
 	err := row.Scan(&mv.ID, &mv.ModulePath, &mv.Version, &timestamp,
-		&mv.IsTagged, &mv.IsStable, &mv.Retracted, &mv.CreatedAt)
+		&mv.IsTagged, &mv.IsStable, &mv.Retracted, &mv.CreatedAt,
+		&mv.Checksum, &mv.ProxyChecksum, &mv.GoSumModules)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -1267,13 +2694,15 @@ func (db *DB) CountModuleVersions(modulePath string) (int, error) {
 	return count, err
 }
 
-// UpsertAIDoc inserts or updates an AI-generated doc
+// UpsertAIDoc inserts or updates an AI-generated doc, adding the cost of regenerating it to its total
 func (db *DB) UpsertAIDoc(doc *AIDoc) error {
 	_, err := db.conn.Exec(`
 		INSERT INTO ai_docs (symbol_name, symbol_kind, import_path, generated_doc, approved, flagged, flag_reason, cost_usd, tokens)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(import_path, symbol_name, symbol_kind) DO UPDATE SET
 			generated_doc = excluded.generated_doc,
+			cost_usd = cost_usd + excluded.cost_usd,
+			tokens = tokens + excluded.tokens,
 			updated_at = CURRENT_TIMESTAMP
 	`, doc.SymbolName, doc.SymbolKind, doc.ImportPath, doc.GeneratedDoc, doc.Approved, doc.Flagged, doc.FlagReason, doc.CostUSD, doc.Tokens)
 	return err
@@ -1282,7 +2711,7 @@ func (db *DB) UpsertAIDoc(doc *AIDoc) error {
 // GetAIDoc retrieves an AI-generated doc for a symbol
 func (db *DB) GetAIDoc(importPath, symbolName, symbolKind string) (*AIDoc, error) {
 	row := db.conn.QueryRow(`
-		SELECT id, symbol_name, symbol_kind, import_path, generated_doc, approved, flagged, flag_reason, cost_usd, tokens, created_at, updated_at
+		SELECT id, symbol_name, symbol_kind, import_path, generated_doc, approved, flagged, flag_reason, orphaned, cost_usd, tokens, created_at, updated_at
 		FROM ai_docs
 		WHERE import_path = ? AND symbol_name = ? AND symbol_kind = ?
 	`, importPath, symbolName, symbolKind)
@@ -1290,7 +2719,7 @@ func (db *DB) GetAIDoc(importPath, symbolName, symbolKind string) (*AIDoc, error
 	doc := &AIDoc{}
 	var flagReason sql.NullString
 	err := row.Scan(&doc.ID, &doc.SymbolName, &doc.SymbolKind, &doc.ImportPath, &doc.GeneratedDoc,
-		&doc.Approved, &doc.Flagged, &flagReason, &doc.CostUSD, &doc.Tokens, &doc.CreatedAt, &doc.UpdatedAt)
+		&doc.Approved, &doc.Flagged, &flagReason, &doc.Orphaned, &doc.CostUSD, &doc.Tokens, &doc.CreatedAt, &doc.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -1309,7 +2738,7 @@ func (db *DB) GetAIDoc(importPath, symbolName, symbolKind string) (*AIDoc, error
 // GetAIDocsForPackage retrieves all AI-generated docs for a package
 func (db *DB) GetAIDocsForPackage(importPath string) ([]*AIDoc, error) {
 	rows, err := db.conn.Query(`
-		SELECT id, symbol_name, symbol_kind, import_path, generated_doc, approved, flagged, flag_reason, cost_usd, tokens, created_at, updated_at
+		SELECT id, symbol_name, symbol_kind, import_path, generated_doc, approved, flagged, flag_reason, orphaned, cost_usd, tokens, created_at, updated_at
 		FROM ai_docs
 		WHERE import_path = ?
 		ORDER BY symbol_kind, symbol_name
@@ -1324,7 +2753,7 @@ func (db *DB) GetAIDocsForPackage(importPath string) ([]*AIDoc, error) {
 		doc := &AIDoc{}
 		var flagReason sql.NullString
 		err := rows.Scan(&doc.ID, &doc.SymbolName, &doc.SymbolKind, &doc.ImportPath, &doc.GeneratedDoc,
-			&doc.Approved, &doc.Flagged, &flagReason, &doc.CostUSD, &doc.Tokens, &doc.CreatedAt, &doc.UpdatedAt)
+			&doc.Approved, &doc.Flagged, &flagReason, &doc.Orphaned, &doc.CostUSD, &doc.Tokens, &doc.CreatedAt, &doc.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("scanning ai doc: %w", err)
 		}
@@ -1349,6 +2778,197 @@ func (db *DB) FlagAIDoc(id int64, reason string) error {
 	return err
 }
 
+// UnflagAIDoc clears the flag on an AI-generated doc after review
+func (db *DB) UnflagAIDoc(id int64) error {
+	_, err := db.conn.Exec(`UPDATE ai_docs SET flagged = 0, flag_reason = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, id)
+	return err
+}
+
+// GetFlaggedAIDocs returns AI-generated docs flagged for review, most recently updated first
+func (db *DB) GetFlaggedAIDocs(limit int) ([]*AIDoc, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := db.conn.Query(`
+		SELECT id, symbol_name, symbol_kind, import_path, generated_doc, approved, flagged, flag_reason, orphaned, cost_usd, tokens, created_at, updated_at
+		FROM ai_docs
+		WHERE flagged = 1
+		ORDER BY updated_at DESC, id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying flagged ai docs: %w", err)
+	}
+	defer rows.Close()
+
+	var docs []*AIDoc
+	for rows.Next() {
+		doc := &AIDoc{}
+		var flagReason sql.NullString
+		err := rows.Scan(&doc.ID, &doc.SymbolName, &doc.SymbolKind, &doc.ImportPath, &doc.GeneratedDoc,
+			&doc.Approved, &doc.Flagged, &flagReason, &doc.Orphaned, &doc.CostUSD, &doc.Tokens, &doc.CreatedAt, &doc.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scanning ai doc: %w", err)
+		}
+		doc.FlagReason = flagReason.String
+		docs = append(docs, doc)
+	}
+	return docs, rows.Err()
+}
+
+// AddFeedback stores a documentation report. Reports about an AI-generated doc
+// also flag that doc with the message as reason, putting it in the review queue.
+func (db *DB) AddFeedback(fb *Feedback) (int64, error) {
+	var id int64
+	err := db.Batch(func(tx *DB) error {
+		result, err := tx.conn.Exec(`
+			INSERT INTO feedback (import_path, symbol, symbol_kind, ai_doc, message)
+			VALUES (?, ?, ?, ?, ?)
+		`, fb.ImportPath, fb.Symbol, fb.SymbolKind, fb.AIDoc, fb.Message)
+		if err != nil {
+			return fmt.Errorf("inserting feedback: %w", err)
+		}
+		if id, err = result.LastInsertId(); err != nil {
+			return err
+		}
+
+		if !fb.AIDoc {
+			return nil
+		}
+		doc, err := tx.GetAIDoc(fb.ImportPath, fb.Symbol, fb.SymbolKind)
+		if err != nil || doc == nil {
+			return err
+		}
+		return tx.FlagAIDoc(doc.ID, fb.Message)
+	})
+	return id, err
+}
+
+// ListFeedback returns unresolved feedback, or all feedback if includeResolved is set, newest first
+func (db *DB) ListFeedback(includeResolved bool, limit int) ([]*Feedback, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := db.conn.Query(`
+		SELECT id, import_path, symbol, symbol_kind, ai_doc, message, resolved, orphaned, created_at
+		FROM feedback
+		WHERE resolved = 0 OR ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`, includeResolved, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying feedback: %w", err)
+	}
+	defer rows.Close()
+
+	var feedback []*Feedback
+	for rows.Next() {
+		fb := &Feedback{}
+		if err := rows.Scan(&fb.ID, &fb.ImportPath, &fb.Symbol, &fb.SymbolKind, &fb.AIDoc, &fb.Message, &fb.Resolved, &fb.Orphaned, &fb.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning feedback: %w", err)
+		}
+		feedback = append(feedback, fb)
+	}
+	return feedback, rows.Err()
+}
+
+// ResolveFeedback marks feedback as handled
+func (db *DB) ResolveFeedback(id int64) error {
+	_, err := db.conn.Exec(`UPDATE feedback SET resolved = 1 WHERE id = ?`, id)
+	return err
+}
+
+// symbolMissing is an SQL condition that holds when the package has no symbol
+// matching a row's symbol name and kind. AI docs name methods without their
+// receiver, packages by package name, and feedback may leave the kind empty.
+func symbolMissing(table, nameCol, kindCol string) string {
+	name, kind := table+"."+nameCol, table+"."+kindCol
+	return fmt.Sprintf(`CASE
+		WHEN %[1]s = '' THEN 0
+		WHEN %[2]s = 'package' THEN %[1]s != ?
+		ELSE NOT EXISTS (
+			SELECT 1 FROM symbols s
+			WHERE s.import_path = %[3]s.import_path
+				AND (%[2]s = '' OR s.kind = %[2]s)
+				AND (s.name = %[1]s OR (s.kind = 'method' AND substr(s.name, -length(%[1]s) - 1) = '.' || %[1]s))
+		)
+	END`, name, kind, table)
+}
+
+// MarkOrphans flags the AI docs and feedback of a freshly reindexed package whose
+// symbol no longer exists, e.g. after a rename, and clears the flag on those whose
+// symbol is back. It returns how many are orphaned.
+func (db *DB) MarkOrphans(importPath, packageName string) (int, error) {
+	var orphaned int
+	err := db.Batch(func(tx *DB) error {
+		if _, err := tx.conn.Exec(`UPDATE ai_docs SET orphaned = `+symbolMissing("ai_docs", "symbol_name", "symbol_kind")+`
+			WHERE import_path = ?`, packageName, importPath); err != nil {
+			return fmt.Errorf("marking orphaned ai docs: %w", err)
+		}
+		if _, err := tx.conn.Exec(`UPDATE feedback SET orphaned = `+symbolMissing("feedback", "symbol", "symbol_kind")+`
+			WHERE import_path = ?`, packageName, importPath); err != nil {
+			return fmt.Errorf("marking orphaned feedback: %w", err)
+		}
+		return tx.conn.QueryRow(`
+			SELECT (SELECT COUNT(*) FROM ai_docs WHERE import_path = ? AND orphaned = 1) +
+				(SELECT COUNT(*) FROM feedback WHERE import_path = ? AND orphaned = 1)
+		`, importPath, importPath).Scan(&orphaned)
+	})
+	return orphaned, err
+}
+
+// GetOrphanedAIDocs returns AI-generated docs whose symbol no longer exists
+func (db *DB) GetOrphanedAIDocs(limit int) ([]*AIDoc, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := db.conn.Query(`
+		SELECT id, symbol_name, symbol_kind, import_path, generated_doc, approved, flagged, flag_reason, orphaned, cost_usd, tokens, created_at, updated_at
+		FROM ai_docs
+		WHERE orphaned = 1
+		ORDER BY import_path, symbol_kind, symbol_name
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying orphaned ai docs: %w", err)
+	}
+	defer rows.Close()
+
+	var docs []*AIDoc
+	for rows.Next() {
+		doc := &AIDoc{}
+		var flagReason sql.NullString
+		err := rows.Scan(&doc.ID, &doc.SymbolName, &doc.SymbolKind, &doc.ImportPath, &doc.GeneratedDoc,
+			&doc.Approved, &doc.Flagged, &flagReason, &doc.Orphaned, &doc.CostUSD, &doc.Tokens, &doc.CreatedAt, &doc.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scanning ai doc: %w", err)
+		}
+		doc.FlagReason = flagReason.String
+		docs = append(docs, doc)
+	}
+	return docs, rows.Err()
+}
+
+// DeleteOrphans removes the orphaned AI docs and feedback, returning how many of each
+func (db *DB) DeleteOrphans() (docs, feedback int64, err error) {
+	err = db.Batch(func(tx *DB) error {
+		result, err := tx.conn.Exec(`DELETE FROM ai_docs WHERE orphaned = 1`)
+		if err != nil {
+			return fmt.Errorf("deleting orphaned ai docs: %w", err)
+		}
+		if docs, err = result.RowsAffected(); err != nil {
+			return err
+		}
+		result, err = tx.conn.Exec(`DELETE FROM feedback WHERE orphaned = 1`)
+		if err != nil {
+			return fmt.Errorf("deleting orphaned feedback: %w", err)
+		}
+		feedback, err = result.RowsAffected()
+		return err
+	})
+	return docs, feedback, err
+}
+
 // GetAIDocStats returns statistics about AI-generated documentation
 func (db *DB) GetAIDocStats() (totalDocs, approvedDocs, flaggedDocs int, totalCost float64, err error) {
 	err = db.conn.QueryRow(`
@@ -1362,6 +2982,79 @@ func (db *DB) GetAIDocStats() (totalDocs, approvedDocs, flaggedDocs int, totalCo
 	return
 }
 
+// RecordCrawlError logs a failure to index a module version
+func (db *DB) RecordCrawlError(modulePath, version, message string) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO crawl_errors (module_path, version, error)
+		VALUES (?, ?, ?)
+	`, modulePath, version, message)
+	if err != nil {
+		return fmt.Errorf("recording crawl error: %w", err)
+	}
+	return nil
+}
+
+// RecentCrawlErrors returns the latest crawl failures, newest first
+func (db *DB) RecentCrawlErrors(limit int) ([]*CrawlError, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := db.conn.Query(`
+		SELECT id, module_path, version, error, created_at
+		FROM crawl_errors
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying crawl errors: %w", err)
+	}
+	defer rows.Close()
+
+	var crawlErrors []*CrawlError
+	for rows.Next() {
+		ce := &CrawlError{}
+		if err := rows.Scan(&ce.ID, &ce.ModulePath, &ce.Version, &ce.Error, &ce.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning crawl error: %w", err)
+		}
+		crawlErrors = append(crawlErrors, ce)
+	}
+	return crawlErrors, rows.Err()
+}
+
+// FailedModuleVersions returns each module version in the crawl error log once,
+// with its latest error, most recently failed first
+func (db *DB) FailedModuleVersions() ([]*CrawlError, error) {
+	rows, err := db.conn.Query(`
+		SELECT MAX(id), module_path, version, error, created_at
+		FROM crawl_errors
+		GROUP BY module_path, version
+		ORDER BY MAX(id) DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying failed module versions: %w", err)
+	}
+	defer rows.Close()
+
+	var failed []*CrawlError
+	for rows.Next() {
+		ce := &CrawlError{}
+		if err := rows.Scan(&ce.ID, &ce.ModulePath, &ce.Version, &ce.Error, &ce.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning crawl error: %w", err)
+		}
+		failed = append(failed, ce)
+	}
+	return failed, rows.Err()
+}
+
+// ClearCrawlErrors removes the logged failures of a module version
+func (db *DB) ClearCrawlErrors(modulePath, version string) error {
+	_, err := db.conn.Exec(`DELETE FROM crawl_errors WHERE module_path = ? AND version = ?`, modulePath, version)
+	if err != nil {
+		return fmt.Errorf("clearing crawl errors: %w", err)
+	}
+	return nil
+}
+
 // JSPackage represents a JavaScript/TypeScript package
 type JSPackage struct {
 	ID             int64
@@ -1381,6 +3074,13 @@ type JSPackage struct {
 	Forks          int
 	Keywords       []string
 	Dependencies   map[string]string
+	// Peer, dev and optional dependencies are only stored in js_dependencies
+	PeerDependencies     map[string]string
+	DevDependencies      map[string]string
+	OptionalDependencies map[string]string
+	// Sizes in bytes; zero when unknown
+	UnpackedSize   int64
+	TarballSize    int64
 	PackageJSON    string
 	README         string
 	CreatedAt      time.Time
@@ -1408,43 +3108,174 @@ func (db *DB) UpsertJSPackage(pkg *JSPackage) (int64, error) {
 	keywordsJSON, _ := json.Marshal(pkg.Keywords)
 	dependenciesJSON, _ := json.Marshal(pkg.Dependencies)
 
-	result, err := db.conn.Exec(`
-		INSERT INTO js_packages (
-			name, version, description, author, license, repository_url,
-			homepage, npm_url, github_url, main_file, types_file,
-			has_typescript, stars, forks, keywords_json, dependencies_json,
-			package_json, readme, indexed_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))
-		ON CONFLICT(name) DO UPDATE SET
-			version=excluded.version,
-			description=excluded.description,
-			author=excluded.author,
-			license=excluded.license,
-			repository_url=excluded.repository_url,
-			homepage=excluded.homepage,
-			npm_url=excluded.npm_url,
-			github_url=excluded.github_url,
-			main_file=excluded.main_file,
-			types_file=excluded.types_file,
-			has_typescript=excluded.has_typescript,
-			stars=excluded.stars,
-			forks=excluded.forks,
-			keywords_json=excluded.keywords_json,
-			dependencies_json=excluded.dependencies_json,
-			package_json=excluded.package_json,
-			readme=excluded.readme,
-			updated_at=datetime('now'),
-			indexed_at=datetime('now')
-	`, pkg.Name, pkg.Version, pkg.Description, pkg.Author, pkg.License,
-		pkg.RepositoryURL, pkg.Homepage, pkg.NPMURL, pkg.GitHubURL,
-		pkg.MainFile, pkg.TypesFile, pkg.HasTypeScript, pkg.Stars, pkg.Forks,
-		keywordsJSON, dependenciesJSON, pkg.PackageJSON, pkg.README)
+	var id int64
+	err := db.Batch(func(tx *DB) error {
+		result, err := tx.conn.Exec(`
+			INSERT INTO js_packages (
+				name, version, description, author, license, repository_url,
+				homepage, npm_url, github_url, main_file, types_file,
+				has_typescript, stars, forks, keywords_json, dependencies_json,
+				package_json, readme, unpacked_size, tarball_size, indexed_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))
+			ON CONFLICT(name) DO UPDATE SET
+				version=excluded.version,
+				description=excluded.description,
+				author=excluded.author,
+				license=excluded.license,
+				repository_url=excluded.repository_url,
+				homepage=excluded.homepage,
+				npm_url=excluded.npm_url,
+				github_url=excluded.github_url,
+				main_file=excluded.main_file,
+				types_file=excluded.types_file,
+				has_typescript=excluded.has_typescript,
+				stars=excluded.stars,
+				forks=excluded.forks,
+				keywords_json=excluded.keywords_json,
+				dependencies_json=excluded.dependencies_json,
+				package_json=excluded.package_json,
+				readme=excluded.readme,
+				unpacked_size=excluded.unpacked_size,
+				tarball_size=excluded.tarball_size,
+				updated_at=datetime('now'),
+				indexed_at=datetime('now')
+		`, pkg.Name, pkg.Version, pkg.Description, pkg.Author, pkg.License,
+			pkg.RepositoryURL, pkg.Homepage, pkg.NPMURL, pkg.GitHubURL,
+			pkg.MainFile, pkg.TypesFile, pkg.HasTypeScript, pkg.Stars, pkg.Forks,
+			keywordsJSON, dependenciesJSON, pkg.PackageJSON, pkg.README,
+			pkg.UnpackedSize, pkg.TarballSize)
+		if err != nil {
+			return err
+		}
 
+		if id, err = result.LastInsertId(); err != nil {
+			return err
+		}
+		return tx.replaceJSDependencies(pkg)
+	})
 	if err != nil {
 		return 0, err
 	}
 
-	return result.LastInsertId()
+	return id, nil
+}
+
+// npm dependency relationship types
+const (
+	JSDepRuntime  = "runtime"
+	JSDepPeer     = "peer"
+	JSDepDev      = "dev"
+	JSDepOptional = "optional"
+)
+
+// JSDependency is a dependency edge between two npm packages
+type JSDependency struct {
+	PackageName    string
+	DependencyName string
+	VersionRange   string
+	Type           string
+}
+
+// replaceJSDependencies rewrites the dependency edges of a package
+func (db *DB) replaceJSDependencies(pkg *JSPackage) error {
+	if _, err := db.conn.Exec(`DELETE FROM js_dependencies WHERE package_name = ?`, pkg.Name); err != nil {
+		return fmt.Errorf("deleting dependencies: %w", err)
+	}
+
+	byType := []struct {
+		depType string
+		deps    map[string]string
+	}{
+		{JSDepRuntime, pkg.Dependencies},
+		{JSDepPeer, pkg.PeerDependencies},
+		{JSDepDev, pkg.DevDependencies},
+		{JSDepOptional, pkg.OptionalDependencies},
+	}
+	for _, group := range byType {
+		for name, versionRange := range group.deps {
+			_, err := db.conn.Exec(`
+				INSERT INTO js_dependencies (package_name, dependency_name, version_range, dep_type)
+				VALUES (?, ?, ?, ?)
+			`, pkg.Name, name, versionRange, group.depType)
+			if err != nil {
+				return fmt.Errorf("inserting dependency: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// GetJSDependencies returns the dependencies of an npm package
+func (db *DB) GetJSDependencies(packageName string) ([]*JSDependency, error) {
+	rows, err := db.conn.Query(`
+		SELECT package_name, dependency_name, COALESCE(version_range, ''), dep_type
+		FROM js_dependencies
+		WHERE package_name = ?
+		ORDER BY dependency_name
+	`, packageName)
+	if err != nil {
+		return nil, fmt.Errorf("querying dependencies: %w", err)
+	}
+	defer rows.Close()
+
+	var deps []*JSDependency
+	for rows.Next() {
+		dep := &JSDependency{}
+		if err := rows.Scan(&dep.PackageName, &dep.DependencyName, &dep.VersionRange, &dep.Type); err != nil {
+			return nil, fmt.Errorf("scanning dependency: %w", err)
+		}
+		deps = append(deps, dep)
+	}
+
+	return deps, rows.Err()
+}
+
+// GetJSDependents returns indexed npm packages that depend on the given package
+func (db *DB) GetJSDependents(packageName string, limit, offset int) ([]*JSPackage, int, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	total, err := db.GetJSDependentsCount(packageName)
+	if err != nil {
+		return nil, 0, fmt.Errorf("counting dependents: %w", err)
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT DISTINCT p.id, p.name, p.version, p.description
+		FROM js_dependencies d
+		JOIN js_packages p ON d.package_name = p.name
+		WHERE d.dependency_name = ?
+		ORDER BY p.name
+		LIMIT ? OFFSET ?
+	`, packageName, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("querying dependents: %w", err)
+	}
+	defer rows.Close()
+
+	var packages []*JSPackage
+	for rows.Next() {
+		pkg := &JSPackage{}
+		if err := rows.Scan(&pkg.ID, &pkg.Name, &pkg.Version, &pkg.Description); err != nil {
+			return nil, 0, fmt.Errorf("scanning dependent: %w", err)
+		}
+		packages = append(packages, pkg)
+	}
+
+	return packages, total, rows.Err()
+}
+
+// GetJSDependentsCount returns the count of indexed npm packages that depend on the given package
+func (db *DB) GetJSDependentsCount(packageName string) (int, error) {
+	var count int
+	err := db.conn.QueryRow(`
+		SELECT COUNT(DISTINCT d.package_name)
+		FROM js_dependencies d
+		JOIN js_packages p ON d.package_name = p.name
+		WHERE d.dependency_name = ?
+	`, packageName).Scan(&count)
+	return count, err
 }
 
 // UpsertJSSymbol inserts or updates a JavaScript/TypeScript symbol
@@ -1476,7 +3307,8 @@ func (db *DB) DeleteJSPackageSymbols(packageID int64) error {
 // SearchJSPackages searches for JavaScript/TypeScript packages
 func (db *DB) SearchJSPackages(query string, limit int) ([]*JSPackage, error) {
 	rows, err := db.conn.Query(`
-		SELECT id, name, version, description, author, license, stars, forks
+		SELECT id, name, version, description, author, license, stars, forks,
+			COALESCE(unpacked_size, 0), COALESCE(tarball_size, 0)
 		FROM js_packages
 		WHERE id IN (
 			SELECT docid FROM js_packages_fts
@@ -1495,7 +3327,8 @@ func (db *DB) SearchJSPackages(query string, limit int) ([]*JSPackage, error) {
 	for rows.Next() {
 		var pkg JSPackage
 		if err := rows.Scan(&pkg.ID, &pkg.Name, &pkg.Version, &pkg.Description,
-			&pkg.Author, &pkg.License, &pkg.Stars, &pkg.Forks); err != nil {
+			&pkg.Author, &pkg.License, &pkg.Stars, &pkg.Forks,
+			&pkg.UnpackedSize, &pkg.TarballSize); err != nil {
 			return nil, err
 		}
 		packages = append(packages, &pkg)
@@ -1544,6 +3377,7 @@ func (db *DB) GetJSPackage(name string) (*JSPackage, error) {
 			repository_url, homepage, npm_url, github_url,
 			main_file, types_file, has_typescript, stars, forks,
 			keywords_json, dependencies_json, package_json, readme,
+			COALESCE(unpacked_size, 0), COALESCE(tarball_size, 0),
 			created_at, updated_at, indexed_at
 		FROM js_packages WHERE name = ?
 	`, name).Scan(&pkg.ID, &pkg.Name, &pkg.Version, &pkg.Description,
@@ -1551,6 +3385,7 @@ func (db *DB) GetJSPackage(name string) (*JSPackage, error) {
 		&pkg.NPMURL, &pkg.GitHubURL, &pkg.MainFile, &pkg.TypesFile,
 		&pkg.HasTypeScript, &pkg.Stars, &pkg.Forks,
 		&keywordsJSON, &dependenciesJSON, &pkg.PackageJSON, &pkg.README,
+		&pkg.UnpackedSize, &pkg.TarballSize,
 		&pkg.CreatedAt, &pkg.UpdatedAt, &pkg.IndexedAt)
 
 	if err == sql.ErrNoRows {
@@ -1585,12 +3420,20 @@ type RustCrate struct {
 	Categories     []string
 	Dependencies   map[string]string
 	Authors        []string
+	Features       map[string][]string // Cargo feature to the features and dependencies it enables
+	NoStd          bool                // builds without the standard library
+	WASM           bool                // supports WebAssembly targets
 	README         string
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
 	IndexedAt      time.Time
 }
 
+// DefaultFeatures returns the features enabled when a dependent does not opt out
+func (c *RustCrate) DefaultFeatures() []string {
+	return c.Features["default"]
+}
+
 // RustSymbol represents a Rust symbol
 type RustSymbol struct {
 	ID        int64
@@ -1611,12 +3454,13 @@ func (db *DB) UpsertRustCrate(crate *RustCrate) (int64, error) {
 	categoriesJSON, _ := json.Marshal(crate.Categories)
 	dependenciesJSON, _ := json.Marshal(crate.Dependencies)
 	authorsJSON, _ := json.Marshal(crate.Authors)
+	featuresJSON, _ := json.Marshal(crate.Features)
 
 	result, err := db.conn.Exec(`
 		INSERT INTO rust_crates (name, version, description, license, repository,
 			homepage, documentation, downloads, keywords_json, categories_json,
-			dependencies_json, authors_json, readme, updated_at, indexed_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+			dependencies_json, authors_json, features_json, no_std, wasm, readme, updated_at, indexed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 		ON CONFLICT(name) DO UPDATE SET
 			version = excluded.version,
 			description = excluded.description,
@@ -1629,12 +3473,15 @@ func (db *DB) UpsertRustCrate(crate *RustCrate) (int64, error) {
 			categories_json = excluded.categories_json,
 			dependencies_json = excluded.dependencies_json,
 			authors_json = excluded.authors_json,
+			features_json = excluded.features_json,
+			no_std = excluded.no_std,
+			wasm = excluded.wasm,
 			readme = excluded.readme,
 			updated_at = CURRENT_TIMESTAMP,
 			indexed_at = CURRENT_TIMESTAMP
 	`, crate.Name, crate.Version, crate.Description, crate.License, crate.Repository,
 		crate.Homepage, crate.Documentation, crate.Downloads, string(keywordsJSON),
-		string(categoriesJSON), string(dependenciesJSON), string(authorsJSON), crate.README)
+		string(categoriesJSON), string(dependenciesJSON), string(authorsJSON), string(featuresJSON), crate.NoStd, crate.WASM, crate.README)
 
 	if err != nil {
 		return 0, err
@@ -1764,17 +3611,17 @@ func (db *DB) SearchRustSymbols(query string, limit int) ([]*RustSymbol, error)
 // GetRustCrate retrieves a Rust crate by name
 func (db *DB) GetRustCrate(name string) (*RustCrate, error) {
 	var crate RustCrate
-	var keywordsJSON, categoriesJSON, dependenciesJSON, authorsJSON sql.NullString
+	var keywordsJSON, categoriesJSON, dependenciesJSON, authorsJSON, featuresJSON sql.NullString
 
 	err := db.conn.QueryRow(`
 		SELECT id, name, version, description, license, repository, homepage,
 			documentation, downloads, keywords_json, categories_json,
-			dependencies_json, authors_json, readme, created_at, updated_at, indexed_at
+			dependencies_json, authors_json, features_json, no_std, wasm, readme, created_at, updated_at, indexed_at
 		FROM rust_crates WHERE name = ?
 	`, name).Scan(&crate.ID, &crate.Name, &crate.Version, &crate.Description,
 		&crate.License, &crate.Repository, &crate.Homepage, &crate.Documentation,
 		&crate.Downloads, &keywordsJSON, &categoriesJSON, &dependenciesJSON,
-		&authorsJSON, &crate.README, &crate.CreatedAt, &crate.UpdatedAt,
+		&authorsJSON, &featuresJSON, &crate.NoStd, &crate.WASM, &crate.README, &crate.CreatedAt, &crate.UpdatedAt,
 		&crate.IndexedAt)
 
 	if err == sql.ErrNoRows {
@@ -1796,6 +3643,9 @@ func (db *DB) GetRustCrate(name string) (*RustCrate, error) {
 	if authorsJSON.Valid {
 		json.Unmarshal([]byte(authorsJSON.String), &crate.Authors)
 	}
+	if featuresJSON.Valid {
+		json.Unmarshal([]byte(featuresJSON.String), &crate.Features)
+	}
 
 	return &crate, nil
 }
@@ -2325,12 +4175,22 @@ func (db *DB) SearchPHPSymbols(query string, limit int) ([]*PHPSymbol, error) {
 
 // GetPopularRustCrates returns popular Rust crates ordered by downloads
 func (db *DB) GetPopularRustCrates(limit int) ([]*RustCrate, error) {
+	return db.rustCrateCards(`ORDER BY downloads DESC LIMIT ?`, limit)
+}
+
+// GetRecentRustCrates returns the Rust crates indexed since the given time,
+// most recent first
+func (db *DB) GetRecentRustCrates(since time.Time, limit int) ([]*RustCrate, error) {
+	return db.rustCrateCards(`WHERE indexed_at >= ? ORDER BY indexed_at DESC LIMIT ?`, sqliteTime(since), limit)
+}
+
+// rustCrateCards returns the fields of the rust_crates rows selected by
+// clauses that the home page cards show
+func (db *DB) rustCrateCards(clauses string, args ...any) ([]*RustCrate, error) {
 	rows, err := db.conn.Query(`
-		SELECT id, name, version, description, license, downloads, repository
+		SELECT id, name, version, description, license, downloads, repository, indexed_at
 		FROM rust_crates
-		ORDER BY downloads DESC
-		LIMIT ?
-	`, limit)
+		`+clauses, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -2340,7 +4200,7 @@ func (db *DB) GetPopularRustCrates(limit int) ([]*RustCrate, error) {
 	for rows.Next() {
 		c := &RustCrate{}
 		var desc, license, repo sql.NullString
-		if err := rows.Scan(&c.ID, &c.Name, &c.Version, &desc, &license, &c.Downloads, &repo); err != nil {
+		if err := rows.Scan(&c.ID, &c.Name, &c.Version, &desc, &license, &c.Downloads, &repo, &c.IndexedAt); err != nil {
 			return nil, err
 		}
 		c.Description = desc.String
@@ -2353,12 +4213,22 @@ func (db *DB) GetPopularRustCrates(limit int) ([]*RustCrate, error) {
 
 // GetPopularJSPackages returns popular JS packages ordered by stars
 func (db *DB) GetPopularJSPackages(limit int) ([]*JSPackage, error) {
+	return db.jsPackageCards(`ORDER BY stars DESC LIMIT ?`, limit)
+}
+
+// GetRecentJSPackages returns the JS packages indexed since the given time,
+// most recent first
+func (db *DB) GetRecentJSPackages(since time.Time, limit int) ([]*JSPackage, error) {
+	return db.jsPackageCards(`WHERE indexed_at >= ? ORDER BY indexed_at DESC LIMIT ?`, sqliteTime(since), limit)
+}
+
+// jsPackageCards returns the fields of the js_packages rows selected by
+// clauses that the home page cards show
+func (db *DB) jsPackageCards(clauses string, args ...any) ([]*JSPackage, error) {
 	rows, err := db.conn.Query(`
-		SELECT id, name, version, description, license, stars, repository_url
+		SELECT id, name, version, description, license, stars, repository_url, indexed_at
 		FROM js_packages
-		ORDER BY stars DESC
-		LIMIT ?
-	`, limit)
+		`+clauses, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -2368,7 +4238,7 @@ func (db *DB) GetPopularJSPackages(limit int) ([]*JSPackage, error) {
 	for rows.Next() {
 		p := &JSPackage{}
 		var desc, license, repo sql.NullString
-		if err := rows.Scan(&p.ID, &p.Name, &p.Version, &desc, &license, &p.Stars, &repo); err != nil {
+		if err := rows.Scan(&p.ID, &p.Name, &p.Version, &desc, &license, &p.Stars, &repo, &p.IndexedAt); err != nil {
 			return nil, err
 		}
 		p.Description = desc.String
@@ -2381,12 +4251,22 @@ func (db *DB) GetPopularJSPackages(limit int) ([]*JSPackage, error) {
 
 // GetPopularPythonPackages returns popular Python packages ordered by downloads
 func (db *DB) GetPopularPythonPackages(limit int) ([]*PythonPackage, error) {
+	return db.pythonPackageCards(`ORDER BY downloads DESC LIMIT ?`, limit)
+}
+
+// GetRecentPythonPackages returns the Python packages indexed since the given
+// time, most recent first
+func (db *DB) GetRecentPythonPackages(since time.Time, limit int) ([]*PythonPackage, error) {
+	return db.pythonPackageCards(`WHERE indexed_at >= ? ORDER BY indexed_at DESC LIMIT ?`, sqliteTime(since), limit)
+}
+
+// pythonPackageCards returns the fields of the python_packages rows selected
+// by clauses that the home page cards show
+func (db *DB) pythonPackageCards(clauses string, args ...any) ([]*PythonPackage, error) {
 	rows, err := db.conn.Query(`
-		SELECT id, name, version, summary, license, downloads, home_page
+		SELECT id, name, version, summary, license, downloads, home_page, indexed_at
 		FROM python_packages
-		ORDER BY downloads DESC
-		LIMIT ?
-	`, limit)
+		`+clauses, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -2396,7 +4276,7 @@ func (db *DB) GetPopularPythonPackages(limit int) ([]*PythonPackage, error) {
 	for rows.Next() {
 		p := &PythonPackage{}
 		var summary, license, homepage sql.NullString
-		if err := rows.Scan(&p.ID, &p.Name, &p.Version, &summary, &license, &p.Downloads, &homepage); err != nil {
+		if err := rows.Scan(&p.ID, &p.Name, &p.Version, &summary, &license, &p.Downloads, &homepage, &p.IndexedAt); err != nil {
 			return nil, err
 		}
 		p.Summary = summary.String
@@ -2409,13 +4289,22 @@ func (db *DB) GetPopularPythonPackages(limit int) ([]*PythonPackage, error) {
 
 // GetPopularPHPPackages returns popular PHP packages ordered by downloads
 func (db *DB) GetPopularPHPPackages(limit int) ([]*PHPPackage, error) {
+	return db.phpPackageCards(`WHERE name != '' ORDER BY downloads DESC, stars DESC LIMIT ?`, limit)
+}
+
+// GetRecentPHPPackages returns the PHP packages indexed since the given time,
+// most recent first
+func (db *DB) GetRecentPHPPackages(since time.Time, limit int) ([]*PHPPackage, error) {
+	return db.phpPackageCards(`WHERE name != '' AND indexed_at >= ? ORDER BY indexed_at DESC LIMIT ?`, sqliteTime(since), limit)
+}
+
+// phpPackageCards returns the fields of the php_packages rows selected by
+// clauses that the home page cards show
+func (db *DB) phpPackageCards(clauses string, args ...any) ([]*PHPPackage, error) {
 	rows, err := db.conn.Query(`
-		SELECT id, name, version, description, license, downloads, stars, repository_url
+		SELECT id, name, version, description, license, downloads, stars, repository_url, indexed_at
 		FROM php_packages
-		WHERE name != ''
-		ORDER BY downloads DESC, stars DESC
-		LIMIT ?
-	`, limit)
+		`+clauses, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -2425,7 +4314,7 @@ func (db *DB) GetPopularPHPPackages(limit int) ([]*PHPPackage, error) {
 	for rows.Next() {
 		p := &PHPPackage{}
 		var desc, license, repo sql.NullString
-		if err := rows.Scan(&p.ID, &p.Name, &p.Version, &desc, &license, &p.Downloads, &p.Stars, &repo); err != nil {
+		if err := rows.Scan(&p.ID, &p.Name, &p.Version, &desc, &license, &p.Downloads, &p.Stars, &repo, &p.IndexedAt); err != nil {
 			return nil, err
 		}
 		p.Description = desc.String
@@ -2436,6 +4325,12 @@ func (db *DB) GetPopularPHPPackages(limit int) ([]*PHPPackage, error) {
 	return packages, nil
 }
 
+// sqliteTime formats t as CURRENT_TIMESTAMP stores it, in UTC, so that it
+// compares as text with the indexed_at and updated_at columns
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(time.DateTime)
+}
+
 // UpsertEmbedding stores or updates an embedding for a package
 func (db *DB) UpsertEmbedding(importPath, lang, textHash string, embedding []float32) error {
 	// Convert float32 slice to bytes
@@ -2498,6 +4393,88 @@ func (db *DB) GetAllEmbeddings(lang string) ([]*Embedding, error) {
 	return embeddings, nil
 }
 
+// GetEmbeddingHashes returns the stored text hash for each embedded import path in a language
+func (db *DB) GetEmbeddingHashes(lang string) (map[string]string, error) {
+	rows, err := db.conn.Query(`
+		SELECT import_path, text_hash FROM embeddings WHERE lang = ?
+	`, lang)
+	if err != nil {
+		return nil, fmt.Errorf("querying embedding hashes: %w", err)
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]string)
+	for rows.Next() {
+		var importPath, textHash string
+		if err := rows.Scan(&importPath, &textHash); err != nil {
+			return nil, fmt.Errorf("scanning embedding hash: %w", err)
+		}
+		hashes[importPath] = textHash
+	}
+	return hashes, rows.Err()
+}
+
+// UpsertEmbeddingIndex stores the trained ANN index for a language
+func (db *DB) UpsertEmbeddingIndex(idx *EmbeddingIndex) error {
+	var centroids []float32
+	for _, c := range idx.Centroids {
+		if len(c) != idx.Dim {
+			return fmt.Errorf("centroid has dimension %d, want %d", len(c), idx.Dim)
+		}
+		centroids = append(centroids, c...)
+	}
+	assignmentsJSON, err := json.Marshal(idx.Assignments)
+	if err != nil {
+		return fmt.Errorf("marshaling assignments: %w", err)
+	}
+
+	_, err = db.conn.Exec(`
+		INSERT INTO embedding_indexes (lang, dim, embedding_count, centroids, assignments_json)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(lang) DO UPDATE SET
+			dim = excluded.dim,
+			embedding_count = excluded.embedding_count,
+			centroids = excluded.centroids,
+			assignments_json = excluded.assignments_json,
+			created_at = CURRENT_TIMESTAMP
+	`, idx.Lang, idx.Dim, idx.EmbeddingCount, float32SliceToBytes(centroids), string(assignmentsJSON))
+	if err != nil {
+		return fmt.Errorf("upserting embedding index: %w", err)
+	}
+	return nil
+}
+
+// GetEmbeddingIndex retrieves the persisted ANN index for a language
+func (db *DB) GetEmbeddingIndex(lang string) (*EmbeddingIndex, error) {
+	idx := &EmbeddingIndex{Lang: lang}
+	var centroidBytes []byte
+	var assignmentsJSON string
+
+	err := db.conn.QueryRow(`
+		SELECT dim, embedding_count, centroids, assignments_json, created_at
+		FROM embedding_indexes WHERE lang = ?
+	`, lang).Scan(&idx.Dim, &idx.EmbeddingCount, &centroidBytes, &assignmentsJSON, &idx.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("querying embedding index: %w", err)
+	}
+
+	flat := bytesToFloat32Slice(centroidBytes)
+	if idx.Dim <= 0 || len(flat)%idx.Dim != 0 {
+		return nil, fmt.Errorf("corrupt embedding index for %s", lang)
+	}
+	for i := 0; i < len(flat); i += idx.Dim {
+		idx.Centroids = append(idx.Centroids, flat[i:i+idx.Dim])
+	}
+	if err := json.Unmarshal([]byte(assignmentsJSON), &idx.Assignments); err != nil {
+		return nil, fmt.Errorf("unmarshaling assignments: %w", err)
+	}
+
+	return idx, nil
+}
+
 // float32SliceToBytes converts a float32 slice to bytes using little-endian encoding
 func float32SliceToBytes(floats []float32) []byte {
 	buf := make([]byte, len(floats)*4)
@@ -1,8 +1,13 @@
 package db
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 )
@@ -193,6 +198,39 @@ func TestListPackages(t *testing.T) {
 	}
 }
 
+func TestPackagesByModule(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	packages := []*Package{
+		{ImportPath: "github.com/a/mod/sub", Name: "sub", ModulePath: "github.com/a/mod"},
+		{ImportPath: "github.com/a/mod", Name: "mod", ModulePath: "github.com/a/mod"},
+		{ImportPath: "github.com/a/mod/v2", Name: "mod", ModulePath: "github.com/a/mod/v2"},
+		{ImportPath: "github.com/b/other", Name: "other", ModulePath: "github.com/b/other"},
+	}
+	for _, pkg := range packages {
+		if _, err := db.UpsertPackage(pkg); err != nil {
+			t.Fatalf("UpsertPackage() error = %v", err)
+		}
+	}
+
+	retrieved, err := db.PackagesByModule("github.com/a/mod")
+	if err != nil {
+		t.Fatalf("PackagesByModule() error = %v", err)
+	}
+	var paths []string
+	for _, pkg := range retrieved {
+		paths = append(paths, pkg.ImportPath)
+	}
+	if want := []string{"github.com/a/mod", "github.com/a/mod/sub"}; !slices.Equal(paths, want) {
+		t.Errorf("PackagesByModule() = %v, want %v", paths, want)
+	}
+
+	if retrieved, err := db.PackagesByModule("github.com/c/none"); err != nil || len(retrieved) != 0 {
+		t.Errorf("PackagesByModule() of an unknown module = %v, %v", retrieved, err)
+	}
+}
+
 func TestSearchPackages(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
@@ -222,7 +260,7 @@ func TestSearchPackages(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			results, err := db.SearchPackages(tt.query, 100)
+			results, err := db.SearchPackages(tt.query, 100, false)
 			if err != nil {
 				t.Fatalf("SearchPackages() error = %v", err)
 			}
@@ -261,6 +299,100 @@ func TestAddImport(t *testing.T) {
 	}
 }
 
+func TestGetImports(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	for _, imported := range []string{"strings", "github.com/test/lib", "fmt", "strings"} {
+		if err := db.AddImport("github.com/test/app", imported, "github.com/test/app"); err != nil {
+			t.Fatalf("AddImport(%s) error = %v", imported, err)
+		}
+	}
+	if err := db.AddImport("github.com/test/other", "os", "github.com/test/other"); err != nil {
+		t.Fatalf("AddImport() error = %v", err)
+	}
+
+	imports, err := db.GetImports("github.com/test/app")
+	if err != nil {
+		t.Fatalf("GetImports() error = %v", err)
+	}
+	if want := []string{"fmt", "github.com/test/lib", "strings"}; !slices.Equal(imports, want) {
+		t.Errorf("GetImports() = %v, want %v", imports, want)
+	}
+
+	if imports, err := db.GetImports("github.com/test/none"); err != nil || len(imports) != 0 {
+		t.Errorf("GetImports(unknown) = %v, %v, want none", imports, err)
+	}
+}
+
+func TestReplaceImports(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if err := db.ReplaceImports("github.com/test/app", "github.com/test/app", []string{"fmt", "os"}); err != nil {
+		t.Fatalf("ReplaceImports() error = %v", err)
+	}
+	if err := db.AddImport("github.com/test/other", "os", "github.com/test/other"); err != nil {
+		t.Fatalf("AddImport() error = %v", err)
+	}
+	if err := db.ReplaceImports("github.com/test/app", "github.com/test/app", []string{"io", "os"}); err != nil {
+		t.Fatalf("ReplaceImports() again error = %v", err)
+	}
+
+	imports, err := db.GetImports("github.com/test/app")
+	if err != nil {
+		t.Fatalf("GetImports() error = %v", err)
+	}
+	if want := []string{"io", "os"}; !slices.Equal(imports, want) {
+		t.Errorf("imports = %v, want %v", imports, want)
+	}
+	if count, _ := db.GetImportedByCount("os"); count != 2 {
+		t.Errorf("GetImportedByCount(os) = %d, want 2; other importers must be kept", count)
+	}
+}
+
+func TestRefreshImportedByCounts(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	for _, importer := range []string{"github.com/test/a", "github.com/test/b"} {
+		if err := db.ReplaceImports(importer, importer, []string{"fmt", "github.com/test/lib"}); err != nil {
+			t.Fatalf("ReplaceImports(%s) error = %v", importer, err)
+		}
+	}
+	if count, _ := db.CachedImportedByCount("fmt"); count != 0 {
+		t.Errorf("CachedImportedByCount(fmt) before refresh = %d, want 0", count)
+	}
+
+	n, err := db.RefreshImportedByCounts()
+	if err != nil {
+		t.Fatalf("RefreshImportedByCounts() error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("RefreshImportedByCounts() = %d, want 2 imported packages", n)
+	}
+	if count, _ := db.CachedImportedByCount("github.com/test/lib"); count != 2 {
+		t.Errorf("CachedImportedByCount(lib) = %d, want 2", count)
+	}
+
+	// Counts follow imports only on the next refresh
+	if err := db.ReplaceImports("github.com/test/b", "github.com/test/b", []string{"fmt"}); err != nil {
+		t.Fatalf("ReplaceImports() error = %v", err)
+	}
+	if count, _ := db.CachedImportedByCount("github.com/test/lib"); count != 2 {
+		t.Errorf("CachedImportedByCount(lib) before refresh = %d, want the cached 2", count)
+	}
+	if _, err := db.RefreshImportedByCounts(); err != nil {
+		t.Fatalf("RefreshImportedByCounts() again error = %v", err)
+	}
+	if count, _ := db.CachedImportedByCount("github.com/test/lib"); count != 1 {
+		t.Errorf("CachedImportedByCount(lib) after refresh = %d, want 1", count)
+	}
+	if count, _ := db.CachedImportedByCount("github.com/test/unknown"); count != 0 {
+		t.Errorf("CachedImportedByCount(unknown) = %d, want 0", count)
+	}
+}
+
 func TestGetImportedBy(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
@@ -308,375 +440,1684 @@ func TestGetImportedBy(t *testing.T) {
 	}
 }
 
-func TestGetImportedByCount(t *testing.T) {
+func TestBrowseSymbols(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	// Create library and importers
-	lib := &Package{ImportPath: "github.com/test/lib", Name: "lib", ModulePath: "github.com/test/lib"}
-	if _, err := db.UpsertPackage(lib); err != nil {
+	pkgID, err := db.UpsertPackage(&Package{ImportPath: "net/http", Name: "http"})
+	if err != nil {
 		t.Fatalf("UpsertPackage() error = %v", err)
 	}
-
-	for i := 0; i < 3; i++ {
-		importPath := filepath.Join("github.com/test", "app"+string(rune('a'+i)))
-		importer := &Package{ImportPath: importPath, Name: "app", ModulePath: importPath}
-		if _, err := db.UpsertPackage(importer); err != nil {
-			t.Fatalf("UpsertPackage() error = %v", err)
-		}
-		if err := db.AddImport(importPath, "github.com/test/lib", importPath); err != nil {
-			t.Fatalf("AddImport() error = %v", err)
+	for _, sym := range []*Symbol{
+		{Name: "Handler", Kind: "type"},
+		{Name: "HandleFunc", Kind: "func"},
+		{Name: "Get", Kind: "func"},
+		{Name: "Header", Kind: "type"},
+		{Name: "Header.Get", Kind: "method"},
+		{Name: "ErrHandlerTimeout", Kind: "var"},
+	} {
+		sym.PackageID, sym.ImportPath = pkgID, "net/http"
+		if err := db.UpsertSymbol(sym); err != nil {
+			t.Fatalf("UpsertSymbol(%s) error = %v", sym.Name, err)
 		}
 	}
 
-	count, err := db.GetImportedByCount("github.com/test/lib")
-	if err != nil {
-		t.Fatalf("GetImportedByCount() error = %v", err)
+	tests := []struct {
+		kind, letter  string
+		limit, offset int
+		want          []string
+		wantTotal     int
+	}{
+		{"", "", 10, 0, []string{"ErrHandlerTimeout", "Get", "HandleFunc", "Handler", "Header", "Header.Get"}, 6},
+		{"", "H", 10, 0, []string{"HandleFunc", "Handler", "Header", "Header.Get"}, 4},
+		{"type", "H", 10, 0, []string{"Handler", "Header"}, 2},
+		{"func", "", 10, 0, []string{"Get", "HandleFunc"}, 2},
+		{"", "H", 2, 2, []string{"Header", "Header.Get"}, 4},
+		{"", "h", 10, 0, nil, 0}, // exported names are upper-case
+		{"", "Z", 10, 0, nil, 0},
 	}
-	if count != 3 {
-		t.Errorf("GetImportedByCount() = %v, want 3", count)
+
+	for _, tt := range tests {
+		t.Run(tt.kind+"/"+tt.letter, func(t *testing.T) {
+			symbols, total, err := db.BrowseSymbols(tt.kind, tt.letter, tt.limit, tt.offset)
+			if err != nil {
+				t.Fatalf("BrowseSymbols() error = %v", err)
+			}
+			var got []string
+			for _, sym := range symbols {
+				got = append(got, sym.Name)
+			}
+			if !slices.Equal(got, tt.want) || total != tt.wantTotal {
+				t.Errorf("BrowseSymbols(%q, %q) = %v (total %d), want %v (total %d)", tt.kind, tt.letter, got, total, tt.want, tt.wantTotal)
+			}
+		})
 	}
 }
 
-func TestUpsertSymbol(t *testing.T) {
+func TestPackagesRequiringGoVersion(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	// Insert package first
-	pkg := &Package{ImportPath: "github.com/test/pkg", Name: "pkg"}
-	pkgID, err := db.UpsertPackage(pkg)
-	if err != nil {
-		t.Fatalf("UpsertPackage() error = %v", err)
-	}
-
-	symbol := &Symbol{
-		Name:       "TestFunc",
-		Kind:       "func",
-		PackageID:  pkgID,
-		ImportPath: "github.com/test/pkg",
-		Synopsis:   "TestFunc does testing",
-		Deprecated: false,
+	for path, goVersion := range map[string]string{
+		"example.com/old":    "1.9",
+		"example.com/modern": "1.22",
+		"example.com/newest": "1.23.1",
+		"example.com/exact":  "1.21",
+		"example.com/none":   "",
+	} {
+		if _, err := db.UpsertPackage(&Package{ImportPath: path, Name: "p", GoVersion: goVersion}); err != nil {
+			t.Fatalf("UpsertPackage(%s) error = %v", path, err)
+		}
 	}
 
-	err = db.UpsertSymbol(symbol)
+	pkgs, err := db.PackagesRequiringGoVersion("1.21")
 	if err != nil {
-		t.Fatalf("UpsertSymbol() error = %v", err)
+		t.Fatalf("PackagesRequiringGoVersion() error = %v", err)
 	}
-
-	// Update the symbol
-	symbol.Synopsis = "Updated synopsis"
-	err = db.UpsertSymbol(symbol)
-	if err != nil {
-		t.Fatalf("UpsertSymbol() update error = %v", err)
+	var got []string
+	for _, pkg := range pkgs {
+		got = append(got, pkg.ImportPath+"@"+pkg.GoVersion)
+	}
+	want := []string{"example.com/newest@1.23.1", "example.com/modern@1.22", "example.com/exact@1.21"}
+	if !slices.Equal(got, want) {
+		t.Errorf("PackagesRequiringGoVersion(1.21) = %v, want %v", got, want)
 	}
 }
 
-func TestSearchSymbols(t *testing.T) {
+func TestExamples(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	// Insert package and symbols
-	pkg := &Package{ImportPath: "github.com/test/pkg", Name: "pkg"}
-	pkgID, err := db.UpsertPackage(pkg)
+	pkgID, err := db.UpsertPackage(&Package{ImportPath: "net/http", Name: "http"})
 	if err != nil {
 		t.Fatalf("UpsertPackage() error = %v", err)
 	}
-
-	symbols := []*Symbol{
-		{Name: "HTTPClient", Kind: "type", PackageID: pkgID, ImportPath: "github.com/test/pkg", Synopsis: "HTTP client type"},
-		{Name: "NewClient", Kind: "func", PackageID: pkgID, ImportPath: "github.com/test/pkg", Synopsis: "Creates new HTTP client"},
-		{Name: "ServerConfig", Kind: "type", PackageID: pkgID, ImportPath: "github.com/test/pkg", Synopsis: "Server configuration"},
+	examples := []*Example{
+		{Symbol: "ListenAndServe", Name: "ListenAndServe", Code: "http.HandleFunc(\"/\", hello)\nlog.Fatal(http.ListenAndServe(\":8080\", nil))"},
+		{Symbol: "Get", Name: "Get", Doc: "Fetch a page", Code: "res, err := http.Get(\"https://example.com\")", Output: "200 OK", Verified: true},
+	}
+	if err := db.ReplacePackageExamples(pkgID, "net/http", examples); err != nil {
+		t.Fatalf("ReplacePackageExamples() error = %v", err)
 	}
 
-	for _, sym := range symbols {
-		if err := db.UpsertSymbol(sym); err != nil {
-			t.Fatalf("UpsertSymbol() error = %v", err)
-		}
+	got, err := db.GetPackageExamples("net/http")
+	if err != nil {
+		t.Fatalf("GetPackageExamples() error = %v", err)
+	}
+	if len(got) != 2 || got[0].Name != "Get" || got[0].Output != "200 OK" {
+		t.Errorf("GetPackageExamples() = %+v, want Get then ListenAndServe", got)
+	}
+	if len(got) == 2 && (!got[0].Verified || got[1].Verified) {
+		t.Errorf("GetPackageExamples() verified = %v, %v, want true, false", got[0].Verified, got[1].Verified)
 	}
 
-	// Test search without kind filter
-	results, err := db.SearchSymbols("http", "", 100)
+	results, err := db.SearchExamples("http serve", 10)
 	if err != nil {
-		t.Fatalf("SearchSymbols() error = %v", err)
+		t.Fatalf("SearchExamples() error = %v", err)
 	}
-	if len(results) < 1 {
-		t.Errorf("SearchSymbols() returned %v results, want at least 1", len(results))
+	if len(results) != 1 || results[0].Symbol != "ListenAndServe" {
+		t.Errorf("SearchExamples(http serve) = %+v, want ListenAndServe", results)
 	}
 
-	// Test search with kind filter
-	results, err = db.SearchSymbols("client", "func", 100)
+	// Replacing drops examples that no longer exist
+	if err := db.ReplacePackageExamples(pkgID, "net/http", examples[1:]); err != nil {
+		t.Fatalf("ReplacePackageExamples() error = %v", err)
+	}
+	results, err = db.SearchExamples("ListenAndServe", 10)
 	if err != nil {
-		t.Fatalf("SearchSymbols() with kind error = %v", err)
+		t.Fatalf("SearchExamples() error = %v", err)
 	}
-	if len(results) < 1 {
-		t.Errorf("SearchSymbols() with kind returned %v results, want at least 1", len(results))
+	if len(results) != 0 {
+		t.Errorf("SearchExamples() after replace = %+v, want none", results)
 	}
-	for _, sym := range results {
-		if sym.Kind != "func" {
-			t.Errorf("SearchSymbols() with kind='func' returned symbol with kind=%v", sym.Kind)
+}
+
+func TestExampleMatchQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"http server", "http* serv*"},
+		{"json.Unmarshal", "json* unmarshal*"},
+		{"reading files", "read* file*"},
+		{"  ", ""},
+		{`"quoted" OR -x`, "quoted* or* x*"},
+	}
+	for _, tt := range tests {
+		if got := exampleMatchQuery(tt.query); got != tt.want {
+			t.Errorf("exampleMatchQuery(%q) = %q, want %q", tt.query, got, tt.want)
 		}
 	}
 }
 
-func TestDeletePackageSymbols(t *testing.T) {
+func TestFeedback(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	// Insert package and symbols
-	pkg := &Package{ImportPath: "github.com/test/pkg", Name: "pkg"}
-	pkgID, err := db.UpsertPackage(pkg)
-	if err != nil {
-		t.Fatalf("UpsertPackage() error = %v", err)
+	aiDoc := &AIDoc{SymbolName: "Dial", SymbolKind: "func", ImportPath: "example.com/net", GeneratedDoc: "Dial opens a file.", Approved: true}
+	if err := db.UpsertAIDoc(aiDoc); err != nil {
+		t.Fatalf("UpsertAIDoc() error = %v", err)
 	}
 
-	symbol := &Symbol{
-		Name:       "TestFunc",
-		Kind:       "func",
-		PackageID:  pkgID,
-		ImportPath: "github.com/test/pkg",
+	if _, err := db.AddFeedback(&Feedback{ImportPath: "example.com/net", Symbol: "Listen", Message: "Example is outdated"}); err != nil {
+		t.Fatalf("AddFeedback() error = %v", err)
 	}
-	if err := db.UpsertSymbol(symbol); err != nil {
-		t.Fatalf("UpsertSymbol() error = %v", err)
+	id, err := db.AddFeedback(&Feedback{ImportPath: "example.com/net", Symbol: "Dial", SymbolKind: "func", AIDoc: true, Message: "Dial opens connections, not files"})
+	if err != nil {
+		t.Fatalf("AddFeedback(ai doc) error = %v", err)
 	}
 
-	// Delete symbols
-	err = db.DeletePackageSymbols(pkgID)
+	open, err := db.ListFeedback(false, 10)
 	if err != nil {
-		t.Fatalf("DeletePackageSymbols() error = %v", err)
+		t.Fatalf("ListFeedback() error = %v", err)
+	}
+	if len(open) != 2 || open[0].ID != id || !open[0].AIDoc {
+		t.Fatalf("ListFeedback() = %+v, want the AI doc report first", open)
 	}
 
-	// Verify deletion
-	var count int
-	err = db.conn.QueryRow("SELECT COUNT(*) FROM symbols WHERE package_id = ?", pkgID).Scan(&count)
+	flagged, err := db.GetFlaggedAIDocs(10)
 	if err != nil {
-		t.Fatalf("failed to count symbols: %v", err)
+		t.Fatalf("GetFlaggedAIDocs() error = %v", err)
 	}
-	if count != 0 {
-		t.Errorf("DeletePackageSymbols() left %v symbols, want 0", count)
+	if len(flagged) != 1 || flagged[0].SymbolName != "Dial" || flagged[0].FlagReason != "Dial opens connections, not files" {
+		t.Errorf("GetFlaggedAIDocs() = %+v, want Dial flagged with the report message", flagged)
+	}
+
+	if err := db.ResolveFeedback(id); err != nil {
+		t.Fatalf("ResolveFeedback() error = %v", err)
+	}
+	if open, _ := db.ListFeedback(false, 10); len(open) != 1 {
+		t.Errorf("ListFeedback() after resolve = %d entries, want 1", len(open))
+	}
+	if all, _ := db.ListFeedback(true, 10); len(all) != 2 {
+		t.Errorf("ListFeedback(all) = %d entries, want 2", len(all))
+	}
+
+	if err := db.UnflagAIDoc(flagged[0].ID); err != nil {
+		t.Fatalf("UnflagAIDoc() error = %v", err)
+	}
+	if flagged, _ := db.GetFlaggedAIDocs(10); len(flagged) != 0 {
+		t.Errorf("GetFlaggedAIDocs() after unflag = %+v, want none", flagged)
 	}
 }
 
-func TestDeletePackage(t *testing.T) {
+func TestCrawlErrors(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	// Insert package with symbols and imports
-	pkg := &Package{ImportPath: "github.com/test/pkg", Name: "pkg", ModulePath: "github.com/test/pkg"}
-	pkgID, err := db.UpsertPackage(pkg)
-	if err != nil {
-		t.Fatalf("UpsertPackage() error = %v", err)
-	}
-
-	symbol := &Symbol{Name: "TestFunc", Kind: "func", PackageID: pkgID, ImportPath: "github.com/test/pkg"}
-	if err := db.UpsertSymbol(symbol); err != nil {
-		t.Fatalf("UpsertSymbol() error = %v", err)
+	if crawlErrors, err := db.RecentCrawlErrors(10); err != nil || len(crawlErrors) != 0 {
+		t.Fatalf("RecentCrawlErrors() on an empty log = %v, %v", crawlErrors, err)
 	}
 
-	if err := db.AddImport("github.com/test/pkg", "fmt", "github.com/test/pkg"); err != nil {
-		t.Fatalf("AddImport() error = %v", err)
+	for _, ce := range []CrawlError{
+		{ModulePath: "example.com/a", Version: "v1.0.0", Error: "downloading module: status 410"},
+		{ModulePath: "example.com/b", Version: "v0.2.0", Error: "finding module root: no go.mod"},
+		{ModulePath: "example.com/a", Version: "v1.0.1", Error: "downloading module: timeout"},
+	} {
+		if err := db.RecordCrawlError(ce.ModulePath, ce.Version, ce.Error); err != nil {
+			t.Fatalf("RecordCrawlError() error = %v", err)
+		}
 	}
 
-	// Delete package
-	err = db.DeletePackage("github.com/test/pkg")
+	crawlErrors, err := db.RecentCrawlErrors(2)
 	if err != nil {
-		t.Fatalf("DeletePackage() error = %v", err)
+		t.Fatalf("RecentCrawlErrors() error = %v", err)
+	}
+	if len(crawlErrors) != 2 || crawlErrors[0].Version != "v1.0.1" || crawlErrors[1].ModulePath != "example.com/b" {
+		t.Errorf("RecentCrawlErrors(2) = %+v, want the two latest, newest first", crawlErrors)
+	}
+	if crawlErrors[0].CreatedAt.IsZero() {
+		t.Error("crawl error has no timestamp")
 	}
 
-	// Verify package is deleted
-	retrieved, err := db.GetPackage("github.com/test/pkg")
+	failed, err := db.FailedModuleVersions()
 	if err != nil {
-		t.Fatalf("GetPackage() error = %v", err)
+		t.Fatalf("FailedModuleVersions() error = %v", err)
 	}
-	if retrieved != nil {
-		t.Error("DeletePackage() did not delete package")
+	var got []string
+	for _, ce := range failed {
+		got = append(got, ce.ModulePath+"@"+ce.Version)
 	}
-
-	// Verify symbols are deleted (CASCADE)
-	var symbolCount int
-	db.conn.QueryRow("SELECT COUNT(*) FROM symbols WHERE package_id = ?", pkgID).Scan(&symbolCount)
-	if symbolCount != 0 {
-		t.Errorf("DeletePackage() left %v symbols, want 0", symbolCount)
+	if want := []string{"example.com/a@v1.0.1", "example.com/b@v0.2.0", "example.com/a@v1.0.0"}; !slices.Equal(got, want) {
+		t.Errorf("FailedModuleVersions() = %v, want %v", got, want)
 	}
 
-	// Verify imports are deleted
-	var importCount int
-	db.conn.QueryRow("SELECT COUNT(*) FROM imports WHERE importer_path = ?", "github.com/test/pkg").Scan(&importCount)
-	if importCount != 0 {
-		t.Errorf("DeletePackage() left %v imports, want 0", importCount)
+	if err := db.ClearCrawlErrors("example.com/a", "v1.0.0"); err != nil {
+		t.Fatalf("ClearCrawlErrors() error = %v", err)
+	}
+	if crawlErrors, _ := db.RecentCrawlErrors(10); len(crawlErrors) != 2 {
+		t.Errorf("RecentCrawlErrors() after clear = %d entries, want 2", len(crawlErrors))
 	}
 }
 
-func TestGetStats(t *testing.T) {
+func TestReplacePackageSymbols(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	// Insert test data
-	pkg := &Package{ImportPath: "github.com/test/pkg", Name: "pkg", ModulePath: "github.com/test/pkg"}
-	pkgID, err := db.UpsertPackage(pkg)
+	pkgID, err := db.UpsertPackage(&Package{ImportPath: "example.com/net", Name: "net"})
 	if err != nil {
 		t.Fatalf("UpsertPackage() error = %v", err)
 	}
-
-	symbol := &Symbol{Name: "TestFunc", Kind: "func", PackageID: pkgID, ImportPath: "github.com/test/pkg"}
-	if err := db.UpsertSymbol(symbol); err != nil {
-		t.Fatalf("UpsertSymbol() error = %v", err)
+	replace := func(names ...string) int {
+		t.Helper()
+		var symbols []*Symbol
+		for _, name := range names {
+			symbols = append(symbols, &Symbol{Name: name, Kind: "func", PackageID: pkgID, ImportPath: "example.com/net"})
+		}
+		orphaned, err := db.ReplacePackageSymbols(pkgID, "example.com/net", "net", symbols)
+		if err != nil {
+			t.Fatalf("ReplacePackageSymbols() error = %v", err)
+		}
+		return orphaned
 	}
-
-	if err := db.AddImport("github.com/test/pkg", "fmt", "github.com/test/pkg"); err != nil {
-		t.Fatalf("AddImport() error = %v", err)
+	replace("Dial", "Listen")
+	if err := db.UpsertAIDoc(&AIDoc{ImportPath: "example.com/net", SymbolName: "Dial", SymbolKind: "func", GeneratedDoc: "Generated."}); err != nil {
+		t.Fatalf("UpsertAIDoc() error = %v", err)
 	}
 
-	// Get stats
-	packageCount, symbolCount, importCount, err := db.GetStats()
+	// Renaming Dial drops its symbol and orphans its AI doc
+	if n := replace("DialContext", "Listen"); n != 1 {
+		t.Errorf("ReplacePackageSymbols() after rename = %d orphans, want 1", n)
+	}
+	symbols, err := db.GetPackageSymbols(pkgID)
 	if err != nil {
-		t.Fatalf("GetStats() error = %v", err)
+		t.Fatalf("GetPackageSymbols() error = %v", err)
 	}
-
-	if packageCount != 1 {
-		t.Errorf("GetStats() packageCount = %v, want 1", packageCount)
+	var names []string
+	for _, sym := range symbols {
+		names = append(names, sym.Name)
 	}
-	if symbolCount != 1 {
-		t.Errorf("GetStats() symbolCount = %v, want 1", symbolCount)
+	if want := []string{"DialContext", "Listen"}; !slices.Equal(names, want) {
+		t.Errorf("symbols = %q, want %q", names, want)
 	}
-	if importCount != 1 {
-		t.Errorf("GetStats() importCount = %v, want 1", importCount)
+	if n := replace("Dial", "DialContext", "Listen"); n != 0 {
+		t.Errorf("ReplacePackageSymbols() after restoring Dial = %d orphans, want 0", n)
 	}
 }
 
-func TestCrawlMetadata(t *testing.T) {
+func TestMarkOrphans(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	// Test SetLastCrawlTime and GetLastCrawlTime
-	now := time.Now().Truncate(time.Second)
-	err := db.SetLastCrawlTime(now)
+	pkgID, err := db.UpsertPackage(&Package{ImportPath: "example.com/net", Name: "net"})
 	if err != nil {
-		t.Fatalf("SetLastCrawlTime() error = %v", err)
+		t.Fatalf("UpsertPackage() error = %v", err)
 	}
+	index := func(funcName string) {
+		t.Helper()
+		if err := db.DeletePackageSymbols(pkgID); err != nil {
+			t.Fatalf("DeletePackageSymbols() error = %v", err)
+		}
+		for _, sym := range []*Symbol{
+			{Name: funcName, Kind: "func"},
+			{Name: "Conn", Kind: "type"},
+			{Name: "Conn.Close", Kind: "method"},
+		} {
+			sym.PackageID, sym.ImportPath = pkgID, "example.com/net"
+			if err := db.UpsertSymbol(sym); err != nil {
+				t.Fatalf("UpsertSymbol() error = %v", err)
+			}
+		}
+	}
+	index("Dial")
+
+	for _, doc := range []*AIDoc{
+		{SymbolName: "net", SymbolKind: "package"},
+		{SymbolName: "Dial", SymbolKind: "func"},
+		{SymbolName: "Conn", SymbolKind: "type"},
+		{SymbolName: "Close", SymbolKind: "method"},
+	} {
+		doc.ImportPath, doc.GeneratedDoc = "example.com/net", "Generated."
+		if err := db.UpsertAIDoc(doc); err != nil {
+			t.Fatalf("UpsertAIDoc() error = %v", err)
+		}
+	}
+	for _, fb := range []*Feedback{
+		{Symbol: "", Message: "Package docs are thin"},
+		{Symbol: "Dial", Message: "Example is outdated"},
+		{Symbol: "Conn.Close", Message: "Close may block"},
+		{Symbol: "Dial", SymbolKind: "func", AIDoc: true, Message: "Dial opens connections"},
+	} {
+		fb.ImportPath = "example.com/net"
+		if _, err := db.AddFeedback(fb); err != nil {
+			t.Fatalf("AddFeedback() error = %v", err)
+		}
+	}
+
+	if n, err := db.MarkOrphans("example.com/net", "net"); err != nil || n != 0 {
+		t.Fatalf("MarkOrphans() = %d, %v, want no orphans", n, err)
+	}
+
+	// Renaming Dial orphans its AI doc and both reports about it
+	index("DialContext")
+	if n, err := db.MarkOrphans("example.com/net", "net"); err != nil || n != 3 {
+		t.Fatalf("MarkOrphans() after rename = %d, %v, want 3", n, err)
+	}
+	orphaned, err := db.GetOrphanedAIDocs(10)
+	if err != nil {
+		t.Fatalf("GetOrphanedAIDocs() error = %v", err)
+	}
+	if len(orphaned) != 1 || orphaned[0].SymbolName != "Dial" || !orphaned[0].Orphaned {
+		t.Errorf("GetOrphanedAIDocs() = %+v, want the Dial doc", orphaned)
+	}
+	var orphanedFeedback []string
+	feedback, _ := db.ListFeedback(false, 10)
+	for _, fb := range feedback {
+		if fb.Orphaned {
+			orphanedFeedback = append(orphanedFeedback, fb.Message)
+		}
+	}
+	if want := []string{"Dial opens connections", "Example is outdated"}; !slices.Equal(orphanedFeedback, want) {
+		t.Errorf("orphaned feedback = %q, want %q", orphanedFeedback, want)
+	}
+
+	// Renaming the package orphans its synopsis; restoring Dial clears its flags
+	index("Dial")
+	if n, err := db.MarkOrphans("example.com/net", "netx"); err != nil || n != 1 {
+		t.Fatalf("MarkOrphans() after package rename = %d, %v, want 1", n, err)
+	}
+
+	docs, fbs, err := db.DeleteOrphans()
+	if err != nil || docs != 1 || fbs != 0 {
+		t.Fatalf("DeleteOrphans() = %d, %d, %v, want the package doc only", docs, fbs, err)
+	}
+	if doc, _ := db.GetAIDoc("example.com/net", "net", "package"); doc != nil {
+		t.Error("DeleteOrphans() left the orphaned package doc")
+	}
+	if doc, _ := db.GetAIDoc("example.com/net", "Dial", "func"); doc == nil || doc.Orphaned {
+		t.Errorf("Dial doc after restore = %+v, want kept and not orphaned", doc)
+	}
+}
+
+func TestJSDependencies(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	express := &JSPackage{Name: "express", Version: "4.18.2", Dependencies: map[string]string{"body-parser": "1.20.1", "cookie": "0.5.0"}}
+	if _, err := db.UpsertJSPackage(express); err != nil {
+		t.Fatalf("UpsertJSPackage(express) error = %v", err)
+	}
+	app := &JSPackage{Name: "my-app", Version: "1.0.0", Dependencies: map[string]string{"express": "^4.18.0"}}
+	if _, err := db.UpsertJSPackage(app); err != nil {
+		t.Fatalf("UpsertJSPackage(my-app) error = %v", err)
+	}
+
+	deps, err := db.GetJSDependencies("express")
+	if err != nil {
+		t.Fatalf("GetJSDependencies() error = %v", err)
+	}
+	if len(deps) != 2 || deps[0].DependencyName != "body-parser" || deps[0].VersionRange != "1.20.1" {
+		t.Errorf("GetJSDependencies(express) = %+v, want body-parser and cookie", deps)
+	}
+
+	dependents, total, err := db.GetJSDependents("express", 10, 0)
+	if err != nil {
+		t.Fatalf("GetJSDependents() error = %v", err)
+	}
+	if total != 1 || len(dependents) != 1 || dependents[0].Name != "my-app" {
+		t.Errorf("GetJSDependents(express) = %d packages (total %d), want my-app", len(dependents), total)
+	}
+
+	widgets := &JSPackage{Name: "react-widgets", PeerDependencies: map[string]string{"react": ">=16.8.0"}, DevDependencies: map[string]string{"jest": "^29.0.0"}}
+	if _, err := db.UpsertJSPackage(widgets); err != nil {
+		t.Fatalf("UpsertJSPackage(react-widgets) error = %v", err)
+	}
+	deps, err = db.GetJSDependencies("react-widgets")
+	if err != nil {
+		t.Fatalf("GetJSDependencies() error = %v", err)
+	}
+	types := make(map[string]string)
+	for _, dep := range deps {
+		types[dep.DependencyName] = dep.Type
+	}
+	if types["react"] != JSDepPeer || types["jest"] != JSDepDev {
+		t.Errorf("GetJSDependencies(react-widgets) types = %v, want react peer and jest dev", types)
+	}
+
+	// Re-indexing replaces the previous dependency edges
+	app.Dependencies = map[string]string{"koa": "^2.0.0"}
+	if _, err := db.UpsertJSPackage(app); err != nil {
+		t.Fatalf("UpsertJSPackage(my-app) error = %v", err)
+	}
+	count, err := db.GetJSDependentsCount("express")
+	if err != nil {
+		t.Fatalf("GetJSDependentsCount() error = %v", err)
+	}
+	if count != 0 {
+		t.Errorf("GetJSDependentsCount(express) after update = %d, want 0", count)
+	}
+}
+
+func TestJSPackageSizes(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	pkg := &JSPackage{Name: "lodash", Version: "4.17.21", Description: "Lodash modular utilities", UnpackedSize: 1412415, TarballSize: 318961}
+	if _, err := db.UpsertJSPackage(pkg); err != nil {
+		t.Fatalf("UpsertJSPackage() error = %v", err)
+	}
+
+	got, err := db.GetJSPackage("lodash")
+	if err != nil {
+		t.Fatalf("GetJSPackage() error = %v", err)
+	}
+	if got.UnpackedSize != 1412415 || got.TarballSize != 318961 {
+		t.Errorf("GetJSPackage() sizes = %d/%d, want 1412415/318961", got.UnpackedSize, got.TarballSize)
+	}
+
+	results, err := db.SearchJSPackages("lodash", 10)
+	if err != nil {
+		t.Fatalf("SearchJSPackages() error = %v", err)
+	}
+	if len(results) != 1 || results[0].UnpackedSize != 1412415 {
+		t.Errorf("SearchJSPackages() did not return package sizes: %+v", results)
+	}
+}
+
+func TestRustCrateFeatures(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	crate := &RustCrate{
+		Name:    "tokio",
+		Version: "1.40.0",
+		Features: map[string][]string{
+			"default":         nil,
+			"full":            {"macros", "rt-multi-thread"},
+			"macros":          {"tokio-macros"},
+			"rt-multi-thread": {"rt"},
+			"rt":              nil,
+		},
+	}
+	if _, err := db.UpsertRustCrate(crate); err != nil {
+		t.Fatalf("UpsertRustCrate() error = %v", err)
+	}
+
+	got, err := db.GetRustCrate("tokio")
+	if err != nil || got == nil {
+		t.Fatalf("GetRustCrate() = %v, %v", got, err)
+	}
+	if len(got.Features) != 5 || !slices.Equal(got.Features["full"], []string{"macros", "rt-multi-thread"}) {
+		t.Errorf("GetRustCrate() features = %v", got.Features)
+	}
+	if len(got.DefaultFeatures()) != 0 {
+		t.Errorf("DefaultFeatures() = %v, want none", got.DefaultFeatures())
+	}
+
+	// Reindexing replaces the features of the previous version
+	crate.Features = map[string][]string{"default": {"std"}, "std": nil}
+	if _, err := db.UpsertRustCrate(crate); err != nil {
+		t.Fatalf("UpsertRustCrate() error = %v", err)
+	}
+	got, _ = db.GetRustCrate("tokio")
+	if len(got.Features) != 2 || !slices.Equal(got.DefaultFeatures(), []string{"std"}) {
+		t.Errorf("features after reindex = %v", got.Features)
+	}
+}
+
+func TestRustCratePlatformSupport(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	for _, crate := range []*RustCrate{
+		{Name: "heapless", Version: "0.8.0", NoStd: true},
+		{Name: "wasm-bindgen", Version: "0.2.93", WASM: true},
+	} {
+		if _, err := db.UpsertRustCrate(crate); err != nil {
+			t.Fatalf("UpsertRustCrate() error = %v", err)
+		}
+	}
+
+	heapless, _ := db.GetRustCrate("heapless")
+	bindgen, _ := db.GetRustCrate("wasm-bindgen")
+	if heapless == nil || bindgen == nil {
+		t.Fatal("GetRustCrate() returned nil")
+	}
+	if !heapless.NoStd || heapless.WASM || bindgen.NoStd || !bindgen.WASM {
+		t.Errorf("no_std, wasm = %v, %v and %v, %v", heapless.NoStd, heapless.WASM, bindgen.NoStd, bindgen.WASM)
+	}
+}
+
+func TestRegistryVersions(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	versions := []*RegistryVersion{
+		{Version: "1.0.0", PublishedAt: time.Date(2017, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{Version: "1.0.100", PublishedAt: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Version: "0.0.1", Yanked: true},
+	}
+	if err := db.ReplaceRegistryVersions(EcosystemCrates, "serde", versions); err != nil {
+		t.Fatalf("ReplaceRegistryVersions() error = %v", err)
+	}
+
+	got, err := db.GetRegistryVersions(EcosystemCrates, "serde")
+	if err != nil {
+		t.Fatalf("GetRegistryVersions() error = %v", err)
+	}
+	want := []string{"1.0.100", "1.0.0", "0.0.1"}
+	if len(got) != len(want) {
+		t.Fatalf("GetRegistryVersions() returned %d versions, want %d", len(got), len(want))
+	}
+	for i, v := range want {
+		if got[i].Version != v {
+			t.Errorf("version %d = %s, want %s", i, got[i].Version, v)
+		}
+	}
+	if !got[2].PublishedAt.IsZero() {
+		t.Errorf("undated version has PublishedAt %v", got[2].PublishedAt)
+	}
+	if !got[2].Yanked || got[0].Yanked {
+		t.Errorf("yanked flags = %v/%v, want only 0.0.1 yanked", got[0].Yanked, got[2].Yanked)
+	}
+
+	// Versions are per ecosystem
+	other, err := db.GetRegistryVersions(EcosystemNPM, "serde")
+	if err != nil {
+		t.Fatalf("GetRegistryVersions() error = %v", err)
+	}
+	if len(other) != 0 {
+		t.Errorf("npm serde has %d versions, want 0", len(other))
+	}
+
+	if err := db.ReplaceRegistryVersions("maven", "serde", versions); err == nil {
+		t.Error("expected error for unknown ecosystem")
+	}
+}
+
+func TestGetImportedByCount(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	// Create library and importers
+	lib := &Package{ImportPath: "github.com/test/lib", Name: "lib", ModulePath: "github.com/test/lib"}
+	if _, err := db.UpsertPackage(lib); err != nil {
+		t.Fatalf("UpsertPackage() error = %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		importPath := filepath.Join("github.com/test", "app"+string(rune('a'+i)))
+		importer := &Package{ImportPath: importPath, Name: "app", ModulePath: importPath}
+		if _, err := db.UpsertPackage(importer); err != nil {
+			t.Fatalf("UpsertPackage() error = %v", err)
+		}
+		if err := db.AddImport(importPath, "github.com/test/lib", importPath); err != nil {
+			t.Fatalf("AddImport() error = %v", err)
+		}
+	}
+
+	count, err := db.GetImportedByCount("github.com/test/lib")
+	if err != nil {
+		t.Fatalf("GetImportedByCount() error = %v", err)
+	}
+	if count != 3 {
+		t.Errorf("GetImportedByCount() = %v, want 3", count)
+	}
+}
+
+func TestUpsertSymbol(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	// Insert package first
+	pkg := &Package{ImportPath: "github.com/test/pkg", Name: "pkg"}
+	pkgID, err := db.UpsertPackage(pkg)
+	if err != nil {
+		t.Fatalf("UpsertPackage() error = %v", err)
+	}
+
+	symbol := &Symbol{
+		Name:       "TestFunc",
+		Kind:       "func",
+		PackageID:  pkgID,
+		ImportPath: "github.com/test/pkg",
+		Synopsis:   "TestFunc does testing",
+		Deprecated: false,
+	}
+
+	err = db.UpsertSymbol(symbol)
+	if err != nil {
+		t.Fatalf("UpsertSymbol() error = %v", err)
+	}
+
+	// Update the symbol
+	symbol.Synopsis = "Updated synopsis"
+	err = db.UpsertSymbol(symbol)
+	if err != nil {
+		t.Fatalf("UpsertSymbol() update error = %v", err)
+	}
+}
+
+func TestSymbolSourcePositions(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	pkgID, err := db.UpsertPackage(&Package{ImportPath: "github.com/test/pkg", Name: "pkg"})
+	if err != nil {
+		t.Fatalf("UpsertPackage() error = %v", err)
+	}
+
+	symbol := &Symbol{
+		Name:       "Run",
+		Kind:       "func",
+		PackageID:  pkgID,
+		ImportPath: "github.com/test/pkg",
+		Filename:   "run.go",
+		Line:       12,
+		EndLine:    30,
+	}
+	if err := db.UpsertSymbol(symbol); err != nil {
+		t.Fatalf("UpsertSymbol() error = %v", err)
+	}
+
+	symbols, err := db.GetPackageSymbols(pkgID)
+	if err != nil {
+		t.Fatalf("GetPackageSymbols() error = %v", err)
+	}
+	if len(symbols) != 1 {
+		t.Fatalf("GetPackageSymbols() returned %d symbols, want 1", len(symbols))
+	}
+	got := symbols[0]
+	if got.Filename != "run.go" || got.Line != 12 || got.EndLine != 30 {
+		t.Errorf("position = %s:%d-%d, want run.go:12-30", got.Filename, got.Line, got.EndLine)
+	}
+}
+
+func TestSearchSymbols(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	// Insert package and symbols
+	pkg := &Package{ImportPath: "github.com/test/pkg", Name: "pkg"}
+	pkgID, err := db.UpsertPackage(pkg)
+	if err != nil {
+		t.Fatalf("UpsertPackage() error = %v", err)
+	}
+
+	symbols := []*Symbol{
+		{Name: "HTTPClient", Kind: "type", PackageID: pkgID, ImportPath: "github.com/test/pkg", Synopsis: "HTTP client type"},
+		{Name: "NewClient", Kind: "func", PackageID: pkgID, ImportPath: "github.com/test/pkg", Synopsis: "Creates new HTTP client"},
+		{Name: "ServerConfig", Kind: "type", PackageID: pkgID, ImportPath: "github.com/test/pkg", Synopsis: "Server configuration"},
+	}
+
+	for _, sym := range symbols {
+		if err := db.UpsertSymbol(sym); err != nil {
+			t.Fatalf("UpsertSymbol() error = %v", err)
+		}
+	}
+
+	// Test search without kind filter
+	results, err := db.SearchSymbols("http", "", 100)
+	if err != nil {
+		t.Fatalf("SearchSymbols() error = %v", err)
+	}
+	if len(results) < 1 {
+		t.Errorf("SearchSymbols() returned %v results, want at least 1", len(results))
+	}
+
+	// Test search with kind filter
+	results, err = db.SearchSymbols("client", "func", 100)
+	if err != nil {
+		t.Fatalf("SearchSymbols() with kind error = %v", err)
+	}
+	if len(results) < 1 {
+		t.Errorf("SearchSymbols() with kind returned %v results, want at least 1", len(results))
+	}
+	for _, sym := range results {
+		if sym.Kind != "func" {
+			t.Errorf("SearchSymbols() with kind='func' returned symbol with kind=%v", sym.Kind)
+		}
+	}
+}
+
+func TestSearchSymbolsByShape(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	buf := &Package{ImportPath: "example.com/buf", Name: "buf"}
+	conv := &Package{ImportPath: "example.com/conv", Name: "conv"}
+	for _, pkg := range []*Package{buf, conv} {
+		id, err := db.UpsertPackage(pkg)
+		if err != nil {
+			t.Fatalf("UpsertPackage() error = %v", err)
+		}
+		pkg.ID = id
+	}
+
+	symbols := []*Symbol{
+		{Name: "Buffer.Write", Kind: "method", PackageID: buf.ID, ImportPath: buf.ImportPath, Signature: "func (b *Buffer) Write(p []byte) (n int, err error)"},
+		{Name: "Buffer.Len", Kind: "method", PackageID: buf.ID, ImportPath: buf.ImportPath, Signature: "func (b *Buffer) Len() int"},
+		{Name: "Count", Kind: "func", PackageID: conv.ID, ImportPath: conv.ImportPath, Signature: "func Count(data []byte) (int, error)"},
+		{Name: "Itoa", Kind: "func", PackageID: conv.ID, ImportPath: conv.ImportPath, Signature: "func Itoa(i int) string"},
+		{Name: "Buffer", Kind: "type", PackageID: buf.ID, ImportPath: buf.ImportPath, Decl: "type Buffer struct{}"},
+	}
+	for _, sym := range symbols {
+		if err := db.UpsertSymbol(sym); err != nil {
+			t.Fatalf("UpsertSymbol() error = %v", err)
+		}
+	}
+
+	names := func(pattern, kind string) []string {
+		t.Helper()
+		results, err := db.SearchSymbolsByShape(pattern, kind, 10)
+		if err != nil {
+			t.Fatalf("SearchSymbolsByShape(%q) error = %v", pattern, err)
+		}
+		var names []string
+		for _, sym := range results {
+			names = append(names, sym.Name)
+		}
+		return names
+	}
+
+	if got, want := names("func([]byte) (int, error)", ""), []string{"Buffer.Write", "Count"}; !slices.Equal(got, want) {
+		t.Errorf("shape search = %v, want %v", got, want)
+	}
+	if got, want := names("func([]byte) (int, error)", "func"), []string{"Count"}; !slices.Equal(got, want) {
+		t.Errorf("shape search of funcs = %v, want %v", got, want)
+	}
+	if got, want := names("func(T) string", ""), []string{"Itoa"}; !slices.Equal(got, want) {
+		t.Errorf("wildcard shape search = %v, want %v", got, want)
+	}
+	if _, err := db.SearchSymbolsByShape("func(", "", 10); err == nil {
+		t.Error("SearchSymbolsByShape() accepted an invalid pattern")
+	}
+
+	// Symbols stored before shapes were indexed are backfilled by the migration
+	if _, err := db.conn.Exec(`UPDATE symbols SET shape = NULL`); err != nil {
+		t.Fatalf("clearing shapes: %v", err)
+	}
+	i := slices.IndexFunc(migrations, func(m migration) bool { return m.description == "function shapes" })
+	if err := migrations[i].apply(db); err != nil {
+		t.Fatalf("shape migration error = %v", err)
+	}
+	if got, want := names("func() int", ""), []string{"Buffer.Len"}; !slices.Equal(got, want) {
+		t.Errorf("shape search after backfill = %v, want %v", got, want)
+	}
+}
+
+func TestDeletePackageSymbols(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	// Insert package and symbols
+	pkg := &Package{ImportPath: "github.com/test/pkg", Name: "pkg"}
+	pkgID, err := db.UpsertPackage(pkg)
+	if err != nil {
+		t.Fatalf("UpsertPackage() error = %v", err)
+	}
+
+	symbol := &Symbol{
+		Name:       "TestFunc",
+		Kind:       "func",
+		PackageID:  pkgID,
+		ImportPath: "github.com/test/pkg",
+	}
+	if err := db.UpsertSymbol(symbol); err != nil {
+		t.Fatalf("UpsertSymbol() error = %v", err)
+	}
+
+	// Delete symbols
+	err = db.DeletePackageSymbols(pkgID)
+	if err != nil {
+		t.Fatalf("DeletePackageSymbols() error = %v", err)
+	}
+
+	// Verify deletion
+	var count int
+	err = db.conn.QueryRow("SELECT COUNT(*) FROM symbols WHERE package_id = ?", pkgID).Scan(&count)
+	if err != nil {
+		t.Fatalf("failed to count symbols: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("DeletePackageSymbols() left %v symbols, want 0", count)
+	}
+}
+
+func TestDeletePackage(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	// Insert package with symbols and imports
+	pkg := &Package{ImportPath: "github.com/test/pkg", Name: "pkg", ModulePath: "github.com/test/pkg"}
+	pkgID, err := db.UpsertPackage(pkg)
+	if err != nil {
+		t.Fatalf("UpsertPackage() error = %v", err)
+	}
+
+	symbol := &Symbol{Name: "TestFunc", Kind: "func", PackageID: pkgID, ImportPath: "github.com/test/pkg"}
+	if err := db.UpsertSymbol(symbol); err != nil {
+		t.Fatalf("UpsertSymbol() error = %v", err)
+	}
+
+	if err := db.AddImport("github.com/test/pkg", "fmt", "github.com/test/pkg"); err != nil {
+		t.Fatalf("AddImport() error = %v", err)
+	}
+
+	examples := []*Example{{Symbol: "TestFunc", Name: "TestFunc", Code: "pkg.TestFunc()"}}
+	if err := db.ReplacePackageExamples(pkgID, "github.com/test/pkg", examples); err != nil {
+		t.Fatalf("ReplacePackageExamples() error = %v", err)
+	}
+
+	// Delete package
+	err = db.DeletePackage("github.com/test/pkg")
+	if err != nil {
+		t.Fatalf("DeletePackage() error = %v", err)
+	}
+
+	// Verify package is deleted
+	retrieved, err := db.GetPackage("github.com/test/pkg")
+	if err != nil {
+		t.Fatalf("GetPackage() error = %v", err)
+	}
+	if retrieved != nil {
+		t.Error("DeletePackage() did not delete package")
+	}
+
+	// Verify symbols are deleted (CASCADE)
+	var symbolCount int
+	db.conn.QueryRow("SELECT COUNT(*) FROM symbols WHERE package_id = ?", pkgID).Scan(&symbolCount)
+	if symbolCount != 0 {
+		t.Errorf("DeletePackage() left %v symbols, want 0", symbolCount)
+	}
+
+	// Verify imports are deleted
+	var importCount int
+	db.conn.QueryRow("SELECT COUNT(*) FROM imports WHERE importer_path = ?", "github.com/test/pkg").Scan(&importCount)
+	if importCount != 0 {
+		t.Errorf("DeletePackage() left %v imports, want 0", importCount)
+	}
+
+	// Verify examples are deleted, and no longer found by search
+	var exampleCount int
+	db.conn.QueryRow("SELECT COUNT(*) FROM examples WHERE package_id = ?", pkgID).Scan(&exampleCount)
+	if exampleCount != 0 {
+		t.Errorf("DeletePackage() left %v examples, want 0", exampleCount)
+	}
+	if found, err := db.SearchExamples("TestFunc", 10); err != nil || len(found) != 0 {
+		t.Errorf("SearchExamples() = %v, %v, want no examples", found, err)
+	}
+}
+
+func TestGetStats(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	// Insert test data
+	pkg := &Package{ImportPath: "github.com/test/pkg", Name: "pkg", ModulePath: "github.com/test/pkg"}
+	pkgID, err := db.UpsertPackage(pkg)
+	if err != nil {
+		t.Fatalf("UpsertPackage() error = %v", err)
+	}
+
+	symbol := &Symbol{Name: "TestFunc", Kind: "func", PackageID: pkgID, ImportPath: "github.com/test/pkg"}
+	if err := db.UpsertSymbol(symbol); err != nil {
+		t.Fatalf("UpsertSymbol() error = %v", err)
+	}
+
+	if err := db.AddImport("github.com/test/pkg", "fmt", "github.com/test/pkg"); err != nil {
+		t.Fatalf("AddImport() error = %v", err)
+	}
+
+	// Get stats
+	packageCount, symbolCount, importCount, err := db.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats() error = %v", err)
+	}
+
+	if packageCount != 1 {
+		t.Errorf("GetStats() packageCount = %v, want 1", packageCount)
+	}
+	if symbolCount != 1 {
+		t.Errorf("GetStats() symbolCount = %v, want 1", symbolCount)
+	}
+	if importCount != 1 {
+		t.Errorf("GetStats() importCount = %v, want 1", importCount)
+	}
+}
+
+func TestCrawlMetadata(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	// Test SetLastCrawlTime and GetLastCrawlTime
+	now := time.Now().Truncate(time.Second)
+	err := db.SetLastCrawlTime(now)
+	if err != nil {
+		t.Fatalf("SetLastCrawlTime() error = %v", err)
+	}
+
+	retrieved, err := db.GetLastCrawlTime()
+	if err != nil {
+		t.Fatalf("GetLastCrawlTime() error = %v", err)
+	}
+
+	if !retrieved.Equal(now) {
+		t.Errorf("GetLastCrawlTime() = %v, want %v", retrieved, now)
+	}
+
+	// Test generic metadata
+	err = db.SetMetadata("test_key", "test_value")
+	if err != nil {
+		t.Fatalf("SetMetadata() error = %v", err)
+	}
+
+	value, err := db.GetMetadata("test_key")
+	if err != nil {
+		t.Fatalf("GetMetadata() error = %v", err)
+	}
+	if value != "test_value" {
+		t.Errorf("GetMetadata() = %v, want %v", value, "test_value")
+	}
+
+	// Test non-existent metadata
+	value, err = db.GetMetadata("nonexistent")
+	if err != nil {
+		t.Fatalf("GetMetadata(nonexistent) error = %v", err)
+	}
+	if value != "" {
+		t.Errorf("GetMetadata(nonexistent) = %v, want empty string", value)
+	}
+}
+
+func TestModuleVersions(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	modulePath := "github.com/test/module"
+
+	// Insert versions
+	versions := []*ModuleVersion{
+		{ModulePath: modulePath, Version: "v1.0.0", IsTagged: true, IsStable: true},
+		{ModulePath: modulePath, Version: "v1.1.0", IsTagged: true, IsStable: true},
+		{ModulePath: modulePath, Version: "v0.9.0", IsTagged: true, IsStable: false},
+	}
+
+	for _, mv := range versions {
+		if err := db.UpsertModuleVersion(mv); err != nil {
+			t.Fatalf("UpsertModuleVersion() error = %v", err)
+		}
+	}
+
+	// Test GetModuleVersions
+	retrieved, err := db.GetModuleVersions(modulePath)
+	if err != nil {
+		t.Fatalf("GetModuleVersions() error = %v", err)
+	}
+	if len(retrieved) != 3 {
+		t.Errorf("GetModuleVersions() returned %v versions, want 3", len(retrieved))
+	}
+
+	// Test GetModuleVersion
+	mv, err := db.GetModuleVersion(modulePath, "v1.0.0")
+	if err != nil {
+		t.Fatalf("GetModuleVersion() error = %v", err)
+	}
+	if mv == nil {
+		t.Fatal("GetModuleVersion() returned nil")
+	}
+	if mv.Version != "v1.0.0" {
+		t.Errorf("GetModuleVersion() version = %v, want v1.0.0", mv.Version)
+	}
+
+	// Test GetLatestModuleVersion
+	latest, err := db.GetLatestModuleVersion(modulePath)
+	if err != nil {
+		t.Fatalf("GetLatestModuleVersion() error = %v", err)
+	}
+	if latest == nil {
+		t.Fatal("GetLatestModuleVersion() returned nil")
+	}
+	// Should prefer stable versions
+	if !latest.IsStable {
+		t.Errorf("GetLatestModuleVersion() returned unstable version %v", latest.Version)
+	}
+
+	// Test CountModuleVersions
+	count, err := db.CountModuleVersions(modulePath)
+	if err != nil {
+		t.Fatalf("CountModuleVersions() error = %v", err)
+	}
+	if count != 3 {
+		t.Errorf("CountModuleVersions() = %v, want 3", count)
+	}
+}
+
+func TestUpsertModuleVersion_Update(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	mv := &ModuleVersion{
+		ModulePath: "github.com/test/module",
+		Version:    "v1.0.0",
+		IsTagged:   true,
+		IsStable:   false,
+	}
+
+	if err := db.UpsertModuleVersion(mv); err != nil {
+		t.Fatalf("UpsertModuleVersion() insert error = %v", err)
+	}
+
+	// Update
+	mv.IsStable = true
+	if err := db.UpsertModuleVersion(mv); err != nil {
+		t.Fatalf("UpsertModuleVersion() update error = %v", err)
+	}
+
+	// Verify
+	retrieved, err := db.GetModuleVersion(mv.ModulePath, mv.Version)
+	if err != nil {
+		t.Fatalf("GetModuleVersion() error = %v", err)
+	}
+	if !retrieved.IsStable {
+		t.Error("UpsertModuleVersion() did not update IsStable")
+	}
+}
+
+func TestGetEmbeddingHashes(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if err := db.UpsertEmbedding("github.com/test/a", "go", "hash-a", []float32{0.1, 0.2}); err != nil {
+		t.Fatalf("UpsertEmbedding() error = %v", err)
+	}
+	if err := db.UpsertEmbedding("github.com/test/b", "go", "hash-b", []float32{0.3, 0.4}); err != nil {
+		t.Fatalf("UpsertEmbedding() error = %v", err)
+	}
+	if err := db.UpsertEmbedding("left-pad", "js", "hash-js", []float32{0.5}); err != nil {
+		t.Fatalf("UpsertEmbedding() error = %v", err)
+	}
+
+	hashes, err := db.GetEmbeddingHashes("go")
+	if err != nil {
+		t.Fatalf("GetEmbeddingHashes() error = %v", err)
+	}
+	if len(hashes) != 2 {
+		t.Errorf("GetEmbeddingHashes() returned %d hashes, want 2", len(hashes))
+	}
+	if hashes["github.com/test/a"] != "hash-a" {
+		t.Errorf("GetEmbeddingHashes()[a] = %q, want hash-a", hashes["github.com/test/a"])
+	}
+}
+
+func TestEmbeddingIndex(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	idx, err := db.GetEmbeddingIndex("go")
+	if err != nil {
+		t.Fatalf("GetEmbeddingIndex() error = %v", err)
+	}
+	if idx != nil {
+		t.Fatal("GetEmbeddingIndex() expected nil before any index is stored")
+	}
+
+	stored := &EmbeddingIndex{
+		Lang:           "go",
+		Dim:            2,
+		EmbeddingCount: 3,
+		Centroids:      [][]float32{{1, 0}, {0, 1}},
+		Assignments:    map[string]int{"a": 0, "b": 1, "c": 1},
+	}
+	if err := db.UpsertEmbeddingIndex(stored); err != nil {
+		t.Fatalf("UpsertEmbeddingIndex() error = %v", err)
+	}
+
+	idx, err = db.GetEmbeddingIndex("go")
+	if err != nil {
+		t.Fatalf("GetEmbeddingIndex() error = %v", err)
+	}
+	if idx == nil {
+		t.Fatal("GetEmbeddingIndex() returned nil")
+	}
+	if len(idx.Centroids) != 2 || idx.Centroids[1][1] != 1 {
+		t.Errorf("GetEmbeddingIndex() centroids = %v", idx.Centroids)
+	}
+	if idx.Assignments["c"] != 1 || idx.EmbeddingCount != 3 {
+		t.Errorf("GetEmbeddingIndex() = %+v", idx)
+	}
+}
 
-	retrieved, err := db.GetLastCrawlTime()
+func TestSearchPackages_ExcludesClassified(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if _, err := db.UpsertPackage(&Package{ImportPath: "github.com/test/widgets", Name: "widgets", Synopsis: "Widget library"}); err != nil {
+		t.Fatalf("UpsertPackage() error = %v", err)
+	}
+	if _, err := db.UpsertPackage(&Package{ImportPath: "github.com/test/widgetstest", Name: "widgetstest", Synopsis: "Widget test helpers", Classification: "test-only"}); err != nil {
+		t.Fatalf("UpsertPackage() error = %v", err)
+	}
+
+	results, err := db.SearchPackages("widget*", 10, false)
 	if err != nil {
-		t.Fatalf("GetLastCrawlTime() error = %v", err)
+		t.Fatalf("SearchPackages() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Name != "widgets" {
+		t.Errorf("SearchPackages() = %v, want only widgets", results)
 	}
 
-	if !retrieved.Equal(now) {
-		t.Errorf("GetLastCrawlTime() = %v, want %v", retrieved, now)
+	pkg, err := db.GetPackage("github.com/test/widgetstest")
+	if err != nil {
+		t.Fatalf("GetPackage() error = %v", err)
 	}
+	if pkg.Classification != "test-only" {
+		t.Errorf("GetPackage() classification = %q, want test-only", pkg.Classification)
+	}
+}
 
-	// Test generic metadata
-	err = db.SetMetadata("test_key", "test_value")
+func TestSearchPackages_ExcludeExperimental(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	// Experimental packages come first, so filtering after the LIMIT would drop every result
+	for _, pkg := range []*Package{
+		{ImportPath: "golang.org/x/exp/gears", Name: "gears", Synopsis: "Gear tools", Experimental: true},
+		{ImportPath: "github.com/test/gears/v2", Name: "gears", Synopsis: "Gear tools", Experimental: true},
+		{ImportPath: "github.com/test/gears", Name: "gears", Synopsis: "Gear tools"},
+	} {
+		if _, err := db.UpsertPackage(pkg); err != nil {
+			t.Fatalf("UpsertPackage(%s) error = %v", pkg.ImportPath, err)
+		}
+	}
+
+	results, err := db.SearchPackages("gears", 2, true)
 	if err != nil {
-		t.Fatalf("SetMetadata() error = %v", err)
+		t.Fatalf("SearchPackages() error = %v", err)
 	}
+	if len(results) != 1 || results[0].ImportPath != "github.com/test/gears" {
+		t.Errorf("SearchPackages(excludeExperimental) = %v, want only github.com/test/gears", results)
+	}
+	if results, _ := db.SearchPackages("gears", 10, false); len(results) != 3 {
+		t.Errorf("SearchPackages() = %d results, want 3", len(results))
+	}
+}
 
-	value, err := db.GetMetadata("test_key")
+func TestEachSearchPackage_DeprecatedLast(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	for _, pkg := range []*Package{
+		{ImportPath: "github.com/test/old/bolts", Name: "bolts", Synopsis: "Bolt tools", Deprecated: true},
+		{ImportPath: "github.com/test/bolts", Name: "bolts", Synopsis: "Bolt tools"},
+		{ImportPath: "github.com/test/older/bolts", Name: "bolts", Synopsis: "Bolt tools", Deprecated: true},
+		{ImportPath: "github.com/test/bolts/v2", Name: "bolts", Synopsis: "Bolt tools"},
+	} {
+		if _, err := db.UpsertPackage(pkg); err != nil {
+			t.Fatalf("UpsertPackage(%s) error = %v", pkg.ImportPath, err)
+		}
+	}
+
+	search := func(limit int) []string {
+		var paths []string
+		err := db.EachSearchPackage("bolts", limit, false, func(pkg *Package) error {
+			paths = append(paths, pkg.ImportPath)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("EachSearchPackage() error = %v", err)
+		}
+		return paths
+	}
+	want := []string{"github.com/test/bolts", "github.com/test/bolts/v2", "github.com/test/old/bolts", "github.com/test/older/bolts"}
+	if got := search(10); !slices.Equal(got, want) {
+		t.Errorf("EachSearchPackage() = %v, want %v", got, want)
+	}
+	if got := search(3); !slices.Equal(got, want[:3]) {
+		t.Errorf("EachSearchPackage(limit 3) = %v, want %v", got, want[:3])
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err := db.EachSearchPackage("bolts", 10, false, func(*Package) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("EachSearchPackage() = %v after %d calls, want the callback's error after 1", err, calls)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	err := db.Batch(func(tx *DB) error {
+		for _, path := range []string{"github.com/test/a", "github.com/test/b"} {
+			if _, err := tx.UpsertPackage(&Package{ImportPath: path, Name: "pkg"}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		t.Fatalf("GetMetadata() error = %v", err)
+		t.Fatalf("Batch() error = %v", err)
 	}
-	if value != "test_value" {
-		t.Errorf("GetMetadata() = %v, want %v", value, "test_value")
+
+	// A failing batch is rolled back
+	err = db.Batch(func(tx *DB) error {
+		if _, err := tx.UpsertPackage(&Package{ImportPath: "github.com/test/c", Name: "c"}); err != nil {
+			return err
+		}
+		return os.ErrInvalid
+	})
+	if err != os.ErrInvalid {
+		t.Fatalf("Batch() error = %v, want %v", err, os.ErrInvalid)
 	}
 
-	// Test non-existent metadata
-	value, err = db.GetMetadata("nonexistent")
+	pkgCount, _, _, err := db.GetStats()
 	if err != nil {
-		t.Fatalf("GetMetadata(nonexistent) error = %v", err)
+		t.Fatalf("GetStats() error = %v", err)
 	}
-	if value != "" {
-		t.Errorf("GetMetadata(nonexistent) = %v, want empty string", value)
+	if pkgCount != 2 {
+		t.Errorf("package count = %d, want 2", pkgCount)
 	}
 }
 
-func TestModuleVersions(t *testing.T) {
+func TestOpenReadOnly(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "primary.db")
+
+	primary, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := primary.UpsertPackage(&Package{ImportPath: "github.com/test/pkg", Name: "pkg"}); err != nil {
+		t.Fatalf("UpsertPackage() error = %v", err)
+	}
+
+	replica, err := OpenReadOnly(dbPath)
+	if err != nil {
+		t.Fatalf("OpenReadOnly() error = %v", err)
+	}
+	defer replica.Close()
+
+	if !replica.ReadOnly() {
+		t.Error("ReadOnly() = false, want true")
+	}
+
+	pkg, err := replica.GetPackage("github.com/test/pkg")
+	if err != nil {
+		t.Fatalf("GetPackage() error = %v", err)
+	}
+	if pkg == nil {
+		t.Fatal("GetPackage() returned nil on replica")
+	}
+
+	if _, err := replica.UpsertPackage(&Package{ImportPath: "github.com/test/other", Name: "other"}); err == nil {
+		t.Error("UpsertPackage() on read-only database should fail")
+	}
+
+	// The primary can still write while the replica is open
+	if _, err := primary.UpsertPackage(&Package{ImportPath: "github.com/test/other", Name: "other"}); err != nil {
+		t.Errorf("UpsertPackage() on primary error = %v", err)
+	}
+	primary.Close()
+
+	if _, err := OpenReadOnly(filepath.Join(t.TempDir(), "missing.db")); err == nil {
+		t.Error("OpenReadOnly() on missing file should fail")
+	}
+}
+
+func TestGetSymbolsByImportPath(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	modulePath := "github.com/test/module"
+	pkgID, err := db.UpsertPackage(&Package{ImportPath: "github.com/test/pkg", Name: "pkg"})
+	if err != nil {
+		t.Fatalf("UpsertPackage() error = %v", err)
+	}
+	for _, name := range []string{"Alpha", "Beta"} {
+		sym := &Symbol{Name: name, Kind: "func", PackageID: pkgID, ImportPath: "github.com/test/pkg"}
+		if err := db.UpsertSymbol(sym); err != nil {
+			t.Fatalf("UpsertSymbol() error = %v", err)
+		}
+	}
 
-	// Insert versions
-	versions := []*ModuleVersion{
-		{ModulePath: modulePath, Version: "v1.0.0", IsTagged: true, IsStable: true},
-		{ModulePath: modulePath, Version: "v1.1.0", IsTagged: true, IsStable: true},
-		{ModulePath: modulePath, Version: "v0.9.0", IsTagged: true, IsStable: false},
+	symbols, err := db.GetSymbolsByImportPath("github.com/test/pkg")
+	if err != nil {
+		t.Fatalf("GetSymbolsByImportPath() error = %v", err)
+	}
+	if len(symbols) != 2 {
+		t.Errorf("GetSymbolsByImportPath() returned %d symbols, want 2", len(symbols))
 	}
 
-	for _, mv := range versions {
-		if err := db.UpsertModuleVersion(mv); err != nil {
-			t.Fatalf("UpsertModuleVersion() error = %v", err)
+	// The lookup should use the import_path index rather than a full scan
+	rows, err := db.conn.Query("EXPLAIN QUERY PLAN SELECT id FROM symbols WHERE import_path = ?", "github.com/test/pkg")
+	if err != nil {
+		t.Fatalf("EXPLAIN QUERY PLAN error = %v", err)
+	}
+	defer rows.Close()
+
+	var plan []string
+	for rows.Next() {
+		var id, parent, notUsed int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			t.Fatalf("scanning query plan: %v", err)
 		}
+		plan = append(plan, detail)
 	}
+	if !strings.Contains(strings.Join(plan, "\n"), "idx_symbols_import_path") {
+		t.Errorf("query plan does not use idx_symbols_import_path: %v", plan)
+	}
+}
 
-	// Test GetModuleVersions
-	retrieved, err := db.GetModuleVersions(modulePath)
+func TestMigrate_UpgradesExistingDatabase(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+
+	// Simulate a database created before schema versioning and newer columns
+	conn, err := sql.Open("sqlite3", dbPath)
 	if err != nil {
-		t.Fatalf("GetModuleVersions() error = %v", err)
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	_, err = conn.Exec(`CREATE TABLE packages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		import_path TEXT UNIQUE NOT NULL,
+		name TEXT NOT NULL,
+		synopsis TEXT,
+		doc TEXT,
+		version TEXT,
+		versions_json TEXT,
+		is_tagged INTEGER DEFAULT 0,
+		is_stable INTEGER DEFAULT 0,
+		license TEXT,
+		license_text TEXT,
+		redistributable INTEGER DEFAULT 0,
+		repository TEXT,
+		has_valid_mod INTEGER DEFAULT 0,
+		go_version TEXT,
+		module_path TEXT,
+		gomod_content TEXT,
+		goos_json TEXT,
+		goarch_json TEXT,
+		doc_json TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		indexed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		t.Fatalf("creating old schema: %v", err)
 	}
-	if len(retrieved) != 3 {
-		t.Errorf("GetModuleVersions() returned %v versions, want 3", len(retrieved))
+	if _, err := conn.Exec(`INSERT INTO packages (import_path, name, synopsis, doc, version, license, license_text, repository, go_version, module_path, gomod_content)
+		VALUES ('github.com/test/old', 'old', 'Old package', '', '', '', '', '', '', '', '')`); err != nil {
+		t.Fatalf("inserting old row: %v", err)
 	}
+	conn.Close()
 
-	// Test GetModuleVersion
-	mv, err := db.GetModuleVersion(modulePath, "v1.0.0")
+	db, err := Open(dbPath)
 	if err != nil {
-		t.Fatalf("GetModuleVersion() error = %v", err)
+		t.Fatalf("Open() error = %v", err)
 	}
-	if mv == nil {
-		t.Fatal("GetModuleVersion() returned nil")
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		t.Fatalf("SchemaVersion() error = %v", err)
 	}
-	if mv.Version != "v1.0.0" {
-		t.Errorf("GetModuleVersion() version = %v, want v1.0.0", mv.Version)
+	if want := migrations[len(migrations)-1].version; version != want {
+		t.Errorf("SchemaVersion() = %d, want %d", version, want)
 	}
 
-	// Test GetLatestModuleVersion
-	latest, err := db.GetLatestModuleVersion(modulePath)
+	pkg, err := db.GetPackage("github.com/test/old")
 	if err != nil {
-		t.Fatalf("GetLatestModuleVersion() error = %v", err)
+		t.Fatalf("GetPackage() error = %v", err)
 	}
-	if latest == nil {
-		t.Fatal("GetLatestModuleVersion() returned nil")
+	if pkg == nil || pkg.Synopsis != "Old package" {
+		t.Fatalf("GetPackage() = %+v, want existing row preserved", pkg)
 	}
-	// Should prefer stable versions
-	if !latest.IsStable {
-		t.Errorf("GetLatestModuleVersion() returned unstable version %v", latest.Version)
+
+	// New columns are writable after the upgrade
+	pkg.Classification = "test-only"
+	if _, err := db.UpsertPackage(pkg); err != nil {
+		t.Fatalf("UpsertPackage() error = %v", err)
 	}
+	db.Close()
 
-	// Test CountModuleVersions
-	count, err := db.CountModuleVersions(modulePath)
+	// Reopening applies nothing new
+	db, err = Open(dbPath)
 	if err != nil {
-		t.Fatalf("CountModuleVersions() error = %v", err)
+		t.Fatalf("reopen error = %v", err)
 	}
-	if count != 3 {
-		t.Errorf("CountModuleVersions() = %v, want 3", count)
+	defer db.Close()
+
+	var applied int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&applied); err != nil {
+		t.Fatalf("counting migrations: %v", err)
+	}
+	if applied != len(migrations) {
+		t.Errorf("schema_migrations has %d rows, want %d", applied, len(migrations))
 	}
 }
 
-func TestUpsertModuleVersion_Update(t *testing.T) {
+func TestRebuildFTS(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	mv := &ModuleVersion{
-		ModulePath: "github.com/test/module",
-		Version:    "v1.0.0",
-		IsTagged:   true,
-		IsStable:   false,
+	id, err := db.UpsertPackage(&Package{ImportPath: "github.com/test/drift", Name: "drift", Synopsis: "Drifting package"})
+	if err != nil {
+		t.Fatalf("UpsertPackage() error = %v", err)
+	}
+	if _, err := db.UpsertJSPackage(&JSPackage{Name: "drift-js", Description: "Drifting package"}); err != nil {
+		t.Fatalf("UpsertJSPackage() error = %v", err)
+	}
+	if _, err := db.UpsertPythonPackage(&PythonPackage{Name: "drift-py", Summary: "Drifting package"}); err != nil {
+		t.Fatalf("UpsertPythonPackage() error = %v", err)
 	}
 
-	if err := db.UpsertModuleVersion(mv); err != nil {
-		t.Fatalf("UpsertModuleVersion() insert error = %v", err)
+	// Simulate drift: rows the index lost, as if written with the triggers missing
+	for _, stmt := range []string{
+		`DELETE FROM packages_fts WHERE docid = ` + fmt.Sprint(id),
+		`DELETE FROM js_packages_fts`,
+	} {
+		if _, err := db.conn.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
 	}
 
-	// Update
-	mv.IsStable = true
-	if err := db.UpsertModuleVersion(mv); err != nil {
-		t.Fatalf("UpsertModuleVersion() update error = %v", err)
+	deltas := func() map[string]int {
+		t.Helper()
+		counts, err := db.CheckFTS()
+		if err != nil {
+			t.Fatalf("CheckFTS() error = %v", err)
+		}
+		got := make(map[string]int)
+		for _, c := range counts {
+			if c.Delta() != 0 {
+				got[c.Table] = c.Delta()
+			}
+		}
+		return got
+	}
+	if got := deltas(); len(got) != 2 || got["packages_fts"] != 1 || got["js_packages_fts"] != 1 {
+		t.Errorf("CheckFTS() deltas = %v, want packages_fts and js_packages_fts off by one", got)
+	}
+	if results, _ := db.SearchPackages("drifting", 10, false); len(results) != 0 {
+		t.Fatalf("SearchPackages() found %d packages missing from the index", len(results))
 	}
 
-	// Verify
-	retrieved, err := db.GetModuleVersion(mv.ModulePath, mv.Version)
+	if err := db.RebuildFTS(); err != nil {
+		t.Fatalf("RebuildFTS() error = %v", err)
+	}
+	if got := deltas(); len(got) != 0 {
+		t.Errorf("CheckFTS() deltas after rebuild = %v, want none", got)
+	}
+	if results, _ := db.SearchPackages("drifting", 10, false); len(results) != 1 {
+		t.Errorf("SearchPackages() after rebuild = %d results, want 1", len(results))
+	}
+	if results, _ := db.SearchJSPackages("drifting", 10); len(results) != 1 {
+		t.Errorf("SearchJSPackages() after rebuild = %d results, want 1", len(results))
+	}
+
+	// Reindexing a Python package updates its standalone index
+	if _, err := db.UpsertPythonPackage(&PythonPackage{Name: "drift-py", Summary: "Anchored package"}); err != nil {
+		t.Fatalf("UpsertPythonPackage() update error = %v", err)
+	}
+	if results, _ := db.SearchPythonPackages("anchored", 10); len(results) != 1 {
+		t.Errorf("SearchPythonPackages() after update = %d results, want 1", len(results))
+	}
+}
+
+func TestModulePackageQuality(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	id, err := db.UpsertPackage(&Package{ImportPath: "example.com/m/buf", Name: "buf", ModulePath: "example.com/m"})
 	if err != nil {
-		t.Fatalf("GetModuleVersion() error = %v", err)
+		t.Fatalf("UpsertPackage() error = %v", err)
 	}
-	if !retrieved.IsStable {
-		t.Error("UpsertModuleVersion() did not update IsStable")
+	otherID, err := db.UpsertPackage(&Package{ImportPath: "example.com/other", Name: "other", ModulePath: "example.com/other"})
+	if err != nil {
+		t.Fatalf("UpsertPackage() error = %v", err)
+	}
+
+	symbols := []*Symbol{
+		{Name: "Buffer", Kind: "type", PackageID: id, ImportPath: "example.com/m/buf"},
+		{Name: "Buffer.Len", Kind: "method", PackageID: id, ImportPath: "example.com/m/buf"},
+		{Name: "Copy", Kind: "func", PackageID: id, ImportPath: "example.com/m/buf", Deprecated: true},
+		{Name: "MinRead", Kind: "const", PackageID: id, ImportPath: "example.com/m/buf", Deprecated: true},
+		{Name: "Other", Kind: "func", PackageID: otherID, ImportPath: "example.com/other"},
+	}
+	for _, sym := range symbols {
+		if err := db.UpsertSymbol(sym); err != nil {
+			t.Fatalf("UpsertSymbol() error = %v", err)
+		}
+	}
+	examples := []*Example{
+		{Symbol: "Buffer.Len", Name: "Buffer_Len"},
+		{Symbol: "", Name: ""},
+	}
+	if err := db.ReplacePackageExamples(id, "example.com/m/buf", examples); err != nil {
+		t.Fatalf("ReplacePackageExamples() error = %v", err)
+	}
+
+	quality, err := db.ModulePackageQuality("example.com/m")
+	if err != nil {
+		t.Fatalf("ModulePackageQuality() error = %v", err)
+	}
+	if len(quality) != 1 {
+		t.Fatalf("ModulePackageQuality() = %v, want only example.com/m/buf", quality)
+	}
+	want := PackageQuality{ImportPath: "example.com/m/buf", Symbols: 4, Deprecated: 2, Documented: 3, WithExample: 1}
+	if got := quality["example.com/m/buf"]; got == nil || *got != want {
+		t.Errorf("ModulePackageQuality() = %+v, want %+v", got, want)
+	}
+}
+
+func TestBrowsePackages(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	for _, pkg := range []*Package{
+		{ImportPath: "net/http", Name: "http"},
+		{ImportPath: "github.com/hashicorp/raft", Name: "raft"},
+		{ImportPath: "github.com/gorilla/mux", Name: "mux"},
+		{ImportPath: "golang.org/x/mod/module", Name: "module"},
+		{ImportPath: "hash/crc32", Name: "crc32"},
+		{ImportPath: "html/template", Name: "template"},
+		{ImportPath: "hash/crc32_test", Name: "crc32_test", Classification: "test-only"},
+	} {
+		if _, err := db.UpsertPackage(pkg); err != nil {
+			t.Fatalf("UpsertPackage(%s) error = %v", pkg.ImportPath, err)
+		}
+	}
+
+	tests := []struct {
+		prefix        string
+		limit, offset int
+		want          []string
+		wantTotal     int
+	}{
+		{"", 10, 0, []string{"github.com/gorilla/mux", "github.com/hashicorp/raft", "golang.org/x/mod/module", "hash/crc32", "html/template", "net/http"}, 6},
+		{"h", 10, 0, []string{"hash/crc32", "html/template"}, 2},
+		{"g", 1, 1, []string{"github.com/hashicorp/raft"}, 3},
+		{"github.com/h", 10, 0, []string{"github.com/hashicorp/raft"}, 1},
+		{"H", 10, 0, nil, 0}, // import paths are matched case-sensitively
+		{"*", 10, 0, nil, 0}, // wildcards match literally
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			packages, total, err := db.BrowsePackages(tt.prefix, tt.limit, tt.offset)
+			if err != nil {
+				t.Fatalf("BrowsePackages() error = %v", err)
+			}
+			var got []string
+			for _, pkg := range packages {
+				got = append(got, pkg.ImportPath)
+			}
+			if !slices.Equal(got, tt.want) || total != tt.wantTotal {
+				t.Errorf("BrowsePackages(%q) = %v, %d; want %v, %d", tt.prefix, got, total, tt.want, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestGetRecentPackages(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	for _, pkg := range []*Package{
+		{ImportPath: "example.com/old", Name: "old"},
+		{ImportPath: "example.com/new", Name: "new"},
+		{ImportPath: "example.com/newer", Name: "newer"},
+		{ImportPath: "example.com/newer_test", Name: "newer_test", Classification: "test-only"},
+	} {
+		if _, err := db.UpsertPackage(pkg); err != nil {
+			t.Fatalf("UpsertPackage(%s) error = %v", pkg.ImportPath, err)
+		}
+	}
+	now := time.Now()
+	for path, indexedAt := range map[string]time.Time{
+		"example.com/old":   now.Add(-30 * 24 * time.Hour),
+		"example.com/new":   now.Add(-2 * time.Hour),
+		"example.com/newer": now.Add(-time.Hour),
+	} {
+		if _, err := db.conn.Exec(`UPDATE packages SET indexed_at = ? WHERE import_path = ?`, sqliteTime(indexedAt), path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	packages, err := db.GetRecentPackages(now.Add(-7*24*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("GetRecentPackages() error = %v", err)
+	}
+	var got []string
+	for _, pkg := range packages {
+		got = append(got, pkg.ImportPath)
+	}
+	if want := []string{"example.com/newer", "example.com/new"}; !slices.Equal(got, want) {
+		t.Errorf("GetRecentPackages() = %v, want %v", got, want)
+	}
+	if len(packages) > 0 && now.Sub(packages[0].IndexedAt).Round(time.Minute) != time.Hour {
+		t.Errorf("IndexedAt = %v, want an hour ago", packages[0].IndexedAt)
+	}
+
+	if packages, err := db.GetRecentPackages(now.Add(-7*24*time.Hour), 1); err != nil || len(packages) != 1 {
+		t.Errorf("GetRecentPackages(limit 1) = %d packages, %v; want 1", len(packages), err)
+	}
+}
+
+func TestGetRecentRustCrates(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	for _, crate := range []*RustCrate{
+		{Name: "serde", Version: "1.0.0", Downloads: 1000},
+		{Name: "fresh", Version: "0.1.0", Downloads: 5},
+	} {
+		if _, err := db.UpsertRustCrate(crate); err != nil {
+			t.Fatalf("UpsertRustCrate(%s) error = %v", crate.Name, err)
+		}
+	}
+	if _, err := db.conn.Exec(`UPDATE rust_crates SET indexed_at = ? WHERE name = 'serde'`, sqliteTime(time.Now().Add(-30*24*time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+
+	recent, err := db.GetRecentRustCrates(time.Now().Add(-7*24*time.Hour), 8)
+	if err != nil || len(recent) != 1 || recent[0].Name != "fresh" {
+		t.Errorf("GetRecentRustCrates() = %v, %v; want only fresh", recent, err)
+	}
+	popular, err := db.GetPopularRustCrates(8)
+	if err != nil || len(popular) != 2 || popular[0].Name != "serde" {
+		t.Errorf("GetPopularRustCrates() = %v, %v; want serde first", popular, err)
 	}
 }
@@ -2,12 +2,14 @@ module github.com/alexisbouchez/wikigo
 
 go 1.25.0
 
-require golang.org/x/tools v0.40.0
+require (
+	golang.org/x/mod v0.31.0
+	golang.org/x/tools v0.40.0
+)
 
 require (
 	github.com/evanw/esbuild v0.27.2 // indirect
 	github.com/mattn/go-sqlite3 v1.14.32 // indirect
-	golang.org/x/mod v0.31.0 // indirect
 	golang.org/x/sync v0.19.0 // indirect
 	golang.org/x/sys v0.39.0 // indirect
 )
@@ -23,6 +23,21 @@ type Symbol struct {
 	FilePath  string
 }
 
+// DefaultExtensions are the file extensions parsed as JavaScript or TypeScript,
+// including ES modules (.mjs, .mts), CommonJS (.cjs, .cts) and type declarations
+var DefaultExtensions = []string{".js", ".mjs", ".cjs", ".jsx", ".ts", ".mts", ".cts", ".tsx", ".d.ts"}
+
+// HasExtension reports whether a file name ends in one of exts, which may be
+// compound like .d.ts
+func HasExtension(name string, exts []string) bool {
+	for _, ext := range exts {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
 // Parser handles JavaScript/TypeScript file parsing
 type Parser struct {
 	fset *token.FileSet
@@ -64,7 +79,7 @@ func (p *Parser) ParseFile(filePath string) ([]Symbol, error) {
 func (p *Parser) getLoader(filePath string) esbuild.Loader {
 	ext := filepath.Ext(filePath)
 	switch ext {
-	case ".ts":
+	case ".ts", ".mts", ".cts": // including .d.ts
 		return esbuild.LoaderTS
 	case ".tsx":
 		return esbuild.LoaderTSX
@@ -81,10 +96,10 @@ func (p *Parser) extractSymbols(content, filePath string) []Symbol {
 	lines := strings.Split(content, "\n")
 
 	for i, line := range lines {
-		line = strings.TrimSpace(line)
+		line = normalizeExport(strings.TrimSpace(line))
 
 		// ES6 Export function
-		if strings.HasPrefix(line, "export function ") || strings.HasPrefix(line, "export async function ") {
+		if strings.HasPrefix(line, "export function") || strings.HasPrefix(line, "export async function") {
 			name := p.extractFunctionName(line)
 			if name != "" {
 				symbols = append(symbols, Symbol{
@@ -97,8 +112,21 @@ func (p *Parser) extractSymbols(content, filePath string) []Symbol {
 			}
 		}
 
+		// ES6 Export list: export { a, b as c } or export { a } from "./a"
+		if strings.HasPrefix(line, "export {") {
+			for _, name := range extractExportList(line) {
+				symbols = append(symbols, Symbol{
+					Name:     name,
+					Kind:     "const",
+					Line:     i + 1,
+					Exported: true,
+					FilePath: filePath,
+				})
+			}
+		}
+
 		// ES6 Export const/let arrow functions
-		if strings.HasPrefix(line, "export const ") || strings.HasPrefix(line, "export let ") {
+		if strings.HasPrefix(line, "export const ") || strings.HasPrefix(line, "export let ") || strings.HasPrefix(line, "export var ") {
 			if strings.Contains(line, "=>") || strings.Contains(line, "= function") {
 				name := p.extractConstName(line)
 				if name != "" {
@@ -256,11 +284,54 @@ func (p *Parser) extractSymbols(content, filePath string) []Symbol {
 	return symbols
 }
 
+// normalizeExport rewrites the export forms of ES modules and declaration
+// files to plain exports: "export default function f" and "export declare
+// function f" both become "export function f"
+func normalizeExport(line string) string {
+	rest, ok := strings.CutPrefix(line, "export ")
+	if !ok {
+		return line
+	}
+	rest = strings.TrimPrefix(rest, "declare ")
+	rest = strings.TrimPrefix(rest, "default ")
+	return "export " + rest
+}
+
+// extractExportList returns the exported names of an export list, using the
+// alias of "a as b", and skipping default and type-only markers
+func extractExportList(line string) []string {
+	start, end := strings.Index(line, "{"), strings.Index(line, "}")
+	if start == -1 || end < start {
+		return nil
+	}
+	var names []string
+	for _, item := range strings.Split(line[start+1:end], ",") {
+		fields := strings.Fields(item)
+		if len(fields) > 0 && fields[0] == "type" {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		name := fields[len(fields)-1]
+		if name != "default" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 func (p *Parser) extractFunctionName(line string) string {
 	line = strings.TrimPrefix(line, "export ")
 	line = strings.TrimPrefix(line, "async ")
-	line = strings.TrimPrefix(line, "function ")
-	parts := strings.Split(line, "(")
+	line = strings.TrimPrefix(line, "function")
+	line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "*")) // generators
+	if strings.HasPrefix(line, "(") {
+		return "" // anonymous default export
+	}
+	parts := strings.FieldsFunc(line, func(r rune) bool {
+		return r == ' ' || r == '(' || r == '<'
+	})
 	if len(parts) > 0 {
 		return strings.TrimSpace(parts[0])
 	}
@@ -271,7 +342,10 @@ func (p *Parser) extractConstName(line string) string {
 	line = strings.TrimPrefix(line, "export ")
 	line = strings.TrimPrefix(line, "const ")
 	line = strings.TrimPrefix(line, "let ")
-	parts := strings.Split(line, "=")
+	line = strings.TrimPrefix(line, "var ")
+	parts := strings.FieldsFunc(line, func(r rune) bool {
+		return r == '=' || r == ';'
+	})
 	if len(parts) > 0 {
 		name := strings.TrimSpace(parts[0])
 		// Remove type annotation if present
@@ -290,7 +364,7 @@ func (p *Parser) extractClassName(line string) string {
 	parts := strings.FieldsFunc(line, func(r rune) bool {
 		return r == ' ' || r == '{' || r == '<' || r == '('
 	})
-	if len(parts) > 0 {
+	if len(parts) > 0 && parts[0] != "extends" && parts[0] != "implements" {
 		return strings.TrimSpace(parts[0])
 	}
 	return ""
@@ -3,6 +3,7 @@ package jsparser
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -122,6 +123,106 @@ export class Person {
 	}
 }
 
+func TestParseModuleFiles(t *testing.T) {
+	tests := []struct {
+		file    string
+		content string
+		want    map[string]string
+	}{
+		{
+			file: "index.mjs",
+			content: `
+import { join } from "node:path";
+
+export default function createServer(options) {}
+export async function* entries() {}
+export var version = "1.0.0";
+const parse = (s) => s;
+const format = (v) => String(v);
+export { parse, format as stringify };
+`,
+			want: map[string]string{
+				"createServer": "function",
+				"entries":      "function",
+				"version":      "const",
+				"parse":        "const",
+				"stringify":    "const",
+			},
+		},
+		{
+			file:    "anonymous.mjs",
+			content: "export default function (req, res) {}\n",
+			want:    map[string]string{},
+		},
+		{
+			file: "index.cjs",
+			content: `
+exports.parse = function (s) { return s; };
+module.exports.VERSION = "1.0.0";
+`,
+			want: map[string]string{"parse": "function", "VERSION": "const"},
+		},
+		{
+			file: "index.d.ts",
+			content: `
+export declare function parse(input: string): Node;
+export declare const VERSION: string;
+export declare class Parser<T> {}
+export default interface Options {}
+export { type Node, Token };
+`,
+			want: map[string]string{
+				"parse":   "function",
+				"VERSION": "const",
+				"Parser":  "class",
+				"Options": "interface",
+				"Node":    "const",
+				"Token":   "const",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			testFile := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(testFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+
+			symbols, err := NewParser().ParseFile(testFile)
+			if err != nil {
+				t.Fatalf("ParseFile() error = %v", err)
+			}
+			got := make(map[string]string)
+			for _, sym := range symbols {
+				got[sym.Name] = sym.Kind
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("symbols = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasExtension(t *testing.T) {
+	for name, want := range map[string]bool{
+		"index.js":     true,
+		"index.mjs":    true,
+		"index.cjs":    true,
+		"index.d.ts":   true,
+		"index.mts":    true,
+		"index.json":   false,
+		"index.js.map": false,
+	} {
+		if got := HasExtension(name, DefaultExtensions); got != want {
+			t.Errorf("HasExtension(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if HasExtension("index.ts", []string{".d.ts"}) {
+		t.Error("HasExtension matched index.ts against .d.ts")
+	}
+}
+
 func TestParseGoFile(t *testing.T) {
 	tmpDir := t.TempDir()
 	testFile := filepath.Join(tmpDir, "test.go")
@@ -1,17 +1,26 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"go/ast"
 	"go/doc"
 	"go/format"
 	"go/parser"
 	"go/token"
+	"go/types"
+	"io"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,73 +30,105 @@ import (
 
 // PackageDoc represents complete documentation for a Go package
 type PackageDoc struct {
-	ImportPath       string     `json:"import_path"`
-	Name             string     `json:"name"`
-	Doc              string     `json:"doc"`
-	Synopsis         string     `json:"synopsis"`
-	Version          string     `json:"version,omitempty"`
-	Versions         []string   `json:"versions,omitempty"`
-	IsTagged         bool       `json:"is_tagged,omitempty"`
-	IsStable         bool       `json:"is_stable,omitempty"`
-	PublishedAt      string     `json:"published_at,omitempty"`
-	License          string     `json:"license,omitempty"`
-	LicenseText      string     `json:"license_text,omitempty"`
-	Redistributable  bool       `json:"redistributable,omitempty"`
-	Repository       string     `json:"repository,omitempty"`
-	HasValidMod      bool       `json:"has_valid_mod,omitempty"`
-	GoVersion        string     `json:"go_version,omitempty"`
-	ModulePath       string     `json:"module_path,omitempty"`
-	GoModContent     string     `json:"gomod_content,omitempty"`
-	GOOS             []string   `json:"goos,omitempty"`
-	GOARCH           []string   `json:"goarch,omitempty"`
-	Constants        []Constant `json:"constants"`
-	Variables        []Variable `json:"variables"`
-	Functions        []Function `json:"functions"`
-	Types            []Type     `json:"types"`
-	Examples         []Example  `json:"examples"`
-	Imports          []string   `json:"imports"`
-	Filenames        []string   `json:"filenames"`
+	ImportPath       string      `json:"import_path"`
+	Name             string      `json:"name"`
+	Doc              string      `json:"doc"`
+	Synopsis         string      `json:"synopsis"`
+	Version          string      `json:"version,omitempty"`
+	Versions         []string    `json:"versions,omitempty"`
+	IsTagged         bool        `json:"is_tagged,omitempty"`
+	IsStable         bool        `json:"is_stable,omitempty"`
+	PublishedAt      string      `json:"published_at,omitempty"`
+	License          string      `json:"license,omitempty"`
+	LicenseText      string      `json:"license_text,omitempty"`
+	Redistributable  bool        `json:"redistributable,omitempty"`
+	Repository       string      `json:"repository,omitempty"`
+	HasValidMod      bool        `json:"has_valid_mod,omitempty"`
+	GoVersion        string      `json:"go_version,omitempty"`
+	Toolchain        string      `json:"toolchain,omitempty"`
+	ModulePath       string      `json:"module_path,omitempty"`
+	GoModContent     string      `json:"gomod_content,omitempty"`
+	GoMod            *util.GoMod `json:"gomod,omitempty"`
+	GOOS             []string    `json:"goos,omitempty"`
+	GOARCH           []string    `json:"goarch,omitempty"`
+	Classification   string      `json:"classification,omitempty"` // "test-only" or "example-only"
+	Coverage         string      `json:"coverage,omitempty"`       // test coverage the module reports, e.g. "87.5%"
+	SynopsisSource   string      `json:"synopsis_source,omitempty"` // "readme" or "ai" when the synopsis is derived rather than from the package doc
+	Experimental     bool        `json:"experimental,omitempty"`    // under golang.org/x/exp or documented as experimental, alpha or beta
+	Deprecated       bool        `json:"deprecated,omitempty"`      // the package doc has a "Deprecated:" paragraph
+	Constants        []Constant  `json:"constants"`
+	Variables        []Variable  `json:"variables"`
+	Functions        []Function  `json:"functions"`
+	Types            []Type      `json:"types"`
+	Examples         []Example   `json:"examples"`
+	Imports          []string    `json:"imports"`
+	Filenames        []string    `json:"filenames"`
+	Generate         []string    `json:"generate,omitempty"` // //go:generate commands
+	Sources          map[string]string `json:"sources,omitempty"` // file name to content, with -include-source
 }
 
 // Constant represents a documented constant
 type Constant struct {
-	Names []string `json:"names"`
-	Doc   string   `json:"doc"`
-	Decl  string   `json:"decl"`
+	Names           []string `json:"names"`
+	Doc             string   `json:"doc"`
+	Decl            string   `json:"decl"`
+	Deprecated      bool     `json:"deprecated,omitempty"`
+	DeprecatedNames []string `json:"deprecated_names,omitempty"` // names deprecated individually within the group
 }
 
 // Variable represents a documented variable
 type Variable struct {
-	Names []string `json:"names"`
-	Doc   string   `json:"doc"`
-	Decl  string   `json:"decl"`
+	Names           []string `json:"names"`
+	Doc             string   `json:"doc"`
+	Decl            string   `json:"decl"`
+	Deprecated      bool     `json:"deprecated,omitempty"`
+	DeprecatedNames []string `json:"deprecated_names,omitempty"` // names deprecated individually within the group
 }
 
 // Function represents a documented function
 type Function struct {
-	Name       string    `json:"name"`
-	Doc        string    `json:"doc"`
-	Signature  string    `json:"signature"`
-	Recv       string    `json:"recv,omitempty"`
-	Filename   string    `json:"filename,omitempty"`
-	Line       int       `json:"line,omitempty"`
-	Deprecated bool      `json:"deprecated,omitempty"`
-	Examples   []Example `json:"examples,omitempty"`
+	Name       string          `json:"name"`
+	Doc        string          `json:"doc"`
+	Signature  string          `json:"signature"`
+	Recv       string          `json:"recv,omitempty"`
+	Filename   string          `json:"filename,omitempty"`
+	Line       int             `json:"line,omitempty"`
+	EndLine    int             `json:"end_line,omitempty"`
+	Deprecated bool            `json:"deprecated,omitempty"`
+	Params     []util.ParamDoc `json:"params,omitempty"` // parameters documented in prose
+	Examples   []Example       `json:"examples,omitempty"`
 }
 
 // Type represents a documented type
 type Type struct {
-	Name       string     `json:"name"`
-	Doc        string     `json:"doc"`
-	Decl       string     `json:"decl"`
-	Filename   string     `json:"filename,omitempty"`
-	Line       int        `json:"line,omitempty"`
-	Deprecated bool       `json:"deprecated,omitempty"`
-	Constants  []Constant `json:"constants,omitempty"`
-	Variables  []Variable `json:"variables,omitempty"`
-	Functions  []Function `json:"funcs,omitempty"`
-	Methods    []Function `json:"methods,omitempty"`
-	Examples   []Example  `json:"examples,omitempty"`
+	Name       string           `json:"name"`
+	Doc        string           `json:"doc"`
+	Decl       string           `json:"decl"`
+	Filename   string           `json:"filename,omitempty"`
+	Line       int              `json:"line,omitempty"`
+	EndLine    int              `json:"end_line,omitempty"`
+	Deprecated bool             `json:"deprecated,omitempty"`
+	AliasOf    string           `json:"alias_of,omitempty"`   // aliased type for "type A = B" declarations
+	AliasPath  string           `json:"alias_path,omitempty"` // import path of the aliased type's package, if it is a named type
+	Interface  bool             `json:"interface,omitempty"`
+	MethodSet  []string         `json:"method_set,omitempty"` // normalized method signatures, "*" marks pointer receivers
+	Constants  []Constant       `json:"constants,omitempty"`
+	Variables  []Variable       `json:"variables,omitempty"`
+	Functions  []Function       `json:"funcs,omitempty"`
+	Methods    []Function       `json:"methods,omitempty"`
+	Promoted   []PromotedMethod `json:"promoted,omitempty"`
+	Examples   []Example        `json:"examples,omitempty"`
+
+	Fields           []util.StructField `json:"fields,omitempty"` // exported struct fields with their parsed tags
+	DeprecatedFields []string           `json:"deprecated_fields,omitempty"`
+}
+
+// PromotedMethod is a method promoted from an embedded field
+type PromotedMethod struct {
+	Name      string `json:"name"`
+	Signature string `json:"signature"`
+	From      string `json:"from"`      // embedded type declaring the method, e.g. "sync.Mutex"
+	FromPath  string `json:"from_path"` // import path of the declaring package
 }
 
 // Example represents a runnable example
@@ -96,33 +137,181 @@ type Example struct {
 	Doc    string `json:"doc"`
 	Code   string `json:"code"`
 	Output string `json:"output,omitempty"`
+
+	// Unordered is set for an "// Unordered output:" comment, whose lines may
+	// be printed in any order
+	Unordered bool `json:"unordered,omitempty"`
+	// EmptyOutput is set when the output comment is present but empty: the
+	// example must print nothing, unlike one without an output comment
+	EmptyOutput bool `json:"empty_output,omitempty"`
+
+	// Verified is set by -verify-examples when the example compiled, ran, and printed Output
+	Verified bool `json:"verified,omitempty"`
 }
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Fprintln(os.Stderr, "Usage: wikigo <package-path>")
+	licenseFiles := flag.String("license-files", "", "Comma-separated additional license file names to look for")
+	verify := flag.Bool("verify-examples", false, "Compile and run examples with an Output comment and mark those that still pass (runs the package's code)")
+	verifyTimeout := flag.Duration("verify-timeout", 2*time.Minute, "Time limit for building and running examples with -verify-examples")
+	includeSource := flag.Bool("include-source", false, "Embed the package's source files in the JSON so the server can show them without the module cache")
+	maxSourceSize := flag.Int64("max-source-size", 1<<20, "Skip -include-source for packages whose sources total more bytes than this")
+	outputFormat := flag.String("format", "json", "Output format: json, yaml, or text for a go doc style summary")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: wikigo [-license-files names] [-verify-examples] [-include-source] [-format json|yaml|text] <package-path>")
 		fmt.Fprintln(os.Stderr, "Example: wikigo net/http")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	if !slices.Contains(outputFormats, *outputFormat) {
+		fmt.Fprintf(os.Stderr, "Unknown format %q: want json, yaml or text\n", *outputFormat)
 		os.Exit(1)
 	}
 
-	pkgPath := os.Args[1]
+	pkgPath := flag.Arg(0)
 
-	pkgDoc, err := ExtractPackageDoc(pkgPath)
+	var extraLicenseFiles []string
+	for _, name := range strings.Split(*licenseFiles, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			extraLicenseFiles = append(extraLicenseFiles, name)
+		}
+	}
+
+	pkgDoc, err := ExtractPackageDoc(pkgPath, extraLicenseFiles...)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error extracting package: %v\n", err)
 		os.Exit(1)
 	}
 
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(pkgDoc); err != nil {
-		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+	if *verify {
+		if err := verifyExamples(pkgDoc, pkgPath, *verifyTimeout); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: verifying examples: %v\n", err)
+		}
+	}
+
+	if *includeSource {
+		if err := includeSources(pkgDoc, *maxSourceSize); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: not including sources: %v\n", err)
+		}
+	}
+
+	if err := writeOutput(os.Stdout, pkgDoc, *outputFormat); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding %s: %v\n", strings.ToUpper(*outputFormat), err)
 		os.Exit(1)
 	}
 }
 
-// ExtractPackageDoc extracts all documentation from a Go package
-func ExtractPackageDoc(pkgPath string) (*PackageDoc, error) {
+// outputFormats are the values of -format
+var outputFormats = []string{"json", "yaml", "text"}
+
+// writeOutput writes a package's documentation to w as indented JSON, as YAML
+// with the same keys, or as a plain-text summary
+func writeOutput(w io.Writer, pkg *PackageDoc, format string) error {
+	switch format {
+	case "yaml":
+		data, err := util.MarshalYAML(pkg)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case "text":
+		_, err := io.WriteString(w, formatText(pkg))
+		return err
+	default:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(pkg)
+	}
+}
+
+// formatText summarizes a package the way go doc does: the package clause and
+// synopsis, then one line per constant, variable, function and type, with the
+// constants, variables, constructors and methods of each type indented under it
+func formatText(pkg *PackageDoc) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "package %s // import %q\n", pkg.Name, pkg.ImportPath)
+	if pkg.Synopsis != "" {
+		fmt.Fprintf(&b, "\n%s\n", pkg.Synopsis)
+	}
+
+	section := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s\n\n", title)
+		for _, line := range lines {
+			b.WriteString(line + "\n")
+		}
+	}
+	var consts, vars, funcs, types []string
+	for _, c := range pkg.Constants {
+		consts = append(consts, valueLine("const", c.Decl, c.Names))
+	}
+	for _, v := range pkg.Variables {
+		vars = append(vars, valueLine("var", v.Decl, v.Names))
+	}
+	for _, f := range pkg.Functions {
+		funcs = append(funcs, f.Signature)
+	}
+	for _, t := range pkg.Types {
+		types = append(types, typeLine(t))
+		for _, c := range t.Constants {
+			types = append(types, "    "+valueLine("const", c.Decl, c.Names))
+		}
+		for _, v := range t.Variables {
+			types = append(types, "    "+valueLine("var", v.Decl, v.Names))
+		}
+		for _, f := range t.Functions {
+			types = append(types, "    "+f.Signature)
+		}
+		for _, m := range t.Methods {
+			types = append(types, "    "+m.Signature)
+		}
+	}
+	section("CONSTANTS", consts)
+	section("VARIABLES", vars)
+	section("FUNCTIONS", funcs)
+	section("TYPES", types)
+	return b.String()
+}
+
+// valueLine is a constant or variable declaration on one line: as written
+// when it fits, with a composite literal elided, and "const A, B, C" for a
+// group
+func valueLine(keyword, decl string, names []string) string {
+	first, _, multiline := strings.Cut(decl, "\n")
+	switch {
+	case !multiline:
+		return first
+	case strings.HasSuffix(first, "{") && first != keyword+" (":
+		return first + " ... }"
+	default:
+		return keyword + " " + strings.Join(names, ", ")
+	}
+}
+
+// typeLine is a type declaration on one line, with the body of a struct or
+// interface elided as go doc does: "type Client struct{ ... }"
+func typeLine(t Type) string {
+	first, _, multiline := strings.Cut(t.Decl, "\n")
+	switch {
+	case !multiline:
+		return first
+	case strings.HasSuffix(first, " {") && strings.HasPrefix(first, "type "+t.Name):
+		return strings.TrimSuffix(first, " {") + "{ ... }"
+	default:
+		return "type " + t.Name
+	}
+}
+
+// ExtractPackageDoc extracts all documentation from a Go package. extraLicenseFiles
+// names license files to look for besides the usual LICENSE and COPYING variants.
+func ExtractPackageDoc(pkgPath string, extraLicenseFiles ...string) (*PackageDoc, error) {
 	// Use our own FileSet for consistency
 	fset := token.NewFileSet()
 
@@ -164,9 +353,13 @@ func ExtractPackageDoc(pkgPath string) (*PackageDoc, error) {
 		return nil, fmt.Errorf("reading package directory: %w", err)
 	}
 
-	// Determine the expected package name from the import path
-	expectedPkgName := filepath.Base(pkgPath)
-
+	type parsedFile struct {
+		path   string
+		file   *ast.File
+		isTest bool
+	}
+	var parsed []parsedFile
+	fileCounts := make(map[string]int)
 	for _, entry := range entries {
 		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
 			continue
@@ -178,21 +371,34 @@ func ExtractPackageDoc(pkgPath string) (*PackageDoc, error) {
 			continue // Skip files that fail to parse
 		}
 
-		// Skip files that don't belong to the main package (e.g., example_test.go with package main)
-		pkgName := f.Name.Name
 		isTestFile := strings.HasSuffix(entry.Name(), "_test.go")
+		if !isTestFile {
+			fileCounts[f.Name.Name]++
+		}
+		parsed = append(parsed, parsedFile{fullPath, f, isTestFile})
+	}
 
-		if isTestFile {
+	// Pick the documented package; the directory may also hold an external
+	// foo_test package or stray files such as an ignored package main generator
+	importPath := pkgPath
+	if pkg.PkgPath != "" {
+		importPath = pkg.PkgPath
+	}
+	pkgName, err := util.SelectPackageName(fileCounts, importPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range parsed {
+		name := p.file.Name.Name
+		if p.isTest {
 			// Test files can have package name or package name_test
-			if pkgName == expectedPkgName || pkgName == expectedPkgName+"_test" {
-				testFiles = append(testFiles, f)
-			}
-		} else {
-			// Regular files must match package name
-			if pkgName == expectedPkgName {
-				files = append(files, f)
-				filenames = append(filenames, fullPath)
+			if name == pkgName || name == pkgName+"_test" {
+				testFiles = append(testFiles, p.file)
 			}
+		} else if name == pkgName {
+			files = append(files, p.file)
+			filenames = append(filenames, p.path)
 		}
 	}
 
@@ -200,6 +406,9 @@ func ExtractPackageDoc(pkgPath string) (*PackageDoc, error) {
 		return nil, fmt.Errorf("no parseable Go files found")
 	}
 
+	// go/doc drops function bodies, so record where each function ends first
+	funcEnds := util.FuncEndLines(fset, files)
+
 	// Create documentation
 	docPkg, err := doc.NewFromFiles(fset, files, pkgPath, doc.AllDecls|doc.AllMethods)
 	if err != nil {
@@ -213,13 +422,17 @@ func ExtractPackageDoc(pkgPath string) (*PackageDoc, error) {
 	}
 
 	// Detect license
-	license, licenseText := detectLicense(pkgDir)
+	license, licenseText := detectLicense(pkgDir, extraLicenseFiles...)
 
 	// Detect repository
 	repository := detectRepository(pkgPath, pkgDir)
 
 	// Detect go.mod info
-	hasValidMod, goVersion, modulePath, goModContent := detectGoMod(pkgDir)
+	hasValidMod, goMod, goModContent := detectGoMod(pkgDir)
+	var goVersion, toolchain, modulePath string
+	if goMod != nil {
+		goVersion, toolchain, modulePath = goMod.Go, goMod.Toolchain, goMod.Module
+	}
 
 	// Detect version
 	version := detectVersion(pkgDir, modulePath)
@@ -250,36 +463,53 @@ func ExtractPackageDoc(pkgPath string) (*PackageDoc, error) {
 		Repository:      repository,
 		HasValidMod:     hasValidMod,
 		GoVersion:       goVersion,
+		Toolchain:       toolchain,
 		ModulePath:      modulePath,
 		GoModContent:    goModContent,
+		GoMod:           goMod,
 		Filenames:       filenames,
+		Classification:  util.ClassifyPackage(files, testFiles),
+		Coverage:        detectCoverage(pkgDir),
+		Experimental:    util.IsExperimental(importPath, docPkg.Doc),
+		Deprecated:      isDeprecated(docPkg.Doc),
+	}
+
+	// Packages without a doc comment take their synopsis from their README
+	if result.Synopsis == "" && result.Classification == "" {
+		if synopsis := util.ReadmeSynopsis(pkgDir); synopsis != "" {
+			result.Synopsis, result.SynopsisSource = synopsis, "readme"
+		}
 	}
 
+	result.Generate = util.GenerateDirectives(fset, files)
+
 	// Extract build constraints from filenames
 	goos, goarch := extractBuildConstraints(filenames)
 	result.GOOS = goos
 	result.GOARCH = goarch
 
-	// Extract imports
-	for imp := range pkg.Imports {
-		result.Imports = append(result.Imports, imp)
-	}
+	// Extract imports, sorted so repeated runs produce identical JSON
+	result.Imports = slices.Sorted(maps.Keys(pkg.Imports))
 
 	// Extract constants
 	for _, c := range docPkg.Consts {
 		result.Constants = append(result.Constants, Constant{
-			Names: c.Names,
-			Doc:   c.Doc,
-			Decl:  formatDecl(fset, c.Decl),
+			Names:           c.Names,
+			Doc:             c.Doc,
+			Decl:            formatDecl(fset, c.Decl),
+			Deprecated:      isDeprecated(c.Doc),
+			DeprecatedNames: util.DeprecatedValueNames(c),
 		})
 	}
 
 	// Extract variables
 	for _, v := range docPkg.Vars {
 		result.Variables = append(result.Variables, Variable{
-			Names: v.Names,
-			Doc:   v.Doc,
-			Decl:  formatDecl(fset, v.Decl),
+			Names:           v.Names,
+			Doc:             v.Doc,
+			Decl:            formatDecl(fset, v.Decl),
+			Deprecated:      isDeprecated(v.Doc),
+			DeprecatedNames: util.DeprecatedValueNames(v),
 		})
 	}
 
@@ -292,39 +522,55 @@ func ExtractPackageDoc(pkgPath string) (*PackageDoc, error) {
 			Signature:  formatFuncSignature(f.Decl),
 			Filename:   filepath.Base(pos.Filename),
 			Line:       pos.Line,
+			EndLine:    funcEnds[f.Decl.Pos()],
 			Deprecated: isDeprecated(f.Doc),
 		}
+		fn.Params, _ = util.ParseParamDocs(f.Doc, util.ParamNames(f.Decl.Type))
 		fn.Examples = findExamples(examples, f.Name, fset)
 		result.Functions = append(result.Functions, fn)
 	}
 
+	// Type information is only needed to resolve methods promoted from other packages
+	var typesPkg *types.Package
+	if embedsOtherPackages(docPkg) {
+		typesPkg = loadTypes(pkgDir)
+	}
+
 	// Extract types
 	for _, t := range docPkg.Types {
-		typePos := fset.Position(t.Decl.Pos())
+		typePos, typeEnd := util.TypeDeclRange(fset, t)
 		typ := Type{
 			Name:       t.Name,
 			Doc:        t.Doc,
 			Decl:       formatDecl(fset, t.Decl),
 			Filename:   filepath.Base(typePos.Filename),
 			Line:       typePos.Line,
+			EndLine:    typeEnd.Line,
 			Deprecated: isDeprecated(t.Doc),
 		}
+		typ.AliasOf, typ.AliasPath = aliasTarget(importPath, t, files, fset)
+		typ.Fields = util.StructFields(t)
+		typ.DeprecatedFields = util.DeprecatedFields(t)
 
 		// Type-associated constants
 		for _, c := range t.Consts {
 			typ.Constants = append(typ.Constants, Constant{
-				Names: c.Names,
-				Doc:   c.Doc,
-				Decl:  formatDecl(fset, c.Decl),
+				Names:           c.Names,
+				Doc:             c.Doc,
+				Decl:            formatDecl(fset, c.Decl),
+				Deprecated:      isDeprecated(c.Doc),
+				DeprecatedNames: util.DeprecatedValueNames(c),
 			})
 		}
 
 		// Type-associated variables
 		for _, v := range t.Vars {
 			typ.Variables = append(typ.Variables, Variable{
-				Names: v.Names,
-				Doc:   v.Doc,
-				Decl:  formatDecl(fset, v.Decl),
+				Names:           v.Names,
+				Doc:             v.Doc,
+				Decl:            formatDecl(fset, v.Decl),
+				Deprecated:      isDeprecated(v.Doc),
+				DeprecatedNames: util.DeprecatedValueNames(v),
 			})
 		}
 
@@ -337,8 +583,10 @@ func ExtractPackageDoc(pkgPath string) (*PackageDoc, error) {
 				Signature:  formatFuncSignature(f.Decl),
 				Filename:   filepath.Base(pos.Filename),
 				Line:       pos.Line,
+				EndLine:    funcEnds[f.Decl.Pos()],
 				Deprecated: isDeprecated(f.Doc),
 			}
+			fn.Params, _ = util.ParseParamDocs(f.Doc, util.ParamNames(f.Decl.Type))
 			fn.Examples = findExamples(examples, f.Name, fset)
 			typ.Functions = append(typ.Functions, fn)
 		}
@@ -353,12 +601,20 @@ func ExtractPackageDoc(pkgPath string) (*PackageDoc, error) {
 				Recv:       m.Recv,
 				Filename:   filepath.Base(pos.Filename),
 				Line:       pos.Line,
+				EndLine:    funcEnds[m.Decl.Pos()],
 				Deprecated: isDeprecated(m.Doc),
 			}
-			method.Examples = findExamples(examples, t.Name+"_"+m.Name, fset)
+			method.Params, _ = util.ParseParamDocs(m.Doc, util.ParamNames(m.Decl.Type))
+			method.Examples = findExamples(examples, t.Name+"."+m.Name, fset)
 			typ.Methods = append(typ.Methods, method)
 		}
 
+		// Method set, used to match types against interfaces
+		typ.Interface, typ.MethodSet = util.MethodSet(docPkg, t)
+
+		// Methods promoted from embedded types in other packages
+		typ.Promoted = promotedMethods(typesPkg, t.Name, typ.Methods)
+
 		// Type examples
 		typ.Examples = findExamples(examples, t.Name, fset)
 
@@ -405,6 +661,9 @@ func formatFuncSignature(decl *ast.FuncDecl) string {
 	}
 
 	buf.WriteString(decl.Name.Name)
+	if tparams := decl.Type.TypeParams; tparams != nil && len(tparams.List) > 0 {
+		buf.WriteString("[" + formatFieldList(tparams.List) + "]")
+	}
 	buf.WriteString(formatFuncType(decl.Type))
 
 	return buf.String()
@@ -490,15 +749,15 @@ func formatExpr(expr ast.Expr) string {
 	case *ast.FuncType:
 		return "func" + formatFuncType(e)
 	case *ast.InterfaceType:
-		if e.Methods == nil || len(e.Methods.List) == 0 {
-			return "interface{}"
-		}
-		return "interface{ ... }"
+		return formatBlock("interface", interfaceElems(e.Methods))
 	case *ast.StructType:
-		if e.Fields == nil || len(e.Fields.List) == 0 {
-			return "struct{}"
-		}
-		return "struct{ ... }"
+		return formatBlock("struct", structElems(e.Fields))
+	case *ast.UnaryExpr:
+		// ~T in constraints
+		return e.Op.String() + formatExpr(e.X)
+	case *ast.BinaryExpr:
+		// Unions in constraints
+		return formatExpr(e.X) + " " + e.Op.String() + " " + formatExpr(e.Y)
 	case *ast.Ellipsis:
 		return "..." + formatExpr(e.Elt)
 	case *ast.BasicLit:
@@ -518,42 +777,403 @@ func formatExpr(expr ast.Expr) string {
 	}
 }
 
-// findExamples finds examples matching a given name
+// maxInlineBlockWidth is the width up to which formatBlock keeps a struct or
+// interface type on one line
+const maxInlineBlockWidth = 80
+
+// formatBlock formats a struct or interface type from its fields or elements:
+// on one line when short, like struct{ X, Y int }, and otherwise one element
+// per line, indented like gofmt does
+func formatBlock(keyword string, elems []string) string {
+	if len(elems) == 0 {
+		return keyword + "{}"
+	}
+	inline := keyword + "{ " + strings.Join(elems, "; ") + " }"
+	if len(inline) <= maxInlineBlockWidth && !strings.Contains(inline, "\n") {
+		return inline
+	}
+
+	var buf strings.Builder
+	buf.WriteString(keyword + "{\n")
+	for _, elem := range elems {
+		buf.WriteString("\t" + strings.ReplaceAll(elem, "\n", "\n\t") + "\n")
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
+// structElems formats the fields of a struct type with their tags
+func structElems(fields *ast.FieldList) []string {
+	if fields == nil {
+		return nil
+	}
+	var elems []string
+	for _, f := range fields.List {
+		elem := formatExpr(f.Type)
+		if len(f.Names) > 0 {
+			var names []string
+			for _, n := range f.Names {
+				names = append(names, n.Name)
+			}
+			elem = strings.Join(names, ", ") + " " + elem
+		}
+		if f.Tag != nil {
+			elem += " " + f.Tag.Value
+		}
+		elems = append(elems, elem)
+	}
+	return elems
+}
+
+// interfaceElems formats the methods, embedded interfaces and type sets of an
+// interface type
+func interfaceElems(methods *ast.FieldList) []string {
+	if methods == nil {
+		return nil
+	}
+	var elems []string
+	for _, f := range methods.List {
+		if ft, ok := f.Type.(*ast.FuncType); ok && len(f.Names) > 0 {
+			elems = append(elems, f.Names[0].Name+formatFuncType(ft))
+			continue
+		}
+		elems = append(elems, formatExpr(f.Type))
+	}
+	return elems
+}
+
+// aliasTarget returns the aliased type of an alias declaration and, when it is a
+// named type, the import path of the package declaring it; importPath is the
+// documented package's own, not the pattern it was loaded with
+func aliasTarget(importPath string, t *doc.Type, files []*ast.File, fset *token.FileSet) (string, string) {
+	for _, spec := range t.Decl.Specs {
+		ts, ok := spec.(*ast.TypeSpec)
+		if !ok || ts.Name.Name != t.Name || !ts.Assign.IsValid() {
+			continue
+		}
+		target := formatExpr(ts.Type)
+		switch x := ts.Type.(type) {
+		case *ast.Ident:
+			if token.IsExported(x.Name) {
+				return target, importPath
+			}
+		case *ast.SelectorExpr:
+			if pkgIdent, ok := x.X.(*ast.Ident); ok {
+				return target, resolveImport(pkgIdent.Name, fileOf(ts.Pos(), files, fset))
+			}
+		}
+		return target, ""
+	}
+	return "", ""
+}
+
+// fileOf returns the file containing pos
+func fileOf(pos token.Pos, files []*ast.File, fset *token.FileSet) *ast.File {
+	for _, f := range files {
+		if fset.File(f.Pos()) == fset.File(pos) {
+			return f
+		}
+	}
+	return nil
+}
+
+// resolveImport returns the import path a file refers to by name
+func resolveImport(name string, file *ast.File) string {
+	if file == nil {
+		return ""
+	}
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		if imp.Name != nil {
+			if imp.Name.Name == name {
+				return path
+			}
+			continue
+		}
+		if importName(path) == name {
+			return path
+		}
+	}
+	return ""
+}
+
+// importName guesses the package name of an import path from its last element,
+// skipping major version suffixes and common "go-" prefixes or ".go" suffixes
+func importName(path string) string {
+	parts := strings.Split(path, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = parts[len(parts)-2]
+	}
+	if i := strings.Index(name, ".v"); i > 0 {
+		name = name[:i] // gopkg.in/yaml.v3
+	}
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, ".go")
+	return strings.ReplaceAll(name, "-", "")
+}
+
+// embedsOtherPackages reports whether any exported struct embeds a type from another package
+func embedsOtherPackages(docPkg *doc.Package) bool {
+	for _, t := range docPkg.Types {
+		for _, spec := range t.Decl.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			for _, field := range st.Fields.List {
+				typ := field.Type
+				if star, ok := typ.(*ast.StarExpr); ok {
+					typ = star.X
+				}
+				if _, ok := typ.(*ast.SelectorExpr); ok && len(field.Names) == 0 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// loadTypes loads the type information of the package in pkgDir, from the
+// export data the go command compiles rather than by type-checking it and its
+// dependencies from source. Source is the fallback for when the export data
+// cannot be read, such as when it was written by a newer toolchain than
+// golang.org/x/tools supports. It is best-effort: if neither works, a warning
+// is printed and nil is returned.
+func loadTypes(pkgDir string) *types.Package {
+	load := func(mode packages.LoadMode) (*types.Package, error) {
+		pkgs, err := packages.Load(&packages.Config{Mode: mode, Dir: pkgDir}, ".")
+		if err != nil {
+			return nil, err
+		}
+		if len(pkgs) == 0 {
+			return nil, fmt.Errorf("no package found")
+		}
+		if len(pkgs[0].Errors) > 0 {
+			return nil, pkgs[0].Errors[0]
+		}
+		return pkgs[0].Types, nil
+	}
+
+	pkg, err := load(packages.NeedName | packages.NeedTypes)
+	if err == nil {
+		return pkg
+	}
+	pkg, err = load(packages.NeedName | packages.NeedImports | packages.NeedDeps | packages.NeedSyntax | packages.NeedTypes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: loading types of %s: %v\n", pkgDir, err)
+		return nil
+	}
+	return pkg
+}
+
+// promotedMethods returns the exported methods a type gains through embedding
+// that go/doc does not already list, namely those from other packages
+func promotedMethods(pkg *types.Package, typeName string, declared []Function) []PromotedMethod {
+	if pkg == nil {
+		return nil
+	}
+	obj, ok := pkg.Scope().Lookup(typeName).(*types.TypeName)
+	if !ok || obj.IsAlias() {
+		return nil
+	}
+	if _, ok := obj.Type().Underlying().(*types.Struct); !ok {
+		return nil
+	}
+
+	listed := make(map[string]bool, len(declared))
+	for _, m := range declared {
+		listed[m.Name] = true
+	}
+	qualifier := func(p *types.Package) string {
+		if p == pkg {
+			return ""
+		}
+		return p.Name()
+	}
+
+	var promoted []PromotedMethod
+	mset := types.NewMethodSet(types.NewPointer(obj.Type()))
+	for i := 0; i < mset.Len(); i++ {
+		sel := mset.At(i)
+		fn, ok := sel.Obj().(*types.Func)
+		if !ok || len(sel.Index()) < 2 || !fn.Exported() || listed[fn.Name()] {
+			continue
+		}
+		sig := fn.Type().(*types.Signature)
+		recv := sig.Recv().Type()
+		if ptr, ok := recv.(*types.Pointer); ok {
+			recv = ptr.Elem()
+		}
+		named, ok := recv.(*types.Named)
+		if !ok || named.Obj().Pkg() == nil {
+			continue
+		}
+		promoted = append(promoted, PromotedMethod{
+			Name:      fn.Name(),
+			Signature: "func (" + types.TypeString(sig.Recv().Type(), qualifier) + ") " + fn.Name() + strings.TrimPrefix(types.TypeString(sig, qualifier), "func"),
+			From:      types.TypeString(named, qualifier),
+			FromPath:  named.Obj().Pkg().Path(),
+		})
+	}
+	return promoted
+}
+
+// findExamples finds the examples of a symbol: "" for the package, "Func", "Type", or "Type.Method"
 func findExamples(examples []*doc.Example, name string, fset *token.FileSet) []Example {
 	var result []Example
 	for _, ex := range examples {
 		exName := ex.Name
-		match := false
 
-		if name == "" && exName == "" {
-			match = true
-		} else if name != "" && (exName == name || strings.HasPrefix(exName, name+"_")) {
-			match = true
-		}
-
-		if match {
+		// Match on the documented symbol so that method examples (Type_Method)
+		// are not also listed under their type
+		if util.ExampleSymbol(exName) == name {
 			code := formatDecl(fset, ex.Code)
 			if code == "" && ex.Play != nil {
 				code = formatDecl(fset, ex.Play)
 			}
 
 			result = append(result, Example{
-				Name:   exName,
-				Doc:    ex.Doc,
-				Code:   code,
-				Output: ex.Output,
+				Name:        exName,
+				Doc:         ex.Doc,
+				Code:        code,
+				Output:      ex.Output,
+				Unordered:   ex.Unordered,
+				EmptyOutput: ex.EmptyOutput,
 			})
 		}
 	}
 	return result
 }
 
-// detectLicense looks for a license file and identifies the license type
-func detectLicense(dir string) (licenseType string, licenseText string) {
+// allExamples returns pointers to every example of a package and its symbols
+func allExamples(pkg *PackageDoc) []*Example {
+	var all []*Example
+	add := func(list []Example) {
+		for i := range list {
+			all = append(all, &list[i])
+		}
+	}
+	add(pkg.Examples)
+	for _, fn := range pkg.Functions {
+		add(fn.Examples)
+	}
+	for _, t := range pkg.Types {
+		add(t.Examples)
+		for _, fn := range t.Functions {
+			add(fn.Examples)
+		}
+		for _, m := range t.Methods {
+			add(m.Examples)
+		}
+	}
+	return all
+}
+
+// includeSources embeds the package's source files in pkg.Sources, keyed by file
+// name, unless together they are larger than maxBytes
+func includeSources(pkg *PackageDoc, maxBytes int64) error {
+	var total int64
+	for _, name := range pkg.Filenames {
+		info, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+	}
+	if total > maxBytes {
+		return fmt.Errorf("sources total %d bytes, more than the %d byte limit", total, maxBytes)
+	}
+
+	sources := make(map[string]string, len(pkg.Filenames))
+	for _, name := range pkg.Filenames {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		sources[filepath.Base(name)] = string(data)
+	}
+	pkg.Sources = sources
+	return nil
+}
+
+// verifyExamples runs the examples that declare an output with "go test" and marks
+// those that pass as Verified. Examples that no longer compile or print something
+// else stay unverified. This executes the package's code, in a separate process
+// bounded by timeout.
+func verifyExamples(pkg *PackageDoc, pkgPath string, timeout time.Duration) error {
+	byTest := make(map[string][]*Example)
+	for _, ex := range allExamples(pkg) {
+		if ex.Output != "" || ex.EmptyOutput {
+			byTest["Example"+ex.Name] = append(byTest["Example"+ex.Name], ex)
+		}
+	}
+	if len(byTest) == 0 {
+		return nil
+	}
+
+	var names []string
+	for name := range byTest {
+		names = append(names, name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "go", "test", "-json", "-count=1", "-vet=off",
+		"-run", "^("+strings.Join(names, "|")+")$", pkgPath)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, runErr := cmd.Output()
+	if ctx.Err() != nil {
+		return fmt.Errorf("timed out after %v", timeout)
+	}
+
+	// go test exits non-zero when any example fails; the events still report the others
+	passed := make(map[string]bool)
+	var buildOutput strings.Builder
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		var event struct{ Action, Test, Output string }
+		if json.Unmarshal(scanner.Bytes(), &event) != nil {
+			continue
+		}
+		switch {
+		case event.Action == "pass" && event.Test != "":
+			passed[event.Test] = true
+		case event.Action == "build-output":
+			buildOutput.WriteString(event.Output)
+		}
+	}
+
+	for name, examples := range byTest {
+		for _, ex := range examples {
+			ex.Verified = passed[name]
+		}
+	}
+
+	if len(passed) == 0 && runErr != nil {
+		if msg := strings.TrimSpace(buildOutput.String() + stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", runErr, msg)
+		}
+		return runErr
+	}
+	return nil
+}
+
+// detectLicense looks for license files and identifies the license type
+func detectLicense(dir string, extraNames ...string) (licenseType string, licenseText string) {
 	// Walk up directories to find LICENSE file (for module root)
 	currentDir := dir
 	for i := 0; i < 10; i++ { // Limit depth
-		licenseType, licenseText = findLicenseInDir(currentDir)
+		licenseType, licenseText = util.DetectLicense(currentDir, extraNames...)
 		if licenseType != "" {
 			return licenseType, licenseText
 		}
@@ -566,23 +1186,21 @@ func detectLicense(dir string) (licenseType string, licenseText string) {
 	return "", ""
 }
 
-func findLicenseInDir(dir string) (licenseType string, licenseText string) {
-	licenseFiles := []string{
-		"LICENSE", "LICENSE.txt", "LICENSE.md",
-		"LICENCE", "LICENCE.txt", "LICENCE.md",
-		"COPYING", "COPYING.txt",
-	}
-
-	for _, name := range licenseFiles {
-		path := filepath.Join(dir, name)
-		content, err := os.ReadFile(path)
-		if err != nil {
-			continue
+// detectCoverage returns the test coverage reported at the root of the module
+// containing dir, found by walking up to its go.mod
+func detectCoverage(dir string) string {
+	currentDir := dir
+	for i := 0; i < 10; i++ {
+		if _, err := os.Stat(filepath.Join(currentDir, "go.mod")); err == nil {
+			return util.DetectCoverage(currentDir)
 		}
-		text := string(content)
-		return identifyLicense(text), text
+		parent := filepath.Dir(currentDir)
+		if parent == currentDir {
+			break
+		}
+		currentDir = parent
 	}
-	return "", ""
+	return ""
 }
 
 // Deprecated: Use util.IdentifyLicense instead
@@ -595,25 +1213,18 @@ func isRedistributable(license string) bool {
 	return util.IsRedistributable(license)
 }
 
-// detectGoMod checks for a valid go.mod and extracts Go version, module path, and content
-func detectGoMod(pkgDir string) (hasValidMod bool, goVersion string, modulePath string, goModContent string) {
+// detectGoMod finds the nearest go.mod and parses its directives
+func detectGoMod(pkgDir string) (hasValidMod bool, goMod *util.GoMod, goModContent string) {
 	currentDir := pkgDir
 	for i := 0; i < 10; i++ {
 		gomodPath := filepath.Join(currentDir, "go.mod")
 		content, err := os.ReadFile(gomodPath)
 		if err == nil {
-			goModContent = string(content)
-			for _, line := range strings.Split(goModContent, "\n") {
-				line = strings.TrimSpace(line)
-				if strings.HasPrefix(line, "module ") {
-					hasValidMod = true
-					modulePath = strings.TrimSpace(strings.TrimPrefix(line, "module "))
-				}
-				if strings.HasPrefix(line, "go ") {
-					goVersion = strings.TrimSpace(strings.TrimPrefix(line, "go "))
-				}
+			goMod, err = util.ParseGoMod(content)
+			if err != nil {
+				return false, nil, string(content)
 			}
-			return hasValidMod, goVersion, modulePath, goModContent
+			return goMod.Module != "", goMod, string(content)
 		}
 		parent := filepath.Dir(currentDir)
 		if parent == currentDir {
@@ -621,7 +1232,7 @@ func detectGoMod(pkgDir string) (hasValidMod bool, goVersion string, modulePath
 		}
 		currentDir = parent
 	}
-	return false, "", "", ""
+	return false, nil, ""
 }
 
 // detectRepository detects the repository URL from the import path or go.mod
@@ -702,14 +1313,7 @@ func extractBuildConstraints(filenames []string) (goos []string, goarch []string
 		}
 	}
 
-	for os := range goosSet {
-		goos = append(goos, os)
-	}
-	for arch := range goarchSet {
-		goarch = append(goarch, arch)
-	}
-
-	return goos, goarch
+	return slices.Sorted(maps.Keys(goosSet)), slices.Sorted(maps.Keys(goarchSet))
 }
 
 // detectVersion tries to detect the package version from git tags or go.mod
@@ -0,0 +1,439 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/parser"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// extractFixture extracts a fixture package from testdata/extract
+func extractFixture(t *testing.T, name string) *PackageDoc {
+	t.Helper()
+	pkg, err := ExtractPackageDoc("./testdata/extract/" + name)
+	if err != nil {
+		t.Fatalf("ExtractPackageDoc(%s) error = %v", name, err)
+	}
+	return pkg
+}
+
+func findFunc(funcs []Function, name string) *Function {
+	for i := range funcs {
+		if funcs[i].Name == name {
+			return &funcs[i]
+		}
+	}
+	return nil
+}
+
+func findType(pkg *PackageDoc, name string) *Type {
+	for i := range pkg.Types {
+		if pkg.Types[i].Name == name {
+			return &pkg.Types[i]
+		}
+	}
+	return nil
+}
+
+func TestExtractPackageDoc_Signatures(t *testing.T) {
+	tests := []struct {
+		fixture   string
+		typeName  string // "" for package-level functions
+		funcName  string
+		signature string
+	}{
+		{"generics", "Set", "Add", "func (s *Set[T]) Add(v T)"},
+		{"generics", "Set", "Len", "func (s *Set[T]) Len() int"},
+		{"generics", "", "Map", "func Map[T, U any](s []T, f func(T) U) []U"},
+		{"generics", "", "Sum", "func Sum[N Number](s ...N) N"},
+		{"generics", "Set", "NewSet", "func NewSet[T comparable]() *Set[T]"},
+		{"generics", "", "Keys", "func Keys[M ~map[K]V, K comparable, V any](m M) []K"},
+		{"generics", "", "Clamp", "func Clamp[T interface{ ~int | ~float64 }](v, lo, hi T) T"},
+		{"generics", "Result", "Lookup", "func Lookup(name string) Result[User, error]"},
+		{"generics", "", "Index", "func Index(names []string) map[string]*Set[string]"},
+		{"embedded", "Base", "Hello", "func (Base) Hello() string"},
+		{"embedded", "Derived", "Rename", "func (d *Derived) Rename(name string)"},
+		{"examples", "", "Greet", "func Greet(name string) string"},
+		{"examples", "Greeter", "Greet", "func (g Greeter) Greet(name string) string"},
+		{"deprecated", "", "Old", "func Old()"},
+	}
+	pkgs := make(map[string]*PackageDoc)
+	for _, tt := range tests {
+		t.Run(tt.fixture+"/"+tt.typeName+"."+tt.funcName, func(t *testing.T) {
+			pkg, ok := pkgs[tt.fixture]
+			if !ok {
+				pkg = extractFixture(t, tt.fixture)
+				pkgs[tt.fixture] = pkg
+			}
+
+			funcs := pkg.Functions
+			if tt.typeName != "" {
+				typ := findType(pkg, tt.typeName)
+				if typ == nil {
+					t.Fatalf("type %s not found", tt.typeName)
+				}
+				funcs = append(typ.Functions, typ.Methods...)
+			}
+			fn := findFunc(funcs, tt.funcName)
+			if fn == nil {
+				t.Fatalf("function %s not found", tt.funcName)
+			}
+			if fn.Signature != tt.signature {
+				t.Errorf("signature = %q, want %q", fn.Signature, tt.signature)
+			}
+			if fn.Filename == "" || fn.Line == 0 || fn.EndLine < fn.Line {
+				t.Errorf("position = %s:%d-%d", fn.Filename, fn.Line, fn.EndLine)
+			}
+		})
+	}
+}
+
+func TestExtractPackageDoc_Generics(t *testing.T) {
+	pkg := extractFixture(t, "generics")
+
+	set := findType(pkg, "Set")
+	if set == nil {
+		t.Fatal("type Set not found")
+	}
+	if !strings.HasPrefix(set.Decl, "type Set[T comparable] struct") {
+		t.Errorf("Set decl = %q", set.Decl)
+	}
+	if findFunc(set.Functions, "NewSet") == nil {
+		t.Error("NewSet should be listed as a constructor of Set")
+	}
+	if result := findType(pkg, "Result"); result == nil || !strings.HasPrefix(result.Decl, "type Result[T, E any] struct") {
+		t.Errorf("Result decl lost its type parameters: %+v", result)
+	}
+
+	number := findType(pkg, "Number")
+	if number == nil || !strings.Contains(number.Decl, "~int | ~int64 | ~float64") {
+		t.Errorf("Number constraint decl not preserved: %+v", number)
+	}
+
+	for _, name := range []string{"Map", "Sum"} {
+		if findFunc(pkg.Functions, name) == nil {
+			t.Errorf("generic function %s not found", name)
+		}
+	}
+}
+
+func TestFormatExpr(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"Result[User, error]", "Result[User, error]"},
+		{"map[string]*Set[int]", "map[string]*Set[int]"},
+		{"struct{}", "struct{}"},
+		{"interface{}", "interface{}"},
+		{"struct{ X, Y int; Name string `json:\"name,omitempty\"` }", "struct{ X, Y int; Name string `json:\"name,omitempty\"` }"},
+		{"struct{ io.Reader; n int }", "struct{ io.Reader; n int }"},
+		{"interface{ Read(p []byte) (n int, err error); io.Closer }", "interface{ Read(p []byte) (n int, err error); io.Closer }"},
+		{"interface{ ~int | ~int64 | ~float64 }", "interface{ ~int | ~int64 | ~float64 }"},
+		{"func(opts struct{ Verbose bool }) error", "func(opts struct{ Verbose bool }) error"},
+		{
+			"struct{ Host string `json:\"host\"`; Port int `json:\"port\"`; TLS struct{ Cert, Key string } `json:\"tls\"` }",
+			"struct{\n\tHost string `json:\"host\"`\n\tPort int `json:\"port\"`\n\tTLS struct{ Cert, Key string } `json:\"tls\"`\n}",
+		},
+		{
+			"struct{ Server struct{ Host, Path, Scheme string; Port int; ReadTimeout, WriteTimeout time.Duration }; Debug bool }",
+			"struct{\n\tServer struct{\n\t\tHost, Path, Scheme string\n\t\tPort int\n\t\tReadTimeout, WriteTimeout time.Duration\n\t}\n\tDebug bool\n}",
+		},
+	}
+	for _, tt := range tests {
+		expr, err := parser.ParseExpr(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseExpr(%q) error = %v", tt.expr, err)
+		}
+		if got := formatExpr(expr); got != tt.want {
+			t.Errorf("formatExpr(%q) =\n%s\nwant\n%s", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestExtractPackageDoc_Aliases(t *testing.T) {
+	pkg := extractFixture(t, "aliases")
+
+	tests := []struct {
+		name, aliasOf, aliasPath string
+	}{
+		// The package's import path, not the "./testdata/..." pattern it was loaded with
+		{"A", "Real", "github.com/alexisbouchez/wikigo/testdata/extract/aliases"},
+		{"W", "io.Writer", "io"},
+	}
+	for _, tt := range tests {
+		typ := findType(pkg, tt.name)
+		if typ == nil {
+			t.Fatalf("type %s not found", tt.name)
+		}
+		if typ.AliasOf != tt.aliasOf || typ.AliasPath != tt.aliasPath {
+			t.Errorf("%s alias of %q in %q, want %q in %q", tt.name, typ.AliasOf, typ.AliasPath, tt.aliasOf, tt.aliasPath)
+		}
+	}
+}
+
+func TestLoadTypes(t *testing.T) {
+	// A directory is loaded as such, not taken for an import path
+	pkg := loadTypes(filepath.Join("testdata", "extract", "embedded"))
+	if pkg == nil || pkg.Scope().Lookup("Derived") == nil {
+		t.Fatalf("loadTypes() = %v, want the embedded fixture's types", pkg)
+	}
+	if pkg := loadTypes(t.TempDir()); pkg != nil {
+		t.Errorf("loadTypes(empty dir) = %v, want nil", pkg)
+	}
+}
+
+func TestExtractPackageDoc_EmbeddedTypes(t *testing.T) {
+	pkg := extractFixture(t, "embedded")
+
+	derived := findType(pkg, "Derived")
+	if derived == nil {
+		t.Fatal("type Derived not found")
+	}
+	if hello := findFunc(derived.Methods, "Hello"); hello == nil {
+		t.Error("method Hello promoted from Base should be listed on Derived")
+	}
+	if len(derived.Promoted) != 1 || derived.Promoted[0].Name != "Write" || derived.Promoted[0].FromPath != "io" {
+		t.Errorf("Promoted = %+v, want Write from io.Writer", derived.Promoted)
+	}
+	if !reflect.DeepEqual(pkg.Imports, []string{"io"}) {
+		t.Errorf("Imports = %v, want [io]", pkg.Imports)
+	}
+}
+
+func TestExtractPackageDoc_Examples(t *testing.T) {
+	pkg := extractFixture(t, "examples")
+
+	greet := findFunc(pkg.Functions, "Greet")
+	if greet == nil || len(greet.Examples) != 3 {
+		t.Fatalf("Greet examples = %+v, want 3", greet)
+	}
+	byName := make(map[string]Example)
+	for _, ex := range greet.Examples {
+		byName[ex.Name] = ex
+	}
+	if ex := byName["Greet"]; ex.Output != "hello gopher\n" || ex.Unordered || ex.EmptyOutput || !strings.Contains(ex.Code, `examples.Greet("gopher")`) {
+		t.Errorf("Greet example = %+v", ex)
+	}
+	if ex := byName["Greet_unordered"]; ex.Output != "hello bob\nhello ann\n" || !ex.Unordered || ex.EmptyOutput {
+		t.Errorf("Greet_unordered example = %+v, want unordered output", ex)
+	}
+	if ex := byName["Greet_silent"]; ex.Output != "" || !ex.EmptyOutput {
+		t.Errorf("Greet_silent example = %+v, want an empty output", ex)
+	}
+
+	greeter := findType(pkg, "Greeter")
+	if greeter == nil {
+		t.Fatal("type Greeter not found")
+	}
+	method := findFunc(greeter.Methods, "Greet")
+	if method == nil || len(method.Examples) != 1 || method.Examples[0].Output != "hi gopher\n" {
+		t.Errorf("Greeter.Greet examples = %+v", method)
+	}
+	if len(greeter.Examples) != 0 {
+		t.Errorf("method examples should not be listed under the type, got %+v", greeter.Examples)
+	}
+}
+
+func TestExtractPackageDoc_Deprecated(t *testing.T) {
+	pkg := extractFixture(t, "deprecated")
+
+	if !pkg.Deprecated || pkg.Synopsis != "Package deprecated marks symbols, fields, and constants as deprecated." {
+		t.Errorf("package Deprecated = %v, synopsis %q; want deprecated with the first sentence as synopsis", pkg.Deprecated, pkg.Synopsis)
+	}
+	if extractFixture(t, "generics").Deprecated {
+		t.Error("generics package should not be deprecated")
+	}
+
+	if old := findFunc(pkg.Functions, "Old"); old == nil || !old.Deprecated {
+		t.Error("Old should be deprecated")
+	}
+	if fresh := findFunc(pkg.Functions, "New"); fresh == nil || fresh.Deprecated {
+		t.Error("New should not be deprecated")
+	}
+
+	config := findType(pkg, "Config")
+	if config == nil || !reflect.DeepEqual(config.DeprecatedFields, []string{"Title"}) {
+		t.Errorf("Config deprecated fields = %+v, want [Title]", config)
+	}
+
+	if len(pkg.Constants) != 1 || !reflect.DeepEqual(pkg.Constants[0].DeprecatedNames, []string{"ModeQuick"}) {
+		t.Errorf("Constants = %+v, want ModeQuick deprecated", pkg.Constants)
+	}
+}
+
+func TestExtractPackageDoc_BuildConstraints(t *testing.T) {
+	pkg := extractFixture(t, "constraints")
+
+	// gen.go is an ignored package main generator and must not be documented
+	if pkg.Name != "constraints" {
+		t.Errorf("Name = %q, want constraints", pkg.Name)
+	}
+	if findFunc(pkg.Functions, "main") != nil {
+		t.Error("main from the ignored generator should not be documented")
+	}
+	for _, name := range []string{"Portable", "LinuxOnly", "WindowsOnly"} {
+		if findFunc(pkg.Functions, name) == nil {
+			t.Errorf("function %s not found", name)
+		}
+	}
+	if !reflect.DeepEqual(pkg.GOOS, []string{"linux", "windows"}) {
+		t.Errorf("GOOS = %v, want [linux windows]", pkg.GOOS)
+	}
+	if !reflect.DeepEqual(pkg.GOARCH, []string{"amd64"}) {
+		t.Errorf("GOARCH = %v, want [amd64]", pkg.GOARCH)
+	}
+	if want := []string{"bytes", "errors", "fmt", "os", "strings"}; !reflect.DeepEqual(pkg.Imports, want) {
+		t.Errorf("Imports = %v, want %v", pkg.Imports, want)
+	}
+}
+
+func TestExtractPackageDoc_Deterministic(t *testing.T) {
+	first, err := json.Marshal(extractFixture(t, "constraints"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 5 {
+		again, err := json.Marshal(extractFixture(t, "constraints"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(again, first) {
+			t.Fatalf("extracting twice produced different JSON:\n%s\n%s", first, again)
+		}
+	}
+}
+
+func TestVerifyExamples(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs fixture examples")
+	}
+
+	pkg := extractFixture(t, "staleexamples")
+	if err := verifyExamples(pkg, "./testdata/extract/staleexamples", time.Minute); err != nil {
+		t.Fatalf("verifyExamples() error = %v", err)
+	}
+	double := findFunc(pkg.Functions, "Double")
+	if double == nil {
+		t.Fatal("Double not found")
+	}
+	want := map[string]bool{
+		"Double":          true,  // prints its declared output
+		"Double_stale":    false, // prints something else
+		"Double_noOutput": false, // declares no output, so it is never run
+	}
+	if len(double.Examples) != len(want) {
+		t.Fatalf("Double examples = %+v, want %d", double.Examples, len(want))
+	}
+	for _, ex := range double.Examples {
+		if ex.Verified != want[ex.Name] {
+			t.Errorf("example %s: Verified = %v, want %v", ex.Name, ex.Verified, want[ex.Name])
+		}
+	}
+
+	methods := extractFixture(t, "examples")
+	if err := verifyExamples(methods, "./testdata/extract/examples", time.Minute); err != nil {
+		t.Fatalf("verifyExamples(examples) error = %v", err)
+	}
+	for _, ex := range allExamples(methods) {
+		if !ex.Verified {
+			t.Errorf("example %s not verified", ex.Name)
+		}
+	}
+
+	broken := extractFixture(t, "brokenexamples")
+	err := verifyExamples(broken, "./testdata/extract/brokenexamples", time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "too many arguments") {
+		t.Errorf("verifyExamples(brokenexamples) error = %v, want the compile error", err)
+	}
+	for _, ex := range allExamples(broken) {
+		if ex.Verified {
+			t.Errorf("example %s verified although it does not compile", ex.Name)
+		}
+	}
+}
+
+func TestIncludeSources(t *testing.T) {
+	pkg := extractFixture(t, "constraints")
+
+	if err := includeSources(pkg, 10); err == nil || pkg.Sources != nil {
+		t.Errorf("includeSources() over the limit = %v, sources %v, want an error and no sources", err, pkg.Sources)
+	}
+
+	if err := includeSources(pkg, 1<<20); err != nil {
+		t.Fatalf("includeSources() error = %v", err)
+	}
+	if len(pkg.Sources) != len(pkg.Filenames) {
+		t.Fatalf("included %d sources, want one per file in %v", len(pkg.Sources), pkg.Filenames)
+	}
+	src, ok := pkg.Sources["constraints.go"]
+	if !ok || !strings.HasPrefix(src, "// Package constraints has platform-specific files.") {
+		t.Errorf("Sources[constraints.go] = %q, want the file content", src)
+	}
+}
+
+func TestFormatText(t *testing.T) {
+	pkg := extractFixture(t, "generics")
+
+	want := `package generics // import "./testdata/extract/generics"
+
+Package generics exercises type parameters in signatures and declarations.
+
+FUNCTIONS
+
+func Clamp[T interface{ ~int | ~float64 }](v, lo, hi T) T
+func Index(names []string) map[string]*Set[string]
+func Keys[M ~map[K]V, K comparable, V any](m M) []K
+func Map[T, U any](s []T, f func(T) U) []U
+func Sum[N Number](s ...N) N
+
+TYPES
+
+type Number interface{ ... }
+type Result[T, E any] struct{ ... }
+    func Lookup(name string) Result[User, error]
+type Set[T comparable] struct{ ... }
+    func NewSet[T comparable]() *Set[T]
+    func (s *Set[T]) Add(v T)
+    func (s *Set[T]) Len() int
+type User struct{ ... }
+`
+	if got := formatText(pkg); got != want {
+		t.Errorf("formatText() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := formatText(extractFixture(t, "deprecated")); !strings.Contains(got, "\nCONSTANTS\n\nconst ModeFast, ModeQuick\n") {
+		t.Errorf("formatText(deprecated) does not list the grouped constants by name:\n%s", got)
+	}
+}
+
+func TestWriteOutput(t *testing.T) {
+	pkg := extractFixture(t, "examples")
+
+	var jsonOut, yamlOut bytes.Buffer
+	if err := writeOutput(&jsonOut, pkg, "json"); err != nil {
+		t.Fatalf("writeOutput(json) error = %v", err)
+	}
+	var decoded PackageDoc
+	if err := json.Unmarshal(jsonOut.Bytes(), &decoded); err != nil || decoded.ImportPath != pkg.ImportPath {
+		t.Errorf("writeOutput(json) = %s, %v", jsonOut.String(), err)
+	}
+
+	if err := writeOutput(&yamlOut, pkg, "yaml"); err != nil {
+		t.Fatalf("writeOutput(yaml) error = %v", err)
+	}
+	for _, want := range []string{
+		"import_path: ./testdata/extract/examples\n",
+		"synopsis: Package examples has runnable examples.\n",
+		"functions:\n  - name: Greet\n",
+		"        empty_output: true\n",
+	} {
+		if !strings.Contains(yamlOut.String(), want) {
+			t.Errorf("writeOutput(yaml) does not contain %q:\n%s", want, yamlOut.String())
+		}
+	}
+}
@@ -0,0 +1,13 @@
+// Package aliases exercises type alias declarations.
+package aliases
+
+import "io"
+
+// Real is the type A aliases.
+type Real struct{}
+
+// A is an alias for a type in the same package.
+type A = Real
+
+// W is an alias for a type in another package.
+type W = io.Writer
@@ -0,0 +1,5 @@
+// Package brokenexamples has an example that no longer compiles.
+package brokenexamples
+
+// Triple returns three times n.
+func Triple(n int) int { return 3 * n }
@@ -0,0 +1,12 @@
+package brokenexamples_test
+
+import (
+	"fmt"
+
+	"github.com/alexisbouchez/wikigo/testdata/extract/brokenexamples"
+)
+
+func ExampleTriple() {
+	fmt.Println(brokenexamples.Triple(2, 1))
+	// Output: 6
+}
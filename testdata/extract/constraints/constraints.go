@@ -0,0 +1,15 @@
+// Package constraints has platform-specific files.
+package constraints
+
+import (
+	"strings"
+	"errors"
+	"os"
+	"fmt"
+	"bytes"
+)
+
+var _ = []any{strings.ToUpper, errors.New, os.Exit, fmt.Sprint, bytes.Equal}
+
+// Portable works everywhere.
+func Portable() {}
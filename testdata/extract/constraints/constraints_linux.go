@@ -0,0 +1,4 @@
+package constraints
+
+// LinuxOnly is only built on Linux.
+func LinuxOnly() {}
@@ -0,0 +1,4 @@
+package constraints
+
+// WindowsOnly is only built on 64-bit Windows.
+func WindowsOnly() {}
@@ -0,0 +1,6 @@
+//go:build ignore
+
+// gen.go generates nothing; it is excluded from the build.
+package main
+
+func main() {}
@@ -0,0 +1,27 @@
+// Package deprecated marks symbols, fields, and constants as deprecated.
+//
+// Deprecated: use example.com/deprecated/v2 instead.
+package deprecated
+
+// Old does nothing.
+//
+// Deprecated: use New instead.
+func Old() {}
+
+// New does nothing, better.
+func New() {}
+
+// Config configures things.
+type Config struct {
+	Name string
+
+	// Deprecated: use Name.
+	Title string
+}
+
+// Modes.
+const (
+	ModeFast = iota
+	// Deprecated: same as ModeFast.
+	ModeQuick
+)
@@ -0,0 +1,22 @@
+// Package embedded exercises methods and embedded types.
+package embedded
+
+import "io"
+
+// Base provides a greeting.
+type Base struct{}
+
+// Hello returns a greeting.
+func (Base) Hello() string { return "hello" }
+
+// Derived embeds Base and an io.Writer.
+type Derived struct {
+	Base
+	io.Writer
+
+	// Name is the display name.
+	Name string
+}
+
+// Rename changes the display name.
+func (d *Derived) Rename(name string) { d.Name = name }
@@ -0,0 +1,32 @@
+package examples_test
+
+import (
+	"fmt"
+
+	"github.com/alexisbouchez/wikigo/testdata/extract/examples"
+)
+
+func ExampleGreet() {
+	fmt.Println(examples.Greet("gopher"))
+	// Output: hello gopher
+}
+
+func ExampleGreet_unordered() {
+	for _, name := range []string{"ann", "bob"} {
+		fmt.Println(examples.Greet(name))
+	}
+	// Unordered output:
+	// hello bob
+	// hello ann
+}
+
+func ExampleGreet_silent() {
+	_ = examples.Greet("gopher")
+	// Output:
+}
+
+func ExampleGreeter_Greet() {
+	g := examples.Greeter{Prefix: "hi "}
+	fmt.Println(g.Greet("gopher"))
+	// Output: hi gopher
+}
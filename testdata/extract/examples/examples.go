@@ -0,0 +1,11 @@
+// Package examples has runnable examples.
+package examples
+
+// Greet returns a greeting for name.
+func Greet(name string) string { return "hello " + name }
+
+// Greeter greets people.
+type Greeter struct{ Prefix string }
+
+// Greet greets name with the prefix.
+func (g Greeter) Greet(name string) string { return g.Prefix + name }
@@ -0,0 +1,78 @@
+// Package generics exercises type parameters in signatures and declarations.
+package generics
+
+// Number is a constraint satisfied by integer and float types.
+type Number interface {
+	~int | ~int64 | ~float64
+}
+
+// Map returns the result of applying f to each element of s.
+func Map[T, U any](s []T, f func(T) U) []U {
+	out := make([]U, 0, len(s))
+	for _, v := range s {
+		out = append(out, f(v))
+	}
+	return out
+}
+
+// Sum adds up the elements of s.
+func Sum[N Number](s ...N) N {
+	var total N
+	for _, v := range s {
+		total += v
+	}
+	return total
+}
+
+// Set is a set of comparable values.
+type Set[T comparable] struct {
+	m map[T]struct{}
+}
+
+// NewSet returns an empty set.
+func NewSet[T comparable]() *Set[T] {
+	return &Set[T]{m: make(map[T]struct{})}
+}
+
+// Add inserts v into the set.
+func (s *Set[T]) Add(v T) {
+	s.m[v] = struct{}{}
+}
+
+// Len reports the number of elements.
+func (s *Set[T]) Len() int { return len(s.m) }
+
+// Result holds either a value or an error.
+type Result[T, E any] struct {
+	Value T
+	Err   E
+}
+
+// User is a registered user.
+type User struct {
+	Name string
+}
+
+// Lookup finds a user by name. It returns a [Result[User, error]].
+func Lookup(name string) Result[User, error] {
+	return Result[User, error]{Value: User{Name: name}}
+}
+
+// Index groups names into sets by their first letter.
+func Index(names []string) map[string]*Set[string] {
+	return nil
+}
+
+// Keys returns the keys of m in no particular order.
+func Keys[M ~map[K]V, K comparable, V any](m M) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Clamp limits v to the range [lo, hi].
+func Clamp[T interface{ ~int | ~float64 }](v, lo, hi T) T {
+	return min(max(v, lo), hi)
+}
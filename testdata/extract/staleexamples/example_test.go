@@ -0,0 +1,21 @@
+package staleexamples_test
+
+import (
+	"fmt"
+
+	"github.com/alexisbouchez/wikigo/testdata/extract/staleexamples"
+)
+
+func ExampleDouble() {
+	fmt.Println(staleexamples.Double(2))
+	// Output: 4
+}
+
+func ExampleDouble_stale() {
+	fmt.Println(staleexamples.Double(3))
+	// Output: 5
+}
+
+func ExampleDouble_noOutput() {
+	fmt.Println(staleexamples.Double(4))
+}
@@ -0,0 +1,5 @@
+// Package staleexamples has examples whose output has drifted.
+package staleexamples
+
+// Double returns twice n.
+func Double(n int) int { return 2 * n }
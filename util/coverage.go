@@ -0,0 +1,108 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// coverageFiles are the files a module may report its test coverage in, at its root
+var coverageFiles = []string{"coverage.txt", "coverage.out", "cover.out"}
+
+// readmeFiles are the README names checked for coverage badges
+var readmeFiles = []string{"README.md", "README.markdown", "README", "README.rst", "README.txt"}
+
+var (
+	// coverageLineRe matches "coverage: 87.5% of statements" as printed by go test -cover
+	coverageLineRe = regexp.MustCompile(`(?i)\bcoverage:?\s+(\d{1,3}(?:\.\d+)?)%`)
+	// coverageBadgeRe matches shields.io badges such as
+	// img.shields.io/badge/coverage-87%25-green or .../Go%20Coverage-87.5%25-brightgreen
+	coverageBadgeRe = regexp.MustCompile(`(?i)img\.shields\.io/badge/[^-/()\s]*coverage-(\d{1,3}(?:\.\d+)?)(?:%25|%)`)
+)
+
+// DetectCoverage returns the test coverage a module reports in its root
+// directory, such as "87.5%", or "" when there is none. It reads a Go cover
+// profile or the output of go test -cover from coverage.txt, and falls back
+// to a coverage badge in the README.
+func DetectCoverage(dir string) string {
+	for _, name := range coverageFiles {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		if coverage := coverageFromProfile(data); coverage != "" {
+			return coverage
+		}
+		if m := coverageLineRe.FindSubmatch(data); m != nil {
+			return coveragePercent(string(m[1]))
+		}
+	}
+	for _, name := range readmeFiles {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		if m := coverageBadgeRe.FindSubmatch(data); m != nil {
+			return coveragePercent(string(m[1]))
+		}
+	}
+	return ""
+}
+
+// coverageFromProfile computes the statement coverage of a Go cover profile,
+// as go tool cover -func does, or returns "" when data is not a profile.
+// Blocks repeated by merged profiles count once, covered if any run covered them.
+func coverageFromProfile(data []byte) string {
+	if !bytes.HasPrefix(data, []byte("mode:")) {
+		return ""
+	}
+	type block struct {
+		statements int
+		covered    bool
+	}
+	blocks := make(map[string]*block)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// file.go:12.34,15.2 3 1
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || strings.HasPrefix(fields[0], "mode:") {
+			continue
+		}
+		statements, err1 := strconv.Atoi(fields[1])
+		count, err2 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		b, ok := blocks[fields[0]]
+		if !ok {
+			b = &block{statements: statements}
+			blocks[fields[0]] = b
+		}
+		b.covered = b.covered || count > 0
+	}
+
+	var total, covered int
+	for _, b := range blocks {
+		total += b.statements
+		if b.covered {
+			covered += b.statements
+		}
+	}
+	if total == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.1f%%", float64(covered)*100/float64(total))
+}
+
+// coveragePercent formats a reported percentage, or returns "" when it is out of range
+func coveragePercent(value string) string {
+	if f, err := strconv.ParseFloat(value, 64); err != nil || f > 100 {
+		return ""
+	}
+	return value + "%"
+}
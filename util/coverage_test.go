@@ -0,0 +1,44 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectCoverage(t *testing.T) {
+	profile := "mode: set\n" +
+		"example.com/m/a.go:3.14,5.2 2 1\n" +
+		"example.com/m/a.go:7.14,9.2 3 0\n" +
+		"example.com/m/b.go:3.14,5.2 3 0\n" +
+		"mode: set\n" +
+		"example.com/m/b.go:3.14,5.2 3 1\n" // merged run covering b.go
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"cover profile", map[string]string{"coverage.txt": profile}, "62.5%"},
+		{"go test output", map[string]string{"coverage.txt": "ok  \texample.com/m\t0.01s\tcoverage: 81.3% of statements\n"}, "81.3%"},
+		{"shields badge", map[string]string{"README.md": "[![Coverage](https://img.shields.io/badge/coverage-87%25-brightgreen)](https://example.com)\n"}, "87%"},
+		{"gopherbadger badge", map[string]string{"README.md": "![](https://img.shields.io/badge/Go%20Coverage-92.4%25-brightgreen.svg?longCache=true)"}, "92.4%"},
+		{"coverage file first", map[string]string{"coverage.txt": profile, "README.md": "https://img.shields.io/badge/coverage-87%25-green"}, "62.5%"},
+		{"prose is not a figure", map[string]string{"README.md": "We aim for coverage: 100% of the API.\n"}, ""},
+		{"out of range", map[string]string{"README.md": "https://img.shields.io/badge/coverage-870%25-green"}, ""},
+		{"none", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := DetectCoverage(dir); got != tt.want {
+				t.Errorf("DetectCoverage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package util
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/types"
+	"regexp"
+	"strings"
+)
+
+// exportedIdent matches exported identifiers not already qualified by a package
+var exportedIdent = regexp.MustCompile(`(^|[^.\w])([A-Z]\w*)`)
+
+// MethodSet returns the method set of a documented type, used to match types
+// against interfaces: for an interface, the methods it requires, and for
+// another type, the methods declared on it with "*" marking pointer receivers.
+// Signatures are normalized by MethodSignature so they compare across packages.
+func MethodSet(docPkg *doc.Package, t *doc.Type) (isInterface bool, set []string) {
+	if iface := interfaceType(t); iface != nil {
+		return true, interfaceMethodSet(docPkg, iface, map[string]bool{t.Name: true})
+	}
+	for _, m := range t.Methods {
+		sig := MethodSignature(docPkg.Name, m.Name, m.Decl.Type)
+		if m.Decl.Recv != nil && len(m.Decl.Recv.List) > 0 {
+			if _, ok := m.Decl.Recv.List[0].Type.(*ast.StarExpr); ok {
+				sig = "*" + sig
+			}
+		}
+		set = append(set, sig)
+	}
+	return false, set
+}
+
+// MethodSignature formats a method as "Name(params) results" using only types,
+// qualifying the package's own exported types so signatures compare across packages
+func MethodSignature(pkgName, name string, ft *ast.FuncType) string {
+	typeList := func(fields *ast.FieldList) []string {
+		var out []string
+		if fields == nil {
+			return out
+		}
+		for _, f := range fields.List {
+			t := exportedIdent.ReplaceAllString(types.ExprString(f.Type), "${1}"+pkgName+".$2")
+			for n := max(len(f.Names), 1); n > 0; n-- {
+				out = append(out, t)
+			}
+		}
+		return out
+	}
+
+	sig := name + "(" + strings.Join(typeList(ft.Params), ", ") + ")"
+	switch results := typeList(ft.Results); len(results) {
+	case 0:
+	case 1:
+		sig += " " + results[0]
+	default:
+		sig += " (" + strings.Join(results, ", ") + ")"
+	}
+	return sig
+}
+
+// interfaceType returns the interface a documented type declares, or nil
+func interfaceType(t *doc.Type) *ast.InterfaceType {
+	for _, spec := range t.Decl.Specs {
+		if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.Name == t.Name {
+			iface, _ := ts.Type.(*ast.InterfaceType)
+			return iface
+		}
+	}
+	return nil
+}
+
+// interfaceMethodSet returns the methods an interface requires, expanding interfaces
+// embedded from the same package. It returns nil when the set cannot be resolved,
+// such as for interfaces embedding types from other packages or type constraints.
+func interfaceMethodSet(docPkg *doc.Package, iface *ast.InterfaceType, seen map[string]bool) []string {
+	var set []string
+	for _, field := range iface.Methods.List {
+		if ft, ok := field.Type.(*ast.FuncType); ok {
+			for _, name := range field.Names {
+				set = append(set, MethodSignature(docPkg.Name, name.Name, ft))
+			}
+			continue
+		}
+
+		ident, ok := field.Type.(*ast.Ident)
+		if !ok || seen[ident.Name] {
+			return nil
+		}
+		if ident.Name == "error" {
+			set = append(set, "Error() string")
+			continue
+		}
+		var embedded *ast.InterfaceType
+		for _, t := range docPkg.Types {
+			if t.Name == ident.Name {
+				embedded = interfaceType(t)
+			}
+		}
+		if embedded == nil {
+			return nil
+		}
+		seen[ident.Name] = true
+		sub := interfaceMethodSet(docPkg, embedded, seen)
+		if sub == nil {
+			return nil
+		}
+		set = append(set, sub...)
+	}
+	return set
+}
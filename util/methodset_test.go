@@ -0,0 +1,75 @@
+package util
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"slices"
+	"testing"
+)
+
+func TestMethodSet(t *testing.T) {
+	const src = `package io
+
+type Reader interface {
+	Read(p []byte) (n int, err error)
+}
+
+type ReadCloser interface {
+	Reader
+	Close() error
+}
+
+type ErrReader interface {
+	error
+	Reader
+}
+
+type Foreign interface {
+	fmt.Stringer
+}
+
+type File struct{}
+
+func (f *File) Read(p []byte) (int, error) { return 0, nil }
+func (f File) Name() string              { return "" }
+func (f *File) Chain(next Reader) *File  { return f }
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "io.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docPkg, err := doc.NewFromFiles(fset, []*ast.File{f}, "io")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		iface bool
+		want  []string
+	}{
+		{"Reader", true, []string{"Read([]byte) (int, error)"}},
+		{"ReadCloser", true, []string{"Read([]byte) (int, error)", "Close() error"}},
+		{"ErrReader", true, []string{"Error() string", "Read([]byte) (int, error)"}},
+		{"Foreign", true, nil}, // embeds an interface from another package
+		{"File", false, []string{"*Chain(io.Reader) *io.File", "Name() string", "*Read([]byte) (int, error)"}},
+	}
+	for _, tt := range tests {
+		var typ *doc.Type
+		for _, dt := range docPkg.Types {
+			if dt.Name == tt.name {
+				typ = dt
+			}
+		}
+		if typ == nil {
+			t.Fatalf("type %s not found", tt.name)
+		}
+		isInterface, got := MethodSet(docPkg, typ)
+		if isInterface != tt.iface || !slices.Equal(got, tt.want) {
+			t.Errorf("MethodSet(%s) = %v, %q, want %v, %q", tt.name, isInterface, got, tt.iface, tt.want)
+		}
+	}
+}
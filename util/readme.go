@@ -0,0 +1,89 @@
+package util
+
+import (
+	"go/doc"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// markdownLinkRe matches [text](url) links, keeping their text
+	markdownLinkRe = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	// markdownEmphasisRe matches **bold**, __bold__, *italic* and `code` spans
+	markdownEmphasisRe = regexp.MustCompile("\\*\\*([^*]+)\\*\\*|__([^_]+)__|\\*([^*\\s][^*]*)\\*|`([^`]*)`")
+	// setextUnderlineRe matches the ==== and ---- lines under setext headings
+	setextUnderlineRe = regexp.MustCompile(`^(=+|-+)$`)
+)
+
+// ReadmeSynopsis returns a one-sentence synopsis taken from the first paragraph
+// of prose in the README of dir, or "" when there is none. Headings, badges,
+// images, HTML, code blocks and tables are skipped.
+func ReadmeSynopsis(dir string) string {
+	for _, name := range readmeFiles {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		if synopsis := readmeSynopsis(string(data)); synopsis != "" {
+			return synopsis
+		}
+	}
+	return ""
+}
+
+// readmeSynopsis returns the synopsis of a README's text
+func readmeSynopsis(text string) string {
+	var paragraph []string
+	inFence := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if setextUnderlineRe.MatchString(line) {
+			paragraph = nil // the paragraph was a heading
+			continue
+		}
+		if line == "" {
+			if len(paragraph) > 0 {
+				break
+			}
+			continue
+		}
+		if skipReadmeLine(line) {
+			if len(paragraph) > 0 {
+				break
+			}
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+
+	prose := markdownLinkRe.ReplaceAllString(strings.Join(paragraph, " "), "$1")
+	prose = markdownEmphasisRe.ReplaceAllString(prose, "$1$2$3$4")
+	return doc.Synopsis(prose)
+}
+
+// skipReadmeLine reports whether a README line is not prose: a heading, a
+// badge or image, HTML, a table row, a quote or a list item
+func skipReadmeLine(line string) bool {
+	switch {
+	case strings.HasPrefix(line, "#"),
+		strings.HasPrefix(line, "!["),
+		strings.HasPrefix(line, "[!["),
+		strings.HasPrefix(line, "<"),
+		strings.HasPrefix(line, "|"),
+		strings.HasPrefix(line, ">"),
+		strings.HasPrefix(line, "- "), strings.HasPrefix(line, "* "), strings.HasPrefix(line, "+ "),
+		strings.HasPrefix(line, ".. "): // reStructuredText directives
+		return true
+	}
+	// A line of only links, such as a table of contents entry or badge row
+	return strings.TrimSpace(markdownLinkRe.ReplaceAllString(line, "")) == ""
+}
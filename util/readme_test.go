@@ -0,0 +1,52 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadmeSynopsis(t *testing.T) {
+	tests := []struct {
+		name   string
+		readme string
+		want   string
+	}{
+		{
+			"heading and badges",
+			"# widgets\n\n[![Go Reference](https://pkg.go.dev/badge/x.svg)](https://pkg.go.dev/x) [![CI](https://x/ci.svg)](https://x/ci)\n\nPackage widgets builds **fast** widgets for `net/http` servers. It has no dependencies.\n",
+			"Package widgets builds fast widgets for net/http servers.",
+		},
+		{
+			"wrapped paragraph with links",
+			"Widgets is a [toolkit](https://example.com) for building\nwidgets quickly.\n\nMore text.\n",
+			"Widgets is a toolkit for building widgets quickly.",
+		},
+		{
+			"setext heading and html",
+			"Widgets\n=======\n\n<p align=\"center\"><img src=\"logo.png\"></p>\n\nA widget library\n",
+			"A widget library",
+		},
+		{
+			"code and lists only",
+			"# widgets\n\n```go\nwidgets.New()\n```\n\n- fast\n- small\n",
+			"",
+		},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte(tt.readme), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if got := ReadmeSynopsis(dir); got != tt.want {
+				t.Errorf("ReadmeSynopsis() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := ReadmeSynopsis(t.TempDir()); got != "" {
+		t.Errorf("ReadmeSynopsis() without a README = %q, want \"\"", got)
+	}
+}
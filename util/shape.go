@@ -0,0 +1,251 @@
+package util
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"regexp"
+	"strings"
+)
+
+// Shape is the structure of a function signature: the types of its parameters
+// and results, without receiver or names
+type Shape struct {
+	Params  []string
+	Results []string
+}
+
+// String formats the shape as a function type, e.g. "func([]byte) (int, error)"
+func (s Shape) String() string {
+	out := "func(" + strings.Join(s.Params, ", ") + ")"
+	switch len(s.Results) {
+	case 0:
+	case 1:
+		out += " " + s.Results[0]
+	default:
+		out += " (" + strings.Join(s.Results, ", ") + ")"
+	}
+	return out
+}
+
+// SignatureShape reduces a function or method declaration such as
+// "func (b *Buffer) Write(p []byte) (n int, err error)" to its shape,
+// "func([]byte) (int, error)". It reports false when sig does not parse.
+func SignatureShape(sig string) (Shape, bool) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", "package p\n"+sig, 0)
+	if err != nil || len(file.Decls) != 1 {
+		return Shape{}, false
+	}
+	fn, ok := file.Decls[0].(*ast.FuncDecl)
+	if !ok {
+		return Shape{}, false
+	}
+	return funcShape(fn.Type), true
+}
+
+// restParam stands in for a trailing "..." in shape patterns while they are parsed
+const restParam = "_shape_rest_"
+
+// trailingRest matches a "..." that ends a parameter or result list rather than starting a variadic type
+var trailingRest = regexp.MustCompile(`\.\.\.(\s*\))`)
+
+// ParseShapePattern parses a shape to search for, like "func(context.Context, ...) (T, error)".
+// Names are optional and dropped, the leading func may be omitted, single capital
+// letters stand for any type and a final "..." for any further parameters or results.
+func ParseShapePattern(pattern string) (Shape, error) {
+	pattern = strings.TrimSpace(pattern)
+	if !strings.HasPrefix(pattern, "func") {
+		pattern = "func" + pattern
+	}
+	expr, err := parser.ParseExpr(trailingRest.ReplaceAllString(pattern, restParam+"$1"))
+	if err != nil {
+		return Shape{}, fmt.Errorf("invalid shape %q: %w", pattern, err)
+	}
+	ft, ok := expr.(*ast.FuncType)
+	if !ok {
+		return Shape{}, fmt.Errorf("invalid shape %q: not a function type", pattern)
+	}
+	shape := funcShape(ft)
+	for _, list := range [][]string{shape.Params, shape.Results} {
+		for i, typ := range list {
+			if typ == restParam {
+				list[i] = "..."
+			}
+		}
+	}
+	return shape, nil
+}
+
+// funcShape lists the parameter and result types of a function type, one per name
+func funcShape(ft *ast.FuncType) Shape {
+	ast.Inspect(ft, func(n ast.Node) bool {
+		if inner, ok := n.(*ast.FuncType); ok {
+			inner.TypeParams = nil
+			stripNames(inner.Params)
+			stripNames(inner.Results)
+		}
+		return true
+	})
+
+	var shape Shape
+	if ft.Params != nil {
+		for _, field := range ft.Params.List {
+			shape.Params = append(shape.Params, types.ExprString(field.Type))
+		}
+	}
+	if ft.Results != nil {
+		for _, field := range ft.Results.List {
+			shape.Results = append(shape.Results, types.ExprString(field.Type))
+		}
+	}
+	return shape
+}
+
+// stripNames drops the names of a field list, repeating the type of grouped
+// fields like "a, b int" once per name
+func stripNames(fields *ast.FieldList) {
+	if fields == nil {
+		return
+	}
+	var list []*ast.Field
+	for _, field := range fields.List {
+		for range max(len(field.Names), 1) {
+			list = append(list, &ast.Field{Type: field.Type})
+		}
+	}
+	fields.List = list
+}
+
+// Match reports whether shape has the form of pattern: the same types, except
+// that each single capital letter in pattern matches any one type, consistently
+// throughout, and a final "..." matches any remaining types
+func (pattern Shape) Match(shape Shape) bool {
+	bindings := make(map[string]string)
+	return matchTypes(pattern.Params, shape.Params, bindings) && matchTypes(pattern.Results, shape.Results, bindings)
+}
+
+// matchTypes matches a list of types against a pattern list
+func matchTypes(pattern, list []string, bindings map[string]string) bool {
+	if n := len(pattern); n > 0 && pattern[n-1] == "..." {
+		pattern = pattern[:n-1]
+		if len(list) < len(pattern) {
+			return false
+		}
+		list = list[:len(pattern)]
+	}
+	if len(pattern) != len(list) {
+		return false
+	}
+	for i := range pattern {
+		if !matchTokens(typeTokens(pattern[i]), typeTokens(list[i]), bindings) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchTokens matches the tokens of a type against a pattern, where wildcards
+// match a balanced run of tokens
+func matchTokens(pattern, typ []string, bindings map[string]string) bool {
+	if len(pattern) == 0 {
+		return len(typ) == 0
+	}
+	if !isShapeWildcard(pattern[0]) {
+		return len(typ) > 0 && pattern[0] == typ[0] && matchTokens(pattern[1:], typ[1:], bindings)
+	}
+
+	name := pattern[0]
+	for n := 1; n <= len(typ); n++ {
+		if !balancedTokens(typ[:n]) {
+			continue
+		}
+		value := strings.Join(typ[:n], " ")
+		prev, bound := bindings[name]
+		if bound && prev != value {
+			continue
+		}
+		bindings[name] = value
+		if matchTokens(pattern[1:], typ[n:], bindings) {
+			return true
+		}
+		if !bound {
+			delete(bindings, name)
+		}
+	}
+	return false
+}
+
+// isShapeWildcard reports whether a pattern token is a single capital letter
+func isShapeWildcard(tok string) bool {
+	return len(tok) == 1 && tok[0] >= 'A' && tok[0] <= 'Z'
+}
+
+// balancedTokens reports whether tokens form a whole type: brackets balance and
+// no comma separates two types
+func balancedTokens(tokens []string) bool {
+	depth := 0
+	for _, tok := range tokens {
+		switch tok {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			if depth--; depth < 0 {
+				return false
+			}
+		case ",":
+			if depth == 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+// typeTokens splits a type expression into Go tokens
+func typeTokens(typ string) []string {
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	src := []byte(typ)
+	s.Init(fset.AddFile("", fset.Base(), len(src)), src, nil, 0)
+
+	var tokens []string
+	for {
+		_, tok, lit := s.Scan()
+		switch {
+		case tok == token.EOF:
+			return tokens
+		case tok == token.SEMICOLON && lit == "\n":
+			// automatically inserted
+		case tok == token.IDENT && len(tokens) >= 2 && tokens[len(tokens)-1] == ".":
+			// Keep qualified identifiers whole, so io.R is not a wildcard
+			tokens[len(tokens)-2] += "." + lit
+			tokens = tokens[:len(tokens)-1]
+		case lit != "":
+			tokens = append(tokens, lit)
+		default:
+			tokens = append(tokens, tok.String())
+		}
+	}
+}
+
+// shapeWildcard finds the first wildcard type or "..." in a formatted shape pattern
+var shapeWildcard = regexp.MustCompile(`(^|[^\w.]|\.\.\.)[A-Z]\b|\.\.\.[,)]`)
+
+// LiteralPrefix returns the part of the formatted pattern before its first
+// wildcard, which every matching shape starts with
+func (pattern Shape) LiteralPrefix() string {
+	s := pattern.String()
+	if loc := shapeWildcard.FindStringIndex(s); loc != nil {
+		prefix := s[:loc[0]]
+		// Keep the character preceding a wildcard type, like "(" or " "
+		if s[loc[0]] != '.' && !isShapeWildcard(s[loc[0]:loc[0]+1]) {
+			prefix += s[loc[0] : loc[0]+1]
+		}
+		// "func(string, ...)" also matches "func(string)"
+		return strings.TrimSuffix(prefix, ", ")
+	}
+	return s
+}
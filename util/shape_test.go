@@ -0,0 +1,90 @@
+package util
+
+import "testing"
+
+func TestSignatureShape(t *testing.T) {
+	tests := []struct {
+		sig  string
+		want string
+	}{
+		{"func (b *Buffer) Write(p []byte) (n int, err error)", "func([]byte) (int, error)"},
+		{"func Copy(dst io.Writer, src io.Reader) (written int64, err error)", "func(io.Writer, io.Reader) (int64, error)"},
+		{"func Map[T, U any](s []T, f func(x T) U) []U", "func([]T, func(T) U) []U"},
+		{"func Max(a, b int) int", "func(int, int) int"},
+		{"func Printf(format string, args ...any)", "func(string, ...any)"},
+		{"func Now() time.Time", "func() time.Time"},
+	}
+	for _, tt := range tests {
+		got, ok := SignatureShape(tt.sig)
+		if !ok || got.String() != tt.want {
+			t.Errorf("SignatureShape(%q) = %q, %v, want %q", tt.sig, got, ok, tt.want)
+		}
+	}
+
+	if _, ok := SignatureShape("type T struct{}"); ok {
+		t.Error("SignatureShape() accepted a type declaration")
+	}
+}
+
+func TestShapeMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		shape   string
+		want    bool
+	}{
+		{"func([]byte) (int, error)", "func([]byte) (int, error)", true},
+		{"([]byte) (int, error)", "func([]byte) (int, error)", true},
+		{"func(p []byte) (n int, err error)", "func([]byte) (int, error)", true},
+		{"func([]byte) (int, error)", "func([]byte) int", false},
+		{"func(T) T", "func(string) string", true},
+		{"func(T) T", "func(string) int", false},
+		{"func([]T) T", "func([]map[string]int) map[string]int", true},
+		{"func(map[K]V) []K", "func(map[string]int) []string", true},
+		{"func(context.Context, ...) error", "func(context.Context) error", true},
+		{"func(context.Context, ...) error", "func(context.Context, string, int) error", true},
+		{"func(context.Context, ...) error", "func(string) error", false},
+		{"func(string, ...T)", "func(string, ...any)", true},
+		{"func(io.R)", "func(io.Reader)", false},
+		{"func() (T, ...)", "func() (int, bool, error)", true},
+	}
+	for _, tt := range tests {
+		pattern, err := ParseShapePattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("ParseShapePattern(%q) error = %v", tt.pattern, err)
+		}
+		shape, err := ParseShapePattern(tt.shape)
+		if err != nil {
+			t.Fatalf("ParseShapePattern(%q) error = %v", tt.shape, err)
+		}
+		if got := pattern.Match(shape); got != tt.want {
+			t.Errorf("%q.Match(%q) = %v, want %v", tt.pattern, tt.shape, got, tt.want)
+		}
+	}
+
+	if _, err := ParseShapePattern("func(int"); err == nil {
+		t.Error("ParseShapePattern() accepted an unbalanced pattern")
+	}
+}
+
+func TestShapeLiteralPrefix(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"func([]byte) (int, error)", "func([]byte) (int, error)"},
+		{"func(T) T", "func("},
+		{"func(string, []T) error", "func(string, []"},
+		{"func(context.Context, ...) error", "func(context.Context"},
+		{"func(string, ...T)", "func(string"},
+		{"func(io.Reader) R", "func(io.Reader) "},
+	}
+	for _, tt := range tests {
+		pattern, err := ParseShapePattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("ParseShapePattern(%q) error = %v", tt.pattern, err)
+		}
+		if got := pattern.LiteralPrefix(); got != tt.want {
+			t.Errorf("%q.LiteralPrefix() = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
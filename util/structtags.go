@@ -0,0 +1,131 @@
+package util
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// StructField is an exported field of a struct type
+type StructField struct {
+	Name     string     `json:"name"` // embedded fields are named after their type
+	Type     string     `json:"type"`
+	Doc      string     `json:"doc,omitempty"`
+	Embedded bool       `json:"embedded,omitempty"`
+	Tag      string     `json:"tag,omitempty"`  // raw struct tag
+	Tags     []FieldTag `json:"tags,omitempty"` // the well-known keys of Tag, parsed
+}
+
+// FieldTag is the value of a well-known struct tag key
+type FieldTag struct {
+	Key     string   `json:"key"`               // json, xml, yaml, db or validate
+	Name    string   `json:"name,omitempty"`    // serialized name; "-" leaves the field out, "" keeps the default
+	Options []string `json:"options,omitempty"` // e.g. omitempty or attr; validation rules for validate
+}
+
+// fieldTagKeys are the struct tag keys FieldTags parses, in display order
+var fieldTagKeys = []string{"json", "xml", "yaml", "db", "validate"}
+
+// StructFields returns the exported fields of a struct type, with their tags
+func StructFields(t *doc.Type) []StructField {
+	for _, spec := range t.Decl.Specs {
+		if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.Name == t.Name {
+			return structFields(ts)
+		}
+	}
+	return nil
+}
+
+// StructFieldsOfDecl returns the exported fields of the struct type name from
+// its declaration source, as stored for indexed packages
+func StructFieldsOfDecl(decl, name string) []StructField {
+	if !strings.Contains(decl, "struct") {
+		return nil
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), "", "package p\n"+decl, parser.ParseComments)
+	if err != nil {
+		return nil
+	}
+	for _, d := range file.Decls {
+		gd, ok := d.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.Name == name {
+				return structFields(ts)
+			}
+		}
+	}
+	return nil
+}
+
+func structFields(ts *ast.TypeSpec) []StructField {
+	st, ok := ts.Type.(*ast.StructType)
+	if !ok {
+		return nil
+	}
+	var fields []StructField
+	for _, field := range st.Fields.List {
+		f := StructField{Type: types.ExprString(field.Type)}
+		if field.Doc != nil {
+			f.Doc = strings.TrimSpace(field.Doc.Text())
+		} else if field.Comment != nil {
+			f.Doc = strings.TrimSpace(field.Comment.Text())
+		}
+		if field.Tag != nil {
+			if tag, err := strconv.Unquote(field.Tag.Value); err == nil {
+				f.Tag = tag
+				f.Tags = FieldTags(tag)
+			}
+		}
+		if len(field.Names) == 0 {
+			// Embedded field, named after its type
+			if f.Name = embeddedName(field.Type); ast.IsExported(f.Name) {
+				f.Embedded = true
+				fields = append(fields, f)
+			}
+			continue
+		}
+		for _, n := range field.Names {
+			if n.IsExported() {
+				f.Name = n.Name
+				fields = append(fields, f)
+			}
+		}
+	}
+	return fields
+}
+
+// FieldTags parses the json, xml, yaml, db and validate keys of a struct tag.
+// The first comma-separated element is the name, except for validate whose
+// value is a list of rules.
+func FieldTags(tag string) []FieldTag {
+	var tags []FieldTag
+	for _, key := range fieldTagKeys {
+		value, ok := reflect.StructTag(tag).Lookup(key)
+		if !ok {
+			continue
+		}
+		ft := FieldTag{Key: key}
+		parts := strings.Split(value, ",")
+		if key != "validate" {
+			ft.Name, parts = parts[0], parts[1:]
+		}
+		for _, part := range parts {
+			if part = strings.TrimSpace(part); part != "" {
+				ft.Options = append(ft.Options, part)
+			}
+		}
+		if ft.Name == "" && len(ft.Options) == 0 {
+			continue
+		}
+		tags = append(tags, ft)
+	}
+	return tags
+}
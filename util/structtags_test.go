@@ -0,0 +1,64 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFieldTags(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want []FieldTag
+	}{
+		{`json:"user_name,omitempty"`, []FieldTag{{Key: "json", Name: "user_name", Options: []string{"omitempty"}}}},
+		{`json:"-"`, []FieldTag{{Key: "json", Name: "-"}}},
+		{`json:",string"`, []FieldTag{{Key: "json", Options: []string{"string"}}}},
+		{
+			`xml:"id,attr" yaml:"id" db:"user_id" validate:"required,min=1"`,
+			[]FieldTag{
+				{Key: "xml", Name: "id", Options: []string{"attr"}},
+				{Key: "yaml", Name: "id"},
+				{Key: "db", Name: "user_id"},
+				{Key: "validate", Options: []string{"required", "min=1"}},
+			},
+		},
+		{`mapstructure:"name" json:""`, nil},
+		{`not a tag`, nil},
+	}
+	for _, tt := range tests {
+		if got := FieldTags(tt.tag); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FieldTags(%q) = %+v, want %+v", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestStructFieldsOfDecl(t *testing.T) {
+	decl := "type User struct {\n" +
+		"\t// Name is the user's name\n" +
+		"\tName string `json:\"user_name,omitempty\"`\n" +
+		"\tAge, Height int // in years and centimeters\n" +
+		"\t*Base `json:\"-\"`\n" +
+		"\tsecret string `json:\"secret\"`\n" +
+		"}"
+	want := []StructField{
+		{
+			Name: "Name", Type: "string", Doc: "Name is the user's name",
+			Tag:  `json:"user_name,omitempty"`,
+			Tags: []FieldTag{{Key: "json", Name: "user_name", Options: []string{"omitempty"}}},
+		},
+		{Name: "Age", Type: "int", Doc: "in years and centimeters"},
+		{Name: "Height", Type: "int", Doc: "in years and centimeters"},
+		{Name: "Base", Type: "*Base", Embedded: true, Tag: `json:"-"`, Tags: []FieldTag{{Key: "json", Name: "-"}}},
+	}
+	if got := StructFieldsOfDecl(decl, "User"); !reflect.DeepEqual(got, want) {
+		t.Errorf("StructFieldsOfDecl() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := StructFieldsOfDecl("type ID int", "ID"); got != nil {
+		t.Errorf("StructFieldsOfDecl(non-struct) = %+v, want nil", got)
+	}
+	grouped := "type (\n\tA struct{ X int }\n\tB struct{ Y string `db:\"y\"` }\n)"
+	if got := StructFieldsOfDecl(grouped, "B"); len(got) != 1 || got[0].Name != "Y" || got[0].Tags[0].Name != "y" {
+		t.Errorf("StructFieldsOfDecl(grouped, B) = %+v, want field Y with db name y", got)
+	}
+}
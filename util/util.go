@@ -1,9 +1,23 @@
 package util
 
 import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"go/version"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
 )
 
 // IsDeprecated checks if documentation text indicates deprecation
@@ -15,36 +29,368 @@ func IsDeprecated(docText string) bool {
 	return strings.Contains(docText, "\nDeprecated:") || strings.Contains(docText, "\n\nDeprecated:")
 }
 
-// IsRedistributable checks if a license allows redistribution
+// DeprecationNotice returns what follows "Deprecated:" in a doc comment up to
+// the end of its paragraph, e.g. "Use foo/v2 instead.", with the lines joined;
+// "" when IsDeprecated is false
+func DeprecationNotice(docText string) string {
+	for _, para := range strings.Split(strings.TrimSpace(docText), "\n\n") {
+		lines := strings.Split(para, "\n")
+		for i, line := range lines {
+			if notice, ok := strings.CutPrefix(line, "Deprecated:"); ok {
+				return strings.Join(strings.Fields(notice+" "+strings.Join(lines[i+1:], " ")), " ")
+			}
+		}
+	}
+	return ""
+}
+
+// experimentalDocRe matches doc comments that label their package unstable:
+// "Experimental:" paragraphs like "Deprecated:" ones, or sentences such as
+// "Package foo is experimental" and "This API is in beta"
+var experimentalDocRe = regexp.MustCompile(`(?m)^\s*(?:Experimental:|EXPERIMENTAL\b)|(?i)\b(?:package|api|module)\b[^.]{0,40}?\b(?:is|are)\s+(?:(?:currently|still|highly|considered)\s+)?(?:an?\s+)?(?:experimental|(?:in\s+)?(?:alpha|beta))\b`)
+
+// IsExperimental reports whether a package's API may change without notice:
+// it lives under golang.org/x/exp or an "experimental" path element, or its
+// doc says it is experimental, alpha or beta
+func IsExperimental(importPath, docText string) bool {
+	if importPath == "golang.org/x/exp" || strings.HasPrefix(importPath, "golang.org/x/exp/") {
+		return true
+	}
+	if slices.Contains(strings.Split(importPath, "/"), "experimental") {
+		return true
+	}
+	return experimentalDocRe.MatchString(docText)
+}
+
+// DeprecatedValueNames returns the names in a const or var group whose own
+// comment marks them deprecated. Deprecation of the whole group is in v.Doc.
+func DeprecatedValueNames(v *doc.Value) []string {
+	var names []string
+	for _, spec := range v.Decl.Specs {
+		vs, ok := spec.(*ast.ValueSpec)
+		if !ok || !commentDeprecated(vs.Doc, vs.Comment) {
+			continue
+		}
+		for _, n := range vs.Names {
+			if n.IsExported() {
+				names = append(names, n.Name)
+			}
+		}
+	}
+	return names
+}
+
+// DeprecatedFields returns the exported fields of a struct type whose doc or
+// line comment marks them deprecated
+func DeprecatedFields(t *doc.Type) []string {
+	var names []string
+	for _, spec := range t.Decl.Specs {
+		ts, ok := spec.(*ast.TypeSpec)
+		if !ok || ts.Name.Name != t.Name {
+			continue
+		}
+		st, ok := ts.Type.(*ast.StructType)
+		if !ok {
+			return nil
+		}
+		for _, field := range st.Fields.List {
+			if !commentDeprecated(field.Doc, field.Comment) {
+				continue
+			}
+			if len(field.Names) == 0 {
+				// Embedded field, named after its type
+				if name := embeddedName(field.Type); ast.IsExported(name) {
+					names = append(names, name)
+				}
+			}
+			for _, n := range field.Names {
+				if n.IsExported() {
+					names = append(names, n.Name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+func commentDeprecated(groups ...*ast.CommentGroup) bool {
+	for _, g := range groups {
+		if g != nil && IsDeprecated(g.Text()) {
+			return true
+		}
+	}
+	return false
+}
+
+func embeddedName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.Ident:
+		return e.Name
+	case *ast.IndexExpr:
+		return embeddedName(e.X)
+	case *ast.IndexListExpr:
+		return embeddedName(e.X)
+	}
+	return ""
+}
+
+// IsRedistributable checks if a license allows redistribution. SPDX expressions
+// are redistributable when any OR alternative has only redistributable AND terms.
 func IsRedistributable(license string) bool {
 	redistributable := map[string]bool{
 		"MIT": true, "Apache-2.0": true, "BSD-2-Clause": true, "BSD-3-Clause": true,
 		"ISC": true, "MPL-2.0": true, "Unlicense": true, "CC0-1.0": true, "LGPL": true,
 	}
-	return redistributable[license]
+	license = strings.NewReplacer("(", "", ")", "").Replace(license)
+	for _, alternative := range strings.Split(license, " OR ") {
+		ok := true
+		for _, term := range strings.Split(alternative, " AND ") {
+			term, _, _ = strings.Cut(strings.TrimSpace(term), " WITH ")
+			ok = ok && redistributable[term]
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// LicenseFile is a license file found in a module directory
+type LicenseFile struct {
+	Name string // path relative to the module directory, e.g. "LICENSE-MIT" or "licenses/BSD.txt"
+	Type string // SPDX identifier, or "Unknown"
+	Text string
 }
 
-// DetectLicense detects the license type and text from a directory
-func DetectLicense(dir string) (licenseType string, licenseText string) {
-	licenseFiles := []string{
-		"LICENSE", "LICENSE.txt", "LICENSE.md", "LICENCE", "LICENCE.txt",
-		"COPYING", "COPYING.txt",
+// licenseDirs are subdirectories holding one file per license, as in the REUSE layout
+var licenseDirs = []string{"LICENSES", "licenses", "LICENCES", "licences"}
+
+// isLicenseFileName reports whether a file name looks like a license file:
+// LICENSE, LICENCE, COPYING, or UNLICENSE with an optional extension or suffix
+// such as LICENSE-MIT or COPYING.LESSER
+func isLicenseFileName(name string, extraNames []string) bool {
+	for _, extra := range extraNames {
+		if strings.EqualFold(name, extra) {
+			return true
+		}
+	}
+	upper := strings.ToUpper(name)
+	for _, base := range []string{"LICENSE", "LICENCE", "COPYING", "UNLICENSE"} {
+		if rest, ok := strings.CutPrefix(upper, base); ok && (rest == "" || rest[0] == '.' || rest[0] == '-' || rest[0] == '_') {
+			return true
+		}
 	}
+	return false
+}
 
-	for _, name := range licenseFiles {
-		path := filepath.Join(dir, name)
-		content, err := os.ReadFile(path)
+// licenseFromName guesses the license from a file name such as LICENSE-APACHE or
+// licenses/MIT.txt, for texts IdentifyLicense does not recognize
+func licenseFromName(name string) string {
+	hint := strings.ToLower(filepath.Base(name))
+	for _, ext := range []string{".txt", ".md", ".rst"} {
+		hint = strings.TrimSuffix(hint, ext)
+	}
+	for _, prefix := range []string{"license", "licence", "copying"} {
+		hint = strings.TrimLeft(strings.TrimPrefix(hint, prefix), "-_.")
+	}
+	switch hint {
+	case "mit":
+		return "MIT"
+	case "apache", "apache2", "apache-2", "apache-2.0":
+		return "Apache-2.0"
+	case "bsd-2-clause":
+		return "BSD-2-Clause"
+	case "bsd", "bsd-3-clause":
+		return "BSD-3-Clause"
+	case "isc":
+		return "ISC"
+	case "mpl-2.0":
+		return "MPL-2.0"
+	case "gpl-2.0":
+		return "GPL-2.0"
+	case "gpl-3.0", "gpl":
+		return "GPL-3.0"
+	case "unlicense":
+		return "Unlicense"
+	case "cc0-1.0":
+		return "CC0-1.0"
+	}
+	return ""
+}
+
+// DetectLicenseFiles identifies every license file in dir and its licenses/
+// subdirectory, sorted by name. extraNames adds file names to look for.
+func DetectLicenseFiles(dir string, extraNames ...string) []LicenseFile {
+	var names []string
+	if entries, err := os.ReadDir(dir); err == nil {
+		for _, e := range entries {
+			if !e.IsDir() && isLicenseFileName(e.Name(), extraNames) {
+				names = append(names, e.Name())
+			}
+		}
+	}
+	for _, sub := range licenseDirs {
+		entries, err := os.ReadDir(filepath.Join(dir, sub))
 		if err != nil {
 			continue
 		}
-		text := string(content)
-		return IdentifyLicense(text), text
+		for _, e := range entries {
+			if !e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+				names = append(names, sub+"/"+e.Name())
+			}
+		}
+	}
+	sort.Strings(names)
+
+	var files []LicenseFile
+	for _, name := range names {
+		text, ok := ReadLicenseFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if !ok {
+			continue
+		}
+		typ := SPDXExpression(text)
+		if typ == "" {
+			typ = IdentifyLicense(text)
+		}
+		if typ == "Unknown" {
+			if hint := licenseFromName(name); hint != "" {
+				typ = hint
+			}
+		}
+		files = append(files, LicenseFile{Name: name, Type: typ, Text: text})
+	}
+	return files
+}
+
+// LicenseExpression combines the licenses of several files into an SPDX "OR"
+// expression, e.g. "MIT OR Apache-2.0". Unrecognized files only count when
+// no file is recognized.
+func LicenseExpression(files []LicenseFile) string {
+	var types []string
+	seen := make(map[string]bool)
+	for _, f := range files {
+		if f.Type != "Unknown" && !seen[f.Type] {
+			seen[f.Type] = true
+			types = append(types, f.Type)
+		}
+	}
+	if len(types) == 0 && len(files) > 0 {
+		return "Unknown"
+	}
+	return strings.Join(types, " OR ")
+}
+
+// JoinLicenseTexts concatenates license texts, headed by their file names when
+// there is more than one
+func JoinLicenseTexts(files []LicenseFile) string {
+	if len(files) == 1 {
+		return files[0].Text
+	}
+	var b strings.Builder
+	for i, f := range files {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("==> " + f.Name + " <==\n\n")
+		b.WriteString(strings.TrimRight(f.Text, "\n") + "\n")
+	}
+	return b.String()
+}
+
+var spdxLineRe = regexp.MustCompile(`SPDX-License-Identifier:\s*(.+)`)
+
+// SPDXExpression returns the expression of the first SPDX-License-Identifier line
+// in content, e.g. a go.mod comment, or "" if there is none
+func SPDXExpression(content string) string {
+	if len(content) > LicenseIdentifyLimit {
+		content = content[:LicenseIdentifyLimit]
+	}
+	m := spdxLineRe.FindStringSubmatch(content)
+	if m == nil {
+		return ""
+	}
+	expr := strings.TrimSpace(m[1])
+	expr = strings.TrimSpace(strings.TrimSuffix(expr, "*/"))
+	return expr
+}
+
+// DetectLicense identifies the licenses of the module in dir. Multiple license
+// files are combined into an SPDX "OR" expression and their texts concatenated;
+// an SPDX-License-Identifier comment in go.mod takes precedence over the files.
+func DetectLicense(dir string, extraNames ...string) (licenseType string, licenseText string) {
+	files := DetectLicenseFiles(dir, extraNames...)
+	licenseType, licenseText = LicenseExpression(files), JoinLicenseTexts(files)
+	if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+		if expr := SPDXExpression(string(data)); expr != "" {
+			licenseType = expr
+		}
+	}
+	return licenseType, licenseText
+}
+
+// Size caps for license files: identification only needs the opening of the text,
+// while the stored text is kept whole up to a larger limit
+const (
+	LicenseIdentifyLimit = 64 << 10
+	LicenseTextLimit     = 512 << 10
+)
+
+// licenseTruncatedNote is appended to license texts cut at LicenseTextLimit
+const licenseTruncatedNote = "\n\n[License text truncated]\n"
+
+// ReadLicenseFile reads a license file of at most LicenseTextLimit bytes, truncating
+// longer files. Missing, empty, and binary files are reported as not ok.
+func ReadLicenseFile(path string) (text string, ok bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(io.LimitReader(f, LicenseTextLimit+1))
+	if err != nil || len(content) == 0 {
+		return "", false
+	}
+	truncated := len(content) > LicenseTextLimit
+	if truncated {
+		content = content[:LicenseTextLimit]
+	}
+	if !isText(content) {
+		return "", false
+	}
+	text = strings.ToValidUTF8(string(content), "\uFFFD")
+	if truncated {
+		text += licenseTruncatedNote
+	}
+	return text, true
+}
+
+// isText reports whether content looks like text rather than binary data:
+// no NUL bytes and few control characters. Invalid UTF-8 such as Latin-1
+// copyright signs is tolerated and repaired by the caller.
+func isText(content []byte) bool {
+	if bytes.IndexByte(content, 0) >= 0 {
+		return false
+	}
+	control := 0
+	for _, b := range content {
+		if b < 0x20 && b != '\n' && b != '\r' && b != '\t' && b != '\f' {
+			control++
+		}
 	}
-	return "", ""
+	return control*100 < len(content)
 }
 
-// IdentifyLicense identifies the license type from license text
+// IdentifyLicense identifies the license type from the first LicenseIdentifyLimit
+// bytes of a license text
 func IdentifyLicense(content string) string {
+	if len(content) > LicenseIdentifyLimit {
+		content = content[:LicenseIdentifyLimit]
+	}
 	content = strings.ToLower(content)
 	switch {
 	case strings.Contains(content, "apache license") && strings.Contains(content, "version 2.0"):
@@ -71,22 +417,509 @@ func IdentifyLicense(content string) string {
 
 // ModuleToRepoURL converts a Go module path to a repository URL
 func ModuleToRepoURL(modulePath string) string {
+	repoURL, _ := splitRepoPath(modulePath)
+	return repoURL
+}
+
+// splitRepoPath splits a module path into the URL of its repository on a known
+// code host and the rest of the path below the repository root
+func splitRepoPath(modulePath string) (repoURL, rest string) {
 	parts := strings.Split(modulePath, "/")
 	if len(parts) < 2 {
-		return ""
+		return "", ""
 	}
 	host := parts[0]
+	var n int
 	switch {
-	case host == "github.com" && len(parts) >= 3:
-		return "https://github.com/" + parts[1] + "/" + parts[2]
-	case host == "gitlab.com" && len(parts) >= 3:
-		return "https://gitlab.com/" + parts[1] + "/" + parts[2]
-	case host == "bitbucket.org" && len(parts) >= 3:
-		return "https://bitbucket.org/" + parts[1] + "/" + parts[2]
+	case (host == "github.com" || host == "gitlab.com" || host == "bitbucket.org") && len(parts) >= 3:
+		repoURL, n = "https://"+strings.Join(parts[:3], "/"), 3
 	case strings.HasPrefix(host, "go.googlesource.com"):
-		return "https://go.googlesource.com/" + parts[1]
+		repoURL, n = "https://go.googlesource.com/"+parts[1], 2
 	case host == "golang.org" && len(parts) >= 3 && parts[1] == "x":
-		return "https://go.googlesource.com/" + parts[2]
+		repoURL, n = "https://go.googlesource.com/"+parts[2], 3
+	default:
+		return "", ""
+	}
+	return repoURL, strings.Join(parts[n:], "/")
+}
+
+// ModuleRepoDir returns the directory holding a module's go.mod within its
+// repository, such as "submodule" for github.com/org/repo/submodule, or "" for
+// a module at the repository root. A major version suffix like /v2 does not
+// count, as such modules are usually developed on a branch at the root.
+func ModuleRepoDir(modulePath string) string {
+	if prefix, _, ok := module.SplitPathVersion(modulePath); ok {
+		modulePath = prefix
+	}
+	_, dir := splitRepoPath(modulePath)
+	return dir
+}
+
+// SourceURL links to a file of a module version on its code host, at line when
+// it is positive, or to the directory when file is empty. pkgDir is the package
+// directory relative to the module root. It returns "" for unknown hosts.
+func SourceURL(modulePath, version, pkgDir, file string, line int) string {
+	repoURL := ModuleToRepoURL(modulePath)
+	if repoURL == "" {
+		return ""
+	}
+	moduleDir := ModuleRepoDir(modulePath)
+
+	ref := "HEAD"
+	switch {
+	case module.IsPseudoVersion(version):
+		if rev, err := module.PseudoVersionRev(version); err == nil {
+			ref = rev
+		}
+	case version != "":
+		// Modules in a subdirectory are tagged with the directory as prefix
+		ref = strings.TrimSuffix(version, "+incompatible")
+		if moduleDir != "" {
+			ref = moduleDir + "/" + ref
+		}
+	}
+
+	kind := "blob"
+	if file == "" {
+		kind = "tree"
+	}
+	var url, lineAnchor string
+	switch {
+	case strings.HasPrefix(repoURL, "https://github.com/"):
+		url, lineAnchor = repoURL+"/"+kind+"/"+ref, "#L%d"
+	case strings.HasPrefix(repoURL, "https://gitlab.com/"):
+		url, lineAnchor = repoURL+"/-/"+kind+"/"+ref, "#L%d"
+	case strings.HasPrefix(repoURL, "https://bitbucket.org/"):
+		url, lineAnchor = repoURL+"/src/"+ref, "#lines-%d"
+	default:
+		url, lineAnchor = repoURL+"/+/"+ref, "#%d"
+	}
+	if p := path.Join(moduleDir, pkgDir, file); p != "" {
+		url += "/" + p
+	}
+	if file != "" && line > 0 {
+		url += fmt.Sprintf(lineAnchor, line)
+	}
+	return url
+}
+
+// FuncEndLines maps the position of each function declaration to its last line
+func FuncEndLines(fset *token.FileSet, files []*ast.File) map[token.Pos]int {
+	ends := make(map[token.Pos]int)
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok {
+				ends[fn.Pos()] = fset.Position(fn.End()).Line
+			}
+		}
+	}
+	return ends
+}
+
+// GenerateDirectives returns the commands of the //go:generate directives in
+// files, in file order. Like go generate, only comments starting a line count.
+func GenerateDirectives(fset *token.FileSet, files []*ast.File) []string {
+	var commands []string
+	for _, f := range files {
+		for _, group := range f.Comments {
+			for _, c := range group.List {
+				command, ok := strings.CutPrefix(c.Text, "//go:generate ")
+				if !ok || fset.Position(c.Slash).Column != 1 {
+					continue
+				}
+				if command = strings.TrimSpace(command); command != "" {
+					commands = append(commands, command)
+				}
+			}
+		}
+	}
+	return commands
+}
+
+// TypeDeclRange returns the start and end of a type's declaration. Types declared
+// in a group span only their own spec rather than the whole group.
+func TypeDeclRange(fset *token.FileSet, t *doc.Type) (start, end token.Position) {
+	if t.Decl.Lparen.IsValid() {
+		for _, spec := range t.Decl.Specs {
+			if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.Name == t.Name {
+				return fset.Position(ts.Pos()), fset.Position(ts.End())
+			}
+		}
+	}
+	return fset.Position(t.Decl.Pos()), fset.Position(t.Decl.End())
+}
+
+// GoMod holds the directives of a parsed go.mod file
+type GoMod struct {
+	Module    string       `json:"module"`
+	Go        string       `json:"go,omitempty"`
+	Toolchain string       `json:"toolchain,omitempty"`
+	Require   []ModRequire `json:"require,omitempty"`
+	Replace   []ModReplace `json:"replace,omitempty"`
+	Retract   []ModRetract `json:"retract,omitempty"`
+}
+
+// ModRequire is a single require directive
+type ModRequire struct {
+	Path     string `json:"path"`
+	Version  string `json:"version"`
+	Indirect bool   `json:"indirect,omitempty"`
+}
+
+// ModReplace is a single replace directive. NewVersion is empty when the
+// replacement is a local directory.
+type ModReplace struct {
+	OldPath    string `json:"old_path"`
+	OldVersion string `json:"old_version,omitempty"`
+	NewPath    string `json:"new_path"`
+	NewVersion string `json:"new_version,omitempty"`
+}
+
+// IsLocal reports whether the replacement is a directory on disk rather than a module
+func (r ModReplace) IsLocal() bool {
+	return modfile.IsDirectoryPath(r.NewPath)
+}
+
+// IsFork reports whether the replacement is a different module, such as a fork
+func (r ModReplace) IsFork() bool {
+	return !r.IsLocal() && r.NewPath != r.OldPath
+}
+
+// ModRetract is a single retracted version or version range
+type ModRetract struct {
+	Low       string `json:"low"`
+	High      string `json:"high"`
+	Rationale string `json:"rationale,omitempty"`
+}
+
+// ParseGoMod parses the content of a go.mod file
+func ParseGoMod(content []byte) (*GoMod, error) {
+	f, err := modfile.Parse("go.mod", content, nil)
+	if err != nil {
+		return nil, err
+	}
+	return goModFromFile(f), nil
+}
+
+// ParseGoModLax parses a go.mod file leniently, as the go command reads the
+// go.mod files of dependencies: unknown directives are skipped, and replace
+// and exclude directives are left out
+func ParseGoModLax(content []byte) (*GoMod, error) {
+	f, err := modfile.ParseLax("go.mod", content, nil)
+	if err != nil {
+		return nil, err
+	}
+	return goModFromFile(f), nil
+}
+
+func goModFromFile(f *modfile.File) *GoMod {
+	gm := &GoMod{}
+	if f.Module != nil {
+		gm.Module = f.Module.Mod.Path
+	}
+	if f.Go != nil {
+		gm.Go = f.Go.Version
+	}
+	if f.Toolchain != nil {
+		gm.Toolchain = f.Toolchain.Name
+	}
+	for _, r := range f.Require {
+		gm.Require = append(gm.Require, ModRequire{
+			Path:     r.Mod.Path,
+			Version:  r.Mod.Version,
+			Indirect: r.Indirect,
+		})
+	}
+	for _, r := range f.Replace {
+		gm.Replace = append(gm.Replace, ModReplace{
+			OldPath:    r.Old.Path,
+			OldVersion: r.Old.Version,
+			NewPath:    r.New.Path,
+			NewVersion: r.New.Version,
+		})
+	}
+	for _, r := range f.Retract {
+		gm.Retract = append(gm.Retract, ModRetract{
+			Low:       r.Low,
+			High:      r.High,
+			Rationale: r.Rationale,
+		})
+	}
+	return gm
+}
+
+// EnforcedGoVersion is the first release whose toolchains treat the go directive
+// as a minimum requirement instead of advice
+const EnforcedGoVersion = "1.21"
+
+// CompareGoVersions compares go directive versions such as "1.9", "1.22", and
+// "1.22.1", returning -1, 0, or +1. Invalid versions sort before valid ones.
+func CompareGoVersions(a, b string) int {
+	return version.Compare("go"+a, "go"+b)
+}
+
+// RequiresGoVersion reports whether a go directive is enforced as a minimum,
+// so older toolchains cannot build the module
+func RequiresGoVersion(goVersion string) bool {
+	return version.IsValid("go"+goVersion) && CompareGoVersions(goVersion, EnforcedGoVersion) >= 0
+}
+
+// ImportPathLeaf returns the last element of an import path without its major
+// version suffix, e.g. "yaml" for "gopkg.in/yaml.v3" and "chi" for "github.com/go-chi/chi/v5"
+func ImportPathLeaf(importPath string) string {
+	importPath = strings.TrimSuffix(filepath.ToSlash(importPath), "/")
+	leaf := importPath[strings.LastIndex(importPath, "/")+1:]
+	if i := strings.LastIndex(importPath, "/"); i >= 0 && isMajorVersion(leaf) {
+		if rest := importPath[:i]; strings.Contains(rest, "/") {
+			leaf = rest[strings.LastIndex(rest, "/")+1:]
+		}
+	}
+	if i := strings.LastIndex(leaf, ".v"); i > 0 && isMajorVersion(leaf[i+1:]) {
+		leaf = leaf[:i]
+	}
+	return leaf
+}
+
+// isMajorVersion reports whether elem is a "vN" path element
+func isMajorVersion(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' {
+		return false
+	}
+	for _, r := range elem[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesLeaf reports whether a package name is the conventional name for an
+// import path leaf, allowing for "go-" prefixes, "-go" suffixes, and punctuation
+func matchesLeaf(name, leaf string) bool {
+	leaf = strings.ToLower(leaf)
+	if name == leaf {
+		return true
+	}
+	leaf = strings.TrimPrefix(leaf, "go-")
+	leaf = strings.TrimSuffix(strings.TrimSuffix(leaf, "-go"), ".go")
+	leaf = strings.NewReplacer("-", "", "_", "", ".", "").Replace(leaf)
+	return name == leaf
+}
+
+// SelectPackageName picks the package to document in a directory, given the number
+// of non-test files declaring each package name. External test packages are never
+// chosen; among the rest, the name matching the import path leaf wins, then the
+// name with the most files. An error lists the candidates when no rule decides.
+func SelectPackageName(fileCounts map[string]int, importPath string) (string, error) {
+	var candidates []string
+	for name, n := range fileCounts {
+		if n > 0 && !strings.HasSuffix(name, "_test") {
+			candidates = append(candidates, name)
+		}
+	}
+	sort.Strings(candidates)
+
+	switch len(candidates) {
+	case 0:
+		return "", fmt.Errorf("no non-test package found for %s", importPath)
+	case 1:
+		return candidates[0], nil
+	}
+
+	leaf := ImportPathLeaf(importPath)
+	for _, name := range candidates {
+		if matchesLeaf(name, leaf) {
+			return name, nil
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return fileCounts[candidates[i]] > fileCounts[candidates[j]]
+	})
+	if fileCounts[candidates[0]] > fileCounts[candidates[1]] {
+		return candidates[0], nil
+	}
+
+	described := make([]string, len(candidates))
+	for i, name := range candidates {
+		described[i] = fmt.Sprintf("%s (%d files)", name, fileCounts[name])
+	}
+	return "", fmt.Errorf("ambiguous package for %s: found %s", importPath, strings.Join(described, ", "))
+}
+
+// Package classifications for packages without a usable exported API
+const (
+	PackageTestOnly    = "test-only"
+	PackageExampleOnly = "example-only"
+)
+
+// ClassifyPackage returns PackageTestOnly or PackageExampleOnly when the non-test
+// files declare no exported API, or "" for a regular package
+func ClassifyPackage(files, testFiles []*ast.File) string {
+	for _, f := range files {
+		if f.Name.Name == "main" || hasExportedDecl(f) {
+			return ""
+		}
+	}
+
+	for _, f := range testFiles {
+		if len(doc.Examples(f)) > 0 {
+			return PackageExampleOnly
+		}
+	}
+	if len(testFiles) > 0 {
+		return PackageTestOnly
 	}
 	return ""
 }
+
+// hasExportedDecl reports whether a file declares an exported top-level identifier
+func hasExportedDecl(f *ast.File) bool {
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Name.IsExported() && (d.Recv == nil || receiverExported(d.Recv)) {
+				return true
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.IsExported() {
+						return true
+					}
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						if name.IsExported() {
+							return true
+						}
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// receiverExported reports whether a method receiver's base type is exported
+func receiverExported(recv *ast.FieldList) bool {
+	if len(recv.List) == 0 {
+		return false
+	}
+	expr := recv.List[0].Type
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.IndexExpr:
+			expr = t.X
+		case *ast.IndexListExpr:
+			expr = t.X
+		case *ast.Ident:
+			return t.IsExported()
+		default:
+			return false
+		}
+	}
+}
+
+// ExampleSymbol returns the symbol an example documents from its name without the
+// "Example" prefix: "" for package examples, "T.M" for methods, and "F" or "T" otherwise.
+// Lowercase parts are suffixes distinguishing several examples of the same symbol.
+func ExampleSymbol(name string) string {
+	parts := strings.Split(name, "_")
+	if parts[0] == "" {
+		return ""
+	}
+	if len(parts) > 1 && parts[1] != "" && ast.IsExported(parts[1]) {
+		return parts[0] + "." + parts[1]
+	}
+	return parts[0]
+}
+
+// ParamDoc documents a single function parameter or result
+type ParamDoc struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+var (
+	// paramLineRe matches "name - description" lines, optionally bulleted or quoted,
+	// with "-", "–", "—" or ":" as separator
+	paramLineRe   = regexp.MustCompile("^(\\s*)(?:[-*•]\\s+)?[`\\[]?([A-Za-z_][A-Za-z0-9_]*)[`\\]]?\\s*(?:-|–|—|:)\\s+(\\S.*)$")
+	paramHeaderRe = regexp.MustCompile(`(?i)^\s*(parameters|params|arguments|args|returns?)\s*:?\s*$`)
+)
+
+// ParseParamDocs finds prose documentation of the named parameters in a doc comment.
+// It returns the documented parameters and the doc with those lines removed, or nil
+// and the unchanged doc when no parameter is documented.
+func ParseParamDocs(docText string, names []string) ([]ParamDoc, string) {
+	if docText == "" || len(names) == 0 {
+		return nil, docText
+	}
+	isParam := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name != "" && name != "_" {
+			isParam[name] = true
+		}
+	}
+
+	lines := strings.Split(docText, "\n")
+	var params []ParamDoc
+	var kept []string
+	for i := 0; i < len(lines); i++ {
+		m := paramLineRe.FindStringSubmatch(lines[i])
+		if m == nil || !isParam[m[2]] {
+			kept = append(kept, lines[i])
+			continue
+		}
+
+		// Indented lines that follow continue the description
+		desc := strings.TrimSpace(m[3])
+		for i+1 < len(lines) {
+			next := lines[i+1]
+			if strings.TrimSpace(next) == "" || len(next)-len(strings.TrimLeft(next, " \t")) <= len(m[1]) {
+				break
+			}
+			if n := paramLineRe.FindStringSubmatch(next); n != nil && isParam[n[2]] {
+				break
+			}
+			desc += " " + strings.TrimSpace(next)
+			i++
+		}
+		params = append(params, ParamDoc{Name: m[2], Description: desc})
+
+		// Drop a "Parameters:" style header introducing the list
+		if len(kept) > 0 && paramHeaderRe.MatchString(kept[len(kept)-1]) {
+			kept = kept[:len(kept)-1]
+		}
+	}
+	if len(params) == 0 {
+		return nil, docText
+	}
+
+	rest := strings.TrimSpace(strings.Join(kept, "\n"))
+	for strings.Contains(rest, "\n\n\n") {
+		rest = strings.ReplaceAll(rest, "\n\n\n", "\n\n")
+	}
+	if rest != "" {
+		rest += "\n"
+	}
+	return params, rest
+}
+
+// ParamNames returns the names of a function's parameters and named results
+func ParamNames(ft *ast.FuncType) []string {
+	var names []string
+	for _, list := range []*ast.FieldList{ft.Params, ft.Results} {
+		if list == nil {
+			continue
+		}
+		for _, field := range list.List {
+			for _, name := range field.Names {
+				names = append(names, name.Name)
+			}
+		}
+	}
+	return names
+}
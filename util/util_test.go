@@ -0,0 +1,696 @@
+package util
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parseFiles(t *testing.T, fset *token.FileSet, sources ...string) []*ast.File {
+	t.Helper()
+	var files []*ast.File
+	for i, src := range sources {
+		f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+		if err != nil {
+			t.Fatalf("parsing source %d: %v", i, err)
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+func TestClassifyPackage(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     []string
+		testFiles []string
+		want      string
+	}{
+		{
+			name:  "exported func",
+			files: []string{"package foo\nfunc Do() {}"},
+			want:  "",
+		},
+		{
+			name:  "exported type",
+			files: []string{"package foo\ntype T struct{}"},
+			want:  "",
+		},
+		{
+			name:      "unexported only with tests",
+			files:     []string{"package foo\nfunc helper() {}"},
+			testFiles: []string{"package foo\nimport \"testing\"\nfunc TestHelper(t *testing.T) {}"},
+			want:      PackageTestOnly,
+		},
+		{
+			name:      "method on unexported type",
+			files:     []string{"package foo\ntype t struct{}\nfunc (t) Exported() {}"},
+			testFiles: []string{"package foo\nimport \"testing\"\nfunc TestX(t *testing.T) {}"},
+			want:      PackageTestOnly,
+		},
+		{
+			name:      "examples only",
+			files:     []string{"// Package foo shows examples.\npackage foo"},
+			testFiles: []string{"package foo_test\nimport \"fmt\"\nfunc Example() {\n\tfmt.Println(1)\n\t// Output: 1\n}"},
+			want:      PackageExampleOnly,
+		},
+		{
+			name:      "command",
+			files:     []string{"package main\nfunc main() {}"},
+			testFiles: []string{"package main\nimport \"testing\"\nfunc TestMain(t *testing.T) {}"},
+			want:      "",
+		},
+		{
+			name:  "no exported API and no tests",
+			files: []string{"package foo\nvar x = 1"},
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			files := parseFiles(t, fset, tt.files...)
+			testFiles := parseFiles(t, fset, tt.testFiles...)
+			if got := ClassifyPackage(files, testFiles); got != tt.want {
+				t.Errorf("ClassifyPackage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsExperimental(t *testing.T) {
+	tests := []struct {
+		importPath string
+		doc        string
+		want       bool
+	}{
+		{"golang.org/x/exp/slices", "Package slices defines various functions useful with slices.", true},
+		{"golang.org/x/exp", "", true},
+		{"golang.org/x/exploit", "", false},
+		{"google.golang.org/grpc/experimental/stats", "", true},
+		{"example.com/feature", "Package feature toggles features.\n\nExperimental: the API may change.", true},
+		{"example.com/feature", "EXPERIMENTAL: do not use in production.", true},
+		{"example.com/feature", "Package feature is experimental and may change.", true},
+		{"example.com/feature", "This API is currently in beta.", true},
+		{"example.com/feature", "Package feature is an alpha release of the new client.", true},
+		{"example.com/stats", "Package stats computes alpha and beta coefficients.", false},
+		{"example.com/flags", "Package flags parses experimental feature flags.", false},
+		{"example.com/stable", "Package stable is production ready.", false},
+	}
+	for _, tt := range tests {
+		if got := IsExperimental(tt.importPath, tt.doc); got != tt.want {
+			t.Errorf("IsExperimental(%q, %q) = %v, want %v", tt.importPath, tt.doc, got, tt.want)
+		}
+	}
+}
+
+func TestDeprecationNotice(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want string
+	}{
+		{"Deprecated: Use foo/v2 instead.", "Use foo/v2 instead."},
+		{"Package foo does things.\n\nDeprecated: Use foo/v2\ninstead.\n\nIt will be removed.", "Use foo/v2 instead."},
+		{"Package foo does things.\nDeprecated: foo is frozen.\n", "foo is frozen."},
+		{"Package foo does things.\n  Deprecated: indented, as in a code block.", ""},
+		{"Package foo does things.", ""},
+	}
+	for _, tt := range tests {
+		if got := DeprecationNotice(tt.doc); got != tt.want {
+			t.Errorf("DeprecationNotice(%q) = %q, want %q", tt.doc, got, tt.want)
+		}
+	}
+}
+
+func TestExampleSymbol(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"", ""},
+		{"_basic", ""},
+		{"ListenAndServe", "ListenAndServe"},
+		{"Server", "Server"},
+		{"Server_Shutdown", "Server.Shutdown"},
+		{"Server_Shutdown_graceful", "Server.Shutdown"},
+		{"Server_tls", "Server"},
+	}
+	for _, tt := range tests {
+		if got := ExampleSymbol(tt.name); got != tt.want {
+			t.Errorf("ExampleSymbol(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseParamDocs(t *testing.T) {
+	tests := []struct {
+		name       string
+		doc        string
+		params     []string
+		wantParams []ParamDoc
+		wantRest   string
+	}{
+		{
+			name:   "dash list with header",
+			doc:    "Dial connects to the address.\n\nParameters:\n  network - the network name, such as \"tcp\"\n  address - host and port to connect to\n\nIt returns an error on failure.\n",
+			params: []string{"network", "address"},
+			wantParams: []ParamDoc{
+				{Name: "network", Description: "the network name, such as \"tcp\""},
+				{Name: "address", Description: "host and port to connect to"},
+			},
+			wantRest: "Dial connects to the address.\n\nIt returns an error on failure.\n",
+		},
+		{
+			name:   "bullets, colons and continuation lines",
+			doc:    "Copy copies data.\n\n- `dst`: where data is written,\n    which must not be nil\n- src: where data is read from\n",
+			params: []string{"dst", "src"},
+			wantParams: []ParamDoc{
+				{Name: "dst", Description: "where data is written, which must not be nil"},
+				{Name: "src", Description: "where data is read from"},
+			},
+			wantRest: "Copy copies data.\n",
+		},
+		{
+			name:     "only known parameter names",
+			doc:      "Run starts the job.\n\nNote: it blocks until done.\n",
+			params:   []string{"ctx"},
+			wantRest: "Run starts the job.\n\nNote: it blocks until done.\n",
+		},
+		{
+			name:     "no params",
+			doc:      "Now returns the current time.\n",
+			wantRest: "Now returns the current time.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, rest := ParseParamDocs(tt.doc, tt.params)
+			if len(params) != len(tt.wantParams) {
+				t.Fatalf("ParseParamDocs() params = %+v, want %+v", params, tt.wantParams)
+			}
+			for i := range params {
+				if params[i] != tt.wantParams[i] {
+					t.Errorf("param %d = %+v, want %+v", i, params[i], tt.wantParams[i])
+				}
+			}
+			if rest != tt.wantRest {
+				t.Errorf("ParseParamDocs() rest = %q, want %q", rest, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestParseGoMod(t *testing.T) {
+	content := `module example.com/mod
+
+go 1.21.0
+
+toolchain go1.22.3
+
+require (
+	github.com/a/b v1.2.3
+	golang.org/x/sys v0.1.0 // indirect
+)
+
+replace github.com/a/b => github.com/fork/b v1.2.4
+
+replace example.com/local v1.0.0 => ../local
+
+retract (
+	v1.0.1 // Published accidentally.
+	[v0.9.0, v0.9.5]
+)
+`
+	gm, err := ParseGoMod([]byte(content))
+	if err != nil {
+		t.Fatalf("ParseGoMod() error = %v", err)
+	}
+	if gm.Module != "example.com/mod" || gm.Go != "1.21.0" || gm.Toolchain != "go1.22.3" {
+		t.Errorf("ParseGoMod() module/go/toolchain = %q/%q/%q", gm.Module, gm.Go, gm.Toolchain)
+	}
+
+	wantRequire := []ModRequire{
+		{Path: "github.com/a/b", Version: "v1.2.3"},
+		{Path: "golang.org/x/sys", Version: "v0.1.0", Indirect: true},
+	}
+	if len(gm.Require) != len(wantRequire) {
+		t.Fatalf("Require = %+v, want %+v", gm.Require, wantRequire)
+	}
+	for i := range wantRequire {
+		if gm.Require[i] != wantRequire[i] {
+			t.Errorf("Require[%d] = %+v, want %+v", i, gm.Require[i], wantRequire[i])
+		}
+	}
+
+	wantReplace := []ModReplace{
+		{OldPath: "github.com/a/b", NewPath: "github.com/fork/b", NewVersion: "v1.2.4"},
+		{OldPath: "example.com/local", OldVersion: "v1.0.0", NewPath: "../local"},
+	}
+	if len(gm.Replace) != len(wantReplace) {
+		t.Fatalf("Replace = %+v, want %+v", gm.Replace, wantReplace)
+	}
+	for i := range wantReplace {
+		if gm.Replace[i] != wantReplace[i] {
+			t.Errorf("Replace[%d] = %+v, want %+v", i, gm.Replace[i], wantReplace[i])
+		}
+	}
+
+	wantRetract := []ModRetract{
+		{Low: "v1.0.1", High: "v1.0.1", Rationale: "Published accidentally."},
+		{Low: "v0.9.0", High: "v0.9.5"},
+	}
+	if len(gm.Retract) != len(wantRetract) {
+		t.Fatalf("Retract = %+v, want %+v", gm.Retract, wantRetract)
+	}
+	for i := range wantRetract {
+		if gm.Retract[i] != wantRetract[i] {
+			t.Errorf("Retract[%d] = %+v, want %+v", i, gm.Retract[i], wantRetract[i])
+		}
+	}
+}
+
+func TestParseGoMod_Invalid(t *testing.T) {
+	if _, err := ParseGoMod([]byte("module\nrequire (")); err == nil {
+		t.Error("ParseGoMod() expected error for malformed go.mod")
+	}
+}
+
+func TestModReplace_Kind(t *testing.T) {
+	tests := []struct {
+		replace   ModReplace
+		wantLocal bool
+		wantFork  bool
+	}{
+		{ModReplace{OldPath: "github.com/a/b", NewPath: "github.com/fork/b", NewVersion: "v1.0.0"}, false, true},
+		{ModReplace{OldPath: "github.com/a/b", NewPath: "../b"}, true, false},
+		{ModReplace{OldPath: "github.com/a/b", NewPath: "/src/b"}, true, false},
+		{ModReplace{OldPath: "github.com/a/b", OldVersion: "v1.0.0", NewPath: "github.com/a/b", NewVersion: "v1.0.1"}, false, false},
+	}
+	for _, tt := range tests {
+		if got := tt.replace.IsLocal(); got != tt.wantLocal {
+			t.Errorf("%+v IsLocal() = %v, want %v", tt.replace, got, tt.wantLocal)
+		}
+		if got := tt.replace.IsFork(); got != tt.wantFork {
+			t.Errorf("%+v IsFork() = %v, want %v", tt.replace, got, tt.wantFork)
+		}
+	}
+}
+
+func TestDeclRanges(t *testing.T) {
+	src := `package foo
+
+// Run runs.
+func Run() {
+	println("a")
+	println("b")
+}
+
+type (
+	A int
+	B struct {
+		X int
+	}
+)
+
+type C struct{}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []*ast.File{f}
+	ends := FuncEndLines(fset, files)
+
+	pkg, err := doc.NewFromFiles(fset, files, "example.com/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ends[pkg.Funcs[0].Decl.Pos()]; got != 7 {
+		t.Errorf("Run ends on line %d, want 7", got)
+	}
+
+	want := map[string][2]int{"A": {10, 10}, "B": {11, 13}, "C": {16, 16}}
+	for _, typ := range pkg.Types {
+		start, end := TypeDeclRange(fset, typ)
+		if w := want[typ.Name]; start.Line != w[0] || end.Line != w[1] {
+			t.Errorf("%s spans %d-%d, want %d-%d", typ.Name, start.Line, end.Line, w[0], w[1])
+		}
+	}
+}
+
+func TestGenerateDirectives(t *testing.T) {
+	fset := token.NewFileSet()
+	files := parseFiles(t, fset,
+		"package p\n\n//go:generate stringer -type=Color\n\n// Color is a color.\ntype Color int\n",
+		"package p\n\n//go:generate go run gen.go -out tables.go\n//go:generate   \n\nfunc f() {\n\t//go:generate indented is not a directive\n}\n\n// See //go:generate in the docs.\nvar x int\n",
+	)
+
+	got := GenerateDirectives(fset, files)
+	want := []string{"stringer -type=Color", "go run gen.go -out tables.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GenerateDirectives() = %q, want %q", got, want)
+	}
+}
+
+func TestDeprecatedFieldsAndValues(t *testing.T) {
+	src := `package foo
+
+type Config struct {
+	Name string
+
+	// Deprecated: use Name.
+	Title string
+	Legacy bool // Deprecated: no longer read.
+	// Deprecated: embed Base instead.
+	*Old
+}
+
+type Old struct{}
+
+const (
+	Fast = iota
+	// Deprecated: same as Fast.
+	Quick
+	Slow // Deprecated: removed in v2.
+)
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := doc.NewFromFiles(fset, []*ast.File{f}, "example.com/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, typ := range pkg.Types {
+		if typ.Name != "Config" {
+			continue
+		}
+		got := DeprecatedFields(typ)
+		if want := []string{"Title", "Legacy", "Old"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("DeprecatedFields = %v, want %v", got, want)
+		}
+	}
+
+	if len(pkg.Consts) != 1 {
+		t.Fatalf("got %d const groups, want 1", len(pkg.Consts))
+	}
+	got := DeprecatedValueNames(pkg.Consts[0])
+	if want := []string{"Quick", "Slow"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DeprecatedValueNames = %v, want %v", got, want)
+	}
+}
+
+func TestImportPathLeaf(t *testing.T) {
+	tests := map[string]string{
+		"github.com/x/y/pkg":       "pkg",
+		"github.com/go-chi/chi/v5": "chi",
+		"gopkg.in/yaml.v3":         "yaml",
+		"example.com/v2":           "v2",
+		"strings":                  "strings",
+		"github.com/x/vendor/":     "vendor",
+	}
+	for path, want := range tests {
+		if got := ImportPathLeaf(path); got != want {
+			t.Errorf("ImportPathLeaf(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestSelectPackageName(t *testing.T) {
+	tests := []struct {
+		name       string
+		fileCounts map[string]int
+		importPath string
+		want       string
+		wantErr    bool
+	}{
+		{"external test package", map[string]int{"foo": 1, "foo_test": 3}, "example.com/foo", "foo", false},
+		{"directory name differs", map[string]int{"yaml": 4}, "gopkg.in/yaml.v3", "yaml", false},
+		{"leaf match beats file count", map[string]int{"foo": 1, "main": 2}, "example.com/foo", "foo", false},
+		{"go- prefix", map[string]int{"toml": 2, "main": 2}, "github.com/x/go-toml/v2", "toml", false},
+		{"most files", map[string]int{"client": 5, "main": 1}, "example.com/api", "client", false},
+		{"ambiguous", map[string]int{"a": 2, "b": 2}, "example.com/c", "", true},
+		{"only tests", map[string]int{"foo_test": 2}, "example.com/foo", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectPackageName(tt.fileCounts, tt.importPath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SelectPackageName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SelectPackageName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadLicenseFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	mit := "MIT License\n\nPermission is hereby granted, free of charge, to any person\n"
+	if text, ok := ReadLicenseFile(write("LICENSE", []byte(mit))); !ok || text != mit {
+		t.Errorf("ReadLicenseFile(small) = %q, %v", text, ok)
+	}
+
+	huge := mit + strings.Repeat("x", 2*LicenseTextLimit)
+	text, ok := ReadLicenseFile(write("HUGE", []byte(huge)))
+	if !ok {
+		t.Fatal("ReadLicenseFile(huge) not ok")
+	}
+	if len(text) != LicenseTextLimit+len(licenseTruncatedNote) || !strings.HasSuffix(text, licenseTruncatedNote) {
+		t.Errorf("huge license text has %d bytes, want truncation at %d", len(text), LicenseTextLimit)
+	}
+
+	// Latin-1 text is still a license; invalid bytes are replaced
+	latin1 := []byte("Copyright \xa9 1999\nISC License\n")
+	if text, ok := ReadLicenseFile(write("LATIN1", latin1)); !ok || text != "Copyright \uFFFD 1999\nISC License\n" {
+		t.Errorf("ReadLicenseFile(latin1) = %q, %v", text, ok)
+	}
+
+	for name, content := range map[string][]byte{
+		"BINARY":  {0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00},
+		"CONTROL": []byte("\x01\x02\x03\x04\x05\x06\x07\x08License"),
+		"EMPTY":   {},
+	} {
+		if _, ok := ReadLicenseFile(write(name, content)); ok {
+			t.Errorf("ReadLicenseFile(%s) should not be ok", name)
+		}
+	}
+	if _, ok := ReadLicenseFile(filepath.Join(dir, "MISSING")); ok {
+		t.Error("ReadLicenseFile(missing) should not be ok")
+	}
+}
+
+func TestDetectLicense_SkipsBinary(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "LICENSE"), []byte{0x00, 0x01, 0x02}, 0644)
+	os.WriteFile(filepath.Join(dir, "COPYING"), []byte("ISC License\n"), 0644)
+
+	if got, _ := DetectLicense(dir); got != "ISC" {
+		t.Errorf("DetectLicense() = %q, want ISC from COPYING", got)
+	}
+}
+
+func TestIdentifyLicense_OnlyReadsPrefix(t *testing.T) {
+	content := strings.Repeat(" ", LicenseIdentifyLimit) + "MIT License"
+	if got := IdentifyLicense(content); got != "Unknown" {
+		t.Errorf("IdentifyLicense() = %q, want Unknown for text past the limit", got)
+	}
+}
+
+func TestDetectLicense_MultipleFiles(t *testing.T) {
+	mit := "MIT License\n\nPermission is hereby granted, free of charge, to any person\n"
+	apache := "Apache License\nVersion 2.0, January 2004\n"
+	write := func(dir, name, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		files     map[string]string
+		extra     []string
+		wantType  string
+		wantFiles []string
+	}{
+		{
+			name:      "single LICENSE",
+			files:     map[string]string{"LICENSE": mit, "README.md": "MIT License"},
+			wantType:  "MIT",
+			wantFiles: []string{"LICENSE"},
+		},
+		{
+			name:      "dual license files",
+			files:     map[string]string{"LICENSE-MIT": mit, "LICENSE-APACHE": apache},
+			wantType:  "Apache-2.0 OR MIT",
+			wantFiles: []string{"LICENSE-APACHE", "LICENSE-MIT"},
+		},
+		{
+			name:      "licenses directory named by SPDX id",
+			files:     map[string]string{"LICENSES/MIT.txt": "Copyright (c) Someone", "LICENSES/BSD-2-Clause.txt": "Copyright (c) Someone"},
+			wantType:  "BSD-2-Clause OR MIT",
+			wantFiles: []string{"LICENSES/BSD-2-Clause.txt", "LICENSES/MIT.txt"},
+		},
+		{
+			name:      "extra file name",
+			files:     map[string]string{"TERMS.txt": mit},
+			extra:     []string{"terms.txt"},
+			wantType:  "MIT",
+			wantFiles: []string{"TERMS.txt"},
+		},
+		{
+			name:      "SPDX comment in go.mod wins",
+			files:     map[string]string{"LICENSE": "Custom terms", "go.mod": "// SPDX-License-Identifier: MIT OR Apache-2.0\nmodule example.com/m\n"},
+			wantType:  "MIT OR Apache-2.0",
+			wantFiles: []string{"LICENSE"},
+		},
+		{
+			name:     "no license",
+			files:    map[string]string{"main.go": "package main"},
+			wantType: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				write(dir, name, content)
+			}
+
+			var names []string
+			for _, f := range DetectLicenseFiles(dir, tt.extra...) {
+				names = append(names, f.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantFiles) {
+				t.Errorf("DetectLicenseFiles() = %v, want %v", names, tt.wantFiles)
+			}
+
+			licenseType, text := DetectLicense(dir, tt.extra...)
+			if licenseType != tt.wantType {
+				t.Errorf("DetectLicense() type = %q, want %q", licenseType, tt.wantType)
+			}
+			if len(tt.wantFiles) > 1 && !strings.Contains(text, "==> "+tt.wantFiles[1]+" <==") {
+				t.Errorf("combined text lacks a header for %s:\n%s", tt.wantFiles[1], text)
+			}
+		})
+	}
+}
+
+func TestIsRedistributable_Expressions(t *testing.T) {
+	tests := map[string]bool{
+		"MIT":                            true,
+		"MIT OR Apache-2.0":              true,
+		"GPL-3.0 OR MIT":                 true,
+		"MIT AND GPL-3.0":                false,
+		"(MIT AND ISC) OR GPL-3.0":       true,
+		"Apache-2.0 WITH LLVM-exception": true,
+		"Unknown":                        false,
+		"":                               false,
+	}
+	for license, want := range tests {
+		if got := IsRedistributable(license); got != want {
+			t.Errorf("IsRedistributable(%q) = %v, want %v", license, got, want)
+		}
+	}
+}
+
+func TestCompareGoVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.22", "1.9", 1},
+		{"1.9", "1.22", -1},
+		{"1.21", "1.21.0", -1},
+		{"1.21.3", "1.21.0", 1},
+		{"1.23", "1.23", 0},
+		{"", "1.16", -1},
+	}
+	for _, tt := range tests {
+		if got := CompareGoVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareGoVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRequiresGoVersion(t *testing.T) {
+	tests := map[string]bool{
+		"1.23":   true,
+		"1.21.0": true,
+		"1.21":   true,
+		"1.20":   false,
+		"1.9":    false,
+		"":       false,
+		"banana": false,
+	}
+	for v, want := range tests {
+		if got := RequiresGoVersion(v); got != want {
+			t.Errorf("RequiresGoVersion(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
+
+func TestModuleRepoDir(t *testing.T) {
+	tests := map[string]string{
+		"github.com/org/repo":               "",
+		"github.com/org/repo/v2":            "",
+		"github.com/org/repo/submodule":     "submodule",
+		"github.com/org/repo/tools/lint/v3": "tools/lint",
+		"golang.org/x/tools/gopls":          "gopls",
+		"example.com/org/repo/sub":          "",
+	}
+	for modulePath, want := range tests {
+		if got := ModuleRepoDir(modulePath); got != want {
+			t.Errorf("ModuleRepoDir(%q) = %q, want %q", modulePath, got, want)
+		}
+	}
+}
+
+func TestSourceURL(t *testing.T) {
+	tests := []struct {
+		modulePath, version, pkgDir, file string
+		line                              int
+		want                              string
+	}{
+		{"github.com/org/repo", "v1.2.0", "", "repo.go", 10, "https://github.com/org/repo/blob/v1.2.0/repo.go#L10"},
+		{"github.com/org/repo/submodule", "v0.3.0", "", "", 0, "https://github.com/org/repo/tree/submodule/v0.3.0/submodule"},
+		{"github.com/org/repo/submodule", "v0.3.0", "internal/x", "x.go", 5, "https://github.com/org/repo/blob/submodule/v0.3.0/submodule/internal/x/x.go#L5"},
+		{"github.com/org/repo/v2", "v2.0.1", "pkg", "a.go", 0, "https://github.com/org/repo/blob/v2.0.1/pkg/a.go"},
+		{"github.com/org/repo", "v0.0.0-20240102030405-abcdef123456", "", "", 0, "https://github.com/org/repo/tree/abcdef123456"},
+		{"github.com/org/repo", "v3.1.0+incompatible", "", "a.go", 0, "https://github.com/org/repo/blob/v3.1.0/a.go"},
+		{"github.com/org/repo", "", "", "", 0, "https://github.com/org/repo/tree/HEAD"},
+		{"gitlab.com/group/project/sub", "v1.0.0", "", "a.go", 3, "https://gitlab.com/group/project/-/blob/sub/v1.0.0/sub/a.go#L3"},
+		{"bitbucket.org/team/repo", "v1.0.0", "", "a.go", 3, "https://bitbucket.org/team/repo/src/v1.0.0/a.go#lines-3"},
+		{"golang.org/x/tools/gopls", "v0.16.0", "", "main.go", 7, "https://go.googlesource.com/tools/+/gopls/v0.16.0/gopls/main.go#7"},
+		{"example.com/repo", "v1.0.0", "", "a.go", 1, ""},
+	}
+	for _, tt := range tests {
+		if got := SourceURL(tt.modulePath, tt.version, tt.pkgDir, tt.file, tt.line); got != tt.want {
+			t.Errorf("SourceURL(%q, %q, %q, %q, %d) = %q, want %q", tt.modulePath, tt.version, tt.pkgDir, tt.file, tt.line, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MarshalYAML encodes v as a YAML document. Struct fields are named and
+// omitted by their json tags, so the document has the same keys as the
+// encoding/json output; multi-line strings are written as literal blocks.
+func MarshalYAML(v interface{}) ([]byte, error) {
+	var e yamlEncoder
+	rv := indirect(reflect.ValueOf(v))
+	if isYAMLCollection(rv) && yamlLen(rv) > 0 {
+		if err := e.collection(rv, 0); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := e.node(rv, 0); err != nil {
+			return nil, err
+		}
+		// node writes the separator expected after "key:"
+		e.buf.Next(1)
+	}
+	return e.buf.Bytes(), nil
+}
+
+type yamlEncoder struct {
+	buf bytes.Buffer
+}
+
+// node writes the value of a mapping entry or sequence item whose key or
+// dash has been written: " scalar\n" on the same line, or a newline and the
+// contents of a collection indented by indent+2
+func (e *yamlEncoder) node(v reflect.Value, indent int) error {
+	v = indirect(v)
+	if !v.IsValid() || (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.IsNil() {
+		e.buf.WriteString(" null\n")
+		return nil
+	}
+	if v.Kind() == reflect.String && isYAMLBlock(v.String()) {
+		e.block(v.String(), indent+2)
+		return nil
+	}
+	if !isYAMLCollection(v) {
+		s, err := yamlScalar(v)
+		if err != nil {
+			return err
+		}
+		e.buf.WriteString(" " + s + "\n")
+		return nil
+	}
+	if yamlLen(v) == 0 {
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			e.buf.WriteString(" []\n")
+		} else {
+			e.buf.WriteString(" {}\n")
+		}
+		return nil
+	}
+	e.buf.WriteByte('\n')
+	return e.collection(v, indent+2)
+}
+
+// collection writes the entries of a non-empty mapping or the items of a
+// non-empty sequence, each on its own lines at indent
+func (e *yamlEncoder) collection(v reflect.Value, indent int) error {
+	pad := strings.Repeat(" ", indent)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			item := indirect(v.Index(i))
+			if isYAMLCollection(item) && yamlLen(item) > 0 && item.Kind() != reflect.Slice && item.Kind() != reflect.Array {
+				// A mapping item starts on the dash's line: "- key: value"
+				start := e.buf.Len()
+				if err := e.collection(item, indent+2); err != nil {
+					return err
+				}
+				copy(e.buf.Bytes()[start+indent:], "- ")
+				continue
+			}
+			e.buf.WriteString(pad + "-")
+			if err := e.node(item, indent); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		names := make([]string, len(keys))
+		for i, k := range keys {
+			names[i] = fmt.Sprint(k.Interface())
+		}
+		sort.Sort(byName{names, keys})
+		for i, k := range keys {
+			e.buf.WriteString(pad + yamlString(names[i]) + ":")
+			if err := e.node(v.MapIndex(k), indent); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for _, f := range yamlFields(v) {
+			e.buf.WriteString(pad + yamlString(f.name) + ":")
+			if err := e.node(f.value, indent); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// block writes s as a literal block scalar with its lines at indent
+func (e *yamlEncoder) block(s string, indent int) {
+	chomp, body := "-", s
+	if strings.HasSuffix(s, "\n\n") {
+		chomp, body = "+", s[:len(s)-1]
+	} else if strings.HasSuffix(s, "\n") {
+		chomp, body = "", s[:len(s)-1]
+	}
+	e.buf.WriteString(" |" + chomp + "\n")
+	pad := strings.Repeat(" ", indent)
+	for _, line := range strings.Split(body, "\n") {
+		if line != "" {
+			e.buf.WriteString(pad + line)
+		}
+		e.buf.WriteByte('\n')
+	}
+}
+
+// byName sorts map keys by their string form
+type byName struct {
+	names []string
+	keys  []reflect.Value
+}
+
+func (b byName) Len() int           { return len(b.names) }
+func (b byName) Less(i, j int) bool { return b.names[i] < b.names[j] }
+func (b byName) Swap(i, j int) {
+	b.names[i], b.names[j] = b.names[j], b.names[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
+
+type yamlField struct {
+	name  string
+	value reflect.Value
+}
+
+// yamlFields returns the fields of a struct as encoding/json would encode
+// them: exported, renamed by their json tag, without "-" and empty omitempty
+// fields, and with untagged embedded structs inlined
+func yamlFields(v reflect.Value) []yamlField {
+	var fields []yamlField
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := v.Field(i)
+		if ft := sf.Type; sf.Anonymous && name == "" {
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if inner := indirect(fv); inner.IsValid() {
+					fields = append(fields, yamlFields(inner)...)
+				}
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if strings.Contains(","+opts+",", ",omitempty,") && isEmptyValue(fv) {
+			continue
+		}
+		fields = append(fields, yamlField{name, fv})
+	}
+	return fields
+}
+
+// isEmptyValue reports whether omitempty leaves v out, as in encoding/json
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+// indirect follows pointers and interfaces, returning the zero Value for nil
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func isYAMLCollection(v reflect.Value) bool {
+	switch indirect(v).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return true
+	}
+	return false
+}
+
+// yamlLen is the number of entries or items a collection is written with
+func yamlLen(v reflect.Value) int {
+	if v.Kind() == reflect.Struct {
+		return len(yamlFields(v))
+	}
+	return v.Len()
+}
+
+func yamlScalar(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return yamlString(v.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		switch f := v.Float(); {
+		case math.IsNaN(f):
+			return ".nan", nil
+		case math.IsInf(f, 1):
+			return ".inf", nil
+		case math.IsInf(f, -1):
+			return "-.inf", nil
+		default:
+			return strconv.FormatFloat(f, 'g', -1, v.Type().Bits()), nil
+		}
+	}
+	return "", fmt.Errorf("yaml: unsupported type %s", v.Type())
+}
+
+// yamlReserved are the plain scalars YAML 1.1 and 1.2 parsers read as
+// booleans, null or special floats
+var yamlReserved = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true,
+	"y": true, "n": true, "null": true, "~": true, ".nan": true, ".inf": true, "+.inf": true,
+}
+
+// yamlString returns s as a plain scalar when it would read back as the same
+// string, double-quoted otherwise
+func yamlString(s string) string {
+	if s == "" || yamlReserved[strings.ToLower(s)] || strings.TrimSpace(s) != s ||
+		strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") ||
+		strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return strconv.Quote(s)
+	}
+	if len(s) >= 5 && strings.Trim(s[:4], "0123456789") == "" && s[4] == '-' {
+		// YAML 1.1 reads dates and timestamps as such
+		return strconv.Quote(s)
+	}
+	for _, r := range s {
+		if !unicode.IsPrint(r) || r == unicode.ReplacementChar {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
+// isYAMLBlock reports whether s is written as a literal block: it spans
+// several lines, has no control characters besides tabs, and its first line
+// with content does not start with a space, which would be taken for
+// indentation
+func isYAMLBlock(s string) bool {
+	if !strings.Contains(s, "\n") {
+		return false
+	}
+	for _, r := range s {
+		if r != '\n' && r != '\t' && !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	for _, line := range strings.Split(s, "\n") {
+		if line != "" {
+			return line[0] != ' '
+		}
+	}
+	return false
+}
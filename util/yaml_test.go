@@ -0,0 +1,123 @@
+package util
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMarshalYAML(t *testing.T) {
+	type inner struct {
+		Key   string `json:"key"`
+		Count int    `json:"count,omitempty"`
+	}
+	type Embedded struct {
+		Extra bool `json:"extra"`
+	}
+	type doc struct {
+		Embedded
+		Name     string            `json:"name"`
+		Doc      string            `json:"doc"`
+		Skipped  string            `json:"-"`
+		Empty    string            `json:"empty,omitempty"`
+		Untagged float64           // named after the field
+		Items    []inner           `json:"items"`
+		Names    []string          `json:"names"`
+		None     []string          `json:"none"`
+		Nested   [][]int           `json:"nested"`
+		Files    map[string]string `json:"files"`
+		Ptr      *inner            `json:"ptr"`
+		secret   string
+	}
+	v := &doc{
+		Embedded: Embedded{Extra: true},
+		Name:     "example.com/pkg",
+		Doc:      "First line.\n\n\tcode()\n",
+		Skipped:  "skipped",
+		Untagged: math.Inf(1),
+		Items:    []inner{{Key: "a", Count: 2}, {Key: "yes"}},
+		Names:    []string{},
+		Nested:   [][]int{{1, 2}},
+		Files:    map[string]string{"b.go": "package b", "a.go": "package a\n"},
+		secret:   "secret",
+	}
+	want := `extra: true
+name: example.com/pkg
+doc: |
+  First line.
+
+  	code()
+Untagged: .inf
+items:
+  - key: a
+    count: 2
+  - key: "yes"
+names: []
+none: null
+nested:
+  -
+    - 1
+    - 2
+files:
+  a.go: |
+    package a
+  b.go: package b
+ptr: null
+`
+	got, err := MarshalYAML(v)
+	if err != nil {
+		t.Fatalf("MarshalYAML() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("MarshalYAML() =\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := MarshalYAML(struct{ F func() }{}); err == nil {
+		t.Errorf("MarshalYAML(func) error = nil, want an error")
+	}
+}
+
+func TestYAMLString(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"net/http", "net/http"},
+		{"v1.2.3", "v1.2.3"},
+		{"func (c *Client) Do(req *Request) (*Response, error)", "func (c *Client) Do(req *Request) (*Response, error)"},
+		{"", `""`},
+		{"true", `"true"`},
+		{"No", `"No"`},
+		{"1.21", `"1.21"`},
+		{"0x1F", `"0x1F"`},
+		{"2024-01-15T10:00:00Z", `"2024-01-15T10:00:00Z"`},
+		{"key: value", `"key: value"`},
+		{"a #comment", `"a #comment"`},
+		{"*Base", `"*Base"`},
+		{"- item", `"- item"`},
+		{" padded", `" padded"`},
+		{"tab\there", `"tab\there"`},
+	}
+	for _, tt := range tests {
+		if got := yamlString(tt.s); got != tt.want {
+			t.Errorf("yamlString(%q) = %s, want %s", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestIsYAMLBlock(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"one line", false},
+		{"two\nlines", true},
+		{"\tindented\n", true},
+		{"\n  leading spaces", false},
+		{"carriage\r\nreturn", false},
+	}
+	for _, tt := range tests {
+		if got := isYAMLBlock(tt.s); got != tt.want {
+			t.Errorf("isYAMLBlock(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package web
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alexisbouchez/wikigo/db"
+)
+
+// requireAdmin serves next only to requests carrying the admin token, either as
+// a bearer token or as the password of HTTP basic auth so browsers can prompt
+// for it. Admin pages do not exist when no token is configured.
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.adminToken == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			_, token, ok = r.BasicAuth()
+		}
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="wikigo admin"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// crawlErrorSorts orders crawl errors by the columns of the /admin/errors table
+var crawlErrorSorts = map[string]func(a, b *db.CrawlError) bool{
+	"module": func(a, b *db.CrawlError) bool { return a.ModulePath < b.ModulePath },
+	"version": func(a, b *db.CrawlError) bool {
+		return a.ModulePath < b.ModulePath || a.ModulePath == b.ModulePath && a.Version < b.Version
+	},
+	"error": func(a, b *db.CrawlError) bool { return a.Error < b.Error },
+}
+
+// handleAdminErrors lists recent crawl failures, newest first unless ?sort=
+// names another column
+func (s *Server) handleAdminErrors(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	limit := 500
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = min(l, 5000)
+	}
+	crawlErrors, err := s.db.RecentCrawlErrors(limit)
+	if err != nil {
+		log.Printf("Error fetching crawl errors: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	sortBy := r.URL.Query().Get("sort")
+	if less, ok := crawlErrorSorts[sortBy]; ok {
+		sort.SliceStable(crawlErrors, func(i, j int) bool { return less(crawlErrors[i], crawlErrors[j]) })
+	} else {
+		sortBy = "time"
+	}
+
+	w.Header().Add("Vary", "Accept")
+	if prefersJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		if crawlErrors == nil {
+			crawlErrors = []*db.CrawlError{}
+		}
+		json.NewEncoder(w).Encode(crawlErrors)
+		return
+	}
+
+	modules := make(map[string]bool)
+	for _, ce := range crawlErrors {
+		modules[ce.ModulePath] = true
+	}
+
+	data := struct {
+		Title       string
+		SearchQuery string
+		Pkg         *PackageDoc
+		Errors      []*db.CrawlError
+		Modules     int
+		Sort        string
+	}{
+		Title:   "Crawl Errors - Go Packages",
+		Errors:  crawlErrors,
+		Modules: len(modules),
+		Sort:    sortBy,
+	}
+	if err := s.templates.ExecuteTemplate(w, "admin_errors.html", data); err != nil {
+		log.Printf("Error rendering crawl errors: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
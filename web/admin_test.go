@@ -0,0 +1,75 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alexisbouchez/wikigo/db"
+)
+
+func TestHandleAdminErrors(t *testing.T) {
+	s, handler := seededServer(t)
+
+	for _, ce := range []db.CrawlError{
+		{ModulePath: "example.com/zeta", Version: "v1.0.0", Error: "downloading module: status 410"},
+		{ModulePath: "example.com/alpha", Version: "v0.1.0", Error: "finding module root: <no go.mod>"},
+	} {
+		if err := s.db.RecordCrawlError(ce.ModulePath, ce.Version, ce.Error); err != nil {
+			t.Fatalf("RecordCrawlError() error = %v", err)
+		}
+	}
+
+	if w := serve(handler, "/admin/errors"); w.Code != http.StatusNotFound {
+		t.Errorf("without an admin token status = %d, want 404", w.Code)
+	}
+
+	s.adminToken = "s3cret"
+	withToken := func(target string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		req.SetBasicAuth("admin", "s3cret")
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve(handler, "/admin/errors"); w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("without credentials status = %d, want 401 with a challenge", w.Code)
+	}
+	if w := serve(handler, "/admin/errors", "Authorization", "Bearer wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("with a wrong token status = %d, want 401", w.Code)
+	}
+	if w := serve(handler, "/admin/errors", "Authorization", "Bearer s3cret"); w.Code != http.StatusOK {
+		t.Errorf("with a bearer token status = %d, want 200", w.Code)
+	}
+
+	w := withToken("/admin/errors")
+	body := w.Body.String()
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if !strings.Contains(body, "2 failures across 2 modules") || !strings.Contains(body, "&lt;no go.mod&gt;") {
+		t.Errorf("page does not list the escaped crawl errors:\n%s", body)
+	}
+	if strings.Index(body, "example.com/alpha") > strings.Index(body, "example.com/zeta") {
+		t.Error("default order is not newest first")
+	}
+
+	body = withToken("/admin/errors?sort=module").Body.String()
+	if strings.Index(body, "example.com/alpha") > strings.Index(body, "example.com/zeta") {
+		t.Error("?sort=module does not order by module path")
+	}
+
+	var crawlErrors []db.CrawlError
+	if err := json.Unmarshal(withToken("/admin/errors?sort=module", "Accept", "application/json").Body.Bytes(), &crawlErrors); err != nil {
+		t.Fatalf("decoding JSON: %v", err)
+	}
+	if len(crawlErrors) != 2 || crawlErrors[0].ModulePath != "example.com/alpha" {
+		t.Errorf("JSON crawl errors = %+v", crawlErrors)
+	}
+}
@@ -0,0 +1,130 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alexisbouchez/wikigo/db"
+)
+
+// aiDocStatus is the generation state of one symbol's AI doc, without the generated text
+type aiDocStatus struct {
+	Name      string     `json:"name"`
+	Kind      string     `json:"kind"`   // "package", "func", "type" or "method"
+	Status    string     `json:"status"` // "missing", "pending", "approved", "flagged" or "orphaned"
+	CostUSD   float64    `json:"cost_usd"`
+	Tokens    int        `json:"tokens"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// aiDocsReport summarizes the AI docs of a package
+type aiDocsReport struct {
+	ImportPath   string        `json:"import_path"`
+	Generated    int           `json:"generated"`
+	Approved     int           `json:"approved"`
+	Missing      int           `json:"missing"`
+	TotalCostUSD float64       `json:"total_cost_usd"`
+	TotalTokens  int           `json:"total_tokens"`
+	Symbols      []aiDocStatus `json:"symbols"`
+}
+
+// handleAIDocsAPI reports which symbols of a package have AI docs, their review
+// state and what they cost. Undocumented exported symbols without one are listed
+// as missing, so clients can tell what gendocs still has to cover.
+func (s *Server) handleAIDocsAPI(w http.ResponseWriter, r *http.Request) {
+	importPath := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/aidocs/"), "/")
+	if importPath == "" {
+		http.Error(w, "Usage: /api/aidocs/<import-path>", http.StatusBadRequest)
+		return
+	}
+	if s.db == nil {
+		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	docs, err := s.db.GetAIDocsForPackage(importPath)
+	if err != nil {
+		log.Printf("Error fetching AI docs for %s: %v", importPath, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	pkg, ok := s.FindPackage(importPath)
+	if !ok && len(docs) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(buildAIDocsReport(importPath, pkg, docs))
+}
+
+// buildAIDocsReport merges a package's AI docs with its undocumented exported
+// symbols; pkg may be nil when only the AI docs are known
+func buildAIDocsReport(importPath string, pkg *PackageDoc, docs []*db.AIDoc) aiDocsReport {
+	report := aiDocsReport{ImportPath: importPath, Symbols: []aiDocStatus{}}
+
+	seen := make(map[string]bool)
+	for _, doc := range docs {
+		status := "pending"
+		switch {
+		case doc.Orphaned:
+			status = "orphaned"
+		case doc.Flagged:
+			status = "flagged"
+		case doc.Approved:
+			status = "approved"
+			report.Approved++
+		}
+		updated := doc.UpdatedAt
+		report.Symbols = append(report.Symbols, aiDocStatus{
+			Name:      doc.SymbolName,
+			Kind:      doc.SymbolKind,
+			Status:    status,
+			CostUSD:   doc.CostUSD,
+			Tokens:    doc.Tokens,
+			UpdatedAt: &updated,
+		})
+		report.Generated++
+		report.TotalCostUSD += doc.CostUSD
+		report.TotalTokens += doc.Tokens
+		seen[doc.SymbolKind+":"+doc.SymbolName] = true
+	}
+
+	// Symbols are keyed the way gendocs stores them, methods by their bare name
+	missing := func(kind, name, comment string) {
+		if comment != "" || seen[kind+":"+name] {
+			return
+		}
+		seen[kind+":"+name] = true
+		report.Symbols = append(report.Symbols, aiDocStatus{Name: name, Kind: kind, Status: "missing"})
+		report.Missing++
+	}
+	if pkg != nil {
+		missing("package", pkg.Name, pkg.Doc)
+		for _, fn := range pkg.Functions {
+			missing("func", fn.Name, fn.Doc)
+		}
+		for _, t := range pkg.Types {
+			missing("type", t.Name, t.Doc)
+			for _, fn := range t.Functions {
+				missing("func", fn.Name, fn.Doc)
+			}
+			for _, m := range t.Methods {
+				missing("method", m.Name, m.Doc)
+			}
+		}
+	}
+
+	sort.SliceStable(report.Symbols, func(i, j int) bool {
+		a, b := report.Symbols[i], report.Symbols[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	return report
+}
@@ -0,0 +1,81 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/alexisbouchez/wikigo/db"
+)
+
+func TestHandleAIDocsAPI(t *testing.T) {
+	s, handler := seededServer(t)
+
+	s.packages["example.com/mem/widgets"].Types[0].Methods = []Function{
+		{Name: "Spin", Recv: "*Widget", Doc: "Spin spins the widget."},
+		{Name: "Stop", Recv: "*Widget"},
+	}
+	for _, doc := range []*db.AIDoc{
+		{SymbolName: "NewWidget", SymbolKind: "func", Approved: true, CostUSD: 0.002, Tokens: 1000},
+		{SymbolName: "Widget", SymbolKind: "type", Flagged: true, FlagReason: "wrong", CostUSD: 0.001, Tokens: 500},
+		{SymbolName: "Widget", SymbolKind: "type", CostUSD: 0.001, Tokens: 500}, // regenerated
+		{SymbolName: "Reset", SymbolKind: "method", CostUSD: 0.0005, Tokens: 250},
+	} {
+		doc.ImportPath, doc.GeneratedDoc = "example.com/mem/widgets", "Generated."
+		if err := s.db.UpsertAIDoc(doc); err != nil {
+			t.Fatalf("UpsertAIDoc() error = %v", err)
+		}
+	}
+	// Indexing flags the Reset doc, whose method no longer exists, as orphaned
+	if err := s.IndexPackage(s.packages["example.com/mem/widgets"]); err != nil {
+		t.Fatalf("IndexPackage() error = %v", err)
+	}
+
+	w := serve(handler, "/api/aidocs/example.com/mem/widgets")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body)
+	}
+	var report aiDocsReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+
+	if report.Generated != 3 || report.Approved != 1 || report.Missing != 2 || report.TotalTokens != 2250 {
+		t.Errorf("generated, approved, missing, tokens = %d, %d, %d, %d, want 3, 1, 2, 2250",
+			report.Generated, report.Approved, report.Missing, report.TotalTokens)
+	}
+	if report.TotalCostUSD < 0.00449 || report.TotalCostUSD > 0.00451 {
+		t.Errorf("total cost = %v, want 0.0045", report.TotalCostUSD)
+	}
+
+	var got []string
+	for _, sym := range report.Symbols {
+		got = append(got, sym.Kind+":"+sym.Name+"="+sym.Status)
+	}
+	want := []string{
+		"func:NewWidget=approved",
+		"method:Reset=orphaned", // no longer in the package
+		"method:Stop=missing",   // Spin has a doc comment and needs none
+		"package:widgets=missing",
+		"type:Widget=flagged",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("symbols = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("symbols[%d] = %s, want %s", i, got[i], want[i])
+		}
+	}
+	if strings.Contains(w.Body.String(), "Generated.") || strings.Contains(w.Body.String(), "wrong") {
+		t.Error("response includes the generated doc or flag reason")
+	}
+
+	if w := serve(handler, "/api/aidocs/example.com/missing"); w.Code != http.StatusNotFound {
+		t.Errorf("missing package status = %d, want 404", w.Code)
+	}
+	if w := serve(handler, "/api/aidocs/"); w.Code != http.StatusBadRequest {
+		t.Errorf("empty path status = %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,130 @@
+package web
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// allPackagesPerPage is the page size of /packages
+const allPackagesPerPage = 50
+
+// allPackagesURL links to a page of /packages, omitting default parameters
+func allPackagesURL(letter string, page int) string {
+	q := url.Values{}
+	if letter != "" {
+		q.Set("letter", letter)
+	}
+	if page > 1 {
+		q.Set("page", strconv.Itoa(page))
+	}
+	if len(q) == 0 {
+		return "/packages"
+	}
+	return "/packages?" + q.Encode()
+}
+
+// browsePackagesInMemory lists the packages loaded from JSON files whose
+// import path starts with letter, sorted like db.BrowsePackages
+func (s *Server) browsePackagesInMemory(letter string) []*PackageDoc {
+	var packages []*PackageDoc
+	for _, pkg := range s.packages {
+		if pkg.Classification != "" || !strings.HasPrefix(pkg.ImportPath, letter) {
+			continue
+		}
+		packages = append(packages, pkg)
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].ImportPath < packages[j].ImportPath
+	})
+	return packages
+}
+
+// handleAllPackages serves /packages?letter=&page=, an alphabetical browse of
+// every indexed package by the first letter of its import path
+func (s *Server) handleAllPackages(w http.ResponseWriter, r *http.Request) {
+	// Import paths start with a lower-case host or standard library name
+	letter := r.URL.Query().Get("letter")
+	if len(letter) != 1 || letter[0] < 'a' || letter[0] > 'z' {
+		letter = ""
+	}
+
+	page := 1
+	if p := r.URL.Query().Get("page"); p != "" {
+		if n, err := fmt.Sscanf(p, "%d", &page); err != nil || n != 1 || page < 1 {
+			page = 1
+		}
+	}
+	offset := (page - 1) * allPackagesPerPage
+
+	var packages []*PackageDoc
+	var total int
+	if s.db != nil {
+		dbPkgs, n, err := s.db.BrowsePackages(letter, allPackagesPerPage, offset)
+		if err != nil {
+			log.Printf("Error browsing packages: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		for _, dbPkg := range dbPkgs {
+			if pkg, ok := s.packages[dbPkg.ImportPath]; ok {
+				packages = append(packages, pkg)
+				continue
+			}
+			packages = append(packages, packageSummary(dbPkg))
+		}
+		total = n
+	} else {
+		all := s.browsePackagesInMemory(letter)
+		total = len(all)
+		if offset < total {
+			packages = all[offset:min(offset+allPackagesPerPage, total)]
+		}
+	}
+
+	totalPages := max((total+allPackagesPerPage-1)/allPackagesPerPage, 1)
+
+	letters := []symbolLetter{{Letter: "", URL: allPackagesURL("", 1), Active: letter == ""}}
+	for c := 'a'; c <= 'z'; c++ {
+		l := string(c)
+		letters = append(letters, symbolLetter{Letter: l, URL: allPackagesURL(l, 1), Active: letter == l})
+	}
+
+	data := struct {
+		Title       string
+		SearchQuery string
+		Pkg         *PackageDoc
+		Letter      string
+		Letters     []symbolLetter
+		Packages    []*PackageDoc
+		Page        int
+		TotalPages  int
+		Total       int
+		PrevURL     string
+		NextURL     string
+	}{
+		Title:      "All Packages - Go Packages",
+		Pkg:        nil,
+		Letter:     letter,
+		Letters:    letters,
+		Packages:   packages,
+		Page:       page,
+		TotalPages: totalPages,
+		Total:      total,
+	}
+	if page > 1 {
+		data.PrevURL = allPackagesURL(letter, page-1)
+	}
+	if page < totalPages {
+		data.NextURL = allPackagesURL(letter, page+1)
+	}
+
+	if err := s.templates.ExecuteTemplate(w, "all_packages.html", data); err != nil {
+		log.Printf("Error rendering all packages: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
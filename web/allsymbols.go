@@ -0,0 +1,214 @@
+package web
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// allSymbolsPerPage is the page size of /all-symbols
+const allSymbolsPerPage = 100
+
+// symbolKinds are the kinds accepted by the symbol search and browse filters
+var symbolKinds = []string{"func", "type", "method", "const", "var"}
+
+// symbolLetter is one entry of the A-Z bar on /all-symbols and /packages
+type symbolLetter struct {
+	Letter string // "" for all letters
+	URL    string
+	Active bool
+}
+
+// allSymbolsURL links to a page of /all-symbols, omitting default parameters
+func allSymbolsURL(kind, letter string, page int) string {
+	q := url.Values{}
+	if kind != "" {
+		q.Set("kind", kind)
+	}
+	if letter != "" {
+		q.Set("letter", letter)
+	}
+	if page > 1 {
+		q.Set("page", strconv.Itoa(page))
+	}
+	if len(q) == 0 {
+		return "/all-symbols"
+	}
+	return "/all-symbols?" + q.Encode()
+}
+
+// packageSymbols lists the exported symbols of a package in page order
+func packageSymbols(pkg *PackageDoc) []SymbolResult {
+	var results []SymbolResult
+	add := func(name, kind, doc string, deprecated bool) {
+		results = append(results, SymbolResult{
+			Name:       name,
+			Kind:       kind,
+			Package:    pkg.Name,
+			ImportPath: pkg.ImportPath,
+			Synopsis:   shortDoc(doc),
+			Deprecated: deprecated,
+		})
+	}
+
+	for _, c := range pkg.Constants {
+		for _, name := range c.Names {
+			add(name, "const", c.Doc, c.Deprecated || slices.Contains(c.DeprecatedNames, name))
+		}
+	}
+	for _, v := range pkg.Variables {
+		for _, name := range v.Names {
+			add(name, "var", v.Doc, v.Deprecated || slices.Contains(v.DeprecatedNames, name))
+		}
+	}
+	for _, fn := range pkg.Functions {
+		add(fn.Name, "func", fn.Doc, fn.Deprecated)
+	}
+	for _, t := range pkg.Types {
+		add(t.Name, "type", t.Doc, t.Deprecated)
+		for _, c := range t.Constants {
+			for _, name := range c.Names {
+				add(name, "const", c.Doc, c.Deprecated || slices.Contains(c.DeprecatedNames, name))
+			}
+		}
+		for _, v := range t.Variables {
+			for _, name := range v.Names {
+				add(name, "var", v.Doc, v.Deprecated || slices.Contains(v.DeprecatedNames, name))
+			}
+		}
+		for _, fn := range t.Functions {
+			add(fn.Name, "func", fn.Doc, fn.Deprecated)
+		}
+		for _, m := range t.Methods {
+			add(t.Name+"."+m.Name, "method", m.Doc, m.Deprecated)
+		}
+	}
+	return results
+}
+
+// browseSymbolsInMemory lists the symbols of packages loaded from JSON files,
+// sorted like db.BrowseSymbols
+func (s *Server) browseSymbolsInMemory(kind, letter string) []SymbolResult {
+	var results []SymbolResult
+	for _, pkg := range s.packages {
+		if pkg.Classification != "" {
+			continue
+		}
+		for _, sym := range packageSymbols(pkg) {
+			if (kind != "" && sym.Kind != kind) || (letter != "" && sym.Name[:1] != letter) {
+				continue
+			}
+			results = append(results, sym)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Name != results[j].Name {
+			return results[i].Name < results[j].Name
+		}
+		return results[i].ImportPath < results[j].ImportPath
+	})
+	return results
+}
+
+// handleAllSymbols serves /all-symbols?kind=&letter=&page=, an alphabetical browse
+// of every indexed symbol
+func (s *Server) handleAllSymbols(w http.ResponseWriter, r *http.Request) {
+	kind := r.URL.Query().Get("kind")
+	if !slices.Contains(symbolKinds, kind) {
+		kind = ""
+	}
+	// Exported names start with an upper-case letter
+	letter := r.URL.Query().Get("letter")
+	if len(letter) != 1 || letter[0] < 'A' || letter[0] > 'Z' {
+		letter = ""
+	}
+
+	page := 1
+	if p := r.URL.Query().Get("page"); p != "" {
+		if n, err := fmt.Sscanf(p, "%d", &page); err != nil || n != 1 || page < 1 {
+			page = 1
+		}
+	}
+	offset := (page - 1) * allSymbolsPerPage
+
+	var results []SymbolResult
+	var total int
+	if s.db != nil {
+		symbols, n, err := s.db.BrowseSymbols(kind, letter, allSymbolsPerPage, offset)
+		if err != nil {
+			log.Printf("Error browsing symbols: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		for _, sym := range symbols {
+			packageName := sym.ImportPath
+			if pkg, ok := s.packages[sym.ImportPath]; ok {
+				packageName = pkg.Name
+			}
+			results = append(results, SymbolResult{
+				Name:       sym.Name,
+				Kind:       sym.Kind,
+				Package:    packageName,
+				ImportPath: sym.ImportPath,
+				Synopsis:   sym.Synopsis,
+				Deprecated: sym.Deprecated,
+			})
+		}
+		total = n
+	} else {
+		all := s.browseSymbolsInMemory(kind, letter)
+		total = len(all)
+		if offset < total {
+			results = all[offset:min(offset+allSymbolsPerPage, total)]
+		}
+	}
+
+	totalPages := max((total+allSymbolsPerPage-1)/allSymbolsPerPage, 1)
+
+	letters := []symbolLetter{{Letter: "", URL: allSymbolsURL(kind, "", 1), Active: letter == ""}}
+	for c := 'A'; c <= 'Z'; c++ {
+		l := string(c)
+		letters = append(letters, symbolLetter{Letter: l, URL: allSymbolsURL(kind, l, 1), Active: letter == l})
+	}
+
+	data := struct {
+		Title       string
+		SearchQuery string
+		Pkg         *PackageDoc
+		Kind        string
+		Letter      string
+		Letters     []symbolLetter
+		Results     []SymbolResult
+		Page        int
+		TotalPages  int
+		Total       int
+		PrevURL     string
+		NextURL     string
+	}{
+		Title:      "All Symbols - Go Packages",
+		Pkg:        nil,
+		Kind:       kind,
+		Letter:     letter,
+		Letters:    letters,
+		Results:    results,
+		Page:       page,
+		TotalPages: totalPages,
+		Total:      total,
+	}
+	if page > 1 {
+		data.PrevURL = allSymbolsURL(kind, letter, page-1)
+	}
+	if page < totalPages {
+		data.NextURL = allSymbolsURL(kind, letter, page+1)
+	}
+
+	if err := s.templates.ExecuteTemplate(w, "all_symbols.html", data); err != nil {
+		log.Printf("Error rendering all symbols: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
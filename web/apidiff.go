@@ -0,0 +1,263 @@
+package web
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// apiSymbols maps "kind:name" keys to the declaration of each exported symbol
+func apiSymbols(pkg *PackageDoc) map[string]string {
+	symbols := make(map[string]string)
+	if pkg == nil {
+		return symbols
+	}
+	for _, f := range pkg.Functions {
+		symbols["func:"+f.Name] = f.Signature
+	}
+	for _, t := range pkg.Types {
+		symbols["type:"+t.Name] = t.Decl
+		for _, m := range t.Methods {
+			symbols["method:"+t.Name+"."+m.Name] = m.Signature
+		}
+		for _, f := range t.Functions {
+			symbols["func:"+f.Name] = f.Signature
+		}
+		for _, c := range t.Constants {
+			for _, name := range c.Names {
+				symbols["const:"+name] = c.Decl
+			}
+		}
+		for _, v := range t.Variables {
+			for _, name := range v.Names {
+				symbols["var:"+name] = v.Decl
+			}
+		}
+	}
+	for _, c := range pkg.Constants {
+		for _, name := range c.Names {
+			symbols["const:"+name] = c.Decl
+		}
+	}
+	for _, v := range pkg.Variables {
+		for _, name := range v.Names {
+			symbols["var:"+name] = v.Decl
+		}
+	}
+	return symbols
+}
+
+// DiffAPI compares the exported API of two versions of a package and returns
+// the "added", "removed", and "changed" symbols sorted by kind and name, each
+// marked Breaking when it is incompatible under Go's compatibility rules.
+// Either package may be nil when the package was added or removed.
+func DiffAPI(oldPkg, newPkg *PackageDoc) []DiffEntry {
+	oldSymbols, newSymbols := apiSymbols(oldPkg), apiSymbols(newPkg)
+
+	var diff []DiffEntry
+	for key, oldDecl := range oldSymbols {
+		kind, name, _ := strings.Cut(key, ":")
+		newDecl, exists := newSymbols[key]
+		switch {
+		case !exists:
+			diff = append(diff, DiffEntry{Kind: "removed", Type: kind, Name: name, OldDecl: oldDecl})
+		case normalizeDecl(oldDecl) != normalizeDecl(newDecl):
+			diff = append(diff, DiffEntry{Kind: "changed", Type: kind, Name: name, OldDecl: oldDecl, NewDecl: newDecl})
+		}
+	}
+	for key, newDecl := range newSymbols {
+		if _, exists := oldSymbols[key]; !exists {
+			kind, name, _ := strings.Cut(key, ":")
+			diff = append(diff, DiffEntry{Kind: "added", Type: kind, Name: name, NewDecl: newDecl})
+		}
+	}
+
+	for i := range diff {
+		diff[i].Breaking = isBreakingChange(diff[i])
+	}
+
+	sort.Slice(diff, func(i, j int) bool {
+		if diff[i].Type != diff[j].Type {
+			return diff[i].Type < diff[j].Type
+		}
+		return diff[i].Name < diff[j].Name
+	})
+	return diff
+}
+
+// normalizeDecl collapses whitespace so reformatting alone is not reported as a change
+func normalizeDecl(decl string) string {
+	return strings.Join(strings.Fields(decl), " ")
+}
+
+// isBreakingChange applies Go's compatibility rules to a single change: removing
+// a symbol or changing a signature breaks callers, adding symbols does not.
+// Adding exported fields to a struct and changing a constant's value are compatible;
+// any change to an interface breaks either its callers or its implementations.
+func isBreakingChange(e DiffEntry) bool {
+	switch e.Kind {
+	case "removed":
+		return true
+	case "changed":
+		switch e.Type {
+		case "type":
+			return typeChangeBreaks(e.OldDecl, e.NewDecl)
+		case "const", "var":
+			oldType, ok1 := valueSpecType(e.OldDecl, e.Name)
+			newType, ok2 := valueSpecType(e.NewDecl, e.Name)
+			return !ok1 || !ok2 || oldType != newType
+		}
+		return true
+	}
+	return false
+}
+
+// parseDecl parses a single top-level declaration
+func parseDecl(decl string) (*ast.GenDecl, *token.FileSet) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", "package p\n"+decl, 0)
+	if err != nil || len(f.Decls) == 0 {
+		return nil, nil
+	}
+	gen, _ := f.Decls[0].(*ast.GenDecl)
+	return gen, fset
+}
+
+func exprString(fset *token.FileSet, node ast.Node) string {
+	if node == nil {
+		return ""
+	}
+	var b strings.Builder
+	printer.Fprint(&b, fset, node)
+	return b.String()
+}
+
+// valueSpecType returns the declared type of a constant or variable, "" if untyped
+func valueSpecType(decl, name string) (string, bool) {
+	gen, fset := parseDecl(decl)
+	if gen == nil {
+		return "", false
+	}
+	for _, spec := range gen.Specs {
+		vs, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		for _, n := range vs.Names {
+			if n.Name == name {
+				return exprString(fset, vs.Type), true
+			}
+		}
+	}
+	return "", false
+}
+
+// typeChangeBreaks reports whether a changed type declaration is incompatible.
+// Unparseable declarations are conservatively treated as breaking.
+func typeChangeBreaks(oldDecl, newDecl string) bool {
+	oldGen, oldFset := parseDecl(oldDecl)
+	newGen, newFset := parseDecl(newDecl)
+	if oldGen == nil || newGen == nil || len(oldGen.Specs) != 1 || len(newGen.Specs) != 1 {
+		return true
+	}
+	oldSpec, ok1 := oldGen.Specs[0].(*ast.TypeSpec)
+	newSpec, ok2 := newGen.Specs[0].(*ast.TypeSpec)
+	if !ok1 || !ok2 || oldSpec.Assign.IsValid() != newSpec.Assign.IsValid() {
+		return true
+	}
+	if exprString(oldFset, oldSpec.TypeParams) != exprString(newFset, newSpec.TypeParams) {
+		return true
+	}
+
+	oldStruct, ok1 := oldSpec.Type.(*ast.StructType)
+	newStruct, ok2 := newSpec.Type.(*ast.StructType)
+	if !ok1 || !ok2 {
+		return normalizeDecl(exprString(oldFset, oldSpec.Type)) != normalizeDecl(exprString(newFset, newSpec.Type))
+	}
+
+	// Structs stay compatible as long as every exported field keeps its type
+	newFields := structFields(newFset, newStruct)
+	for name, typ := range structFields(oldFset, oldStruct) {
+		if newFields[name] != typ {
+			return true
+		}
+	}
+	return false
+}
+
+// structFields maps exported field names, including embedded types, to their types
+func structFields(fset *token.FileSet, st *ast.StructType) map[string]string {
+	fields := make(map[string]string)
+	for _, field := range st.Fields.List {
+		typ := exprString(fset, field.Type)
+		if len(field.Names) == 0 {
+			name := strings.TrimPrefix(typ, "*")
+			if i := strings.LastIndex(name, "."); i >= 0 {
+				name = name[i+1:]
+			}
+			if i := strings.Index(name, "["); i >= 0 {
+				name = name[:i]
+			}
+			if ast.IsExported(name) {
+				fields[name] = typ
+			}
+			continue
+		}
+		for _, n := range field.Names {
+			if n.IsExported() {
+				fields[n.Name] = typ
+			}
+		}
+	}
+	return fields
+}
+
+// APIVerdict summarizes the compatibility impact of a diff
+type APIVerdict struct {
+	Breaking bool
+	Changes  int    // added, removed, and changed symbols
+	Bump     string // recommended semver bump: "major", "minor", or "patch"
+	Reason   string
+}
+
+// Verdict recommends a semver bump for releasing the diffed API after fromVersion.
+// Breaking changes need a major version, except in v0 where no compatibility is promised.
+func Verdict(diff []DiffEntry, fromVersion string) APIVerdict {
+	var v APIVerdict
+	added := 0
+	for _, e := range diff {
+		switch e.Kind {
+		case "added", "removed", "changed":
+			v.Changes++
+		default:
+			continue
+		}
+		if e.Breaking {
+			v.Breaking = true
+		}
+		if e.Kind == "added" {
+			added++
+		}
+	}
+
+	switch {
+	case v.Breaking && semver.Major(fromVersion) == "v0":
+		v.Bump = "minor"
+		v.Reason = "Incompatible changes; v0 makes no compatibility promise, so a minor bump is enough."
+	case v.Breaking:
+		v.Bump = "major"
+		v.Reason = "Incompatible changes require a new major version, which Go modules publish under a /vN module path."
+	case added > 0:
+		v.Bump = "minor"
+		v.Reason = "Backwards-compatible additions to the API."
+	default:
+		v.Bump = "patch"
+		v.Reason = "No incompatible changes or additions to the exported API."
+	}
+	return v
+}
@@ -0,0 +1,144 @@
+package web
+
+import "testing"
+
+func TestDiffAPI(t *testing.T) {
+	oldPkg := &PackageDoc{
+		Functions: []Function{
+			{Name: "Foo", Signature: "func Foo(x int) error"},
+			{Name: "Gone", Signature: "func Gone()"},
+		},
+		Types: []Type{{
+			Name:    "T",
+			Decl:    "type T struct {\n\tA int\n}",
+			Methods: []Function{{Name: "M", Signature: "func (t *T) M()"}},
+		}},
+		Constants: []Constant{{Names: []string{"X"}, Decl: "const X = 1"}},
+	}
+	newPkg := &PackageDoc{
+		Functions: []Function{
+			{Name: "Foo", Signature: "func Foo(x int64) error"},
+			{Name: "Added", Signature: "func Added()"},
+		},
+		Types: []Type{{
+			Name:    "T",
+			Decl:    "type T struct {\n    A int\n}",
+			Methods: []Function{{Name: "M", Signature: "func (t *T) M()"}},
+		}},
+		Constants: []Constant{{Names: []string{"X"}, Decl: "const X = 1"}},
+	}
+
+	want := []DiffEntry{
+		{Kind: "added", Type: "func", Name: "Added", NewDecl: "func Added()"},
+		{Kind: "changed", Type: "func", Name: "Foo", OldDecl: "func Foo(x int) error", NewDecl: "func Foo(x int64) error", Breaking: true},
+		{Kind: "removed", Type: "func", Name: "Gone", OldDecl: "func Gone()", Breaking: true},
+	}
+	got := DiffAPI(oldPkg, newPkg)
+	if len(got) != len(want) {
+		t.Fatalf("DiffAPI() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+}
+
+func TestDiffAPI_NewPackage(t *testing.T) {
+	pkg := &PackageDoc{Functions: []Function{{Name: "New", Signature: "func New()"}}}
+	got := DiffAPI(nil, pkg)
+	if len(got) != 1 || got[0].Kind != "added" || got[0].Breaking {
+		t.Errorf("DiffAPI(nil, pkg) = %+v, want one non-breaking addition", got)
+	}
+}
+
+func TestIsBreakingChange(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry DiffEntry
+		want  bool
+	}{
+		{"removed func", DiffEntry{Kind: "removed", Type: "func", Name: "F"}, true},
+		{"added func", DiffEntry{Kind: "added", Type: "func", Name: "F"}, false},
+		{"added method", DiffEntry{Kind: "added", Type: "method", Name: "T.M"}, false},
+		{"changed signature", DiffEntry{Kind: "changed", Type: "method", Name: "T.M", OldDecl: "func (t *T) M()", NewDecl: "func (t *T) M(x int)"}, true},
+		{
+			"struct gains field",
+			DiffEntry{Kind: "changed", Type: "type", Name: "T",
+				OldDecl: "type T struct {\n\tA int\n\t// contains filtered or unexported fields\n}",
+				NewDecl: "type T struct {\n\tA int\n\tB string\n}"},
+			false,
+		},
+		{
+			"struct field type changed",
+			DiffEntry{Kind: "changed", Type: "type", Name: "T",
+				OldDecl: "type T struct {\n\tA int\n}",
+				NewDecl: "type T struct {\n\tA int64\n}"},
+			true,
+		},
+		{
+			"struct field removed",
+			DiffEntry{Kind: "changed", Type: "type", Name: "T",
+				OldDecl: "type T struct {\n\tA int\n\tio.Reader\n}",
+				NewDecl: "type T struct {\n\tA int\n}"},
+			true,
+		},
+		{
+			"interface gains method",
+			DiffEntry{Kind: "changed", Type: "type", Name: "I",
+				OldDecl: "type I interface {\n\tM()\n}",
+				NewDecl: "type I interface {\n\tM()\n\tN()\n}"},
+			true,
+		},
+		{
+			"const value changed",
+			DiffEntry{Kind: "changed", Type: "const", Name: "B",
+				OldDecl: "const (\n\tA = 1\n\tB = 2\n)",
+				NewDecl: "const (\n\tA = 1\n\tB = 3\n)"},
+			false,
+		},
+		{
+			"const type changed",
+			DiffEntry{Kind: "changed", Type: "const", Name: "A",
+				OldDecl: "const A int = 1",
+				NewDecl: "const A int64 = 1"},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBreakingChange(tt.entry); got != tt.want {
+				t.Errorf("isBreakingChange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerdict(t *testing.T) {
+	removed := DiffEntry{Kind: "removed", Type: "func", Name: "F", Breaking: true}
+	added := DiffEntry{Kind: "added", Type: "func", Name: "G"}
+	tests := []struct {
+		name        string
+		diff        []DiffEntry
+		fromVersion string
+		wantBump    string
+	}{
+		{"removed exported func", []DiffEntry{removed, added}, "v1.4.0", "major"},
+		{"breaking in v0", []DiffEntry{removed}, "v0.3.1", "minor"},
+		{"additions only", []DiffEntry{added}, "v1.4.0", "minor"},
+		{"no changes", nil, "v1.4.0", "patch"},
+		{"informational entries", []DiffEntry{{Kind: "unchanged", Type: "func", Name: "F"}}, "v1.4.0", "patch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := Verdict(tt.diff, tt.fromVersion)
+			if v.Bump != tt.wantBump {
+				t.Errorf("Verdict().Bump = %q, want %q", v.Bump, tt.wantBump)
+			}
+			if v.Breaking != (tt.diff != nil && tt.diff[0].Breaking) {
+				t.Errorf("Verdict().Breaking = %v", v.Breaking)
+			}
+		})
+	}
+}
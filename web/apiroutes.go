@@ -0,0 +1,182 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"strings"
+)
+
+// apiRoute describes a JSON endpoint, both for registering it and for the /api landing page
+type apiRoute struct {
+	Method      string
+	Path        string // documented path, with {placeholders}
+	Description string
+	Example     string // example request path and query
+	Body        string // example JSON body for POST endpoints
+	AI          bool   // needs MISTRAL_API_KEY
+
+	pattern string           // mux pattern; empty when served by another route's handler
+	handler http.HandlerFunc // wrapped in the API rate limiter
+}
+
+// apiRoutes lists every JSON endpoint in the order shown on the /api page
+func (s *Server) apiRoutes() []apiRoute {
+	return []apiRoute{
+		{
+			Method: http.MethodGet, Path: "/api/packages",
+			Description: "List the Go packages loaded from disk or indexed in the database, by import path: all of them, or a page given limit (default 100, max 1000) or offset.",
+			Example:     "/api/packages?limit=20&offset=40",
+		},
+		{
+			Method: http.MethodGet, Path: "/api/search?q={query}",
+			Description: "Search packages across ecosystems, ranked together by relevance and popularity within each ecosystem. Optional: lang=go|rust|js|python|php, limit (default 50, max 200), sort=size, mode=semantic. With Accept: application/x-ndjson, results stream one per line, unranked, with limit up to 1000000 (default: all matches).",
+			Example:     "/api/search?q=http&lang=go",
+		},
+		{
+			Method: http.MethodGet, Path: "/api/{import-path}",
+			Description: "Documentation of a package as JSON. Package pages return the same with Accept: application/json. Optional: fields=name,synopsis,... to select top-level fields.",
+			Example:     "/api/fmt?fields=name,synopsis,imports",
+			pattern:     "/api/", handler: s.handleAPI,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/{import-path}/symbols?section={section}",
+			Description: "Constants, variables, functions or types of a package page section, rendered as HTML inside JSON; package pages load the symbols beyond their per-section cap from here. Optional: offset, limit.",
+			Example:     "/api/fmt/symbols?section=functions&offset=10",
+		},
+		{
+			Method: http.MethodGet, Path: "/api/source/{import-path}/{symbol}",
+			Description: "Source text of a function, type, or Type.Method, or of a whole file when the last element is a file name.",
+			Example:     "/api/source/strings/Builder.Len",
+			pattern:     "/api/source/", handler: s.handleSource,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/aidocs/{import-path}",
+			Description: "AI doc status of each symbol of a package (missing, pending, approved, flagged or orphaned) with token and cost totals. Needs a database.",
+			Example:     "/api/aidocs/example.com/strutil",
+			pattern:     "/api/aidocs/", handler: s.handleAIDocsAPI,
+		},
+		{
+			Method: http.MethodPost, Path: "/graphql",
+			Description: "Query packages, symbols, versions and imports, selecting only the fields you need. GET /graphql returns the schema.",
+			Example:     "/graphql",
+			Body:        `{"query": "{ package(path: \"net/http\") { name synopsis importedByCount } }"}`,
+			pattern:     "/graphql", handler: s.handleGraphQL,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/semantic-search?q={query}",
+			Description: "Search packages by meaning using embeddings. Optional: lang, limit.",
+			Example:     "/api/semantic-search?q=parse+json&limit=5",
+			AI:          true,
+			pattern:     "/api/semantic-search", handler: s.handleSemanticSearch,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/understand-query?q={query}",
+			Description: "Interpret a natural-language search query.",
+			Example:     "/api/understand-query?q=read+a+file+line+by+line",
+			AI:          true,
+			pattern:     "/api/understand-query", handler: s.handleUnderstandQuery,
+		},
+		{
+			Method: http.MethodPost, Path: "/api/explain",
+			Description: "Explain a code snippet.",
+			Example:     "/api/explain",
+			Body:        `{"code": "func Add(a, b int) int { return a + b }"}`,
+			AI:          true,
+			pattern:     "/api/explain", handler: s.handleExplain,
+		},
+		{
+			Method: http.MethodPost, Path: "/api/enhance-doc",
+			Description: "Suggest documentation for a symbol.",
+			Example:     "/api/enhance-doc",
+			Body:        `{"name": "Reverse", "type": "function", "signature": "func Reverse(s string) string"}`,
+			AI:          true,
+			pattern:     "/api/enhance-doc", handler: s.handleEnhanceDoc,
+		},
+		{
+			Method: http.MethodPost, Path: "/api/generate-example",
+			Description: "Generate a usage example for a function.",
+			Example:     "/api/generate-example",
+			Body:        `{"function_name": "Reverse", "signature": "func Reverse(s string) string", "import_path": "example.com/strutil"}`,
+			AI:          true,
+			pattern:     "/api/generate-example", handler: s.handleGenerateExample,
+		},
+		{
+			Method: http.MethodPost, Path: "/api/translate",
+			Description: "Translate documentation text.",
+			Example:     "/api/translate",
+			Body:        `{"text": "Reverse returns s reversed.", "language": "fr"}`,
+			AI:          true,
+			pattern:     "/api/translate", handler: s.handleTranslate,
+		},
+		{
+			Method: http.MethodPost, Path: "/api/validate",
+			Description: "Check generated content for hallucinated symbols and imports.",
+			Example:     "/api/validate",
+			Body:        `{"content": "strings.Reverse(s)", "expected_symbols": ["Reverse"], "is_go_code": true}`,
+			pattern:     "/api/validate", handler: s.handleValidate,
+		},
+		{
+			Method: http.MethodPost, Path: "/api/license-summary",
+			Description: "Summarize a license text in plain language.",
+			Example:     "/api/license-summary",
+			Body:        `{"license_text": "Permission is hereby granted, free of charge, ..."}`,
+			AI:          true,
+			pattern:     "/api/license-summary", handler: s.handleLicenseSummary,
+		},
+	}
+}
+
+// Curl returns a copyable curl command for the route's example request
+func (rt apiRoute) Curl(baseURL string) string {
+	url := "'" + baseURL + rt.Example + "'"
+	if rt.Method == http.MethodPost {
+		return "curl -X POST -H 'Content-Type: application/json' -d '" + rt.Body + "' " + url
+	}
+	return "curl " + url
+}
+
+// requestBaseURL returns the scheme and host the request was made to
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// renderAPIIndex renders the /api landing page listing every JSON endpoint
+func (s *Server) renderAPIIndex(w http.ResponseWriter, r *http.Request) {
+	type endpoint struct {
+		apiRoute
+		Command string
+	}
+	baseURL := requestBaseURL(r)
+	var endpoints []endpoint
+	for _, rt := range s.apiRoutes() {
+		endpoints = append(endpoints, endpoint{rt, rt.Curl(baseURL)})
+	}
+
+	data := struct {
+		Title       string
+		SearchQuery string
+		Pkg         *PackageDoc
+		Endpoints   []endpoint
+		AIEnabled   bool
+	}{
+		Title:     "JSON API - Go Packages",
+		Pkg:       nil,
+		Endpoints: endpoints,
+		AIEnabled: s.aiService != nil,
+	}
+
+	if err := s.templates.ExecuteTemplate(w, "api.html", data); err != nil {
+		log.Printf("Error rendering API index: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// wantsAPIIndex reports whether a request for /api/ comes from a browser rather
+// than an API client expecting the package list
+func wantsAPIIndex(r *http.Request) bool {
+	return !prefersJSON(r) && strings.Contains(r.Header.Get("Accept"), "text/html")
+}
@@ -0,0 +1,64 @@
+package web
+
+import (
+	"strings"
+
+	"github.com/alexisbouchez/wikigo/util"
+)
+
+// attachConstructors lists each function once: package-level functions that
+// are already listed under a type are dropped, and those constructing a type
+// of the package are moved under it, as go/doc does. Packages rebuilt from the
+// symbols table have every function at package level.
+func attachConstructors(pkg *PackageDoc) {
+	types := make(map[string]int, len(pkg.Types))
+	seen := make(map[string]bool)
+	for i, t := range pkg.Types {
+		types[t.Name] = i
+		for _, fn := range t.Functions {
+			seen[fn.Name] = true
+		}
+	}
+
+	var standalone []Function
+	for _, fn := range pkg.Functions {
+		if seen[fn.Name] {
+			continue
+		}
+		seen[fn.Name] = true
+		if i, ok := types[constructedType(fn.Signature, types)]; ok {
+			pkg.Types[i].Functions = append(pkg.Types[i].Functions, fn)
+			continue
+		}
+		standalone = append(standalone, fn)
+	}
+	pkg.Functions = standalone
+}
+
+// constructedType returns the type of the package a function signature
+// constructs: the only one of types among its results, possibly as a pointer,
+// slice or array, or "" when there is none or more than one
+func constructedType(sig string, types map[string]int) string {
+	shape, ok := util.SignatureShape(sig)
+	if !ok {
+		return ""
+	}
+	var constructed string
+	for _, result := range shape.Results {
+		if strings.HasPrefix(result, "[") {
+			result = result[strings.Index(result, "]")+1:]
+		}
+		result = strings.TrimPrefix(result, "*")
+		if i := strings.Index(result, "["); i > 0 {
+			result = result[:i] // instantiated generic type
+		}
+		if _, ok := types[result]; !ok {
+			continue
+		}
+		if constructed != "" {
+			return ""
+		}
+		constructed = result
+	}
+	return constructed
+}
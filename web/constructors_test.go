@@ -0,0 +1,100 @@
+package web
+
+import (
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAttachConstructors(t *testing.T) {
+	pkg := &PackageDoc{
+		Functions: []Function{
+			{Name: "NewBuffer", Signature: "func NewBuffer(buf []byte) *Buffer"},
+			{Name: "NewBufferString", Signature: "func NewBufferString(s string) *Buffer"},
+			{Name: "Split", Signature: "func Split(s, sep []byte) [][]byte"},
+			{Name: "NewReaders", Signature: "func NewReaders(n int) []*Reader"},
+			{Name: "Pair", Signature: "func Pair() (*Buffer, *Reader)"},
+			{Name: "Split", Signature: "func Split(s, sep []byte) [][]byte"},
+		},
+		Types: []Type{
+			{Name: "Buffer", Functions: []Function{{Name: "NewBuffer", Signature: "func NewBuffer(buf []byte) *Buffer"}}},
+			{Name: "Reader"},
+		},
+	}
+	attachConstructors(pkg)
+
+	names := func(funcs []Function) []string {
+		var names []string
+		for _, fn := range funcs {
+			names = append(names, fn.Name)
+		}
+		return names
+	}
+	if got, want := names(pkg.Functions), []string{"Split", "Pair"}; !slices.Equal(got, want) {
+		t.Errorf("package functions = %v, want %v", got, want)
+	}
+	if got, want := names(pkg.Types[0].Functions), []string{"NewBuffer", "NewBufferString"}; !slices.Equal(got, want) {
+		t.Errorf("Buffer functions = %v, want %v", got, want)
+	}
+	if got, want := names(pkg.Types[1].Functions), []string{"NewReaders"}; !slices.Equal(got, want) {
+		t.Errorf("Reader functions = %v, want %v", got, want)
+	}
+}
+
+func TestHandler_SymbolsListConstructorsOnce(t *testing.T) {
+	bytesPkg := func() *PackageDoc {
+		return &PackageDoc{
+			ImportPath: "bytes",
+			Name:       "bytes",
+			Functions:  []Function{{Name: "NewBuffer", Doc: "NewBuffer creates a Buffer.", Signature: "func NewBuffer(buf []byte) *Buffer"}},
+			Types: []Type{{
+				Name:      "Buffer",
+				Decl:      "type Buffer struct{}",
+				Functions: []Function{{Name: "NewBuffer", Doc: "NewBuffer creates a Buffer.", Signature: "func NewBuffer(buf []byte) *Buffer"}},
+			}},
+		}
+	}
+
+	s, err := NewServerWithDB(t.TempDir(), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+	if err := s.IndexPackage(bytesPkg()); err != nil {
+		t.Fatalf("IndexPackage() error = %v", err)
+	}
+	dbHandler, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+
+	memServer, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	memServer.packages["bytes"] = bytesPkg()
+	attachConstructors(memServer.packages["bytes"])
+	memHandler, err := memServer.Handler()
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+
+	for name, handler := range map[string]http.Handler{"database": dbHandler, "memory": memHandler} {
+		w := serve(handler, "/symbols?q=NewBuffer")
+		if got := strings.Count(w.Body.String(), `class="SymbolResult"`); got != 1 {
+			t.Errorf("%s: NewBuffer is listed %d times, want once", name, got)
+		}
+	}
+
+	// Rebuilt from the symbols table, the constructor is listed under its type
+	dbPkg, err := s.db.GetPackage("bytes")
+	if err != nil || dbPkg == nil {
+		t.Fatalf("GetPackage() = %v, %v", dbPkg, err)
+	}
+	pkg := s.dbPackageToDoc(dbPkg)
+	if len(pkg.Functions) != 0 || len(pkg.Types) != 1 || len(pkg.Types[0].Functions) != 1 {
+		t.Errorf("rebuilt package functions = %+v, types = %+v", pkg.Functions, pkg.Types)
+	}
+}
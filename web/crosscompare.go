@@ -0,0 +1,188 @@
+package web
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// apiSymbol is a symbol reduced to what can be compared across languages
+type apiSymbol struct {
+	Kind string // "func", "type" or "value"
+	Name string // as declared, e.g. Client.Get or get_user
+	Decl string
+}
+
+// foreignPrefixes are the path prefixes search results use for other ecosystems
+var foreignPrefixes = []string{"crates.io/", "npm/", "pypi/", "packagist/"}
+
+// isForeignPath reports whether a compare target names a non-Go package
+func isForeignPath(path string) bool {
+	for _, prefix := range foreignPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// coarseKind folds the symbol kinds of every parser into func, type or value
+func coarseKind(kind string) string {
+	switch kind {
+	case "func", "function", "method", "macro":
+		return "func"
+	case "type", "struct", "enum", "trait", "class", "interface":
+		return "type"
+	case "const", "constant", "static", "var":
+		return "value"
+	}
+	return ""
+}
+
+// goSurface lists the exported API of a Go package
+func goSurface(pkg *PackageDoc) []apiSymbol {
+	var surface []apiSymbol
+	for _, c := range pkg.Constants {
+		for _, name := range c.Names {
+			surface = append(surface, apiSymbol{Kind: "value", Name: name})
+		}
+	}
+	for _, v := range pkg.Variables {
+		for _, name := range v.Names {
+			surface = append(surface, apiSymbol{Kind: "value", Name: name})
+		}
+	}
+	for _, f := range pkg.Functions {
+		surface = append(surface, apiSymbol{Kind: "func", Name: f.Name, Decl: f.Signature})
+	}
+	for _, t := range pkg.Types {
+		surface = append(surface, apiSymbol{Kind: "type", Name: t.Name, Decl: t.Decl})
+		for _, f := range t.Functions {
+			surface = append(surface, apiSymbol{Kind: "func", Name: f.Name, Decl: f.Signature})
+		}
+		for _, m := range t.Methods {
+			surface = append(surface, apiSymbol{Kind: "func", Name: t.Name + "." + m.Name, Decl: m.Signature})
+		}
+	}
+	return surface
+}
+
+// foreignPackage loads the public API of a crates.io/, npm/, pypi/ or packagist/
+// package from the database, with a PackageDoc holding just its summary
+func (s *Server) foreignPackage(path string) (*PackageDoc, []apiSymbol, bool) {
+	if s.db == nil {
+		return nil, nil, false
+	}
+	ecosystem, name, _ := strings.Cut(path, "/")
+	pkg := &PackageDoc{ImportPath: path, Name: name}
+
+	var surface []apiSymbol
+	add := func(kind, name, signature string, public bool) {
+		if k := coarseKind(kind); public && k != "" {
+			surface = append(surface, apiSymbol{Kind: k, Name: name, Decl: signature})
+		}
+	}
+
+	switch ecosystem {
+	case "crates.io":
+		crate, err := s.db.GetRustCrate(name)
+		if err != nil || crate == nil {
+			return nil, nil, false
+		}
+		pkg.Synopsis, pkg.Version = crate.Description, crate.Version
+		symbols, _ := s.db.GetRustCrateSymbols(crate.ID)
+		for _, sym := range symbols {
+			add(sym.Kind, sym.Name, sym.Signature, sym.Public)
+		}
+	case "npm":
+		js, err := s.db.GetJSPackage(name)
+		if err != nil || js == nil {
+			return nil, nil, false
+		}
+		pkg.Synopsis, pkg.Version = js.Description, js.Version
+		symbols, _ := s.db.GetJSPackageSymbols(js.ID)
+		for _, sym := range symbols {
+			add(sym.Kind, sym.Name, sym.Signature, sym.Exported)
+		}
+	case "pypi":
+		py, err := s.db.GetPythonPackage(name)
+		if err != nil || py == nil {
+			return nil, nil, false
+		}
+		pkg.Synopsis, pkg.Version = py.Summary, py.Version
+		symbols, _ := s.db.GetPythonPackageSymbols(py.ID)
+		for _, sym := range symbols {
+			add(sym.Kind, sym.Name, sym.Signature, sym.Public)
+		}
+	case "packagist":
+		php, err := s.db.GetPHPPackage(name)
+		if err != nil || php == nil {
+			return nil, nil, false
+		}
+		pkg.Synopsis, pkg.Version = php.Description, php.Version
+		symbols, _ := s.db.GetPHPPackageSymbols(php.ID)
+		for _, sym := range symbols {
+			add(sym.Kind, sym.Name, sym.Signature, sym.Public)
+		}
+	default:
+		return nil, nil, false
+	}
+	return pkg, surface, true
+}
+
+// surfaceKey identifies a symbol across languages: its coarse kind and its name
+// without receiver, case or underscores, so Client.GetUser matches get_user
+func surfaceKey(sym apiSymbol) string {
+	name := sym.Name
+	if i := strings.LastIndexAny(name, ".:"); i >= 0 {
+		name = name[i+1:]
+	}
+	return sym.Kind + ":" + strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+// compareSurfaces matches two APIs by kind and name only, since signatures are not
+// comparable across languages. Shared symbols come first, then those only on the
+// left, then those only on the right.
+func compareSurfaces(left, right []apiSymbol) (diff []DiffEntry, similarity int) {
+	index := func(surface []apiSymbol) (map[string]apiSymbol, []string) {
+		byKey := make(map[string]apiSymbol)
+		var keys []string
+		for _, sym := range surface {
+			key := surfaceKey(sym)
+			if _, ok := byKey[key]; !ok {
+				byKey[key] = sym
+				keys = append(keys, key)
+			}
+		}
+		return byKey, keys
+	}
+	leftByKey, leftKeys := index(left)
+	rightByKey, rightKeys := index(right)
+
+	shared := 0
+	for _, key := range leftKeys {
+		l := leftByKey[key]
+		if r, ok := rightByKey[key]; ok {
+			shared++
+			diff = append(diff, DiffEntry{Kind: "shared", Type: l.Kind, Name: l.Name + " / " + r.Name, OldDecl: cmp.Or(l.Decl, l.Name), NewDecl: cmp.Or(r.Decl, r.Name)})
+		} else {
+			diff = append(diff, DiffEntry{Kind: "only-left", Type: l.Kind, Name: l.Name, OldDecl: l.Decl})
+		}
+	}
+	for _, key := range rightKeys {
+		if _, ok := leftByKey[key]; !ok {
+			r := rightByKey[key]
+			diff = append(diff, DiffEntry{Kind: "only-right", Type: r.Kind, Name: r.Name, NewDecl: r.Decl})
+		}
+	}
+
+	order := map[string]int{"shared": 0, "only-left": 1, "only-right": 2}
+	slices.SortStableFunc(diff, func(a, b DiffEntry) int {
+		return cmp.Or(cmp.Compare(order[a.Kind], order[b.Kind]), cmp.Compare(a.Type, b.Type), strings.Compare(a.Name, b.Name))
+	})
+
+	if total := len(leftKeys) + len(rightKeys) - shared; total > 0 {
+		similarity = shared * 100 / total
+	}
+	return diff, similarity
+}
@@ -0,0 +1,82 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/alexisbouchez/wikigo/db"
+)
+
+func TestCompareSurfaces(t *testing.T) {
+	goAPI := []apiSymbol{
+		{Kind: "type", Name: "Client", Decl: "type Client struct{}"},
+		{Kind: "func", Name: "Client.GetUser", Decl: "func (c *Client) GetUser(id int) (*User, error)"},
+		{Kind: "func", Name: "Client.Close", Decl: "func (c *Client) Close() error"},
+		{Kind: "value", Name: "DefaultTimeout"},
+	}
+	rustAPI := []apiSymbol{
+		{Kind: "type", Name: "Client", Decl: "pub struct Client"},
+		{Kind: "func", Name: "get_user", Decl: "pub fn get_user(&self, id: u64) -> Result<User>"},
+		{Kind: "func", Name: "client", Decl: "pub fn client() -> Client"}, // a function, not the type
+	}
+
+	diff, similarity := compareSurfaces(goAPI, rustAPI)
+
+	var got []string
+	for _, d := range diff {
+		got = append(got, d.Kind+" "+d.Type+" "+d.Name)
+	}
+	want := []string{
+		"shared func Client.GetUser / get_user",
+		"shared type Client / Client",
+		"only-left func Client.Close",
+		"only-left value DefaultTimeout",
+		"only-right func client",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("compareSurfaces() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if similarity != 40 {
+		t.Errorf("similarity = %d, want 40", similarity)
+	}
+}
+
+func TestHandler_CompareAcrossEcosystems(t *testing.T) {
+	s, handler := seededServer(t)
+
+	crateID, err := s.db.UpsertRustCrate(&db.RustCrate{Name: "widgets", Version: "0.2.0", Description: "Widgets for Rust."})
+	if err != nil {
+		t.Fatalf("UpsertRustCrate() error = %v", err)
+	}
+	for _, sym := range []*db.RustSymbol{
+		{Name: "Widget", Kind: "struct", Signature: "pub struct Widget", Public: true},
+		{Name: "new_widget", Kind: "function", Signature: "pub fn new_widget() -> Widget", Public: true},
+		{Name: "spin", Kind: "function", Signature: "pub fn spin(&mut self)", Public: true},
+		{Name: "helper", Kind: "function", Signature: "fn helper()"},
+	} {
+		sym.CrateID, sym.CrateName = crateID, "widgets"
+		if err := s.db.UpsertRustSymbol(sym); err != nil {
+			t.Fatalf("UpsertRustSymbol() error = %v", err)
+		}
+	}
+
+	w := serve(handler, "/compare/?pkg1=example.com/mem/widgets&pkg2=crates.io/widgets")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{"Widgets for Rust.", "NewWidget / new_widget", "Widget / Widget", "66%", `<span class="DiffEntry-name">spin</span>`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("compare page does not contain %q", want)
+		}
+	}
+	if strings.Contains(body, "helper") {
+		t.Error("compare page lists a private Rust function")
+	}
+
+	w = serve(handler, "/compare/?pkg1=example.com/mem/widgets&pkg2=crates.io/missing")
+	if !strings.Contains(w.Body.String(), `Package "crates.io/missing" not found.`) {
+		t.Errorf("missing crate not reported: %s", w.Body.String())
+	}
+}
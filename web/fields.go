@@ -0,0 +1,129 @@
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// packageJSONFields maps the JSON names of PackageDoc fields to their index
+var packageJSONFields = func() map[string]int {
+	fields := make(map[string]int)
+	t := reflect.TypeFor[PackageDoc]()
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	return fields
+}()
+
+// writePackageJSON writes a package as JSON, or a JSON 404 if it was not found.
+// ?fields=name,synopsis selects top-level fields, in that order; otherwise a slim
+// server leaves out the license text, go.mod and embedded sources, which can dwarf
+// the rest of the package and stay available on /license/, /mod/ and /api/source.
+// The response carries an ETag of its body and the time the package was indexed
+// as Last-Modified, so clients polling a package get a 304 until it is reindexed.
+func (s *Server) writePackageJSON(w http.ResponseWriter, r *http.Request, pkg *PackageDoc, found bool) {
+	w.Header().Set("Content-Type", "application/json")
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "package not found"})
+		return
+	}
+
+	if fields := r.URL.Query().Get("fields"); fields != "" {
+		obj, err := selectPackageFields(pkg, fields)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		writeConditionalJSON(w, r, obj, pkg.IndexedAt)
+		return
+	}
+
+	if s.slimJSON {
+		slim := *pkg
+		slim.LicenseText, slim.GoModContent, slim.Sources = "", "", nil
+		pkg = &slim
+	}
+	writeConditionalJSON(w, r, pkg, pkg.IndexedAt)
+}
+
+// writeConditionalJSON writes v as JSON with an ETag of the encoding and a
+// Last-Modified of modTime unless it is zero, answering 304 Not Modified when
+// the request's If-None-Match or If-Modified-Since show the client has it.
+// The whole document is always written: Range requests get all of it.
+func writeConditionalJSON(w http.ResponseWriter, r *http.Request, v any, modTime time.Time) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	h := w.Header()
+	h.Set("ETag", etag)
+	if !modTime.IsZero() {
+		h.Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	}
+	if notModified(r, etag, modTime) {
+		h.Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.Write(buf.Bytes())
+}
+
+// notModified reports whether a GET or HEAD request's If-None-Match lists etag,
+// or, without If-None-Match, its If-Modified-Since is no earlier than modTime
+func notModified(r *http.Request, etag string, modTime time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == etag || tag == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	if modTime.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !modTime.Truncate(time.Second).After(since)
+}
+
+// selectPackageFields returns the comma-separated JSON fields of a package, empty
+// ones included
+func selectPackageFields(pkg *PackageDoc, fields string) (gqlObject, error) {
+	v := reflect.ValueOf(pkg).Elem()
+	var obj gqlObject
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(fields, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		i, ok := packageJSONFields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		seen[name] = true
+		obj = append(obj, gqlEntry{Key: name, Value: v.Field(i).Interface()})
+	}
+	return obj, nil
+}
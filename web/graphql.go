@@ -0,0 +1,814 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// graphQLSchema documents the read-only schema served at /graphql
+const graphQLSchema = `type Query {
+  package(path: String!): Package
+  packages(query: String!, limit: Int = 20): [Package!]!
+  symbols(query: String!, kind: String, limit: Int = 20): [Symbol!]!
+}
+
+type Package {
+  importPath: String!
+  name: String!
+  synopsis: String
+  doc: String
+  version: String
+  license: String
+  goVersion: String
+  modulePath: String
+  repository: String
+  imports: [String!]!
+  importedByCount: Int!
+  importedBy(limit: Int = 20): [Package!]!
+  symbols(kind: String): [Symbol!]!
+  versions: [Version!]!
+}
+
+type Symbol {
+  name: String!
+  kind: String!
+  package: String!
+  importPath: String!
+  synopsis: String
+  deprecated: Boolean!
+}
+
+type Version {
+  version: String!
+  isTagged: Boolean!
+  isStable: Boolean!
+  retracted: Boolean!
+  publishedAt: String
+}
+`
+
+const (
+	// graphQLDefaultLimit is the length of list fields queried without a limit
+	graphQLDefaultLimit = 20
+	// graphQLMaxLimit caps the limit argument of list fields
+	graphQLMaxLimit = 100
+	// graphQLMaxDepth caps how deeply selection sets nest
+	graphQLMaxDepth = 5
+	// graphQLMaxNodes caps the objects a query may resolve, counting each
+	// list field as long as its limit allows
+	graphQLMaxNodes = 1000
+)
+
+// gqlField is one field of a parsed selection set
+type gqlField struct {
+	Alias      string // response key; the field name unless aliased
+	Name       string
+	Args       map[string]any
+	Selections []*gqlField
+}
+
+// gqlObject is a response object that keeps fields in query order
+type gqlObject []gqlEntry
+
+type gqlEntry struct {
+	Key   string
+	Value any
+}
+
+// MarshalJSON writes the object's fields in the order they were selected
+func (o gqlObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, e := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(e.Key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := json.Marshal(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// gqlParser parses the subset of GraphQL the endpoint supports: a single query
+// with selection sets, aliases, arguments and variables. Fragments, directives
+// and mutations are rejected.
+type gqlParser struct {
+	src  string
+	pos  int
+	vars map[string]any
+}
+
+// parseGraphQL parses a query document into the selections of its root Query
+func parseGraphQL(query string, variables map[string]any) ([]*gqlField, error) {
+	vars := make(map[string]any, len(variables))
+	for k, v := range variables {
+		vars[k] = v
+	}
+	p := &gqlParser{src: query, vars: vars}
+
+	p.skip()
+	if p.peek() != '{' {
+		switch op := p.name(); op {
+		case "query":
+		case "mutation", "subscription":
+			return nil, fmt.Errorf("%s operations are not supported; the API is read-only", op)
+		default:
+			return nil, p.errorf("expected a query")
+		}
+		p.skip()
+		if p.peek() != '(' && p.peek() != '{' {
+			p.name() // operation name
+			p.skip()
+		}
+		if p.peek() == '(' {
+			if err := p.variableDefinitions(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	fields, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	p.skip()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("only a single operation is supported")
+	}
+	return fields, nil
+}
+
+func (p *gqlParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("syntax error at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// skip advances past whitespace, commas and comments, which GraphQL ignores
+func (p *gqlParser) skip() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *gqlParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *gqlParser) expect(c byte) error {
+	p.skip()
+	if p.peek() != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+// name reads a GraphQL name, returning "" if there is none at the cursor
+func (p *gqlParser) name() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (p.pos > start && c >= '0' && c <= '9') {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+// variableDefinitions reads ($name: Type = default, ...), applying defaults
+// for variables the request did not provide
+func (p *gqlParser) variableDefinitions() error {
+	p.pos++ // (
+	for {
+		p.skip()
+		if p.peek() == ')' {
+			p.pos++
+			return nil
+		}
+		if err := p.expect('$'); err != nil {
+			return err
+		}
+		name := p.name()
+		if name == "" {
+			return p.errorf("expected a variable name")
+		}
+		if err := p.expect(':'); err != nil {
+			return err
+		}
+		// Types are not checked; skip names, brackets and !
+		for {
+			p.skip()
+			if c := p.peek(); c == '[' || c == ']' || c == '!' {
+				p.pos++
+			} else if p.name() == "" {
+				break
+			}
+		}
+		if p.peek() == '=' {
+			p.pos++
+			def, err := p.value()
+			if err != nil {
+				return err
+			}
+			if _, ok := p.vars[name]; !ok {
+				p.vars[name] = def
+			}
+		}
+	}
+}
+
+func (p *gqlParser) selectionSet() ([]*gqlField, error) {
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+	var fields []*gqlField
+	for {
+		p.skip()
+		switch {
+		case p.peek() == '}':
+			p.pos++
+			if len(fields) == 0 {
+				return nil, p.errorf("empty selection set")
+			}
+			return fields, nil
+		case strings.HasPrefix(p.src[p.pos:], "..."):
+			return nil, p.errorf("fragments are not supported")
+		case p.peek() == 0:
+			return nil, p.errorf("unterminated selection set")
+		}
+		f, err := p.field()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+}
+
+func (p *gqlParser) field() (*gqlField, error) {
+	name := p.name()
+	if name == "" {
+		return nil, p.errorf("expected a field name")
+	}
+	f := &gqlField{Alias: name, Name: name}
+
+	p.skip()
+	if p.peek() == ':' {
+		p.pos++
+		p.skip()
+		if f.Name = p.name(); f.Name == "" {
+			return nil, p.errorf("expected a field name after alias %q", name)
+		}
+		p.skip()
+	}
+
+	if p.peek() == '(' {
+		p.pos++
+		f.Args = make(map[string]any)
+		for {
+			p.skip()
+			if p.peek() == ')' {
+				p.pos++
+				break
+			}
+			arg := p.name()
+			if arg == "" {
+				return nil, p.errorf("expected an argument name")
+			}
+			if err := p.expect(':'); err != nil {
+				return nil, err
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			f.Args[arg] = v
+		}
+		p.skip()
+	}
+
+	if p.peek() == '@' {
+		return nil, p.errorf("directives are not supported")
+	}
+	if p.peek() == '{' {
+		sel, err := p.selectionSet()
+		if err != nil {
+			return nil, err
+		}
+		f.Selections = sel
+	}
+	return f, nil
+}
+
+// value reads an argument value; enums are returned as strings
+func (p *gqlParser) value() (any, error) {
+	p.skip()
+	switch c := p.peek(); {
+	case c == '$':
+		p.pos++
+		name := p.name()
+		v, ok := p.vars[name]
+		if !ok {
+			return nil, fmt.Errorf("variable $%s is not defined", name)
+		}
+		return v, nil
+	case c == '"':
+		end := p.pos + 1
+		for end < len(p.src) && p.src[end] != '"' {
+			if p.src[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(p.src) {
+			return nil, p.errorf("unterminated string")
+		}
+		var s string
+		if err := json.Unmarshal([]byte(p.src[p.pos:end+1]), &s); err != nil {
+			return nil, p.errorf("invalid string: %v", err)
+		}
+		p.pos = end + 1
+		return s, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.src) && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+		if n, err := strconv.Atoi(p.src[start:p.pos]); err == nil {
+			return n, nil
+		}
+		f, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", p.src[start:p.pos])
+		}
+		return f, nil
+	case c == '[':
+		p.pos++
+		var list []any
+		for {
+			p.skip()
+			if p.peek() == ']' {
+				p.pos++
+				return list, nil
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+	case c == '{':
+		return nil, p.errorf("input objects are not supported")
+	}
+
+	switch name := p.name(); name {
+	case "":
+		return nil, p.errorf("expected a value")
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	default:
+		return name, nil
+	}
+}
+
+// gqlString returns a string argument, or an error if a required one is missing
+func gqlString(f *gqlField, name string, required bool) (string, error) {
+	v, ok := f.Args[name]
+	if !ok || v == nil {
+		if required {
+			return "", fmt.Errorf("field %q requires argument %q", f.Name, name)
+		}
+		return "", nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("argument %q of field %q must be a String", name, f.Name)
+	}
+	return s, nil
+}
+
+// gqlLimit returns the limit argument clamped to 1..graphQLMaxLimit
+func gqlLimit(f *gqlField, def int) (int, error) {
+	limit := def
+	switch v := f.Args["limit"].(type) {
+	case nil:
+	case int:
+		limit = v
+	case float64: // JSON variables decode as float64
+		limit = int(v)
+	default:
+		return 0, fmt.Errorf("argument \"limit\" of field %q must be an Int", f.Name)
+	}
+	return min(max(limit, 1), graphQLMaxLimit), nil
+}
+
+// checkGraphQLCost rejects queries nested deeper than graphQLMaxDepth or that
+// could resolve more than graphQLMaxNodes objects, before any is resolved
+func checkGraphQLCost(fields []*gqlField) error {
+	nodes, depth, err := gqlCost(fields, true)
+	if err != nil {
+		return err
+	}
+	if depth > graphQLMaxDepth {
+		return fmt.Errorf("query nests %d levels deep; the maximum is %d", depth, graphQLMaxDepth)
+	}
+	if nodes > graphQLMaxNodes {
+		return fmt.Errorf("query could resolve more than %d objects; lower its limits or nesting", graphQLMaxNodes)
+	}
+	return nil
+}
+
+// gqlCost returns how many objects a selection set may resolve and how deeply
+// it nests. Lists taking a limit count that many items; the other lists come
+// from an object already loaded and count once. The count saturates just above
+// graphQLMaxNodes so deep nesting cannot overflow it.
+func gqlCost(sel []*gqlField, root bool) (nodes, depth int, err error) {
+	for _, f := range sel {
+		if f.Selections == nil {
+			continue
+		}
+		items := 1
+		if f.Name == "importedBy" || root && (f.Name == "packages" || f.Name == "symbols") {
+			if items, err = gqlLimit(f, graphQLDefaultLimit); err != nil {
+				return 0, 0, err
+			}
+		}
+		n, d, err := gqlCost(f.Selections, false)
+		if err != nil {
+			return 0, 0, err
+		}
+		nodes = min(nodes+items*(1+n), graphQLMaxNodes+1)
+		depth = max(depth, d+1)
+	}
+	return nodes, depth, nil
+}
+
+// gqlResolve builds an object from a selection set, resolving each field with fn
+func gqlResolve(typeName string, sel []*gqlField, fn func(f *gqlField) (any, error)) (gqlObject, error) {
+	obj := make(gqlObject, 0, len(sel))
+	for _, f := range sel {
+		var v any
+		var err error
+		if f.Name == "__typename" {
+			v = typeName
+		} else {
+			v, err = fn(f)
+		}
+		if err != nil {
+			return nil, err
+		}
+		obj = append(obj, gqlEntry{f.Alias, v})
+	}
+	return obj, nil
+}
+
+// gqlScalar rejects a selection set on a scalar field
+func gqlScalar(f *gqlField, v any) (any, error) {
+	if f.Selections != nil {
+		return nil, fmt.Errorf("field %q is a scalar and cannot have a selection set", f.Name)
+	}
+	return v, nil
+}
+
+// gqlList resolves an object type for each item, requiring a selection set
+func gqlList[T any](f *gqlField, typeName string, items []T, resolve func(T) (gqlObject, error)) (any, error) {
+	if f.Selections == nil {
+		return nil, fmt.Errorf("field %q of type [%s] must have a selection set", f.Name, typeName)
+	}
+	list := make([]gqlObject, 0, len(items))
+	for _, item := range items {
+		obj, err := resolve(item)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, obj)
+	}
+	return list, nil
+}
+
+func unknownField(f *gqlField, typeName string) error {
+	return fmt.Errorf("cannot query field %q on type %q", f.Name, typeName)
+}
+
+// gqlQuery resolves a field of the root Query type
+func (s *Server) gqlQuery(f *gqlField) (any, error) {
+	switch f.Name {
+	case "package":
+		path, err := gqlString(f, "path", true)
+		if err != nil {
+			return nil, err
+		}
+		if f.Selections == nil {
+			return nil, fmt.Errorf("field %q of type Package must have a selection set", f.Name)
+		}
+		pkg, ok := s.FindPackage(path)
+		if !ok {
+			return nil, nil
+		}
+		return s.gqlPackage(f.Selections, pkg, false)
+
+	case "packages":
+		query, err := gqlString(f, "query", true)
+		if err != nil {
+			return nil, err
+		}
+		limit, err := gqlLimit(f, graphQLDefaultLimit)
+		if err != nil {
+			return nil, err
+		}
+		var pkgs []*PackageDoc
+		summaries := s.db != nil
+		if s.db != nil {
+			dbPkgs, err := s.db.SearchPackages(query, limit, false)
+			if err != nil {
+				log.Printf("Error searching packages: %v", err)
+				return nil, fmt.Errorf("searching packages failed")
+			}
+			for _, dbPkg := range dbPkgs {
+				pkgs = append(pkgs, packageSummary(dbPkg))
+			}
+		} else {
+			queryLower := strings.ToLower(query)
+			for _, pkg := range s.listPackages(0, 0) {
+				if len(pkgs) < limit && (strings.Contains(strings.ToLower(pkg.ImportPath), queryLower) ||
+					strings.Contains(strings.ToLower(pkg.Synopsis), queryLower)) {
+					pkgs = append(pkgs, pkg)
+				}
+			}
+		}
+		return gqlList(f, "Package", pkgs, func(pkg *PackageDoc) (gqlObject, error) {
+			return s.gqlPackage(f.Selections, pkg, summaries)
+		})
+
+	case "symbols":
+		query, err := gqlString(f, "query", true)
+		if err != nil {
+			return nil, err
+		}
+		kind, err := gqlString(f, "kind", false)
+		if err != nil {
+			return nil, err
+		}
+		limit, err := gqlLimit(f, graphQLDefaultLimit)
+		if err != nil {
+			return nil, err
+		}
+		var symbols []SymbolResult
+		if s.db != nil {
+			dbSymbols, err := s.db.SearchSymbols(query, kind, limit)
+			if err != nil {
+				log.Printf("Error searching symbols: %v", err)
+				return nil, fmt.Errorf("searching symbols failed")
+			}
+			for _, sym := range dbSymbols {
+				symbols = append(symbols, SymbolResult{
+					Name:       sym.Name,
+					Kind:       sym.Kind,
+					Package:    sym.ImportPath[strings.LastIndex(sym.ImportPath, "/")+1:],
+					ImportPath: sym.ImportPath,
+					Synopsis:   sym.Synopsis,
+					Deprecated: sym.Deprecated,
+				})
+			}
+		} else {
+			queryLower := strings.ToLower(query)
+			for _, sym := range s.browseSymbolsInMemory(kind, "") {
+				if len(symbols) < limit && strings.Contains(strings.ToLower(sym.Name), queryLower) {
+					symbols = append(symbols, sym)
+				}
+			}
+		}
+		return gqlList(f, "Symbol", symbols, func(sym SymbolResult) (gqlObject, error) {
+			return gqlSymbol(f.Selections, sym)
+		})
+	}
+	return nil, unknownField(f, "Query")
+}
+
+// gqlPackage resolves the selected fields of a Package. Search results and
+// importers are summaries; the whole package is loaded only for fields they lack.
+func (s *Server) gqlPackage(sel []*gqlField, pkg *PackageDoc, summary bool) (gqlObject, error) {
+	full := pkg
+	if summary {
+		full = nil
+	}
+	load := func() *PackageDoc {
+		if full == nil {
+			full = pkg
+			if p, ok := s.FindPackage(pkg.ImportPath); ok {
+				full = p
+			}
+		}
+		return full
+	}
+
+	return gqlResolve("Package", sel, func(f *gqlField) (any, error) {
+		switch f.Name {
+		case "importPath":
+			return gqlScalar(f, pkg.ImportPath)
+		case "name":
+			return gqlScalar(f, pkg.Name)
+		case "synopsis":
+			return gqlScalar(f, pkg.Synopsis)
+		case "doc":
+			return gqlScalar(f, load().Doc)
+		case "version":
+			return gqlScalar(f, pkg.Version)
+		case "license":
+			return gqlScalar(f, pkg.License)
+		case "goVersion":
+			return gqlScalar(f, load().GoVersion)
+		case "modulePath":
+			return gqlScalar(f, pkg.ModulePath)
+		case "repository":
+			return gqlScalar(f, pkg.Repository)
+		case "imports":
+			imports := load().Imports
+			if imports == nil {
+				imports = []string{}
+			}
+			return gqlScalar(f, imports)
+		case "importedByCount":
+			return gqlScalar(f, s.GetImportedByCount(pkg.ImportPath))
+		case "importedBy":
+			limit, err := gqlLimit(f, graphQLDefaultLimit)
+			if err != nil {
+				return nil, err
+			}
+			var importers []*PackageDoc
+			if s.db != nil {
+				dbPkgs, _, err := s.db.GetImportedBy(pkg.ImportPath, limit, 0)
+				if err != nil {
+					log.Printf("Error getting imported by: %v", err)
+				}
+				for _, dbPkg := range dbPkgs {
+					importers = append(importers, packageSummary(dbPkg))
+				}
+			}
+			return gqlList(f, "Package", importers, func(p *PackageDoc) (gqlObject, error) {
+				return s.gqlPackage(f.Selections, p, true)
+			})
+		case "symbols":
+			kind, err := gqlString(f, "kind", false)
+			if err != nil {
+				return nil, err
+			}
+			var symbols []SymbolResult
+			for _, sym := range packageSymbols(load()) {
+				if kind == "" || sym.Kind == kind {
+					symbols = append(symbols, sym)
+				}
+			}
+			return gqlList(f, "Symbol", symbols, func(sym SymbolResult) (gqlObject, error) {
+				return gqlSymbol(f.Selections, sym)
+			})
+		case "versions":
+			return gqlList(f, "Version", s.packageVersions(load()), func(v VersionInfo) (gqlObject, error) {
+				return gqlVersion(f.Selections, v)
+			})
+		}
+		return nil, unknownField(f, "Package")
+	})
+}
+
+// gqlSymbol resolves the selected fields of a Symbol
+func gqlSymbol(sel []*gqlField, sym SymbolResult) (gqlObject, error) {
+	return gqlResolve("Symbol", sel, func(f *gqlField) (any, error) {
+		switch f.Name {
+		case "name":
+			return gqlScalar(f, sym.Name)
+		case "kind":
+			return gqlScalar(f, sym.Kind)
+		case "package":
+			return gqlScalar(f, sym.Package)
+		case "importPath":
+			return gqlScalar(f, sym.ImportPath)
+		case "synopsis":
+			return gqlScalar(f, sym.Synopsis)
+		case "deprecated":
+			return gqlScalar(f, sym.Deprecated)
+		}
+		return nil, unknownField(f, "Symbol")
+	})
+}
+
+// gqlVersion resolves the selected fields of a Version
+func gqlVersion(sel []*gqlField, v VersionInfo) (gqlObject, error) {
+	return gqlResolve("Version", sel, func(f *gqlField) (any, error) {
+		switch f.Name {
+		case "version":
+			return gqlScalar(f, v.Version)
+		case "isTagged":
+			return gqlScalar(f, v.IsTagged)
+		case "isStable":
+			return gqlScalar(f, v.IsStable)
+		case "retracted":
+			return gqlScalar(f, v.Retracted)
+		case "publishedAt":
+			if v.Timestamp == "" {
+				return gqlScalar(f, nil)
+			}
+			return gqlScalar(f, v.Timestamp)
+		}
+		return nil, unknownField(f, "Version")
+	})
+}
+
+// gqlError is an entry of the errors list of a GraphQL response
+type gqlError struct {
+	Message string `json:"message"`
+}
+
+// handleGraphQL serves read-only GraphQL queries from GET ?query= or a POST JSON
+// body; a GET without a query returns the schema
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables"`
+	}
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		if req.Query == "" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Write([]byte(graphQLSchema))
+			return
+		}
+		if v := r.URL.Query().Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				writeGraphQLError(w, http.StatusBadRequest, "variables must be a JSON object")
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			writeGraphQLError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if strings.TrimSpace(req.Query) == "" {
+		writeGraphQLError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+	fields, err := parseGraphQL(req.Query, req.Variables)
+	if err == nil {
+		err = checkGraphQLCost(fields)
+	}
+	if err != nil {
+		writeGraphQLError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := gqlResolve("Query", fields, s.gqlQuery)
+	if err != nil {
+		// Field errors still answer 200, with null data, as GraphQL clients expect
+		writeGraphQLError(w, http.StatusOK, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+// writeGraphQLError writes a GraphQL response carrying a single error
+func writeGraphQLError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"data":   nil,
+		"errors": []gqlError{{Message: message}},
+	})
+}
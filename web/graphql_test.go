@@ -0,0 +1,235 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseGraphQL(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		want      string // fields as alias=name(args){selections}
+		wantErr   string
+	}{
+		{
+			name:  "shorthand",
+			query: `{ package(path: "net/http") { name synopsis } }`,
+			want:  `package=package(path:net/http){name=name,synopsis=synopsis}`,
+		},
+		{
+			name:  "named query with alias and comment",
+			query: "query Pkg {\n  # just the name\n  p: package(path: \"fmt\") { n: name }\n}",
+			want:  `p=package(path:fmt){n=name}`,
+		},
+		{
+			name:      "variables",
+			query:     `query ($path: String!, $limit: Int = 5) { package(path: $path) { importedBy(limit: $limit) { name } } }`,
+			variables: map[string]any{"path": "os"},
+			want:      `package=package(path:os){importedBy=importedBy(limit:5){name=name}}`,
+		},
+		{
+			name:      "provided variable overrides default",
+			query:     `query ($limit: Int = 5) { symbols(query: "Read", limit: $limit, kind: func) { name } }`,
+			variables: map[string]any{"limit": float64(2)},
+			want:      `symbols=symbols(kind:func,limit:2,query:Read){name=name}`,
+		},
+		{
+			name:  "escaped string",
+			query: `{ symbols(query: "a\"b") { name } }`,
+			want:  `symbols=symbols(query:a"b){name=name}`,
+		},
+		{name: "mutation", query: `mutation { package(path: "fmt") { name } }`, wantErr: "read-only"},
+		{name: "fragment", query: `{ package(path: "fmt") { ...Fields } }`, wantErr: "fragments"},
+		{name: "directive", query: `{ package(path: "fmt") @skip(if: true) { name } }`, wantErr: "directives"},
+		{name: "undefined variable", query: `{ package(path: $path) { name } }`, wantErr: "$path is not defined"},
+		{name: "two operations", query: `{ a: package(path: "fmt") { name } } { b }`, wantErr: "single operation"},
+		{name: "unterminated", query: `{ package(path: "fmt") { name }`, wantErr: "unterminated"},
+		{name: "empty selection", query: `{ }`, wantErr: "empty selection set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := parseGraphQL(tt.query, tt.variables)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseGraphQL() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseGraphQL() error = %v", err)
+			}
+			if got := formatGQLFields(fields); got != tt.want {
+				t.Errorf("parseGraphQL() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// formatGQLFields renders parsed fields compactly, with arguments sorted by name
+func formatGQLFields(fields []*gqlField) string {
+	var parts []string
+	for _, f := range fields {
+		s := f.Alias + "=" + f.Name
+		if f.Args != nil {
+			var args []string
+			for k, v := range f.Args {
+				args = append(args, k+":"+fmt.Sprint(v))
+			}
+			slices.Sort(args)
+			s += "(" + strings.Join(args, ",") + ")"
+		}
+		if f.Selections != nil {
+			s += "{" + formatGQLFields(f.Selections) + "}"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestCheckGraphQLCost(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr string
+	}{
+		{`{ package(path: "fmt") { name importedBy { name versions { version } } } }`, ""},
+		{`{ packages(query: "http", limit: 10) { importedBy(limit: 40) { symbols { name } } } }`, ""},
+		{`{ packages(query: "http") { importedBy { importedBy { name } } } }`, "more than 1000 objects"},
+		{`{ a: packages(query: "http", limit: 100) { name } b: symbols(query: "Read", limit: 100) { name } c: packages(query: "io", limit: 100) { importedBy(limit: 8) { name } } }`, "more than 1000 objects"},
+		{`{ package(path: "fmt") { importedBy { importedBy { importedBy { importedBy { versions { version } } } } } } }`, "6 levels deep"},
+	}
+	for _, tt := range tests {
+		fields, err := parseGraphQL(tt.query, nil)
+		if err != nil {
+			t.Fatalf("parseGraphQL(%q): %v", tt.query, err)
+		}
+		err = checkGraphQLCost(fields)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("checkGraphQLCost(%q) = %v, want error containing %q", tt.query, err, tt.wantErr)
+		}
+	}
+}
+
+func TestHandler_GraphQL(t *testing.T) {
+	_, handler := seededServer(t)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/graphql", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "only the selected fields, in order",
+			query: `{ package(path: "example.com/mem/widgets") { name synopsis importedByCount } }`,
+			want:  `{"data":{"package":{"name":"widgets","synopsis":"Package widgets builds widgets in memory.","importedByCount":1}}}`,
+		},
+		{
+			name:  "database package with aliases",
+			query: `{ g: package(path: "example.com/db/gadgets") { __typename path: importPath goVersion imports types: symbols(kind: "type") { name kind } } }`,
+			want:  `{"data":{"g":{"__typename":"Package","path":"example.com/db/gadgets","goVersion":"1.23","imports":["example.com/mem/widgets","fmt"],"types":[{"name":"Gadget","kind":"type"}]}}}`,
+		},
+		{
+			name:  "importers and versions",
+			query: `{ package(path: "example.com/mem/widgets") { importedBy { importPath versions { version } } } }`,
+			want:  `{"data":{"package":{"importedBy":[{"importPath":"example.com/db/gadgets","versions":[{"version":"v1.3.0"},{"version":"v1.4.0"}]}]}}}`,
+		},
+		{
+			name:  "missing package",
+			query: `{ package(path: "example.com/missing") { name } }`,
+			want:  `{"data":{"package":null}}`,
+		},
+		{
+			name:  "package search",
+			query: `{ packages(query: "gadgets", limit: 1) { importPath } }`,
+			want:  `{"data":{"packages":[{"importPath":"example.com/db/gadgets"}]}}`,
+		},
+		{
+			name:  "unknown field",
+			query: `{ package(path: "example.com/mem/widgets") { stars } }`,
+			want:  `{"data":null,"errors":[{"message":"cannot query field \"stars\" on type \"Package\""}]}`,
+		},
+		{
+			name:  "object without selection",
+			query: `{ package(path: "example.com/mem/widgets") }`,
+			want:  `{"data":null,"errors":[{"message":"field \"package\" of type Package must have a selection set"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(map[string]string{"query": tt.query})
+			w := post(string(body))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.want {
+				t.Errorf("response =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("variables", func(t *testing.T) {
+		w := post(`{"query": "query ($p: String!) { package(path: $p) { name } }", "variables": {"p": "example.com/db/gadgets"}}`)
+		if got := strings.TrimSpace(w.Body.String()); got != `{"data":{"package":{"name":"gadgets"}}}` {
+			t.Errorf("response = %s", got)
+		}
+	})
+
+	t.Run("GET query", func(t *testing.T) {
+		w := serve(handler, "/graphql?query="+url.QueryEscape(`{ package(path: "example.com/mem/widgets") { goVersion } }`))
+		if got := strings.TrimSpace(w.Body.String()); got != `{"data":{"package":{"goVersion":"1.22"}}}` {
+			t.Errorf("response = %s", got)
+		}
+	})
+
+	t.Run("GET schema", func(t *testing.T) {
+		w := serve(handler, "/graphql")
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "importedByCount: Int!") {
+			t.Errorf("status = %d, body does not document the schema", w.Code)
+		}
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		w := post(`{"query": "{ package(path: \"fmt\") { name }"}`)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"errors"`) {
+			t.Errorf("status = %d, body = %s", w.Code, w.Body)
+		}
+	})
+
+	t.Run("too deep", func(t *testing.T) {
+		w := post(`{"query": "{ package(path: \"fmt\") { importedBy(limit: 1) { importedBy(limit: 1) { importedBy(limit: 1) { importedBy(limit: 1) { importedBy(limit: 1) { name } } } } } } }"}`)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "levels deep") {
+			t.Errorf("status = %d, body = %s", w.Code, w.Body)
+		}
+	})
+
+	t.Run("too many nodes", func(t *testing.T) {
+		w := post(`{"query": "{ packages(query: \"fmt\", limit: 100) { importedBy(limit: 100) { name } } }"}`)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "more than 1000 objects") {
+			t.Errorf("status = %d, body = %s", w.Code, w.Body)
+		}
+	})
+
+	t.Run("mutation", func(t *testing.T) {
+		w := post(`{"query": "mutation { deletePackage(path: \"fmt\") }"}`)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "read-only") {
+			t.Errorf("status = %d, body = %s", w.Code, w.Body)
+		}
+	})
+}
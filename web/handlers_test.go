@@ -0,0 +1,1134 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alexisbouchez/wikigo/db"
+)
+
+// seededServer returns a server with one package loaded in memory and others only
+// in its database, routed through the same handler as ListenAndServe
+func seededServer(t *testing.T) (*Server, http.Handler) {
+	t.Helper()
+
+	s, err := NewServerWithDB(t.TempDir(), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	s.packages["example.com/mem/widgets"] = &PackageDoc{
+		ImportPath: "example.com/mem/widgets",
+		Name:       "widgets",
+		Synopsis:   "Package widgets builds widgets in memory.",
+		GoVersion:  "1.22",
+		License:    "MIT",
+		Functions:  []Function{{Name: "NewWidget", Signature: "func NewWidget() *Widget"}},
+		Types:      []Type{{Name: "Widget", Decl: "type Widget struct{}"}},
+	}
+
+	gadgets := &PackageDoc{
+		ImportPath:   "example.com/db/gadgets",
+		Name:         "gadgets",
+		Synopsis:     "Package gadgets builds gadgets from the database.",
+		Version:      "v1.4.0",
+		Versions:     []string{"v1.3.0", "v1.4.0"},
+		GoVersion:    "1.23",
+		License:      "Apache-2.0",
+		LicenseText:  "Apache License, Version 2.0",
+		ModulePath:   "example.com/db/gadgets",
+		Coverage:     "87.5%",
+		GoModContent: "module example.com/db/gadgets\n\ngo 1.23\n\nrequire example.com/mem v0.3.0\n",
+		Imports:      []string{"fmt", "example.com/mem/widgets"},
+		Types:        []Type{{Name: "Gadget", Decl: "type Gadget struct{}"}},
+	}
+	// Enough symbols to span two pages of symbol search
+	for i := range 120 {
+		gadgets.Functions = append(gadgets.Functions, Function{
+			Name:      fmt.Sprintf("Gizmo%03d", i),
+			Doc:       "Gizmo spins a gizmo.", // the symbol index matches whole words
+			Signature: fmt.Sprintf("func Gizmo%03d()", i),
+		})
+	}
+	if err := s.IndexPackage(gadgets); err != nil {
+		t.Fatalf("IndexPackage(%s) error = %v", gadgets.ImportPath, err)
+	}
+
+	// Enough packages to span two pages of search results
+	for i := range 60 {
+		pkg := &PackageDoc{
+			ImportPath: fmt.Sprintf("example.com/db/sprocket%02d", i),
+			Name:       fmt.Sprintf("sprocket%02d", i),
+			Synopsis:   "Package sprocket turns sprockets.",
+		}
+		if err := s.IndexPackage(pkg); err != nil {
+			t.Fatalf("IndexPackage(%s) error = %v", pkg.ImportPath, err)
+		}
+	}
+
+	// As dbmaint imported-by does after a crawl
+	if _, err := s.db.RefreshImportedByCounts(); err != nil {
+		t.Fatalf("RefreshImportedByCounts() error = %v", err)
+	}
+
+	handler, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+	return s, handler
+}
+
+func serve(handler http.Handler, target string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", target, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandler_PackagePages(t *testing.T) {
+	_, handler := seededServer(t)
+
+	tests := []struct {
+		name     string
+		target   string
+		wantCode int
+		wantBody string
+	}{
+		{"home", "/", http.StatusOK, "example.com/mem/widgets"},
+		{"memory package", "/example.com/mem/widgets", http.StatusOK, "NewWidget"},
+		{"memory package by suffix", "/mem/widgets", http.StatusOK, "NewWidget"},
+		{"database package", "/example.com/db/gadgets", http.StatusOK, "Gizmo000"},
+		{"unknown package", "/example.com/missing", http.StatusNotFound, "404 page not found"},
+		{"static file", "/static/main.js", http.StatusOK, "copyImportPath"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(handler, tt.target)
+			if w.Code != tt.wantCode {
+				t.Fatalf("GET %s: status = %d, want %d", tt.target, w.Code, tt.wantCode)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("GET %s: body does not contain %q", tt.target, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandler_SymbolCap(t *testing.T) {
+	_, handler := seededServer(t)
+
+	body := serve(handler, "/example.com/db/gadgets").Body.String()
+	if !strings.Contains(body, `id="Gizmo099"`) || strings.Contains(body, `id="Gizmo100"`) {
+		t.Errorf("package page does not render exactly the first %d functions", defaultMaxSymbols)
+	}
+	if !strings.Contains(body, `data-section="functions" data-offset="100"`) {
+		t.Error("package page has no button loading the other functions")
+	}
+	if strings.Contains(body, `data-section="types"`) {
+		t.Error("package page offers to load types it already shows")
+	}
+
+	var got struct {
+		Section string `json:"section"`
+		Offset  int    `json:"offset"`
+		Count   int    `json:"count"`
+		Total   int    `json:"total"`
+		HTML    string `json:"html"`
+	}
+	w := serve(handler, "/api/example.com/db/gadgets/symbols?section=functions&offset=100")
+	if w.Code != http.StatusOK {
+		t.Fatalf("symbols: status = %d, want 200", w.Code)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("symbols: invalid JSON: %v", err)
+	}
+	if got.Section != "functions" || got.Offset != 100 || got.Count != 20 || got.Total != 120 {
+		t.Errorf("symbols = %s %d +%d of %d, want functions 100 +20 of 120", got.Section, got.Offset, got.Count, got.Total)
+	}
+	if !strings.Contains(got.HTML, `id="Gizmo119"`) || strings.Contains(got.HTML, `id="Gizmo099"`) {
+		t.Errorf("symbols HTML does not hold exactly the functions from the offset")
+	}
+
+	w = serve(handler, "/api/example.com/db/gadgets/symbols?section=functions&offset=110&limit=5")
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || got.Count != 5 {
+		t.Errorf("symbols with limit: count = %d, err = %v, want 5", got.Count, err)
+	}
+
+	for _, target := range []string{
+		"/api/example.com/db/gadgets/symbols?section=methods",
+		"/api/example.com/db/gadgets/symbols?section=functions&offset=-1",
+	} {
+		if w := serve(handler, target); w.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: status = %d, want 400", target, w.Code)
+		}
+	}
+	if w := serve(handler, "/api/example.com/missing/symbols?section=functions"); w.Code != http.StatusNotFound {
+		t.Errorf("symbols of unknown package: status = %d, want 404", w.Code)
+	}
+}
+
+func TestHandler_Search(t *testing.T) {
+	_, handler := seededServer(t)
+
+	if w := serve(handler, "/search"); w.Code != http.StatusFound || w.Header().Get("Location") != "/" {
+		t.Errorf("GET /search: status = %d, Location = %q, want a redirect home", w.Code, w.Header().Get("Location"))
+	}
+
+	tests := []struct {
+		target      string
+		wantResults int
+	}{
+		{"/search?q=sprocket", 50},
+		{"/search?q=sprocket&page=1", 50},
+		{"/search?q=sprocket&page=2", 10},
+		{"/search?q=sprocket&page=3", 0},
+		{"/search?q=sprocket&page=0", 50},   // clamped to the first page
+		{"/search?q=sprocket&page=abc", 50}, // ignored
+		{"/search?q=gadgets", 1},
+		{"/search?q=nothingmatches", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := serve(handler, tt.target)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+			if got := strings.Count(w.Body.String(), `<div class="SearchResult">`); got != tt.wantResults {
+				t.Errorf("results = %d, want %d", got, tt.wantResults)
+			}
+		})
+	}
+}
+
+func TestHandler_SearchWithoutDatabase(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+	s.packages["example.com/mem/widgets"] = &PackageDoc{ImportPath: "example.com/mem/widgets", Name: "widgets"}
+	s.packages["example.com/mem/widgets_test"] = &PackageDoc{ImportPath: "example.com/mem/widgets_test", Name: "widgets_test", Classification: "test-only"}
+
+	handler, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+
+	w := serve(handler, "/search?q=widgets")
+	if got := strings.Count(w.Body.String(), `<div class="SearchResult">`); got != 1 {
+		t.Errorf("in-memory search results = %d, want 1 (test-only packages are hidden)", got)
+	}
+
+	w = serve(handler, "/api/search?q=widgets")
+	var results []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil {
+		t.Fatalf("decoding /api/search: %v", err)
+	}
+	if len(results) != 1 || results[0]["import_path"] != "example.com/mem/widgets" {
+		t.Errorf("in-memory /api/search = %v, want only example.com/mem/widgets", results)
+	}
+}
+
+func TestHandler_DerivedSynopsis(t *testing.T) {
+	s, handler := seededServer(t)
+	pkg := &PackageDoc{
+		ImportPath:     "example.com/db/flywheel",
+		Name:           "flywheel",
+		Synopsis:       "Flywheel stores rotational energy.",
+		SynopsisSource: "readme",
+	}
+	if err := s.IndexPackage(pkg); err != nil {
+		t.Fatalf("IndexPackage() error = %v", err)
+	}
+
+	w := serve(handler, "/search?q=flywheel")
+	if body := w.Body.String(); !strings.Contains(body, "Flywheel stores rotational energy.") || !strings.Contains(body, `class="SynopsisSource"`) {
+		t.Errorf("search results do not mark the README synopsis:\n%s", body)
+	}
+	if strings.Contains(serve(handler, "/search?q=gadgets").Body.String(), `class="SynopsisSource"`) {
+		t.Error("search results mark a synopsis from the package doc as derived")
+	}
+
+	var doc PackageDoc
+	if err := json.Unmarshal(serve(handler, "/api/example.com/db/flywheel").Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decoding package: %v", err)
+	}
+	if doc.SynopsisSource != "readme" {
+		t.Errorf("synopsis_source = %q, want readme", doc.SynopsisSource)
+	}
+}
+
+func TestListPackages_Database(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+	for _, name := range []string{"e", "c", "a", "d", "b"} {
+		if err := s.IndexPackage(&PackageDoc{ImportPath: "example.com/" + name, Name: name}); err != nil {
+			t.Fatalf("IndexPackage() error = %v", err)
+		}
+	}
+
+	var got []string
+	for _, pkg := range s.listPackages(2, 1) {
+		got = append(got, pkg.ImportPath)
+	}
+	if want := []string{"example.com/b", "example.com/c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listPackages(2, 1) = %v, want %v", got, want)
+	}
+	if n := len(s.listPackages(10, 4)); n != 1 {
+		t.Errorf("listPackages(10, 4) returned %d packages, want 1", n)
+	}
+	if n := len(s.listPackages(0, 0)); n != 5 {
+		t.Errorf("listPackages(0, 0) returned %d packages, want all 5", n)
+	}
+}
+
+func TestHandler_APIPackagesUnpaged(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+	for i := range defaultPackageListLimit + 20 {
+		if _, err := s.db.UpsertPackage(&db.Package{ImportPath: fmt.Sprintf("example.com/p%03d", i), Name: "p"}); err != nil {
+			t.Fatalf("UpsertPackage() error = %v", err)
+		}
+	}
+	handler, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+
+	// Clients predating paging get every package
+	var list []map[string]string
+	if err := json.Unmarshal(serve(handler, "/api/packages").Body.Bytes(), &list); err != nil {
+		t.Fatalf("decoding package list: %v", err)
+	}
+	if len(list) != defaultPackageListLimit+20 {
+		t.Errorf("package list has %d entries, want all %d", len(list), defaultPackageListLimit+20)
+	}
+	if err := json.Unmarshal(serve(handler, "/api/packages?limit=").Body.Bytes(), &list); err != nil {
+		t.Fatalf("decoding package page: %v", err)
+	}
+	if len(list) != defaultPackageListLimit {
+		t.Errorf("package page has %d entries, want %d", len(list), defaultPackageListLimit)
+	}
+}
+
+func TestHandler_API(t *testing.T) {
+	_, handler := seededServer(t)
+
+	t.Run("package list", func(t *testing.T) {
+		w := serve(handler, "/api/packages")
+		var list []map[string]string
+		if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+			t.Fatalf("decoding package list: %v", err)
+		}
+		// Packages loaded from JSON files and those only in the database, by import path
+		if len(list) != 62 {
+			t.Fatalf("package list has %d entries, want 62", len(list))
+		}
+		if list[0]["import_path"] != "example.com/db/gadgets" || list[61]["import_path"] != "example.com/mem/widgets" {
+			t.Errorf("package list runs from %s to %s", list[0]["import_path"], list[61]["import_path"])
+		}
+
+		// Pages mixing loaded and database packages add up to the whole list
+		var paged []map[string]string
+		for offset := 0; offset < 70; offset += 9 {
+			var page []map[string]string
+			w := serve(handler, fmt.Sprintf("/api/packages?limit=9&offset=%d", offset))
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+				t.Fatalf("decoding package page: %v", err)
+			}
+			paged = append(paged, page...)
+		}
+		if !reflect.DeepEqual(paged, list) {
+			t.Errorf("paged package list has %d entries, want the %d of the full list in order", len(paged), len(list))
+		}
+
+		// An offset alone pages with the default limit
+		var page []map[string]string
+		if err := json.Unmarshal(serve(handler, "/api/packages?offset=60").Body.Bytes(), &page); err != nil {
+			t.Fatalf("decoding package page: %v", err)
+		}
+		if !reflect.DeepEqual(page, list[60:]) {
+			t.Errorf("offset=60 returned %d entries, want the last 2", len(page))
+		}
+	})
+
+	t.Run("memory package", func(t *testing.T) {
+		w := serve(handler, "/api/example.com/mem/widgets")
+		var pkg PackageDoc
+		if err := json.Unmarshal(w.Body.Bytes(), &pkg); err != nil {
+			t.Fatalf("decoding package: %v", err)
+		}
+		if w.Code != http.StatusOK || pkg.Name != "widgets" || len(pkg.Functions) != 1 {
+			t.Errorf("status = %d, package = %+v", w.Code, pkg)
+		}
+	})
+
+	t.Run("database package", func(t *testing.T) {
+		w := serve(handler, "/api/example.com/db/gadgets")
+		var pkg PackageDoc
+		if err := json.Unmarshal(w.Body.Bytes(), &pkg); err != nil {
+			t.Fatalf("decoding package: %v", err)
+		}
+		if w.Code != http.StatusOK || pkg.Name != "gadgets" || len(pkg.Functions) != 120 || len(pkg.Types) != 1 {
+			t.Errorf("status = %d, name = %q, %d functions, %d types", w.Code, pkg.Name, len(pkg.Functions), len(pkg.Types))
+		}
+	})
+
+	t.Run("conditional requests", func(t *testing.T) {
+		w := serve(handler, "/api/example.com/db/gadgets")
+		etag, lastModified := w.Header().Get("ETag"), w.Header().Get("Last-Modified")
+		if etag == "" || lastModified == "" {
+			t.Fatalf("ETag = %q, Last-Modified = %q, want both set", etag, lastModified)
+		}
+
+		w = serve(handler, "/api/example.com/db/gadgets", "If-None-Match", etag)
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("If-None-Match: status = %d with %d bytes, want an empty 304", w.Code, w.Body.Len())
+		}
+		w = serve(handler, "/api/example.com/db/gadgets", "If-Modified-Since", lastModified)
+		if w.Code != http.StatusNotModified {
+			t.Errorf("If-Modified-Since: status = %d, want 304", w.Code)
+		}
+		w = serve(handler, "/api/example.com/db/gadgets", "If-None-Match", `"stale"`)
+		if w.Code != http.StatusOK {
+			t.Errorf("stale If-None-Match: status = %d, want 200", w.Code)
+		}
+
+		// Range is for files: a JSON document is never cut short
+		full := serve(handler, "/api/example.com/db/gadgets").Body.String()
+		w = serve(handler, "/api/example.com/db/gadgets", "Range", "bytes=0-9")
+		if w.Code != http.StatusOK || w.Body.String() != full || w.Header().Get("Content-Range") != "" {
+			t.Errorf("Range: status = %d with %d of %d bytes, want the whole document", w.Code, w.Body.Len(), len(full))
+		}
+
+		// Selecting fields changes the body, and with it the ETag
+		w = serve(handler, "/api/example.com/db/gadgets?fields=name", "If-None-Match", etag)
+		if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+			t.Errorf("fields: status = %d, ETag = %q, want 200 with a different ETag", w.Code, w.Header().Get("ETag"))
+		}
+	})
+
+	t.Run("unknown package", func(t *testing.T) {
+		w := serve(handler, "/api/example.com/missing")
+		if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "package not found") {
+			t.Errorf("status = %d, body = %q, want a JSON 404", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("search", func(t *testing.T) {
+		w := serve(handler, "/api/search?q=gadgets&lang=go")
+		var results []map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil {
+			t.Fatalf("decoding search results: %v", err)
+		}
+		if len(results) != 1 || results[0]["import_path"] != "example.com/db/gadgets" || results[0]["lang"] != "go" {
+			t.Errorf("search results = %v, want example.com/db/gadgets", results)
+		}
+	})
+
+	t.Run("search limit", func(t *testing.T) {
+		var results []map[string]any
+		json.Unmarshal(serve(handler, "/api/search?q=sprocket&limit=5").Body.Bytes(), &results)
+		if len(results) != 5 {
+			t.Errorf("search with limit=5 returned %d results", len(results))
+		}
+		json.Unmarshal(serve(handler, "/api/search?q=sprocket").Body.Bytes(), &results)
+		if len(results) != 50 {
+			t.Errorf("search without limit returned %d results, want the default 50", len(results))
+		}
+	})
+
+	t.Run("search ndjson", func(t *testing.T) {
+		w := serve(handler, "/api/search?q=sprocket&lang=go", "Accept", "application/x-ndjson")
+		if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("Content-Type = %q, want application/x-ndjson", ct)
+		}
+		lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+		if len(lines) != 60 {
+			t.Fatalf("streamed %d results, want all 60 sprockets past the default limit", len(lines))
+		}
+		for _, line := range lines {
+			var result map[string]any
+			if err := json.Unmarshal([]byte(line), &result); err != nil {
+				t.Fatalf("decoding line %q: %v", line, err)
+			}
+			if !strings.HasPrefix(result["import_path"].(string), "example.com/db/sprocket") || result["lang"] != "go" {
+				t.Errorf("result = %v, want a sprocket", result)
+			}
+		}
+
+		w = serve(handler, "/api/search?q=sprocket&limit=500", "Accept", "application/x-ndjson")
+		if n := strings.Count(w.Body.String(), "\n"); n != 60 {
+			t.Errorf("limit=500 streamed %d results, want 60", n)
+		}
+		w = serve(handler, "/api/search?q=sprocket&limit=7", "Accept", "application/x-ndjson")
+		if n := strings.Count(w.Body.String(), "\n"); n != 7 {
+			t.Errorf("limit=7 streamed %d results", n)
+		}
+	})
+
+	t.Run("accept json on package page", func(t *testing.T) {
+		w := serve(handler, "/example.com/db/gadgets", "Accept", "application/json")
+		if ct := w.Header().Get("Content-Type"); w.Code != http.StatusOK || ct != "application/json" {
+			t.Errorf("status = %d, Content-Type = %q", w.Code, ct)
+		}
+	})
+}
+
+func TestHandler_Symbols(t *testing.T) {
+	_, handler := seededServer(t)
+
+	tests := []struct {
+		target      string
+		wantResults int
+	}{
+		{"/symbols", 0},
+		{"/symbols?q=Gizmo", 100},
+		{"/symbols?q=Gizmo&page=2", 20},
+		{"/symbols?q=Gizmo&page=3", 0},
+		{"/symbols?q=Gizmo&page=-1", 100},
+		{"/symbols?q=Gadget&kind=type", 1},
+		{"/symbols?q=Gizmo&kind=type", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := serve(handler, tt.target)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+			if got := strings.Count(w.Body.String(), `class="SymbolResult"`); got != tt.wantResults {
+				t.Errorf("results = %d, want %d", got, tt.wantResults)
+			}
+		})
+	}
+}
+
+func TestHandler_SymbolShapes(t *testing.T) {
+	buffers := &PackageDoc{
+		ImportPath: "example.com/db/buffers",
+		Name:       "buffers",
+		Functions:  []Function{{Name: "Count", Signature: "func Count(data []byte) (int, error)"}},
+		Types: []Type{{
+			Name:    "Buffer",
+			Decl:    "type Buffer struct{}",
+			Methods: []Function{{Name: "Write", Recv: "*Buffer", Signature: "func (b *Buffer) Write(p []byte) (n int, err error)"}},
+		}},
+	}
+
+	memServer, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	memServer.packages[buffers.ImportPath] = buffers
+	memHandler, err := memServer.Handler()
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+
+	dbServer, dbHandler := seededServer(t)
+	if err := dbServer.IndexPackage(buffers); err != nil {
+		t.Fatalf("IndexPackage() error = %v", err)
+	}
+
+	for name, handler := range map[string]http.Handler{"memory": memHandler, "database": dbHandler} {
+		t.Run(name, func(t *testing.T) {
+			tests := []struct {
+				target      string
+				wantResults int
+			}{
+				{"/symbols?q=" + url.QueryEscape("func([]byte) (int, error)"), 2},
+				{"/symbols?q=" + url.QueryEscape("func([]byte) (int, error)") + "&kind=method", 1},
+				{"/symbols?q=" + url.QueryEscape("func(T) (int, ...)"), 2},
+				{"/symbols?q=" + url.QueryEscape("func(string) error"), 0},
+			}
+			for _, tt := range tests {
+				w := serve(handler, tt.target)
+				if w.Code != http.StatusOK {
+					t.Fatalf("%s status = %d, want 200", tt.target, w.Code)
+				}
+				if got := strings.Count(w.Body.String(), `class="SymbolResult"`); got != tt.wantResults {
+					t.Errorf("%s results = %d, want %d", tt.target, got, tt.wantResults)
+				}
+			}
+
+			body := serve(handler, "/symbols?q="+url.QueryEscape("func([]byte) (int, error)")).Body.String()
+			if !strings.Contains(body, "Buffer.Write") || !strings.Contains(body, "func (b *Buffer) Write(p []byte) (n int, err error)") {
+				t.Errorf("results do not show Buffer.Write with its signature:\n%s", body)
+			}
+
+			if body := serve(handler, "/symbols?q="+url.QueryEscape("func([]byte")).Body.String(); !strings.Contains(body, "Could not parse the function shape") {
+				t.Errorf("invalid shape is not reported:\n%s", body)
+			}
+		})
+	}
+}
+
+func TestHandler_Badge(t *testing.T) {
+	_, handler := seededServer(t)
+
+	tests := []struct {
+		target      string
+		wantLabel   string
+		wantMessage string
+	}{
+		{"/badge/example.com/mem/widgets", "go", "1.22"},
+		{"/badge/example.com/mem/widgets?type=license", "license", "MIT"},
+		{"/badge/mem/widgets", "go", "1.22"},
+		{"/badge/example.com/db/gadgets", "go", "1.23"},
+		{"/badge/example.com/db/gadgets?type=license", "license", "Apache-2.0"},
+		{"/badge/example.com/missing", "go", "unknown"},
+		{"/badge/example.com/mem/widgets?type=custom&label=docs&message=view", "docs", "view"},
+		{"/badge/example.com/mem/widgets?type=license&label=licence", "licence", "MIT"},
+		{"/badge/example.com/mem/widgets?type=custom&message=%3Csvg%3E%0A%22onload%22%00", "wikigo", `<svg> "onload"`},
+		{"/badge/example.com/mem/widgets?type=custom&message=" + strings.Repeat("x", 100), "wikigo", strings.Repeat("x", maxBadgeText)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := serve(handler, tt.target)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+			var badge map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &badge); err != nil {
+				t.Fatalf("decoding badge: %v", err)
+			}
+			if badge["label"] != tt.wantLabel || badge["message"] != tt.wantMessage {
+				t.Errorf("badge = %v, want label %q and message %q", badge, tt.wantLabel, tt.wantMessage)
+			}
+		})
+	}
+
+	color := func(target string) any {
+		t.Helper()
+		var badge map[string]any
+		if err := json.Unmarshal(serve(handler, target).Body.Bytes(), &badge); err != nil {
+			t.Fatalf("decoding badge: %v", err)
+		}
+		return badge["color"]
+	}
+	if got := color("/badge/example.com/mem/widgets?type=custom&label=docs&message=view&color=blue"); got != "blue" {
+		t.Errorf("color = %v, want blue", got)
+	}
+	if got := color("/badge/example.com/mem/widgets?color=fff%22%3E"); got != "00add8" {
+		t.Errorf("invalid color override = %v, want the default 00add8", got)
+	}
+
+	if w := serve(handler, "/badge/"); w.Code != http.StatusBadRequest {
+		t.Errorf("GET /badge/: status = %d, want 400", w.Code)
+	}
+	if w := serve(handler, "/badge/example.com/mem/widgets?type=custom"); w.Code != http.StatusBadRequest {
+		t.Errorf("custom badge without message: status = %d, want 400", w.Code)
+	}
+}
+
+func TestHandler_Permalinks(t *testing.T) {
+	_, handler := seededServer(t)
+
+	tests := []struct {
+		permalink string
+		query     string
+	}{
+		{"/diff/example.com/mem/widgets/v1.0.0...v1.2.0", "/diff/example.com/mem/widgets?v1=v1.0.0&v2=v1.2.0"},
+		{"/compare/example.com/mem/widgets...example.com/db/gadgets", "/compare/?pkg1=example.com/mem/widgets&pkg2=example.com/db/gadgets"},
+	}
+	for _, tt := range tests {
+		w := serve(handler, tt.permalink)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status = %d, want 200", tt.permalink, w.Code)
+		}
+		if want := serve(handler, tt.query).Body.String(); w.Body.String() != want {
+			t.Errorf("GET %s differs from GET %s", tt.permalink, tt.query)
+		}
+		if share := `data-path="` + tt.permalink + `"`; !strings.Contains(w.Body.String(), share) {
+			t.Errorf("GET %s: share button does not copy the permalink", tt.permalink)
+		}
+	}
+}
+
+func TestHandler_ModuleChecksum(t *testing.T) {
+	s, handler := seededServer(t)
+
+	if body := serve(handler, "/mod/example.com/db/gadgets").Body.String(); strings.Contains(body, "Checksum") {
+		t.Error("module page shows a checksum before the version was hashed")
+	}
+
+	mv := &db.ModuleVersion{ModulePath: "example.com/db/gadgets", Version: "v1.4.0"}
+	if err := s.db.UpsertModuleVersion(mv); err != nil {
+		t.Fatalf("UpsertModuleVersion() error = %v", err)
+	}
+	mv.Checksum, mv.ProxyChecksum, mv.GoSumModules = "h1:computed=", "h1:reported=", 3
+	if err := s.db.SetModuleChecksum(mv); err != nil {
+		t.Fatalf("SetModuleChecksum() error = %v", err)
+	}
+
+	body := serve(handler, "/mod/example.com/db/gadgets").Body.String()
+	for _, want := range []string{
+		"example.com/db/gadgets v1.4.0 h1:computed=",
+		`class="Module-warning"`,
+		"h1:reported=",
+		`href="https://sum.golang.org/lookup/example.com/db/gadgets@v1.4.0"`,
+		"pins the checksums of 3 module versions",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("module page does not contain %q", want)
+		}
+	}
+}
+
+func TestHandler_DatabasePackagePages(t *testing.T) {
+	_, handler := seededServer(t)
+
+	// example.com/db/gadgets was indexed but never loaded from a JSON file
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"/license/example.com/db/gadgets", "Apache License, Version 2.0"},
+		{"/imports/example.com/db/gadgets", "example.com/mem/widgets"},
+		{"/mod/example.com/db/gadgets", "example.com/mem"},
+		{"/mod/example.com/db/gadgets/raw", "require example.com/mem v0.3.0"},
+		{"/versions/example.com/db/gadgets", "v1.3.0"},
+		{"/example.com/db/gadgets", "Coverage 87.5%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := serve(handler, tt.target)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("body does not contain %q", tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_GenerateDirectives(t *testing.T) {
+	s, handler := seededServer(t)
+
+	colors := &PackageDoc{
+		ImportPath: "example.com/db/colors",
+		Name:       "colors",
+		Synopsis:   "Package colors names colors.",
+		Generate:   []string{"stringer -type=Color", "go run gen.go -out <tables>.go"},
+	}
+	if err := s.IndexPackage(colors); err != nil {
+		t.Fatalf("IndexPackage() error = %v", err)
+	}
+
+	w := serve(handler, "/example.com/db/colors")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{`href="#pkg-buildinfo"`, "stringer -type=Color\n", "go run gen.go -out &lt;tables&gt;.go"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("package page does not contain %q", want)
+		}
+	}
+
+	w = serve(handler, "/api/example.com/db/colors?fields=generate")
+	if got, want := strings.TrimSpace(w.Body.String()), `{"generate":["stringer -type=Color","go run gen.go -out \u003ctables\u003e.go"]}`; got != want {
+		t.Errorf("API response = %s, want %s", got, want)
+	}
+
+	// Packages without directives have no build info section
+	if strings.Contains(serve(handler, "/example.com/db/gadgets").Body.String(), "pkg-buildinfo") {
+		t.Error("package without directives shows a build info section")
+	}
+}
+
+func TestHandler_PackageJSONFields(t *testing.T) {
+	s, handler := seededServer(t)
+
+	tests := []struct {
+		name     string
+		target   string
+		header   []string
+		wantCode int
+		want     string
+	}{
+		{
+			name:     "selected fields in order",
+			target:   "/api/example.com/db/gadgets?fields=version,name,license_text",
+			wantCode: http.StatusOK,
+			want:     `{"version":"v1.4.0","name":"gadgets","license_text":"Apache License, Version 2.0"}`,
+		},
+		{
+			name:     "empty fields are kept",
+			target:   "/api/example.com/mem/widgets?fields=name,gomod_content",
+			wantCode: http.StatusOK,
+			want:     `{"name":"widgets","gomod_content":""}`,
+		},
+		{
+			name:     "package page negotiation",
+			target:   "/example.com/mem/widgets?fields=go_version",
+			header:   []string{"Accept", "application/json"},
+			wantCode: http.StatusOK,
+			want:     `{"go_version":"1.22"}`,
+		},
+		{
+			name:     "unknown field",
+			target:   "/api/example.com/db/gadgets?fields=name,stars",
+			wantCode: http.StatusBadRequest,
+			want:     `{"error":"unknown field \"stars\""}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(handler, tt.target, tt.header...)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.want {
+				t.Errorf("body = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Listings never carry the heavy fields
+	for _, target := range []string{"/api/packages", "/api/search?q=gadgets"} {
+		if body := serve(handler, target).Body.String(); strings.Contains(body, "license_text") || strings.Contains(body, "gomod_content") {
+			t.Errorf("%s includes license text or go.mod", target)
+		}
+	}
+
+	full := serve(handler, "/api/example.com/db/gadgets").Body.String()
+	if !strings.Contains(full, `"license_text"`) || !strings.Contains(full, `"gomod_content"`) {
+		t.Errorf("package JSON lacks license text or go.mod: %s", full)
+	}
+
+	s.slimJSON = true
+	slim := serve(handler, "/api/example.com/db/gadgets").Body.String()
+	if strings.Contains(slim, `"license_text"`) || strings.Contains(slim, `"gomod_content"`) || !strings.Contains(slim, `"license":"Apache-2.0"`) {
+		t.Errorf("slim package JSON = %s, want the license name without its text or go.mod", slim)
+	}
+	if w := serve(handler, "/license/example.com/db/gadgets"); !strings.Contains(w.Body.String(), "Apache License, Version 2.0") {
+		t.Error("slim server dropped the license page text")
+	}
+}
+
+func TestHandler_AllSymbols(t *testing.T) {
+	_, handler := seededServer(t)
+
+	tests := []struct {
+		target      string
+		wantResults int
+		wantBody    string
+	}{
+		{"/all-symbols", 100, `href="/all-symbols?page=2"`},
+		{"/all-symbols?page=2", 21, `href="/all-symbols"`}, // 120 funcs and one type
+		{"/all-symbols?kind=type", 1, "Gadget"},
+		{"/all-symbols?kind=func&letter=G", 100, `href="/all-symbols?kind=func&amp;letter=G&amp;page=2"`},
+		{"/all-symbols?kind=func&letter=G&page=2", 20, "Page 2 of 2"},
+		{"/all-symbols?letter=N", 0, "No symbols on this page"},  // NewWidget is only loaded in memory
+		{"/all-symbols?letter=g&kind=bogus", 100, "Page 1 of 2"}, // invalid filters are ignored
+		{"/all-symbols?page=9", 0, "No symbols on this page"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := serve(handler, tt.target)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+			body := w.Body.String()
+			if got := strings.Count(body, `class="SymbolResult"`); got != tt.wantResults {
+				t.Errorf("results = %d, want %d", got, tt.wantResults)
+			}
+			if !strings.Contains(body, tt.wantBody) {
+				t.Errorf("body does not contain %q", tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandler_AllSymbolsWithoutDatabase(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+	s.packages["example.com/mem/widgets"] = &PackageDoc{
+		ImportPath: "example.com/mem/widgets",
+		Name:       "widgets",
+		Constants:  []Constant{{Names: []string{"MaxWidgets", "MinWidgets"}}},
+		Functions:  []Function{{Name: "NewWidget"}},
+		Types: []Type{{
+			Name:      "Widget",
+			Functions: []Function{{Name: "MakeWidget"}},
+			Methods:   []Function{{Name: "Spin"}},
+		}},
+	}
+
+	handler, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+
+	tests := []struct {
+		target string
+		want   []string
+	}{
+		{"/all-symbols", []string{"MakeWidget", "MaxWidgets", "MinWidgets", "NewWidget", "Widget", "Widget.Spin"}},
+		{"/all-symbols?letter=M", []string{"MakeWidget", "MaxWidgets", "MinWidgets"}},
+		{"/all-symbols?kind=func", []string{"MakeWidget", "NewWidget"}},
+		{"/all-symbols?kind=method&letter=W", []string{"Widget.Spin"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			body := serve(handler, tt.target).Body.String()
+			var got []string
+			for _, part := range strings.Split(body, `class="SymbolResult-name">`)[1:] {
+				got = append(got, part[:strings.Index(part, "<")])
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("symbols = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_DatabaseOnly(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	// Populate the database the way the crawler would, then serve it without a data directory
+	indexer, err := NewServerWithDB(t.TempDir(), dbPath)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	err = indexer.IndexPackage(&PackageDoc{
+		ImportPath: "example.com/crawled/rotor",
+		Name:       "rotor",
+		Synopsis:   "Package rotor spins rotors.",
+		Functions:  []Function{{Name: "Spin", Signature: "func Spin()"}},
+	})
+	if err != nil {
+		t.Fatalf("IndexPackage() error = %v", err)
+	}
+	indexer.Close()
+
+	s, err := NewServerWithOptions(Options{DBPath: dbPath})
+	if err != nil {
+		t.Fatalf("NewServerWithOptions() error = %v", err)
+	}
+	defer s.Close()
+	if len(s.packages) != 0 {
+		t.Errorf("loaded %d packages from JSON files, want none", len(s.packages))
+	}
+	handler, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"/", "example.com/crawled/rotor"},
+		{"/example.com/crawled/rotor", `id="Spin"`},
+		{"/api/packages", `"import_path":"example.com/crawled/rotor"`},
+		{"/api/example.com/crawled/rotor", `"name":"rotor"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := serve(handler, tt.target)
+			if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("status = %d, body does not contain %q", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_AllPackages(t *testing.T) {
+	_, handler := seededServer(t)
+
+	tests := []struct {
+		target      string
+		wantResults int
+		wantBody    string
+	}{
+		{"/packages", 50, `href="/packages?page=2"`},
+		{"/packages?page=2", 11, `href="/packages"`}, // gadgets and 60 sprockets
+		{"/packages?letter=e", 50, `href="/packages?letter=e&amp;page=2"`},
+		{"/packages?letter=e&page=2", 11, "example.com/db/sprocket59"},
+		{"/packages?letter=h", 0, "No packages on this page"},
+		{"/packages?letter=E", 50, "Page 1 of 2"}, // invalid letters are ignored
+		{"/packages?page=9", 0, "No packages on this page"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := serve(handler, tt.target)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+			body := w.Body.String()
+			if got := strings.Count(body, `class="PackageResult"`); got != tt.wantResults {
+				t.Errorf("results = %d, want %d", got, tt.wantResults)
+			}
+			if !strings.Contains(body, tt.wantBody) {
+				t.Errorf("body does not contain %q", tt.wantBody)
+			}
+		})
+	}
+
+	if body := serve(handler, "/").Body.String(); !strings.Contains(body, `href="/packages"`) {
+		t.Error("home page does not link to the package browse")
+	}
+}
+
+func TestHandler_HomeRecentlyIndexed(t *testing.T) {
+	s, handler := seededServer(t)
+
+	body := serve(handler, "/").Body.String()
+	if !strings.Contains(body, "New Go Packages This Week") || strings.Contains(body, "New Rust Crates This Week") {
+		t.Fatalf("home page rails: want only the Go one, got Go %v, Rust %v",
+			strings.Contains(body, "New Go Packages This Week"), strings.Contains(body, "New Rust Crates This Week"))
+	}
+	_, rail, _ := strings.Cut(body, "PackageGrid-list--rail")
+	rail, _, _ = strings.Cut(rail, "PackageGrid-title")
+	if n := strings.Count(rail, `class="PackageCard"`); n != 8 {
+		t.Errorf("Go rail has %d packages, want 8", n)
+	}
+	if !strings.Contains(rail, "indexed just now") {
+		t.Error("Go rail does not say when packages were indexed")
+	}
+	if strings.Contains(rail, "example.com/mem/widgets") {
+		t.Error("Go rail lists a package that is not in the database")
+	}
+
+	if _, err := s.db.UpsertRustCrate(&db.RustCrate{Name: "fresh", Version: "0.1.0"}); err != nil {
+		t.Fatalf("UpsertRustCrate() error = %v", err)
+	}
+	body = serve(handler, "/").Body.String()
+	if !strings.Contains(body, "New Rust Crates This Week") || !strings.Contains(body, `href="/crates/fresh"`) {
+		t.Error("home page has no Rust rail with the newly indexed crate")
+	}
+}
+
+func TestHandler_DeprecatedPackages(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+	// The deprecated package is indexed first, so only deprecation ranks it last
+	for _, pkg := range []*PackageDoc{
+		{
+			ImportPath: "example.com/old/yaml", Name: "yaml", Synopsis: "Package yaml parses YAML.",
+			Doc: "Package yaml parses YAML.\n\nDeprecated: Use example.com/new/yaml\ninstead.\n", Deprecated: true,
+		},
+		{ImportPath: "example.com/new/yaml", Name: "yaml", Synopsis: "Package yaml parses YAML, faster.", Doc: "Package yaml parses YAML, faster.\n"},
+	} {
+		if err := s.IndexPackage(pkg); err != nil {
+			t.Fatalf("IndexPackage(%s) error = %v", pkg.ImportPath, err)
+		}
+	}
+	handler, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+
+	body := serve(handler, "/search?q=yaml").Body.String()
+	if strings.Count(body, `class="DeprecatedBadge"`) != 1 {
+		t.Errorf("search should badge the deprecated package:\n%s", body)
+	}
+	if active, old := strings.Index(body, `href="/example.com/new/yaml"`), strings.Index(body, `href="/example.com/old/yaml"`); active < 0 || old < active {
+		t.Errorf("search lists the deprecated package at %d, before the active one at %d", old, active)
+	}
+
+	var results []map[string]interface{}
+	if err := json.Unmarshal(serve(handler, "/api/search?q=yaml").Body.Bytes(), &results); err != nil {
+		t.Fatalf("decoding API search: %v", err)
+	}
+	if len(results) != 2 || results[0]["import_path"] != "example.com/new/yaml" || results[1]["deprecated"] != true {
+		t.Errorf("API search = %v, want the active package first", results)
+	}
+
+	body = serve(handler, "/example.com/old/yaml").Body.String()
+	if !strings.Contains(body, `class="Package-deprecated"`) || !strings.Contains(body, "Use example.com/new/yaml instead.") {
+		t.Error("deprecated package page has no banner with the notice")
+	}
+	if body := serve(handler, "/example.com/new/yaml").Body.String(); strings.Contains(body, `class="Package-deprecated"`) {
+		t.Error("active package page has a deprecation banner")
+	}
+}
+
+func TestHandler_ExperimentalPackages(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+	for _, pkg := range []*PackageDoc{
+		{ImportPath: "golang.org/x/exp/slices", Name: "slices", Synopsis: "Package slices defines generic slice functions.", Experimental: true},
+		{ImportPath: "example.com/slices", Name: "slices", Synopsis: "Package slices sorts slices."},
+	} {
+		if err := s.IndexPackage(pkg); err != nil {
+			t.Fatalf("IndexPackage(%s) error = %v", pkg.ImportPath, err)
+		}
+	}
+	handler, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+
+	body := serve(handler, "/search?q=slices").Body.String()
+	if strings.Count(body, `class="SearchResult"`) != 2 || strings.Count(body, `class="ExperimentalBadge"`) != 1 {
+		t.Errorf("search should list both packages, one badged experimental:\n%s", body)
+	}
+	if !strings.Contains(body, `href="/search?experimental=exclude&amp;q=slices"`) {
+		t.Error("search does not link to excluding experimental packages")
+	}
+
+	body = serve(handler, "/search?q=slices&experimental=exclude").Body.String()
+	if strings.Count(body, `class="SearchResult"`) != 1 || strings.Contains(body, "golang.org/x/exp/slices") {
+		t.Errorf("search with experimental=exclude should only list example.com/slices:\n%s", body)
+	}
+
+	var results []map[string]interface{}
+	if err := json.Unmarshal(serve(handler, "/api/search?q=slices&experimental=exclude").Body.Bytes(), &results); err != nil {
+		t.Fatalf("decoding API search: %v", err)
+	}
+	if len(results) != 1 || results[0]["import_path"] != "example.com/slices" || results[0]["experimental"] != false {
+		t.Errorf("API search with experimental=exclude = %v, want only example.com/slices", results)
+	}
+
+	// The experimental package is indexed first, so it must be left out before the limit applies
+	w := serve(handler, "/api/search?q=slices&lang=go&limit=1&experimental=exclude", "Accept", "application/x-ndjson")
+	if body := w.Body.String(); strings.Count(body, "\n") != 1 || !strings.Contains(body, `"example.com/slices"`) {
+		t.Errorf("streamed search with experimental=exclude = %q, want only example.com/slices", body)
+	}
+
+	if body := serve(handler, "/golang.org/x/exp/slices").Body.String(); !strings.Contains(body, `class="ExperimentalBadge"`) {
+		t.Error("experimental package page has no badge")
+	}
+	if body := serve(handler, "/example.com/slices").Body.String(); strings.Contains(body, `class="ExperimentalBadge"`) {
+		t.Error("stable package page has an experimental badge")
+	}
+}
@@ -0,0 +1,148 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxSymbols is how many symbols each section of a package page shows
+// before a "Show all" button loads the rest
+const defaultMaxSymbols = 100
+
+// pageSymbols are the constants, variables, functions and types rendered in
+// the sections of a package page: at most maxSymbols of each, the rest being
+// loaded on demand from /api/<path>/symbols
+type pageSymbols struct {
+	Constants []Constant
+	Variables []Variable
+	Functions []Function
+	Types     []Type
+	More      map[string]int // symbols left out, by section
+}
+
+// pageSymbols caps each section of a package page at the server's maxSymbols
+func (s *Server) pageSymbols(pkg *PackageDoc) pageSymbols {
+	shown := pageSymbols{More: make(map[string]int)}
+	shown.Constants = capSymbols(pkg.Constants, s.maxSymbols, shown.More, "constants")
+	shown.Variables = capSymbols(pkg.Variables, s.maxSymbols, shown.More, "variables")
+	shown.Functions = capSymbols(pkg.Functions, s.maxSymbols, shown.More, "functions")
+	shown.Types = capSymbols(pkg.Types, s.maxSymbols, shown.More, "types")
+	return shown
+}
+
+// capSymbols returns the first limit symbols of a section, recording how many
+// were left out; a negative limit keeps them all
+func capSymbols[T any](symbols []T, limit int, more map[string]int, section string) []T {
+	if limit < 0 || len(symbols) <= limit {
+		return symbols
+	}
+	more[section] = len(symbols) - limit
+	return symbols[:limit]
+}
+
+// symbolItem is a function or type rendered by the "function" and "type"
+// templates, with the package page it is on
+type symbolItem struct {
+	Page *packagePage
+	Item interface{}
+}
+
+// symbolsFragment is the data of the "symbolsFragment" template: the symbols
+// of one section of a package page
+type symbolsFragment struct {
+	Page      *packagePage
+	Constants []Constant
+	Variables []Variable
+	Functions []Function
+	Types     []Type
+}
+
+// isSymbolsRequest reports whether an /api/ path asks for the symbols of a
+// package page section rather than for a package that ends in /symbols
+func isSymbolsRequest(r *http.Request, path string) bool {
+	return strings.HasSuffix(path, "/symbols") && r.URL.Query().Has("section")
+}
+
+// handleSymbols serves /api/<path>/symbols?section=functions&offset=100, the
+// symbols of a package page section from offset on, rendered as on the page:
+// the "Show all" buttons insert its html in place of themselves. ?limit= caps
+// the number of symbols returned.
+func (s *Server) handleSymbols(w http.ResponseWriter, r *http.Request, importPath string) {
+	w.Header().Set("Content-Type", "application/json")
+	pkg, ok := s.FindPackage(importPath)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "package not found"})
+		return
+	}
+
+	query := r.URL.Query()
+	offset, err := strconv.Atoi(query.Get("offset"))
+	if query.Get("offset") == "" {
+		offset, err = 0, nil
+	}
+	if err != nil || offset < 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid offset"})
+		return
+	}
+	limit := -1
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	var fragment symbolsFragment
+	var total int
+	switch section := query.Get("section"); section {
+	case "constants":
+		total = len(pkg.Constants)
+		fragment.Constants = symbolsFrom(pkg.Constants, offset, limit)
+	case "variables":
+		total = len(pkg.Variables)
+		fragment.Variables = symbolsFrom(pkg.Variables, offset, limit)
+	case "functions":
+		total = len(pkg.Functions)
+		fragment.Functions = symbolsFrom(pkg.Functions, offset, limit)
+	case "types":
+		total = len(pkg.Types)
+		fragment.Types = symbolsFrom(pkg.Types, offset, limit)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "section must be constants, variables, functions or types"})
+		return
+	}
+	fragment.Page = s.symbolPage(pkg, fragment.Types)
+
+	var buf bytes.Buffer
+	if err := s.templates.ExecuteTemplate(&buf, "symbolsFragment", fragment); err != nil {
+		log.Printf("Error rendering symbols: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "rendering failed"})
+		return
+	}
+	count := len(fragment.Constants) + len(fragment.Variables) + len(fragment.Functions) + len(fragment.Types)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"section": query.Get("section"),
+		"offset":  offset,
+		"count":   count,
+		"total":   total,
+		"html":    buf.String(),
+	})
+}
+
+// symbolsFrom returns up to limit symbols from offset on, all of them for a
+// negative limit
+func symbolsFrom[T any](symbols []T, offset, limit int) []T {
+	if offset >= len(symbols) {
+		return nil
+	}
+	symbols = symbols[offset:]
+	if limit >= 0 && len(symbols) > limit {
+		symbols = symbols[:limit]
+	}
+	return symbols
+}
@@ -0,0 +1,167 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ModulePackage is a row of the module overview: a package with the signals
+// that tell how well it is documented
+type ModulePackage struct {
+	ImportPath  string `json:"import_path"`
+	Name        string `json:"name"`
+	Synopsis    string `json:"synopsis"`
+	Symbols     int    `json:"symbols"`
+	Deprecated  int    `json:"deprecated"`
+	Documented  int    `json:"documentable"`
+	WithExample int    `json:"with_example"`
+}
+
+// ExampleCoverage returns the percentage of documentable symbols with an example
+func (p ModulePackage) ExampleCoverage() int {
+	if p.Documented == 0 {
+		return 0
+	}
+	return p.WithExample * 100 / p.Documented
+}
+
+// handleModulePackages lists the packages of a module at /module/<module-path>
+func (s *Server) handleModulePackages(w http.ResponseWriter, r *http.Request) {
+	modulePath := strings.Trim(strings.TrimPrefix(r.URL.Path, "/module/"), "/")
+	if modulePath == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	packages, err := s.modulePackages(modulePath)
+	if err != nil {
+		log.Printf("Error listing packages of module %s: %v", modulePath, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if len(packages) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	total := ModulePackage{ImportPath: modulePath}
+	for _, p := range packages {
+		total.Symbols += p.Symbols
+		total.Deprecated += p.Deprecated
+		total.Documented += p.Documented
+		total.WithExample += p.WithExample
+	}
+
+	if prefersJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			ModulePath string          `json:"module_path"`
+			Packages   []ModulePackage `json:"packages"`
+		}{modulePath, packages})
+		return
+	}
+
+	data := struct {
+		Title       string
+		SearchQuery string
+		Pkg         *PackageDoc
+		ModulePath  string
+		Packages    []ModulePackage
+		Total       ModulePackage
+	}{
+		Title:       "Packages - " + modulePath + " - Go Packages",
+		SearchQuery: "",
+		Pkg:         nil,
+		ModulePath:  modulePath,
+		Packages:    packages,
+		Total:       total,
+	}
+
+	if err := s.templates.ExecuteTemplate(w, "module_packages.html", data); err != nil {
+		log.Printf("Error rendering module packages: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// modulePackages returns the indexed packages of a module ordered by import
+// path, from the database when available
+func (s *Server) modulePackages(modulePath string) ([]ModulePackage, error) {
+	if s.db != nil {
+		dbPackages, err := s.db.PackagesByModule(modulePath)
+		if err != nil {
+			return nil, err
+		}
+		quality, err := s.db.ModulePackageQuality(modulePath)
+		if err != nil {
+			return nil, err
+		}
+		var packages []ModulePackage
+		for _, pkg := range dbPackages {
+			p := ModulePackage{ImportPath: pkg.ImportPath, Name: pkg.Name, Synopsis: pkg.Synopsis}
+			if q, ok := quality[pkg.ImportPath]; ok {
+				p.Symbols, p.Deprecated = q.Symbols, q.Deprecated
+				p.Documented, p.WithExample = q.Documented, q.WithExample
+			}
+			packages = append(packages, p)
+		}
+		return packages, nil
+	}
+
+	var packages []ModulePackage
+	for _, pkg := range s.packages {
+		if pkg.ModulePath == modulePath {
+			packages = append(packages, packageQuality(pkg))
+		}
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].ImportPath < packages[j].ImportPath
+	})
+	return packages, nil
+}
+
+// packageQuality counts the symbols of a package the way they are indexed
+func packageQuality(pkg *PackageDoc) ModulePackage {
+	p := ModulePackage{ImportPath: pkg.ImportPath, Name: pkg.Name, Synopsis: pkg.Synopsis}
+	documented := func(deprecated bool, examples []Example) {
+		p.Symbols++
+		p.Documented++
+		if deprecated {
+			p.Deprecated++
+		}
+		if len(examples) > 0 {
+			p.WithExample++
+		}
+	}
+	values := func(names []string, deprecated bool, deprecatedNames []string) {
+		for _, name := range names {
+			p.Symbols++
+			if deprecated || slices.Contains(deprecatedNames, name) {
+				p.Deprecated++
+			}
+		}
+	}
+
+	for _, fn := range pkg.Functions {
+		documented(fn.Deprecated, fn.Examples)
+	}
+	for _, t := range pkg.Types {
+		documented(t.Deprecated, t.Examples)
+		for _, fn := range t.Functions {
+			documented(fn.Deprecated, fn.Examples)
+		}
+		for _, m := range t.Methods {
+			documented(m.Deprecated, m.Examples)
+		}
+	}
+	for _, c := range pkg.Constants {
+		values(c.Names, c.Deprecated, c.DeprecatedNames)
+	}
+	for _, v := range pkg.Variables {
+		values(v.Names, v.Deprecated, v.DeprecatedNames)
+	}
+	return p
+}
@@ -0,0 +1,132 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHandler_ModulePackages(t *testing.T) {
+	modulePackages := func() []*PackageDoc {
+		return []*PackageDoc{
+			{
+				ImportPath: "example.com/kit",
+				Name:       "kit",
+				Synopsis:   "Package kit is a toolkit.",
+				ModulePath: "example.com/kit",
+				Functions: []Function{
+					{Name: "Run", Signature: "func Run()", Examples: []Example{{Name: "Run", Code: "Run()"}}},
+					{Name: "Start", Signature: "func Start()", Deprecated: true},
+				},
+				Constants: []Constant{{Names: []string{"A", "B"}, DeprecatedNames: []string{"B"}}},
+			},
+			{
+				ImportPath: "example.com/kit/sub",
+				Name:       "sub",
+				Synopsis:   "Package sub has helpers.",
+				ModulePath: "example.com/kit",
+				Types:      []Type{{Name: "Helper", Decl: "type Helper struct{}"}},
+			},
+			{ImportPath: "example.com/kitchen", Name: "kitchen", ModulePath: "example.com/kitchen"},
+		}
+	}
+
+	s, err := NewServerWithDB(t.TempDir(), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+	for _, pkg := range modulePackages() {
+		if err := s.IndexPackage(pkg); err != nil {
+			t.Fatalf("IndexPackage() error = %v", err)
+		}
+	}
+	dbHandler, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+
+	memServer, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	for _, pkg := range modulePackages() {
+		memServer.packages[pkg.ImportPath] = pkg
+	}
+	memHandler, err := memServer.Handler()
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+
+	for name, handler := range map[string]http.Handler{"database": dbHandler, "memory": memHandler} {
+		w := serve(handler, "/module/example.com/kit")
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want %d", name, w.Code, http.StatusOK)
+		}
+		body := w.Body.String()
+		for _, want := range []string{`href="/example.com/kit/sub"`, "Package kit is a toolkit.", "50%"} {
+			if !strings.Contains(body, want) {
+				t.Errorf("%s: page does not contain %q", name, want)
+			}
+		}
+		if strings.Contains(body, "example.com/kitchen") {
+			t.Errorf("%s: page lists a package of another module", name)
+		}
+
+		w = serve(handler, "/module/example.com/kit", "Accept", "application/json")
+		var resp struct {
+			Packages []ModulePackage `json:"packages"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: decoding JSON: %v", name, err)
+		}
+		want := []ModulePackage{
+			{ImportPath: "example.com/kit", Name: "kit", Synopsis: "Package kit is a toolkit.", Symbols: 4, Deprecated: 2, Documented: 2, WithExample: 1},
+			{ImportPath: "example.com/kit/sub", Name: "sub", Synopsis: "Package sub has helpers.", Symbols: 1, Documented: 1},
+		}
+		if len(resp.Packages) != len(want) {
+			t.Fatalf("%s: packages = %+v, want %+v", name, resp.Packages, want)
+		}
+		for i := range want {
+			if resp.Packages[i] != want[i] {
+				t.Errorf("%s: packages[%d] = %+v, want %+v", name, i, resp.Packages[i], want[i])
+			}
+		}
+
+		if w := serve(handler, "/module/example.com/missing"); w.Code != http.StatusNotFound {
+			t.Errorf("%s: unknown module status = %d, want %d", name, w.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestGetSubdirectories_DatabaseOnly(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	parent := &PackageDoc{ImportPath: "example.com/kit", Name: "kit", ModulePath: "example.com/kit"}
+	for _, pkg := range []*PackageDoc{
+		{ImportPath: "example.com/kit/sub", Name: "sub", Synopsis: "Package sub has helpers.", ModulePath: "example.com/kit"},
+		{ImportPath: "example.com/kit/sub/deep", Name: "deep", ModulePath: "example.com/kit"},
+		{ImportPath: "example.com/kit/other", Name: "other", ModulePath: "example.com/kit/other"},
+	} {
+		if err := s.IndexPackage(pkg); err != nil {
+			t.Fatalf("IndexPackage() error = %v", err)
+		}
+	}
+	s.packages["example.com/kit/mem"] = &PackageDoc{ImportPath: "example.com/kit/mem", Name: "mem"}
+
+	subdirs := s.getSubdirectories(parent)
+	want := []Subdirectory{
+		{Name: "mem", Path: "example.com/kit/mem"},
+		{Name: "sub", Path: "example.com/kit/sub", Synopsis: "Package sub has helpers."},
+	}
+	if !slices.Equal(subdirs, want) {
+		t.Errorf("getSubdirectories() = %+v, want %+v", subdirs, want)
+	}
+}
@@ -0,0 +1,41 @@
+package web
+
+import "strings"
+
+// Diffs and comparisons have permalinks of the form
+// /diff/<import-path>/<v1>...<v2> and /compare/<pkg1>...<pkg2>, which the
+// handlers accept alongside their query parameters.
+
+// diffPermalink returns the permalink of the diff between two versions of a package
+func diffPermalink(importPath, v1, v2 string) string {
+	return "/diff/" + importPath + "/" + v1 + "..." + v2
+}
+
+// parseDiffPermalink splits the path of a diff permalink, without the /diff/
+// prefix, into the import path and the two versions
+func parseDiffPermalink(path string) (importPath, v1, v2 string, ok bool) {
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return "", "", "", false
+	}
+	v1, v2, ok = strings.Cut(path[i+1:], "...")
+	if !ok || v1 == "" || v2 == "" {
+		return "", "", "", false
+	}
+	return path[:i], v1, v2, true
+}
+
+// comparePermalink returns the permalink of the comparison of two packages
+func comparePermalink(pkg1, pkg2 string) string {
+	return "/compare/" + pkg1 + "..." + pkg2
+}
+
+// parseComparePermalink splits the path of a compare permalink, without the
+// /compare/ prefix, into the two package paths
+func parseComparePermalink(path string) (pkg1, pkg2 string, ok bool) {
+	pkg1, pkg2, ok = strings.Cut(path, "...")
+	if !ok || pkg1 == "" || pkg2 == "" {
+		return "", "", false
+	}
+	return pkg1, pkg2, true
+}
@@ -0,0 +1,99 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// RawDoc is the unrendered doc comment of a package and its symbols
+type RawDoc struct {
+	ImportPath string         `json:"import_path"`
+	Doc        string         `json:"doc"`
+	Symbols    []RawSymbolDoc `json:"symbols"`
+}
+
+// RawSymbolDoc is the unrendered doc comment of a single declaration.
+// Methods are named "Type.Method"; grouped constants and variables share one entry.
+type RawSymbolDoc struct {
+	Kind string `json:"kind"` // "const", "var", "func", "type", or "method"
+	Name string `json:"name"`
+	Doc  string `json:"doc"`
+}
+
+// rawDoc collects doc comments in the order they appear on the package page
+func rawDoc(pkg *PackageDoc) RawDoc {
+	raw := RawDoc{ImportPath: pkg.ImportPath, Doc: pkg.Doc, Symbols: []RawSymbolDoc{}}
+	add := func(kind, name, doc string) {
+		raw.Symbols = append(raw.Symbols, RawSymbolDoc{Kind: kind, Name: name, Doc: doc})
+	}
+
+	for _, c := range pkg.Constants {
+		add("const", strings.Join(c.Names, ", "), c.Doc)
+	}
+	for _, v := range pkg.Variables {
+		add("var", strings.Join(v.Names, ", "), v.Doc)
+	}
+	for _, f := range pkg.Functions {
+		add("func", f.Name, f.Doc)
+	}
+	for _, t := range pkg.Types {
+		add("type", t.Name, t.Doc)
+		for _, c := range t.Constants {
+			add("const", strings.Join(c.Names, ", "), c.Doc)
+		}
+		for _, v := range t.Variables {
+			add("var", strings.Join(v.Names, ", "), v.Doc)
+		}
+		for _, f := range t.Functions {
+			add("func", f.Name, f.Doc)
+		}
+		for _, m := range t.Methods {
+			add("method", t.Name+"."+m.Name, m.Doc)
+		}
+	}
+	return raw
+}
+
+// rawDocText renders doc comments as plain text, one "kind name" header per symbol
+func rawDocText(raw RawDoc) string {
+	var b strings.Builder
+	b.WriteString("package " + raw.ImportPath + "\n")
+	if raw.Doc != "" {
+		b.WriteString("\n" + strings.TrimRight(raw.Doc, "\n") + "\n")
+	}
+	for _, sym := range raw.Symbols {
+		b.WriteString("\n" + sym.Kind + " " + sym.Name + "\n")
+		if sym.Doc != "" {
+			b.WriteString(strings.TrimRight(sym.Doc, "\n") + "\n")
+		}
+	}
+	return b.String()
+}
+
+// handleRawDoc serves a package's doc comments exactly as written in the source:
+// /raw-doc/<import-path> as plain text, or as JSON with ?format=json or Accept: application/json
+func (s *Server) handleRawDoc(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/raw-doc/"), "/")
+	if path == "" {
+		http.Error(w, "Usage: /raw-doc/<import-path>", http.StatusBadRequest)
+		return
+	}
+
+	pkg, ok := s.FindPackage(path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	raw := rawDoc(pkg)
+
+	w.Header().Add("Vary", "Accept")
+	if r.URL.Query().Get("format") == "json" || prefersJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(raw)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(rawDocText(raw)))
+}
@@ -0,0 +1,69 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleRawDoc(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	s.packages["example.com/strutil"] = &PackageDoc{
+		ImportPath: "example.com/strutil",
+		Name:       "strutil",
+		Doc:        "Package strutil has [string] helpers.\n\n\tcode block\n",
+		Constants:  []Constant{{Names: []string{"A", "B"}, Doc: "A and B are letters.\n"}},
+		Functions:  []Function{{Name: "Reverse", Doc: "Reverse returns s reversed.\n"}},
+		Types: []Type{{
+			Name:    "Builder",
+			Doc:     "A Builder builds <strings>.\n",
+			Methods: []Function{{Name: "Len", Doc: "Len returns the length.\n"}},
+		}},
+	}
+
+	t.Run("text", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.handleRawDoc(w, httptest.NewRequest("GET", "/raw-doc/example.com/strutil", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", w.Code)
+		}
+		want := "package example.com/strutil\n\n" +
+			"Package strutil has [string] helpers.\n\n\tcode block\n\n" +
+			"const A, B\nA and B are letters.\n\n" +
+			"func Reverse\nReverse returns s reversed.\n\n" +
+			"type Builder\nA Builder builds <strings>.\n\n" +
+			"method Builder.Len\nLen returns the length.\n"
+		if got := w.Body.String(); got != want {
+			t.Errorf("body = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.handleRawDoc(w, httptest.NewRequest("GET", "/raw-doc/example.com/strutil?format=json", nil))
+		var raw RawDoc
+		if err := json.Unmarshal(w.Body.Bytes(), &raw); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		if raw.Doc != "Package strutil has [string] helpers.\n\n\tcode block\n" {
+			t.Errorf("doc = %q", raw.Doc)
+		}
+		if len(raw.Symbols) != 4 || raw.Symbols[3].Name != "Builder.Len" || raw.Symbols[3].Kind != "method" {
+			t.Errorf("symbols = %+v", raw.Symbols)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.handleRawDoc(w, httptest.NewRequest("GET", "/raw-doc/example.com/missing", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404", w.Code)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package web
+
+import (
+	"go/ast"
+	"go/parser"
+	"log"
+	"sort"
+
+	"github.com/alexisbouchez/wikigo/util"
+)
+
+// SymbolLink is a link to a symbol shown next to a type
+type SymbolLink struct {
+	Name string // Func, Type.Method, or pkg.Type for other packages
+	Link string
+}
+
+// RelatedSymbols are the symbols of a package and its module related to one
+// of its types, for quick navigation from the type's documentation
+type RelatedSymbols struct {
+	Constructors []SymbolLink
+	Methods      []SymbolLink
+	Accepting    []SymbolLink // functions and methods of other types taking the type
+	Returning    []SymbolLink // functions and methods of other types returning it
+	Implements   []SymbolLink // interfaces of the module the type satisfies
+}
+
+// Empty reports whether nothing is related to the type
+func (r RelatedSymbols) Empty() bool {
+	return len(r.Constructors)+len(r.Methods)+len(r.Accepting)+len(r.Returning)+len(r.Implements) == 0
+}
+
+// relatedSymbols cross-references a type with the functions of its package,
+// using their signatures, and with the interfaces of its module
+func (s *Server) relatedSymbols(pkg *PackageDoc, t *Type) RelatedSymbols {
+	var related RelatedSymbols
+	for _, fn := range t.Functions {
+		related.Constructors = append(related.Constructors, SymbolLink{Name: fn.Name, Link: "#" + fn.Name})
+	}
+	for _, m := range t.Methods {
+		related.Methods = append(related.Methods, SymbolLink{Name: t.Name + "." + m.Name, Link: "#" + t.Name + "." + m.Name})
+	}
+
+	add := func(sig string, link SymbolLink) {
+		shape, ok := util.SignatureShape(sig)
+		if !ok {
+			return
+		}
+		if mentionsAny(shape.Params, t.Name) {
+			related.Accepting = append(related.Accepting, link)
+		}
+		if mentionsAny(shape.Results, t.Name) {
+			related.Returning = append(related.Returning, link)
+		}
+	}
+	for _, fn := range pkg.Functions {
+		add(fn.Signature, SymbolLink{Name: fn.Name, Link: "#" + fn.Name})
+	}
+	for _, other := range pkg.Types {
+		if other.Name == t.Name {
+			continue
+		}
+		for _, fn := range other.Functions {
+			add(fn.Signature, SymbolLink{Name: fn.Name, Link: "#" + fn.Name})
+		}
+		for _, m := range other.Methods {
+			add(m.Signature, SymbolLink{Name: other.Name + "." + m.Name, Link: "#" + other.Name + "." + m.Name})
+		}
+	}
+
+	if !t.Interface && len(t.MethodSet) > 0 {
+		for _, p := range s.modulePackageDocs(pkg) {
+			for _, iface := range p.Types {
+				if !iface.Interface || len(iface.MethodSet) == 0 || iface.Name == t.Name && p == pkg {
+					continue
+				}
+				if _, ok := implementsMethodSet(t.MethodSet, iface.MethodSet); !ok {
+					continue
+				}
+				link := SymbolLink{Name: iface.Name, Link: "#" + iface.Name}
+				if p != pkg {
+					link = SymbolLink{Name: p.Name + "." + iface.Name, Link: "/" + p.ImportPath + "#" + iface.Name}
+				}
+				related.Implements = append(related.Implements, link)
+			}
+		}
+		sort.Slice(related.Implements, func(i, j int) bool {
+			return related.Implements[i].Link < related.Implements[j].Link
+		})
+	}
+	return related
+}
+
+// modulePackageDocs returns pkg followed by the other packages of its module,
+// loaded or indexed. Packages from the database only carry their types' method
+// sets, which is all matching types against interfaces needs.
+func (s *Server) modulePackageDocs(pkg *PackageDoc) []*PackageDoc {
+	packages := []*PackageDoc{pkg}
+	if pkg.ModulePath == "" {
+		return packages
+	}
+	seen := map[string]bool{pkg.ImportPath: true}
+	for _, p := range s.packages {
+		if p.ModulePath == pkg.ModulePath && !seen[p.ImportPath] {
+			packages = append(packages, p)
+			seen[p.ImportPath] = true
+		}
+	}
+	if s.db == nil {
+		return packages
+	}
+
+	types, err := s.db.GetModuleMethodSets(pkg.ModulePath)
+	if err != nil {
+		log.Printf("Error fetching method sets: %v", err)
+		return packages
+	}
+	indexed := make(map[string]*PackageDoc)
+	for _, t := range types {
+		if seen[t.ImportPath] {
+			continue
+		}
+		p := indexed[t.ImportPath]
+		if p == nil {
+			p = &PackageDoc{ImportPath: t.ImportPath, Name: t.Package, ModulePath: pkg.ModulePath}
+			indexed[t.ImportPath] = p
+			packages = append(packages, p)
+		}
+		p.Types = append(p.Types, Type{Name: t.Name, Interface: t.Interface, MethodSet: t.MethodSet})
+	}
+	return packages
+}
+
+// mentionsAny reports whether any of the type expressions refers to the
+// package's type name, unqualified, as in *T, []T, map[string]T or func(T)
+func mentionsAny(types []string, name string) bool {
+	for _, typ := range types {
+		expr, err := parser.ParseExpr(typ)
+		if err != nil {
+			continue
+		}
+		found := false
+		ast.Inspect(expr, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				return false // pkg.T names another package's type
+			case *ast.Ident:
+				found = found || n.Name == name
+			}
+			return !found
+		})
+		if found {
+			return true
+		}
+	}
+	return false
+}
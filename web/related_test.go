@@ -0,0 +1,78 @@
+package web
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRelatedSymbols(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	pkg := &PackageDoc{
+		ImportPath: "example.com/buf",
+		Name:       "buf",
+		ModulePath: "example.com/buf",
+		Functions: []Function{
+			{Name: "Copy", Signature: "func Copy(dst *Buffer, src io.Reader) error"},
+			{Name: "Split", Signature: "func Split(b Buffer, n int) []Buffer"},
+			{Name: "Wrap", Signature: "func Wrap(r other.Buffer) Reader"},
+		},
+		Types: []Type{
+			{
+				Name:      "Buffer",
+				MethodSet: []string{"*Len() int", "*Write([]byte) (int, error)"},
+				Functions: []Function{{Name: "NewBuffer", Signature: "func NewBuffer(data []byte) *Buffer"}},
+				Methods: []Function{
+					{Name: "Len", Signature: "func (b *Buffer) Len() int"},
+					{Name: "Write", Signature: "func (b *Buffer) Write(p []byte) (int, error)"},
+				},
+			},
+			{
+				Name:    "Reader",
+				Methods: []Function{{Name: "WriteTo", Signature: "func (r *Reader) WriteTo(w map[string]*Buffer) error"}},
+			},
+			{Name: "Writer", Interface: true, MethodSet: []string{"Write([]byte) (int, error)"}},
+			{Name: "Closer", Interface: true, MethodSet: []string{"Close() error"}},
+		},
+	}
+	sub := &PackageDoc{
+		ImportPath: "example.com/buf/sized",
+		Name:       "sized",
+		ModulePath: "example.com/buf",
+		Types:      []Type{{Name: "Lener", Interface: true, MethodSet: []string{"Len() int"}}},
+	}
+	for _, p := range []*PackageDoc{pkg, sub} {
+		s.packages[p.ImportPath] = p
+	}
+
+	got := s.relatedSymbols(pkg, &pkg.Types[0])
+	want := RelatedSymbols{
+		Constructors: []SymbolLink{{"NewBuffer", "#NewBuffer"}},
+		Methods:      []SymbolLink{{"Buffer.Len", "#Buffer.Len"}, {"Buffer.Write", "#Buffer.Write"}},
+		Accepting:    []SymbolLink{{"Copy", "#Copy"}, {"Split", "#Split"}, {"Reader.WriteTo", "#Reader.WriteTo"}},
+		Returning:    []SymbolLink{{"Split", "#Split"}},
+		Implements:   []SymbolLink{{"Writer", "#Writer"}, {"sized.Lener", "/example.com/buf/sized#Lener"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("relatedSymbols(Buffer) =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if r := s.relatedSymbols(pkg, &pkg.Types[3]); !r.Empty() {
+		t.Errorf("relatedSymbols(Closer) = %+v, want nothing", r)
+	}
+
+	w := httptest.NewRecorder()
+	s.renderPackage(w, httptest.NewRequest("GET", "/example.com/buf", nil), pkg)
+	body := w.Body.String()
+	for _, link := range []string{`href="#Reader.WriteTo"`, `href="/example.com/buf/sized#Lener"`} {
+		if !strings.Contains(body, link) {
+			t.Errorf("package page has no related link %s", link)
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package web
 
 import (
+	"math"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -13,6 +16,21 @@ type SearchResult struct {
 
 // calculateRelevanceScore calculates a relevance score for a search result
 func calculateRelevanceScore(query string, result map[string]interface{}) float64 {
+	score := textRelevanceScore(query, result)
+
+	// Popularity boost
+	if downloads, ok := result["downloads"].(int); ok && downloads > 0 {
+		score += popularityScore(downloads)
+	}
+	if stars, ok := result["stars"].(int); ok && stars > 0 {
+		score += popularityScore(stars * 10) // Stars weighted higher
+	}
+
+	return score
+}
+
+// textRelevanceScore scores how well a result's name, path and synopsis match the query
+func textRelevanceScore(query string, result map[string]interface{}) float64 {
 	query = strings.ToLower(query)
 	var score float64
 
@@ -58,15 +76,42 @@ func calculateRelevanceScore(query string, result map[string]interface{}) float6
 		score += float64(20 - len(name))
 	}
 
-	// Popularity boost
-	if downloads, ok := result["downloads"].(int); ok && downloads > 0 {
-		score += popularityScore(downloads)
-	}
-	if stars, ok := result["stars"].(int); ok && stars > 0 {
-		score += popularityScore(stars * 10) // Stars weighted higher
+	return score
+}
+
+// maxPopularityScore is the most popularity adds to a result's score
+const maxPopularityScore = 25
+
+// ecosystemPopularity is the popularity count that earns the full popularity
+// score in each ecosystem. Counts differ in kind and scale, so every result is
+// measured against its own ecosystem rather than against the others.
+var ecosystemPopularity = map[string]int{
+	"go":   1000,     // importing packages
+	"rust": 10000000, // crates.io downloads
+	"js":   10000,    // GitHub stars
+	"php":  10000000, // Packagist downloads
+}
+
+// normalizedPopularity scores a result's popularity on a log scale relative to
+// its ecosystem. Ecosystems without popularity data get half the maximum, so
+// their results are neither favoured nor buried.
+func normalizedPopularity(result map[string]interface{}) float64 {
+	full, ok := ecosystemPopularity[getString(result, "lang")]
+	if !ok {
+		return maxPopularityScore / 2
 	}
 
-	return score
+	var count int
+	for _, key := range []string{"imported_by", "downloads", "stars"} {
+		if n, ok := result[key].(int); ok {
+			count = n
+			break
+		}
+	}
+	if count <= 0 {
+		return 0
+	}
+	return maxPopularityScore * min(1, math.Log1p(float64(count))/math.Log1p(float64(full)))
 }
 
 // popularityScore converts raw popularity to a bounded score
@@ -130,3 +175,90 @@ func sortByRelevance(query string, results []map[string]interface{}) []map[strin
 
 	return sorted
 }
+
+// sortBySize orders results by unpacked size, smallest first. Results without
+// a known size keep their relevance order after the sized ones.
+func sortBySize(results []map[string]interface{}) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, _ := results[i]["unpacked_size"].(int64)
+		b, _ := results[j]["unpacked_size"].(int64)
+		if a == 0 || b == 0 {
+			return a != 0 && b == 0
+		}
+		return a < b
+	})
+}
+
+// deprecatedPenalty is taken off the score of deprecated packages: as much as
+// an exact name match earns, so that they rank below active alternatives
+const deprecatedPenalty = 1000
+
+// rankResults merges results from every ecosystem into one list ranked by text
+// relevance plus popularity normalized per ecosystem, drops duplicates and keeps
+// at most limit results (all when limit <= 0)
+func rankResults(query string, results []map[string]interface{}, limit int) []map[string]interface{} {
+	scored := make([]SearchResult, 0, len(results))
+	best := make(map[string]int) // dedupe key -> index in scored
+	for _, r := range results {
+		sr := SearchResult{Data: r, Score: textRelevanceScore(query, r) + normalizedPopularity(r)}
+		if deprecated, _ := r["deprecated"].(bool); deprecated {
+			sr.Score -= deprecatedPenalty
+		}
+		key, major := dedupeKey(r)
+		if i, ok := best[key]; ok {
+			// Keep the latest major version, then the better match
+			_, keptMajor := dedupeKey(scored[i].Data)
+			if major > keptMajor || major == keptMajor && sr.Score > scored[i].Score {
+				scored[i] = sr
+			}
+			continue
+		}
+		best[key] = len(scored)
+		scored = append(scored, sr)
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].Score > scored[j].Score
+	})
+	if limit > 0 && len(scored) > limit {
+		scored = scored[:limit]
+	}
+
+	ranked := make([]map[string]interface{}, len(scored))
+	for i, s := range scored {
+		ranked[i] = s.Data
+	}
+	return ranked
+}
+
+var (
+	// majorVersionElem matches the major version element of a Go import path
+	majorVersionElem = regexp.MustCompile(`^v([2-9]|[1-9][0-9]+)$`)
+	// pypiSeparators are the runs of characters PyPI treats as equivalent in names
+	pypiSeparators = regexp.MustCompile(`[-_.]+`)
+)
+
+// dedupeKey identifies results that are the same package within an ecosystem:
+// the major versions of a Go module, and PyPI names that normalize alike. It also
+// returns the Go major version, 1 for other results.
+func dedupeKey(result map[string]interface{}) (string, int) {
+	lang := getString(result, "lang")
+	path := getString(result, "import_path")
+
+	switch lang {
+	case "go":
+		major := 1
+		elems := strings.Split(path, "/")
+		for i := len(elems) - 1; i > 0; i-- {
+			if m := majorVersionElem.FindStringSubmatch(elems[i]); m != nil {
+				major, _ = strconv.Atoi(m[1])
+				elems = append(elems[:i:i], elems[i+1:]...)
+				break
+			}
+		}
+		return lang + ":" + strings.Join(elems, "/"), major
+	case "python":
+		return lang + ":" + pypiSeparators.ReplaceAllString(strings.ToLower(path), "-"), 1
+	}
+	return lang + ":" + path, 1
+}
@@ -1,6 +1,7 @@
 package web
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -113,6 +114,24 @@ func TestSortByRelevance(t *testing.T) {
 	}
 }
 
+func TestSortBySize(t *testing.T) {
+	results := []map[string]interface{}{
+		{"name": "lodash", "unpacked_size": int64(1400000)},
+		{"name": "unknown"},
+		{"name": "lodash.get", "unpacked_size": int64(9000)},
+		{"name": "just-safe-get", "unpacked_size": int64(3000)},
+	}
+
+	sortBySize(results)
+
+	want := []string{"just-safe-get", "lodash.get", "lodash", "unknown"}
+	for i, name := range want {
+		if got := getString(results[i], "name"); got != name {
+			t.Errorf("position %d: got %s, want %s", i, got, name)
+		}
+	}
+}
+
 func TestSortByRelevance_Empty(t *testing.T) {
 	results := []map[string]interface{}{}
 	sorted := sortByRelevance("test", results)
@@ -178,3 +197,103 @@ func TestGetString(t *testing.T) {
 		t.Error("nil value should return empty")
 	}
 }
+
+func TestRankResults(t *testing.T) {
+	results := []map[string]interface{}{
+		// Go first, as the handler appends it, with a marginal match
+		{"name": "httputil", "import_path": "example.com/x/httputil", "lang": "go", "imported_by": 2},
+		{"name": "http", "import_path": "example.com/web/http", "lang": "go", "imported_by": 0},
+		{"name": "http", "import_path": "example.com/web/v3/http", "lang": "go", "imported_by": 0},
+		{"name": "http", "import_path": "example.com/web/v2/http", "lang": "go", "imported_by": 500},
+		{"name": "http", "import_path": "crates.io/http", "lang": "rust", "downloads": 90000000},
+		{"name": "http", "import_path": "pypi/http", "lang": "python"},
+		{"name": "HTTP", "import_path": "pypi/HTTP", "lang": "python"},
+		{"name": "got", "import_path": "npm/got", "lang": "js", "synopsis": "Human-friendly HTTP request library", "stars": 14000},
+	}
+
+	ranked := rankResults("http", results, 0)
+	var got []string
+	for _, r := range ranked {
+		got = append(got, getString(r, "import_path"))
+	}
+	want := []string{
+		"crates.io/http",          // fully popular in its ecosystem
+		"pypi/http",               // no popularity data, half the bonus
+		"example.com/web/v3/http", // the latest major of three, though unpopular
+		"example.com/x/httputil",
+		"npm/got", // synopsis match only
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("rankResults() =\n%v\nwant\n%v", got, want)
+	}
+
+	if ranked := rankResults("http", results, 2); len(ranked) != 2 || getString(ranked[0], "import_path") != "crates.io/http" {
+		t.Errorf("rankResults() with limit 2 = %v", ranked)
+	}
+}
+
+func TestRankResults_Deprecated(t *testing.T) {
+	results := []map[string]interface{}{
+		{"name": "yaml", "import_path": "example.com/old/yaml", "lang": "go", "imported_by": 900, "deprecated": true},
+		{"name": "yamlx", "import_path": "example.com/yamlx", "lang": "go", "imported_by": 0},
+		{"name": "yaml", "import_path": "example.com/new/yaml", "lang": "go", "imported_by": 3},
+	}
+	ranked := rankResults("yaml", results, 0)
+	var got []string
+	for _, r := range ranked {
+		got = append(got, getString(r, "import_path"))
+	}
+	// Below an active package of the same name, not below every partial match
+	want := []string{"example.com/new/yaml", "example.com/old/yaml", "example.com/yamlx"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("rankResults() = %v, want %v", got, want)
+	}
+}
+
+func TestNormalizedPopularity(t *testing.T) {
+	tests := []struct {
+		result map[string]interface{}
+		want   float64
+	}{
+		{map[string]interface{}{"lang": "rust", "downloads": 10000000}, maxPopularityScore},
+		{map[string]interface{}{"lang": "rust", "downloads": 500000000}, maxPopularityScore},
+		{map[string]interface{}{"lang": "go", "imported_by": 1000}, maxPopularityScore},
+		{map[string]interface{}{"lang": "js", "stars": 0}, 0},
+		{map[string]interface{}{"lang": "php"}, 0},
+		{map[string]interface{}{"lang": "python"}, maxPopularityScore / 2},
+	}
+	for _, tt := range tests {
+		if got := normalizedPopularity(tt.result); got != tt.want {
+			t.Errorf("normalizedPopularity(%v) = %v, want %v", tt.result, got, tt.want)
+		}
+	}
+
+	// The same share of each ecosystem's scale scores the same
+	crate := normalizedPopularity(map[string]interface{}{"lang": "rust", "downloads": 3162})
+	npm := normalizedPopularity(map[string]interface{}{"lang": "js", "stars": 100})
+	if crate < npm-1 || crate > npm+1 {
+		t.Errorf("popularity halfway up each scale = %v (rust), %v (js), want about equal", crate, npm)
+	}
+}
+
+func TestDedupeKey(t *testing.T) {
+	tests := []struct {
+		lang, path string
+		wantKey    string
+		wantMajor  int
+	}{
+		{"go", "github.com/a/b", "go:github.com/a/b", 1},
+		{"go", "github.com/a/b/v2", "go:github.com/a/b", 2},
+		{"go", "github.com/a/b/v12/sub", "go:github.com/a/b/sub", 12},
+		{"go", "github.com/a/v1/b", "go:github.com/a/v1/b", 1},
+		{"go", "gopkg.in/yaml.v3", "go:gopkg.in/yaml.v3", 1},
+		{"python", "pypi/Foo_Bar.baz", "python:pypi/foo-bar-baz", 1},
+		{"rust", "crates.io/foo_bar", "rust:crates.io/foo_bar", 1},
+	}
+	for _, tt := range tests {
+		key, major := dedupeKey(map[string]interface{}{"lang": tt.lang, "import_path": tt.path})
+		if key != tt.wantKey || major != tt.wantMajor {
+			t.Errorf("dedupeKey(%s %s) = %q, %d, want %q, %d", tt.lang, tt.path, key, major, tt.wantKey, tt.wantMajor)
+		}
+	}
+}
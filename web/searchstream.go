@@ -0,0 +1,252 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexisbouchez/wikigo/db"
+)
+
+// ndjsonContentType is the media type of newline-delimited JSON
+const ndjsonContentType = "application/x-ndjson"
+
+const (
+	// maxStreamSearchLimit caps ?limit= when /api/search streams NDJSON, and
+	// is the limit without one: every match of most queries
+	maxStreamSearchLimit = 1_000_000
+	// streamFlushInterval is the number of results written between flushes
+	streamFlushInterval = 100
+)
+
+// wantsNDJSON reports whether the request's Accept header asks for
+// newline-delimited JSON
+func wantsNDJSON(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(mediaType), ndjsonContentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleSearchStream writes the matches of /api/search as newline-delimited
+// JSON, one result object per line in the shape of the JSON array. Go packages,
+// the bulk of the index, are written as they are read from the database.
+// Results come ecosystem by ecosystem in index order, without the ranking and
+// deduplication of the array, which need every result at once.
+func (s *Server) handleSearchStream(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	lang := r.URL.Query().Get("lang")
+	noExperimental := excludeExperimental(r)
+	limit := maxStreamSearchLimit
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = min(l, maxStreamSearchLimit)
+	}
+
+	w.Header().Set("Content-Type", ndjsonContentType)
+	if query == "" {
+		return
+	}
+
+	stream := &resultStream{enc: json.NewEncoder(w), remaining: limit, rc: http.NewResponseController(w), writeTimeout: s.timeouts.Write}
+	stream.flusher, _ = w.(http.Flusher)
+	stream.extendDeadline()
+	defer stream.flush()
+
+	if s.db == nil {
+		queryLower := strings.ToLower(query)
+		for _, pkg := range s.listPackages(0, 0) {
+			if pkg.Classification != "" || (noExperimental && pkg.Experimental) {
+				continue
+			}
+			if strings.Contains(strings.ToLower(pkg.ImportPath), queryLower) ||
+				strings.Contains(strings.ToLower(pkg.Name), queryLower) ||
+				strings.Contains(strings.ToLower(pkg.Synopsis), queryLower) {
+				if stream.write(map[string]interface{}{
+					"import_path":  pkg.ImportPath,
+					"name":         pkg.Name,
+					"synopsis":     pkg.Synopsis,
+					"lang":         "go",
+					"experimental": pkg.Experimental,
+					"deprecated":   pkg.Deprecated,
+				}) != nil {
+					return
+				}
+			}
+		}
+		return
+	}
+
+	// Once the response has started, errors can only end the stream early
+	if lang == "" || lang == "go" {
+		err := s.db.EachSearchPackage(query, stream.remaining, noExperimental, func(pkg *db.Package) error {
+			return stream.write(s.goSearchResult(pkg))
+		})
+		if err != nil {
+			log.Printf("Streaming search error in API: %v", err)
+			return
+		}
+	}
+	if (lang == "" || lang == "rust") && stream.remaining > 0 {
+		crates, err := s.db.SearchRustCrates(query, stream.remaining)
+		if err != nil {
+			log.Printf("Rust crate search error in API: %v", err)
+		}
+		for _, crate := range crates {
+			if stream.write(rustSearchResult(crate)) != nil {
+				return
+			}
+		}
+	}
+	if (lang == "" || lang == "js" || lang == "npm") && stream.remaining > 0 {
+		pkgs, err := s.db.SearchJSPackages(query, stream.remaining)
+		if err != nil {
+			log.Printf("JS package search error in API: %v", err)
+		}
+		for _, pkg := range pkgs {
+			if stream.write(jsSearchResult(pkg)) != nil {
+				return
+			}
+		}
+	}
+	if (lang == "" || lang == "python" || lang == "pypi") && stream.remaining > 0 {
+		pkgs, err := s.db.SearchPythonPackages(query, stream.remaining)
+		if err != nil {
+			log.Printf("Python package search error in API: %v", err)
+		}
+		for _, pkg := range pkgs {
+			if stream.write(pythonSearchResult(pkg)) != nil {
+				return
+			}
+		}
+	}
+	if (lang == "" || lang == "php" || lang == "packagist") && stream.remaining > 0 {
+		pkgs, err := s.db.SearchPHPPackages(query, stream.remaining)
+		if err != nil {
+			log.Printf("PHP package search error in API: %v", err)
+		}
+		for _, pkg := range pkgs {
+			if stream.write(phpSearchResult(pkg)) != nil {
+				return
+			}
+		}
+	}
+}
+
+// errStreamLimit stops a result stream once its limit is reached
+var errStreamLimit = errors.New("stream limit reached")
+
+// resultStream writes search results as NDJSON, flushing every few lines so
+// clients can process them while the rest are read
+type resultStream struct {
+	enc          *json.Encoder
+	flusher      http.Flusher
+	rc           *http.ResponseController
+	writeTimeout time.Duration // the server's WriteTimeout, zero for none
+	remaining    int
+	written      int
+}
+
+// write encodes a result on its own line. It fails once the limit is reached
+// or the client is gone, which ends the stream.
+func (st *resultStream) write(result map[string]interface{}) error {
+	if st.remaining <= 0 {
+		return errStreamLimit
+	}
+	if err := st.enc.Encode(result); err != nil {
+		return err
+	}
+	st.remaining--
+	st.written++
+	if st.written%streamFlushInterval == 0 {
+		st.flush()
+	}
+	return nil
+}
+
+func (st *resultStream) flush() {
+	if st.flusher != nil {
+		st.flusher.Flush()
+	}
+	st.extendDeadline()
+}
+
+// extendDeadline gives the stream another write timeout from now, so the
+// server's WriteTimeout bounds each batch of results rather than the whole
+// stream, which may run far longer
+func (st *resultStream) extendDeadline() {
+	if st.rc == nil || st.writeTimeout <= 0 {
+		return
+	}
+	if err := st.rc.SetWriteDeadline(time.Now().Add(st.writeTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("Extending search stream deadline: %v", err)
+	}
+}
+
+// goSearchResult is the /api/search entry of a Go package
+func (s *Server) goSearchResult(pkg *db.Package) map[string]interface{} {
+	return map[string]interface{}{
+		"import_path":  pkg.ImportPath,
+		"name":         pkg.Name,
+		"synopsis":     pkg.Synopsis,
+		"lang":         "go",
+		"imported_by":  s.GetImportedByCount(pkg.ImportPath),
+		"experimental": pkg.Experimental,
+		"deprecated":   pkg.Deprecated,
+	}
+}
+
+// rustSearchResult is the /api/search entry of a Rust crate
+func rustSearchResult(crate *db.RustCrate) map[string]interface{} {
+	return map[string]interface{}{
+		"import_path": "crates.io/" + crate.Name,
+		"name":        crate.Name,
+		"synopsis":    crate.Description,
+		"lang":        "rust",
+		"version":     crate.Version,
+		"downloads":   crate.Downloads,
+	}
+}
+
+// jsSearchResult is the /api/search entry of an npm package
+func jsSearchResult(pkg *db.JSPackage) map[string]interface{} {
+	return map[string]interface{}{
+		"import_path":   "npm/" + pkg.Name,
+		"name":          pkg.Name,
+		"synopsis":      pkg.Description,
+		"lang":          "js",
+		"version":       pkg.Version,
+		"stars":         pkg.Stars,
+		"unpacked_size": pkg.UnpackedSize,
+		"tarball_size":  pkg.TarballSize,
+	}
+}
+
+// pythonSearchResult is the /api/search entry of a PyPI package
+func pythonSearchResult(pkg *db.PythonPackage) map[string]interface{} {
+	return map[string]interface{}{
+		"import_path": "pypi/" + pkg.Name,
+		"name":        pkg.Name,
+		"synopsis":    pkg.Summary,
+		"lang":        "python",
+		"version":     pkg.Version,
+	}
+}
+
+// phpSearchResult is the /api/search entry of a Packagist package
+func phpSearchResult(pkg *db.PHPPackage) map[string]interface{} {
+	return map[string]interface{}{
+		"import_path": "packagist/" + pkg.Name,
+		"name":        pkg.Name,
+		"synopsis":    pkg.Description,
+		"lang":        "php",
+		"version":     pkg.Version,
+		"downloads":   pkg.Downloads,
+	}
+}
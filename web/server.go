@@ -1,21 +1,36 @@
 package web
 
 import (
+	"cmp"
 	"embed"
 	"encoding/json"
 	"fmt"
+	"go/build"
+	"go/doc/comment"
+	"go/token"
 	"html/template"
+	"io"
 	"io/fs"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"github.com/alexisbouchez/wikigo/ai"
 	"github.com/alexisbouchez/wikigo/db"
+	"github.com/alexisbouchez/wikigo/util"
+	"golang.org/x/mod/module"
 )
 
 //go:embed templates/*.html
@@ -45,6 +60,11 @@ type PackageDoc struct {
 	GoModContent     string     `json:"gomod_content,omitempty"`
 	GOOS             []string   `json:"goos,omitempty"`
 	GOARCH           []string   `json:"goarch,omitempty"`
+	Classification   string     `json:"classification,omitempty"` // "test-only" or "example-only"
+	Coverage         string     `json:"coverage,omitempty"`       // test coverage the module reports, e.g. "87.5%"
+	SynopsisSource   string     `json:"synopsis_source,omitempty"` // "readme" or "ai" when the synopsis is derived rather than from the package doc
+	Experimental     bool       `json:"experimental,omitempty"`    // under golang.org/x/exp or documented as experimental, alpha or beta
+	Deprecated       bool       `json:"deprecated,omitempty"`      // the package doc has a "Deprecated:" paragraph
 	Constants        []Constant `json:"constants"`
 	Variables        []Variable `json:"variables"`
 	Functions        []Function `json:"functions"`
@@ -52,6 +72,9 @@ type PackageDoc struct {
 	Examples         []Example  `json:"examples"`
 	Imports          []string   `json:"imports"`
 	Filenames        []string   `json:"filenames"`
+	Generate         []string   `json:"generate,omitempty"` // //go:generate commands
+	Sources          map[string]string `json:"sources,omitempty"` // file name to content, embedded by wikigo -include-source
+	IndexedAt        time.Time  `json:"-"` // when the package was indexed or its JSON file written, for Last-Modified
 }
 
 // Subdirectory represents a child package
@@ -63,43 +86,96 @@ type Subdirectory struct {
 
 // Constant represents a documented constant
 type Constant struct {
-	Names []string `json:"names"`
-	Doc   string   `json:"doc"`
-	Decl  string   `json:"decl"`
+	Names           []string `json:"names"`
+	Doc             string   `json:"doc"`
+	Decl            string   `json:"decl"`
+	Deprecated      bool     `json:"deprecated,omitempty"`
+	DeprecatedNames []string `json:"deprecated_names,omitempty"` // names deprecated individually within the group
 }
 
 // Variable represents a documented variable
 type Variable struct {
-	Names []string `json:"names"`
-	Doc   string   `json:"doc"`
-	Decl  string   `json:"decl"`
+	Names           []string `json:"names"`
+	Doc             string   `json:"doc"`
+	Decl            string   `json:"decl"`
+	Deprecated      bool     `json:"deprecated,omitempty"`
+	DeprecatedNames []string `json:"deprecated_names,omitempty"` // names deprecated individually within the group
 }
 
 // Function represents a documented function
 type Function struct {
-	Name       string    `json:"name"`
-	Doc        string    `json:"doc"`
-	Signature  string    `json:"signature"`
-	Recv       string    `json:"recv,omitempty"`
-	Filename   string    `json:"filename,omitempty"`
-	Line       int       `json:"line,omitempty"`
-	Deprecated bool      `json:"deprecated,omitempty"`
-	Examples   []Example `json:"examples,omitempty"`
+	Name       string          `json:"name"`
+	Doc        string          `json:"doc"`
+	Signature  string          `json:"signature"`
+	Recv       string          `json:"recv,omitempty"`
+	Filename   string          `json:"filename,omitempty"`
+	Line       int             `json:"line,omitempty"`
+	EndLine    int             `json:"end_line,omitempty"`
+	Deprecated bool            `json:"deprecated,omitempty"`
+	Params     []util.ParamDoc `json:"params,omitempty"`
+	Examples   []Example       `json:"examples,omitempty"`
 }
 
 // Type represents a documented type
 type Type struct {
-	Name       string     `json:"name"`
-	Doc        string     `json:"doc"`
-	Decl       string     `json:"decl"`
-	Filename   string     `json:"filename,omitempty"`
-	Line       int        `json:"line,omitempty"`
-	Deprecated bool       `json:"deprecated,omitempty"`
-	Constants  []Constant `json:"constants,omitempty"`
-	Variables  []Variable `json:"variables,omitempty"`
-	Functions  []Function `json:"funcs,omitempty"`
-	Methods    []Function `json:"methods,omitempty"`
-	Examples   []Example  `json:"examples,omitempty"`
+	Name       string           `json:"name"`
+	Doc        string           `json:"doc"`
+	Decl       string           `json:"decl"`
+	Filename   string           `json:"filename,omitempty"`
+	Line       int              `json:"line,omitempty"`
+	EndLine    int              `json:"end_line,omitempty"`
+	Deprecated bool             `json:"deprecated,omitempty"`
+	AliasOf    string           `json:"alias_of,omitempty"`
+	AliasPath  string           `json:"alias_path,omitempty"`
+	Interface  bool             `json:"interface,omitempty"`
+	MethodSet  []string         `json:"method_set,omitempty"` // normalized method signatures, "*" marks pointer receivers
+	Constants  []Constant       `json:"constants,omitempty"`
+	Variables  []Variable       `json:"variables,omitempty"`
+	Functions  []Function       `json:"funcs,omitempty"`
+	Methods    []Function       `json:"methods,omitempty"`
+	Promoted   []PromotedMethod `json:"promoted,omitempty"`
+	Examples   []Example        `json:"examples,omitempty"`
+
+	Fields           []util.StructField `json:"fields,omitempty"` // exported struct fields with their parsed tags
+	DeprecatedFields []string           `json:"deprecated_fields,omitempty"`
+}
+
+// RequiredGoVersion returns the go directive when toolchains enforce it as a
+// minimum version, or "" when it is absent or only advisory
+func (p *PackageDoc) RequiredGoVersion() string {
+	if util.RequiresGoVersion(p.GoVersion) {
+		return p.GoVersion
+	}
+	return ""
+}
+
+// AliasLink returns the URL of the aliased type, or "" if it is not a named type
+func (t Type) AliasLink() string {
+	if t.AliasOf == "" || t.AliasPath == "" {
+		return ""
+	}
+	name := t.AliasOf
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return "/" + t.AliasPath + "#" + name
+}
+
+// PromotedMethod is a method promoted from an embedded field
+type PromotedMethod struct {
+	Name      string `json:"name"`
+	Signature string `json:"signature"`
+	From      string `json:"from"`
+	FromPath  string `json:"from_path"`
+}
+
+// Anchor returns the fragment of the method on its declaring package's page
+func (m PromotedMethod) Anchor() string {
+	typeName := m.From
+	if i := strings.LastIndex(typeName, "."); i >= 0 {
+		typeName = typeName[i+1:]
+	}
+	return typeName + "." + m.Name
 }
 
 // Example represents a runnable example
@@ -108,6 +184,14 @@ type Example struct {
 	Doc    string `json:"doc"`
 	Code   string `json:"code"`
 	Output string `json:"output,omitempty"`
+
+	// Unordered is set for an "// Unordered output:" comment
+	Unordered bool `json:"unordered,omitempty"`
+	// EmptyOutput is set for an output comment with nothing after it
+	EmptyOutput bool `json:"empty_output,omitempty"`
+
+	// Verified is set when the example compiled, ran, and printed Output at extraction time
+	Verified bool `json:"verified,omitempty"`
 }
 
 // Server represents the documentation web server
@@ -119,6 +203,77 @@ type Server struct {
 	aiService   *ai.Service   // optional AI service for code explanations
 	searchCache *Cache        // cache for search results
 	rateLimiter *RateLimiter  // rate limiter for API endpoints
+	vectors     vectorIndexes // ANN indexes for semantic search
+	loadWorkers int           // concurrent JSON parsers used by loadPackages
+	goroot      string        // standard library sources for /api/source
+	modCache    string        // module cache holding third-party sources for /api/source
+	slimJSON    bool          // omit the license text, go.mod and sources from package JSON
+	socket      string        // Unix socket ListenAndServe listens on instead of addr
+	adminToken  string        // token guarding the /admin pages; empty disables them
+	synopsisLen int           // synopsis length in search results and package cards; negative for no limit
+	maxSymbols  int           // symbols rendered per package page section; negative for no limit
+	staticGzip  bool          // gzip CSS, JS and other text assets for clients accepting it
+	timeouts    Timeouts      // connection timeouts of the HTTP server ListenAndServe runs
+	goModErrors sync.Map      // modules whose go.mod failed to parse, so the error is logged once
+
+	feedbackLimiter *RateLimiter // stricter rate limiter for documentation reports
+}
+
+const (
+	// defaultSearchLimit is the number of results /api/search returns without ?limit=
+	defaultSearchLimit = 50
+	// maxSearchLimit caps ?limit= on /api/search
+	maxSearchLimit = 200
+	// defaultPackageListLimit is the page size of /api/packages given ?offset= without ?limit=
+	defaultPackageListLimit = 100
+	// maxPackageListLimit caps ?limit= on /api/packages
+	maxPackageListLimit = 1000
+	// defaultSynopsisLen is how much of a synopsis search results and package cards show
+	defaultSynopsisLen = 160
+	// loadBatchSize is the number of packages indexed per transaction at startup
+	loadBatchSize = 200
+	// loadProgressInterval is how often loadPackages logs progress
+	loadProgressInterval = 1000
+)
+
+// Timeouts are the connection timeouts of the HTTP server, as in http.Server.
+// Zero fields take the defaults below and negative ones disable the timeout.
+type Timeouts struct {
+	ReadHeader time.Duration // reading the request line and headers
+	Read       time.Duration // reading the whole request, body included
+	Write      time.Duration // from the end of the request headers to the end of the response
+	Idle       time.Duration // keep-alive connections waiting for the next request
+}
+
+// defaultTimeouts keep slow clients from holding connections open while
+// leaving AI explanations time to complete; streamed search results extend
+// the write deadline as they go
+var defaultTimeouts = Timeouts{
+	ReadHeader: 10 * time.Second,
+	Read:       30 * time.Second,
+	Write:      2 * time.Minute,
+	Idle:       2 * time.Minute,
+}
+
+// Options configures a Server
+type Options struct {
+	DataDir     string // directory containing JSON documentation files; empty serves only the database
+	DBPath      string // SQLite database path (optional)
+	LoadWorkers int    // concurrent JSON parsers at startup (default: number of CPUs)
+	ReadOnlyDB  bool   // open DBPath read-only (e.g. a replica) and skip indexing
+	GOROOT      string // Go installation to read standard library sources from (default: go env GOROOT)
+	ModCache    string // module cache to read module sources from (default: go env GOMODCACHE)
+	SlimJSON    bool   // leave license text, go.mod and embedded sources out of package JSON unless ?fields= asks
+	AdminToken  string // token required by the /admin pages; they are disabled without one
+	SynopsisLen int    // characters of synopsis shown in search results and package cards (default 160, negative for no limit)
+	MaxSymbols  int    // symbols rendered per section of a package page before "Show all" (default 100, negative for no limit)
+	Socket      string // Unix socket path to listen on instead of a TCP address
+
+	NoStaticGzip bool // serve static assets uncompressed even to clients accepting gzip
+
+	// Timeouts of the HTTP server ListenAndServe runs (defaults: 10s to read
+	// the headers, 30s to read the request, 2m to write the response, 2m idle)
+	Timeouts Timeouts
 }
 
 // NewServer creates a new documentation server
@@ -128,35 +283,76 @@ func NewServer(dataDir string) (*Server, error) {
 
 // NewServerWithDB creates a new documentation server with optional SQLite database
 func NewServerWithDB(dataDir, dbPath string) (*Server, error) {
+	return NewServerWithOptions(Options{DataDir: dataDir, DBPath: dbPath})
+}
+
+// NewServerWithOptions creates a new documentation server from options
+func NewServerWithOptions(opts Options) (*Server, error) {
+	dataDir, dbPath := opts.DataDir, opts.DBPath
 	s := &Server{
 		packages:    make(map[string]*PackageDoc),
 		dataDir:     dataDir,
+		loadWorkers: opts.LoadWorkers,
+		goroot:      opts.GOROOT,
+		modCache:    opts.ModCache,
+		slimJSON:    opts.SlimJSON,
+		socket:      opts.Socket,
+		synopsisLen: opts.SynopsisLen,
+		maxSymbols:  opts.MaxSymbols,
+		staticGzip:  !opts.NoStaticGzip,
+		timeouts:    opts.Timeouts,
+		adminToken:  opts.AdminToken,
 		searchCache: NewCache(5 * time.Minute),              // 5 minute TTL for search results
 		rateLimiter: NewRateLimiter(100, time.Minute, 200),  // 100 req/min, burst of 200
+
+		feedbackLimiter: NewRateLimiter(5, time.Hour, 5), // 5 reports/hour
+	}
+	if s.goroot == "" {
+		s.goroot = build.Default.GOROOT
+	}
+	if s.modCache == "" {
+		s.modCache = defaultModCache()
+	}
+	if s.synopsisLen == 0 {
+		s.synopsisLen = defaultSynopsisLen
+	}
+	if s.maxSymbols == 0 {
+		s.maxSymbols = defaultMaxSymbols
+	}
+	s.timeouts = s.timeouts.withDefaults()
+
+	// Initialize AI service (from environment)
+	s.aiService = ai.NewServiceFromEnv()
+	if s.aiService != nil {
+		s.aiService.SetBudget(5.0, 100.0) // $5/day, $100/month
+		// AI_FEATURES overrides the features enabled by default
+		err := s.aiService.EnableFromEnv(ai.FlagExplainCode, ai.FlagLicenseSummary, ai.FlagEnhanceDocs,
+			ai.FlagSemanticSearch, ai.FlagQueryUnderstanding, ai.FlagAutoExamples, ai.FlagDocTranslation)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("AI service initialized")
 	}
 
 	// Open database if path provided
 	if dbPath != "" {
-		database, err := db.Open(dbPath)
+		open := db.Open
+		if opts.ReadOnlyDB {
+			open = db.OpenReadOnly
+		}
+		database, err := open(dbPath)
 		if err != nil {
 			return nil, fmt.Errorf("opening database: %w", err)
 		}
 		s.db = database
-		log.Printf("Opened database: %s", dbPath)
-	}
+		if opts.ReadOnlyDB {
+			log.Printf("Opened database (read-only): %s", dbPath)
+		} else {
+			log.Printf("Opened database: %s", dbPath)
+		}
 
-	// Initialize AI service (from environment)
-	s.aiService = ai.NewServiceFromEnv()
-	if s.aiService != nil {
-		s.aiService.SetBudget(5.0, 100.0) // $5/day, $100/month
-		s.aiService.Enable(ai.FlagExplainCode)
-		s.aiService.Enable(ai.FlagLicenseSummary)
-		s.aiService.Enable(ai.FlagEnhanceDocs)
-		s.aiService.Enable(ai.FlagSemanticSearch)
-		s.aiService.Enable(ai.FlagQueryUnderstanding)
-		s.aiService.Enable(ai.FlagAutoExamples)
-		s.aiService.Enable(ai.FlagDocTranslation)
-		log.Printf("AI service initialized")
+		// Build semantic search indexes in the background; queries fall back to a linear scan until ready
+		go s.buildVectorIndexes()
 	}
 
 	// Parse templates
@@ -164,6 +360,11 @@ func NewServerWithDB(dataDir, dbPath string) (*Server, error) {
 		"formatDoc":      formatDoc,
 		"formatDocHTML":  formatDocHTML,
 		"shortDoc":       shortDoc,
+		"truncate":       truncate,
+		"synopsisLen":    func() int { return s.synopsisLen },
+		"symbolItem":     func(page *packagePage, item interface{}) symbolItem { return symbolItem{Page: page, Item: item} },
+		"taggedFields":   taggedFields,
+		"formatFieldTag": formatFieldTag,
 		"baseName":       filepath.Base,
 		"hasPrefix":      strings.HasPrefix,
 		"trimPrefix":     strings.TrimPrefix,
@@ -175,6 +376,14 @@ func NewServerWithDB(dataDir, dbPath string) (*Server, error) {
 		"sub":            func(a, b int) int { return a - b },
 		"cond":           func(cond bool, t, f string) string { if cond { return t }; return f },
 		"highlightQuery": highlightQuery,
+		"formatSize":     formatSize,
+		"withoutParams":  docWithoutParams,
+		"feedbackTarget": feedbackTarget,
+		"moduleRepoURL":  util.ModuleToRepoURL,
+		"typeParams":     typeParams,
+		"instantiations": instantiations,
+		"indexedAgo":     func(t time.Time) string { return indexedAgo(t, time.Now()) },
+		"deprecation":    util.DeprecationNotice,
 	}
 
 	tmpl, err := template.New("").Funcs(funcMap).ParseFS(templatesFS, "templates/*.html")
@@ -204,7 +413,49 @@ func (s *Server) IndexPackage(pkg *PackageDoc) error {
 	if s.db == nil {
 		return fmt.Errorf("database not configured")
 	}
+	attachConstructors(pkg)
+	if err := indexPackage(s.db, pkg); err != nil {
+		return err
+	}
+	return nil
+}
 
+// packageExamples collects the examples of a package and all of its symbols
+func packageExamples(pkg *PackageDoc) []*db.Example {
+	var examples []*db.Example
+	add := func(list []Example) {
+		for _, ex := range list {
+			examples = append(examples, &db.Example{
+				ImportPath:  pkg.ImportPath,
+				Symbol:      util.ExampleSymbol(ex.Name),
+				Name:        ex.Name,
+				Doc:         ex.Doc,
+				Code:        ex.Code,
+				Output:      ex.Output,
+				Unordered:   ex.Unordered,
+				EmptyOutput: ex.EmptyOutput,
+				Verified:    ex.Verified,
+			})
+		}
+	}
+	add(pkg.Examples)
+	for _, fn := range pkg.Functions {
+		add(fn.Examples)
+	}
+	for _, t := range pkg.Types {
+		add(t.Examples)
+		for _, fn := range t.Functions {
+			add(fn.Examples)
+		}
+		for _, m := range t.Methods {
+			add(m.Examples)
+		}
+	}
+	return examples
+}
+
+// indexPackage writes a package and its symbols and imports using the given database handle
+func indexPackage(database *db.DB, pkg *PackageDoc) error {
 	// Convert PackageDoc to JSON for storage
 	docJSON, err := json.Marshal(pkg)
 	if err != nil {
@@ -231,21 +482,23 @@ func (s *Server) IndexPackage(pkg *PackageDoc) error {
 		GoModContent:    pkg.GoModContent,
 		GOOS:            pkg.GOOS,
 		GOARCH:          pkg.GOARCH,
+		Generate:        pkg.Generate,
 		DocJSON:         string(docJSON),
+		Classification:  pkg.Classification,
+		Coverage:        pkg.Coverage,
+		SynopsisSource:  pkg.SynopsisSource,
+		Experimental:    pkg.Experimental,
+		Deprecated:      pkg.Deprecated,
 	}
 
 	// Upsert package
-	pkgID, err := s.db.UpsertPackage(dbPkg)
+	pkgID, err := database.UpsertPackage(dbPkg)
 	if err != nil {
 		return fmt.Errorf("upserting package: %w", err)
 	}
 
-	// Delete old symbols
-	if err := s.db.DeletePackageSymbols(pkgID); err != nil {
-		return fmt.Errorf("deleting old symbols: %w", err)
-	}
-
 	// Index symbols
+	var symbols []*db.Symbol
 	for _, fn := range pkg.Functions {
 		sym := &db.Symbol{
 			Name:       fn.Name,
@@ -253,11 +506,14 @@ func (s *Server) IndexPackage(pkg *PackageDoc) error {
 			PackageID:  pkgID,
 			ImportPath: pkg.ImportPath,
 			Synopsis:   shortDoc(fn.Doc),
+			Signature:  fn.Signature,
 			Deprecated: fn.Deprecated,
+			Filename:   fn.Filename,
+			Line:       fn.Line,
+			EndLine:    fn.EndLine,
+			Params:     fn.Params,
 		}
-		if err := s.db.UpsertSymbol(sym); err != nil {
-			log.Printf("Warning: failed to index symbol %s: %v", fn.Name, err)
-		}
+		symbols = append(symbols, sym)
 	}
 
 	for _, t := range pkg.Types {
@@ -268,11 +524,15 @@ func (s *Server) IndexPackage(pkg *PackageDoc) error {
 			PackageID:  pkgID,
 			ImportPath: pkg.ImportPath,
 			Synopsis:   shortDoc(t.Doc),
+			Decl:       t.Decl,
 			Deprecated: t.Deprecated,
+			Filename:   t.Filename,
+			Line:       t.Line,
+			EndLine:    t.EndLine,
+			Interface:  t.Interface,
+			MethodSet:  t.MethodSet,
 		}
-		if err := s.db.UpsertSymbol(sym); err != nil {
-			log.Printf("Warning: failed to index type %s: %v", t.Name, err)
-		}
+		symbols = append(symbols, sym)
 
 		// Index methods
 		for _, m := range t.Methods {
@@ -282,11 +542,14 @@ func (s *Server) IndexPackage(pkg *PackageDoc) error {
 				PackageID:  pkgID,
 				ImportPath: pkg.ImportPath,
 				Synopsis:   shortDoc(m.Doc),
+				Signature:  m.Signature,
 				Deprecated: m.Deprecated,
+				Filename:   m.Filename,
+				Line:       m.Line,
+				EndLine:    m.EndLine,
+				Params:     m.Params,
 			}
-			if err := s.db.UpsertSymbol(sym); err != nil {
-				log.Printf("Warning: failed to index method %s: %v", m.Name, err)
-			}
+			symbols = append(symbols, sym)
 		}
 
 		// Index type functions (constructors)
@@ -297,11 +560,14 @@ func (s *Server) IndexPackage(pkg *PackageDoc) error {
 				PackageID:  pkgID,
 				ImportPath: pkg.ImportPath,
 				Synopsis:   shortDoc(fn.Doc),
+				Signature:  fn.Signature,
 				Deprecated: fn.Deprecated,
+				Filename:   fn.Filename,
+				Line:       fn.Line,
+				EndLine:    fn.EndLine,
+				Params:     fn.Params,
 			}
-			if err := s.db.UpsertSymbol(sym); err != nil {
-				log.Printf("Warning: failed to index func %s: %v", fn.Name, err)
-			}
+			symbols = append(symbols, sym)
 		}
 	}
 
@@ -314,10 +580,9 @@ func (s *Server) IndexPackage(pkg *PackageDoc) error {
 				PackageID:  pkgID,
 				ImportPath: pkg.ImportPath,
 				Synopsis:   shortDoc(c.Doc),
+				Deprecated: c.Deprecated || slices.Contains(c.DeprecatedNames, name),
 			}
-			if err := s.db.UpsertSymbol(sym); err != nil {
-				log.Printf("Warning: failed to index const %s: %v", name, err)
-			}
+			symbols = append(symbols, sym)
 		}
 	}
 
@@ -330,30 +595,38 @@ func (s *Server) IndexPackage(pkg *PackageDoc) error {
 				PackageID:  pkgID,
 				ImportPath: pkg.ImportPath,
 				Synopsis:   shortDoc(v.Doc),
+				Deprecated: v.Deprecated || slices.Contains(v.DeprecatedNames, name),
 			}
-			if err := s.db.UpsertSymbol(sym); err != nil {
-				log.Printf("Warning: failed to index var %s: %v", name, err)
-			}
+			symbols = append(symbols, sym)
 		}
 	}
 
+	if _, err := database.ReplacePackageSymbols(pkgID, pkg.ImportPath, pkg.Name, symbols); err != nil {
+		return fmt.Errorf("indexing symbols: %w", err)
+	}
+
+	// Index examples
+	if err := database.ReplacePackageExamples(pkgID, pkg.ImportPath, packageExamples(pkg)); err != nil {
+		log.Printf("Warning: failed to index examples: %v", err)
+	}
+
 	// Index imports
 	for _, imp := range pkg.Imports {
-		if err := s.db.AddImport(pkg.ImportPath, imp, pkg.ModulePath); err != nil {
+		if err := database.AddImport(pkg.ImportPath, imp, pkg.ModulePath); err != nil {
 			log.Printf("Warning: failed to index import %s: %v", imp, err)
 		}
 	}
 
-	log.Printf("Indexed package: %s", pkg.ImportPath)
 	return nil
 }
 
-// GetImportedByCount returns the count of packages that import the given package
+// GetImportedByCount returns the count of packages that import the given
+// package, as of the database's last imported-by refresh
 func (s *Server) GetImportedByCount(importPath string) int {
 	if s.db == nil {
 		return 0
 	}
-	count, err := s.db.GetImportedByCount(importPath)
+	count, err := s.db.CachedImportedByCount(importPath)
 	if err != nil {
 		log.Printf("Error getting imported by count: %v", err)
 		return 0
@@ -423,7 +696,20 @@ func (s *Server) dbPackageToDoc(dbPkg *db.Package) *PackageDoc {
 		GoModContent:    dbPkg.GoModContent,
 		GOOS:            dbPkg.GOOS,
 		GOARCH:          dbPkg.GOARCH,
+		Generate:        dbPkg.Generate,
+		Classification:  dbPkg.Classification,
+		Coverage:        dbPkg.Coverage,
+		SynopsisSource:  dbPkg.SynopsisSource,
+		Experimental:    dbPkg.Experimental,
+		Deprecated:      dbPkg.Deprecated,
+		IndexedAt:       dbPkg.IndexedAt,
+	}
+
+	imports, err := s.db.GetImports(dbPkg.ImportPath)
+	if err != nil {
+		log.Printf("Error fetching imports: %v", err)
 	}
+	pkg.Imports = imports
 
 	// Fetch symbols for this package
 	symbols, err := s.db.GetPackageSymbols(dbPkg.ID)
@@ -440,32 +726,45 @@ func (s *Server) dbPackageToDoc(dbPkg *db.Package) *PackageDoc {
 				Name:       sym.Name,
 				Doc:        sym.Doc,
 				Signature:  sym.Signature,
+				Filename:   sym.Filename,
+				Line:       sym.Line,
+				EndLine:    sym.EndLine,
 				Deprecated: sym.Deprecated,
+				Params:     sym.Params,
 			})
 		case "type":
 			pkg.Types = append(pkg.Types, Type{
 				Name:       sym.Name,
 				Doc:        sym.Doc,
 				Decl:       sym.Decl,
+				Filename:   sym.Filename,
+				Line:       sym.Line,
+				EndLine:    sym.EndLine,
 				Deprecated: sym.Deprecated,
+				Fields:     util.StructFieldsOfDecl(sym.Decl, sym.Name),
+				Interface:  sym.Interface,
+				MethodSet:  sym.MethodSet,
 			})
 		case "const":
 			pkg.Constants = append(pkg.Constants, Constant{
-				Names: []string{sym.Name},
-				Doc:   sym.Doc,
-				Decl:  sym.Decl,
+				Names:      []string{sym.Name},
+				Doc:        sym.Doc,
+				Decl:       sym.Decl,
+				Deprecated: sym.Deprecated,
 			})
 		case "var":
 			pkg.Variables = append(pkg.Variables, Variable{
-				Names: []string{sym.Name},
-				Doc:   sym.Doc,
-				Decl:  sym.Decl,
+				Names:      []string{sym.Name},
+				Doc:        sym.Doc,
+				Decl:       sym.Decl,
+				Deprecated: sym.Deprecated,
 			})
 		case "method":
 			// Methods are attached to types - skip for now
 			// TODO: properly attach methods to their types
 		}
 	}
+	attachConstructors(pkg)
 
 	return pkg
 }
@@ -488,79 +787,259 @@ func (s *Server) FindPackageWithPath(path string) (*PackageDoc, string, bool) {
 
 // loadPackages loads all package documentation from JSON files
 func (s *Server) loadPackages() error {
-	return filepath.Walk(s.dataDir, func(path string, info os.FileInfo, err error) error {
+	if s.dataDir == "" {
+		return nil
+	}
+
+	var paths []string
+	err := filepath.Walk(s.dataDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if info.IsDir() || !strings.HasSuffix(path, ".json") {
-			return nil
+		if !info.IsDir() && strings.HasSuffix(path, ".json") {
+			paths = append(paths, path)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return nil
+	}
 
-		data, err := os.ReadFile(path)
-		if err != nil {
-			log.Printf("Warning: could not read %s: %v", path, err)
-			return nil
-		}
+	workers := s.loadWorkers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
 
-		var pkg PackageDoc
-		if err := json.Unmarshal(data, &pkg); err != nil {
-			log.Printf("Warning: could not parse %s: %v", path, err)
-			return nil
-		}
+	type loadedPackage struct {
+		order int // position in walk order, so duplicates resolve as before
+		pkg   *PackageDoc
+	}
 
-		s.packages[pkg.ImportPath] = &pkg
-		log.Printf("Loaded package: %s", pkg.ImportPath)
+	// Parse files concurrently; the bounded results channel applies backpressure
+	jobs := make(chan int)
+	results := make(chan loadedPackage, workers*2)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				data, err := os.ReadFile(paths[i])
+				if err != nil {
+					log.Printf("Warning: could not read %s: %v", paths[i], err)
+					continue
+				}
 
-		// Index into database if available
-		if s.db != nil {
-			if err := s.IndexPackage(&pkg); err != nil {
-				log.Printf("Warning: could not index %s: %v", pkg.ImportPath, err)
+				var pkg PackageDoc
+				if err := json.Unmarshal(data, &pkg); err != nil {
+					log.Printf("Warning: could not parse %s: %v", paths[i], err)
+					continue
+				}
+				if info, err := os.Stat(paths[i]); err == nil {
+					pkg.IndexedAt = info.ModTime()
+				}
+				attachConstructors(&pkg)
+				results <- loadedPackage{order: i, pkg: &pkg}
+			}
+		}()
+	}
+	go func() {
+		for i := range paths {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// A read-only replica is indexed by the crawler, not by the server
+	index := s.db != nil && !s.db.ReadOnly()
+
+	// Store and index on this goroutine so map and DB writes are serialized
+	start := time.Now()
+	order := make(map[string]int)
+	var batch []*PackageDoc
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		err := s.db.Batch(func(tx *db.DB) error {
+			for _, pkg := range batch {
+				if err := indexPackage(tx, pkg); err != nil {
+					log.Printf("Warning: could not index %s: %v", pkg.ImportPath, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("Warning: could not index batch: %v", err)
+		}
+		batch = batch[:0]
+	}
+
+	processed := 0
+	for r := range results {
+		processed++
+		if prev, ok := order[r.pkg.ImportPath]; !ok || r.order > prev {
+			order[r.pkg.ImportPath] = r.order
+			s.packages[r.pkg.ImportPath] = r.pkg
+			if index {
+				batch = append(batch, r.pkg)
+				if len(batch) >= loadBatchSize {
+					flush()
+				}
 			}
 		}
+		if processed%loadProgressInterval == 0 {
+			log.Printf("Loading packages: %d/%d files", processed, len(paths))
+		}
+	}
+	if index {
+		flush()
+		if _, err := s.db.RefreshImportedByCounts(); err != nil {
+			log.Printf("Warning: could not refresh imported-by counts: %v", err)
+		}
+	}
 
-		return nil
-	})
+	log.Printf("Loaded %d packages from %d files in %v", len(s.packages), len(paths), time.Since(start).Round(time.Millisecond))
+	return nil
 }
 
-// ListenAndServe starts the HTTP server
+// ListenAndServe starts the HTTP server on addr, or on the Unix socket if one is configured
 func (s *Server) ListenAndServe(addr string) error {
+	srv, err := s.httpServer(addr)
+	if err != nil {
+		return err
+	}
+
+	if s.socket == "" {
+		log.Printf("Starting server on %s", addr)
+		return srv.ListenAndServe()
+	}
+
+	ln, err := listenUnix(s.socket)
+	if err != nil {
+		return err
+	}
+	log.Printf("Starting server on unix socket %s", s.socket)
+	return srv.Serve(ln)
+}
+
+// httpServer returns the HTTP server serving the site on addr, with the
+// server's connection timeouts
+func (s *Server) httpServer(addr string) (*http.Server, error) {
+	handler, err := s.Handler()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: s.timeouts.ReadHeader,
+		ReadTimeout:       s.timeouts.Read,
+		WriteTimeout:      s.timeouts.Write,
+		IdleTimeout:       s.timeouts.Idle,
+	}, nil
+}
+
+// withDefaults fills the zero timeouts with the defaults and turns negative
+// ones into zero, which http.Server takes as no timeout
+func (t Timeouts) withDefaults() Timeouts {
+	resolve := func(d, def time.Duration) time.Duration {
+		switch {
+		case d == 0:
+			return def
+		case d < 0:
+			return 0
+		}
+		return d
+	}
+	return Timeouts{
+		ReadHeader: resolve(t.ReadHeader, defaultTimeouts.ReadHeader),
+		Read:       resolve(t.Read, defaultTimeouts.Read),
+		Write:      resolve(t.Write, defaultTimeouts.Write),
+		Idle:       resolve(t.Idle, defaultTimeouts.Idle),
+	}
+}
+
+// listenUnix listens on a Unix socket, replacing a stale one left by a server that did not shut down cleanly
+func listenUnix(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use by another server", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("removing stale socket: %w", err)
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	// The reverse proxy usually runs as another user
+	if err := os.Chmod(path, 0666); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("setting socket permissions: %w", err)
+	}
+	return ln, nil
+}
+
+// Handler returns the handler serving every route of the site
+func (s *Server) Handler() (http.Handler, error) {
 	mux := http.NewServeMux()
 
 	// Static files
 	staticContent, err := fs.Sub(staticFS, "static")
 	if err != nil {
-		return err
+		return nil, err
+	}
+	static, err := newStaticHandler(staticContent, s.staticGzip)
+	if err != nil {
+		return nil, fmt.Errorf("compressing static assets: %w", err)
 	}
-	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.FS(staticContent))))
+	mux.Handle("/static/", http.StripPrefix("/static/", static))
 
 	// Routes
 	mux.HandleFunc("/", s.handleHome)
 	mux.HandleFunc("/search", s.handleSearch)
-	mux.HandleFunc("/api/", s.rateLimiter.Middleware(s.handleAPI))
 	mux.HandleFunc("/badge/", s.rateLimiter.Middleware(s.handleBadge))
 	mux.HandleFunc("/license/", s.handleLicense)
 	mux.HandleFunc("/imports/", s.handleImports)
 	mux.HandleFunc("/mod/", s.handleModule)
+	mux.HandleFunc("/module/", s.handleModulePackages)
+	mux.HandleFunc("/raw-doc/", s.handleRawDoc)
 	mux.HandleFunc("/versions/", s.handleVersions)
 	mux.HandleFunc("/importedby/", s.handleImportedBy)
 	mux.HandleFunc("/symbols", s.handleSymbolSearch)
+	mux.HandleFunc("/all-symbols", s.handleAllSymbols)
+	mux.HandleFunc("/packages", s.handleAllPackages)
+	mux.HandleFunc("/examples", s.handleExamples)
+	mux.HandleFunc("/examples/", s.handleExamples)
+	mux.HandleFunc("/feedback", s.feedbackLimiter.Middleware(s.handleFeedback))
 	mux.HandleFunc("/diff/", s.handleDiff)
 	mux.HandleFunc("/compare/", s.handleCompare)
-	mux.HandleFunc("/api/explain", s.rateLimiter.Middleware(s.handleExplain))
-	mux.HandleFunc("/api/license-summary", s.rateLimiter.Middleware(s.handleLicenseSummary))
-	mux.HandleFunc("/api/enhance-doc", s.rateLimiter.Middleware(s.handleEnhanceDoc))
-	mux.HandleFunc("/api/semantic-search", s.rateLimiter.Middleware(s.handleSemanticSearch))
-	mux.HandleFunc("/api/understand-query", s.rateLimiter.Middleware(s.handleUnderstandQuery))
-	mux.HandleFunc("/api/generate-example", s.rateLimiter.Middleware(s.handleGenerateExample))
-	mux.HandleFunc("/api/translate", s.rateLimiter.Middleware(s.handleTranslate))
-	mux.HandleFunc("/api/validate", s.rateLimiter.Middleware(s.handleValidate))
+	mux.HandleFunc("/admin/errors", s.rateLimiter.Middleware(s.requireAdmin(s.handleAdminErrors)))
+	for _, route := range s.apiRoutes() {
+		if route.handler != nil {
+			mux.HandleFunc(route.pattern, s.rateLimiter.Middleware(route.handler))
+		}
+	}
 	mux.HandleFunc("/crates.io/", s.handleRustCrate)
 	mux.HandleFunc("/npm/", s.handleJSPackage)
 	mux.HandleFunc("/pypi/", s.handlePythonPackage)
 	mux.HandleFunc("/packagist/", s.handlePHPPackage)
 
-	log.Printf("Starting server on %s", addr)
-	return http.ListenAndServe(addr, mux)
+	return mux, nil
 }
 
 // handleHome handles the home page and package documentation pages
@@ -575,6 +1054,13 @@ func (s *Server) handleHome(w http.ResponseWriter, r *http.Request) {
 	// Try to find package
 	pkg, ok := s.FindPackage(path)
 
+	// The same URL serves HTML to browsers and JSON to API clients
+	w.Header().Add("Vary", "Accept")
+	if prefersJSON(r) {
+		s.writePackageJSON(w, r, pkg, ok)
+		return
+	}
+
 	if !ok {
 		http.NotFound(w, r)
 		return
@@ -583,20 +1069,74 @@ func (s *Server) handleHome(w http.ResponseWriter, r *http.Request) {
 	s.renderPackage(w, r, pkg)
 }
 
+// prefersJSON reports whether the Accept header ranks application/json above
+// text/html; browsers never list JSON, so they always get the HTML page
+func prefersJSON(r *http.Request) bool {
+	jsonQ, htmlQ := -1.0, -1.0
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case "application/json":
+			if jsonQ < 0 {
+				jsonQ = q
+			}
+		case "text/html":
+			if htmlQ < 0 {
+				htmlQ = q
+			}
+		}
+	}
+	return jsonQ > 0 && jsonQ > htmlQ
+}
+
+// recentWindow is how far back the home page's "new this week" rails look
+const recentWindow = 7 * 24 * time.Hour
+
+// indexedAgo describes when a package was indexed relative to now, at the
+// granularity of the "new this week" rails
+func indexedAgo(indexedAt, now time.Time) string {
+	switch age := now.Sub(indexedAt); {
+	case age < time.Hour:
+		return "indexed just now"
+	case age < 2*time.Hour:
+		return "indexed an hour ago"
+	case age < 24*time.Hour:
+		return fmt.Sprintf("indexed %d hours ago", int(age/time.Hour))
+	case age < 48*time.Hour:
+		return "indexed yesterday"
+	default:
+		return fmt.Sprintf("indexed %d days ago", int(age/(24*time.Hour)))
+	}
+}
+
+// homePackages is how many Go packages the home page lists
+const homePackages = 12
+
 // renderHome renders the home page
 func (s *Server) renderHome(w http.ResponseWriter, r *http.Request) {
-	// Get Go packages (standard library)
+	// Get Go packages (standard library first, then by import path); without a
+	// data directory, list what the crawler indexed instead
 	var goPackages []*PackageDoc
-	for _, pkg := range s.packages {
-		goPackages = append(goPackages, pkg)
+	if len(s.packages) > 0 {
+		for _, pkg := range s.packages {
+			goPackages = append(goPackages, pkg)
+		}
+		sort.Slice(goPackages, func(i, j int) bool {
+			return goPackages[i].ImportPath < goPackages[j].ImportPath
+		})
+	} else {
+		goPackages = s.listPackages(homePackages, 0)
 	}
-	// Sort by import path (std lib first, then by name)
-	sort.Slice(goPackages, func(i, j int) bool {
-		return goPackages[i].ImportPath < goPackages[j].ImportPath
-	})
-	// Limit to 12 for homepage
-	if len(goPackages) > 12 {
-		goPackages = goPackages[:12]
+	if len(goPackages) > homePackages {
+		goPackages = goPackages[:homePackages]
 	}
 
 	// Get popular packages from other ecosystems
@@ -605,7 +1145,36 @@ func (s *Server) renderHome(w http.ResponseWriter, r *http.Request) {
 	var pythonPackages []*db.PythonPackage
 	var phpPackages []*db.PHPPackage
 
+	// And what each ecosystem indexed in the last week
+	var recentGo []*PackageDoc
+	var recentRust []*db.RustCrate
+	var recentJS []*db.JSPackage
+	var recentPython []*db.PythonPackage
+	var recentPHP []*db.PHPPackage
+
 	if s.db != nil {
+		since := time.Now().Add(-recentWindow)
+		if pkgs, err := s.db.GetRecentPackages(since, 8); err == nil {
+			for _, dbPkg := range pkgs {
+				pkg := packageSummary(dbPkg)
+				pkg.SynopsisSource = dbPkg.SynopsisSource
+				pkg.IndexedAt = dbPkg.IndexedAt
+				recentGo = append(recentGo, pkg)
+			}
+		}
+		if crates, err := s.db.GetRecentRustCrates(since, 8); err == nil {
+			recentRust = crates
+		}
+		if pkgs, err := s.db.GetRecentJSPackages(since, 8); err == nil {
+			recentJS = pkgs
+		}
+		if pkgs, err := s.db.GetRecentPythonPackages(since, 8); err == nil {
+			recentPython = pkgs
+		}
+		if pkgs, err := s.db.GetRecentPHPPackages(since, 8); err == nil {
+			recentPHP = pkgs
+		}
+
 		// Rust crates - order by downloads
 		if crates, err := s.db.GetPopularRustCrates(8); err == nil {
 			rustCrates = crates
@@ -633,6 +1202,11 @@ func (s *Server) renderHome(w http.ResponseWriter, r *http.Request) {
 		JSPackages     []*db.JSPackage
 		PythonPackages []*db.PythonPackage
 		PHPPackages    []*db.PHPPackage
+		RecentGo       []*PackageDoc
+		RecentRust     []*db.RustCrate
+		RecentJS       []*db.JSPackage
+		RecentPython   []*db.PythonPackage
+		RecentPHP      []*db.PHPPackage
 	}{
 		Title:          "Wikistral - Package Documentation",
 		SearchQuery:    "",
@@ -642,6 +1216,11 @@ func (s *Server) renderHome(w http.ResponseWriter, r *http.Request) {
 		JSPackages:     jsPackages,
 		PythonPackages: pythonPackages,
 		PHPPackages:    phpPackages,
+		RecentGo:       recentGo,
+		RecentRust:     recentRust,
+		RecentJS:       recentJS,
+		RecentPython:   recentPython,
+		RecentPHP:      recentPHP,
 	}
 
 	if err := s.templates.ExecuteTemplate(w, "home.html", data); err != nil {
@@ -650,32 +1229,194 @@ func (s *Server) renderHome(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// getSubdirectories returns subdirectories for a package
-func (s *Server) getSubdirectories(importPath string) []Subdirectory {
+// packageSummary converts a database package row to a PackageDoc without
+// fetching its symbols
+func packageSummary(dbPkg *db.Package) *PackageDoc {
+	return &PackageDoc{
+		ImportPath: dbPkg.ImportPath,
+		Name:       dbPkg.Name,
+		Synopsis:   dbPkg.Synopsis,
+		Version:    dbPkg.Version,
+		License:    dbPkg.License,
+		Repository: dbPkg.Repository,
+		ModulePath: dbPkg.ModulePath,
+	}
+}
+
+// listPackages returns the packages loaded from JSON files plus those only in the
+// database, sorted by import path. Database-only packages carry just their summary.
+// With a limit, only that many are returned after skipping offset, and only
+// those are read from the database; a limit of 0 returns every package.
+func (s *Server) listPackages(limit, offset int) []*PackageDoc {
+	packages := make([]*PackageDoc, 0, len(s.packages))
+	for _, pkg := range s.packages {
+		packages = append(packages, pkg)
+	}
+	if s.db != nil {
+		var dbPkgs []*db.Package
+		var err error
+		switch {
+		case limit <= 0:
+			dbPkgs, err = s.db.ListPackages()
+		case len(s.packages) == 0:
+			dbPkgs, err = s.db.ListPackagesPage(limit, offset)
+			offset = 0
+		default:
+			// Loaded packages may be in the database too, so read enough
+			// rows from the start to fill the page without them
+			dbPkgs, err = s.db.ListPackagesPage(offset+limit+len(s.packages), 0)
+		}
+		if err != nil {
+			log.Printf("Error listing packages from db: %v", err)
+		}
+		for _, dbPkg := range dbPkgs {
+			if _, ok := s.packages[dbPkg.ImportPath]; ok {
+				continue
+			}
+			packages = append(packages, packageSummary(dbPkg))
+		}
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].ImportPath < packages[j].ImportPath
+	})
+	if limit > 0 {
+		packages = packages[min(offset, len(packages)):min(offset+limit, len(packages))]
+	}
+	return packages
+}
+
+// getSubdirectories returns the direct child packages of a package, including
+// those of its module that are only in the database
+func (s *Server) getSubdirectories(pkg *PackageDoc) []Subdirectory {
 	var subdirs []Subdirectory
-	prefix := importPath + "/"
-
-	for path, pkg := range s.packages {
-		if strings.HasPrefix(path, prefix) {
-			rest := strings.TrimPrefix(path, prefix)
-			// Only include direct children (no further slashes)
-			if !strings.Contains(rest, "/") {
-				subdirs = append(subdirs, Subdirectory{
-					Name:     rest,
-					Path:     path,
-					Synopsis: pkg.Synopsis,
+	prefix := pkg.ImportPath + "/"
+	seen := make(map[string]bool)
+	add := func(path, synopsis string) {
+		rest, ok := strings.CutPrefix(path, prefix)
+		// Only include direct children (no further slashes)
+		if !ok || strings.Contains(rest, "/") || seen[path] {
+			return
+		}
+		seen[path] = true
+		subdirs = append(subdirs, Subdirectory{
+			Name:     rest,
+			Path:     path,
+			Synopsis: synopsis,
+		})
+	}
+
+	for path, child := range s.packages {
+		add(path, child.Synopsis)
+	}
+	if s.db != nil && pkg.ModulePath != "" {
+		dbPkgs, err := s.db.PackagesByModule(pkg.ModulePath)
+		if err != nil {
+			log.Printf("Error listing packages of module %s: %v", pkg.ModulePath, err)
+		}
+		for _, dbPkg := range dbPkgs {
+			add(dbPkg.ImportPath, dbPkg.Synopsis)
+		}
+	}
+
+	sort.Slice(subdirs, func(i, j int) bool {
+		return subdirs[i].Name < subdirs[j].Name
+	})
+	return subdirs
+}
+
+// Implementation is an indexed concrete type that satisfies an interface
+type Implementation struct {
+	ImportPath string
+	Package    string
+	Name       string
+	Pointer    bool // only *Name has the full method set
+}
+
+// findImplementations returns the exported concrete types in the interface's
+// package and module whose method sets include every method of the interface
+func (s *Server) findImplementations(pkg *PackageDoc, iface *Type) []Implementation {
+	if len(iface.MethodSet) == 0 {
+		return nil
+	}
+
+	var impls []Implementation
+	for _, p := range s.modulePackageDocs(pkg) {
+		for _, t := range p.Types {
+			if t.Interface || len(t.MethodSet) == 0 || !token.IsExported(t.Name) {
+				continue
+			}
+			if pointer, ok := implementsMethodSet(t.MethodSet, iface.MethodSet); ok {
+				impls = append(impls, Implementation{
+					ImportPath: p.ImportPath,
+					Package:    p.Name,
+					Name:       t.Name,
+					Pointer:    pointer,
 				})
 			}
 		}
 	}
-	return subdirs
+
+	sort.Slice(impls, func(i, j int) bool {
+		if impls[i].ImportPath != impls[j].ImportPath {
+			return impls[i].ImportPath < impls[j].ImportPath
+		}
+		return impls[i].Name < impls[j].Name
+	})
+	return impls
+}
+
+// implementsMethodSet reports whether methods covers every required signature,
+// and whether a pointer receiver is needed to do so
+func implementsMethodSet(methods, required []string) (pointer, ok bool) {
+	have := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		sig := strings.TrimPrefix(m, "*")
+		have[sig] = have[sig] || sig != m
+	}
+	for _, sig := range required {
+		ptr, found := have[sig]
+		if !found {
+			return false, false
+		}
+		pointer = pointer || ptr
+	}
+	return pointer, true
 }
 
 // renderPackage renders a package documentation page
 func (s *Server) renderPackage(w http.ResponseWriter, r *http.Request, pkg *PackageDoc) {
-	subdirs := s.getSubdirectories(pkg.ImportPath)
-	importedByCount := s.GetImportedByCount(pkg.ImportPath)
+	shown := s.pageSymbols(pkg)
+	data := s.symbolPage(pkg, shown.Types)
+	data.Title = pkg.Name + " package - " + pkg.ImportPath + " - Go Packages"
+	data.Subdirectories = s.getSubdirectories(pkg)
+	data.ImportedByCount = s.GetImportedByCount(pkg.ImportPath)
+	data.Shown = shown
+	data.FeedbackSent = r.URL.Query().Get("feedback") == "sent"
+
+	if err := s.templates.ExecuteTemplate(w, "package.html", data); err != nil {
+		log.Printf("Error rendering package: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
 
+// packagePage is the data of package.html
+type packagePage struct {
+	Title           string
+	SearchQuery     string
+	Pkg             *PackageDoc
+	Subdirectories  []Subdirectory
+	ImportedByCount int
+	Shown           pageSymbols // the symbols rendered in the page's sections
+	AIDocs          map[string]string
+	Implementations map[string][]Implementation
+	Related         map[string]RelatedSymbols
+	FeedbackEnabled bool
+	FeedbackSent    bool
+}
+
+// symbolPage returns the part of a package page its symbols are rendered
+// with: AI docs, and the implementations and related symbols of the given types
+func (s *Server) symbolPage(pkg *PackageDoc, types []Type) *packagePage {
 	// Fetch AI-generated docs if database is available
 	aiDocsMap := make(map[string]string) // key: "kind:name" -> value: generated doc
 	if s.db != nil {
@@ -692,26 +1433,102 @@ func (s *Server) renderPackage(w http.ResponseWriter, r *http.Request, pkg *Pack
 		}
 	}
 
-	data := struct {
-		Title           string
-		SearchQuery     string
-		Pkg             *PackageDoc
-		Subdirectories  []Subdirectory
-		ImportedByCount int
-		AIDocs          map[string]string
-	}{
-		Title:           pkg.Name + " package - " + pkg.ImportPath + " - Go Packages",
-		SearchQuery:     "",
+	// Find implementations of the package's interfaces, and what relates to each type
+	implementations := make(map[string][]Implementation)
+	related := make(map[string]RelatedSymbols)
+	for i := range types {
+		t := &types[i]
+		if t.Interface {
+			if impls := s.findImplementations(pkg, t); len(impls) > 0 {
+				implementations[t.Name] = impls
+			}
+		}
+		if r := s.relatedSymbols(pkg, t); !r.Empty() {
+			related[t.Name] = r
+		}
+	}
+
+	return &packagePage{
 		Pkg:             pkg,
-		Subdirectories:  subdirs,
-		ImportedByCount: importedByCount,
 		AIDocs:          aiDocsMap,
+		Implementations: implementations,
+		Related:         related,
+		FeedbackEnabled: s.db != nil && !s.db.ReadOnly(),
+	}
+}
+
+// maxFeedbackLength caps the size of a documentation report message
+const maxFeedbackLength = 2000
+
+// FeedbackTarget identifies the AI-generated doc a report form refers to
+type FeedbackTarget struct {
+	ImportPath string
+	Symbol     string
+	SymbolKind string
+}
+
+// feedbackTarget builds a report target from an AI doc key such as "method:Close"
+func feedbackTarget(importPath, aiDocKey string) FeedbackTarget {
+	kind, symbol, _ := strings.Cut(aiDocKey, ":")
+	return FeedbackTarget{ImportPath: importPath, Symbol: symbol, SymbolKind: kind}
+}
+
+// handleFeedback records a report of incorrect documentation submitted from a package page
+func (s *Server) handleFeedback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.db == nil || s.db.ReadOnly() {
+		http.Error(w, "Feedback is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 4*maxFeedbackLength)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	fb := &db.Feedback{
+		ImportPath: strings.TrimSpace(r.PostFormValue("import_path")),
+		Symbol:     strings.TrimSpace(r.PostFormValue("symbol")),
+		SymbolKind: strings.TrimSpace(r.PostFormValue("symbol_kind")),
+		AIDoc:      r.PostFormValue("ai_doc") == "1",
+		Message:    strings.TrimSpace(r.PostFormValue("message")),
+	}
+	if fb.ImportPath == "" || fb.Message == "" {
+		http.Error(w, "import_path and message are required", http.StatusBadRequest)
+		return
+	}
+	if len(fb.Message) > maxFeedbackLength {
+		http.Error(w, "Message is too long", http.StatusBadRequest)
+		return
+	}
+	if _, ok := s.FindPackage(fb.ImportPath); !ok {
+		http.Error(w, "Unknown package", http.StatusBadRequest)
+		return
+	}
+
+	id, err := s.db.AddFeedback(fb)
+	if err != nil {
+		log.Printf("Error saving feedback: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
 	}
 
-	if err := s.templates.ExecuteTemplate(w, "package.html", data); err != nil {
-		log.Printf("Error rendering package: %v", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"id": id, "status": "received"})
+		return
 	}
+	http.Redirect(w, r, "/"+fb.ImportPath+"?feedback=sent#pkg-feedback", http.StatusSeeOther)
+}
+
+// excludeExperimental reports whether a search asks to leave experimental
+// packages out with ?experimental=exclude
+func excludeExperimental(r *http.Request) bool {
+	return r.URL.Query().Get("experimental") == "exclude"
 }
 
 // handleSearch handles search requests
@@ -721,6 +1538,7 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/", http.StatusFound)
 		return
 	}
+	noExperimental := excludeExperimental(r)
 
 	// Get pagination params
 	page := 1
@@ -739,7 +1557,7 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 
 	// Use database search if available (much faster)
 	if s.db != nil {
-		dbPkgs, err := s.db.SearchPackages(query, 1000) // Get more for pagination
+		dbPkgs, err := s.db.SearchPackages(query, 1000, noExperimental) // Get more for pagination
 		if err != nil {
 			log.Printf("Database search error: %v", err)
 			// Fall back to in-memory search
@@ -772,12 +1590,20 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	{
 		queryLower := strings.ToLower(query)
 		for _, pkg := range s.packages {
+			// Test-only and example-only packages have no API worth listing
+			if pkg.Classification != "" || (noExperimental && pkg.Experimental) {
+				continue
+			}
 			if strings.Contains(strings.ToLower(pkg.ImportPath), queryLower) ||
 				strings.Contains(strings.ToLower(pkg.Name), queryLower) ||
 				strings.Contains(strings.ToLower(pkg.Synopsis), queryLower) {
 				allResults = append(allResults, pkg)
 			}
 		}
+		// Deprecated packages come last, as in the database search
+		sort.SliceStable(allResults, func(i, j int) bool {
+			return !allResults[i].Deprecated && allResults[j].Deprecated
+		})
 		total = len(allResults)
 
 		// Paginate
@@ -796,30 +1622,40 @@ render:
 		totalPages = 1
 	}
 
+	// Link to the same search with experimental packages toggled
+	toggle := url.Values{"q": {query}}
+	if !noExperimental {
+		toggle.Set("experimental", "exclude")
+	}
+
 	data := struct {
-		Title       string
-		SearchQuery string
-		Pkg         *PackageDoc
-		Query       string
-		Results     []*PackageDoc
-		Page        int
-		TotalPages  int
-		Total       int
-		PerPage     int
-		HasPrev     bool
-		HasNext     bool
+		Title               string
+		SearchQuery         string
+		Pkg                 *PackageDoc
+		Query               string
+		Results             []*PackageDoc
+		Page                int
+		TotalPages          int
+		Total               int
+		PerPage             int
+		HasPrev             bool
+		HasNext             bool
+		ExcludeExperimental bool
+		ToggleExperimental  string
 	}{
-		Title:       "Search Results - " + query + " - Go Packages",
-		SearchQuery: query,
-		Pkg:         nil,
-		Query:       query,
-		Results:     results,
-		Page:        page,
-		TotalPages:  totalPages,
-		Total:       total,
-		PerPage:     perPage,
-		HasPrev:     page > 1,
-		HasNext:     page < totalPages,
+		Title:               "Search Results - " + query + " - Go Packages",
+		SearchQuery:         query,
+		Pkg:                 nil,
+		Query:               query,
+		Results:             results,
+		Page:                page,
+		TotalPages:          totalPages,
+		Total:               total,
+		PerPage:             perPage,
+		HasPrev:             page > 1,
+		HasNext:             page < totalPages,
+		ExcludeExperimental: noExperimental,
+		ToggleExperimental:  "/search?" + toggle.Encode(),
 	}
 
 	if err := s.templates.ExecuteTemplate(w, "search.html", data); err != nil {
@@ -832,13 +1668,28 @@ render:
 func (s *Server) handleAPI(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/api/")
 
+	if path == "" && wantsAPIIndex(r) {
+		s.renderAPIIndex(w, r)
+		return
+	}
+
 	if path == "" || path == "packages" {
-		// List all packages
+		// List every package, or a page of them when asked for one
+		limit, offset := 0, 0
+		if q := r.URL.Query(); q.Has("limit") || q.Has("offset") {
+			limit = defaultPackageListLimit
+			if l, err := strconv.Atoi(q.Get("limit")); err == nil && l > 0 {
+				limit = min(l, maxPackageListLimit)
+			}
+			if o, err := strconv.Atoi(q.Get("offset")); err == nil && o > 0 {
+				offset = o
+			}
+		}
 		w.Header().Set("Content-Type", "application/json")
 		var pkgList []map[string]string
-		for importPath, pkg := range s.packages {
+		for _, pkg := range s.listPackages(limit, offset) {
 			pkgList = append(pkgList, map[string]string{
-				"import_path": importPath,
+				"import_path": pkg.ImportPath,
 				"name":        pkg.Name,
 				"synopsis":    pkg.Synopsis,
 			})
@@ -848,16 +1699,33 @@ func (s *Server) handleAPI(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if path == "search" {
+		if r.URL.Query().Get("mode") == "semantic" {
+			s.handleSemanticSearch(w, r)
+			return
+		}
+
+		if wantsNDJSON(r) {
+			s.handleSearchStream(w, r)
+			return
+		}
+
 		query := r.URL.Query().Get("q")
 		lang := r.URL.Query().Get("lang") // "go", "rust", or "" for all
+		sortBy := r.URL.Query().Get("sort") // "size" ranks smaller packages first
+		limit := defaultSearchLimit
+		if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+			limit = min(l, maxSearchLimit)
+		}
 		w.Header().Set("Content-Type", "application/json")
 		if query == "" {
 			json.NewEncoder(w).Encode([]map[string]interface{}{})
 			return
 		}
 
+		noExperimental := excludeExperimental(r)
+
 		// Check cache first
-		cacheKey := "api:search:" + query + ":" + lang
+		cacheKey := "api:search:" + query + ":" + lang + ":" + sortBy + ":" + strconv.Itoa(limit) + ":" + strconv.FormatBool(noExperimental)
 		if cached, ok := s.searchCache.Get(cacheKey); ok {
 			json.NewEncoder(w).Encode(cached)
 			return
@@ -867,100 +1735,74 @@ func (s *Server) handleAPI(w http.ResponseWriter, r *http.Request) {
 
 		// Use database search if available
 		if s.db != nil {
+			// Each ecosystem may fill the whole page once results are ranked together
+			fetch := max(limit, defaultSearchLimit)
+
 			// Search Go packages
 			if lang == "" || lang == "go" {
-				dbPkgs, err := s.db.SearchPackages(query, 50)
+				dbPkgs, err := s.db.SearchPackages(query, fetch, noExperimental)
 				if err != nil {
 					log.Printf("Database search error in API: %v", err)
 				} else {
 					for _, dbPkg := range dbPkgs {
-						results = append(results, map[string]interface{}{
-							"import_path": dbPkg.ImportPath,
-							"name":        dbPkg.Name,
-							"synopsis":    dbPkg.Synopsis,
-							"lang":        "go",
-						})
+						results = append(results, s.goSearchResult(dbPkg))
 					}
 				}
 			}
 
 			// Search Rust crates
 			if lang == "" || lang == "rust" {
-				rustCrates, err := s.db.SearchRustCrates(query, 50)
+				rustCrates, err := s.db.SearchRustCrates(query, fetch)
 				if err != nil {
 					log.Printf("Rust crate search error in API: %v", err)
 				} else {
 					for _, crate := range rustCrates {
-						results = append(results, map[string]interface{}{
-							"import_path": "crates.io/" + crate.Name,
-							"name":        crate.Name,
-							"synopsis":    crate.Description,
-							"lang":        "rust",
-							"version":     crate.Version,
-							"downloads":   crate.Downloads,
-						})
+						results = append(results, rustSearchResult(crate))
 					}
 				}
 			}
 
 			// Search JS/npm packages
 			if lang == "" || lang == "js" || lang == "npm" {
-				jsPkgs, err := s.db.SearchJSPackages(query, 50)
+				jsPkgs, err := s.db.SearchJSPackages(query, fetch)
 				if err != nil {
 					log.Printf("JS package search error in API: %v", err)
 				} else {
 					for _, pkg := range jsPkgs {
-						results = append(results, map[string]interface{}{
-							"import_path": "npm/" + pkg.Name,
-							"name":        pkg.Name,
-							"synopsis":    pkg.Description,
-							"lang":        "js",
-							"version":     pkg.Version,
-							"stars":       pkg.Stars,
-						})
+						results = append(results, jsSearchResult(pkg))
 					}
 				}
 			}
 
 			// Search Python/PyPI packages
 			if lang == "" || lang == "python" || lang == "pypi" {
-				pyPkgs, err := s.db.SearchPythonPackages(query, 50)
+				pyPkgs, err := s.db.SearchPythonPackages(query, fetch)
 				if err != nil {
 					log.Printf("Python package search error in API: %v", err)
 				} else {
 					for _, pkg := range pyPkgs {
-						results = append(results, map[string]interface{}{
-							"import_path": "pypi/" + pkg.Name,
-							"name":        pkg.Name,
-							"synopsis":    pkg.Summary,
-							"lang":        "python",
-							"version":     pkg.Version,
-						})
+						results = append(results, pythonSearchResult(pkg))
 					}
 				}
 			}
 
 			// Search PHP/Packagist packages
 			if lang == "" || lang == "php" || lang == "packagist" {
-				phpPkgs, err := s.db.SearchPHPPackages(query, 50)
+				phpPkgs, err := s.db.SearchPHPPackages(query, fetch)
 				if err != nil {
 					log.Printf("PHP package search error in API: %v", err)
 				} else {
 					for _, pkg := range phpPkgs {
-						results = append(results, map[string]interface{}{
-							"import_path": "packagist/" + pkg.Name,
-							"name":        pkg.Name,
-							"synopsis":    pkg.Description,
-							"lang":        "php",
-							"version":     pkg.Version,
-							"downloads":   pkg.Downloads,
-						})
+						results = append(results, phpSearchResult(pkg))
 					}
 				}
 			}
 
-			// Sort by relevance
-			results = sortByRelevance(query, results)
+			// Rank all ecosystems together
+			results = rankResults(query, results, limit)
+			if sortBy == "size" {
+				sortBySize(results)
+			}
 			s.searchCache.Set(cacheKey, results)
 			json.NewEncoder(w).Encode(results)
 			return
@@ -969,36 +1811,73 @@ func (s *Server) handleAPI(w http.ResponseWriter, r *http.Request) {
 		// Fallback: in-memory search (Go only)
 		queryLower := strings.ToLower(query)
 		for _, pkg := range s.packages {
+			if pkg.Classification != "" || (noExperimental && pkg.Experimental) {
+				continue
+			}
 			if strings.Contains(strings.ToLower(pkg.ImportPath), queryLower) ||
 				strings.Contains(strings.ToLower(pkg.Name), queryLower) ||
 				strings.Contains(strings.ToLower(pkg.Synopsis), queryLower) {
 				results = append(results, map[string]interface{}{
-					"import_path": pkg.ImportPath,
-					"name":        pkg.Name,
-					"synopsis":    pkg.Synopsis,
-					"lang":        "go",
+					"import_path":  pkg.ImportPath,
+					"name":         pkg.Name,
+					"synopsis":     pkg.Synopsis,
+					"lang":         "go",
+					"experimental": pkg.Experimental,
+					"deprecated":   pkg.Deprecated,
 				})
 			}
 		}
 		// Sort by relevance
-		results = sortByRelevance(query, results)
+		results = rankResults(query, results, limit)
 		s.searchCache.Set(cacheKey, results)
 		json.NewEncoder(w).Encode(results)
 		return
 	}
 
+	if isSymbolsRequest(r, path) {
+		s.handleSymbols(w, r, strings.TrimSuffix(path, "/symbols"))
+		return
+	}
+
 	// Try to find package
 	pkg, ok := s.FindPackage(path)
+	s.writePackageJSON(w, r, pkg, ok)
+}
 
-	if !ok {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "package not found"})
-		return
+// crateFeature is a Cargo feature flag as listed on a crate page
+type crateFeature struct {
+	Name    string
+	Enables []string // features and optional dependencies the flag turns on
+	Default bool     // enabled by the default feature set
+}
+
+// crateFeatures lists a crate's feature flags by name, without the default set
+// itself, marking those that are enabled by default directly or transitively
+func crateFeatures(crate *db.RustCrate) []crateFeature {
+	enabled := make(map[string]bool)
+	var enable func(name string)
+	enable = func(name string) {
+		if enabled[name] {
+			return
+		}
+		enabled[name] = true
+		for _, dep := range crate.Features[name] {
+			enable(dep)
+		}
+	}
+	for _, name := range crate.DefaultFeatures() {
+		enable(name)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(pkg)
+	var features []crateFeature
+	for name, enables := range crate.Features {
+		if name == "default" {
+			continue
+		}
+		features = append(features, crateFeature{Name: name, Enables: enables, Default: enabled[name]})
+	}
+	sort.Slice(features, func(i, j int) bool { return features[i].Name < features[j].Name })
+	return features
 }
 
 // handleRustCrate handles Rust crate pages
@@ -1014,6 +1893,8 @@ func (s *Server) handleRustCrate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	crateName, showVersions := strings.CutSuffix(crateName, "/versions")
+
 	crate, err := s.db.GetRustCrate(crateName)
 	if err != nil {
 		log.Printf("Error getting crate: %v", err)
@@ -1025,6 +1906,11 @@ func (s *Server) handleRustCrate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if showVersions {
+		s.handleRegistryVersions(w, db.EcosystemCrates, "crates.io", "/crates.io/"+crate.Name, crate.Name, crate.Version)
+		return
+	}
+
 	symbols, err := s.db.GetRustCrateSymbols(crate.ID)
 	if err != nil {
 		log.Printf("Error getting crate symbols: %v", err)
@@ -1065,6 +1951,7 @@ func (s *Server) handleRustCrate(w http.ResponseWriter, r *http.Request) {
 		SearchQuery   string
 		Pkg           *PackageDoc
 		Crate         *db.RustCrate
+		Features      []crateFeature
 		Symbols       []*db.RustSymbol
 		SymbolsByKind []symbolGroup
 	}{
@@ -1072,6 +1959,7 @@ func (s *Server) handleRustCrate(w http.ResponseWriter, r *http.Request) {
 		SearchQuery:   "",
 		Pkg:           nil,
 		Crate:         crate,
+		Features:      crateFeatures(crate),
 		Symbols:       symbols,
 		SymbolsByKind: symbolsByKind,
 	}
@@ -1095,6 +1983,12 @@ func (s *Server) handleJSPackage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if name, ok := strings.CutSuffix(pkgName, "/dependents"); ok {
+		s.handleJSDependents(w, r, name)
+		return
+	}
+	pkgName, showVersions := strings.CutSuffix(pkgName, "/versions")
+
 	pkg, err := s.db.GetJSPackage(pkgName)
 	if err != nil {
 		log.Printf("Error getting JS package: %v", err)
@@ -1106,6 +2000,11 @@ func (s *Server) handleJSPackage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if showVersions {
+		s.handleRegistryVersions(w, db.EcosystemNPM, "npm", "/npm/"+pkg.Name, pkg.Name, pkg.Version)
+		return
+	}
+
 	symbols, err := s.db.GetJSPackageSymbols(pkg.ID)
 	if err != nil {
 		log.Printf("Error getting JS package symbols: %v", err)
@@ -1141,20 +2040,63 @@ func (s *Server) handleJSPackage(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	dependencies, err := s.db.GetJSDependencies(pkg.Name)
+	if err != nil {
+		log.Printf("Error getting JS dependencies: %v", err)
+	}
+
+	// Group dependencies by relationship type
+	type dependencyGroup struct {
+		ID           string
+		Title        string
+		Dependencies []*db.JSDependency
+	}
+	groups := []*dependencyGroup{
+		{ID: "pkg-dependencies", Title: "Dependencies"},
+		{ID: "pkg-peer-dependencies", Title: "Peer Dependencies"},
+		{ID: "pkg-optional-dependencies", Title: "Optional Dependencies"},
+		{ID: "pkg-dev-dependencies", Title: "Dev Dependencies"},
+	}
+	groupByType := map[string]*dependencyGroup{
+		db.JSDepRuntime:  groups[0],
+		db.JSDepPeer:     groups[1],
+		db.JSDepOptional: groups[2],
+		db.JSDepDev:      groups[3],
+	}
+	var dependencyGroups []*dependencyGroup
+	for _, dep := range dependencies {
+		if g, ok := groupByType[dep.Type]; ok {
+			g.Dependencies = append(g.Dependencies, dep)
+		}
+	}
+	for _, g := range groups {
+		if len(g.Dependencies) > 0 {
+			dependencyGroups = append(dependencyGroups, g)
+		}
+	}
+	dependentsCount, err := s.db.GetJSDependentsCount(pkg.Name)
+	if err != nil {
+		log.Printf("Error counting JS dependents: %v", err)
+	}
+
 	data := struct {
-		Title         string
-		SearchQuery   string
-		Pkg           *PackageDoc
-		JSPkg         *db.JSPackage
-		Symbols       []*db.JSSymbol
-		SymbolsByKind []symbolGroup
+		Title           string
+		SearchQuery     string
+		Pkg             *PackageDoc
+		JSPkg           *db.JSPackage
+		Symbols         []*db.JSSymbol
+		SymbolsByKind   []symbolGroup
+		Dependencies    []*dependencyGroup
+		DependentsCount int
 	}{
-		Title:         pkg.Name + " - npm package",
-		SearchQuery:   "",
-		Pkg:           nil,
-		JSPkg:         pkg,
-		Symbols:       symbols,
-		SymbolsByKind: symbolsByKind,
+		Title:           pkg.Name + " - npm package",
+		SearchQuery:     "",
+		Pkg:             nil,
+		JSPkg:           pkg,
+		Symbols:         symbols,
+		SymbolsByKind:   symbolsByKind,
+		Dependencies:    dependencyGroups,
+		DependentsCount: dependentsCount,
 	}
 
 	if err := s.templates.ExecuteTemplate(w, "js_package.html", data); err != nil {
@@ -1163,6 +2105,69 @@ func (s *Server) handleJSPackage(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleJSDependents handles the list of npm packages depending on a package
+func (s *Server) handleJSDependents(w http.ResponseWriter, r *http.Request, pkgName string) {
+	pkg, err := s.db.GetJSPackage(pkgName)
+	if err != nil {
+		log.Printf("Error getting JS package: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if pkg == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Get pagination params
+	page := 1
+	if p := r.URL.Query().Get("page"); p != "" {
+		if n, err := fmt.Sscanf(p, "%d", &page); err != nil || n != 1 || page < 1 {
+			page = 1
+		}
+	}
+	perPage := 50
+	offset := (page - 1) * perPage
+
+	dependents, total, err := s.db.GetJSDependents(pkg.Name, perPage, offset)
+	if err != nil {
+		log.Printf("Error getting JS dependents: %v", err)
+	}
+
+	totalPages := (total + perPage - 1) / perPage
+	if totalPages < 1 {
+		totalPages = 1
+	}
+
+	data := struct {
+		Title       string
+		SearchQuery string
+		Pkg         *PackageDoc
+		JSPkg       *db.JSPackage
+		Dependents  []*db.JSPackage
+		Total       int
+		Page        int
+		TotalPages  int
+		HasPrev     bool
+		HasNext     bool
+	}{
+		Title:       "Dependents - " + pkg.Name + " - npm package",
+		SearchQuery: "",
+		Pkg:         nil,
+		JSPkg:       pkg,
+		Dependents:  dependents,
+		Total:       total,
+		Page:        page,
+		TotalPages:  totalPages,
+		HasPrev:     page > 1,
+		HasNext:     page < totalPages,
+	}
+
+	if err := s.templates.ExecuteTemplate(w, "js_dependents.html", data); err != nil {
+		log.Printf("Error rendering JS dependents: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
 // handlePythonPackage handles Python/PyPI package pages
 func (s *Server) handlePythonPackage(w http.ResponseWriter, r *http.Request) {
 	pkgName := strings.TrimPrefix(r.URL.Path, "/pypi/")
@@ -1176,6 +2181,8 @@ func (s *Server) handlePythonPackage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	pkgName, showVersions := strings.CutSuffix(pkgName, "/versions")
+
 	pkg, err := s.db.GetPythonPackage(pkgName)
 	if err != nil {
 		log.Printf("Error getting Python package: %v", err)
@@ -1187,6 +2194,11 @@ func (s *Server) handlePythonPackage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if showVersions {
+		s.handleRegistryVersions(w, db.EcosystemPyPI, "PyPI", "/pypi/"+pkg.Name, pkg.Name, pkg.Version)
+		return
+	}
+
 	symbols, err := s.db.GetPythonPackageSymbols(pkg.ID)
 	if err != nil {
 		log.Printf("Error getting Python package symbols: %v", err)
@@ -1338,18 +2350,13 @@ func (s *Server) handleBadge(w http.ResponseWriter, r *http.Request) {
 	if badgeType == "" {
 		badgeType = "go-version"
 	}
+	if badgeType == "custom" && badgeText(r.URL.Query().Get("message")) == "" {
+		http.Error(w, "message required for custom badges", http.StatusBadRequest)
+		return
+	}
 
 	// Find package
-	pkg, ok := s.packages[path]
-	if !ok {
-		for importPath, p := range s.packages {
-			if strings.HasSuffix(importPath, "/"+path) || importPath == path {
-				pkg = p
-				ok = true
-				break
-			}
-		}
-	}
+	pkg, ok := s.FindPackage(path)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Cache-Control", "max-age=3600")
@@ -1392,6 +2399,18 @@ func (s *Server) handleBadge(w http.ResponseWriter, r *http.Request) {
 			"message":       license,
 			"color":         color,
 		}
+	case "deps":
+		direct, _ := goModDependencyCounts(s.parseGoMod(pkg))
+		color := "blue"
+		if direct == 0 {
+			color = "brightgreen"
+		}
+		badge = map[string]interface{}{
+			"schemaVersion": 1,
+			"label":         "dependencies",
+			"message":       strconv.Itoa(direct),
+			"color":         color,
+		}
 	case "valid-mod":
 		msg := "yes"
 		color := "brightgreen"
@@ -1405,6 +2424,13 @@ func (s *Server) handleBadge(w http.ResponseWriter, r *http.Request) {
 			"message":       msg,
 			"color":         color,
 		}
+	case "custom":
+		badge = map[string]interface{}{
+			"schemaVersion": 1,
+			"label":         "wikigo",
+			"message":       badgeText(r.URL.Query().Get("message")),
+			"color":         "00add8",
+		}
 	default:
 		badge = map[string]interface{}{
 			"schemaVersion": 1,
@@ -1414,9 +2440,43 @@ func (s *Server) handleBadge(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Label and color can be overridden for any badge of a known package
+	if label := badgeText(r.URL.Query().Get("label")); label != "" {
+		badge["label"] = label
+	}
+	if color := r.URL.Query().Get("color"); badgeColorRe.MatchString(color) {
+		badge["color"] = color
+	}
+
 	json.NewEncoder(w).Encode(badge)
 }
 
+// maxBadgeText is the maximum length in runes of a user-supplied badge label or message
+const maxBadgeText = 64
+
+// badgeColorRe matches the colors shields.io accepts: hex values and named colors
+var badgeColorRe = regexp.MustCompile(`^(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6}|[a-z]{1,20})$`)
+
+// badgeText cleans up user-supplied badge text: control and other
+// non-printable characters are dropped, whitespace is collapsed and the
+// result is truncated to maxBadgeText runes
+func badgeText(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return ' '
+		}
+		if !unicode.IsPrint(r) {
+			return -1
+		}
+		return r
+	}, text)
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > maxBadgeText {
+		text = strings.TrimSpace(string(runes[:maxBadgeText]))
+	}
+	return text
+}
+
 // handleLicense handles the license full text page
 func (s *Server) handleLicense(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/license/")
@@ -1426,16 +2486,7 @@ func (s *Server) handleLicense(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Find package
-	pkg, ok := s.packages[path]
-	if !ok {
-		for importPath, p := range s.packages {
-			if strings.HasSuffix(importPath, "/"+path) || importPath == path {
-				pkg = p
-				ok = true
-				break
-			}
-		}
-	}
+	pkg, ok := s.FindPackage(path)
 
 	if !ok || pkg.LicenseText == "" {
 		http.NotFound(w, r)
@@ -1467,16 +2518,7 @@ func (s *Server) handleImports(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Find package
-	pkg, ok := s.packages[path]
-	if !ok {
-		for importPath, p := range s.packages {
-			if strings.HasSuffix(importPath, "/"+path) || importPath == path {
-				pkg = p
-				ok = true
-				break
-			}
-		}
-	}
+	pkg, ok := s.FindPackage(path)
 
 	if !ok {
 		http.NotFound(w, r)
@@ -1531,6 +2573,7 @@ type SymbolResult struct {
 	Package    string
 	ImportPath string
 	Synopsis   string
+	Signature  string // set for function shape searches
 	Deprecated bool
 }
 
@@ -1553,8 +2596,20 @@ func (s *Server) handleSymbolSearch(w http.ResponseWriter, r *http.Request) {
 	var allResults []SymbolResult
 	var results []SymbolResult
 	var total int
+	var shapeErr string
 
-	if query != "" {
+	if isShapeQuery(query) {
+		var err error
+		allResults, err = s.searchSymbolsByShape(query, kind, 1000)
+		if err != nil {
+			log.Printf("Shape symbol search error: %v", err)
+			shapeErr = err.Error()
+		}
+		total = len(allResults)
+		if offset < total {
+			results = allResults[offset:min(offset+perPage, total)]
+		}
+	} else if query != "" {
 		// Use database search if available (much faster)
 		if s.db != nil {
 			dbSymbols, err := s.db.SearchSymbols(query, kind, 1000) // Get more for pagination
@@ -1562,8 +2617,15 @@ func (s *Server) handleSymbolSearch(w http.ResponseWriter, r *http.Request) {
 				log.Printf("Database symbol search error: %v", err)
 				// Fall back to in-memory search
 			} else {
-				// Convert db.Symbol to SymbolResult
+				// Convert db.Symbol to SymbolResult, once per symbol: packages indexed
+				// before constructors were deduplicated may store them twice
+				seen := make(map[[3]string]bool)
 				for _, sym := range dbSymbols {
+					key := [3]string{sym.ImportPath, sym.Kind, sym.Name}
+					if seen[key] {
+						continue
+					}
+					seen[key] = true
 					pkg, ok := s.packages[sym.ImportPath]
 					packageName := sym.ImportPath
 					if ok {
@@ -1721,6 +2783,7 @@ render:
 		Pkg         *PackageDoc
 		Query       string
 		Kind        string
+		ShapeError  string
 		Results     []SymbolResult
 		Page        int
 		TotalPages  int
@@ -1734,6 +2797,7 @@ render:
 		Pkg:         nil,
 		Query:       query,
 		Kind:        kind,
+		ShapeError:  shapeErr,
 		Results:     results,
 		Page:        page,
 		TotalPages:  totalPages,
@@ -1749,6 +2813,99 @@ render:
 	}
 }
 
+// handleExamples searches runnable examples across all packages, or lists the
+// examples of one package under /examples/<import path>
+func (s *Server) handleExamples(w http.ResponseWriter, r *http.Request) {
+	importPath := strings.Trim(strings.TrimPrefix(r.URL.Path, "/examples"), "/")
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+
+	var examples []*db.Example
+	var err error
+	switch {
+	case importPath != "":
+		if s.db != nil {
+			examples, err = s.db.GetPackageExamples(importPath)
+		} else if pkg, ok := s.packages[importPath]; ok {
+			examples = packageExamples(pkg)
+		}
+	case query != "":
+		if s.db != nil {
+			examples, err = s.db.SearchExamples(query, 100)
+		} else {
+			examples = s.searchExamplesInMemory(query, 100)
+		}
+	}
+	if err != nil {
+		log.Printf("Error loading examples: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if importPath != "" && len(examples) == 0 {
+		if _, ok := s.FindPackage(importPath); !ok {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	title := "Examples - Go Packages"
+	if importPath != "" {
+		title = "Examples - " + importPath + " - Go Packages"
+	}
+
+	data := struct {
+		Title       string
+		SearchQuery string
+		Pkg         *PackageDoc
+		Query       string
+		ImportPath  string
+		Examples    []*db.Example
+	}{
+		Title:       title,
+		SearchQuery: "",
+		Pkg:         nil,
+		Query:       query,
+		ImportPath:  importPath,
+		Examples:    examples,
+	}
+
+	if err := s.templates.ExecuteTemplate(w, "examples.html", data); err != nil {
+		log.Printf("Error rendering examples: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// searchExamplesInMemory matches examples containing every query word when no database is configured
+func (s *Server) searchExamplesInMemory(query string, limit int) []*db.Example {
+	words := strings.Fields(strings.ToLower(query))
+
+	paths := make([]string, 0, len(s.packages))
+	for path := range s.packages {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var results []*db.Example
+	for _, path := range paths {
+		for _, ex := range packageExamples(s.packages[path]) {
+			text := strings.ToLower(path + " " + ex.Symbol + " " + ex.Doc + " " + ex.Code)
+			match := true
+			for _, word := range words {
+				if !strings.Contains(text, word) {
+					match = false
+					break
+				}
+			}
+			if match {
+				results = append(results, ex)
+				if len(results) >= limit {
+					return results
+				}
+			}
+		}
+	}
+	return results
+}
+
 // handleModule handles the module info page
 func (s *Server) handleModule(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/mod/")
@@ -1757,31 +2914,51 @@ func (s *Server) handleModule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Find package
-	pkg, ok := s.packages[path]
-	if !ok {
-		for importPath, p := range s.packages {
-			if strings.HasSuffix(importPath, "/"+path) || importPath == path {
-				pkg = p
-				ok = true
-				break
-			}
+	// /mod/<path>/raw serves the go.mod itself, unless "raw" is part of the path
+	raw := false
+	if trimmed, ok := strings.CutSuffix(path, "/raw"); ok {
+		if _, exists := s.FindPackage(path); !exists {
+			path, raw = trimmed, true
 		}
 	}
 
+	// Find package
+	pkg, ok := s.FindPackage(path)
+
 	if !ok || pkg.GoModContent == "" {
 		http.NotFound(w, r)
 		return
 	}
 
+	if raw {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, pkg.GoModContent)
+		return
+	}
+
+	// A go.mod that fails to parse is still shown as text
+	gm := s.parseGoMod(pkg)
+	direct, indirect := goModDependencyCounts(gm)
+	checksum, sumDBLookup := s.moduleChecksum(pkg)
+
 	data := struct {
-		Title       string
-		SearchQuery string
-		Pkg         *PackageDoc
+		Title        string
+		SearchQuery  string
+		Pkg          *PackageDoc
+		GoMod        *util.GoMod
+		Dependencies int
+		Indirect     int
+		Checksum     *db.ModuleVersion
+		SumDBLookup  string
 	}{
-		Title:       "Module - " + pkg.ModulePath + " - Go Packages",
-		SearchQuery: "",
-		Pkg:         pkg,
+		Title:        "Module - " + pkg.ModulePath + " - Go Packages",
+		SearchQuery:  "",
+		Pkg:          pkg,
+		GoMod:        gm,
+		Dependencies: direct,
+		Indirect:     indirect,
+		Checksum:     checksum,
+		SumDBLookup:  sumDBLookup,
 	}
 
 	if err := s.templates.ExecuteTemplate(w, "module.html", data); err != nil {
@@ -1790,6 +2967,59 @@ func (s *Server) handleModule(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// moduleChecksum returns the crawled version of a package's module with its
+// checksums, and the checksum database page to compare them with, or nil when
+// the crawler has not hashed that version
+func (s *Server) moduleChecksum(pkg *PackageDoc) (*db.ModuleVersion, string) {
+	if s.db == nil || pkg.ModulePath == "" || pkg.Version == "" {
+		return nil, ""
+	}
+	mv, err := s.db.GetModuleVersion(pkg.ModulePath, pkg.Version)
+	if err != nil {
+		log.Printf("Error fetching module version: %v", err)
+		return nil, ""
+	}
+	if mv == nil || mv.Checksum == "" {
+		return nil, ""
+	}
+	escPath, err := module.EscapePath(mv.ModulePath)
+	if err != nil {
+		return mv, ""
+	}
+	return mv, "https://sum.golang.org/lookup/" + escPath + "@" + mv.Version
+}
+
+// parseGoMod parses a package's go.mod. A file strict parsing rejects, such as
+// one using directives newer than golang.org/x/mod knows, is parsed leniently
+// so its requirements still count. The error is logged once per module.
+func (s *Server) parseGoMod(pkg *PackageDoc) *util.GoMod {
+	gm, err := util.ParseGoMod([]byte(pkg.GoModContent))
+	if err == nil {
+		return gm
+	}
+	module := cmp.Or(pkg.ModulePath, pkg.ImportPath)
+	if _, logged := s.goModErrors.LoadOrStore(module, true); !logged {
+		log.Printf("Error parsing go.mod of %s: %v", module, err)
+	}
+	gm, _ = util.ParseGoModLax([]byte(pkg.GoModContent))
+	return gm
+}
+
+// goModDependencyCounts counts the direct and indirect requirements of a go.mod
+func goModDependencyCounts(gm *util.GoMod) (direct, indirect int) {
+	if gm == nil {
+		return 0, 0
+	}
+	for _, req := range gm.Require {
+		if req.Indirect {
+			indirect++
+		} else {
+			direct++
+		}
+	}
+	return direct, indirect
+}
+
 // handleVersions handles the versions list page
 // VersionInfo represents version information for display
 type VersionInfo struct {
@@ -1798,33 +3028,13 @@ type VersionInfo struct {
 	IsTagged  bool
 	IsStable  bool
 	Retracted bool
+	Yanked    bool
 	IsCurrent bool
 }
 
-func (s *Server) handleVersions(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/versions/")
-	if path == "" {
-		http.NotFound(w, r)
-		return
-	}
-
-	// Find package
-	pkg, ok := s.packages[path]
-	if !ok {
-		for importPath, p := range s.packages {
-			if strings.HasSuffix(importPath, "/"+path) || importPath == path {
-				pkg = p
-				ok = true
-				break
-			}
-		}
-	}
-
-	if !ok {
-		http.NotFound(w, r)
-		return
-	}
-
+// packageVersions returns the version history of a package's module from the
+// database, falling back to the versions recorded with the package
+func (s *Server) packageVersions(pkg *PackageDoc) []VersionInfo {
 	// Get version history from database if available
 	var versions []VersionInfo
 	if s.db != nil {
@@ -1855,6 +3065,25 @@ func (s *Server) handleVersions(w http.ResponseWriter, r *http.Request) {
 			})
 		}
 	}
+	return versions
+}
+
+func (s *Server) handleVersions(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/versions/")
+	if path == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Find package
+	pkg, ok := s.FindPackage(path)
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	versions := s.packageVersions(pkg)
 
 	data := struct {
 		Title       string
@@ -1874,6 +3103,73 @@ func (s *Server) handleVersions(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleRegistryVersions renders the version history of an npm, PyPI or crates.io package
+func (s *Server) handleRegistryVersions(w http.ResponseWriter, ecosystem, registry, packageURL, name, current string) {
+	dbVersions, err := s.db.GetRegistryVersions(ecosystem, name)
+	if err != nil {
+		log.Printf("Error getting %s versions: %v", registry, err)
+	}
+
+	var versions []VersionInfo
+	for _, v := range dbVersions {
+		vi := VersionInfo{
+			Version:   v.Version,
+			IsTagged:  true,
+			IsStable:  !isPrereleaseVersion(v.Version),
+			Yanked:    v.Yanked,
+			IsCurrent: v.Version == current,
+		}
+		if !v.PublishedAt.IsZero() {
+			vi.Timestamp = v.PublishedAt.Format("Jan 2, 2006")
+		}
+		versions = append(versions, vi)
+	}
+
+	data := struct {
+		Title       string
+		SearchQuery string
+		Pkg         *PackageDoc
+		Name        string
+		Registry    string
+		PackageURL  string
+		Versions    []VersionInfo
+	}{
+		Title:       "Versions - " + name + " - " + registry + " package",
+		SearchQuery: "",
+		Pkg:         nil,
+		Name:        name,
+		Registry:    registry,
+		PackageURL:  packageURL,
+		Versions:    versions,
+	}
+
+	if err := s.templates.ExecuteTemplate(w, "registry_versions.html", data); err != nil {
+		log.Printf("Error rendering %s versions: %v", registry, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// isPrereleaseVersion reports whether a registry version looks like a pre-release,
+// covering semver ("1.0.0-beta.1") and PEP 440 ("2.0a1", "1.0rc2", "1.0.dev3") forms
+func isPrereleaseVersion(version string) bool {
+	if strings.Contains(version, "-") {
+		return true
+	}
+	v := strings.ToLower(version)
+	for i := 1; i < len(v); i++ {
+		if v[i-1] < '0' || v[i-1] > '9' {
+			continue
+		}
+		rest := strings.TrimPrefix(v[i:], ".")
+		for _, marker := range []string{"a", "b", "rc", "dev", "alpha", "beta", "pre"} {
+			if strings.HasPrefix(rest, marker) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // ImportedByPackage represents a package that imports another package
 type ImportedByPackage struct {
 	ImportPath string
@@ -1979,259 +3275,136 @@ func firstLine(s string) string {
 	return strings.TrimSpace(s)
 }
 
-func formatDocHTML(doc string) template.HTML {
-	if doc == "" {
-		return ""
-	}
-
-	// Convert doc comments to HTML
-	lines := strings.Split(doc, "\n")
-	var result strings.Builder
-	var codeBlock strings.Builder
-	inPre := false
-
-	for _, line := range lines {
-		// Detect code blocks (lines starting with tab or spaces)
-		if strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "    ") {
-			if !inPre {
-				inPre = true
-				codeBlock.Reset()
-			}
-			// Remove leading tab/spaces for display
-			line = strings.TrimPrefix(line, "\t")
-			line = strings.TrimPrefix(line, "    ")
-			codeBlock.WriteString(line)
-			codeBlock.WriteString("\n")
-		} else {
-			if inPre {
-				result.WriteString(`<pre><code class="language-go">`)
-				result.WriteString(template.HTMLEscapeString(codeBlock.String()))
-				result.WriteString("</code></pre>")
-				inPre = false
-			}
-			if line == "" {
-				result.WriteString("<p></p>")
-			} else if strings.HasPrefix(line, "# ") {
-				// Go 1.19+ doc headers
-				header := strings.TrimPrefix(line, "# ")
-				result.WriteString("<h3 class=\"Documentation-header\">")
-				result.WriteString(template.HTMLEscapeString(header))
-				result.WriteString("</h3>")
-			} else if strings.HasPrefix(line, "## ") {
-				header := strings.TrimPrefix(line, "## ")
-				result.WriteString("<h4 class=\"Documentation-subheader\">")
-				result.WriteString(template.HTMLEscapeString(header))
-				result.WriteString("</h4>")
-			} else {
-				// Convert [Name] references to links
-				processed := processDocLinks(line)
-				result.WriteString("<p>")
-				result.WriteString(processed)
-				result.WriteString("</p>")
-			}
-		}
+// docWithoutParams removes the lines documenting params, which are rendered as a table
+func docWithoutParams(doc string, params []util.ParamDoc) string {
+	if len(params) == 0 {
+		return doc
 	}
-
-	if inPre {
-		result.WriteString(`<pre><code class="language-go">`)
-		result.WriteString(template.HTMLEscapeString(codeBlock.String()))
-		result.WriteString("</code></pre>")
+	names := make([]string, len(params))
+	for i, p := range params {
+		names[i] = p.Name
 	}
-
-	return template.HTML(result.String())
+	_, rest := util.ParseParamDocs(doc, names)
+	return rest
 }
 
-func processDocLinks(text string) string {
-	// First, escape HTML but preserve our special markers
-	escaped := template.HTMLEscapeString(text)
-
-	// Process URLs first (before other processing)
-	escaped = autoLinkURLs(escaped)
-
-	// Process cross-package type references (e.g., io.Reader, http.Handler)
-	escaped = linkCrossPackageTypes(escaped)
+// docParser parses doc comments; [pkg.Name] links resolve against the standard
+// library names as well as full import paths, and [Name] links always point into
+// the current page. linkInstantiations adds links such as [List[int]].
+var docParser = &comment.Parser{
+	LookupPackage: stdPkgPath,
+	LookupSym:     func(recv, name string) bool { return true },
+}
 
-	// Process [Name] references
-	var result strings.Builder
-	i := 0
-	for i < len(escaped) {
-		if escaped[i] == '[' {
-			// Find closing bracket
-			j := i + 1
-			for j < len(escaped) && escaped[j] != ']' {
-				j++
-			}
-			if j < len(escaped) {
-				name := escaped[i+1 : j]
-				// Create anchor link
-				result.WriteString(`<a href="#`)
-				result.WriteString(anchorName(name))
-				result.WriteString(`">`)
-				result.WriteString(name)
-				result.WriteString(`</a>`)
-				i = j + 1
-				continue
-			}
-		}
-		result.WriteByte(escaped[i])
-		i++
-	}
-	return result.String()
+// docPrinter renders doc links the way wikigo serves packages: at /<import path>,
+// with symbols anchored as Name or Type.Method
+var docPrinter = &comment.Printer{
+	DocLinkURL: func(link *comment.DocLink) string {
+		return link.DefaultURL("")
+	},
 }
 
-// linkCrossPackageTypes detects and links cross-package type references
-// like io.Reader, http.Handler, context.Context
-func linkCrossPackageTypes(text string) string {
-	// Standard library packages that are commonly referenced
-	stdPkgs := map[string]bool{
-		"bufio": true, "bytes": true, "context": true, "crypto": true,
-		"encoding": true, "errors": true, "fmt": true, "hash": true,
-		"io": true, "log": true, "math": true, "net": true, "os": true,
-		"path": true, "reflect": true, "regexp": true, "runtime": true,
-		"sort": true, "strconv": true, "strings": true, "sync": true,
-		"syscall": true, "testing": true, "time": true, "unicode": true,
-		"http": true, "url": true, "json": true, "xml": true, "sql": true,
-		"template": true, "exec": true, "filepath": true, "zip": true,
-		"tar": true, "gzip": true, "heap": true, "list": true, "ring": true,
+// formatDocHTML renders a doc comment as HTML following go/doc/comment, the
+// syntax gofmt and pkg.go.dev use. Doc comments are plain text, so any HTML in
+// them, escaped or not, is shown literally rather than interpreted.
+func formatDocHTML(doc string) template.HTML {
+	if strings.TrimSpace(doc) == "" {
+		return ""
 	}
 
 	var result strings.Builder
-	i := 0
-	for i < len(text) {
-		// Check if we're at a word boundary and have a lowercase letter
-		if i == 0 || !isIdentChar(text[i-1]) {
-			// Try to match pattern: lowercase_identifier.UppercaseIdentifier
-			j := i
-			for j < len(text) && isLowerIdentChar(text[j]) {
-				j++
-			}
-			if j > i && j < len(text) && text[j] == '.' {
-				pkgName := text[i:j]
-				if stdPkgs[pkgName] {
-					k := j + 1
-					// Type name must start with uppercase
-					if k < len(text) && text[k] >= 'A' && text[k] <= 'Z' {
-						for k < len(text) && isIdentChar(text[k]) {
-							k++
-						}
-						typeName := text[j+1 : k]
-						// Build the link
-						pkgPath := getStdPkgPath(pkgName)
-						result.WriteString(`<a href="/`)
-						result.WriteString(pkgPath)
-						result.WriteString(`#`)
-						result.WriteString(typeName)
-						result.WriteString(`" class="TypeLink">`)
-						result.WriteString(pkgName)
-						result.WriteString(".")
-						result.WriteString(typeName)
-						result.WriteString(`</a>`)
-						i = k
-						continue
-					}
-				}
-			}
+	for _, block := range docParser.Parse(doc).Content {
+		switch b := block.(type) {
+		case *comment.Heading:
+			fmt.Fprintf(&result, `<h3 id="%s" class="Documentation-header">`, template.HTMLEscapeString(b.DefaultID()))
+			result.WriteString(template.HTMLEscapeString(docPlainText(b.Text)))
+			result.WriteString("</h3>\n")
+		case *comment.Code:
+			result.WriteString(`<pre><code class="language-go">`)
+			result.WriteString(template.HTMLEscapeString(b.Text))
+			result.WriteString("</code></pre>\n")
+		default:
+			// Paragraphs and lists, with their links
+			linkInstantiations(b)
+			result.Write(docPrinter.HTML(&comment.Doc{Content: []comment.Block{b}}))
 		}
-		result.WriteByte(text[i])
-		i++
 	}
-	return result.String()
-}
-
-func isIdentChar(c byte) bool {
-	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_'
+	return template.HTML(result.String())
 }
 
-func isLowerIdentChar(c byte) bool {
-	return (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_'
+// docPlainText returns the text of a heading, without markup
+func docPlainText(text []comment.Text) string {
+	var b strings.Builder
+	for _, t := range text {
+		switch t := t.(type) {
+		case comment.Plain:
+			b.WriteString(string(t))
+		case comment.Italic:
+			b.WriteString(string(t))
+		case *comment.Link:
+			b.WriteString(docPlainText(t.Text))
+		case *comment.DocLink:
+			b.WriteString(docPlainText(t.Text))
+		}
+	}
+	return b.String()
 }
 
-// getStdPkgPath returns the full import path for common std lib short names
-func getStdPkgPath(short string) string {
-	paths := map[string]string{
-		"http":     "net/http",
-		"url":      "net/url",
-		"json":     "encoding/json",
-		"xml":      "encoding/xml",
-		"sql":      "database/sql",
-		"template": "text/template",
-		"exec":     "os/exec",
-		"filepath": "path/filepath",
-		"zip":      "archive/zip",
-		"tar":      "archive/tar",
-		"gzip":     "compress/gzip",
-		"heap":     "container/heap",
-		"list":     "container/list",
-		"ring":     "container/ring",
+func shortDoc(doc string) string {
+	// Return first sentence or first line
+	if idx := strings.Index(doc, "."); idx != -1 {
+		return strings.TrimSpace(doc[:idx+1])
 	}
-	if full, ok := paths[short]; ok {
-		return full
+	if idx := strings.Index(doc, "\n"); idx != -1 {
+		return strings.TrimSpace(doc[:idx])
 	}
-	return short
+	return strings.TrimSpace(doc)
 }
 
-func autoLinkURLs(text string) string {
-	// Simple URL detection and auto-linking
-	var result strings.Builder
-	i := 0
-	for i < len(text) {
-		// Check for RFC references (RFC 1234)
-		if i+4 < len(text) && text[i:i+4] == "RFC " {
-			j := i + 4
-			// Find RFC number
-			for j < len(text) && text[j] >= '0' && text[j] <= '9' {
-				j++
-			}
-			if j > i+4 {
-				rfcNum := text[i+4 : j]
-				result.WriteString(`<a href="https://www.rfc-editor.org/rfc/rfc`)
-				result.WriteString(rfcNum)
-				result.WriteString(`" target="_blank">RFC `)
-				result.WriteString(rfcNum)
-				result.WriteString(`</a>`)
-				i = j
-				continue
-			}
-		}
-		// Check for http:// or https://
-		if i+7 < len(text) && (text[i:i+7] == "http://" || (i+8 < len(text) && text[i:i+8] == "https://")) {
-			// Find end of URL
-			j := i
-			for j < len(text) && !isURLTerminator(text[j]) {
-				j++
-			}
-			// Trim trailing punctuation
-			for j > i && (text[j-1] == '.' || text[j-1] == ',' || text[j-1] == ')' || text[j-1] == ';') {
-				j--
-			}
-			url := text[i:j]
-			result.WriteString(`<a href="`)
-			result.WriteString(url)
-			result.WriteString(`" target="_blank">`)
-			result.WriteString(url)
-			result.WriteString(`</a>`)
-			i = j
-			continue
+// truncate shortens s to at most n characters, cutting at the last word boundary
+// and appending an ellipsis. A single word longer than n is cut between characters.
+// n <= 0 leaves s unchanged.
+func truncate(s string, n int) string {
+	runes := []rune(strings.TrimSpace(s))
+	if n <= 0 || len(runes) <= n {
+		return string(runes)
+	}
+
+	// Leave room for the ellipsis
+	cut := runes[:max(n-1, 1)]
+	// Unless the cut already falls right after a word, drop the partial word
+	if !unicode.IsSpace(runes[len(cut)]) {
+		if i := lastSpace(cut); i > 0 {
+			cut = cut[:i]
 		}
-		result.WriteByte(text[i])
-		i++
 	}
-	return result.String()
+	return strings.TrimRightFunc(string(cut), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + "…"
 }
 
-func isURLTerminator(c byte) bool {
-	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '<' || c == '>'
+// lastSpace returns the index of the last whitespace rune in runes, or -1
+func lastSpace(runes []rune) int {
+	for i := len(runes) - 1; i >= 0; i-- {
+		if unicode.IsSpace(runes[i]) {
+			return i
+		}
+	}
+	return -1
 }
 
-func shortDoc(doc string) string {
-	// Return first sentence or first line
-	if idx := strings.Index(doc, "."); idx != -1 {
-		return strings.TrimSpace(doc[:idx+1])
+// formatSize renders a byte count in human-readable units
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
 	}
-	if idx := strings.Index(doc, "\n"); idx != -1 {
-		return strings.TrimSpace(doc[:idx])
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
 	}
-	return strings.TrimSpace(doc)
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGT"[exp])
 }
 
 func anchorName(name string) string {
@@ -2239,7 +3412,8 @@ func anchorName(name string) string {
 	return strings.ReplaceAll(name, " ", "-")
 }
 
-func sourceLink(importPath, filename string, line int) string {
+func sourceLink(pkg *PackageDoc, filename string, line int) string {
+	importPath := pkg.ImportPath
 	// Generate link to source code on Go's source browser
 	// For standard library packages
 	if !strings.Contains(importPath, ".") {
@@ -2248,7 +3422,14 @@ func sourceLink(importPath, filename string, line int) string {
 		}
 		return "https://cs.opensource.google/go/go/+/refs/tags/go1.23.0:src/" + importPath + "/"
 	}
-	// For third-party packages, link to pkg.go.dev
+	// Link into the repository of modules on known code hosts, including
+	// modules in a subdirectory of their repository
+	if pkgDir, ok := strings.CutPrefix(importPath, pkg.ModulePath); pkg.ModulePath != "" && ok && (pkgDir == "" || pkgDir[0] == '/') {
+		if url := util.SourceURL(pkg.ModulePath, pkg.Version, strings.TrimPrefix(pkgDir, "/"), filename, line); url != "" {
+			return url
+		}
+	}
+	// For other third-party packages, link to pkg.go.dev
 	return "https://pkg.go.dev/" + importPath + "#section-sourcefiles"
 }
 
@@ -2285,6 +3466,7 @@ type DiffEntry struct {
 	OldDecl   string
 	NewDecl   string
 	Synopsis  string
+	Breaking  bool // incompatible under Go's compatibility rules
 }
 
 // handleDiff handles the API diff between two versions of a package
@@ -2297,6 +3479,9 @@ func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request) {
 
 	v1 := r.URL.Query().Get("v1")
 	v2 := r.URL.Query().Get("v2")
+	if importPath, from, to, ok := parseDiffPermalink(path); ok {
+		path, v1, v2 = importPath, from, to
+	}
 
 	// Find package
 	pkg, ok := s.packages[path]
@@ -2340,6 +3525,7 @@ func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request) {
 	if v1 != "" && v2 != "" {
 		diff = s.calculateDiff(pkg, v1, v2)
 	}
+	verdict := Verdict(diff, v1)
 
 	data := struct {
 		Title       string
@@ -2350,6 +3536,8 @@ func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request) {
 		V2          string
 		Diff        []DiffEntry
 		HasDiff     bool
+		Verdict     APIVerdict
+		Permalink   string
 	}{
 		Title:       "API Diff - " + pkg.ImportPath + " - Go Packages",
 		SearchQuery: "",
@@ -2359,6 +3547,8 @@ func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request) {
 		V2:          v2,
 		Diff:        diff,
 		HasDiff:     v1 != "" && v2 != "",
+		Verdict:     verdict,
+		Permalink:   diffPermalink(pkg.ImportPath, v1, v2),
 	}
 
 	if err := s.templates.ExecuteTemplate(w, "diff.html", data); err != nil {
@@ -2436,59 +3626,83 @@ func (s *Server) calculateDiff(pkg *PackageDoc, v1, v2 string) []DiffEntry {
 	return diff
 }
 
-// handleCompare handles the package comparison view
+// handleCompare handles the package comparison view. Either side may be a
+// crates.io/, npm/, pypi/ or packagist/ package, compared by symbol names.
 func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
 	pkg1Path := r.URL.Query().Get("pkg1")
 	pkg2Path := r.URL.Query().Get("pkg2")
+	if path1, path2, ok := parseComparePermalink(strings.TrimPrefix(r.URL.Path, "/compare/")); ok {
+		pkg1Path, pkg2Path = path1, path2
+	}
 
 	var pkg1, pkg2 *PackageDoc
-
-	if pkg1Path != "" {
-		if p, ok := s.packages[pkg1Path]; ok {
-			pkg1 = p
+	var surface1, surface2 []apiSymbol
+	resolve := func(path string) (*PackageDoc, []apiSymbol) {
+		if path == "" {
+			return nil, nil
 		}
-	}
-
-	if pkg2Path != "" {
-		if p, ok := s.packages[pkg2Path]; ok {
-			pkg2 = p
+		if isForeignPath(path) {
+			pkg, surface, ok := s.foreignPackage(path)
+			if !ok {
+				return nil, nil
+			}
+			return pkg, surface
 		}
+		if pkg, ok := s.FindPackage(path); ok {
+			return pkg, goSurface(pkg)
+		}
+		return nil, nil
 	}
+	pkg1, surface1 = resolve(pkg1Path)
+	pkg2, surface2 = resolve(pkg2Path)
 
 	// Get list of all packages for selection
 	var allPackages []string
 	for path := range s.packages {
 		allPackages = append(allPackages, path)
 	}
+	sort.Strings(allPackages)
 
-	// Compare packages if both are selected
+	// Compare packages if both are selected; signatures only compare within Go
 	var comparison []DiffEntry
+	var similarity int
+	crossLanguage := isForeignPath(pkg1Path) || isForeignPath(pkg2Path)
 	if pkg1 != nil && pkg2 != nil {
-		comparison = s.comparePackages(pkg1, pkg2)
+		if crossLanguage {
+			comparison, similarity = compareSurfaces(surface1, surface2)
+		} else {
+			comparison = s.comparePackages(pkg1, pkg2)
+		}
 	}
 
 	data := struct {
-		Title       string
-		SearchQuery string
-		Pkg         *PackageDoc
-		AllPackages []string
-		Pkg1Path    string
-		Pkg2Path    string
-		Pkg1        *PackageDoc
-		Pkg2        *PackageDoc
-		Comparison  []DiffEntry
-		HasCompare  bool
+		Title         string
+		SearchQuery   string
+		Pkg           *PackageDoc
+		AllPackages   []string
+		Pkg1Path      string
+		Pkg2Path      string
+		Pkg1          *PackageDoc
+		Pkg2          *PackageDoc
+		Comparison    []DiffEntry
+		HasCompare    bool
+		CrossLanguage bool
+		Similarity    int // percentage of symbols found in both packages
+		Permalink     string
 	}{
-		Title:       "Compare Packages - Go Packages",
-		SearchQuery: "",
-		Pkg:         nil,
-		AllPackages: allPackages,
-		Pkg1Path:    pkg1Path,
-		Pkg2Path:    pkg2Path,
-		Pkg1:        pkg1,
-		Pkg2:        pkg2,
-		Comparison:  comparison,
-		HasCompare:  pkg1 != nil && pkg2 != nil,
+		Title:         "Compare Packages - Go Packages",
+		SearchQuery:   "",
+		Pkg:           nil,
+		AllPackages:   allPackages,
+		Pkg1Path:      pkg1Path,
+		Pkg2Path:      pkg2Path,
+		Pkg1:          pkg1,
+		Pkg2:          pkg2,
+		Comparison:    comparison,
+		HasCompare:    pkg1 != nil && pkg2 != nil,
+		CrossLanguage: crossLanguage,
+		Similarity:    similarity,
+		Permalink:     comparePermalink(pkg1Path, pkg2Path),
 	}
 
 	if err := s.templates.ExecuteTemplate(w, "compare.html", data); err != nil {
@@ -2561,6 +3775,13 @@ func (s *Server) comparePackages(pkg1, pkg2 *PackageDoc) []DiffEntry {
 		}
 	}
 
+	// Symbols come from maps; keep the order stable so permalinks render the same
+	sort.Slice(diff, func(i, j int) bool {
+		if diff[i].Type != diff[j].Type {
+			return diff[i].Type < diff[j].Type
+		}
+		return diff[i].Name < diff[j].Name
+	})
 	return diff
 }
 
@@ -2663,37 +3884,46 @@ func (s *Server) handleSemanticSearch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get all embeddings for the language
-	embeddings, err := s.db.GetAllEmbeddings(lang)
-	if err != nil {
-		log.Printf("Error fetching embeddings: %v", err)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"results": []map[string]interface{}{},
-			"error":   "database error",
-		})
-		return
-	}
-
 	// Calculate similarity scores
 	type scoredResult struct {
 		ImportPath string
 		Score      float32
 	}
 	var scored []scoredResult
-	for _, emb := range embeddings {
-		score := ai.CosineSimilarity(queryEmbedding, emb.Embedding)
-		if score > 0.5 { // Only include results above threshold
-			scored = append(scored, scoredResult{
-				ImportPath: emb.ImportPath,
-				Score:      score,
+
+	if idx := s.vectors.get(lang); idx != nil {
+		for _, m := range idx.Search(queryEmbedding, limit) {
+			if m.Score > 0.5 { // Only include results above threshold
+				scored = append(scored, scoredResult{ImportPath: m.ID, Score: m.Score})
+			}
+		}
+	} else {
+		// Index not built yet: scan all embeddings for the language
+		embeddings, err := s.db.GetAllEmbeddings(lang)
+		if err != nil {
+			log.Printf("Error fetching embeddings: %v", err)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"results": []map[string]interface{}{},
+				"error":   "database error",
 			})
+			return
 		}
-	}
 
-	// Sort by score descending
-	sort.Slice(scored, func(i, j int) bool {
-		return scored[i].Score > scored[j].Score
-	})
+		for _, emb := range embeddings {
+			score := ai.CosineSimilarity(queryEmbedding, emb.Embedding)
+			if score > 0.5 { // Only include results above threshold
+				scored = append(scored, scoredResult{
+					ImportPath: emb.ImportPath,
+					Score:      score,
+				})
+			}
+		}
+
+		// Sort by score descending
+		sort.Slice(scored, func(i, j int) bool {
+			return scored[i].Score > scored[j].Score
+		})
+	}
 
 	// Limit results
 	if len(scored) > limit {
@@ -2757,7 +3987,7 @@ func (s *Server) handleUnderstandQuery(w http.ResponseWriter, r *http.Request) {
 	if s.db != nil && len(understanding.Keywords) > 0 {
 		// Search using the first keyword
 		for _, keyword := range understanding.Keywords[:min(2, len(understanding.Keywords))] {
-			pkgs, err := s.db.SearchPackages(keyword, 5)
+			pkgs, err := s.db.SearchPackages(keyword, 5, false)
 			if err == nil {
 				for _, pkg := range pkgs {
 					suggestedPackages = append(suggestedPackages, map[string]interface{}{
@@ -2799,12 +4029,6 @@ func (s *Server) handleGenerateExample(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if AI service is available
-	if s.aiService == nil || !s.aiService.IsEnabled(ai.FlagAutoExamples) {
-		http.Error(w, "Example generation service not available", http.StatusServiceUnavailable)
-		return
-	}
-
 	// Parse request body
 	var req struct {
 		FunctionName string `json:"function_name"`
@@ -2823,6 +4047,12 @@ func (s *Server) handleGenerateExample(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Check if AI service is available
+	if s.aiService == nil || !s.aiService.IsEnabled(ai.FlagAutoExamples) {
+		http.Error(w, "Example generation service not available", http.StatusServiceUnavailable)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 
 	// Check if example exists in database
@@ -2890,12 +4120,6 @@ func (s *Server) handleTranslate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if AI service is available
-	if s.aiService == nil || !s.aiService.IsEnabled(ai.FlagDocTranslation) {
-		http.Error(w, "Translation service not available", http.StatusServiceUnavailable)
-		return
-	}
-
 	// Parse request body
 	var req struct {
 		Text     string `json:"text"`
@@ -2912,6 +4136,12 @@ func (s *Server) handleTranslate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Check if AI service is available
+	if s.aiService == nil || !s.aiService.IsEnabled(ai.FlagDocTranslation) {
+		http.Error(w, "Translation service not available", http.StatusServiceUnavailable)
+		return
+	}
+
 	// Translate
 	translated, err := s.aiService.TranslateDocumentation(req.Text, req.Language)
 	if err != nil {
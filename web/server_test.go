@@ -1,11 +1,23 @@
 package web
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/alexisbouchez/wikigo/db"
+	"github.com/alexisbouchez/wikigo/util"
 )
 
 func TestHandleHome(t *testing.T) {
@@ -30,6 +42,60 @@ func TestHandleHome(t *testing.T) {
 	}
 }
 
+func TestHandleHome_ContentNegotiation(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	s.packages["github.com/x/y/pkg"] = &PackageDoc{ImportPath: "github.com/x/y/pkg", Name: "pkg", Synopsis: "Package pkg does things."}
+
+	tests := []struct {
+		path     string
+		accept   string
+		wantCode int
+		wantJSON bool
+	}{
+		{"/github.com/x/y/pkg", "application/json", http.StatusOK, true},
+		{"/github.com/x/y/pkg", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", http.StatusOK, false},
+		{"/github.com/x/y/pkg", "text/html;q=0.5, application/json", http.StatusOK, true},
+		{"/github.com/x/y/pkg", "application/json;q=0.5, text/html", http.StatusOK, false},
+		{"/github.com/x/y/pkg", "", http.StatusOK, false},
+		{"/github.com/x/y/missing", "application/json", http.StatusNotFound, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			s.handleHome(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("Vary = %q, want Accept", got)
+			}
+			isJSON := strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+			if isJSON != tt.wantJSON {
+				t.Fatalf("JSON response = %v, want %v", isJSON, tt.wantJSON)
+			}
+			if tt.wantJSON && tt.wantCode == http.StatusOK {
+				var pkg PackageDoc
+				if err := json.Unmarshal(w.Body.Bytes(), &pkg); err != nil {
+					t.Fatalf("invalid JSON: %v", err)
+				}
+				if pkg.ImportPath != "github.com/x/y/pkg" {
+					t.Errorf("import_path = %q", pkg.ImportPath)
+				}
+			}
+		})
+	}
+}
+
 func TestHandleSearch_Empty(t *testing.T) {
 	s, err := NewServerWithDB(".", "")
 	if err != nil {
@@ -92,6 +158,47 @@ func TestHandleAPI_ListPackages(t *testing.T) {
 	}
 }
 
+func TestHandleAPI_Index(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	req := httptest.NewRequest("GET", "http://docs.example.com/api/", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	w := httptest.NewRecorder()
+	s.handleAPI(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	patterns := make(map[string]bool)
+	for _, route := range s.apiRoutes() {
+		if !strings.Contains(body, "<code>"+route.Path+"</code>") {
+			t.Errorf("endpoint %s missing from the index", route.Path)
+		}
+		if route.handler != nil {
+			if patterns[route.pattern] {
+				t.Errorf("pattern %s registered twice", route.pattern)
+			}
+			patterns[route.pattern] = true
+		}
+	}
+	if !strings.Contains(body, "curl &#39;http://docs.example.com/api/search?q=http&amp;lang=go&#39;") {
+		t.Error("expected a copyable curl command using the request host")
+	}
+
+	// API clients still get the package list
+	req = httptest.NewRequest("GET", "/api/", nil)
+	w = httptest.NewRecorder()
+	s.handleAPI(w, req)
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+}
+
 func TestHandleAPI_Search(t *testing.T) {
 	s, err := NewServerWithDB(".", "")
 	if err != nil {
@@ -251,6 +358,120 @@ func TestHandleModule_NotFound(t *testing.T) {
 	}
 }
 
+func TestHandleModule_RawAndDependencies(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	goMod := `module example.com/raw
+
+go 1.22
+
+toolchain go1.22.3
+
+require example.com/single v1.0.0
+
+require (
+	example.com/a v1.2.3
+	example.com/b v0.1.0
+	example.com/c v0.2.0 // indirect
+)
+
+replace example.com/a => github.com/fork/a v1.2.4
+`
+	s.packages["example.com/raw"] = &PackageDoc{
+		Name:         "raw",
+		ImportPath:   "example.com/raw",
+		ModulePath:   "example.com/raw",
+		GoModContent: goMod,
+	}
+
+	req := httptest.NewRequest("GET", "/mod/example.com/raw/raw", nil)
+	w := httptest.NewRecorder()
+	s.handleModule(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("raw: expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("raw: expected text/plain, got %q", ct)
+	}
+	if w.Body.String() != goMod {
+		t.Errorf("raw: body = %q, want go.mod content", w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/mod/example.com/raw", nil)
+	w = httptest.NewRecorder()
+	s.handleModule(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("page: expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "depends on 3 modules (1 indirect)") {
+		t.Errorf("page: missing dependency count")
+	}
+	if !strings.Contains(body, "go1.22.3") {
+		t.Errorf("page: missing toolchain")
+	}
+	if !strings.Contains(body, "github.com/fork/a v1.2.4") {
+		t.Errorf("page: missing replace directive")
+	}
+	if !strings.Contains(body, `<a href="https://github.com/fork/a"`) {
+		t.Errorf("page: replace to a fork should link the fork's repository")
+	}
+
+	req = httptest.NewRequest("GET", "/badge/example.com/raw?type=deps", nil)
+	w = httptest.NewRecorder()
+	s.handleBadge(w, req)
+	var badge map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &badge); err != nil {
+		t.Fatalf("failed to parse badge JSON: %v", err)
+	}
+	if badge["label"] != "dependencies" || badge["message"] != "3" {
+		t.Errorf("deps badge = %v, want dependencies/3", badge)
+	}
+}
+
+func TestHandleModule_LaxGoMod(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	// A directive newer than golang.org/x/mod knows fails strict parsing
+	s.packages["example.com/future"] = &PackageDoc{
+		Name:         "future",
+		ImportPath:   "example.com/future",
+		ModulePath:   "example.com/future",
+		GoModContent: "module example.com/future\n\ngo 1.22\n\nfrobnicate on\n\nrequire (\n\texample.com/a v1.0.0\n\texample.com/b v1.0.0 // indirect\n)\n",
+	}
+	var logs strings.Builder
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	for range 2 {
+		w := httptest.NewRecorder()
+		s.handleModule(w, httptest.NewRequest("GET", "/mod/example.com/future", nil))
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "depends on 1 module (1 indirect)") {
+			t.Errorf("page: status %d, missing dependency count", w.Code)
+		}
+	}
+	w := httptest.NewRecorder()
+	s.handleBadge(w, httptest.NewRequest("GET", "/badge/example.com/future?type=deps", nil))
+	var badge map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &badge); err != nil {
+		t.Fatalf("failed to parse badge JSON: %v", err)
+	}
+	if badge["message"] != "1" {
+		t.Errorf("deps badge = %v, want 1 dependency", badge)
+	}
+	if n := strings.Count(logs.String(), "Error parsing go.mod of example.com/future"); n != 1 {
+		t.Errorf("logged the parse error %d times, want once:\n%s", n, logs.String())
+	}
+}
+
 func TestHandleVersions_NotFound(t *testing.T) {
 	s, err := NewServerWithDB(".", "")
 	if err != nil {
@@ -841,6 +1062,154 @@ func TestHandleRustCrate_Redirect(t *testing.T) {
 	}
 }
 
+func TestHandleRustCrate_Features(t *testing.T) {
+	s, handler := seededServer(t)
+
+	_, err := s.db.UpsertRustCrate(&db.RustCrate{
+		Name:    "tokio",
+		Version: "1.40.0",
+		WASM:    true,
+		Features: map[string][]string{
+			"default":         {"full"},
+			"full":            {"macros", "rt-multi-thread"},
+			"macros":          {"tokio-macros"},
+			"rt-multi-thread": {"rt"},
+			"rt":              nil,
+			"tracing":         {"dep:tracing"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("UpsertRustCrate() error = %v", err)
+	}
+
+	crate, _ := s.db.GetRustCrate("tokio")
+	var got []string
+	for _, f := range crateFeatures(crate) {
+		got = append(got, fmt.Sprintf("%s=%v", f.Name, f.Default))
+	}
+	if want := "full=true macros=true rt=true rt-multi-thread=true tracing=false"; strings.Join(got, " ") != want {
+		t.Errorf("crateFeatures() = %s, want %s", strings.Join(got, " "), want)
+	}
+
+	w := serve(handler, "/crates.io/tokio")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"Features (5)",
+		"Enabled by default: <code>full</code>",
+		`<li id="feature-rt-multi-thread">`,
+		"<code>dep:tracing</code>",
+		">wasm</span>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("crate page does not contain %q", want)
+		}
+	}
+	if strings.Contains(body, ">no_std</span>") {
+		t.Error("crate page shows a no_std badge for a crate that needs std")
+	}
+}
+
+func TestFindImplementations(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	io := &PackageDoc{
+		ImportPath: "io",
+		Name:       "io",
+		ModulePath: "std",
+		Types: []Type{
+			{Name: "Writer", Interface: true, MethodSet: []string{"Write([]byte) (int, error)"}},
+			{Name: "discard", MethodSet: []string{"Write([]byte) (int, error)"}},
+		},
+	}
+	bytes := &PackageDoc{
+		ImportPath: "bytes",
+		Name:       "bytes",
+		ModulePath: "std",
+		Types: []Type{
+			{Name: "Buffer", MethodSet: []string{"*Len() int", "*Write([]byte) (int, error)"}},
+			{Name: "Reader", MethodSet: []string{"*Len() int"}},
+		},
+	}
+	other := &PackageDoc{
+		ImportPath: "example.com/sink",
+		Name:       "sink",
+		ModulePath: "example.com/sink",
+		Types:      []Type{{Name: "Sink", MethodSet: []string{"Write([]byte) (int, error)"}}},
+	}
+	for _, p := range []*PackageDoc{io, bytes, other} {
+		s.packages[p.ImportPath] = p
+	}
+
+	impls := s.findImplementations(io, &io.Types[0])
+	if len(impls) != 1 {
+		t.Fatalf("findImplementations() = %+v, want only bytes.Buffer", impls)
+	}
+	if impls[0].ImportPath != "bytes" || impls[0].Name != "Buffer" || !impls[0].Pointer {
+		t.Errorf("findImplementations() = %+v, want *bytes.Buffer", impls[0])
+	}
+
+	req := httptest.NewRequest("GET", "/io", nil)
+	w := httptest.NewRecorder()
+	s.handleHome(w, req)
+	if !strings.Contains(w.Body.String(), `href="/bytes#Buffer"`) {
+		t.Error("io page does not link to bytes.Buffer implementation")
+	}
+}
+
+func TestFindImplementations_Database(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	for _, p := range []*PackageDoc{
+		{
+			ImportPath: "io",
+			Name:       "io",
+			ModulePath: "std",
+			Types:      []Type{{Name: "Writer", Interface: true, MethodSet: []string{"Write([]byte) (int, error)"}}},
+		},
+		{
+			ImportPath: "bytes",
+			Name:       "bytes",
+			ModulePath: "std",
+			Types:      []Type{{Name: "Buffer", MethodSet: []string{"*Len() int", "*Write([]byte) (int, error)"}}},
+		},
+		{
+			ImportPath: "example.com/sink",
+			Name:       "sink",
+			ModulePath: "example.com/sink",
+			Types:      []Type{{Name: "Sink", MethodSet: []string{"Write([]byte) (int, error)"}}},
+		},
+	} {
+		if err := s.IndexPackage(p); err != nil {
+			t.Fatalf("IndexPackage(%s) error = %v", p.ImportPath, err)
+		}
+	}
+
+	// Nothing is loaded in memory: both sides come from the database
+	dbPkg, err := s.db.GetPackage("io")
+	if err != nil || dbPkg == nil {
+		t.Fatalf("GetPackage() = %v, %v", dbPkg, err)
+	}
+	io := s.dbPackageToDoc(dbPkg)
+	if len(io.Types) != 1 || !io.Types[0].Interface {
+		t.Fatalf("rebuilt io types = %+v, want the Writer interface", io.Types)
+	}
+	impls := s.findImplementations(io, &io.Types[0])
+	if len(impls) != 1 || impls[0].ImportPath != "bytes" || impls[0].Name != "Buffer" || !impls[0].Pointer {
+		t.Errorf("findImplementations() = %+v, want only *bytes.Buffer", impls)
+	}
+}
+
 func TestHandleJSPackage_Redirect(t *testing.T) {
 	s, err := NewServerWithDB(".", "")
 	if err != nil {
@@ -858,6 +1227,113 @@ func TestHandleJSPackage_Redirect(t *testing.T) {
 	}
 }
 
+func TestHandleJSPackage_Dependents(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "npm.db")
+	s, err := NewServerWithOptions(Options{DataDir: t.TempDir(), DBPath: dbPath})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	pkgs := []*db.JSPackage{
+		{Name: "express", Version: "4.18.2", Dependencies: map[string]string{"cookie": "0.5.0"}},
+		{Name: "my-app", Version: "1.0.0", Description: "An express app", Dependencies: map[string]string{"express": "^4.18.0"}},
+		{Name: "react-widgets", Version: "1.0.0", PeerDependencies: map[string]string{"react": ">=16.8.0"}},
+	}
+	for _, pkg := range pkgs {
+		if _, err := s.db.UpsertJSPackage(pkg); err != nil {
+			t.Fatalf("UpsertJSPackage(%s) error = %v", pkg.Name, err)
+		}
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/npm/express", `href="/npm/cookie"`},
+		{"/npm/express", "Dependents: 1"},
+		{"/npm/express/dependents", `href="/npm/my-app"`},
+		{"/npm/my-app", "^4.18.0"},
+		{"/npm/react-widgets", `id="pkg-peer-dependencies"`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		w := httptest.NewRecorder()
+		s.handleJSPackage(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected 200, got %d", tt.path, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("GET %s: body does not contain %q", tt.path, tt.want)
+		}
+	}
+
+	// Peer dependencies are not rendered as runtime dependencies
+	req := httptest.NewRequest("GET", "/npm/react-widgets", nil)
+	w := httptest.NewRecorder()
+	s.handleJSPackage(w, req)
+	if strings.Contains(w.Body.String(), `id="pkg-dependencies"`) {
+		t.Error("react-widgets should not have a runtime dependencies section")
+	}
+}
+
+func TestHandleRegistryVersions(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "registry.db")
+	s, err := NewServerWithOptions(Options{DataDir: t.TempDir(), DBPath: dbPath})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	if _, err := s.db.UpsertPythonPackage(&db.PythonPackage{Name: "requests", Version: "2.31.0"}); err != nil {
+		t.Fatalf("UpsertPythonPackage() error = %v", err)
+	}
+	versions := []*db.RegistryVersion{
+		{Version: "2.31.0", PublishedAt: time.Date(2023, 5, 22, 0, 0, 0, 0, time.UTC)},
+		{Version: "3.0.0a1", PublishedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{Version: "2.32.0", PublishedAt: time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC), Yanked: true},
+	}
+	if err := s.db.ReplaceRegistryVersions(db.EcosystemPyPI, "requests", versions); err != nil {
+		t.Fatalf("ReplaceRegistryVersions() error = %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/pypi/requests/versions", nil)
+	w := httptest.NewRecorder()
+	s.handlePythonPackage(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{"2.31.0", "May 22, 2023", "3.0.0a1", "Pre-release", "Current", "Yanked"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("versions page does not contain %q", want)
+		}
+	}
+}
+
+func TestIsPrereleaseVersion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"1.0.0", false},
+		{"4.17.21", false},
+		{"1.0.0-beta.1", true},
+		{"2.0a1", true},
+		{"1.0rc2", true},
+		{"1.0.dev3", true},
+		{"1.0.post1", false},
+	}
+
+	for _, tt := range tests {
+		if result := isPrereleaseVersion(tt.input); result != tt.expected {
+			t.Errorf("isPrereleaseVersion(%q) = %v, want %v", tt.input, result, tt.expected)
+		}
+	}
+}
+
 func TestHandlePythonPackage_Redirect(t *testing.T) {
 	s, err := NewServerWithDB(".", "")
 	if err != nil {
@@ -947,6 +1423,54 @@ func TestShortDoc(t *testing.T) {
 	}
 }
 
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		input string
+		n     int
+		want  string
+	}{
+		{"Package fmt implements formatted I/O.", 100, "Package fmt implements formatted I/O."},
+		{"Package fmt implements formatted I/O.", 20, "Package fmt…"},
+		{"Package fmt implements formatted I/O.", 23, "Package fmt implements…"},
+		{"Package fmt, implements formatted I/O.", 13, "Package fmt…"},
+		{"  Trimmed before counting  ", 24, "Trimmed before counting"},
+		{"Supercalifragilistic words", 8, "Superca…"},
+		{"Ünïcödé strings are cut by character", 10, "Ünïcödé…"},
+		{"日本語のテキスト", 5, "日本語の…"},
+		{"Unlimited", 0, "Unlimited"},
+		{"Unlimited", -1, "Unlimited"},
+	}
+
+	for _, tt := range tests {
+		got := truncate(tt.input, tt.n)
+		if got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.input, tt.n, got, tt.want)
+		}
+		if tt.n > 0 && len([]rune(got)) > tt.n {
+			t.Errorf("truncate(%q, %d) = %q, longer than %d characters", tt.input, tt.n, got, tt.n)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		input    int64
+		expected string
+	}{
+		{0, "0 B"},
+		{512, "512 B"},
+		{1536, "1.5 KB"},
+		{1392640, "1.3 MB"},
+	}
+
+	for _, tt := range tests {
+		result := formatSize(tt.input)
+		if result != tt.expected {
+			t.Errorf("formatSize(%d) = %q, want %q", tt.input, result, tt.expected)
+		}
+	}
+}
+
 func TestAnchorName(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -965,6 +1489,29 @@ func TestAnchorName(t *testing.T) {
 	}
 }
 
+func TestSourceLink(t *testing.T) {
+	tests := []struct {
+		pkg      *PackageDoc
+		filename string
+		line     int
+		expected string
+	}{
+		{&PackageDoc{ImportPath: "net/http"}, "server.go", 42, "https://cs.opensource.google/go/go/+/refs/tags/go1.23.0:src/net/http/server.go;l=42"},
+		{&PackageDoc{ImportPath: "github.com/org/repo/sub/pkg", ModulePath: "github.com/org/repo/sub", Version: "v1.2.0"}, "pkg.go", 7,
+			"https://github.com/org/repo/blob/sub/v1.2.0/sub/pkg/pkg.go#L7"},
+		{&PackageDoc{ImportPath: "github.com/org/repo", ModulePath: "github.com/org/repo", Version: "v1.0.0"}, "repo.go", 3,
+			"https://github.com/org/repo/blob/v1.0.0/repo.go#L3"},
+		{&PackageDoc{ImportPath: "example.com/lib", ModulePath: "example.com/lib"}, "lib.go", 1, "https://pkg.go.dev/example.com/lib#section-sourcefiles"},
+		{&PackageDoc{ImportPath: "github.com/org/repo"}, "repo.go", 1, "https://pkg.go.dev/github.com/org/repo#section-sourcefiles"},
+	}
+
+	for _, tt := range tests {
+		if result := sourceLink(tt.pkg, tt.filename, tt.line); result != tt.expected {
+			t.Errorf("sourceLink(%s, %q, %d) = %q, want %q", tt.pkg.ImportPath, tt.filename, tt.line, result, tt.expected)
+		}
+	}
+}
+
 func TestFormatDoc(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -982,3 +1529,793 @@ func TestFormatDoc(t *testing.T) {
 		}
 	}
 }
+
+func TestFormatDocHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"empty", "", ""},
+		{"blank", "\n\n", ""},
+		{
+			"paragraphs",
+			"First line\ncontinues.\n\n\n\nSecond paragraph.\n",
+			"<p>First line\ncontinues.\n<p>Second paragraph.\n",
+		},
+		{
+			"list",
+			"Options:\n  - fast\n  - small\n    and simple\n\nDone.",
+			"<p>Options:\n<ul>\n<li>fast\n<li>small\nand simple\n</ul>\n<p>Done.\n",
+		},
+		{
+			"numbered list",
+			"Steps:\n  1. fetch\n  2) parse\n\nThen:\n  3. store",
+			"<p>Steps:\n<ol>\n<li>fetch\n<li>parse\n</ol>\n<p>Then:\n<ol>\n<li value=\"3\">store\n</ol>\n",
+		},
+		{
+			"code block with blank line",
+			"Example:\n\n\tx := 1\n\n\tfmt.Println(x)\n\nAfter.",
+			"<p>Example:\n<pre><code class=\"language-go\">x := 1\n\nfmt.Println(x)\n</code></pre>\n<p>After.\n",
+		},
+		{
+			"heading",
+			"# Usage\n\nCall it.",
+			"<h3 id=\"hdr-Usage\" class=\"Documentation-header\">Usage</h3>\n<p>Call it.\n",
+		},
+		{
+			"doc links",
+			"Call [Client.Do] with a [net/http.Handler], see https://go.dev/doc?a=1&b=2.",
+			"<p>Call <a href=\"#Client.Do\">Client.Do</a> with a <a href=\"/net/http#Handler\">net/http.Handler</a>, see <a href=\"https://go.dev/doc?a=1&amp;b=2\">https://go.dev/doc?a=1&amp;b=2</a>.\n",
+		},
+		{
+			"literal html",
+			"Returns <b>bold</b> &amp; more.",
+			"<p>Returns &lt;b&gt;bold&lt;/b&gt; &amp;amp; more.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(formatDocHTML(tt.input)); got != tt.expected {
+				t.Errorf("formatDocHTML(%q) =\n%s\nwant\n%s", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadPackages_Concurrent(t *testing.T) {
+	dataDir := t.TempDir()
+	const numPackages = 250
+	for i := 0; i < numPackages; i++ {
+		pkg := PackageDoc{
+			ImportPath: fmt.Sprintf("example.com/pkg%d", i),
+			Name:       fmt.Sprintf("pkg%d", i),
+			Functions:  []Function{{Name: "Do"}},
+		}
+		data, err := json.Marshal(pkg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dir := filepath.Join(dataDir, fmt.Sprintf("d%d", i%7))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("pkg%d.json", i)), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Unparseable files are skipped
+	if err := os.WriteFile(filepath.Join(dataDir, "broken.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewServerWithOptions(Options{
+		DataDir:     dataDir,
+		DBPath:      filepath.Join(t.TempDir(), "test.db"),
+		LoadWorkers: 4,
+	})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	if len(s.packages) != numPackages {
+		t.Errorf("loaded %d packages, want %d", len(s.packages), numPackages)
+	}
+
+	pkgCount, symCount, _ := s.GetDBStats()
+	if pkgCount != numPackages {
+		t.Errorf("indexed %d packages, want %d", pkgCount, numPackages)
+	}
+	if symCount != numPackages {
+		t.Errorf("indexed %d symbols, want %d", symCount, numPackages)
+	}
+}
+
+func TestNewServer_ReadOnlyDB(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "replica.db")
+	primary, err := db.Open(dbPath)
+	if err != nil {
+		t.Fatalf("db.Open() error = %v", err)
+	}
+	if _, err := primary.UpsertPackage(&db.Package{ImportPath: "example.com/indexed", Name: "indexed"}); err != nil {
+		t.Fatalf("UpsertPackage() error = %v", err)
+	}
+	primary.Close()
+
+	// A JSON file in the data dir is served but not written to the replica
+	dataDir := t.TempDir()
+	data, _ := json.Marshal(PackageDoc{ImportPath: "example.com/local", Name: "local"})
+	if err := os.WriteFile(filepath.Join(dataDir, "local.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewServerWithOptions(Options{DataDir: dataDir, DBPath: dbPath, ReadOnlyDB: true})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	if pkgCount, _, _ := s.GetDBStats(); pkgCount != 1 {
+		t.Errorf("replica has %d packages, want 1", pkgCount)
+	}
+	if _, ok := s.FindPackage("example.com/indexed"); !ok {
+		t.Error("expected package from replica to be found")
+	}
+	if _, ok := s.FindPackage("example.com/local"); !ok {
+		t.Error("expected package from data dir to be found")
+	}
+}
+
+func TestRenderPackage_PromotedMethods(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	pkg := &PackageDoc{
+		ImportPath: "example.com/counter",
+		Name:       "counter",
+		Types: []Type{{
+			Name: "Counter",
+			Decl: "type Counter struct {\n\tsync.Mutex\n}",
+			Promoted: []PromotedMethod{
+				{Name: "Lock", Signature: "func (*sync.Mutex) Lock()", From: "sync.Mutex", FromPath: "sync"},
+				{Name: "Unlock", Signature: "func (*sync.Mutex) Unlock()", From: "sync.Mutex", FromPath: "sync"},
+			},
+		}},
+	}
+	s.packages[pkg.ImportPath] = pkg
+
+	req := httptest.NewRequest("GET", "/example.com/counter", nil)
+	w := httptest.NewRecorder()
+	s.renderPackage(w, req, pkg)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"Promoted methods",
+		`id="Counter.Lock"`,
+		"func (*sync.Mutex) Unlock()",
+		`<a href="/sync#Mutex.Lock">sync.Mutex</a>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("package page missing %q", want)
+		}
+	}
+}
+
+func TestPromotedMethod_Anchor(t *testing.T) {
+	tests := []struct {
+		m    PromotedMethod
+		want string
+	}{
+		{PromotedMethod{Name: "Lock", From: "sync.Mutex"}, "Mutex.Lock"},
+		{PromotedMethod{Name: "Reset", From: "inner"}, "inner.Reset"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Anchor(); got != tt.want {
+			t.Errorf("Anchor() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestRenderPackage_TypeAlias(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	pkg := &PackageDoc{
+		ImportPath: "example.com/alias",
+		Name:       "alias",
+		Types: []Type{
+			{Name: "Reader", Decl: "type Reader = io.Reader", AliasOf: "io.Reader", AliasPath: "io"},
+			{Name: "Pairs", Decl: "type Pairs = map[string]int", AliasOf: "map[string]int"},
+			{Name: "Buffer", Decl: "type Buffer []byte"},
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/example.com/alias", nil)
+	w := httptest.NewRecorder()
+	s.renderPackage(w, req, pkg)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `alias for <a href="/io#Reader"><code>io.Reader</code></a>`) {
+		t.Error("expected Reader to link to the aliased io.Reader")
+	}
+	if !strings.Contains(body, "alias for <code>map[string]int</code>") {
+		t.Error("expected Pairs to be labeled as an alias without a link")
+	}
+	if n := strings.Count(body, `class="AliasBadge"`); n != 2 {
+		t.Errorf("found %d alias badges, want 2", n)
+	}
+}
+
+func TestRenderPackage_DeprecatedFields(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	pkg := &PackageDoc{
+		ImportPath: "example.com/dep",
+		Name:       "dep",
+		Constants: []Constant{
+			{Names: []string{"Fast", "Quick"}, Decl: "const (\n\tFast = iota\n\tQuick\n)", DeprecatedNames: []string{"Quick"}},
+			{Names: []string{"Old"}, Decl: "const Old = 1", Doc: "Deprecated: use Fast.", Deprecated: true},
+		},
+		Types: []Type{
+			{Name: "Config", Decl: "type Config struct {\n\tName string\n\tTitle string\n}", DeprecatedFields: []string{"Title"}},
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/example.com/dep", nil)
+	w := httptest.NewRecorder()
+	s.renderPackage(w, req, pkg)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if n := strings.Count(body, `class="Documentation-deprecatedNames"`); n != 2 {
+		t.Errorf("found %d deprecated name lists, want 2", n)
+	}
+	for _, name := range []string{"<code>Quick</code>", "<code>Title</code>"} {
+		if !strings.Contains(body, name) {
+			t.Errorf("expected %s to be listed as deprecated", name)
+		}
+	}
+	if strings.Contains(body, "<code>Fast</code>") {
+		t.Error("Fast should not be listed as deprecated")
+	}
+}
+
+func TestRenderPackage_VerifiedExample(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	pkg := &PackageDoc{
+		ImportPath: "example.com/ex",
+		Name:       "ex",
+		Functions: []Function{{
+			Name:      "Double",
+			Signature: "func Double(n int) int",
+			Examples: []Example{
+				{Name: "Double", Code: "fmt.Println(ex.Double(2))", Output: "4\n", Verified: true},
+				{Name: "Double_stale", Code: "fmt.Println(ex.Double(3))", Output: "5\n"},
+				{Name: "Double_many", Code: "for n := range 2 {\n\tfmt.Println(ex.Double(n))\n}", Output: "2\n0\n", Unordered: true},
+				{Name: "Double_quiet", Code: "_ = ex.Double(1)", EmptyOutput: true},
+			},
+		}},
+	}
+
+	req := httptest.NewRequest("GET", "/example.com/ex", nil)
+	w := httptest.NewRecorder()
+	s.renderPackage(w, req, pkg)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if n := strings.Count(w.Body.String(), `class="Example-verified"`); n != 1 {
+		t.Errorf("found %d verified badges, want 1", n)
+	}
+	if n := strings.Count(w.Body.String(), "Unordered output:"); n != 1 {
+		t.Errorf("found %d unordered output labels, want 1", n)
+	}
+	if !strings.Contains(w.Body.String(), `<span class="Example-noOutput">(prints nothing)</span>`) {
+		t.Errorf("empty output example not rendered")
+	}
+}
+
+func TestRenderPackage_RequiredGoVersion(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	tests := []struct {
+		goVersion string
+		want      string
+	}{
+		{"1.23", "Requires Go 1.23+"},
+		{"1.16", "Go 1.16"},
+	}
+	for _, tt := range tests {
+		pkg := &PackageDoc{ImportPath: "example.com/gover", Name: "gover", GoVersion: tt.goVersion}
+		w := httptest.NewRecorder()
+		s.renderPackage(w, httptest.NewRequest("GET", "/example.com/gover", nil), pkg)
+		body := w.Body.String()
+		if !strings.Contains(body, tt.want) {
+			t.Errorf("go %s: page does not contain %q", tt.goVersion, tt.want)
+		}
+		if tt.goVersion == "1.16" && strings.Contains(body, "Requires Go") {
+			t.Errorf("go 1.16 is advisory and should not be shown as a requirement")
+		}
+	}
+}
+
+func TestType_AliasLink(t *testing.T) {
+	tests := []struct {
+		typ  Type
+		want string
+	}{
+		{Type{AliasOf: "io.Reader", AliasPath: "io"}, "/io#Reader"},
+		{Type{AliasOf: "Counter", AliasPath: "example.com/promo"}, "/example.com/promo#Counter"},
+		{Type{AliasOf: "map[string]int"}, ""},
+		{Type{}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.typ.AliasLink(); got != tt.want {
+			t.Errorf("AliasLink(%q) = %q, want %q", tt.typ.AliasOf, got, tt.want)
+		}
+	}
+}
+
+func TestHandleExamples(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "examples.db")
+	s, err := NewServerWithOptions(Options{DataDir: t.TempDir(), DBPath: dbPath})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	pkg := &PackageDoc{
+		ImportPath: "net/http",
+		Name:       "http",
+		Functions: []Function{{
+			Name: "ListenAndServe",
+			Examples: []Example{{
+				Name: "ListenAndServe",
+				Code: "http.HandleFunc(\"/hello\", helloHandler)\nlog.Fatal(http.ListenAndServe(\":8080\", nil))",
+			}},
+		}},
+		Types: []Type{{
+			Name: "Header",
+			Methods: []Function{{
+				Name:     "Get",
+				Examples: []Example{{Name: "Header_Get", Code: "fmt.Println(h.Get(\"Accept\"))", Output: "text/html"}},
+			}},
+		}},
+	}
+	if err := s.IndexPackage(pkg); err != nil {
+		t.Fatalf("IndexPackage() error = %v", err)
+	}
+
+	tests := []struct {
+		path    string
+		want    []string
+		notWant string
+	}{
+		{"/examples?q=http+server", []string{`href="/net/http#ListenAndServe"`, "1 example found"}, "Header.Get"},
+		{"/examples/net/http", []string{`href="/net/http#Header.Get"`, "text/html", "2 examples found"}, ""},
+		{"/examples", []string{"Search Tips"}, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		w := httptest.NewRecorder()
+		s.handleExamples(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected 200, got %d", tt.path, w.Code)
+		}
+		body := w.Body.String()
+		for _, want := range tt.want {
+			if !strings.Contains(body, want) {
+				t.Errorf("GET %s: body does not contain %q", tt.path, want)
+			}
+		}
+		if tt.notWant != "" && strings.Contains(body, tt.notWant) {
+			t.Errorf("GET %s: body unexpectedly contains %q", tt.path, tt.notWant)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/examples/example.com/missing", nil)
+	w := httptest.NewRecorder()
+	s.handleExamples(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown package: expected 404, got %d", w.Code)
+	}
+}
+
+func TestRenderPackage_ParamTable(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	pkg := &PackageDoc{
+		ImportPath: "example.com/net",
+		Name:       "net",
+		Functions: []Function{
+			{
+				Name:      "Dial",
+				Doc:       "Dial connects to the address.\n\nParameters:\n  network - the network name\n  address - host and port\n",
+				Signature: "func Dial(network, address string) (Conn, error)",
+				Params: []util.ParamDoc{
+					{Name: "network", Description: "the network name"},
+					{Name: "address", Description: "host and port"},
+				},
+			},
+			{
+				Name:      "Now",
+				Doc:       "Now returns the current time.\n",
+				Signature: "func Now() Time",
+			},
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/example.com/net", nil)
+	w := httptest.NewRecorder()
+	s.renderPackage(w, req, pkg)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if n := strings.Count(body, `class="Documentation-params"`); n != 1 {
+		t.Errorf("found %d parameter tables, want 1", n)
+	}
+	if !strings.Contains(body, "<td><code>network</code></td><td>the network name</td>") {
+		t.Error("parameter table missing network row")
+	}
+	if strings.Contains(body, "network - the network name") {
+		t.Error("documented parameters should not be repeated in the prose")
+	}
+	if !strings.Contains(body, "Now returns the current time.") {
+		t.Error("undocumented function prose missing")
+	}
+}
+
+func TestRenderPackage_ParamTableFromDatabase(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	// Stored the way the crawler stores it, with no JSON file behind it
+	id, err := s.db.UpsertPackage(&db.Package{ImportPath: "example.com/net", Name: "net"})
+	if err != nil {
+		t.Fatalf("UpsertPackage() error = %v", err)
+	}
+	sym := &db.Symbol{
+		Name:       "Dial",
+		Kind:       "func",
+		PackageID:  id,
+		ImportPath: "example.com/net",
+		Doc:        "Dial connects to the address.\n\nParameters:\n  network - the network name\n  address - host and port\n",
+		Signature:  "func Dial(network, address string) (Conn, error)",
+		Params: []util.ParamDoc{
+			{Name: "network", Description: "the network name"},
+			{Name: "address", Description: "host and port"},
+		},
+	}
+	if err := s.db.UpsertSymbol(sym); err != nil {
+		t.Fatalf("UpsertSymbol() error = %v", err)
+	}
+	handler, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+
+	w := serve(handler, "/example.com/net")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `class="Documentation-params"`) || !strings.Contains(body, "<td><code>address</code></td><td>host and port</td>") {
+		t.Error("database-backed function has no parameter table")
+	}
+	if strings.Contains(body, "network - the network name") {
+		t.Error("documented parameters should not be repeated in the prose")
+	}
+}
+
+func TestHandleFeedback(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "feedback.db")
+	s, err := NewServerWithOptions(Options{DataDir: t.TempDir(), DBPath: dbPath})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	pkg := &PackageDoc{ImportPath: "example.com/net", Name: "net", Functions: []Function{{Name: "Dial", Signature: "func Dial()"}}}
+	s.packages[pkg.ImportPath] = pkg
+	if err := s.db.UpsertAIDoc(&db.AIDoc{SymbolName: "Dial", SymbolKind: "func", ImportPath: pkg.ImportPath, GeneratedDoc: "Dial opens a file.", Approved: true}); err != nil {
+		t.Fatalf("UpsertAIDoc() error = %v", err)
+	}
+
+	// The AI doc offers a report form
+	req := httptest.NewRequest("GET", "/example.com/net", nil)
+	w := httptest.NewRecorder()
+	s.renderPackage(w, req, pkg)
+	body := w.Body.String()
+	if !strings.Contains(body, `name="symbol_kind" value="func"`) || !strings.Contains(body, `id="pkg-feedback"`) {
+		t.Fatal("package page missing feedback forms")
+	}
+
+	post := func(form url.Values, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/feedback", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		s.handleFeedback(w, req)
+		return w
+	}
+
+	w = post(url.Values{"import_path": {"example.com/net"}, "symbol": {"Dial"}, "symbol_kind": {"func"}, "ai_doc": {"1"}, "message": {"Dial opens connections"}}, "")
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("expected 303, got %d: %s", w.Code, w.Body.String())
+	}
+	if loc := w.Header().Get("Location"); loc != "/example.com/net?feedback=sent#pkg-feedback" {
+		t.Errorf("redirect = %q", loc)
+	}
+
+	w = post(url.Values{"import_path": {"example.com/net"}, "message": {"Overview is wrong"}}, "application/json")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"received"`) {
+		t.Errorf("JSON submission: got %d %s", w.Code, w.Body.String())
+	}
+
+	flagged, err := s.db.GetFlaggedAIDocs(10)
+	if err != nil {
+		t.Fatalf("GetFlaggedAIDocs() error = %v", err)
+	}
+	if len(flagged) != 1 || flagged[0].FlagReason != "Dial opens connections" {
+		t.Errorf("GetFlaggedAIDocs() = %+v, want Dial flagged with the note", flagged)
+	}
+	if feedback, _ := s.db.ListFeedback(false, 10); len(feedback) != 2 {
+		t.Errorf("ListFeedback() = %d entries, want 2", len(feedback))
+	}
+
+	invalid := []url.Values{
+		{"import_path": {"example.com/net"}},
+		{"message": {"no package"}},
+		{"import_path": {"example.com/unknown"}, "message": {"x"}},
+		{"import_path": {"example.com/net"}, "message": {strings.Repeat("x", maxFeedbackLength+1)}},
+	}
+	for _, form := range invalid {
+		if w := post(form, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("POST %v: expected 400, got %d", form, w.Code)
+		}
+	}
+
+	req = httptest.NewRequest("GET", "/feedback", nil)
+	w = httptest.NewRecorder()
+	s.handleFeedback(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /feedback: expected 405, got %d", w.Code)
+	}
+}
+
+func TestListenAndServe_UnixSocket(t *testing.T) {
+	// Socket paths are limited to about 100 bytes, which t.TempDir can exceed
+	dir, err := os.MkdirTemp("", "wikigo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "wikigo.sock")
+
+	// A socket left behind by a crashed server is replaced
+	stale, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	s, err := NewServerWithOptions(Options{DataDir: ".", Socket: socket})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+	go s.ListenAndServe(":0")
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	var resp *http.Response
+	for range 50 {
+		if resp, err = client.Get("http://wikigo/"); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("GET over the socket: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+
+	if _, err := listenUnix(socket); err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("listenUnix() on a live socket error = %v, want in use", err)
+	}
+	file := filepath.Join(dir, "file")
+	os.WriteFile(file, nil, 0644)
+	if _, err := listenUnix(file); err == nil || !strings.Contains(err.Error(), "not a socket") {
+		t.Errorf("listenUnix() on a regular file error = %v, want not a socket", err)
+	}
+}
+
+func TestTimeouts_WithDefaults(t *testing.T) {
+	got := Timeouts{Read: 5 * time.Second, Write: -1}.withDefaults()
+	want := Timeouts{ReadHeader: defaultTimeouts.ReadHeader, Read: 5 * time.Second, Write: 0, Idle: defaultTimeouts.Idle}
+	if got != want {
+		t.Errorf("withDefaults() = %+v, want %+v", got, want)
+	}
+}
+
+func TestHTTPServer_SlowClientTimesOut(t *testing.T) {
+	s, err := NewServerWithOptions(Options{DataDir: ".", Timeouts: Timeouts{ReadHeader: 100 * time.Millisecond}})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+	srv, err := s.httpServer("")
+	if err != nil {
+		t.Fatalf("httpServer() error = %v", err)
+	}
+	if srv.ReadTimeout != defaultTimeouts.Read || srv.WriteTimeout != defaultTimeouts.Write || srv.IdleTimeout != defaultTimeouts.Idle {
+		t.Errorf("timeouts = %v/%v/%v, want the defaults", srv.ReadTimeout, srv.WriteTimeout, srv.IdleTimeout)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	// A slow-loris client trickles its request headers and never finishes them
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	start := time.Now()
+	conn.Write([]byte("GET / HTTP/1.1\r\nHost: wikigo\r\n"))
+	go func() {
+		for range 20 {
+			time.Sleep(50 * time.Millisecond)
+			if _, err := conn.Write([]byte("X")); err != nil {
+				return
+			}
+		}
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1)
+	for {
+		if _, err := conn.Read(buf); err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				t.Fatal("server kept the slow connection open")
+			}
+			break
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("server closed the slow connection after %v, want about 100ms", elapsed)
+	}
+}
+
+// slowResponseWriter delays every write, to stretch a response past a deadline
+type slowResponseWriter struct {
+	http.ResponseWriter
+	delay time.Duration
+}
+
+func (w slowResponseWriter) Write(p []byte) (int, error) {
+	time.Sleep(w.delay)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w slowResponseWriter) Flush() { w.ResponseWriter.(http.Flusher).Flush() }
+
+func (w slowResponseWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func TestHTTPServer_StreamOutlivesWriteTimeout(t *testing.T) {
+	s, err := NewServerWithOptions(Options{DataDir: t.TempDir(), DBPath: filepath.Join(t.TempDir(), "test.db"), Timeouts: Timeouts{Write: 300 * time.Millisecond}})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+	const n = 500
+	for i := range n {
+		pkg := &PackageDoc{ImportPath: fmt.Sprintf("example.com/cog%d", i), Name: "cog", Synopsis: "Package cog turns cogs."}
+		if err := s.IndexPackage(pkg); err != nil {
+			t.Fatalf("IndexPackage() error = %v", err)
+		}
+	}
+	srv, err := s.httpServer("")
+	if err != nil {
+		t.Fatalf("httpServer() error = %v", err)
+	}
+	// Each batch of results is written well within the timeout, the whole stream is not
+	handler := srv.Handler
+	srv.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(slowResponseWriter{w, time.Millisecond}, r)
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	req, _ := http.NewRequest("GET", "http://"+ln.Addr().String()+"/api/search?q=cog&lang=go", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /api/search error = %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading the stream after %v: %v", time.Since(start), err)
+	}
+	if elapsed, lines := time.Since(start), strings.Count(string(body), "\n"); lines != n || elapsed < srv.WriteTimeout {
+		t.Errorf("streamed %d results in %v, want all %d past the %v write timeout", lines, elapsed, n, srv.WriteTimeout)
+	}
+}
+
+func TestIndexedAgo(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		age  time.Duration
+		want string
+	}{
+		{5 * time.Minute, "indexed just now"},
+		{90 * time.Minute, "indexed an hour ago"},
+		{5 * time.Hour, "indexed 5 hours ago"},
+		{30 * time.Hour, "indexed yesterday"},
+		{6 * 24 * time.Hour, "indexed 6 days ago"},
+	}
+	for _, tt := range tests {
+		if got := indexedAgo(now.Add(-tt.age), now); got != tt.want {
+			t.Errorf("indexedAgo(%v ago) = %q, want %q", tt.age, got, tt.want)
+		}
+	}
+}
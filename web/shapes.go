@@ -0,0 +1,90 @@
+package web
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/alexisbouchez/wikigo/util"
+)
+
+// isShapeQuery reports whether a symbol search is a function shape such as
+// "func([]byte) (int, error)" rather than a name
+func isShapeQuery(query string) bool {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(query), "func")
+	return ok && strings.HasPrefix(strings.TrimSpace(rest), "(")
+}
+
+// searchSymbolsByShape finds the functions and methods whose signature has the
+// shape of the query, from the database when available
+func (s *Server) searchSymbolsByShape(query, kind string, limit int) ([]SymbolResult, error) {
+	pattern, err := util.ParseShapePattern(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.db != nil {
+		dbSymbols, err := s.db.SearchSymbolsByShape(query, kind, limit)
+		if err != nil {
+			return nil, err
+		}
+		var results []SymbolResult
+		for _, sym := range dbSymbols {
+			packageName := sym.ImportPath
+			if pkg, ok := s.packages[sym.ImportPath]; ok {
+				packageName = pkg.Name
+			}
+			results = append(results, SymbolResult{
+				Name:       sym.Name,
+				Kind:       sym.Kind,
+				Package:    packageName,
+				ImportPath: sym.ImportPath,
+				Synopsis:   sym.Synopsis,
+				Signature:  sym.Signature,
+				Deprecated: sym.Deprecated,
+			})
+		}
+		return results, nil
+	}
+
+	var results []SymbolResult
+	add := func(pkg *PackageDoc, name, symKind string, fn Function) {
+		if kind != "" && kind != symKind {
+			return
+		}
+		if shape, ok := util.SignatureShape(fn.Signature); ok && pattern.Match(shape) {
+			results = append(results, SymbolResult{
+				Name:       name,
+				Kind:       symKind,
+				Package:    pkg.Name,
+				ImportPath: pkg.ImportPath,
+				Synopsis:   shortDoc(fn.Doc),
+				Signature:  fn.Signature,
+				Deprecated: fn.Deprecated,
+			})
+		}
+	}
+	for _, pkg := range s.packages {
+		for _, fn := range pkg.Functions {
+			add(pkg, fn.Name, "func", fn)
+		}
+		for _, t := range pkg.Types {
+			for _, fn := range t.Functions {
+				add(pkg, fn.Name, "func", fn)
+			}
+			for _, m := range t.Methods {
+				add(pkg, t.Name+"."+m.Name, "method", m)
+			}
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Name != results[j].Name {
+			return results[i].Name < results[j].Name
+		}
+		return results[i].ImportPath < results[j].ImportPath
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
@@ -0,0 +1,186 @@
+package web
+
+import (
+	"bufio"
+	"fmt"
+	"go/build"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/module"
+)
+
+// defaultModCache returns the module cache used by the go command
+func defaultModCache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	return filepath.Join(build.Default.GOPATH, "pkg", "mod")
+}
+
+// symbolRange returns the file and line range of a function, type, or method.
+// Methods are named "Type.Method".
+func symbolRange(pkg *PackageDoc, symbol string) (filename string, line, endLine int, ok bool) {
+	typeName, method, isMethod := strings.Cut(symbol, ".")
+	for _, f := range pkg.Functions {
+		if !isMethod && f.Name == symbol {
+			return f.Filename, f.Line, f.EndLine, true
+		}
+	}
+	for _, t := range pkg.Types {
+		if t.Name != typeName {
+			for _, f := range t.Functions {
+				if !isMethod && f.Name == symbol {
+					return f.Filename, f.Line, f.EndLine, true
+				}
+			}
+			continue
+		}
+		if !isMethod {
+			return t.Filename, t.Line, t.EndLine, true
+		}
+		for _, m := range t.Methods {
+			if m.Name == method {
+				return m.Filename, m.Line, m.EndLine, true
+			}
+		}
+	}
+	return "", 0, 0, false
+}
+
+// sourceFile locates a package's source file on disk: in GOROOT for the standard
+// library, in the module cache for everything else
+func (s *Server) sourceFile(pkg *PackageDoc, filename string) (string, error) {
+	if filename == "" || filename != filepath.Base(filename) {
+		return "", fmt.Errorf("invalid source filename %q", filename)
+	}
+
+	if first, _, _ := strings.Cut(pkg.ImportPath, "/"); !strings.Contains(first, ".") {
+		return filepath.Join(s.goroot, "src", filepath.FromSlash(pkg.ImportPath), filename), nil
+	}
+
+	if pkg.ModulePath == "" || pkg.Version == "" {
+		return "", fmt.Errorf("no module version known for %s", pkg.ImportPath)
+	}
+	escPath, err := module.EscapePath(pkg.ModulePath)
+	if err != nil {
+		return "", err
+	}
+	escVersion, err := module.EscapeVersion(pkg.Version)
+	if err != nil {
+		return "", err
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(pkg.ImportPath, pkg.ModulePath), "/")
+	return filepath.Join(s.modCache, escPath+"@"+escVersion, filepath.FromSlash(rel), filename), nil
+}
+
+// readLines returns lines [start, end] of a file, inclusive
+func readLines(path string, start, end int) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return scanLines(f, filepath.Base(path), start, end)
+}
+
+// scanLines returns lines [start, end] of the named source, inclusive
+func scanLines(r io.Reader, name string, start, end int) (string, error) {
+	var b strings.Builder
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan() && n <= end; n++ {
+		if n >= start {
+			b.WriteString(scanner.Text())
+			b.WriteByte('\n')
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if b.Len() == 0 {
+		return "", fmt.Errorf("lines %d-%d not found in %s", start, end, name)
+	}
+	return b.String(), nil
+}
+
+// packageSource returns a whole source file, preferring the copy embedded in the
+// package JSON over the one on disk
+func (s *Server) packageSource(pkg *PackageDoc, filename string) (string, error) {
+	if src, ok := pkg.Sources[filename]; ok {
+		return src, nil
+	}
+	file, err := s.sourceFile(pkg, filename)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// handleSource serves the source text of a single declaration, or of a whole
+// file: /api/source/<import-path>/<symbol> or /api/source/<import-path>/<file.go>
+func (s *Server) handleSource(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/source/")
+	i := strings.LastIndex(path, "/")
+	if i <= 0 || i == len(path)-1 {
+		http.Error(w, "Usage: /api/source/<import-path>/<symbol>", http.StatusBadRequest)
+		return
+	}
+	importPath, symbol := path[:i], path[i+1:]
+
+	pkg, ok := s.FindPackage(importPath)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	// No declaration is named like a file, since .go cannot end an identifier
+	if strings.HasSuffix(symbol, ".go") {
+		src, err := s.packageSource(pkg, symbol)
+		if err != nil {
+			http.Error(w, "Source not available", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Source-File", symbol)
+		w.Write([]byte(src))
+		return
+	}
+
+	filename, line, endLine, ok := symbolRange(pkg, symbol)
+	if !ok {
+		http.Error(w, "Symbol not found", http.StatusNotFound)
+		return
+	}
+	if line <= 0 || endLine < line {
+		http.Error(w, "Source range not recorded for this symbol", http.StatusNotFound)
+		return
+	}
+
+	var src string
+	var err error
+	if text, ok := pkg.Sources[filename]; ok {
+		src, err = scanLines(strings.NewReader(text), filename, line, endLine)
+	} else {
+		var file string
+		if file, err = s.sourceFile(pkg, filename); err == nil {
+			src, err = readLines(file, line, endLine)
+		}
+	}
+	if err != nil {
+		http.Error(w, "Source not available", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Source-File", filename)
+	w.Header().Set("X-Source-Lines", fmt.Sprintf("%d-%d", line, endLine))
+	w.Write([]byte(src))
+}
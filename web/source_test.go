@@ -0,0 +1,99 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeSourceFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandleSource(t *testing.T) {
+	goroot, modCache := t.TempDir(), t.TempDir()
+	s, err := NewServerWithOptions(Options{DataDir: t.TempDir(), GOROOT: goroot, ModCache: modCache})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	writeSourceFile(t, filepath.Join(goroot, "src", "strutil", "strutil.go"),
+		"package strutil\n\n// Reverse reverses s\nfunc Reverse(s string) string {\n\treturn s\n}\n\ntype Builder struct {\n\tbuf []byte\n}\n\nfunc (b *Builder) Len() int {\n\treturn len(b.buf)\n}\n")
+	s.packages["strutil"] = &PackageDoc{
+		Name:       "strutil",
+		ImportPath: "strutil",
+		Functions:  []Function{{Name: "Reverse", Filename: "strutil.go", Line: 4, EndLine: 6}},
+		Types: []Type{{
+			Name: "Builder", Filename: "strutil.go", Line: 8, EndLine: 10,
+			Methods: []Function{{Name: "Len", Filename: "strutil.go", Line: 12, EndLine: 14}},
+		}},
+	}
+
+	writeSourceFile(t, filepath.Join(modCache, "github.com", "!acme", "kit@v1.2.0", "sub", "kit.go"),
+		"package sub\n\nfunc Do() {}\n")
+	s.packages["github.com/Acme/kit/sub"] = &PackageDoc{
+		Name:       "sub",
+		ImportPath: "github.com/Acme/kit/sub",
+		ModulePath: "github.com/Acme/kit",
+		Version:    "v1.2.0",
+		Functions:  []Function{{Name: "Do", Filename: "kit.go", Line: 3, EndLine: 3}},
+	}
+
+	// Sources embedded by wikigo -include-source need neither GOROOT nor the module cache
+	s.packages["example.com/offline"] = &PackageDoc{
+		Name:       "offline",
+		ImportPath: "example.com/offline",
+		Functions:  []Function{{Name: "Ping", Filename: "offline.go", Line: 3, EndLine: 3}},
+		Sources:    map[string]string{"offline.go": "package offline\n\nfunc Ping() {}\n"},
+	}
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{"/api/source/strutil/Reverse", http.StatusOK, "func Reverse(s string) string {\n\treturn s\n}\n"},
+		{"/api/source/strutil/Builder", http.StatusOK, "type Builder struct {\n\tbuf []byte\n}\n"},
+		{"/api/source/strutil/Builder.Len", http.StatusOK, "func (b *Builder) Len() int {\n\treturn len(b.buf)\n}\n"},
+		{"/api/source/github.com/Acme/kit/sub/Do", http.StatusOK, "func Do() {}\n"},
+		{"/api/source/example.com/offline/Ping", http.StatusOK, "func Ping() {}\n"},
+		{"/api/source/example.com/offline/offline.go", http.StatusOK, "package offline\n\nfunc Ping() {}\n"},
+		{"/api/source/github.com/Acme/kit/sub/kit.go", http.StatusOK, "package sub\n\nfunc Do() {}\n"},
+		{"/api/source/example.com/offline/missing.go", http.StatusNotFound, ""},
+		{"/api/source/strutil/Missing", http.StatusNotFound, ""},
+		{"/api/source/unknown/pkg/Func", http.StatusNotFound, ""},
+		{"/api/source/strutil", http.StatusBadRequest, ""},
+		{"/api/source/", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.handleSource(w, httptest.NewRequest("GET", tt.path, nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSourceFile_RejectsPaths(t *testing.T) {
+	s := &Server{goroot: t.TempDir()}
+	pkg := &PackageDoc{ImportPath: "strutil"}
+	for _, name := range []string{"", "../secret.go", "sub/a.go"} {
+		if _, err := s.sourceFile(pkg, name); err == nil {
+			t.Errorf("sourceFile(%q) expected error", name)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// precompressedExts are the static assets gzipped once at startup: the
+// stylesheets and scripts every page loads
+var precompressedExts = map[string]bool{".css": true, ".js": true}
+
+// compressibleExts are the other text assets, gzipped on the fly
+var compressibleExts = map[string]bool{".svg": true, ".html": true, ".json": true, ".txt": true, ".xml": true, ".map": true}
+
+// staticHandler serves the static assets, gzip-compressed for clients that
+// accept it
+type staticHandler struct {
+	files   http.Handler
+	gzipped map[string][]byte // precompressed assets by path
+	modTime time.Time
+}
+
+// newStaticHandler returns a handler for the assets of fsys. With compress
+// set, CSS and JS are gzipped up front and other text assets on each request.
+func newStaticHandler(fsys fs.FS, compress bool) (http.Handler, error) {
+	files := http.FileServer(http.FS(fsys))
+	if !compress {
+		return files, nil
+	}
+
+	h := &staticHandler{
+		files:   files,
+		gzipped: make(map[string][]byte),
+		modTime: time.Now(), // embedded files have no modification time
+	}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !precompressedExts[path.Ext(name)] {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		zw.Write(data)
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		h.gzipped[name] = buf.Bytes()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *staticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	ext := path.Ext(name)
+	if !precompressedExts[ext] && !compressibleExts[ext] {
+		h.files.ServeHTTP(w, r)
+		return
+	}
+
+	w.Header().Add("Vary", "Accept-Encoding")
+	if !acceptsGzip(r) {
+		h.files.ServeHTTP(w, r)
+		return
+	}
+
+	if gz, ok := h.gzipped[name]; ok {
+		w.Header().Set("Content-Type", mime.TypeByExtension(ext))
+		w.Header().Set("Content-Encoding", "gzip")
+		http.ServeContent(w, r, name, h.modTime, bytes.NewReader(gz))
+		return
+	}
+
+	// Ranges would refer to the uncompressed bytes, so always send the whole file
+	r = r.Clone(r.Context())
+	r.Header.Del("Range")
+	zw := &gzipResponseWriter{ResponseWriter: w}
+	h.files.ServeHTTP(zw, r)
+	zw.Close()
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(v, 64); err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipResponseWriter compresses successful responses as they are written
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	zw          *gzip.Writer
+	wroteHeader bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if status == http.StatusOK {
+		w.Header().Del("Content-Length")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.zw = gzip.NewWriter(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.zw == nil {
+		return w.ResponseWriter.Write(p)
+	}
+	return w.zw.Write(p)
+}
+
+// Close flushes the compressed body
+func (w *gzipResponseWriter) Close() error {
+	if w.zw == nil {
+		return nil
+	}
+	return w.zw.Close()
+}
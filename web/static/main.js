@@ -200,6 +200,14 @@ function copyImportPath(btn) {
     });
 }
 
+function copyPermalink(btn) {
+    const url = window.location.origin + btn.dataset.path;
+    navigator.clipboard.writeText(url).then(() => {
+        btn.textContent = 'Copied!';
+        setTimeout(() => { btn.textContent = 'Share'; }, 1500);
+    });
+}
+
 document.addEventListener('DOMContentLoaded', function() {
     // Theme toggle button
     const themeToggle = document.getElementById('themeToggle');
@@ -505,6 +513,73 @@ function initLazyLoading() {
     }
 }
 
+// Load the symbols of a package page section left out by the server's
+// -max-symbols cap, replacing the "Show all" button with them. Repeated calls
+// while the section loads share one request.
+function showAllSymbols(btn) {
+    if (!btn.loading) {
+        btn.loading = loadAllSymbols(btn);
+    }
+    return btn.loading;
+}
+
+async function loadAllSymbols(btn) {
+    btn.disabled = true;
+    const originalText = btn.textContent;
+    btn.textContent = 'Loading...';
+
+    try {
+        const params = new URLSearchParams({ section: btn.dataset.section, offset: btn.dataset.offset });
+        const response = await fetch(`/api/${btn.dataset.path}/symbols?${params}`);
+        if (!response.ok) {
+            throw new Error('Failed to load symbols');
+        }
+        const data = await response.json();
+
+        const fragment = document.createElement('template');
+        fragment.innerHTML = data.html;
+        const items = Array.from(fragment.content.children);
+        btn.replaceWith(fragment.content);
+        if (typeof Prism !== 'undefined') {
+            items.forEach(item => Prism.highlightAllUnder(item));
+        }
+    } catch (error) {
+        console.error('Error loading symbols:', error);
+        btn.loading = null;
+        btn.disabled = false;
+        btn.textContent = originalText;
+    }
+}
+
+// Scroll to a symbol left out by the cap once the sections still behind a
+// "Show all" button have loaded
+async function revealSymbol(id) {
+    const buttons = document.querySelectorAll('.ShowAllSymbols');
+    if (!id || document.getElementById(id) || buttons.length === 0) return;
+    await Promise.all(Array.from(buttons, showAllSymbols));
+    document.getElementById(id)?.scrollIntoView();
+}
+
+function hashSymbol() {
+    return decodeURIComponent(location.hash.slice(1));
+}
+
+// Opening the page at such a symbol, or navigating to one later, loads it first
+document.addEventListener('DOMContentLoaded', () => revealSymbol(hashSymbol()));
+window.addEventListener('hashchange', () => revealSymbol(hashSymbol()));
+
+// In-page links to such a symbol, including ones in loaded sections and ones
+// to the current hash, which fire no hashchange
+document.addEventListener('click', function(e) {
+    const link = e.target.closest('a[href^="#"]');
+    if (!link || e.defaultPrevented) return;
+    const id = decodeURIComponent(link.getAttribute('href').slice(1));
+    if (!id || document.getElementById(id) || !document.querySelector('.ShowAllSymbols')) return;
+    e.preventDefault();
+    history.pushState(null, null, '#' + id);
+    revealSymbol(id);
+});
+
 // In-page symbol search with type filters
 function initSymbolSearch() {
     const searchContainer = document.querySelector('.Package-nav');
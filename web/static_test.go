@@ -0,0 +1,62 @@
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"net/http"
+	"testing"
+)
+
+func TestHandler_StaticGzip(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	handler, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+
+	for _, name := range []string{"style.css", "main.js", "go-logo-blue.svg"} {
+		want, err := fs.ReadFile(staticFS, "static/"+name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := serve(handler, "/static/"+name, "Accept-Encoding", "br, gzip;q=0.8")
+		if w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != "gzip" {
+			t.Fatalf("%s: status = %d, Content-Encoding = %q, want gzip", name, w.Code, w.Header().Get("Content-Encoding"))
+		}
+		if w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("%s: Vary = %q, want Accept-Encoding", name, w.Header().Get("Vary"))
+		}
+		zr, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got, err := io.ReadAll(zr); err != nil || !bytes.Equal(got, want) {
+			t.Errorf("%s: decompressed body differs from the asset (err %v)", name, err)
+		}
+
+		for _, encoding := range []string{"", "gzip;q=0"} {
+			w := serve(handler, "/static/"+name, "Accept-Encoding", encoding)
+			if w.Header().Get("Content-Encoding") != "" || !bytes.Equal(w.Body.Bytes(), want) {
+				t.Errorf("%s with Accept-Encoding %q: Content-Encoding = %q, want the uncompressed asset", name, encoding, w.Header().Get("Content-Encoding"))
+			}
+		}
+	}
+
+	plain, err := NewServerWithOptions(Options{NoStaticGzip: true})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	handler, err = plain.Handler()
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+	if w := serve(handler, "/static/style.css", "Accept-Encoding", "gzip"); w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("with NoStaticGzip, Content-Encoding = %q", w.Header().Get("Content-Encoding"))
+	}
+}
@@ -0,0 +1,69 @@
+package web
+
+import (
+	_ "embed"
+	"strings"
+)
+
+// stdlib.txt lists the importable standard library packages of the Go release
+// wikigo is built with. Regenerate it after upgrading Go.
+//go:generate sh -c "go list std | grep -v -e /internal -e ^internal -e ^vendor/ -e ^cmd/ > stdlib.txt"
+
+//go:embed stdlib.txt
+var stdlibList string
+
+// preferredStdPkgs resolves short names shared by several standard library packages
+var preferredStdPkgs = map[string]string{
+	"json":     "encoding/json",
+	"pprof":    "runtime/pprof",
+	"rand":     "math/rand",
+	"scanner":  "text/scanner",
+	"template": "text/template",
+}
+
+// stdPkgsByName maps standard library short names, such as "slices", to import paths
+var stdPkgsByName = indexStdPkgs(stdlibList)
+
+// indexStdPkgs builds the short name index from a newline-separated list of import paths.
+// Ambiguous names not listed in preferredStdPkgs resolve to the shortest path.
+func indexStdPkgs(list string) map[string]string {
+	index := make(map[string]string)
+	for _, path := range strings.Fields(list) {
+		name := stdPkgName(path)
+		if existing, ok := index[name]; ok && !shorterPath(path, existing) {
+			continue
+		}
+		index[name] = path
+	}
+	for name, path := range preferredStdPkgs {
+		if _, ok := index[name]; ok {
+			index[name] = path
+		}
+	}
+	return index
+}
+
+// stdPkgName returns the name a package is referred to by, ignoring major version suffixes
+func stdPkgName(path string) string {
+	parts := strings.Split(path, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = parts[len(parts)-2]
+	}
+	return name
+}
+
+// shorterPath orders paths by depth, then alphabetically
+func shorterPath(a, b string) bool {
+	da, db := strings.Count(a, "/"), strings.Count(b, "/")
+	if da != db {
+		return da < db
+	}
+	return a < b
+}
+
+// stdPkgPath returns the import path of a standard library package by short name
+func stdPkgPath(short string) (string, bool) {
+	path, ok := stdPkgsByName[short]
+	return path, ok
+}
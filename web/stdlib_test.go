@@ -0,0 +1,48 @@
+package web
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatDocHTML_StdlibLinks(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Use [slices.Sort] first", `<a href="/slices#Sort">slices.Sort</a>`},
+		{"see [maps.Keys]", `<a href="/maps#Keys">maps.Keys</a>`},
+		{"like [rand.Intn]", `<a href="/math/rand#Intn">rand.Intn</a>`},
+		{"an [atomic.Int64]", `<a href="/sync/atomic#Int64">atomic.Int64</a>`},
+		{"returns [json.Marshal] output", `<a href="/encoding/json#Marshal">json.Marshal</a>`},
+		{"a [template.Template]", `<a href="/text/template#Template">template.Template</a>`},
+	}
+	for _, tt := range tests {
+		if got := string(formatDocHTML(tt.text)); !strings.Contains(got, tt.want) {
+			t.Errorf("formatDocHTML(%q) = %q, want it to contain %q", tt.text, got, tt.want)
+		}
+	}
+
+	if got := formatDocHTML("[mypkg.Client] is not std"); strings.Contains(string(got), "<a") {
+		t.Errorf("formatDocHTML() linked a non-std package: %q", got)
+	}
+}
+
+func TestIndexStdPkgs(t *testing.T) {
+	index := indexStdPkgs("crypto/rand\nmath/rand\nmath/rand/v2\ngo/scanner\ntext/scanner\nnet/http/pprof\nruntime/pprof\nfoo/bar/baz\nqux/baz\n")
+
+	tests := map[string]string{
+		"rand":    "math/rand",
+		"scanner": "text/scanner",
+		"pprof":   "runtime/pprof",
+		"baz":     "qux/baz",
+	}
+	for name, want := range tests {
+		if got := index[name]; got != want {
+			t.Errorf("index[%q] = %q, want %q", name, got, want)
+		}
+	}
+	if _, ok := index["template"]; ok {
+		t.Error("preferred names missing from the list should not be added")
+	}
+}
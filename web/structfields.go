@@ -0,0 +1,49 @@
+package web
+
+import (
+	"html/template"
+	"strings"
+
+	"github.com/alexisbouchez/wikigo/util"
+)
+
+// fieldTagLabels name the well-known struct tag keys in field tables
+var fieldTagLabels = map[string]string{
+	"json":     "JSON",
+	"xml":      "XML",
+	"yaml":     "YAML",
+	"db":       "DB column",
+	"validate": "Validation",
+}
+
+// taggedFields returns the fields shown in a struct's field table, or nil when
+// none of them has a tag: the declaration already shows everything else
+func taggedFields(fields []util.StructField) []util.StructField {
+	for _, f := range fields {
+		if f.Tag != "" {
+			return fields
+		}
+	}
+	return nil
+}
+
+// formatFieldTag renders a parsed struct tag key as in
+// JSON: <code>user_name</code>, omitempty
+func formatFieldTag(tag util.FieldTag) template.HTML {
+	label := fieldTagLabels[tag.Key]
+	if label == "" {
+		label = tag.Key
+	}
+	var parts []string
+	switch tag.Name {
+	case "":
+	case "-":
+		parts = append(parts, "omitted")
+	default:
+		parts = append(parts, "<code>"+template.HTMLEscapeString(tag.Name)+"</code>")
+	}
+	for _, opt := range tag.Options {
+		parts = append(parts, template.HTMLEscapeString(opt))
+	}
+	return template.HTML(template.HTMLEscapeString(label) + ": " + strings.Join(parts, ", "))
+}
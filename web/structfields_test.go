@@ -0,0 +1,59 @@
+package web
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexisbouchez/wikigo/util"
+)
+
+func TestFormatFieldTag(t *testing.T) {
+	tests := []struct {
+		tag  util.FieldTag
+		want string
+	}{
+		{util.FieldTag{Key: "json", Name: "user_name", Options: []string{"omitempty"}}, "JSON: <code>user_name</code>, omitempty"},
+		{util.FieldTag{Key: "xml", Name: "-"}, "XML: omitted"},
+		{util.FieldTag{Key: "validate", Options: []string{"required", "lt=<5"}}, "Validation: required, lt=&lt;5"},
+	}
+	for _, tt := range tests {
+		if got := string(formatFieldTag(tt.tag)); got != tt.want {
+			t.Errorf("formatFieldTag(%+v) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestHandler_StructFieldTags(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer s.Close()
+
+	pkg := &PackageDoc{
+		ImportPath: "example.com/db/users",
+		Name:       "users",
+		Types: []Type{
+			{Name: "User", Decl: "type User struct {\n\tName string `json:\"user_name,omitempty\" db:\"name\"`\n\tAge  int\n}"},
+			{Name: "Plain", Decl: "type Plain struct {\n\tName string\n}"},
+		},
+	}
+	if err := s.IndexPackage(pkg); err != nil {
+		t.Fatalf("IndexPackage() error = %v", err)
+	}
+	handler, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+
+	body := serve(handler, "/example.com/db/users").Body.String()
+	for _, want := range []string{"JSON: <code>user_name</code>, omitempty", "DB column: <code>name</code>", "<td><code>Age</code></td>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("package page does not contain %q", want)
+		}
+	}
+	if n := strings.Count(body, `class="Documentation-fields"`); n != 1 {
+		t.Errorf("package page has %d field tables, want 1 for the tagged struct", n)
+	}
+}
@@ -0,0 +1,276 @@
+package web
+
+import (
+	"go/ast"
+	"go/doc/comment"
+	"go/parser"
+	"go/token"
+	"path"
+	"regexp"
+	"strings"
+
+	"golang.org/x/mod/module"
+)
+
+// TypeParam is a type parameter list entry of a generic function or type,
+// with its constraint split into parts so known constraints can be linked
+type TypeParam struct {
+	Names      string
+	Constraint []ConstraintPart
+}
+
+// ConstraintPart is a piece of a constraint expression, linked when it names
+// a constraint wikigo can point to
+type ConstraintPart struct {
+	Text  string
+	Link  string
+	Title string // explanation of predeclared constraints
+}
+
+// predeclaredConstraints explains the constraints built into the language
+var predeclaredConstraints = map[string]string{
+	"comparable": "comparable is implemented by all comparable types: booleans, numbers, strings, pointers, channels, interfaces, and arrays and structs of comparable types",
+	"any":        "any is an alias for interface{} and is satisfied by every type",
+}
+
+// constraintPackages are the packages of well-known constraints, by package
+// name, for qualifiers that do not match an import of the package
+var constraintPackages = map[string]string{
+	"constraints": "golang.org/x/exp/constraints",
+	"cmp":         "cmp",
+}
+
+// typeParams returns the type parameters of a function signature or type
+// declaration, or nil when it is not generic
+func typeParams(pkg *PackageDoc, decl string) []TypeParam {
+	src := "package p\n" + decl
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil || len(file.Decls) == 0 {
+		return nil
+	}
+
+	var list *ast.FieldList
+	switch d := file.Decls[0].(type) {
+	case *ast.FuncDecl:
+		list = d.Type.TypeParams
+	case *ast.GenDecl:
+		if len(d.Specs) > 0 {
+			if ts, ok := d.Specs[0].(*ast.TypeSpec); ok {
+				list = ts.TypeParams
+			}
+		}
+	}
+	if list == nil {
+		return nil
+	}
+
+	params := make(map[string]bool)
+	for _, field := range list.List {
+		for _, name := range field.Names {
+			params[name.Name] = true
+		}
+	}
+
+	var result []TypeParam
+	for _, field := range list.List {
+		var names []string
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+		result = append(result, TypeParam{Names: strings.Join(names, ", "), Constraint: exprParts(pkg, fset, src, field.Type, params)})
+	}
+	return result
+}
+
+// exprParts splits the source of an expression into parts, linking the
+// identifiers that name something wikigo can point to. Type parameters of the
+// enclosing declaration are never linked.
+func exprParts(pkg *PackageDoc, fset *token.FileSet, src string, expr ast.Expr, params map[string]bool) []ConstraintPart {
+	var parts []ConstraintPart
+	offset := fset.Position(expr.Pos()).Offset
+	text := func(end int) {
+		if end > offset {
+			parts = append(parts, ConstraintPart{Text: src[offset:end]})
+		}
+	}
+	ast.Inspect(expr, func(n ast.Node) bool {
+		var qualifier, name string
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			x, ok := n.X.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			qualifier, name = x.Name, n.Sel.Name
+		case *ast.Ident:
+			name = n.Name
+		default:
+			return true
+		}
+		start, end := fset.Position(n.Pos()).Offset, fset.Position(n.End()).Offset
+		text(start)
+		part := ConstraintPart{Text: src[start:end]}
+		if qualifier == "" && !params[name] {
+			part.Link, part.Title = constraintLink(pkg, name)
+		} else if qualifier != "" {
+			part.Link = qualifiedLink(pkg, qualifier, name)
+		}
+		parts = append(parts, part)
+		offset = end
+		return false
+	})
+	text(fset.Position(expr.End()).Offset)
+	return parts
+}
+
+// instantiations returns the instantiated generic types a function signature
+// or type declaration refers to, such as Result[User, error], with the generic
+// type and its type arguments linked where wikigo knows them. Receivers are
+// left out: every method of a generic type would list it.
+func instantiations(pkg *PackageDoc, decl string) [][]ConstraintPart {
+	src := "package p\n" + decl
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil || len(file.Decls) == 0 {
+		return nil
+	}
+
+	var roots []ast.Node
+	var typeParams *ast.FieldList
+	switch d := file.Decls[0].(type) {
+	case *ast.FuncDecl:
+		typeParams = d.Type.TypeParams
+		roots = append(roots, d.Type.Params)
+		if d.Type.Results != nil {
+			roots = append(roots, d.Type.Results)
+		}
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			if ts, ok := spec.(*ast.TypeSpec); ok {
+				typeParams = ts.TypeParams
+				roots = append(roots, ts.Type)
+				break
+			}
+		}
+	}
+
+	params := make(map[string]bool)
+	if typeParams != nil {
+		for _, field := range typeParams.List {
+			for _, name := range field.Names {
+				params[name.Name] = true
+			}
+		}
+	}
+
+	var result [][]ConstraintPart
+	seen := make(map[string]bool)
+	for _, root := range roots {
+		ast.Inspect(root, func(n ast.Node) bool {
+			switch n.(type) {
+			case *ast.IndexExpr, *ast.IndexListExpr:
+			default:
+				return true
+			}
+			expr := n.(ast.Expr)
+			start, end := fset.Position(expr.Pos()).Offset, fset.Position(expr.End()).Offset
+			if text := src[start:end]; !seen[text] {
+				seen[text] = true
+				result = append(result, exprParts(pkg, fset, src, expr, params))
+			}
+			return false
+		})
+	}
+	return result
+}
+
+// constraintLink links an unqualified constraint to the builtin package or to
+// a type of the package
+func constraintLink(pkg *PackageDoc, name string) (link, title string) {
+	if doc, ok := predeclaredConstraints[name]; ok {
+		return "/builtin#" + name, doc
+	}
+	for _, t := range pkg.Types {
+		if t.Name == name {
+			return "#" + name, ""
+		}
+	}
+	return "", ""
+}
+
+// qualifiedLink links a constraint of another package, found among the
+// package imports or the well-known constraint packages
+func qualifiedLink(pkg *PackageDoc, qualifier, name string) string {
+	for _, imp := range pkg.Imports {
+		if importName(imp) == qualifier {
+			return "/" + imp + "#" + name
+		}
+	}
+	if importPath, ok := constraintPackages[qualifier]; ok {
+		return "/" + importPath + "#" + name
+	}
+	return ""
+}
+
+// importName returns the package name an import path is usually referred to
+// by: its last element, ignoring a major version suffix
+func importName(importPath string) string {
+	if prefix, _, ok := module.SplitPathVersion(importPath); ok && prefix != "" {
+		importPath = prefix
+	}
+	return path.Base(importPath)
+}
+
+// instantiationLink matches a doc link to an instantiated generic type, such as
+// [Result[User, error]] or [atomic.Pointer[T]], which go/doc/comment leaves as
+// plain text
+var instantiationLink = regexp.MustCompile(`\[(?:(\w+)\.)?([A-Z]\w*)(\[[^\[\]]+\])\]`)
+
+// linkInstantiations turns the instantiated generic types in the plain text of
+// a paragraph or list into doc links to the generic type, followed by its type
+// arguments as text
+func linkInstantiations(block comment.Block) {
+	switch b := block.(type) {
+	case *comment.Paragraph:
+		b.Text = instantiationLinks(b.Text)
+	case *comment.List:
+		for _, item := range b.Items {
+			for _, c := range item.Content {
+				linkInstantiations(c)
+			}
+		}
+	}
+}
+
+func instantiationLinks(text []comment.Text) []comment.Text {
+	var out []comment.Text
+	for _, t := range text {
+		plain, ok := t.(comment.Plain)
+		if !ok {
+			out = append(out, t)
+			continue
+		}
+		s, offset := string(plain), 0
+		for _, m := range instantiationLink.FindAllStringSubmatchIndex(s, -1) {
+			link := &comment.DocLink{Name: s[m[4]:m[5]]}
+			if m[2] >= 0 {
+				importPath, ok := stdPkgPath(s[m[2]:m[3]])
+				if !ok {
+					continue
+				}
+				link.ImportPath = importPath
+			}
+			link.Text = []comment.Text{comment.Plain(s[m[0]+1 : m[5]])}
+			if m[0] > offset {
+				out = append(out, comment.Plain(s[offset:m[0]]))
+			}
+			out = append(out, link, comment.Plain(s[m[6]:m[7]]))
+			offset = m[1]
+		}
+		if offset < len(s) {
+			out = append(out, comment.Plain(s[offset:]))
+		}
+	}
+	return out
+}
@@ -0,0 +1,153 @@
+package web
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTypeParams(t *testing.T) {
+	pkg := &PackageDoc{
+		ImportPath: "example.com/algo",
+		Imports:    []string{"fmt", "gopkg.in/yaml.v3"},
+		Types:      []Type{{Name: "Number"}},
+	}
+
+	tests := []struct {
+		decl string
+		want []TypeParam
+	}{
+		{"func Max[T constraints.Ordered](a, b T) T", []TypeParam{
+			{Names: "T", Constraint: []ConstraintPart{{Text: "constraints.Ordered", Link: "/golang.org/x/exp/constraints#Ordered"}}},
+		}},
+		{"type Set[T comparable] struct{ m map[T]struct{} }", []TypeParam{
+			{Names: "T", Constraint: []ConstraintPart{{Text: "comparable", Link: "/builtin#comparable", Title: predeclaredConstraints["comparable"]}}},
+		}},
+		{"func Sum[N Number, S ~[]N](s S) N", []TypeParam{
+			{Names: "N", Constraint: []ConstraintPart{{Text: "Number", Link: "#Number"}}},
+			{Names: "S", Constraint: []ConstraintPart{{Text: "~[]"}, {Text: "N"}}},
+		}},
+		{"func Print[T fmt.Stringer | yaml.Marshaler](v T)", []TypeParam{
+			{Names: "T", Constraint: []ConstraintPart{
+				{Text: "fmt.Stringer", Link: "/fmt#Stringer"},
+				{Text: " | "},
+				{Text: "yaml.Marshaler", Link: "/gopkg.in/yaml.v3#Marshaler"},
+			}},
+		}},
+		{"func Map[T, U any](s []T, f func(T) U) []U", []TypeParam{
+			{Names: "T, U", Constraint: []ConstraintPart{{Text: "any", Link: "/builtin#any", Title: predeclaredConstraints["any"]}}},
+		}},
+		{"func (s *Set[T]) Add(v T)", nil},
+		{"func Plain(s string) int", nil},
+		{"not a declaration", nil},
+	}
+	for _, tt := range tests {
+		if got := typeParams(pkg, tt.decl); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("typeParams(%q) = %+v, want %+v", tt.decl, got, tt.want)
+		}
+	}
+}
+
+func TestHandler_TypeParamConstraintLinks(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	s.packages["example.com/algo"] = &PackageDoc{
+		ImportPath: "example.com/algo",
+		Name:       "algo",
+		Functions:  []Function{{Name: "Max", Signature: "func Max[T constraints.Ordered](a, b T) T"}},
+	}
+	handler, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+
+	body := serve(handler, "/example.com/algo").Body.String()
+	if want := `<code>T <a href="/golang.org/x/exp/constraints#Ordered">constraints.Ordered</a></code>`; !strings.Contains(body, want) {
+		t.Errorf("package page does not link the constraint, want %s", want)
+	}
+}
+
+func TestInstantiations(t *testing.T) {
+	pkg := &PackageDoc{
+		ImportPath: "example.com/users",
+		Imports:    []string{"sync/atomic"},
+		Types:      []Type{{Name: "Result"}, {Name: "User"}, {Name: "List"}},
+	}
+
+	tests := []struct {
+		decl string
+		want [][]ConstraintPart
+	}{
+		{"func Lookup(name string) Result[User, error]", [][]ConstraintPart{{
+			{Text: "Result", Link: "#Result"}, {Text: "["}, {Text: "User", Link: "#User"}, {Text: ", "}, {Text: "error"}, {Text: "]"},
+		}}},
+		{"func Index(names []string) (map[string]List[int], error)", [][]ConstraintPart{{
+			{Text: "List", Link: "#List"}, {Text: "["}, {Text: "int"}, {Text: "]"},
+		}}},
+		{"func Swap[T any](p *atomic.Pointer[T], l List[T]) List[T]", [][]ConstraintPart{
+			{{Text: "atomic.Pointer", Link: "/sync/atomic#Pointer"}, {Text: "["}, {Text: "T"}, {Text: "]"}},
+			{{Text: "List", Link: "#List"}, {Text: "["}, {Text: "T"}, {Text: "]"}},
+		}},
+		{"type Page struct {\n\tItems List[Result[User, error]]\n}", [][]ConstraintPart{{
+			{Text: "List", Link: "#List"}, {Text: "["}, {Text: "Result", Link: "#Result"}, {Text: "["},
+			{Text: "User", Link: "#User"}, {Text: ", "}, {Text: "error"}, {Text: "]]"},
+		}}},
+		{"func (l *List[T]) Len() int", nil},
+		{"func Plain(s string) int", nil},
+		{"not a declaration", nil},
+	}
+	for _, tt := range tests {
+		if got := instantiations(pkg, tt.decl); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("instantiations(%q) = %+v, want %+v", tt.decl, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDocHTML_InstantiationLinks(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"It returns a [Result[User, error]].", `It returns a <a href="#Result">Result</a>[User, error].`},
+		{"Stores an [atomic.Pointer[Config]]", `Stores an <a href="/sync/atomic#Pointer">atomic.Pointer</a>[Config]`},
+		{"Holds a [List[int]] and a [Set]", `Holds a <a href="#List">List</a>[int] and a <a href="#Set">Set</a>`},
+		{"Takes an [unknown.Pair[K, V]]", `Takes an [unknown.Pair[K, V]]`},
+		{"Indexes [s[i]] directly", `Indexes [s[i]] directly`},
+	}
+	for _, tt := range tests {
+		if got := string(formatDocHTML(tt.text)); !strings.Contains(got, tt.want) {
+			t.Errorf("formatDocHTML(%q) = %q, want it to contain %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestHandler_InstantiationLinks(t *testing.T) {
+	s, err := NewServerWithDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	s.packages["example.com/users"] = &PackageDoc{
+		ImportPath: "example.com/users",
+		Name:       "users",
+		Types: []Type{
+			{Name: "Result", Decl: "type Result[T, E any] struct{}", Functions: []Function{{
+				Name:      "Lookup",
+				Doc:       "Lookup finds a user by name.",
+				Signature: "func Lookup(name string) Result[User, error]",
+			}}},
+			{Name: "User", Decl: "type User struct{}"},
+		},
+	}
+	handler, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+
+	body := serve(handler, "/example.com/users").Body.String()
+	want := `<code><a href="#Result">Result</a>[<a href="#User">User</a>, error]</code>`
+	if !strings.Contains(body, want) {
+		t.Errorf("package page does not link the instantiation, want %s", want)
+	}
+}
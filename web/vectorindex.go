@@ -0,0 +1,87 @@
+package web
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/alexisbouchez/wikigo/ai"
+	"github.com/alexisbouchez/wikigo/db"
+)
+
+// embeddingLangs lists the ecosystems that may have stored embeddings
+var embeddingLangs = []string{"go", "js", "rust", "python", "php"}
+
+// vectorIndexes holds the per-language ANN indexes used by semantic search
+type vectorIndexes struct {
+	mu      sync.RWMutex
+	indexes map[string]*ai.VectorIndex
+}
+
+// get returns the index for a language, or nil if it has not been built
+func (v *vectorIndexes) get(lang string) *ai.VectorIndex {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.indexes[lang]
+}
+
+func (v *vectorIndexes) set(lang string, idx *ai.VectorIndex) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.indexes == nil {
+		v.indexes = make(map[string]*ai.VectorIndex)
+	}
+	v.indexes[lang] = idx
+}
+
+// buildVectorIndexes loads stored embeddings and builds an ANN index per language.
+// Persisted centroids are reused unless the embedding set has grown substantially.
+func (s *Server) buildVectorIndexes() {
+	for _, lang := range embeddingLangs {
+		start := time.Now()
+
+		embeddings, err := s.db.GetAllEmbeddings(lang)
+		if err != nil {
+			log.Printf("Error loading %s embeddings: %v", lang, err)
+			continue
+		}
+		if len(embeddings) == 0 {
+			continue
+		}
+
+		ids := make([]string, len(embeddings))
+		vectors := make([][]float32, len(embeddings))
+		for i, e := range embeddings {
+			ids[i] = e.ImportPath
+			vectors[i] = e.Embedding
+		}
+		dim := len(vectors[0])
+
+		stored, err := s.db.GetEmbeddingIndex(lang)
+		if err != nil {
+			log.Printf("Error loading %s embedding index: %v", lang, err)
+		}
+
+		var idx *ai.VectorIndex
+		if stored != nil && stored.Dim == dim && len(embeddings) <= 2*stored.EmbeddingCount {
+			idx = ai.NewVectorIndex(stored.Centroids, ids, vectors, stored.Assignments)
+		} else {
+			idx = ai.BuildVectorIndex(ids, vectors)
+			if !s.db.ReadOnly() {
+				err := s.db.UpsertEmbeddingIndex(&db.EmbeddingIndex{
+					Lang:           lang,
+					Dim:            dim,
+					EmbeddingCount: len(embeddings),
+					Centroids:      idx.Centroids,
+					Assignments:    idx.Assignments(),
+				})
+				if err != nil {
+					log.Printf("Error saving %s embedding index: %v", lang, err)
+				}
+			}
+		}
+
+		s.vectors.set(lang, idx)
+		log.Printf("Built %s vector index: %d embeddings in %v", lang, idx.Len(), time.Since(start).Round(time.Millisecond))
+	}
+}